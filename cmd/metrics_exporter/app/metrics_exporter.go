@@ -21,6 +21,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	log "github.com/golang/glog"
 	// log "k8s.io/klog"
@@ -37,6 +38,9 @@ const (
 
 	metricsPath = "/metrics"
 	chiListPath = "/chi"
+
+	// credentialsRefreshPeriod specifies how often ClickHouse access credentials are re-read from the secret
+	credentialsRefreshPeriod = time.Minute
 )
 
 // CLI parameter variables
@@ -97,15 +101,32 @@ func Run() {
 	log.Info(chop.Config().String(true))
 
 	exporter := metrics.StartMetricsREST(
+		ctx,
+
 		metricsEP,
 		metricsPath,
 		chop.Config().ClickHouse.Metrics.Timeouts.Collect,
+		chop.Config().ClickHouse.Metrics.Scrape,
 
 		chiListEP,
 		chiListPath,
 	)
+	exporter.SetKubeClient(kubeClient)
 
 	exporter.DiscoveryWatchedCHIs(kubeClient, chopClient)
 
-	<-ctx.Done()
+	// Credentials rotated in the secrets are picked up with no restart
+	ticker := time.NewTicker(credentialsRefreshPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if chop.Get().ConfigManager.UpdateSecretCredentials() {
+				log.Info("ClickHouse access credentials rotated")
+			}
+			exporter.RefreshAccess()
+		}
+	}
 }
@@ -76,7 +76,7 @@ func Run() {
 	keeperErr := initKeeper(ctx)
 
 	var wg sync.WaitGroup
-	wg.Add(3)
+	wg.Add(5)
 
 	go func() {
 		defer wg.Done()
@@ -86,6 +86,14 @@ func Run() {
 		defer wg.Done()
 		runClickHouseReconcilerMetricsExporter(ctx)
 	}()
+	go func() {
+		defer wg.Done()
+		runClickHouseHealthEndpoint(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		runClickHouseWebhooks(ctx)
+	}()
 	go func() {
 		defer wg.Done()
 		if keeperErr == nil {
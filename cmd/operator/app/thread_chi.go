@@ -57,6 +57,7 @@ func initClickHouse(ctx context.Context) {
 
 	// Initialize k8s API clients
 	kubeClient, extClient, chopClient := chop.GetClientset(kubeConfigFile, masterURL)
+	dynamicClient := chop.GetDynamicClient(kubeConfigFile, masterURL)
 
 	// Create operator instance
 	chop.New(kubeClient, chopClient, chopConfigFile)
@@ -80,6 +81,7 @@ func initClickHouse(ctx context.Context) {
 		chopClient,
 		extClient,
 		kubeClient,
+		dynamicClient,
 		chopInformerFactory,
 		kubeInformerFactory,
 	)
@@ -0,0 +1,73 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"time"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+)
+
+// Health endpoint defaults
+const (
+	defaultHealthEndpoint = ":9998"
+	defaultHealthPath     = "/healthz"
+)
+
+// CLI parameter variables
+var (
+	// healthEP defines health end-point IP address
+	healthEP string
+	// healthPath defines health path
+	healthPath string
+)
+
+func init() {
+	flag.StringVar(&healthEP, "health-endpoint", defaultHealthEndpoint, "The operator health endpoint. Empty value disables the endpoint.")
+	flag.StringVar(&healthPath, "health-path", defaultHealthPath, "The operator health path.")
+}
+
+// runClickHouseHealthEndpoint is an entry point of the application
+func runClickHouseHealthEndpoint(ctx context.Context) {
+	log.S().P()
+	defer log.E().P()
+
+	if healthEP == "" {
+		log.V(1).F().Info("Operator health endpoint disabled")
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(healthPath, chiController.HealthHandler())
+	server := &http.Server{
+		Addr:    healthEP,
+		Handler: mux,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	log.V(1).F().Info("Starting operator health endpoint at %s%s", healthEP, healthPath)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.V(1).F().Error("Operator health endpoint failed with err: %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"time"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+)
+
+// Webhooks defaults
+const (
+	defaultWebhookPath           = "/mutate-chi"
+	defaultConversionWebhookPath = "/convert"
+)
+
+// CLI parameter variables
+var (
+	// webhookEP defines webhooks end-point IP address
+	webhookEP string
+	// webhookPath defines mutating webhook path
+	webhookPath string
+	// conversionWebhookPath defines CRD conversion webhook path
+	conversionWebhookPath string
+	// webhookCertFile defines path to TLS certificate of the webhooks
+	webhookCertFile string
+	// webhookKeyFile defines path to TLS key of the webhooks
+	webhookKeyFile string
+)
+
+func init() {
+	flag.StringVar(&webhookEP, "webhook-endpoint", "", "The operator webhooks endpoint, serving mutating webhook, which normalizes CHI at admission, and CRD conversion webhook. Empty value disables the webhooks.")
+	flag.StringVar(&webhookPath, "webhook-path", defaultWebhookPath, "The operator mutating webhook path.")
+	flag.StringVar(&conversionWebhookPath, "conversion-webhook-path", defaultConversionWebhookPath, "The operator CRD conversion webhook path.")
+	flag.StringVar(&webhookCertFile, "webhook-tls-cert", "", "Path to TLS certificate of the operator webhooks.")
+	flag.StringVar(&webhookKeyFile, "webhook-tls-key", "", "Path to TLS key of the operator webhooks.")
+}
+
+// runClickHouseWebhooks is an entry point of the application
+func runClickHouseWebhooks(ctx context.Context) {
+	log.S().P()
+	defer log.E().P()
+
+	if webhookEP == "" {
+		log.V(1).F().Info("Operator webhooks disabled")
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(webhookPath, chiController.MutatingWebhookHandler())
+	mux.Handle(conversionWebhookPath, chiController.ConversionWebhookHandler())
+	server := &http.Server{
+		Addr:    webhookEP,
+		Handler: mux,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	// API server calls admission webhooks over HTTPS only
+	log.V(1).F().Info("Starting operator webhooks at %s mutating: %s conversion: %s", webhookEP, webhookPath, conversionWebhookPath)
+	if err := server.ListenAndServeTLS(webhookCertFile, webhookKeyFile); err != nil && err != http.ErrServerClosed {
+		log.V(1).F().Error("Operator webhooks failed with err: %v", err)
+	}
+}
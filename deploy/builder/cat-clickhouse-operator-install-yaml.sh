@@ -80,6 +80,11 @@ if [[ "${MANIFEST_PRINT_RBAC_CLUSTERED}" == "unspecified" ]] && [[ "${MANIFEST_P
     MANIFEST_PRINT_RBAC_NAMESPACED="no"
 fi
 
+# Serve version v2 of CHI and CHIT CRDs. Requires operator webhooks, which convert v2 to and from the storage version v1
+CRD_V2_SERVED="${CRD_V2_SERVED:-"false"}"
+# Base64-encoded CA certificate the operator webhooks certificate is signed with
+WEBHOOKS_CA_BUNDLE="${WEBHOOKS_CA_BUNDLE:-""}"
+
 # Render operator's Deployment section. May be not required in case of dev localhost run
 MANIFEST_PRINT_DEPLOYMENT="${MANIFEST_PRINT_DEPLOYMENT:-"yes"}"
 
@@ -107,6 +112,9 @@ if [[ "${MANIFEST_PRINT_CRD}" == "yes" ]]; then
         PLURAL="clickhouseinstallations"          \
         SHORT="chi"                               \
         OPERATOR_VERSION="${OPERATOR_VERSION}"    \
+        NAMESPACE="${OPERATOR_NAMESPACE}"         \
+        CA_BUNDLE="${WEBHOOKS_CA_BUNDLE}"         \
+        V2_SERVED="${CRD_V2_SERVED}"              \
         envsubst
 
     # Render CHIT
@@ -119,6 +127,9 @@ if [[ "${MANIFEST_PRINT_CRD}" == "yes" ]]; then
         PLURAL="clickhouseinstallationtemplates"  \
         SHORT="chit"                              \
         OPERATOR_VERSION="${OPERATOR_VERSION}"    \
+        NAMESPACE="${OPERATOR_NAMESPACE}"         \
+        CA_BUNDLE="${WEBHOOKS_CA_BUNDLE}"         \
+        V2_SERVED="${CRD_V2_SERVED}"              \
         envsubst
 
     # Render CHOp config
@@ -138,6 +149,30 @@ if [[ "${MANIFEST_PRINT_CRD}" == "yes" ]]; then
     cat "${TEMPLATES_DIR}/${SECTION_FILE_NAME}" | \
         OPERATOR_VERSION="${OPERATOR_VERSION}"    \
         envsubst
+
+    # Render CHB
+    SECTION_FILE_NAME="clickhouse-operator-install-yaml-template-01-section-crd-04-chb.yaml"
+    ensure_file "${TEMPLATES_DIR}" "${SECTION_FILE_NAME}" "${REPO_PATH_TEMPLATES_PATH}"
+    render_separator
+    cat "${TEMPLATES_DIR}/${SECTION_FILE_NAME}" | \
+        OPERATOR_VERSION="${OPERATOR_VERSION}"    \
+        envsubst
+
+    # Render CHR
+    SECTION_FILE_NAME="clickhouse-operator-install-yaml-template-01-section-crd-05-chr.yaml"
+    ensure_file "${TEMPLATES_DIR}" "${SECTION_FILE_NAME}" "${REPO_PATH_TEMPLATES_PATH}"
+    render_separator
+    cat "${TEMPLATES_DIR}/${SECTION_FILE_NAME}" | \
+        OPERATOR_VERSION="${OPERATOR_VERSION}"    \
+        envsubst
+
+    # Render CHU
+    SECTION_FILE_NAME="clickhouse-operator-install-yaml-template-01-section-crd-06-chu.yaml"
+    ensure_file "${TEMPLATES_DIR}" "${SECTION_FILE_NAME}" "${REPO_PATH_TEMPLATES_PATH}"
+    render_separator
+    cat "${TEMPLATES_DIR}/${SECTION_FILE_NAME}" | \
+        OPERATOR_VERSION="${OPERATOR_VERSION}"    \
+        envsubst
 fi
 
 # Render RBAC section for ClusterRole
@@ -39,12 +39,18 @@ var (
 
 func init() {
 	SchemeBuilder.Register(
+		&ClickHouseBackup{},
+		&ClickHouseBackupList{},
 		&ClickHouseInstallation{},
 		&ClickHouseInstallationList{},
 		&ClickHouseInstallationTemplate{},
 		&ClickHouseInstallationTemplateList{},
 		&ClickHouseOperatorConfiguration{},
 		&ClickHouseOperatorConfigurationList{},
+		&ClickHouseRestore{},
+		&ClickHouseRestoreList{},
+		&ClickHouseUser{},
+		&ClickHouseUserList{},
 	)
 }
 
@@ -0,0 +1,143 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	core "k8s.io/api/core/v1"
+)
+
+// ChiAccess specifies how the operator accesses ClickHouse instances of the CHI
+type ChiAccess struct {
+	// Scheme specifies scheme to connect with, overrides operator's `clickhouse.access.scheme`
+	Scheme string `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+	// TLS specifies TLS material to connect over https with
+	TLS *ChiAccessTLS `json:"tls,omitempty" yaml:"tls,omitempty"`
+	// MetricsCredentials specifies credentials the metrics exporter connects with,
+	// overrides operator's `clickhouse.access` credentials for metrics requests.
+	// In case credentials can not be read, hosts of the CHI are not scraped
+	MetricsCredentials *ChiAccessCredentials `json:"metricsCredentials,omitempty" yaml:"metricsCredentials,omitempty"`
+}
+
+// ChiAccessCredentials specifies credentials, which are read from secrets in the namespace of the CHI
+type ChiAccessCredentials struct {
+	// Username specifies name of the user
+	Username *core.SecretKeySelector `json:"username,omitempty" yaml:"username,omitempty"`
+	// Password specifies password of the user. Empty password is used in case not specified
+	Password *core.SecretKeySelector `json:"password,omitempty" yaml:"password,omitempty"`
+}
+
+// ChiAccessTLS specifies TLS material, which is read from secrets in the namespace of the CHI
+type ChiAccessTLS struct {
+	// CA specifies PEM-encoded CA certificates server certificates are verified with
+	CA *core.SecretKeySelector `json:"ca,omitempty"                 yaml:"ca,omitempty"`
+	// Cert specifies PEM-encoded client certificate, in case servers require client certificates
+	Cert *core.SecretKeySelector `json:"cert,omitempty"               yaml:"cert,omitempty"`
+	// Key specifies PEM-encoded private key of the client certificate
+	Key *core.SecretKeySelector `json:"key,omitempty"                yaml:"key,omitempty"`
+	// InsecureSkipVerify specifies whether server certificates are not verified
+	InsecureSkipVerify *StringBool `json:"insecureSkipVerify,omitempty" yaml:"insecureSkipVerify,omitempty"`
+}
+
+// NewChiAccess creates new ChiAccess
+func NewChiAccess() *ChiAccess {
+	return new(ChiAccess)
+}
+
+// GetScheme gets scheme
+func (a *ChiAccess) GetScheme() string {
+	if a == nil {
+		return ""
+	}
+	return a.Scheme
+}
+
+// GetTLS gets TLS
+func (a *ChiAccess) GetTLS() *ChiAccessTLS {
+	if a == nil {
+		return nil
+	}
+	return a.TLS
+}
+
+// GetMetricsCredentials gets metrics credentials
+func (a *ChiAccess) GetMetricsCredentials() *ChiAccessCredentials {
+	if a == nil {
+		return nil
+	}
+	return a.MetricsCredentials
+}
+
+// MergeFrom merges from specified source
+func (a *ChiAccess) MergeFrom(from *ChiAccess, _type MergeType) *ChiAccess {
+	if from == nil {
+		return a
+	}
+
+	if a == nil {
+		a = NewChiAccess()
+	}
+
+	switch _type {
+	case MergeTypeFillEmptyValues:
+		if a.Scheme == "" {
+			a.Scheme = from.Scheme
+		}
+		if a.TLS == nil {
+			a.TLS = from.TLS
+		}
+		if a.MetricsCredentials == nil {
+			a.MetricsCredentials = from.MetricsCredentials
+		}
+	case MergeTypeOverrideByNonEmptyValues:
+		if from.Scheme != "" {
+			// Override by non-empty values only
+			a.Scheme = from.Scheme
+		}
+		if from.TLS != nil {
+			// Override by non-empty values only
+			a.TLS = from.TLS
+		}
+		if from.MetricsCredentials != nil {
+			// Override by non-empty values only
+			a.MetricsCredentials = from.MetricsCredentials
+		}
+	}
+
+	return a
+}
+
+// HasClientCert checks whether client certificate is specified
+func (t *ChiAccessTLS) HasClientCert() bool {
+	if t == nil {
+		return false
+	}
+	return (t.Cert != nil) && (t.Key != nil)
+}
+
+// IsInsecureSkipVerify checks whether server certificates are not verified
+func (t *ChiAccessTLS) IsInsecureSkipVerify() bool {
+	if t == nil {
+		return false
+	}
+	return t.InsecureSkipVerify.IsTrue()
+}
+
+// HasUsername checks whether username is specified
+func (c *ChiAccessCredentials) HasUsername() bool {
+	if c == nil {
+		return false
+	}
+	return c.Username != nil
+}
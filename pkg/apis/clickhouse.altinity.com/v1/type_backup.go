@@ -0,0 +1,204 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"time"
+
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Possible backup and restore statuses
+const (
+	// BackupStatusPending means backup is not started yet
+	BackupStatusPending = "Pending"
+	// BackupStatusInProgress means backup is being taken or restored on the hosts
+	BackupStatusInProgress = "InProgress"
+	// BackupStatusCompleted means backup is taken or restored on all hosts
+	BackupStatusCompleted = "Completed"
+	// BackupStatusFailed means backup or restore failed on some of the hosts
+	BackupStatusFailed = "Failed"
+)
+
+// DefaultBackupAPIPort specifies default port of clickhouse-backup REST API
+const DefaultBackupAPIPort = 7171
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClickHouseBackup defines backup of a ClickHouseInstallation taken by clickhouse-backup
+type ClickHouseBackup struct {
+	meta.TypeMeta   `json:",inline"            yaml:",inline"`
+	meta.ObjectMeta `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+
+	Spec   ChbSpec    `json:"spec"             yaml:"spec"`
+	Status *ChbStatus `json:"status,omitempty" yaml:"status,omitempty"`
+}
+
+// ChbSpec defines spec section of ClickHouseBackup resource
+type ChbSpec struct {
+	// CHI is the name of the ClickHouseInstallation in the same namespace to be backed up
+	CHI string `json:"chi" yaml:"chi"`
+	// Cluster is the name of the cluster to be backed up. All clusters are backed up in case not specified
+	Cluster string `json:"cluster,omitempty" yaml:"cluster,omitempty"`
+	// Tables is the pattern of tables to be backed up, as accepted by clickhouse-backup
+	Tables string `json:"tables,omitempty" yaml:"tables,omitempty"`
+	// Upload specifies whether backup has to be uploaded to the remote storage
+	Upload *StringBool `json:"upload,omitempty" yaml:"upload,omitempty"`
+	// Port is the port of clickhouse-backup REST API on the hosts
+	Port int32 `json:"port,omitempty" yaml:"port,omitempty"`
+	// Retention specifies how many backups are kept on the hosts
+	Retention *ChbRetention `json:"retention,omitempty" yaml:"retention,omitempty"`
+}
+
+// ChbRetention defines retention policy of backups.
+// Zero value means backups are kept forever.
+type ChbRetention struct {
+	// Local is the number of the most recent local backups to keep on each host
+	Local int `json:"local,omitempty" yaml:"local,omitempty"`
+	// Remote is the number of the most recent remote backups to keep for each shard
+	Remote int `json:"remote,omitempty" yaml:"remote,omitempty"`
+}
+
+// ChbStatus defines status section of ClickHouseBackup resource
+type ChbStatus struct {
+	Status      string                    `json:"status,omitempty"      yaml:"status,omitempty"`
+	StartedAt   string                    `json:"startedAt,omitempty"   yaml:"startedAt,omitempty"`
+	CompletedAt string                    `json:"completedAt,omitempty" yaml:"completedAt,omitempty"`
+	Error       string                    `json:"error,omitempty"       yaml:"error,omitempty"`
+	Hosts       map[string]*ChbHostStatus `json:"hosts,omitempty"       yaml:"hosts,omitempty"`
+}
+
+// ChbHostStatus defines status of backup taken on one host
+type ChbHostStatus struct {
+	// Backup is the name of the backup on the host
+	Backup string `json:"backup,omitempty" yaml:"backup,omitempty"`
+	// Operation is the clickhouse-backup operation being run on the host, such as create or upload
+	Operation string `json:"operation,omitempty" yaml:"operation,omitempty"`
+	// Status is the status of backup on the host
+	Status string `json:"status,omitempty" yaml:"status,omitempty"`
+	// Error is the error backup failed with on the host
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClickHouseBackupList defines a list of ClickHouseBackup resources
+type ClickHouseBackupList struct {
+	meta.TypeMeta `json:",inline"  yaml:",inline"`
+	meta.ListMeta `json:"metadata" yaml:"metadata"`
+	Items         []ClickHouseBackup `json:"items" yaml:"items"`
+}
+
+// GetPort gets port of clickhouse-backup REST API
+func (spec *ChbSpec) GetPort() int32 {
+	if spec == nil || spec.Port == 0 {
+		return DefaultBackupAPIPort
+	}
+	return spec.Port
+}
+
+// GetRetention gets retention policy
+func (spec *ChbSpec) GetRetention() *ChbRetention {
+	if spec == nil || spec.Retention == nil {
+		return &ChbRetention{}
+	}
+	return spec.Retention
+}
+
+// EnsureStatus ensures status is in place
+func (chb *ClickHouseBackup) EnsureStatus() *ChbStatus {
+	if chb.Status == nil {
+		chb.Status = &ChbStatus{
+			Status: BackupStatusPending,
+		}
+	}
+	return chb.Status
+}
+
+// IsFinished checks whether backup is either completed or failed
+func (s *ChbStatus) IsFinished() bool {
+	if s == nil {
+		return false
+	}
+	return (s.Status == BackupStatusCompleted) || (s.Status == BackupStatusFailed)
+}
+
+// Start marks backup as started
+func (s *ChbStatus) Start() {
+	s.Status = BackupStatusInProgress
+	s.StartedAt = time.Now().Format(time.RFC3339)
+	s.CompletedAt = ""
+	s.Error = ""
+	s.Hosts = make(map[string]*ChbHostStatus)
+}
+
+// IsTimedOut checks whether backup or restore is running longer than the timeout
+func (s *ChbStatus) IsTimedOut(timeout time.Duration) bool {
+	if s == nil {
+		return false
+	}
+	started, err := time.Parse(time.RFC3339, s.StartedAt)
+	if err != nil {
+		return false
+	}
+	return time.Since(started) > timeout
+}
+
+// FailHostsInProgress marks hosts backup or restore is still in progress on as failed
+func (s *ChbStatus) FailHostsInProgress(err string) {
+	for _, host := range s.Hosts {
+		if host.Status == BackupStatusInProgress {
+			host.Status = BackupStatusFailed
+			host.Error = err
+		}
+	}
+}
+
+// SetHostStatus sets status of backup on the host
+func (s *ChbStatus) SetHostStatus(host string, status *ChbHostStatus) {
+	if s.Hosts == nil {
+		s.Hosts = make(map[string]*ChbHostStatus)
+	}
+	s.Hosts[host] = status
+}
+
+// IsHostsFinished checks whether backup is either completed or failed on all hosts
+func (s *ChbStatus) IsHostsFinished() bool {
+	for _, host := range s.Hosts {
+		if host.Status == BackupStatusInProgress {
+			return false
+		}
+	}
+	return true
+}
+
+// Finish marks backup as completed in case it is completed on all hosts or as failed otherwise
+func (s *ChbStatus) Finish() {
+	s.Status = BackupStatusCompleted
+	for _, host := range s.Hosts {
+		if host.Status != BackupStatusCompleted {
+			s.Status = BackupStatusFailed
+		}
+	}
+	s.CompletedAt = time.Now().Format(time.RFC3339)
+}
+
+// Fail marks backup as failed as a whole
+func (s *ChbStatus) Fail(err string) {
+	s.Status = BackupStatusFailed
+	s.Error = err
+	s.CompletedAt = time.Now().Format(time.RFC3339)
+}
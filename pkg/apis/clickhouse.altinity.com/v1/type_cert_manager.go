@@ -0,0 +1,156 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"strings"
+)
+
+// Scopes certificates are issued within
+const (
+	// CertManagerScopeHost specifies each host is served with its own certificate
+	CertManagerScopeHost = "Host"
+	// CertManagerScopeCHI specifies all hosts of the CHI are served with one certificate
+	CertManagerScopeCHI = "CHI"
+)
+
+// ChiCertManager specifies server certificates of the hosts to be issued by cert-manager
+type ChiCertManager struct {
+	// Enabled specifies whether certificates are issued
+	Enabled *StringBool `json:"enabled,omitempty"     yaml:"enabled,omitempty"`
+	// Scope specifies whether certificate is issued per host or per CHI
+	Scope string `json:"scope,omitempty"       yaml:"scope,omitempty"`
+	// IssuerRef specifies cert-manager Issuer or ClusterIssuer certificates are issued by
+	IssuerRef *ChiCertManagerIssuerRef `json:"issuerRef,omitempty"   yaml:"issuerRef,omitempty"`
+	// Duration specifies requested lifetime of certificates, ex.: 2160h
+	Duration string `json:"duration,omitempty"    yaml:"duration,omitempty"`
+	// RenewBefore specifies how long before expiry certificates are renewed, ex.: 360h
+	RenewBefore string `json:"renewBefore,omitempty" yaml:"renewBefore,omitempty"`
+	// InterserverSecure specifies whether replicas fetch parts from each other over https.
+	// Unless specified, replicas are switched to https once all hosts are served with certificates
+	InterserverSecure *StringBool `json:"interserverSecure,omitempty" yaml:"interserverSecure,omitempty"`
+}
+
+// ChiCertManagerIssuerRef specifies cert-manager issuer
+type ChiCertManagerIssuerRef struct {
+	Name  string `json:"name,omitempty"  yaml:"name,omitempty"`
+	Kind  string `json:"kind,omitempty"  yaml:"kind,omitempty"`
+	Group string `json:"group,omitempty" yaml:"group,omitempty"`
+}
+
+// NewChiCertManager creates new ChiCertManager
+func NewChiCertManager() *ChiCertManager {
+	return new(ChiCertManager)
+}
+
+// IsEnabled checks whether certificates are issued
+func (c *ChiCertManager) IsEnabled() bool {
+	if c == nil {
+		return false
+	}
+	return c.Enabled.IsTrue()
+}
+
+// GetScope gets scope certificates are issued within
+func (c *ChiCertManager) GetScope() string {
+	if c == nil {
+		return CertManagerScopeHost
+	}
+	if strings.EqualFold(c.Scope, CertManagerScopeCHI) {
+		return CertManagerScopeCHI
+	}
+	return CertManagerScopeHost
+}
+
+// IsScopeCHI checks whether all hosts of the CHI are served with one certificate
+func (c *ChiCertManager) IsScopeCHI() bool {
+	return c.GetScope() == CertManagerScopeCHI
+}
+
+// IsInterserverSecure checks whether replicas fetch parts from each other over https
+func (c *ChiCertManager) IsInterserverSecure() bool {
+	if !c.IsEnabled() {
+		return false
+	}
+	return c.InterserverSecure.IsTrue()
+}
+
+// GetIssuerRef gets issuer reference
+func (c *ChiCertManager) GetIssuerRef() *ChiCertManagerIssuerRef {
+	if c == nil {
+		return nil
+	}
+	return c.IssuerRef
+}
+
+// MergeFrom merges from specified source
+func (c *ChiCertManager) MergeFrom(from *ChiCertManager, _type MergeType) *ChiCertManager {
+	if from == nil {
+		return c
+	}
+
+	if c == nil {
+		c = NewChiCertManager()
+	}
+
+	switch _type {
+	case MergeTypeFillEmptyValues:
+		if c.Enabled == nil {
+			c.Enabled = from.Enabled
+		}
+		if c.Scope == "" {
+			c.Scope = from.Scope
+		}
+		if c.IssuerRef == nil {
+			c.IssuerRef = from.IssuerRef
+		}
+		if c.Duration == "" {
+			c.Duration = from.Duration
+		}
+		if c.RenewBefore == "" {
+			c.RenewBefore = from.RenewBefore
+		}
+		if c.InterserverSecure == nil {
+			c.InterserverSecure = from.InterserverSecure
+		}
+	case MergeTypeOverrideByNonEmptyValues:
+		if from.Enabled != nil {
+			// Override by non-empty values only
+			c.Enabled = from.Enabled
+		}
+		if from.Scope != "" {
+			// Override by non-empty values only
+			c.Scope = from.Scope
+		}
+		if from.IssuerRef != nil {
+			// Override by non-empty values only
+			c.IssuerRef = from.IssuerRef
+		}
+		if from.Duration != "" {
+			// Override by non-empty values only
+			c.Duration = from.Duration
+		}
+		if from.RenewBefore != "" {
+			// Override by non-empty values only
+			c.RenewBefore = from.RenewBefore
+		}
+		if from.InterserverSecure != nil {
+			// Override by non-empty values only
+			c.InterserverSecure = from.InterserverSecure
+		}
+	}
+
+	return c
+}
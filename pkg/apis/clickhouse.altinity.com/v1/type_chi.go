@@ -398,6 +398,12 @@ func (spec *ChiSpec) MergeFrom(from *ChiSpec, _type MergeType) {
 		if spec.NamespaceDomainPattern == "" {
 			spec.NamespaceDomainPattern = from.NamespaceDomainPattern
 		}
+		if spec.RevisionHistoryLimit == nil {
+			spec.RevisionHistoryLimit = from.RevisionHistoryLimit
+		}
+		if spec.RolloutBreakpoint == "" {
+			spec.RolloutBreakpoint = from.RolloutBreakpoint
+		}
 	case MergeTypeOverrideByNonEmptyValues:
 		if from.HasTaskID() {
 			spec.TaskID = from.TaskID
@@ -417,8 +423,21 @@ func (spec *ChiSpec) MergeFrom(from *ChiSpec, _type MergeType) {
 		if from.NamespaceDomainPattern != "" {
 			spec.NamespaceDomainPattern = from.NamespaceDomainPattern
 		}
+		if from.RevisionHistoryLimit != nil {
+			// Override by non-empty values only
+			spec.RevisionHistoryLimit = from.RevisionHistoryLimit
+		}
+		if from.RolloutBreakpoint != "" {
+			// Override by non-empty values only
+			spec.RolloutBreakpoint = from.RolloutBreakpoint
+		}
 	}
 
+	spec.DNS = spec.DNS.MergeFrom(from.DNS, _type)
+	spec.Access = spec.Access.MergeFrom(from.Access, _type)
+	spec.CertManager = spec.CertManager.MergeFrom(from.CertManager, _type)
+	spec.NetworkPolicy = spec.NetworkPolicy.MergeFrom(from.NetworkPolicy, _type)
+	spec.Scale = spec.Scale.MergeFrom(from.Scale, _type)
 	spec.Templating = spec.Templating.MergeFrom(from.Templating, _type)
 	spec.Reconciling = spec.Reconciling.MergeFrom(from.Reconciling, _type)
 	spec.Defaults = spec.Defaults.MergeFrom(from.Defaults, _type)
@@ -628,6 +647,14 @@ func (chi *ClickHouseInstallation) IsRollingUpdate() bool {
 	return chi.Spec.Restart == RestartRollingUpdate
 }
 
+// GetRolloutBreakpoint gets host the rollout pauses at, specified as "cluster/shard/replica"
+func (chi *ClickHouseInstallation) GetRolloutBreakpoint() string {
+	if chi == nil {
+		return ""
+	}
+	return chi.Spec.RolloutBreakpoint
+}
+
 // IsTroubleshoot checks whether CHI is in troubleshoot mode
 func (chi *ClickHouseInstallation) IsTroubleshoot() bool {
 	if chi == nil {
@@ -636,6 +663,38 @@ func (chi *ClickHouseInstallation) IsTroubleshoot() bool {
 	return chi.Spec.Troubleshoot.Value()
 }
 
+// GetAccess gets access spec
+func (chi *ClickHouseInstallation) GetAccess() *ChiAccess {
+	if chi == nil {
+		return nil
+	}
+	return chi.Spec.Access
+}
+
+// GetCertManager gets cert-manager spec
+func (chi *ClickHouseInstallation) GetCertManager() *ChiCertManager {
+	if chi == nil {
+		return nil
+	}
+	return chi.Spec.CertManager
+}
+
+// GetNetworkPolicy gets NetworkPolicy spec
+func (chi *ClickHouseInstallation) GetNetworkPolicy() *ChiNetworkPolicy {
+	if chi == nil {
+		return nil
+	}
+	return chi.Spec.NetworkPolicy
+}
+
+// GetScale gets scale spec
+func (chi *ClickHouseInstallation) GetScale() *ChiScale {
+	if chi == nil {
+		return nil
+	}
+	return chi.Spec.Scale
+}
+
 // GetReconciling gets reconciling spec
 func (chi *ClickHouseInstallation) GetReconciling() *ChiReconciling {
 	if chi == nil {
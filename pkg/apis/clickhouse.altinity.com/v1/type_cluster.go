@@ -14,18 +14,25 @@
 
 package v1
 
+import (
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
 // Cluster defines item of a clusters section of .configuration
 type Cluster struct {
-	Name         string              `json:"name,omitempty"         yaml:"name,omitempty"`
-	Zookeeper    *ChiZookeeperConfig `json:"zookeeper,omitempty"    yaml:"zookeeper,omitempty"`
-	Settings     *Settings           `json:"settings,omitempty"     yaml:"settings,omitempty"`
-	Files        *Settings           `json:"files,omitempty"        yaml:"files,omitempty"`
-	Templates    *ChiTemplateNames   `json:"templates,omitempty"    yaml:"templates,omitempty"`
-	SchemaPolicy *SchemaPolicy       `json:"schemaPolicy,omitempty" yaml:"schemaPolicy,omitempty"`
-	Insecure     *StringBool         `json:"insecure,omitempty"     yaml:"insecure,omitempty"`
-	Secure       *StringBool         `json:"secure,omitempty"       yaml:"secure,omitempty"`
-	Secret       *ClusterSecret      `json:"secret,omitempty"       yaml:"secret,omitempty"`
-	Layout       *ChiClusterLayout   `json:"layout,omitempty"       yaml:"layout,omitempty"`
+	Name              string              `json:"name,omitempty"              yaml:"name,omitempty"`
+	Zookeeper         *ChiZookeeperConfig `json:"zookeeper,omitempty"         yaml:"zookeeper,omitempty"`
+	Settings          *Settings           `json:"settings,omitempty"          yaml:"settings,omitempty"`
+	Files             *Settings           `json:"files,omitempty"             yaml:"files,omitempty"`
+	Macros            map[string]string   `json:"macros,omitempty"            yaml:"macros,omitempty"`
+	Templates         *ChiTemplateNames   `json:"templates,omitempty"         yaml:"templates,omitempty"`
+	SchemaPolicy      *SchemaPolicy       `json:"schemaPolicy,omitempty"      yaml:"schemaPolicy,omitempty"`
+	SchemaManagement  string              `json:"schemaManagement,omitempty"  yaml:"schemaManagement,omitempty"`
+	Insecure          *StringBool         `json:"insecure,omitempty"          yaml:"insecure,omitempty"`
+	Secure            *StringBool         `json:"secure,omitempty"            yaml:"secure,omitempty"`
+	Secret            *ClusterSecret      `json:"secret,omitempty"            yaml:"secret,omitempty"`
+	PriorityClassName string              `json:"priorityClassName,omitempty" yaml:"priorityClassName,omitempty"`
+	Layout            *ChiClusterLayout   `json:"layout,omitempty"            yaml:"layout,omitempty"`
 
 	Runtime ClusterRuntime `json:"-" yaml:"-"`
 }
@@ -142,6 +149,14 @@ func (cluster *Cluster) InheritFilesFrom(chi *ClickHouseInstallation) {
 	})
 }
 
+// InheritMacrosFrom inherits macros from CHI. Macros specified on cluster level take precedence
+func (cluster *Cluster) InheritMacrosFrom(chi *ClickHouseInstallation) {
+	if chi.Spec.Configuration == nil {
+		return
+	}
+	cluster.Macros = util.MergeStringMapsPreserve(cluster.Macros, chi.Spec.Configuration.Macros)
+}
+
 // InheritTemplatesFrom inherits templates from CHI
 func (cluster *Cluster) InheritTemplatesFrom(chi *ClickHouseInstallation) {
 	if chi.Spec.Defaults == nil {
@@ -316,6 +331,14 @@ func (cluster *Cluster) GetInsecure() *StringBool {
 	return cluster.Insecure
 }
 
+// GetPriorityClassName is a getter
+func (cluster *Cluster) GetPriorityClassName() string {
+	if cluster == nil {
+		return ""
+	}
+	return cluster.PriorityClassName
+}
+
 // GetSecure is a getter
 func (cluster *Cluster) GetSecure() *StringBool {
 	if cluster == nil {
@@ -14,6 +14,10 @@
 
 package v1
 
+import (
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
 const (
 	// CommonConfigDir specifies folder's name, where generated common XML files for ClickHouse would be placed
 	CommonConfigDir = "config.d"
@@ -30,12 +34,17 @@ const (
 
 // Configuration defines configuration section of .spec
 type Configuration struct {
-	Zookeeper *ChiZookeeperConfig `json:"zookeeper,omitempty" yaml:"zookeeper,omitempty"`
-	Users     *Settings           `json:"users,omitempty"     yaml:"users,omitempty"`
-	Profiles  *Settings           `json:"profiles,omitempty"  yaml:"profiles,omitempty"`
-	Quotas    *Settings           `json:"quotas,omitempty"    yaml:"quotas,omitempty"`
-	Settings  *Settings           `json:"settings,omitempty"  yaml:"settings,omitempty"`
-	Files     *Settings           `json:"files,omitempty"     yaml:"files,omitempty"`
+	Zookeeper *ChiZookeeperConfig      `json:"zookeeper,omitempty" yaml:"zookeeper,omitempty"`
+	Keeper    *ChiKeeper               `json:"keeper,omitempty"    yaml:"keeper,omitempty"`
+	Users     *Settings                `json:"users,omitempty"     yaml:"users,omitempty"`
+	Profiles  *Settings                `json:"profiles,omitempty"  yaml:"profiles,omitempty"`
+	Quotas    *Settings                `json:"quotas,omitempty"    yaml:"quotas,omitempty"`
+	Settings  *Settings                `json:"settings,omitempty"  yaml:"settings,omitempty"`
+	Files     *Settings                `json:"files,omitempty"     yaml:"files,omitempty"`
+	Macros    map[string]string        `json:"macros,omitempty"    yaml:"macros,omitempty"`
+	Storage   *ChiStorageConfiguration `json:"storage,omitempty"   yaml:"storage,omitempty"`
+	// InterserverCredentials specifies credentials replicas authenticate with to fetch parts from each other
+	InterserverCredentials *ChiInterserverCredentials `json:"interserverCredentials,omitempty" yaml:"interserverCredentials,omitempty"`
 	// TODO refactor into map[string]ChiCluster
 	Clusters []*Cluster `json:"clusters,omitempty"  yaml:"clusters,omitempty"`
 }
@@ -45,6 +54,14 @@ func NewConfiguration() *Configuration {
 	return new(Configuration)
 }
 
+// GetInterserverCredentials gets interserver credentials
+func (configuration *Configuration) GetInterserverCredentials() *ChiInterserverCredentials {
+	if configuration == nil {
+		return nil
+	}
+	return configuration.InterserverCredentials
+}
+
 // MergeFrom merges from specified source
 func (configuration *Configuration) MergeFrom(from *Configuration, _type MergeType) *Configuration {
 	if from == nil {
@@ -56,11 +73,15 @@ func (configuration *Configuration) MergeFrom(from *Configuration, _type MergeTy
 	}
 
 	configuration.Zookeeper = configuration.Zookeeper.MergeFrom(from.Zookeeper, _type)
+	configuration.Keeper = configuration.Keeper.MergeFrom(from.Keeper, _type)
 	configuration.Users = configuration.Users.MergeFrom(from.Users)
 	configuration.Profiles = configuration.Profiles.MergeFrom(from.Profiles)
 	configuration.Quotas = configuration.Quotas.MergeFrom(from.Quotas)
 	configuration.Settings = configuration.Settings.MergeFrom(from.Settings)
 	configuration.Files = configuration.Files.MergeFrom(from.Files)
+	configuration.Macros = util.MergeStringMapsPreserve(configuration.Macros, from.Macros)
+	configuration.Storage = configuration.Storage.MergeFrom(from.Storage)
+	configuration.InterserverCredentials = configuration.InterserverCredentials.MergeFrom(from.InterserverCredentials, _type)
 
 	// TODO merge clusters
 	// Copy Clusters for now
@@ -16,6 +16,7 @@ package v1
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"regexp"
 	"sort"
@@ -39,6 +40,18 @@ const (
 	defaultStatefulSetUpdateTimeout      = 300
 	defaultStatefulSetUpdatePollInterval = 15
 
+	// Default number of failed rollout attempts after which StatefulSet is rolled back
+	defaultStatefulSetUpdateAutoRollbackFailedAttempts = 3
+
+	// Default number of consecutive checks host has to be in the cluster for to be considered as included
+	defaultReconcileHostWaitStableChecks = 1
+
+	// Default timeout (in seconds) PVC may stay Pending for before it is reported to be stuck
+	defaultReconcileHostWaitPVCPendingTimeout = 300
+
+	// Default timeout (in seconds) to connect to each ZooKeeper endpoint
+	defaultReconcileZookeeperTimeout = 3
+
 	// Default values for ClickHouse user configuration
 	// 1. user/profile
 	// 2. user/quota
@@ -68,6 +81,9 @@ const (
 	defaultChPort     = 8123
 	defaultChRootCA   = ""
 
+	// Username of the least-privilege user managed by the operator
+	defaultChManagedUsername = "clickhouse_operator_managed"
+
 	// Timeouts used to limit connection and queries from the operator to ClickHouse instances. In seconds
 	// defaultTimeoutConnect specifies default timeout to connect to the ClickHouse instance. In seconds
 	defaultTimeoutConnect = 2
@@ -75,6 +91,10 @@ const (
 	defaultTimeoutQuery = 5
 	// defaultTimeoutCollect specifies default timeout to collect metrics from the ClickHouse instance. In seconds
 	defaultTimeoutCollect = 8
+	// defaultMetricsScrapeInterval specifies default interval hosts are scraped with in background. In seconds
+	defaultMetricsScrapeInterval = 30
+	// defaultMetricsScrapeMinInterval specifies default minimum interval CHI may specify hosts to be scraped with. In seconds
+	defaultMetricsScrapeMinInterval = 10
 
 	// defaultReconcileCHIsThreadsNumber specifies default number of controller threads running concurrently.
 	// Used in case no other specified in config
@@ -103,6 +123,20 @@ const (
 	defaultTerminationGracePeriod = 30
 	// defaultRevisionHistoryLimit specifies default value for RevisionHistoryLimit
 	defaultRevisionHistoryLimit = 10
+
+	// defaultReadinessProbePath specifies default HTTP path of the ClickHouse readiness probe
+	defaultReadinessProbePath = "/ping"
+	// defaultReadinessProbeInitialDelaySeconds specifies default initial delay of the ClickHouse readiness probe
+	defaultReadinessProbeInitialDelaySeconds = 10
+	// defaultReadinessProbePeriodSeconds specifies default period of the ClickHouse readiness probe
+	defaultReadinessProbePeriodSeconds = 3
+	// defaultStartupProbePath specifies default HTTP path of the ClickHouse startup probe
+	defaultStartupProbePath = "/ping"
+	// defaultStartupProbePeriodSeconds specifies default period of the ClickHouse startup probe
+	defaultStartupProbePeriodSeconds = 5
+	// defaultProbeScalingStoragePerFailureThreshold specifies default size of the host's data volume,
+	// each full chunk of which adds one to the failure threshold of the startup probe
+	defaultProbeScalingStoragePerFailureThreshold = "10Gi"
 )
 
 // Username/password replacers
@@ -134,6 +168,31 @@ const (
 	OnStatefulSetUpdateFailureActionIgnore = "ignore"
 )
 
+const (
+	// How to delete the whole CHI - delete hosts one by one, each host with all its resources
+	DeletionOrderHostByHost = "hostByHost"
+
+	// How to delete the whole CHI - delete resources stage by stage over all hosts:
+	// tables first (to clean ZooKeeper), then StatefulSets, then PVCs and services at last
+	DeletionOrderStaged = "staged"
+)
+
+const (
+	// What to do in case hosts of a cluster are configured to run different ClickHouse images - warn and proceed
+	OnMixedVersionsActionWarn = "warn"
+
+	// What to do in case hosts of a cluster are configured to run different ClickHouse images - reject reconcile
+	OnMixedVersionsActionReject = "reject"
+)
+
+const (
+	// What to do in case reconcile of a host does not complete in time - abort CHI reconcile
+	OnHostTimeoutActionAbort = "abort"
+
+	// What to do in case reconcile of a host does not complete in time - record failure and move to the next host
+	OnHostTimeoutActionContinue = "continue"
+)
+
 // OperatorConfig specifies operator configuration
 // !!! IMPORTANT !!!
 // !!! IMPORTANT !!!
@@ -259,6 +318,11 @@ type OperatorConfigClickHouse struct {
 				Password string
 				Fetched  bool
 				Error    string
+
+				// PreviousUsername and PreviousPassword specify credentials in use before the rotation.
+				// Hosts accept them till users config with rotated credentials is picked up.
+				PreviousUsername string
+				PreviousPassword string
 			}
 		} `json:"secret" yaml:"secret"`
 
@@ -270,6 +334,10 @@ type OperatorConfigClickHouse struct {
 			Connect time.Duration `json:"connect" yaml:"connect"`
 			Query   time.Duration `json:"query"   yaml:"query"`
 		} `json:"timeouts" yaml:"timeouts"`
+
+		// ManagedUser specifies least-privilege user provisioned by the operator in ClickHouse instances.
+		// Used instead of the user specified above for health checks and config reload
+		ManagedUser OperatorConfigManagedUser `json:"managedUser" yaml:"managedUser"`
 	} `json:"access" yaml:"access"`
 
 	// Metrics used to specify how the operator fetches metrics from ClickHouse instances
@@ -277,7 +345,143 @@ type OperatorConfigClickHouse struct {
 		Timeouts struct {
 			Collect time.Duration `json:"collect" yaml:"collect"`
 		} `json:"timeouts" yaml:"timeouts"`
+		// Queries specifies custom SQL queries the metrics exporter runs against each host besides built-in ones
+		Queries []OperatorConfigMetricsQuery `json:"queries,omitempty" yaml:"queries,omitempty"`
+		// Scrape specifies background scraping of hosts, which decouples fetching from prometheus scrapes
+		Scrape OperatorConfigMetricsScrape `json:"scrape" yaml:"scrape"`
 	} `json:"metrics" yaml:"metrics"`
+
+	// Probes specifies probes of ClickHouse containers, which are set up in case pod template does not specify own ones
+	Probes OperatorConfigProbes `json:"probes" yaml:"probes"`
+
+	// SystemCommands specifies SYSTEM commands the operator is permitted to run on ClickHouse instances
+	SystemCommands OperatorConfigSystemCommands `json:"systemCommands" yaml:"systemCommands"`
+}
+
+// OperatorConfigMetricsQuery specifies custom SQL query the metrics exporter runs against each host.
+// Each row of the query result is exported as a sample of the metric, named as chi_clickhouse_custom_<name>
+type OperatorConfigMetricsQuery struct {
+	// Name specifies name of the metric
+	Name string `json:"name"             yaml:"name"`
+	// Help specifies description of the metric
+	Help string `json:"help,omitempty"   yaml:"help,omitempty"`
+	// Type specifies type of the metric, either "gauge" or "counter". Gauge is used in case not specified
+	Type string `json:"type,omitempty"   yaml:"type,omitempty"`
+	// SQL specifies query to be run
+	SQL string `json:"sql"              yaml:"sql"`
+	// Value specifies column value of the metric is read from. Column "value" is used in case not specified
+	Value string `json:"value,omitempty"  yaml:"value,omitempty"`
+	// Labels specifies columns values of labels are read from. Names of the columns are used as label names
+	Labels []string `json:"labels,omitempty" yaml:"labels,omitempty"`
+}
+
+// IsValid checks whether query specifies both metric name and SQL
+func (q OperatorConfigMetricsQuery) IsValid() bool {
+	return (q.Name != "") && (q.SQL != "")
+}
+
+// IsCounter checks whether query specifies counter metric
+func (q OperatorConfigMetricsQuery) IsCounter() bool {
+	return strings.ToLower(q.Type) == "counter"
+}
+
+// IsDescribedAs checks whether query describes metric the same way as the other query does,
+// i.e. with the same help, type and labels. Metrics of the same name have to be described consistently
+func (q OperatorConfigMetricsQuery) IsDescribedAs(other OperatorConfigMetricsQuery) bool {
+	if (q.Help != other.Help) || (q.IsCounter() != other.IsCounter()) || (len(q.Labels) != len(other.Labels)) {
+		return false
+	}
+	for i := range q.Labels {
+		if q.Labels[i] != other.Labels[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// GetValue gets column value of the metric is read from
+func (q OperatorConfigMetricsQuery) GetValue() string {
+	if q.Value == "" {
+		return "value"
+	}
+	return q.Value
+}
+
+// OperatorConfigMetricsScrape specifies background scraping of hosts by the metrics exporter.
+// Hosts are scraped by the pool of workers, each CHI with own interval, and prometheus scrapes are served
+// with metrics fetched by the last scrape of each host
+type OperatorConfigMetricsScrape struct {
+	// Workers specifies number of workers hosts are scraped by. Zero means hosts are fetched on each prometheus scrape
+	Workers int `json:"workers"  yaml:"workers"`
+	// Interval specifies interval each host is scraped with. Can be overridden by CHI. In seconds
+	Interval time.Duration `json:"interval" yaml:"interval"`
+	// MinInterval specifies minimum interval CHI may specify hosts to be scraped with.
+	// Keeps CHIs with too short interval from starving the workers. In seconds
+	MinInterval time.Duration `json:"minInterval" yaml:"minInterval"`
+}
+
+// IsEnabled checks whether background scraping is enabled
+func (s OperatorConfigMetricsScrape) IsEnabled() bool {
+	return s.Workers > 0
+}
+
+// OperatorConfigSystemCommands specifies SYSTEM commands the operator is permitted to run.
+// Commands are specified without SYSTEM keyword, ex.: "RELOAD CONFIG", "DROP REPLICA".
+// Command matches all its variations, ex.: "DROP REPLICA" matches "DROP REPLICA 'replica' FROM ZKPATH '/path'"
+type OperatorConfigSystemCommands struct {
+	// Allow lists commands the operator is permitted to run. Empty list permits all commands
+	Allow []string `json:"allow,omitempty" yaml:"allow,omitempty"`
+	// Deny lists commands the operator is not permitted to run. Deny prevails over allow
+	Deny []string `json:"deny,omitempty" yaml:"deny,omitempty"`
+}
+
+// OperatorConfigManagedUser specifies least-privilege user provisioned by the operator in each CHI.
+// Password of the user is generated and kept in k8s Secret of the CHI
+type OperatorConfigManagedUser struct {
+	// Enabled specifies whether the user is provisioned and used by the operator
+	Enabled StringBool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	// Username specifies name of the user
+	Username string `json:"username,omitempty" yaml:"username,omitempty"`
+}
+
+// IsEnabled checks whether managed user is enabled
+func (u OperatorConfigManagedUser) IsEnabled() bool {
+	return u.Enabled.IsTrue()
+}
+
+// OperatorConfigProbes specifies probes of ClickHouse container
+type OperatorConfigProbes struct {
+	Readiness OperatorConfigProbe `json:"readiness" yaml:"readiness"`
+	// Startup probe is set up only in case its failure threshold is specified
+	Startup OperatorConfigProbe        `json:"startup" yaml:"startup"`
+	Scaling OperatorConfigProbeScaling `json:"scaling" yaml:"scaling"`
+}
+
+// OperatorConfigProbe specifies HTTP probe of ClickHouse container
+type OperatorConfigProbe struct {
+	// Path specifies HTTP path to be probed, ex.: "/ping" or "/?query=SELECT%201"
+	Path                string `json:"path"                yaml:"path"`
+	InitialDelaySeconds int32  `json:"initialDelaySeconds" yaml:"initialDelaySeconds"`
+	PeriodSeconds       int32  `json:"periodSeconds"       yaml:"periodSeconds"`
+	TimeoutSeconds      int32  `json:"timeoutSeconds"      yaml:"timeoutSeconds"`
+	SuccessThreshold    int32  `json:"successThreshold"    yaml:"successThreshold"`
+	FailureThreshold    int32  `json:"failureThreshold"    yaml:"failureThreshold"`
+}
+
+// OperatorConfigProbeScaling specifies how failure threshold of startup probe grows with the size
+// of the host's data volume, since hosts with more data take longer to load it on start.
+// Scaling depends on the host's own data only, so changes of the cluster do not roll other hosts
+type OperatorConfigProbeScaling struct {
+	Enabled StringBool `json:"enabled" yaml:"enabled"`
+	// StoragePerFailureThreshold specifies size of the host's data volume, each full chunk of which adds one to failure threshold
+	StoragePerFailureThreshold string `json:"storagePerFailureThreshold" yaml:"storagePerFailureThreshold"`
+	// MaxFailureThreshold caps scaled failure threshold. Zero means no cap
+	MaxFailureThreshold int32 `json:"maxFailureThreshold" yaml:"maxFailureThreshold"`
+}
+
+// IsEnabled checks whether probe scaling is enabled
+func (s OperatorConfigProbeScaling) IsEnabled() bool {
+	return s.Enabled.IsTrue()
 }
 
 // OperatorConfigTemplate specifies template section
@@ -338,12 +542,19 @@ type OperatorConfigReconcile struct {
 		ReconcileCHIsThreadsNumber           int `json:"reconcileCHIsThreadsNumber"           yaml:"reconcileCHIsThreadsNumber"`
 		ReconcileShardsThreadsNumber         int `json:"reconcileShardsThreadsNumber"         yaml:"reconcileShardsThreadsNumber"`
 		ReconcileShardsMaxConcurrencyPercent int `json:"reconcileShardsMaxConcurrencyPercent" yaml:"reconcileShardsMaxConcurrencyPercent"`
+		// ReconcileCHIsDebounceWindow specifies window (in seconds) within which updates of a CHI
+		// are coalesced into a single reconcile of the latest state. 0 means no debounce
+		ReconcileCHIsDebounceWindow int `json:"reconcileCHIsDebounceWindow" yaml:"reconcileCHIsDebounceWindow"`
 
 		// DEPRECATED, is replaced with reconcileCHIsThreadsNumber
 		ThreadsNumber int `json:"threadsNumber" yaml:"threadsNumber"`
 	} `json:"runtime" yaml:"runtime"`
 
 	StatefulSet struct {
+		// MaxConcurrentOperations specifies max number of StatefulSet create/update operations
+		// running concurrently across all CHIs. 0 means no limit
+		MaxConcurrentOperations int `json:"maxConcurrentOperations" yaml:"maxConcurrentOperations"`
+
 		Create struct {
 			OnFailure string `json:"onFailure" yaml:"onFailure"`
 		} `json:"create" yaml:"create"`
@@ -352,15 +563,108 @@ type OperatorConfigReconcile struct {
 			Timeout      uint64 `json:"timeout" yaml:"timeout"`
 			PollInterval uint64 `json:"pollInterval" yaml:"pollInterval"`
 			OnFailure    string `json:"onFailure" yaml:"onFailure"`
+
+			// AutoRollback specifies how to deal with StatefulSet, which repeatedly fails to roll out
+			AutoRollback OperatorConfigReconcileStatefulSetAutoRollback `json:"autoRollback" yaml:"autoRollback"`
+
+			// InPlaceResize specifies whether to resize pods in-place, when resources requests/limits are the only change
+			InPlaceResize *StringBool `json:"inPlaceResize,omitempty" yaml:"inPlaceResize,omitempty"`
 		} `json:"update" yaml:"update"`
 	} `json:"statefulSet" yaml:"statefulSet"`
 
-	Host OperatorConfigReconcileHost `json:"host" yaml:"host"`
+	Host    OperatorConfigReconcileHost    `json:"host"    yaml:"host"`
+	Cluster OperatorConfigReconcileCluster `json:"cluster" yaml:"cluster"`
+	Events  OperatorConfigReconcileEvents  `json:"events"  yaml:"events"`
+
+	// DeletionOrder specifies how child resources are deleted when the whole CHI is deleted
+	DeletionOrder string `json:"deletionOrder" yaml:"deletionOrder"`
+
+	// AdoptUnmanagedObjects specifies whether pre-existing objects, which are not managed by the operator,
+	// are taken over and overwritten during reconcile
+	AdoptUnmanagedObjects *StringBool `json:"adoptUnmanagedObjects,omitempty" yaml:"adoptUnmanagedObjects,omitempty"`
+
+	// Zookeeper specifies how ZooKeeper/Keeper ensembles used by the CHI are checked during reconcile
+	Zookeeper OperatorConfigReconcileZookeeper `json:"zookeeper" yaml:"zookeeper"`
+
+	// MembershipWebhook specifies endpoints CHIs are allowed to notify about hosts excluded from and included into the cluster
+	MembershipWebhook OperatorConfigReconcileMembershipWebhook `json:"membershipWebhook" yaml:"membershipWebhook"`
+}
+
+// OperatorConfigReconcileMembershipWebhook defines endpoints CHIs are allowed to use as membership webhook.
+// Webhook URL is specified by the CHI, so the operator calls only endpoints allowed by the operator config
+type OperatorConfigReconcileMembershipWebhook struct {
+	// AllowedSchemes specifies URL schemes webhook is allowed to be called with
+	AllowedSchemes []string `json:"allowedSchemes,omitempty" yaml:"allowedSchemes,omitempty"`
+	// AllowedHosts specifies hosts webhook is allowed to be called on. Host may be specified with "*." prefix,
+	// which allows all subdomains of the domain. No host is allowed in case not specified
+	AllowedHosts []string `json:"allowedHosts,omitempty" yaml:"allowedHosts,omitempty"`
+}
+
+// IsAllowed checks whether webhook is allowed to be called with the specified URL
+func (w OperatorConfigReconcileMembershipWebhook) IsAllowed(u *url.URL) bool {
+	if u == nil {
+		return false
+	}
+	schemeAllowed := false
+	for _, scheme := range w.AllowedSchemes {
+		if strings.EqualFold(scheme, u.Scheme) {
+			schemeAllowed = true
+			break
+		}
+	}
+	if !schemeAllowed {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range w.AllowedHosts {
+		allowed = strings.ToLower(allowed)
+		if strings.HasPrefix(allowed, "*.") {
+			if strings.HasSuffix(host, allowed[1:]) {
+				return true
+			}
+			continue
+		}
+		if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// OperatorConfigReconcileZookeeper defines reconcile ZooKeeper config
+type OperatorConfigReconcileZookeeper struct {
+	// Preflight specifies whether reachability of ZooKeeper ensemble is checked before hosts are reconciled
+	Preflight *StringBool `json:"preflight,omitempty" yaml:"preflight,omitempty"`
+	// Timeout specifies timeout (in seconds) to connect to each ZooKeeper endpoint
+	Timeout int `json:"timeout" yaml:"timeout"`
+}
+
+// OperatorConfigReconcileStatefulSetAutoRollback defines auto-rollback of StatefulSet, which repeatedly fails to roll out
+type OperatorConfigReconcileStatefulSetAutoRollback struct {
+	// Enabled specifies whether StatefulSet is rolled back to the last known-good revision
+	Enabled *StringBool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	// FailedAttempts specifies number of failed rollout attempts of the same spec, after which StatefulSet is rolled back
+	FailedAttempts int `json:"failedAttempts" yaml:"failedAttempts"`
+}
+
+// IsEnabled checks whether auto-rollback is enabled
+func (r OperatorConfigReconcileStatefulSetAutoRollback) IsEnabled() bool {
+	return r.Enabled.IsTrue()
 }
 
 // OperatorConfigReconcileHost defines reconcile host config
 type OperatorConfigReconcileHost struct {
 	Wait OperatorConfigReconcileHostWait `json:"wait" yaml:"wait"`
+	// MinHealthyReplicasPerShard specifies minimum number of healthy replicas to be kept in a shard
+	// while hosts are excluded from the cluster during reconcile. 0 means no limit
+	MinHealthyReplicasPerShard int `json:"minHealthyReplicasPerShard" yaml:"minHealthyReplicasPerShard"`
+	// ManageMergesOnRestart specifies whether merges are stopped on the host excluded from the cluster before restart
+	// and started again when the host is included back
+	ManageMergesOnRestart *StringBool `json:"manageMergesOnRestart,omitempty" yaml:"manageMergesOnRestart,omitempty"`
+	// Timeout specifies how long (in seconds) reconcile of a single host may take. 0 means no limit
+	Timeout int `json:"timeout" yaml:"timeout"`
+	// OnTimeout specifies what to do in case reconcile of a host does not complete in time
+	OnTimeout string `json:"onTimeout" yaml:"onTimeout"`
 }
 
 // OperatorConfigReconcileHostWait defines reconcile host wait config
@@ -368,6 +672,32 @@ type OperatorConfigReconcileHostWait struct {
 	Exclude *StringBool `json:"exclude,omitempty" yaml:"exclude,omitempty"`
 	Queries *StringBool `json:"queries,omitempty" yaml:"queries,omitempty"`
 	Include *StringBool `json:"include,omitempty" yaml:"include,omitempty"`
+
+	// StableChecks specifies number of consecutive checks the host has to be in the cluster for,
+	// in order to be considered as included
+	StableChecks int `json:"stableChecks" yaml:"stableChecks"`
+
+	// PVCPendingTimeout specifies how long (in seconds) PVC of the host may stay Pending for,
+	// before it is reported to be stuck
+	PVCPendingTimeout int `json:"pvcPendingTimeout" yaml:"pvcPendingTimeout"`
+}
+
+// OperatorConfigReconcileCluster defines reconcile cluster config
+type OperatorConfigReconcileCluster struct {
+	// OnMixedVersions specifies what to do in case hosts of a cluster are configured to run different ClickHouse images
+	OnMixedVersions string `json:"onMixedVersions" yaml:"onMixedVersions"`
+	// HealthThreshold specifies minimum percentage of reachable replicas in every shard of a cluster,
+	// required to exclude one more host from the cluster during reconcile. 0 means no limit
+	HealthThreshold int `json:"healthThreshold" yaml:"healthThreshold"`
+}
+
+// OperatorConfigReconcileEvents defines reconcile events config
+type OperatorConfigReconcileEvents struct {
+	// Aggregation specifies how repetitive per-host reconcile events are coalesced into summary events
+	Aggregation struct {
+		// Window specifies aggregation window in seconds. 0 means events are not aggregated
+		Window uint64 `json:"window" yaml:"window"`
+	} `json:"aggregation" yaml:"aggregation"`
 }
 
 // OperatorConfigAnnotation specifies annotation section
@@ -375,6 +705,9 @@ type OperatorConfigAnnotation struct {
 	// When transferring annotations from the chi/chit.metadata to CHI objects, use these filters.
 	Include []string `json:"include" yaml:"include"`
 	Exclude []string `json:"exclude" yaml:"exclude"`
+
+	// Whether to annotate Pod with generation of the CHI, which reconcile last touched the Pod.
+	AppendGeneration *StringBool `json:"appendGeneration,omitempty" yaml:"appendGeneration,omitempty"`
 }
 
 // OperatorConfigLabel specifies label section
@@ -750,6 +1083,20 @@ func (c *OperatorConfig) normalizeSectionReconcileStatefulSet() {
 	if c.Reconcile.StatefulSet.Update.OnFailure == "" {
 		c.Reconcile.StatefulSet.Update.OnFailure = OnStatefulSetUpdateFailureActionRollback
 	}
+
+	// Auto-rollback is opt-in
+	c.Reconcile.StatefulSet.Update.AutoRollback.Enabled = c.Reconcile.StatefulSet.Update.AutoRollback.Enabled.Normalize(false)
+	if c.Reconcile.StatefulSet.Update.AutoRollback.FailedAttempts <= 0 {
+		c.Reconcile.StatefulSet.Update.AutoRollback.FailedAttempts = defaultStatefulSetUpdateAutoRollbackFailedAttempts
+	}
+
+	// In-place resize is opt-in
+	c.Reconcile.StatefulSet.Update.InPlaceResize = c.Reconcile.StatefulSet.Update.InPlaceResize.Normalize(false)
+
+	// Negative limit makes no sense, treat it as no limit
+	if c.Reconcile.StatefulSet.MaxConcurrentOperations < 0 {
+		c.Reconcile.StatefulSet.MaxConcurrentOperations = 0
+	}
 }
 
 func (c *OperatorConfig) normalizeSectionClickHouseConfigurationUserDefault() {
@@ -825,6 +1172,10 @@ func (c *OperatorConfig) normalizeSectionClickHouseAccess() {
 	// Adjust seconds to time.Duration
 	c.ClickHouse.Access.Timeouts.Query = c.ClickHouse.Access.Timeouts.Query * time.Second
 
+	if c.ClickHouse.Access.ManagedUser.Username == "" {
+		c.ClickHouse.Access.ManagedUser.Username = defaultChManagedUsername
+	}
+
 }
 
 func (c *OperatorConfig) normalizeSectionClickHouseMetrics() {
@@ -833,6 +1184,40 @@ func (c *OperatorConfig) normalizeSectionClickHouseMetrics() {
 	}
 	// Adjust seconds to time.Duration
 	c.ClickHouse.Metrics.Timeouts.Collect = c.ClickHouse.Metrics.Timeouts.Collect * time.Second
+
+	if c.ClickHouse.Metrics.Scrape.Interval == 0 {
+		c.ClickHouse.Metrics.Scrape.Interval = defaultMetricsScrapeInterval
+	}
+	// Adjust seconds to time.Duration
+	c.ClickHouse.Metrics.Scrape.Interval = c.ClickHouse.Metrics.Scrape.Interval * time.Second
+
+	if c.ClickHouse.Metrics.Scrape.MinInterval == 0 {
+		c.ClickHouse.Metrics.Scrape.MinInterval = defaultMetricsScrapeMinInterval
+	}
+	// Adjust seconds to time.Duration
+	c.ClickHouse.Metrics.Scrape.MinInterval = c.ClickHouse.Metrics.Scrape.MinInterval * time.Second
+}
+
+func (c *OperatorConfig) normalizeSectionClickHouseProbes() {
+	if c.ClickHouse.Probes.Readiness.Path == "" {
+		c.ClickHouse.Probes.Readiness.Path = defaultReadinessProbePath
+	}
+	if c.ClickHouse.Probes.Readiness.InitialDelaySeconds == 0 {
+		c.ClickHouse.Probes.Readiness.InitialDelaySeconds = defaultReadinessProbeInitialDelaySeconds
+	}
+	if c.ClickHouse.Probes.Readiness.PeriodSeconds == 0 {
+		c.ClickHouse.Probes.Readiness.PeriodSeconds = defaultReadinessProbePeriodSeconds
+	}
+	if c.ClickHouse.Probes.Startup.Path == "" {
+		c.ClickHouse.Probes.Startup.Path = defaultStartupProbePath
+	}
+	if c.ClickHouse.Probes.Startup.PeriodSeconds == 0 {
+		c.ClickHouse.Probes.Startup.PeriodSeconds = defaultStartupProbePeriodSeconds
+	}
+	if c.ClickHouse.Probes.Scaling.StoragePerFailureThreshold == "" {
+		c.ClickHouse.Probes.Scaling.StoragePerFailureThreshold = defaultProbeScalingStoragePerFailureThreshold
+	}
+	// Zero timeout and thresholds mean k8s defaults
 }
 
 func (c *OperatorConfig) normalizeSectionLogger() {
@@ -862,6 +1247,72 @@ func (c *OperatorConfig) normalizeSectionReconcileRuntime() {
 	//reconcileWaitInclude: false
 }
 
+func (c *OperatorConfig) normalizeSectionReconcileDeletion() {
+	// Default deletion order
+	if c.Reconcile.DeletionOrder == "" {
+		c.Reconcile.DeletionOrder = DeletionOrderHostByHost
+	}
+}
+
+func (c *OperatorConfig) normalizeSectionReconcileAdoption() {
+	// Do not take over objects, which are not managed by the operator, by default
+	c.Reconcile.AdoptUnmanagedObjects = c.Reconcile.AdoptUnmanagedObjects.Normalize(false)
+}
+
+func (c *OperatorConfig) normalizeSectionReconcileZookeeper() {
+	// Check ZooKeeper reachability by default
+	c.Reconcile.Zookeeper.Preflight = c.Reconcile.Zookeeper.Preflight.Normalize(true)
+	if c.Reconcile.Zookeeper.Timeout < 1 {
+		c.Reconcile.Zookeeper.Timeout = defaultReconcileZookeeperTimeout
+	}
+}
+
+func (c *OperatorConfig) normalizeSectionReconcileMembershipWebhook() {
+	// Membership webhook is called over https only by default
+	if len(c.Reconcile.MembershipWebhook.AllowedSchemes) == 0 {
+		c.Reconcile.MembershipWebhook.AllowedSchemes = []string{"https"}
+	}
+}
+
+func (c *OperatorConfig) normalizeSectionReconcileHost() {
+	// Host is considered as included into the cluster as soon as it is seen there by default
+	if c.Reconcile.Host.Wait.StableChecks < 1 {
+		c.Reconcile.Host.Wait.StableChecks = defaultReconcileHostWaitStableChecks
+	}
+	if c.Reconcile.Host.Wait.PVCPendingTimeout <= 0 {
+		c.Reconcile.Host.Wait.PVCPendingTimeout = defaultReconcileHostWaitPVCPendingTimeout
+	}
+	// Do not touch merges by default
+	c.Reconcile.Host.ManageMergesOnRestart = c.Reconcile.Host.ManageMergesOnRestart.Normalize(false)
+	// No host reconcile deadline by default
+	if c.Reconcile.Host.Timeout < 0 {
+		c.Reconcile.Host.Timeout = 0
+	}
+	// Host, which does not complete in time, stops reconcile by default, same as any other host failure
+	if c.Reconcile.Host.OnTimeout == "" {
+		c.Reconcile.Host.OnTimeout = OnHostTimeoutActionAbort
+	}
+}
+
+func (c *OperatorConfig) normalizeSectionReconcileCluster() {
+	// Default action on mixed ClickHouse versions within a cluster
+	if c.Reconcile.Cluster.OnMixedVersions == "" {
+		c.Reconcile.Cluster.OnMixedVersions = OnMixedVersionsActionWarn
+	}
+	// Health threshold is a percentage
+	switch {
+	case c.Reconcile.Cluster.HealthThreshold < 0:
+		c.Reconcile.Cluster.HealthThreshold = 0
+	case c.Reconcile.Cluster.HealthThreshold > 100:
+		c.Reconcile.Cluster.HealthThreshold = 100
+	}
+}
+
+func (c *OperatorConfig) normalizeSectionAnnotation() {
+	// Do not annotate Pods with generation by default
+	c.Annotation.AppendGeneration = c.Annotation.AppendGeneration.Normalize(false)
+}
+
 func (c *OperatorConfig) normalizeSectionLabel() {
 	//config.IncludeIntoPropagationAnnotations
 	//config.ExcludeFromPropagationAnnotations
@@ -892,10 +1343,18 @@ func (c *OperatorConfig) normalize() {
 	c.normalizeSectionClickHouseConfigurationUserDefault()
 	c.normalizeSectionClickHouseAccess()
 	c.normalizeSectionClickHouseMetrics()
+	c.normalizeSectionClickHouseProbes()
 	c.normalizeSectionTemplate()
 	c.normalizeSectionReconcileStatefulSet()
 	c.normalizeSectionReconcileRuntime()
+	c.normalizeSectionReconcileHost()
+	c.normalizeSectionReconcileCluster()
+	c.normalizeSectionReconcileDeletion()
+	c.normalizeSectionReconcileAdoption()
+	c.normalizeSectionReconcileZookeeper()
+	c.normalizeSectionReconcileMembershipWebhook()
 	c.normalizeSectionLogger()
+	c.normalizeSectionAnnotation()
 	c.normalizeSectionLabel()
 	c.normalizeSectionStatefulSet()
 	c.normalizeSectionPod()
@@ -992,6 +1451,9 @@ func (c *OperatorConfig) String(hideCredentials bool) string {
 		if conf.ClickHouse.Access.Secret.Runtime.Password != "" {
 			conf.ClickHouse.Access.Secret.Runtime.Password = PasswordReplacer
 		}
+		if conf.ClickHouse.Access.Secret.Runtime.PreviousPassword != "" {
+			conf.ClickHouse.Access.Secret.Runtime.PreviousPassword = PasswordReplacer
+		}
 
 		// DEPRECATED
 		conf.CHConfigUserDefaultPassword = PasswordReplacer
@@ -1058,9 +1520,72 @@ func (c *OperatorConfig) GetTerminationGracePeriod() *int64 {
 	return &terminationGracePeriod
 }
 
+// accessCredentialsMutex guards ClickHouse access credentials, which are rotated in runtime
+var accessCredentialsMutex sync.RWMutex
+
+// GetAccessCredentials gets username and password the operator connects to ClickHouse instances with
+func (c *OperatorConfig) GetAccessCredentials() (username, password string) {
+	accessCredentialsMutex.RLock()
+	defer accessCredentialsMutex.RUnlock()
+	return c.ClickHouse.Access.Username, c.ClickHouse.Access.Password
+}
+
+// SetAccessCredentials sets username and password the operator connects to ClickHouse instances with.
+// Credentials in use are kept as previous ones, since hosts accept them till rotated credentials are rendered.
+// Returns true in case credentials are changed
+func (c *OperatorConfig) SetAccessCredentials(username, password string) bool {
+	accessCredentialsMutex.Lock()
+	defer accessCredentialsMutex.Unlock()
+	if (c.ClickHouse.Access.Username == username) && (c.ClickHouse.Access.Password == password) {
+		return false
+	}
+	c.ClickHouse.Access.Secret.Runtime.PreviousUsername = c.ClickHouse.Access.Username
+	c.ClickHouse.Access.Secret.Runtime.PreviousPassword = c.ClickHouse.Access.Password
+	c.ClickHouse.Access.Username = username
+	c.ClickHouse.Access.Password = password
+	return true
+}
+
+// GetPreviousAccessCredentials gets username and password the operator connected to ClickHouse instances with
+// before credentials were rotated. Empty in case there are no previous credentials to fallback to
+func (c *OperatorConfig) GetPreviousAccessCredentials() (username, password string) {
+	accessCredentialsMutex.RLock()
+	defer accessCredentialsMutex.RUnlock()
+	return c.ClickHouse.Access.Secret.Runtime.PreviousUsername, c.ClickHouse.Access.Secret.Runtime.PreviousPassword
+}
+
+// ClearPreviousAccessCredentials drops previous credentials as soon as hosts accept rotated ones
+func (c *OperatorConfig) ClearPreviousAccessCredentials() {
+	accessCredentialsMutex.Lock()
+	defer accessCredentialsMutex.Unlock()
+	c.ClickHouse.Access.Secret.Runtime.PreviousUsername = ""
+	c.ClickHouse.Access.Secret.Runtime.PreviousPassword = ""
+}
+
+// GetReconcileCHIsDebounceWindow gets window within which updates of a CHI are coalesced into a single reconcile
+func (c *OperatorConfig) GetReconcileCHIsDebounceWindow() time.Duration {
+	return time.Duration(c.Reconcile.Runtime.ReconcileCHIsDebounceWindow) * time.Second
+}
+
+// GetReconcileHostWaitPVCPendingTimeout gets how long PVC of the host may stay Pending for, before it is reported to be stuck
+func (c *OperatorConfig) GetReconcileHostWaitPVCPendingTimeout() time.Duration {
+	if c.Reconcile.Host.Wait.PVCPendingTimeout <= 0 {
+		return defaultReconcileHostWaitPVCPendingTimeout * time.Second
+	}
+	return time.Duration(c.Reconcile.Host.Wait.PVCPendingTimeout) * time.Second
+}
+
+// GetEventsAggregationWindow gets window within which repetitive reconcile events are aggregated
+func (c *OperatorConfig) GetEventsAggregationWindow() time.Duration {
+	return time.Duration(c.Reconcile.Events.Aggregation.Window) * time.Second
+}
+
 // GetRevisionHistoryLimit gets pointer to revisionHistoryLimit, as expected by
 // statefulSet.Spec.Template.Spec.RevisionHistoryLimit
 func (c *OperatorConfig) GetRevisionHistoryLimit() *int32 {
+	if c == nil {
+		return nil
+	}
 	revisionHistoryLimit := int32(c.StatefulSet.RevisionHistoryLimit)
 	return &revisionHistoryLimit
 }
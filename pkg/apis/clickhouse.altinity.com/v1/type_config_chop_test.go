@@ -0,0 +1,31 @@
+package v1
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_OperatorConfigReconcileMembershipWebhook_IsAllowed(t *testing.T) {
+	webhook := OperatorConfigReconcileMembershipWebhook{
+		AllowedSchemes: []string{"https"},
+		AllowedHosts:   []string{"lb.example.com", "*.mesh.local"},
+	}
+	for str, allowed := range map[string]bool{
+		"https://lb.example.com/members":      true,
+		"https://LB.example.com:8443/members": true,
+		"https://registry.mesh.local/hosts":   true,
+		"http://lb.example.com/members":       false,
+		"https://lb.example.com.evil.io/":     false,
+		"https://mesh.local/hosts":            false,
+		"https://169.254.169.254/latest":      false,
+	} {
+		u, err := url.Parse(str)
+		require.NoError(t, err)
+		require.Equal(t, allowed, webhook.IsAllowed(u), str)
+	}
+
+	// No host is allowed by default
+	require.False(t, OperatorConfigReconcileMembershipWebhook{AllowedSchemes: []string{"https"}}.IsAllowed(&url.URL{Scheme: "https", Host: "lb.example.com"}))
+}
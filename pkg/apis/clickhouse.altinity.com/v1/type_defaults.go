@@ -16,10 +16,19 @@ package v1
 
 // ChiDefaults defines defaults section of .spec
 type ChiDefaults struct {
-	ReplicasUseFQDN   *StringBool        `json:"replicasUseFQDN,omitempty"    yaml:"replicasUseFQDN,omitempty"`
-	DistributedDDL    *ChiDistributedDDL `json:"distributedDDL,omitempty"     yaml:"distributedDDL,omitempty"`
-	StorageManagement *StorageManagement `json:"storageManagement,omitempty"  yaml:"storageManagement,omitempty"`
-	Templates         *ChiTemplateNames  `json:"templates,omitempty"          yaml:"templates,omitempty"`
+	ReplicasUseFQDN         *StringBool        `json:"replicasUseFQDN,omitempty"         yaml:"replicasUseFQDN,omitempty"`
+	InterserverService      *StringBool        `json:"interserverService,omitempty"      yaml:"interserverService,omitempty"`
+	CreateDistributedTables *StringBool        `json:"createDistributedTables,omitempty" yaml:"createDistributedTables,omitempty"`
+	DistributedDDL          *ChiDistributedDDL `json:"distributedDDL,omitempty"          yaml:"distributedDDL,omitempty"`
+	StorageManagement       *StorageManagement `json:"storageManagement,omitempty"       yaml:"storageManagement,omitempty"`
+	Templates               *ChiTemplateNames  `json:"templates,omitempty"               yaml:"templates,omitempty"`
+	// Subdomain specifies headless Service chi-<chi>-<subdomain> governing StatefulSets of all hosts,
+	// so pods get stable DNS names <pod>.chi-<chi>-<subdomain>.<namespace>.svc.
+	// Changing subdomain recreates StatefulSets of all hosts, since governing Service of StatefulSet is immutable
+	Subdomain string `json:"subdomain,omitempty" yaml:"subdomain,omitempty"`
+	// Keeper specifies Keeper ensemble, which is provisioned automatically in case CHI has replicated clusters,
+	// while neither ZooKeeper nor Keeper is specified in configuration
+	Keeper *ChiKeeper `json:"keeper,omitempty" yaml:"keeper,omitempty"`
 }
 
 // NewChiDefaults creates new ChiDefaults object
@@ -42,16 +51,54 @@ func (defaults *ChiDefaults) MergeFrom(from *ChiDefaults, _type MergeType) *ChiD
 		if !from.ReplicasUseFQDN.HasValue() {
 			defaults.ReplicasUseFQDN = defaults.ReplicasUseFQDN.MergeFrom(from.ReplicasUseFQDN)
 		}
+		if !defaults.InterserverService.HasValue() {
+			defaults.InterserverService = defaults.InterserverService.MergeFrom(from.InterserverService)
+		}
+		if !defaults.CreateDistributedTables.HasValue() {
+			defaults.CreateDistributedTables = defaults.CreateDistributedTables.MergeFrom(from.CreateDistributedTables)
+		}
+		if defaults.Subdomain == "" {
+			defaults.Subdomain = from.Subdomain
+		}
 	case MergeTypeOverrideByNonEmptyValues:
 		if from.ReplicasUseFQDN.HasValue() {
 			// Override by non-empty values only
 			defaults.ReplicasUseFQDN = defaults.ReplicasUseFQDN.MergeFrom(from.ReplicasUseFQDN)
 		}
+		if from.InterserverService.HasValue() {
+			// Override by non-empty values only
+			defaults.InterserverService = defaults.InterserverService.MergeFrom(from.InterserverService)
+		}
+		if from.CreateDistributedTables.HasValue() {
+			// Override by non-empty values only
+			defaults.CreateDistributedTables = defaults.CreateDistributedTables.MergeFrom(from.CreateDistributedTables)
+		}
+		if from.Subdomain != "" {
+			// Override by non-empty values only
+			defaults.Subdomain = from.Subdomain
+		}
 	}
 
 	defaults.DistributedDDL = defaults.DistributedDDL.MergeFrom(from.DistributedDDL, _type)
 	defaults.StorageManagement = defaults.StorageManagement.MergeFrom(from.StorageManagement, _type)
 	defaults.Templates = defaults.Templates.MergeFrom(from.Templates, _type)
+	defaults.Keeper = defaults.Keeper.MergeFrom(from.Keeper, _type)
 
 	return defaults
 }
+
+// GetSubdomain gets subdomain of headless Service governing StatefulSets of all hosts
+func (defaults *ChiDefaults) GetSubdomain() string {
+	if defaults == nil {
+		return ""
+	}
+	return defaults.Subdomain
+}
+
+// GetKeeper gets Keeper ensemble, which is provisioned automatically
+func (defaults *ChiDefaults) GetKeeper() *ChiKeeper {
+	if defaults == nil {
+		return nil
+	}
+	return defaults.Keeper
+}
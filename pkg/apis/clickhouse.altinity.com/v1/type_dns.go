@@ -0,0 +1,92 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+// ChiDNS specifies DNS record to be registered for the CHI entry point by external-dns
+type ChiDNS struct {
+	// Hostname specifies DNS name of the CHI entry point. Macros, ex.: {chi}, {namespace}, are expanded
+	Hostname string `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+	// TTL specifies TTL of the DNS record, in seconds
+	TTL *int32 `json:"ttl,omitempty"      yaml:"ttl,omitempty"`
+}
+
+// NewChiDNS creates new ChiDNS
+func NewChiDNS() *ChiDNS {
+	return new(ChiDNS)
+}
+
+// HasHostname checks whether hostname is specified
+func (d *ChiDNS) HasHostname() bool {
+	if d == nil {
+		return false
+	}
+	return len(d.Hostname) > 0
+}
+
+// GetHostname gets hostname
+func (d *ChiDNS) GetHostname() string {
+	if d == nil {
+		return ""
+	}
+	return d.Hostname
+}
+
+// HasTTL checks whether TTL is specified
+func (d *ChiDNS) HasTTL() bool {
+	if d == nil {
+		return false
+	}
+	return d.TTL != nil
+}
+
+// GetTTL gets TTL
+func (d *ChiDNS) GetTTL() int32 {
+	if !d.HasTTL() {
+		return 0
+	}
+	return *d.TTL
+}
+
+// MergeFrom merges from specified source
+func (d *ChiDNS) MergeFrom(from *ChiDNS, _type MergeType) *ChiDNS {
+	if from == nil {
+		return d
+	}
+
+	if d == nil {
+		d = NewChiDNS()
+	}
+
+	switch _type {
+	case MergeTypeFillEmptyValues:
+		if d.Hostname == "" {
+			d.Hostname = from.Hostname
+		}
+		if d.TTL == nil {
+			d.TTL = from.TTL
+		}
+	case MergeTypeOverrideByNonEmptyValues:
+		if from.Hostname != "" {
+			// Override by non-empty values only
+			d.Hostname = from.Hostname
+		}
+		if from.TTL != nil {
+			// Override by non-empty values only
+			d.TTL = from.TTL
+		}
+	}
+
+	return d
+}
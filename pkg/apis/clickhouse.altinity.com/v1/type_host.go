@@ -34,9 +34,13 @@ type ChiHost struct {
 	HTTPPort            int32             `json:"httpPort,omitempty"            yaml:"httpPort,omitempty"`
 	HTTPSPort           int32             `json:"httpsPort,omitempty"           yaml:"httpsPort,omitempty"`
 	InterserverHTTPPort int32             `json:"interserverHTTPPort,omitempty" yaml:"interserverHTTPPort,omitempty"`
+	GRPCPort            int32             `json:"grpcPort,omitempty"            yaml:"grpcPort,omitempty"`
+	PrometheusPort      int32             `json:"prometheusPort,omitempty"      yaml:"prometheusPort,omitempty"`
 	Settings            *Settings         `json:"settings,omitempty"            yaml:"settings,omitempty"`
 	Files               *Settings         `json:"files,omitempty"               yaml:"files,omitempty"`
 	Templates           *ChiTemplateNames `json:"templates,omitempty"           yaml:"templates,omitempty"`
+	// Weight is advertised by annotations of the host's pod and service and rendered into remote_servers
+	Weight *int `json:"weight,omitempty" yaml:"weight,omitempty"`
 
 	Runtime ChiHostRuntime `json:"-" yaml:"-"`
 }
@@ -52,6 +56,11 @@ type ChiHostRuntime struct {
 	// DesiredStatefulSet is a desired stateful set - reconcile target
 	DesiredStatefulSet *apps.StatefulSet       `json:"-" yaml:"-" testdiff:"ignore"`
 	CHI                *ClickHouseInstallation `json:"-" yaml:"-" testdiff:"ignore"`
+
+	// MergesStopped specifies whether merges are stopped on the host by the operator and have to be started again
+	MergesStopped bool `json:"-" yaml:"-" testdiff:"ignore"`
+	// AdoptedVolumeClaimTemplates maps volume claim templates of adopted StatefulSet onto volume claim templates of the CHI
+	AdoptedVolumeClaimTemplates map[string]string `json:"-" yaml:"-" testdiff:"ignore"`
 }
 
 // GetReconcileAttributes is an ensurer getter
@@ -131,10 +140,38 @@ func (host *ChiHost) MergeFrom(from *ChiHost) {
 	if isUnassigned(host.InterserverHTTPPort) {
 		host.InterserverHTTPPort = from.InterserverHTTPPort
 	}
+	if isUnassigned(host.GRPCPort) {
+		host.GRPCPort = from.GRPCPort
+	}
+	if isUnassigned(host.PrometheusPort) {
+		host.PrometheusPort = from.PrometheusPort
+	}
+	if host.Weight == nil {
+		host.Weight = from.Weight
+	}
 	host.Templates = host.Templates.MergeFrom(from.Templates, MergeTypeFillEmptyValues)
 	host.Templates.HandleDeprecatedFields()
 }
 
+// HasWeight checks whether host has applicable weight value specified
+func (host *ChiHost) HasWeight() bool {
+	if host == nil {
+		return false
+	}
+	if host.Weight == nil {
+		return false
+	}
+	return *host.Weight >= 0
+}
+
+// GetWeight gets weight
+func (host *ChiHost) GetWeight() int {
+	if host.HasWeight() {
+		return *host.Weight
+	}
+	return 0
+}
+
 // GetHostTemplate gets host template
 func (host *ChiHost) GetHostTemplate() (*HostTemplate, bool) {
 	if !host.Templates.HasHostTemplate() {
@@ -187,6 +224,15 @@ func (host *ChiHost) GetZookeeper() *ChiZookeeperConfig {
 	return cluster.Zookeeper
 }
 
+// GetMacros gets custom macros of the host
+func (host *ChiHost) GetMacros() map[string]string {
+	cluster := host.GetCluster()
+	if cluster == nil {
+		return nil
+	}
+	return cluster.Macros
+}
+
 // GetName gets name
 func (host *ChiHost) GetName() string {
 	if host == nil {
@@ -340,6 +386,11 @@ func (host *ChiHost) IsSecure() bool {
 		return host.GetCluster().GetSecure().Value()
 	}
 
+	// Hosts served with certificates issued by cert-manager expose secure by default
+	if host.GetCHI().GetCertManager().IsEnabled() {
+		return true
+	}
+
 	// No cluster value - host should not expose secure
 	return false
 }
@@ -364,6 +415,25 @@ func (host *ChiHost) IsInsecure() bool {
 	return true
 }
 
+// GetPriorityClassName gets name of the PriorityClass pods of the host are to be scheduled with
+func (host *ChiHost) GetPriorityClassName() string {
+	if host == nil {
+		return ""
+	}
+
+	// Cluster value overrides pod template
+	if name := host.GetCluster().GetPriorityClassName(); name != "" {
+		return name
+	}
+
+	// No cluster value - fallback to pod template value
+	if podTemplate, ok := host.GetPodTemplate(); ok {
+		return podTemplate.Spec.PriorityClassName
+	}
+
+	return ""
+}
+
 // IsFirst checks whether the host is the first host of the whole CHI
 func (host *ChiHost) IsFirst() bool {
 	if host == nil {
@@ -0,0 +1,67 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+)
+
+func newPriorityClassTestHost(podTemplateClass, clusterClass string) *ChiHost {
+	chi := &ClickHouseInstallation{
+		Spec: ChiSpec{
+			Configuration: &Configuration{
+				Clusters: []*Cluster{
+					{
+						Name:              "cluster",
+						PriorityClassName: clusterClass,
+					},
+				},
+			},
+			Templates: &Templates{},
+		},
+	}
+	chi.Spec.Templates.EnsurePodTemplatesIndex().Set("pod-template", &PodTemplate{
+		Name: "pod-template",
+		Spec: core.PodSpec{
+			PriorityClassName: podTemplateClass,
+		},
+	})
+
+	host := &ChiHost{
+		Templates: &ChiTemplateNames{
+			PodTemplate: "pod-template",
+		},
+	}
+	host.Runtime.CHI = chi
+	host.Runtime.Address.ClusterName = "cluster"
+	return host
+}
+
+func Test_ChiHost_GetPriorityClassName(t *testing.T) {
+	tests := []struct {
+		name             string
+		podTemplateClass string
+		clusterClass     string
+		want             string
+	}{
+		{"none", "", "", ""},
+		{"pod template", "clickhouse-high", "", "clickhouse-high"},
+		{"cluster", "", "clickhouse-critical", "clickhouse-critical"},
+		{"cluster overrides pod template", "clickhouse-high", "clickhouse-critical", "clickhouse-critical"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host := newPriorityClassTestHost(tt.podTemplateClass, tt.clusterClass)
+			require.Equal(t, tt.want, host.GetPriorityClassName())
+		})
+	}
+
+	// Host without pod template
+	host := newPriorityClassTestHost("clickhouse-high", "")
+	host.Templates = nil
+	require.Equal(t, "", host.GetPriorityClassName())
+
+	var nilHost *ChiHost
+	require.Equal(t, "", nilHost.GetPriorityClassName())
+}
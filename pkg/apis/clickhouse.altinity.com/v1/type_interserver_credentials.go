@@ -0,0 +1,90 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+// ChiInterserverCredentials specifies credentials replicas authenticate with to fetch parts from each other.
+// Credentials are generated by the operator and kept in the Secret of the CHI
+type ChiInterserverCredentials struct {
+	// Enabled specifies whether interserver credentials are generated and required by the hosts
+	Enabled *StringBool `json:"enabled,omitempty"  yaml:"enabled,omitempty"`
+	// Rotation specifies rotation token. Each change of the token rotates credentials
+	Rotation string `json:"rotation,omitempty" yaml:"rotation,omitempty"`
+}
+
+// NewChiInterserverCredentials creates new ChiInterserverCredentials
+func NewChiInterserverCredentials() *ChiInterserverCredentials {
+	return new(ChiInterserverCredentials)
+}
+
+// IsEnabled checks whether interserver credentials are generated and required by the hosts
+func (c *ChiInterserverCredentials) IsEnabled() bool {
+	if c == nil {
+		return false
+	}
+	return c.Enabled.IsTrue()
+}
+
+// GetRotation gets rotation token
+func (c *ChiInterserverCredentials) GetRotation() string {
+	if c == nil {
+		return ""
+	}
+	return c.Rotation
+}
+
+// MergeFrom merges from specified source
+func (c *ChiInterserverCredentials) MergeFrom(from *ChiInterserverCredentials, _type MergeType) *ChiInterserverCredentials {
+	if from == nil {
+		return c
+	}
+
+	if c == nil {
+		c = NewChiInterserverCredentials()
+	}
+
+	switch _type {
+	case MergeTypeFillEmptyValues:
+		if c.Enabled == nil {
+			c.Enabled = from.Enabled
+		}
+		if c.Rotation == "" {
+			c.Rotation = from.Rotation
+		}
+	case MergeTypeOverrideByNonEmptyValues:
+		if from.Enabled != nil {
+			// Override by non-empty values only
+			c.Enabled = from.Enabled
+		}
+		if from.Rotation != "" {
+			// Override by non-empty values only
+			c.Rotation = from.Rotation
+		}
+	}
+
+	return c
+}
+
+// InterserverCredentialsState specifies state of interserver credentials hosts are to run with.
+// Credentials are kept in two slots, so new credentials are distributed to the hosts alongside current ones
+type InterserverCredentialsState struct {
+	// Revision specifies revision of credentials, each rotation makes new revision
+	Revision string
+	// Active specifies slot of credentials hosts authenticate with
+	Active string
+	// Accepted specifies slot of credentials hosts accept in addition to the active ones, if any
+	Accepted string
+	// AllowEmpty specifies whether hosts accept requests without credentials
+	AllowEmpty bool
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+// ChiKeeper defines ClickHouse Keeper ensemble, which is managed by the operator along with the CHI
+type ChiKeeper struct {
+	// Replicas specifies number of Keeper nodes in the ensemble. Odd number of nodes is recommended
+	Replicas int `json:"replicas,omitempty" yaml:"replicas,omitempty"`
+	// Settings specifies Keeper config settings, ex.: keeper_server/coordination_settings/*
+	Settings *Settings `json:"settings,omitempty" yaml:"settings,omitempty"`
+	// Templates specifies pod and volume claim templates of Keeper nodes
+	Templates *Templates `json:"templates,omitempty" yaml:"templates,omitempty"`
+	// Provisioned marks ensemble provisioned automatically as specified in .spec.defaults.keeper.
+	// Is set by the normalizer only, so it is kept in normalized CHI and is not a part of CRD schema
+	Provisioned bool `json:"provisioned,omitempty" yaml:"provisioned,omitempty"`
+}
+
+// NewChiKeeper creates new ChiKeeper object
+func NewChiKeeper() *ChiKeeper {
+	return new(ChiKeeper)
+}
+
+// GetReplicas gets number of Keeper nodes
+func (k *ChiKeeper) GetReplicas() int {
+	if k == nil {
+		return 0
+	}
+	return k.Replicas
+}
+
+// IsProvisioned checks whether ensemble is provisioned automatically as specified in .spec.defaults.keeper
+func (k *ChiKeeper) IsProvisioned() bool {
+	if k == nil {
+		return false
+	}
+	return k.Provisioned
+}
+
+// GetSettings gets Keeper settings
+func (k *ChiKeeper) GetSettings() *Settings {
+	if k == nil {
+		return nil
+	}
+	return k.Settings
+}
+
+// GetTemplates gets Keeper templates
+func (k *ChiKeeper) GetTemplates() *Templates {
+	if k == nil {
+		return nil
+	}
+	return k.Templates
+}
+
+// MergeFrom merges from provided object
+func (k *ChiKeeper) MergeFrom(from *ChiKeeper, _type MergeType) *ChiKeeper {
+	if from == nil {
+		return k
+	}
+
+	if k == nil {
+		k = NewChiKeeper()
+	}
+
+	switch _type {
+	case MergeTypeFillEmptyValues:
+		if k.Replicas == 0 {
+			k.Replicas = from.Replicas
+		}
+	case MergeTypeOverrideByNonEmptyValues:
+		if from.Replicas > 0 {
+			k.Replicas = from.Replicas
+		}
+	}
+	k.Settings = k.Settings.MergeFrom(from.Settings)
+	k.Templates = k.Templates.MergeFrom(from.Templates, _type)
+
+	return k
+}
+
+// GetClientPort gets client port of Keeper nodes
+func (k *ChiKeeper) GetClientPort() int {
+	if !k.GetSettings().Has("keeper_server/tcp_port") {
+		return 9181
+	}
+	return k.GetSettings().Get("keeper_server/tcp_port").ScalarInt()
+}
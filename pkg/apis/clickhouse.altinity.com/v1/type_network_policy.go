@@ -0,0 +1,81 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	networking "k8s.io/api/networking/v1"
+)
+
+// ChiNetworkPolicy specifies NetworkPolicy the operator restricts ingress to ClickHouse instances of the CHI with.
+// Pods of the CHI and the operator are always allowed
+type ChiNetworkPolicy struct {
+	// Enabled specifies whether NetworkPolicy is generated
+	Enabled *StringBool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	// From specifies additional peers ingress is allowed from
+	From []networking.NetworkPolicyPeer `json:"from,omitempty"    yaml:"from,omitempty"`
+}
+
+// NewChiNetworkPolicy creates new ChiNetworkPolicy
+func NewChiNetworkPolicy() *ChiNetworkPolicy {
+	return new(ChiNetworkPolicy)
+}
+
+// IsEnabled checks whether NetworkPolicy is generated
+func (p *ChiNetworkPolicy) IsEnabled() bool {
+	if p == nil {
+		return false
+	}
+	return p.Enabled.IsTrue()
+}
+
+// GetFrom gets additional peers ingress is allowed from
+func (p *ChiNetworkPolicy) GetFrom() []networking.NetworkPolicyPeer {
+	if p == nil {
+		return nil
+	}
+	return p.From
+}
+
+// MergeFrom merges from specified source
+func (p *ChiNetworkPolicy) MergeFrom(from *ChiNetworkPolicy, _type MergeType) *ChiNetworkPolicy {
+	if from == nil {
+		return p
+	}
+
+	if p == nil {
+		p = NewChiNetworkPolicy()
+	}
+
+	switch _type {
+	case MergeTypeFillEmptyValues:
+		if p.Enabled == nil {
+			p.Enabled = from.Enabled
+		}
+		if len(p.From) == 0 {
+			p.From = from.From
+		}
+	case MergeTypeOverrideByNonEmptyValues:
+		if from.Enabled != nil {
+			// Override by non-empty values only
+			p.Enabled = from.Enabled
+		}
+		if len(from.From) > 0 {
+			// Override by non-empty values only
+			p.From = from.From
+		}
+	}
+
+	return p
+}
@@ -0,0 +1,92 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"time"
+
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClickHouseRestore defines restore of a ClickHouseInstallation from the backup taken by ClickHouseBackup.
+// Restore status has the same layout as the backup one.
+type ClickHouseRestore struct {
+	meta.TypeMeta   `json:",inline"            yaml:",inline"`
+	meta.ObjectMeta `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+
+	Spec   ChrSpec    `json:"spec"             yaml:"spec"`
+	Status *ChbStatus `json:"status,omitempty" yaml:"status,omitempty"`
+}
+
+// ChrSpec defines spec section of ClickHouseRestore resource
+type ChrSpec struct {
+	// CHI is the name of the ClickHouseInstallation in the same namespace to be restored
+	CHI string `json:"chi" yaml:"chi"`
+	// Cluster is the name of the cluster to be restored. All clusters are restored in case not specified
+	Cluster string `json:"cluster,omitempty" yaml:"cluster,omitempty"`
+	// Backup is the name of the ClickHouseBackup the backup was taken by
+	Backup string `json:"backup" yaml:"backup"`
+	// Tables is the pattern of tables to be restored, as accepted by clickhouse-backup
+	Tables string `json:"tables,omitempty" yaml:"tables,omitempty"`
+	// Remote specifies whether backup has to be downloaded from the remote storage
+	Remote *StringBool `json:"remote,omitempty" yaml:"remote,omitempty"`
+	// DropExisting specifies whether existing tables have to be dropped before restore
+	DropExisting *StringBool `json:"dropExisting,omitempty" yaml:"dropExisting,omitempty"`
+	// Port is the port of clickhouse-backup REST API on the hosts
+	Port int32 `json:"port,omitempty" yaml:"port,omitempty"`
+	// Timeout is the number of seconds restore is allowed to run. Hosts not restored in time fail the restore
+	Timeout int32 `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// DefaultRestoreTimeout specifies default number of seconds restore is allowed to run
+const DefaultRestoreTimeout = 4 * 60 * 60
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClickHouseRestoreList defines a list of ClickHouseRestore resources
+type ClickHouseRestoreList struct {
+	meta.TypeMeta `json:",inline"  yaml:",inline"`
+	meta.ListMeta `json:"metadata" yaml:"metadata"`
+	Items         []ClickHouseRestore `json:"items" yaml:"items"`
+}
+
+// GetPort gets port of clickhouse-backup REST API
+func (spec *ChrSpec) GetPort() int32 {
+	if spec == nil || spec.Port == 0 {
+		return DefaultBackupAPIPort
+	}
+	return spec.Port
+}
+
+// GetTimeout gets duration restore is allowed to run
+func (spec *ChrSpec) GetTimeout() time.Duration {
+	if spec == nil || spec.Timeout <= 0 {
+		return DefaultRestoreTimeout * time.Second
+	}
+	return time.Duration(spec.Timeout) * time.Second
+}
+
+// EnsureStatus ensures status is in place
+func (chr *ClickHouseRestore) EnsureStatus() *ChbStatus {
+	if chr.Status == nil {
+		chr.Status = &ChbStatus{
+			Status: BackupStatusPending,
+		}
+	}
+	return chr.Status
+}
@@ -0,0 +1,139 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import "strings"
+
+// Possible values of what is scaled by /scale subresource
+const (
+	// ScaleTargetReplicas specifies replicas of each shard of the cluster are scaled
+	ScaleTargetReplicas = "replicas"
+	// ScaleTargetShards specifies shards of the cluster are scaled
+	ScaleTargetShards = "shards"
+)
+
+// ChiScale specifies cluster of the CHI, which is scaled by /scale subresource,
+// ex.: by `kubectl scale` or HorizontalPodAutoscaler
+type ChiScale struct {
+	// Cluster specifies name of the cluster being scaled. The first cluster is scaled in case not specified
+	Cluster string `json:"cluster,omitempty"  yaml:"cluster,omitempty"`
+	// Target specifies what is scaled, either "replicas" or "shards". Replicas are scaled in case not specified
+	Target string `json:"target,omitempty"   yaml:"target,omitempty"`
+	// Replicas specifies number of replicas or shards of the cluster, as requested by /scale subresource.
+	// Overrides counters specified in layout of the cluster
+	Replicas *int32 `json:"replicas,omitempty" yaml:"replicas,omitempty"`
+	// AllowShardsScaleDown specifies whether shards can be removed by /scale subresource.
+	// Data of removed shards is lost, thus shards scale down is refused unless explicitly allowed
+	AllowShardsScaleDown *StringBool `json:"allowShardsScaleDown,omitempty" yaml:"allowShardsScaleDown,omitempty"`
+}
+
+// NewChiScale creates new ChiScale
+func NewChiScale() *ChiScale {
+	return new(ChiScale)
+}
+
+// GetCluster gets name of the cluster being scaled
+func (s *ChiScale) GetCluster() string {
+	if s == nil {
+		return ""
+	}
+	return s.Cluster
+}
+
+// IsShards checks whether shards are scaled
+func (s *ChiScale) IsShards() bool {
+	if s == nil {
+		return false
+	}
+	return strings.ToLower(s.Target) == ScaleTargetShards
+}
+
+// HasReplicas checks whether number of replicas or shards is requested
+func (s *ChiScale) HasReplicas() bool {
+	if s == nil {
+		return false
+	}
+	return s.Replicas != nil
+}
+
+// GetReplicas gets requested number of replicas or shards
+func (s *ChiScale) GetReplicas() int {
+	if !s.HasReplicas() {
+		return 0
+	}
+	return int(*s.Replicas)
+}
+
+// IsShardsScaleDownAllowed checks whether shards can be removed by /scale subresource
+func (s *ChiScale) IsShardsScaleDownAllowed() bool {
+	if s == nil {
+		return false
+	}
+	return s.AllowShardsScaleDown.IsTrue()
+}
+
+// IsScaled checks whether specified cluster, having specified index among clusters of the CHI, is scaled
+func (s *ChiScale) IsScaled(cluster *Cluster, index int) bool {
+	if s.GetCluster() == "" {
+		return index == 0
+	}
+	return (cluster != nil) && (cluster.Name == s.GetCluster())
+}
+
+// MergeFrom merges from specified source
+func (s *ChiScale) MergeFrom(from *ChiScale, _type MergeType) *ChiScale {
+	if from == nil {
+		return s
+	}
+
+	if s == nil {
+		s = NewChiScale()
+	}
+
+	switch _type {
+	case MergeTypeFillEmptyValues:
+		if s.Cluster == "" {
+			s.Cluster = from.Cluster
+		}
+		if s.Target == "" {
+			s.Target = from.Target
+		}
+		if s.Replicas == nil {
+			s.Replicas = from.Replicas
+		}
+		if s.AllowShardsScaleDown == nil {
+			s.AllowShardsScaleDown = from.AllowShardsScaleDown
+		}
+	case MergeTypeOverrideByNonEmptyValues:
+		if from.Cluster != "" {
+			// Override by non-empty values only
+			s.Cluster = from.Cluster
+		}
+		if from.Target != "" {
+			// Override by non-empty values only
+			s.Target = from.Target
+		}
+		if from.Replicas != nil {
+			// Override by non-empty values only
+			s.Replicas = from.Replicas
+		}
+		if from.AllowShardsScaleDown != nil {
+			// Override by non-empty values only
+			s.AllowShardsScaleDown = from.AllowShardsScaleDown
+		}
+	}
+
+	return s
+}
@@ -380,6 +380,16 @@ func (s *Settings) GetInterserverHTTPPort() int32 {
 	return s.fetchPort("interserver_http_port")
 }
 
+// GetGRPCPort gets gRPC port from settings
+func (s *Settings) GetGRPCPort() int32 {
+	return s.fetchPort("grpc_port")
+}
+
+// GetPrometheusPort gets Prometheus endpoint port from settings
+func (s *Settings) GetPrometheusPort() int32 {
+	return s.fetchPort("prometheus/port")
+}
+
 // MergeFrom merges into `dst` non-empty new-key-values from `src` in case no such `key` already in `src`
 func (s *Settings) MergeFrom(src *Settings) *Settings {
 	if src.Len() == 0 {
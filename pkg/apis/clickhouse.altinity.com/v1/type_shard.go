@@ -133,3 +133,11 @@ func (shard *ChiShard) GetWeight() int {
 	}
 	return 0
 }
+
+// IsInMaintenance checks whether shard is requested to be taken out of the cluster for maintenance
+func (shard *ChiShard) IsInMaintenance() bool {
+	if shard == nil {
+		return false
+	}
+	return shard.Maintenance.IsTrue()
+}
@@ -16,7 +16,12 @@ package v1
 
 import (
 	"sort"
+	"strings"
 	"sync"
+	"time"
+
+	apiMeta "k8s.io/apimachinery/pkg/api/meta"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/altinity/clickhouse-operator/pkg/util"
 	"github.com/altinity/clickhouse-operator/pkg/version"
@@ -34,6 +39,55 @@ const (
 	StatusCompleted   = "Completed"
 	StatusAborted     = "Aborted"
 	StatusTerminating = "Terminating"
+	// StatusRolloutPaused specifies rollout reached the breakpoint host and waits for the breakpoint to be advanced or cleared
+	StatusRolloutPaused = "RolloutPaused"
+	// StatusAwaitingApproval means reconcile waits for ActionPlan to be approved
+	StatusAwaitingApproval = "AwaitingApproval"
+	// StatusDryRun means reconcile published ActionPlan of the CHI without applying it
+	StatusDryRun = "DryRun"
+	// StatusDegraded means reconcile completed, but some StatefulSets were rolled back to the last known-good revision
+	// after repeated rollout failures, so spec of the CHI is not applied completely
+	StatusDegraded = "Degraded"
+)
+
+// Phases of host reconcile
+const (
+	// HostPhasePending means reconcile of the host has not started yet
+	HostPhasePending = "Pending"
+	// HostPhaseExcluding means host is being excluded from the cluster
+	HostPhaseExcluding = "Excluding"
+	// HostPhaseUpdating means objects of the host are being updated
+	HostPhaseUpdating = "Updating"
+	// HostPhaseWaitingInCluster means host is being included into the cluster and is waited to serve
+	HostPhaseWaitingInCluster = "WaitingInCluster"
+	// HostPhaseDone means reconcile of the host is completed
+	HostPhaseDone = "Done"
+)
+
+// Types of CHI status conditions
+const (
+	// ConditionTypeReady means reconcile of the CHI completed and spec of the CHI is applied
+	ConditionTypeReady = "Ready"
+	// ConditionTypeProgressing means reconcile of the CHI is in progress
+	ConditionTypeProgressing = "Progressing"
+	// ConditionTypeDegraded means spec of the CHI is not applied completely, because of failed hosts or rolled back StatefulSets
+	ConditionTypeDegraded = "Degraded"
+	// ConditionTypeReconcileFailed means the last reconcile of the CHI failed or was aborted
+	ConditionTypeReconcileFailed = "ReconcileFailed"
+)
+
+// Reasons of CHI status conditions
+const (
+	ConditionReasonReconcileStarted   = "ReconcileStarted"
+	ConditionReasonReconcileCompleted = "ReconcileCompleted"
+	ConditionReasonReconcileFailed    = "ReconcileFailed"
+	ConditionReasonReconcileAborted   = "ReconcileAborted"
+	ConditionReasonRolloutPaused      = "RolloutPaused"
+	ConditionReasonAwaitingApproval   = "AwaitingApproval"
+	ConditionReasonDryRun             = "DryRun"
+	ConditionReasonRolledBack         = "RolledBack"
+	ConditionReasonHostsFailed        = "HostsFailed"
+	ConditionReasonTerminating        = "Terminating"
 )
 
 // ChiStatus defines status section of ClickHouseInstallation resource.
@@ -74,10 +128,61 @@ type ChiStatus struct {
 	NormalizedCHICompleted *ClickHouseInstallation `json:"normalizedCompleted,omitempty"    yaml:"normalizedCompleted,omitempty"`
 	HostsWithTablesCreated []string                `json:"hostsWithTablesCreated,omitempty" yaml:"hostsWithTablesCreated,omitempty"`
 	UsedTemplates          []*TemplateRef          `json:"usedTemplates,omitempty"          yaml:"usedTemplates,omitempty"`
+	EffectiveStorage       map[string]string       `json:"effectiveStorage,omitempty"       yaml:"effectiveStorage,omitempty"`
+
+	// ScaleReplicas is the number of replicas or shards of the cluster scaled by /scale subresource
+	ScaleReplicas int `json:"scaleReplicas,omitempty" yaml:"scaleReplicas,omitempty"`
+	// ScaleSelector is the label selector of pods of the cluster scaled by /scale subresource
+	ScaleSelector string `json:"scaleSelector,omitempty" yaml:"scaleSelector,omitempty"`
+
+	// FailedRollouts tracks StatefulSets, which failed to roll out, indexed by StatefulSet name
+	FailedRollouts map[string]ChiStatefulSetRollout `json:"failedRollouts,omitempty" yaml:"failedRollouts,omitempty"`
+	// StuckRollouts explains why StatefulSet rollout of a host does not progress, indexed by host name
+	StuckRollouts map[string]string `json:"stuckRollouts,omitempty" yaml:"stuckRollouts,omitempty"`
+	// HostFailures explains why reconcile of a host failed, indexed by host name
+	HostFailures map[string]string `json:"hostFailures,omitempty" yaml:"hostFailures,omitempty"`
+	// PendingCanaries lists canary hosts, which have not passed verification yet, indexed by host name.
+	// Rollout of the rest of the cluster is gated on such hosts until they pass verification
+	PendingCanaries map[string]string `json:"pendingCanaries,omitempty" yaml:"pendingCanaries,omitempty"`
+	// HostsProgress describes progress of reconcile of each host, indexed by host name
+	HostsProgress map[string]ChiHostProgress `json:"hostsProgress,omitempty" yaml:"hostsProgress,omitempty"`
+
+	// PendingPlan is the ActionPlan, which waits for approval
+	PendingPlan string `json:"pendingPlan,omitempty" yaml:"pendingPlan,omitempty"`
+	// PendingPlanHash is the hash of the pending ActionPlan, which has to be specified in approval annotation
+	PendingPlanHash string `json:"pendingPlanHash,omitempty" yaml:"pendingPlanHash,omitempty"`
+	// DryRunPlan is the ActionPlan, published by dry-run reconcile
+	DryRunPlan string `json:"dryRunPlan,omitempty" yaml:"dryRunPlan,omitempty"`
+
+	// ObservedGeneration is the generation of the CHI, reconcile of which completed the last
+	ObservedGeneration int64 `json:"observedGeneration,omitempty" yaml:"observedGeneration,omitempty"`
+
+	// Conditions are standard Kubernetes conditions, which describe state of the CHI reconcile
+	Conditions []meta.Condition `json:"conditions,omitempty" yaml:"conditions,omitempty"`
 
 	mu sync.RWMutex `json:"-" yaml:"-"`
 }
 
+// ChiStatefulSetRollout describes failed rollouts of a StatefulSet
+type ChiStatefulSetRollout struct {
+	// Version is the object version of the StatefulSet, which fails to roll out
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	// Failures is the number of failed rollout attempts of the version
+	Failures int `json:"failures,omitempty" yaml:"failures,omitempty"`
+	// RolledBack specifies whether StatefulSet was rolled back to the last known-good revision
+	RolledBack bool `json:"rolledBack,omitempty" yaml:"rolledBack,omitempty"`
+}
+
+// ChiHostProgress describes progress of host reconcile
+type ChiHostProgress struct {
+	// Phase is the current phase of host reconcile
+	Phase string `json:"phase,omitempty" yaml:"phase,omitempty"`
+	// StartedAt is the time reconcile of the host started at
+	StartedAt string `json:"startedAt,omitempty" yaml:"startedAt,omitempty"`
+	// Error is the error host reconcile failed with
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
 // CopyCHIStatusOptions specifies what to copy in CHI status options
 type CopyCHIStatusOptions struct {
 	Actions           bool
@@ -136,6 +241,14 @@ func (s *ChiStatus) Fill(params *FillStatusParams) {
 	})
 }
 
+// SetScale sets number of replicas or shards and label selector of pods of the cluster scaled by /scale subresource
+func (s *ChiStatus) SetScale(replicas int, selector string) {
+	doWithWriteLock(s, func(s *ChiStatus) {
+		s.ScaleReplicas = replicas
+		s.ScaleSelector = selector
+	})
+}
+
 // SetError sets status error
 func (s *ChiStatus) SetError(err string) {
 	doWithWriteLock(s, func(s *ChiStatus) {
@@ -174,6 +287,192 @@ func (s *ChiStatus) SyncHostTablesCreated() {
 	})
 }
 
+// SetEffectiveStorage sets storage size the PVC is actually provisioned with.
+// Empty size clears PVC's entry.
+func (s *ChiStatus) SetEffectiveStorage(pvc string, size string) {
+	doWithWriteLock(s, func(s *ChiStatus) {
+		if size == "" {
+			delete(s.EffectiveStorage, pvc)
+			if len(s.EffectiveStorage) == 0 {
+				s.EffectiveStorage = nil
+			}
+			return
+		}
+		if s.EffectiveStorage == nil {
+			s.EffectiveStorage = make(map[string]string)
+		}
+		s.EffectiveStorage[pvc] = size
+	})
+}
+
+// SetFailedRollout sets failed rollout of the StatefulSet.
+// Nil rollout clears StatefulSet's entry.
+func (s *ChiStatus) SetFailedRollout(statefulSet string, rollout *ChiStatefulSetRollout) {
+	doWithWriteLock(s, func(s *ChiStatus) {
+		if rollout == nil {
+			delete(s.FailedRollouts, statefulSet)
+			if len(s.FailedRollouts) == 0 {
+				s.FailedRollouts = nil
+			}
+			return
+		}
+		if s.FailedRollouts == nil {
+			s.FailedRollouts = make(map[string]ChiStatefulSetRollout)
+		}
+		s.FailedRollouts[statefulSet] = *rollout
+	})
+}
+
+// SetRolloutStuckReason sets reason StatefulSet rollout of the host is stuck.
+// Empty reason clears host's entry.
+func (s *ChiStatus) SetRolloutStuckReason(host, reason string) {
+	doWithWriteLock(s, func(s *ChiStatus) {
+		if reason == "" {
+			delete(s.StuckRollouts, host)
+			if len(s.StuckRollouts) == 0 {
+				s.StuckRollouts = nil
+			}
+			return
+		}
+		if s.StuckRollouts == nil {
+			s.StuckRollouts = make(map[string]string)
+		}
+		s.StuckRollouts[host] = reason
+	})
+}
+
+// SetHostFailure sets reason reconcile of the host failed.
+// Empty reason clears host's entry.
+func (s *ChiStatus) SetHostFailure(host, reason string) {
+	doWithWriteLock(s, func(s *ChiStatus) {
+		if reason == "" {
+			delete(s.HostFailures, host)
+			if len(s.HostFailures) == 0 {
+				s.HostFailures = nil
+			}
+			return
+		}
+		if s.HostFailures == nil {
+			s.HostFailures = make(map[string]string)
+		}
+		s.HostFailures[host] = reason
+	})
+}
+
+// SetCanaryPending sets reason canary host has not passed verification yet.
+// Empty reason clears host's entry.
+func (s *ChiStatus) SetCanaryPending(host, reason string) {
+	doWithWriteLock(s, func(s *ChiStatus) {
+		if reason == "" {
+			delete(s.PendingCanaries, host)
+			if len(s.PendingCanaries) == 0 {
+				s.PendingCanaries = nil
+			}
+			return
+		}
+		if s.PendingCanaries == nil {
+			s.PendingCanaries = make(map[string]string)
+		}
+		s.PendingCanaries[host] = reason
+	})
+}
+
+// ResetHostsProgress sets all specified hosts pending reconcile. Progress of other hosts is dropped
+func (s *ChiStatus) ResetHostsProgress(hosts []string) {
+	doWithWriteLock(s, func(s *ChiStatus) {
+		s.HostsProgress = nil
+		if len(hosts) == 0 {
+			return
+		}
+		s.HostsProgress = make(map[string]ChiHostProgress, len(hosts))
+		for _, host := range hosts {
+			s.HostsProgress[host] = ChiHostProgress{
+				Phase: HostPhasePending,
+			}
+		}
+	})
+}
+
+// SetHostPhase sets phase of the host reconcile.
+// Reconcile of the host is considered to be started as the host leaves pending or done phase.
+func (s *ChiStatus) SetHostPhase(host, phase string) {
+	doWithWriteLock(s, func(s *ChiStatus) {
+		if s.HostsProgress == nil {
+			s.HostsProgress = make(map[string]ChiHostProgress)
+		}
+		progress := s.HostsProgress[host]
+		switch progress.Phase {
+		case "", HostPhasePending, HostPhaseDone:
+			if phase != HostPhasePending {
+				progress.StartedAt = time.Now().Format(time.RFC3339)
+				progress.Error = ""
+			}
+		}
+		progress.Phase = phase
+		s.HostsProgress[host] = progress
+	})
+}
+
+// SetHostProgressError sets error reconcile of the host failed with. Phase of the host is kept as is
+func (s *ChiStatus) SetHostProgressError(host, err string) {
+	doWithWriteLock(s, func(s *ChiStatus) {
+		if s.HostsProgress == nil {
+			s.HostsProgress = make(map[string]ChiHostProgress)
+		}
+		progress := s.HostsProgress[host]
+		progress.Error = err
+		s.HostsProgress[host] = progress
+	})
+}
+
+// AwaitPlanApproval marks reconcile as waiting for approval of the specified ActionPlan
+func (s *ChiStatus) AwaitPlanApproval(hash, plan string) {
+	doWithWriteLock(s, func(s *ChiStatus) {
+		s.Status = StatusAwaitingApproval
+		s.PendingPlan = plan
+		s.PendingPlanHash = hash
+		setConditionNoSync(s, ConditionTypeProgressing, false, ConditionReasonAwaitingApproval, "Action plan waits for approval")
+	})
+}
+
+// ClearPendingPlan clears ActionPlan, which waits for approval
+func (s *ChiStatus) ClearPendingPlan() {
+	doWithWriteLock(s, func(s *ChiStatus) {
+		s.PendingPlan = ""
+		s.PendingPlanHash = ""
+	})
+}
+
+// GetPendingPlanHash gets hash of the ActionPlan, which waits for approval
+func (s *ChiStatus) GetPendingPlanHash() string {
+	return getStringWithReadLock(s, func(s *ChiStatus) string {
+		return s.PendingPlanHash
+	})
+}
+
+// PublishDryRunPlan marks reconcile as dry-run one, which published the specified ActionPlan
+func (s *ChiStatus) PublishDryRunPlan(plan string) {
+	doWithWriteLock(s, func(s *ChiStatus) {
+		s.Status = StatusDryRun
+		s.DryRunPlan = plan
+		setConditionNoSync(s, ConditionTypeProgressing, false, ConditionReasonDryRun, "Action plan is published without being applied")
+	})
+}
+
+// ClearDryRunPlan clears ActionPlan, published by dry-run reconcile
+func (s *ChiStatus) ClearDryRunPlan() {
+	doWithWriteLock(s, func(s *ChiStatus) {
+		s.DryRunPlan = ""
+	})
+}
+
+// GetDryRunPlan gets ActionPlan, published by dry-run reconcile
+func (s *ChiStatus) GetDryRunPlan() string {
+	return getStringWithReadLock(s, func(s *ChiStatus) string {
+		return s.DryRunPlan
+	})
+}
+
 // PushUsedTemplate pushes used template to the list of used templates
 func (s *ChiStatus) PushUsedTemplate(templateRef *TemplateRef) {
 	doWithWriteLock(s, func(s *ChiStatus) {
@@ -188,6 +487,13 @@ func (s *ChiStatus) GetUsedTemplatesCount() int {
 	})
 }
 
+// SetObservedGeneration sets generation of the CHI, reconcile of which completed
+func (s *ChiStatus) SetObservedGeneration(generation int64) {
+	doWithWriteLock(s, func(s *ChiStatus) {
+		s.ObservedGeneration = generation
+	})
+}
+
 // SetAction action setter
 func (s *ChiStatus) SetAction(action string) {
 	doWithWriteLock(s, func(s *ChiStatus) {
@@ -286,6 +592,8 @@ func (s *ChiStatus) ReconcileStart(deleteHostsCount int) {
 		s.HostsDeletedCount = 0
 		s.HostsDeleteCount = deleteHostsCount
 		pushTaskIDStartedNoSync(s)
+		setConditionNoSync(s, ConditionTypeReady, false, ConditionReasonReconcileStarted, "Reconcile is in progress")
+		setConditionNoSync(s, ConditionTypeProgressing, true, ConditionReasonReconcileStarted, "Reconcile is in progress")
 	})
 }
 
@@ -296,8 +604,27 @@ func (s *ChiStatus) ReconcileComplete() {
 			return
 		}
 		s.Status = StatusCompleted
+		if len(getRolledBackStatefulSetsNoSync(s)) > 0 || len(s.HostFailures) > 0 {
+			s.Status = StatusDegraded
+		}
 		s.Action = ""
 		pushTaskIDCompletedNoSync(s)
+		setConditionNoSync(s, ConditionTypeReady, true, ConditionReasonReconcileCompleted, "Reconcile completed")
+		setConditionNoSync(s, ConditionTypeProgressing, false, ConditionReasonReconcileCompleted, "Reconcile completed")
+		setConditionNoSync(s, ConditionTypeReconcileFailed, false, ConditionReasonReconcileCompleted, "Reconcile completed")
+		setDegradedConditionNoSync(s)
+	})
+}
+
+// ReconcilePause marks reconcile paused at the rollout breakpoint
+func (s *ChiStatus) ReconcilePause() {
+	doWithWriteLock(s, func(s *ChiStatus) {
+		if s == nil {
+			return
+		}
+		s.Status = StatusRolloutPaused
+		s.Action = ""
+		setConditionNoSync(s, ConditionTypeProgressing, false, ConditionReasonRolloutPaused, "Rollout is paused at the breakpoint host")
 	})
 }
 
@@ -310,6 +637,19 @@ func (s *ChiStatus) ReconcileAbort() {
 		s.Status = StatusAborted
 		s.Action = ""
 		pushTaskIDCompletedNoSync(s)
+		setConditionNoSync(s, ConditionTypeProgressing, false, ConditionReasonReconcileAborted, "Reconcile aborted")
+		setConditionNoSync(s, ConditionTypeReconcileFailed, true, ConditionReasonReconcileAborted, "Reconcile aborted")
+	})
+}
+
+// ReconcileFail marks reconcile failed with the specified error
+func (s *ChiStatus) ReconcileFail(err string) {
+	doWithWriteLock(s, func(s *ChiStatus) {
+		if s == nil {
+			return
+		}
+		setConditionNoSync(s, ConditionTypeProgressing, false, ConditionReasonReconcileFailed, err)
+		setConditionNoSync(s, ConditionTypeReconcileFailed, true, ConditionReasonReconcileFailed, err)
 	})
 }
 
@@ -327,6 +667,8 @@ func (s *ChiStatus) DeleteStart() {
 		s.HostsDeletedCount = 0
 		s.HostsDeleteCount = 0
 		pushTaskIDStartedNoSync(s)
+		setConditionNoSync(s, ConditionTypeReady, false, ConditionReasonTerminating, "CHI is being deleted")
+		setConditionNoSync(s, ConditionTypeProgressing, true, ConditionReasonTerminating, "CHI is being deleted")
 	})
 }
 
@@ -339,11 +681,33 @@ func (s *ChiStatus) CopyFrom(f *ChiStatus, opts CopyCHIStatusOptions) {
 			}
 
 			if opts.InheritableFields {
+				s.ScaleReplicas = from.ScaleReplicas
+				s.ScaleSelector = from.ScaleSelector
 				s.TaskIDsStarted = from.TaskIDsStarted
 				s.TaskIDsCompleted = from.TaskIDsCompleted
 				s.Actions = from.Actions
 				s.Errors = from.Errors
 				s.HostsWithTablesCreated = from.HostsWithTablesCreated
+				s.EffectiveStorage = nil
+				if len(from.EffectiveStorage) > 0 {
+					s.EffectiveStorage = util.CopyMap(from.EffectiveStorage)
+				}
+				s.FailedRollouts = copyFailedRollouts(from.FailedRollouts)
+				s.StuckRollouts = nil
+				if len(from.StuckRollouts) > 0 {
+					s.StuckRollouts = util.CopyMap(from.StuckRollouts)
+				}
+				s.HostFailures = nil
+				if len(from.HostFailures) > 0 {
+					s.HostFailures = util.CopyMap(from.HostFailures)
+				}
+				s.PendingCanaries = nil
+				if len(from.PendingCanaries) > 0 {
+					s.PendingCanaries = util.CopyMap(from.PendingCanaries)
+				}
+				s.HostsProgress = copyHostsProgress(from.HostsProgress)
+				s.Conditions = copyConditions(from.Conditions)
+				s.ObservedGeneration = from.ObservedGeneration
 			}
 
 			if opts.Actions {
@@ -357,6 +721,24 @@ func (s *ChiStatus) CopyFrom(f *ChiStatus, opts CopyCHIStatusOptions) {
 				if len(from.UsedTemplates) > 0 {
 					s.UsedTemplates = append(s.UsedTemplates, from.UsedTemplates...)
 				}
+				s.EffectiveStorage = nil
+				if len(from.EffectiveStorage) > 0 {
+					s.EffectiveStorage = util.CopyMap(from.EffectiveStorage)
+				}
+				s.FailedRollouts = copyFailedRollouts(from.FailedRollouts)
+				s.StuckRollouts = nil
+				if len(from.StuckRollouts) > 0 {
+					s.StuckRollouts = util.CopyMap(from.StuckRollouts)
+				}
+				s.HostFailures = nil
+				if len(from.HostFailures) > 0 {
+					s.HostFailures = util.CopyMap(from.HostFailures)
+				}
+				s.PendingCanaries = nil
+				if len(from.PendingCanaries) > 0 {
+					s.PendingCanaries = util.CopyMap(from.PendingCanaries)
+				}
+				s.HostsProgress = copyHostsProgress(from.HostsProgress)
 			}
 
 			if opts.Errors {
@@ -392,7 +774,32 @@ func (s *ChiStatus) CopyFrom(f *ChiStatus, opts CopyCHIStatusOptions) {
 				s.PodIPs = from.PodIPs
 				s.FQDNs = from.FQDNs
 				s.Endpoint = from.Endpoint
+				s.ScaleReplicas = from.ScaleReplicas
+				s.ScaleSelector = from.ScaleSelector
 				s.NormalizedCHI = from.NormalizedCHI
+				s.EffectiveStorage = nil
+				if len(from.EffectiveStorage) > 0 {
+					s.EffectiveStorage = util.CopyMap(from.EffectiveStorage)
+				}
+				s.FailedRollouts = copyFailedRollouts(from.FailedRollouts)
+				s.StuckRollouts = nil
+				if len(from.StuckRollouts) > 0 {
+					s.StuckRollouts = util.CopyMap(from.StuckRollouts)
+				}
+				s.HostFailures = nil
+				if len(from.HostFailures) > 0 {
+					s.HostFailures = util.CopyMap(from.HostFailures)
+				}
+				s.PendingCanaries = nil
+				if len(from.PendingCanaries) > 0 {
+					s.PendingCanaries = util.CopyMap(from.PendingCanaries)
+				}
+				s.HostsProgress = copyHostsProgress(from.HostsProgress)
+				s.PendingPlan = from.PendingPlan
+				s.PendingPlanHash = from.PendingPlanHash
+				s.DryRunPlan = from.DryRunPlan
+				s.Conditions = copyConditions(from.Conditions)
+				s.ObservedGeneration = from.ObservedGeneration
 			}
 
 			if opts.Normalized {
@@ -426,8 +833,33 @@ func (s *ChiStatus) CopyFrom(f *ChiStatus, opts CopyCHIStatusOptions) {
 				s.PodIPs = from.PodIPs
 				s.FQDNs = from.FQDNs
 				s.Endpoint = from.Endpoint
+				s.ScaleReplicas = from.ScaleReplicas
+				s.ScaleSelector = from.ScaleSelector
 				s.NormalizedCHI = from.NormalizedCHI
 				s.NormalizedCHICompleted = from.NormalizedCHICompleted
+				s.EffectiveStorage = nil
+				if len(from.EffectiveStorage) > 0 {
+					s.EffectiveStorage = util.CopyMap(from.EffectiveStorage)
+				}
+				s.FailedRollouts = copyFailedRollouts(from.FailedRollouts)
+				s.StuckRollouts = nil
+				if len(from.StuckRollouts) > 0 {
+					s.StuckRollouts = util.CopyMap(from.StuckRollouts)
+				}
+				s.HostFailures = nil
+				if len(from.HostFailures) > 0 {
+					s.HostFailures = util.CopyMap(from.HostFailures)
+				}
+				s.PendingCanaries = nil
+				if len(from.PendingCanaries) > 0 {
+					s.PendingCanaries = util.CopyMap(from.PendingCanaries)
+				}
+				s.HostsProgress = copyHostsProgress(from.HostsProgress)
+				s.PendingPlan = from.PendingPlan
+				s.PendingPlanHash = from.PendingPlanHash
+				s.DryRunPlan = from.DryRunPlan
+				s.Conditions = copyConditions(from.Conditions)
+				s.ObservedGeneration = from.ObservedGeneration
 			}
 		})
 	})
@@ -510,6 +942,15 @@ func (s *ChiStatus) GetStatus() string {
 	})
 }
 
+// GetObservedGeneration gets generation of the CHI, reconcile of which completed the last
+func (s *ChiStatus) GetObservedGeneration() int64 {
+	var generation int64
+	doWithReadLock(s, func(s *ChiStatus) {
+		generation = s.ObservedGeneration
+	})
+	return generation
+}
+
 // GetTaskID gets task ipd
 func (s *ChiStatus) GetTaskID() string {
 	return getStringWithReadLock(s, func(s *ChiStatus) string {
@@ -629,6 +1070,20 @@ func (s *ChiStatus) GetFQDNs() []string {
 	})
 }
 
+// GetScaleReplicas gets number of replicas or shards of the cluster scaled by /scale subresource
+func (s *ChiStatus) GetScaleReplicas() int {
+	return getIntWithReadLock(s, func(s *ChiStatus) int {
+		return s.ScaleReplicas
+	})
+}
+
+// GetScaleSelector gets label selector of pods of the cluster scaled by /scale subresource
+func (s *ChiStatus) GetScaleSelector() string {
+	return getStringWithReadLock(s, func(s *ChiStatus) string {
+		return s.ScaleSelector
+	})
+}
+
 // GetEndpoint gets API endpoint
 func (s *ChiStatus) GetEndpoint() string {
 	return getStringWithReadLock(s, func(s *ChiStatus) string {
@@ -657,6 +1112,84 @@ func (s *ChiStatus) GetHostsWithTablesCreated() []string {
 	})
 }
 
+// GetEffectiveStorage gets storage size the PVC is actually provisioned with, in case it differs from the requested one
+func (s *ChiStatus) GetEffectiveStorage(pvc string) string {
+	size := ""
+	doWithReadLock(s, func(s *ChiStatus) {
+		size = s.EffectiveStorage[pvc]
+	})
+	return size
+}
+
+// GetFailedRollout gets failed rollout of the StatefulSet
+func (s *ChiStatus) GetFailedRollout(statefulSet string) (rollout ChiStatefulSetRollout, ok bool) {
+	doWithReadLock(s, func(s *ChiStatus) {
+		rollout, ok = s.FailedRollouts[statefulSet]
+	})
+	return rollout, ok
+}
+
+// GetHostFailure gets reason reconcile of the host failed
+func (s *ChiStatus) GetHostFailure(host string) string {
+	reason := ""
+	doWithReadLock(s, func(s *ChiStatus) {
+		reason = s.HostFailures[host]
+	})
+	return reason
+}
+
+// IsCanaryPending checks whether canary host has not passed verification yet
+func (s *ChiStatus) IsCanaryPending(host string) bool {
+	pending := false
+	doWithReadLock(s, func(s *ChiStatus) {
+		_, pending = s.PendingCanaries[host]
+	})
+	return pending
+}
+
+// GetHostProgress gets progress of the host reconcile
+func (s *ChiStatus) GetHostProgress(host string) (progress ChiHostProgress, ok bool) {
+	doWithReadLock(s, func(s *ChiStatus) {
+		progress, ok = s.HostsProgress[host]
+	})
+	return progress, ok
+}
+
+// GetCondition gets condition of the specified type
+func (s *ChiStatus) GetCondition(conditionType string) (condition meta.Condition, ok bool) {
+	doWithReadLock(s, func(s *ChiStatus) {
+		if c := apiMeta.FindStatusCondition(s.Conditions, conditionType); c != nil {
+			condition, ok = *c, true
+		}
+	})
+	return condition, ok
+}
+
+// GetFailedHosts gets names of hosts, which reconcile failed
+func (s *ChiStatus) GetFailedHosts() (names []string) {
+	doWithReadLock(s, func(s *ChiStatus) {
+		names = getFailedHostsNoSync(s)
+	})
+	return names
+}
+
+// GetRolledBackStatefulSets gets names of StatefulSets, which were rolled back to the last known-good revision
+func (s *ChiStatus) GetRolledBackStatefulSets() (names []string) {
+	doWithReadLock(s, func(s *ChiStatus) {
+		names = getRolledBackStatefulSetsNoSync(s)
+	})
+	return names
+}
+
+// GetRolloutStuckReason gets reason StatefulSet rollout of the host is stuck
+func (s *ChiStatus) GetRolloutStuckReason(host string) string {
+	reason := ""
+	doWithReadLock(s, func(s *ChiStatus) {
+		reason = s.StuckRollouts[host]
+	})
+	return reason
+}
+
 // Begin helpers
 
 func doWithWriteLock(s *ChiStatus, f func(s *ChiStatus)) {
@@ -753,3 +1286,84 @@ func pushTaskIDCompletedNoSync(s *ChiStatus) {
 		s.TaskIDsCompleted = s.TaskIDsCompleted[:maxTaskIDs]
 	}
 }
+
+// getRolledBackStatefulSetsNoSync gets sorted names of StatefulSets, which were rolled back
+func getRolledBackStatefulSetsNoSync(s *ChiStatus) (names []string) {
+	for name, rollout := range s.FailedRollouts {
+		if rollout.RolledBack {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// setConditionNoSync sets condition of the specified type w/o sync.
+// Transition time of the condition is changed only in case condition status changes
+func setConditionNoSync(s *ChiStatus, conditionType string, status bool, reason, message string) {
+	conditionStatus := meta.ConditionFalse
+	if status {
+		conditionStatus = meta.ConditionTrue
+	}
+	apiMeta.SetStatusCondition(&s.Conditions, meta.Condition{
+		Type:    conditionType,
+		Status:  conditionStatus,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+// setDegradedConditionNoSync sets Degraded condition according to failed hosts and rolled back StatefulSets w/o sync
+func setDegradedConditionNoSync(s *ChiStatus) {
+	switch {
+	case len(getRolledBackStatefulSetsNoSync(s)) > 0:
+		setConditionNoSync(s, ConditionTypeDegraded, true, ConditionReasonRolledBack,
+			"StatefulSets are rolled back to the last known-good revision: "+strings.Join(getRolledBackStatefulSetsNoSync(s), ", "))
+	case len(s.HostFailures) > 0:
+		setConditionNoSync(s, ConditionTypeDegraded, true, ConditionReasonHostsFailed,
+			"Reconcile of hosts failed: "+strings.Join(getFailedHostsNoSync(s), ", "))
+	default:
+		setConditionNoSync(s, ConditionTypeDegraded, false, ConditionReasonReconcileCompleted, "Spec is applied completely")
+	}
+}
+
+// copyConditions copies conditions, empty set is copied as nil
+func copyConditions(src []meta.Condition) []meta.Condition {
+	if len(src) == 0 {
+		return nil
+	}
+	return append([]meta.Condition(nil), src...)
+}
+
+// getFailedHostsNoSync gets sorted names of hosts, which reconcile failed
+func getFailedHostsNoSync(s *ChiStatus) (names []string) {
+	for host := range s.HostFailures {
+		names = append(names, host)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// copyHostsProgress copies hosts progress, empty set is copied as nil
+func copyHostsProgress(src map[string]ChiHostProgress) map[string]ChiHostProgress {
+	if len(src) == 0 {
+		return nil
+	}
+	res := make(map[string]ChiHostProgress, len(src))
+	for host, progress := range src {
+		res[host] = progress
+	}
+	return res
+}
+
+// copyFailedRollouts copies failed rollouts, empty set is copied as nil
+func copyFailedRollouts(src map[string]ChiStatefulSetRollout) map[string]ChiStatefulSetRollout {
+	if len(src) == 0 {
+		return nil
+	}
+	res := make(map[string]ChiStatefulSetRollout, len(src))
+	for statefulSet, rollout := range src {
+		res[statefulSet] = rollout
+	}
+	return res
+}
@@ -3,6 +3,7 @@ package v1
 
 import (
 	"github.com/stretchr/testify/require"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sync"
 	"testing"
 )
@@ -246,3 +247,118 @@ func Test_ChiStatus_BasicOperations_SingleStatus_ConcurrencyTest(t *testing.T) {
 		})
 	}
 }
+
+func Test_ChiStatus_ReconcileComplete_Degraded(t *testing.T) {
+	s := &ChiStatus{}
+	s.SetFailedRollout("sts-b", &ChiStatefulSetRollout{Version: "1", Failures: 1})
+	s.ReconcileComplete()
+	require.Equal(t, StatusCompleted, s.GetStatus())
+	require.Empty(t, s.GetRolledBackStatefulSets())
+
+	// Rolled back StatefulSets keep CHI degraded
+	s.SetFailedRollout("sts-c", &ChiStatefulSetRollout{Version: "1", Failures: 3, RolledBack: true})
+	s.SetFailedRollout("sts-a", &ChiStatefulSetRollout{Version: "1", Failures: 3, RolledBack: true})
+	s.ReconcileComplete()
+	require.Equal(t, StatusDegraded, s.GetStatus())
+	require.Equal(t, []string{"sts-a", "sts-c"}, s.GetRolledBackStatefulSets())
+
+	// Successful rollouts clear degradation
+	s.SetFailedRollout("sts-a", nil)
+	s.SetFailedRollout("sts-c", nil)
+	s.ReconcileComplete()
+	require.Equal(t, StatusCompleted, s.GetStatus())
+
+	// Failed hosts keep CHI degraded as well
+	s.SetHostFailure("host-b", "failed")
+	s.SetHostFailure("host-a", "failed")
+	s.ReconcileComplete()
+	require.Equal(t, StatusDegraded, s.GetStatus())
+	require.Equal(t, []string{"host-a", "host-b"}, s.GetFailedHosts())
+
+	s.SetHostFailure("host-a", "")
+	s.SetHostFailure("host-b", "")
+	s.ReconcileComplete()
+	require.Equal(t, StatusCompleted, s.GetStatus())
+}
+
+func Test_ChiStatus_HostsProgress(t *testing.T) {
+	s := &ChiStatus{}
+	s.ResetHostsProgress([]string{"host-a", "host-b"})
+	progress, ok := s.GetHostProgress("host-a")
+	require.True(t, ok)
+	require.Equal(t, ChiHostProgress{Phase: HostPhasePending}, progress)
+
+	// Host reconcile starts as host leaves pending phase
+	s.SetHostPhase("host-a", HostPhaseExcluding)
+	progress, _ = s.GetHostProgress("host-a")
+	require.Equal(t, HostPhaseExcluding, progress.Phase)
+	require.NotEmpty(t, progress.StartedAt)
+	startedAt := progress.StartedAt
+
+	// Error keeps the phase the host is stuck on
+	s.SetHostPhase("host-a", HostPhaseWaitingInCluster)
+	s.SetHostProgressError("host-a", "timeout")
+	progress, _ = s.GetHostProgress("host-a")
+	require.Equal(t, ChiHostProgress{Phase: HostPhaseWaitingInCluster, StartedAt: startedAt, Error: "timeout"}, progress)
+
+	// Progress is copied along with status
+	copied := &ChiStatus{}
+	copied.CopyFrom(s, CopyCHIStatusOptions{Actions: true})
+	progress, _ = copied.GetHostProgress("host-a")
+	require.Equal(t, "timeout", progress.Error)
+
+	// Reset drops progress of the previous reconcile
+	s.ResetHostsProgress([]string{"host-b"})
+	_, ok = s.GetHostProgress("host-a")
+	require.False(t, ok)
+}
+
+func Test_ChiStatus_Conditions(t *testing.T) {
+	s := &ChiStatus{}
+	s.ReconcileStart(0)
+	ready, ok := s.GetCondition(ConditionTypeReady)
+	require.True(t, ok)
+	require.Equal(t, meta.ConditionFalse, ready.Status)
+	progressing, _ := s.GetCondition(ConditionTypeProgressing)
+	require.Equal(t, meta.ConditionTrue, progressing.Status)
+	require.Equal(t, ConditionReasonReconcileStarted, progressing.Reason)
+
+	s.SetHostFailure("host-a", "failed")
+	s.ReconcileComplete()
+	ready, _ = s.GetCondition(ConditionTypeReady)
+	require.Equal(t, meta.ConditionTrue, ready.Status)
+	degraded, _ := s.GetCondition(ConditionTypeDegraded)
+	require.Equal(t, meta.ConditionTrue, degraded.Status)
+	require.Equal(t, ConditionReasonHostsFailed, degraded.Reason)
+	failed, _ := s.GetCondition(ConditionTypeReconcileFailed)
+	require.Equal(t, meta.ConditionFalse, failed.Status)
+
+	// Transition time is kept as long as condition status does not change
+	s.ReconcileComplete()
+	degradedAgain, _ := s.GetCondition(ConditionTypeDegraded)
+	require.Equal(t, degraded.LastTransitionTime, degradedAgain.LastTransitionTime)
+
+	s.ReconcileFail("host-a failed")
+	failed, _ = s.GetCondition(ConditionTypeReconcileFailed)
+	require.Equal(t, meta.ConditionTrue, failed.Status)
+	require.Equal(t, "host-a failed", failed.Message)
+
+	// Conditions are inherited by the next reconcile
+	next := &ChiStatus{}
+	next.CopyFrom(s, CopyCHIStatusOptions{InheritableFields: true})
+	failed, ok = next.GetCondition(ConditionTypeReconcileFailed)
+	require.True(t, ok)
+	require.Equal(t, meta.ConditionTrue, failed.Status)
+}
+
+func Test_ChiStatus_ObservedGeneration(t *testing.T) {
+	s := &ChiStatus{}
+	require.Equal(t, int64(0), s.GetObservedGeneration())
+	s.SetObservedGeneration(3)
+
+	// Observed generation is inherited by the next reconcile and is kept until it completes
+	next := &ChiStatus{}
+	next.CopyFrom(s, CopyCHIStatusOptions{InheritableFields: true})
+	next.ReconcileStart(0)
+	require.Equal(t, int64(3), next.GetObservedGeneration())
+}
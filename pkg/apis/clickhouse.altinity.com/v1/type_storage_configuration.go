@@ -0,0 +1,124 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+// ChiStorageConfiguration defines additional disks of ClickHouse hosts and storage policies over them.
+// Rendered into ClickHouse <storage_configuration> config section
+type ChiStorageConfiguration struct {
+	// Disks specifies additional disks, each backed by a PVC built from a VolumeClaimTemplate
+	Disks []*ChiStorageDisk `json:"disks,omitempty"    yaml:"disks,omitempty"`
+	// Policies specifies storage policies, which can be referenced by tables via `storage_policy` setting
+	Policies []*ChiStoragePolicy `json:"policies,omitempty" yaml:"policies,omitempty"`
+}
+
+// ChiStorageDisk defines disk of a ClickHouse host
+type ChiStorageDisk struct {
+	// Name specifies name of the disk as used by ClickHouse
+	Name string `json:"name,omitempty"                yaml:"name,omitempty"`
+	// VolumeClaimTemplate specifies name of the VolumeClaimTemplate the disk PVC is built from
+	VolumeClaimTemplate string `json:"volumeClaimTemplate,omitempty" yaml:"volumeClaimTemplate,omitempty"`
+}
+
+// ChiStoragePolicy defines storage policy
+type ChiStoragePolicy struct {
+	// Name specifies name of the policy
+	Name string `json:"name,omitempty"       yaml:"name,omitempty"`
+	// Volumes specifies ordered list of volumes of the policy. Parts are moved from earlier volumes to later ones
+	Volumes []*ChiStoragePolicyVolume `json:"volumes,omitempty"    yaml:"volumes,omitempty"`
+	// MoveFactor specifies share of free space on a volume at which parts start to be moved to the next volume
+	MoveFactor string `json:"moveFactor,omitempty" yaml:"moveFactor,omitempty"`
+}
+
+// ChiStoragePolicyVolume defines volume of a storage policy
+type ChiStoragePolicyVolume struct {
+	// Name specifies name of the volume
+	Name string `json:"name,omitempty"  yaml:"name,omitempty"`
+	// Disks specifies names of the disks the volume consists of. Built-in `default` disk can be referenced
+	Disks []string `json:"disks,omitempty" yaml:"disks,omitempty"`
+}
+
+// NewChiStorageConfiguration creates new ChiStorageConfiguration object
+func NewChiStorageConfiguration() *ChiStorageConfiguration {
+	return new(ChiStorageConfiguration)
+}
+
+// IsEmpty checks whether storage configuration has nothing to render
+func (s *ChiStorageConfiguration) IsEmpty() bool {
+	if s == nil {
+		return true
+	}
+	return (len(s.Disks) == 0) && (len(s.Policies) == 0)
+}
+
+// GetDisks gets disks
+func (s *ChiStorageConfiguration) GetDisks() []*ChiStorageDisk {
+	if s == nil {
+		return nil
+	}
+	return s.Disks
+}
+
+// GetPolicies gets policies
+func (s *ChiStorageConfiguration) GetPolicies() []*ChiStoragePolicy {
+	if s == nil {
+		return nil
+	}
+	return s.Policies
+}
+
+// MergeFrom merges from provided object
+func (s *ChiStorageConfiguration) MergeFrom(from *ChiStorageConfiguration) *ChiStorageConfiguration {
+	if from == nil {
+		return s
+	}
+
+	if s == nil {
+		s = NewChiStorageConfiguration()
+	}
+
+	// Disks and policies are merged by name, existing entries take precedence
+	for _, disk := range from.Disks {
+		if !s.hasDisk(disk.Name) {
+			s.Disks = append(s.Disks, disk.DeepCopy())
+		}
+	}
+	for _, policy := range from.Policies {
+		if !s.hasPolicy(policy.Name) {
+			s.Policies = append(s.Policies, policy.DeepCopy())
+		}
+	}
+
+	return s
+}
+
+// hasDisk checks whether disk with specified name is listed
+func (s *ChiStorageConfiguration) hasDisk(name string) bool {
+	for _, disk := range s.GetDisks() {
+		if disk.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPolicy checks whether policy with specified name is listed
+func (s *ChiStorageConfiguration) hasPolicy(name string) bool {
+	for _, policy := range s.GetPolicies() {
+		if policy.Name == name {
+			return true
+		}
+	}
+	return false
+}
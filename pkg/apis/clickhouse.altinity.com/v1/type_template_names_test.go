@@ -0,0 +1,49 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GetServiceTemplate_PerScope(t *testing.T) {
+	chi := &ClickHouseInstallation{
+		Spec: ChiSpec{
+			Defaults: &ChiDefaults{
+				Templates: &ChiTemplateNames{ServiceTemplate: "load-balancer"},
+			},
+			Templates: &Templates{},
+		},
+	}
+	for _, name := range []string{"load-balancer", "cluster", "shard", "headless"} {
+		chi.Spec.Templates.EnsureServiceTemplatesIndex().Set(name, &ServiceTemplate{Name: name})
+	}
+	cluster := &Cluster{Templates: &ChiTemplateNames{ClusterServiceTemplate: "cluster"}}
+	cluster.Runtime.CHI = chi
+	shard := &ChiShard{Templates: &ChiTemplateNames{ShardServiceTemplate: "shard"}}
+	shard.Runtime.CHI = chi
+	host := &ChiHost{Templates: &ChiTemplateNames{ReplicaServiceTemplate: "headless"}}
+	host.Runtime.CHI = chi
+
+	// Each scope picks up template assigned to it
+	template, ok := chi.GetCHIServiceTemplate()
+	require.True(t, ok)
+	require.Equal(t, "load-balancer", template.Name)
+	template, ok = cluster.GetServiceTemplate()
+	require.True(t, ok)
+	require.Equal(t, "cluster", template.Name)
+	template, ok = shard.GetServiceTemplate()
+	require.True(t, ok)
+	require.Equal(t, "shard", template.Name)
+	template, ok = host.GetServiceTemplate()
+	require.True(t, ok)
+	require.Equal(t, "headless", template.Name)
+
+	// Unassigned or unknown template is not picked up
+	cluster.Templates.ClusterServiceTemplate = ""
+	_, ok = cluster.GetServiceTemplate()
+	require.False(t, ok)
+	shard.Templates.ShardServiceTemplate = "unknown"
+	_, ok = shard.GetServiceTemplate()
+	require.False(t, ok)
+}
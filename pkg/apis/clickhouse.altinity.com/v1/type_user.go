@@ -0,0 +1,104 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// UserStatusApplied specifies user is rendered into users config of the CHI
+	UserStatusApplied = "Applied"
+	// UserStatusRejected specifies user is not rendered into users config of the CHI
+	UserStatusRejected = "Rejected"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClickHouseUser defines user of a ClickHouseInstallation along with its grants.
+// User is rendered into users config of the CHI, the same way as users of .spec.configuration.users are.
+type ClickHouseUser struct {
+	meta.TypeMeta   `json:",inline"            yaml:",inline"`
+	meta.ObjectMeta `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+
+	Spec   ChuSpec    `json:"spec"             yaml:"spec"`
+	Status *ChuStatus `json:"status,omitempty" yaml:"status,omitempty"`
+}
+
+// ChuSpec defines spec section of ClickHouseUser resource
+type ChuSpec struct {
+	// CHI is the name of the ClickHouseInstallation in the same namespace the user belongs to
+	CHI string `json:"chi" yaml:"chi"`
+	// Username is the name of the user in ClickHouse. Name of the resource is used in case not specified
+	Username string `json:"username,omitempty" yaml:"username,omitempty"`
+	// PasswordSHA256Hex is SHA256 hash of the password
+	PasswordSHA256Hex string `json:"passwordSHA256Hex,omitempty" yaml:"passwordSHA256Hex,omitempty"`
+	// PasswordSecretRef refers to the key of the Secret in the same namespace the plaintext password is kept in
+	PasswordSecretRef *core.SecretKeySelector `json:"passwordSecretRef,omitempty" yaml:"passwordSecretRef,omitempty"`
+	// Profile is the name of the settings profile of the user
+	Profile string `json:"profile,omitempty" yaml:"profile,omitempty"`
+	// Quota is the name of the quota of the user
+	Quota string `json:"quota,omitempty" yaml:"quota,omitempty"`
+	// Networks specifies networks the user is allowed to connect from
+	Networks *ChuNetworks `json:"networks,omitempty" yaml:"networks,omitempty"`
+	// Grants are GRANT statements without grantee, such as "GRANT SELECT ON db.*"
+	Grants []string `json:"grants,omitempty" yaml:"grants,omitempty"`
+}
+
+// ChuNetworks defines networks the user is allowed to connect from
+type ChuNetworks struct {
+	IP         []string `json:"ip,omitempty"         yaml:"ip,omitempty"`
+	HostRegexp string   `json:"hostRegexp,omitempty" yaml:"hostRegexp,omitempty"`
+}
+
+// ChuStatus defines status section of ClickHouseUser resource
+type ChuStatus struct {
+	Status string `json:"status,omitempty" yaml:"status,omitempty"`
+	Error  string `json:"error,omitempty"  yaml:"error,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClickHouseUserList defines a list of ClickHouseUser resources
+type ClickHouseUserList struct {
+	meta.TypeMeta `json:",inline"  yaml:",inline"`
+	meta.ListMeta `json:"metadata" yaml:"metadata"`
+	Items         []ClickHouseUser `json:"items" yaml:"items"`
+}
+
+// GetUsername gets name of the user in ClickHouse
+func (chu *ClickHouseUser) GetUsername() string {
+	if chu.Spec.Username != "" {
+		return chu.Spec.Username
+	}
+	return chu.Name
+}
+
+// SetApplied marks user as rendered into users config of the CHI
+func (chu *ClickHouseUser) SetApplied() {
+	chu.Status = &ChuStatus{
+		Status: UserStatusApplied,
+	}
+}
+
+// SetRejected marks user as not rendered into users config of the CHI
+func (chu *ClickHouseUser) SetRejected(err string) {
+	chu.Status = &ChuStatus{
+		Status: UserStatusRejected,
+		Error:  err,
+	}
+}
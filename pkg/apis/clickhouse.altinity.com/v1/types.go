@@ -20,6 +20,7 @@ import (
 	"time"
 
 	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -51,6 +52,14 @@ type ClickHouseInstallation struct {
 type ClickHouseInstallationRuntime struct {
 	attributes        *ComparableAttributes `json:"-" yaml:"-"`
 	commonConfigMutex sync.Mutex            `json:"-" yaml:"-"`
+	// pendingGeneratedPasswords specifies users, passwords of which are to be generated, since the Secret does not have them
+	pendingGeneratedPasswords []string `json:"-" yaml:"-"`
+	// generatedPasswordsError specifies error the Secret with generated passwords is not available with
+	generatedPasswordsError error `json:"-" yaml:"-"`
+	// interserverCredentials specifies state of interserver credentials hosts are to run with
+	interserverCredentials *InterserverCredentialsState `json:"-" yaml:"-"`
+	// referencedSecrets specifies secrets, values of which are rendered into config, as 'namespace/name'
+	referencedSecrets []string `json:"-" yaml:"-"`
 }
 
 func newClickHouseInstallationRuntime() *ClickHouseInstallationRuntime {
@@ -71,6 +80,53 @@ func (runtime *ClickHouseInstallationRuntime) UnlockCommonConfig() {
 	runtime.commonConfigMutex.Unlock()
 }
 
+// AddPendingGeneratedPassword adds the user, password of which is to be generated
+func (runtime *ClickHouseInstallationRuntime) AddPendingGeneratedPassword(username string) {
+	runtime.pendingGeneratedPasswords = append(runtime.pendingGeneratedPasswords, username)
+}
+
+// GetPendingGeneratedPasswords gets users, passwords of which are to be generated
+func (runtime *ClickHouseInstallationRuntime) GetPendingGeneratedPasswords() []string {
+	return runtime.pendingGeneratedPasswords
+}
+
+// SetGeneratedPasswordsError sets error the Secret with generated passwords is not available with
+func (runtime *ClickHouseInstallationRuntime) SetGeneratedPasswordsError(err error) {
+	runtime.generatedPasswordsError = err
+}
+
+// GetGeneratedPasswordsError gets error the Secret with generated passwords is not available with
+func (runtime *ClickHouseInstallationRuntime) GetGeneratedPasswordsError() error {
+	return runtime.generatedPasswordsError
+}
+
+// SetInterserverCredentials sets state of interserver credentials hosts are to run with
+func (runtime *ClickHouseInstallationRuntime) SetInterserverCredentials(state *InterserverCredentialsState) {
+	runtime.interserverCredentials = state
+}
+
+// GetInterserverCredentials gets state of interserver credentials hosts are to run with.
+// Returns nil in case interserver credentials are not managed
+func (runtime *ClickHouseInstallationRuntime) GetInterserverCredentials() *InterserverCredentialsState {
+	return runtime.interserverCredentials
+}
+
+// AddReferencedSecret adds the secret, values of which are rendered into config
+func (runtime *ClickHouseInstallationRuntime) AddReferencedSecret(namespace, name string) {
+	key := namespace + "/" + name
+	for _, secret := range runtime.referencedSecrets {
+		if secret == key {
+			return
+		}
+	}
+	runtime.referencedSecrets = append(runtime.referencedSecrets, key)
+}
+
+// GetReferencedSecrets gets secrets, values of which are rendered into config, as 'namespace/name'
+func (runtime *ClickHouseInstallationRuntime) GetReferencedSecrets() []string {
+	return runtime.referencedSecrets
+}
+
 // ComparableAttributes specifies CHI attributes that are comparable
 type ComparableAttributes struct {
 	AdditionalEnvVars      []core.EnvVar      `json:"-" yaml:"-"`
@@ -100,17 +156,24 @@ type ClickHouseOperatorConfiguration struct {
 
 // ChiSpec defines spec section of ClickHouseInstallation resource
 type ChiSpec struct {
-	TaskID                 *string         `json:"taskID,omitempty"                 yaml:"taskID,omitempty"`
-	Stop                   *StringBool     `json:"stop,omitempty"                   yaml:"stop,omitempty"`
-	Restart                string          `json:"restart,omitempty"                yaml:"restart,omitempty"`
-	Troubleshoot           *StringBool     `json:"troubleshoot,omitempty"           yaml:"troubleshoot,omitempty"`
-	NamespaceDomainPattern string          `json:"namespaceDomainPattern,omitempty" yaml:"namespaceDomainPattern,omitempty"`
-	Templating             *ChiTemplating  `json:"templating,omitempty"             yaml:"templating,omitempty"`
-	Reconciling            *ChiReconciling `json:"reconciling,omitempty"            yaml:"reconciling,omitempty"`
-	Defaults               *ChiDefaults    `json:"defaults,omitempty"               yaml:"defaults,omitempty"`
-	Configuration          *Configuration  `json:"configuration,omitempty"          yaml:"configuration,omitempty"`
-	Templates              *Templates      `json:"templates,omitempty"              yaml:"templates,omitempty"`
-	UseTemplates           []*TemplateRef  `json:"useTemplates,omitempty"           yaml:"useTemplates,omitempty"`
+	TaskID                 *string           `json:"taskID,omitempty"                 yaml:"taskID,omitempty"`
+	Stop                   *StringBool       `json:"stop,omitempty"                   yaml:"stop,omitempty"`
+	Restart                string            `json:"restart,omitempty"                yaml:"restart,omitempty"`
+	Troubleshoot           *StringBool       `json:"troubleshoot,omitempty"           yaml:"troubleshoot,omitempty"`
+	NamespaceDomainPattern string            `json:"namespaceDomainPattern,omitempty" yaml:"namespaceDomainPattern,omitempty"`
+	DNS                    *ChiDNS           `json:"dns,omitempty"                    yaml:"dns,omitempty"`
+	Access                 *ChiAccess        `json:"access,omitempty"                 yaml:"access,omitempty"`
+	CertManager            *ChiCertManager   `json:"certManager,omitempty"            yaml:"certManager,omitempty"`
+	NetworkPolicy          *ChiNetworkPolicy `json:"networkPolicy,omitempty"          yaml:"networkPolicy,omitempty"`
+	Scale                  *ChiScale         `json:"scale,omitempty"                  yaml:"scale,omitempty"`
+	RevisionHistoryLimit   *int32            `json:"revisionHistoryLimit,omitempty"   yaml:"revisionHistoryLimit,omitempty"`
+	RolloutBreakpoint      string            `json:"rolloutBreakpoint,omitempty"      yaml:"rolloutBreakpoint,omitempty"`
+	Templating             *ChiTemplating    `json:"templating,omitempty"             yaml:"templating,omitempty"`
+	Reconciling            *ChiReconciling   `json:"reconciling,omitempty"            yaml:"reconciling,omitempty"`
+	Defaults               *ChiDefaults      `json:"defaults,omitempty"               yaml:"defaults,omitempty"`
+	Configuration          *Configuration    `json:"configuration,omitempty"          yaml:"configuration,omitempty"`
+	Templates              *Templates        `json:"templates,omitempty"              yaml:"templates,omitempty"`
+	UseTemplates           []*TemplateRef    `json:"useTemplates,omitempty"           yaml:"useTemplates,omitempty"`
 }
 
 // TemplateRef defines UseTemplate section of ClickHouseInstallation resource
@@ -379,6 +442,218 @@ func (c *ChiObjectsCleanup) SetSecret(v string) *ChiObjectsCleanup {
 	return c
 }
 
+// ChiMembershipWebhook defines external endpoint, such as a load balancer or a service mesh registry,
+// to be notified about hosts excluded from and included into the cluster
+type ChiMembershipWebhook struct {
+	// URL of the endpoint. Host-level macros, such as {chi}, {cluster}, {shard}, {replica}, {host}, are expanded
+	URL string `json:"url,omitempty" yaml:"url,omitempty"`
+	// Timeout specifies timeout of a call in seconds
+	Timeout int `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// NewChiMembershipWebhook creates new membership webhook
+func NewChiMembershipWebhook() *ChiMembershipWebhook {
+	return new(ChiMembershipWebhook)
+}
+
+// MergeFrom merges from specified membership webhook
+func (t *ChiMembershipWebhook) MergeFrom(from *ChiMembershipWebhook, _type MergeType) *ChiMembershipWebhook {
+	if from == nil {
+		return t
+	}
+
+	if t == nil {
+		t = NewChiMembershipWebhook()
+	}
+
+	switch _type {
+	case MergeTypeFillEmptyValues:
+		if t.URL == "" {
+			t.URL = from.URL
+		}
+		if t.Timeout == 0 {
+			t.Timeout = from.Timeout
+		}
+	case MergeTypeOverrideByNonEmptyValues:
+		if from.URL != "" {
+			// Override by non-empty values only
+			t.URL = from.URL
+		}
+		if from.Timeout != 0 {
+			// Override by non-empty values only
+			t.Timeout = from.Timeout
+		}
+	}
+
+	return t
+}
+
+// IsEnabled checks whether membership webhook is specified
+func (t *ChiMembershipWebhook) IsEnabled() bool {
+	if t == nil {
+		return false
+	}
+	return t.URL != ""
+}
+
+// GetTimeout gets timeout of a call
+func (t *ChiMembershipWebhook) GetTimeout() time.Duration {
+	if t == nil {
+		return 0
+	}
+	return time.Duration(t.Timeout) * time.Second
+}
+
+// ChiReadinessQuery defines SQL query, which has to return truthy result on the host
+// before the host is included into the cluster
+type ChiReadinessQuery struct {
+	// Query to run on the host. Non-zero number or "true" is considered to be truthy result
+	Query string `json:"query,omitempty" yaml:"query,omitempty"`
+	// Timeout specifies how long to wait for the query to return truthy result, in seconds
+	Timeout int `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// defaultReadinessQueryTimeout specifies how long to wait for readiness query by default
+const defaultReadinessQueryTimeout = 5 * time.Minute
+
+// NewChiReadinessQuery creates new readiness query
+func NewChiReadinessQuery() *ChiReadinessQuery {
+	return new(ChiReadinessQuery)
+}
+
+// MergeFrom merges from specified readiness query
+func (t *ChiReadinessQuery) MergeFrom(from *ChiReadinessQuery, _type MergeType) *ChiReadinessQuery {
+	if from == nil {
+		return t
+	}
+
+	if t == nil {
+		t = NewChiReadinessQuery()
+	}
+
+	switch _type {
+	case MergeTypeFillEmptyValues:
+		if t.Query == "" {
+			t.Query = from.Query
+		}
+		if t.Timeout == 0 {
+			t.Timeout = from.Timeout
+		}
+	case MergeTypeOverrideByNonEmptyValues:
+		if from.Query != "" {
+			// Override by non-empty values only
+			t.Query = from.Query
+		}
+		if from.Timeout != 0 {
+			// Override by non-empty values only
+			t.Timeout = from.Timeout
+		}
+	}
+
+	return t
+}
+
+// IsEnabled checks whether readiness query is specified
+func (t *ChiReadinessQuery) IsEnabled() bool {
+	if t == nil {
+		return false
+	}
+	return t.Query != ""
+}
+
+// GetTimeout gets how long to wait for the query to return truthy result
+func (t *ChiReadinessQuery) GetTimeout() time.Duration {
+	if t == nil {
+		return 0
+	}
+	if t.Timeout <= 0 {
+		return defaultReadinessQueryTimeout
+	}
+	return time.Duration(t.Timeout) * time.Second
+}
+
+const (
+	// HostFailureActionAbort aborts reconcile of the CHI on the first failed host
+	HostFailureActionAbort = "abort"
+	// HostFailureActionContinue records failure of the host and proceeds with the rest of the hosts
+	HostFailureActionContinue = "continue"
+	// HostFailureActionRetry retries reconcile of the failed host and aborts reconcile of the CHI
+	// in case the host does not succeed within specified number of retries
+	HostFailureActionRetry = "retry"
+)
+
+// defaultHostFailureRetries specifies how many times failed host is retried by default
+const defaultHostFailureRetries = 3
+
+// ChiHostFailurePolicy defines what to do in case reconcile of a host fails
+type ChiHostFailurePolicy struct {
+	// Action specifies what to do with the failed host. One of: abort, continue, retry
+	Action string `json:"action,omitempty" yaml:"action,omitempty"`
+	// Retries specifies how many times reconcile of the failed host is retried, applicable to "retry" action only
+	Retries int `json:"retries,omitempty" yaml:"retries,omitempty"`
+}
+
+// NewChiHostFailurePolicy creates new host failure policy
+func NewChiHostFailurePolicy() *ChiHostFailurePolicy {
+	return new(ChiHostFailurePolicy)
+}
+
+// MergeFrom merges from specified host failure policy
+func (t *ChiHostFailurePolicy) MergeFrom(from *ChiHostFailurePolicy, _type MergeType) *ChiHostFailurePolicy {
+	if from == nil {
+		return t
+	}
+
+	if t == nil {
+		t = NewChiHostFailurePolicy()
+	}
+
+	switch _type {
+	case MergeTypeFillEmptyValues:
+		if t.Action == "" {
+			t.Action = from.Action
+		}
+		if t.Retries == 0 {
+			t.Retries = from.Retries
+		}
+	case MergeTypeOverrideByNonEmptyValues:
+		if from.Action != "" {
+			// Override by non-empty values only
+			t.Action = from.Action
+		}
+		if from.Retries != 0 {
+			// Override by non-empty values only
+			t.Retries = from.Retries
+		}
+	}
+
+	return t
+}
+
+// GetAction gets what to do with the failed host. Abort is the default
+func (t *ChiHostFailurePolicy) GetAction() string {
+	if t == nil {
+		return HostFailureActionAbort
+	}
+	switch action := strings.ToLower(t.Action); action {
+	case HostFailureActionContinue, HostFailureActionRetry:
+		return action
+	default:
+		return HostFailureActionAbort
+	}
+}
+
+// GetRetries gets how many times reconcile of the failed host is retried
+func (t *ChiHostFailurePolicy) GetRetries() int {
+	if t.GetAction() != HostFailureActionRetry {
+		return 0
+	}
+	if t.Retries <= 0 {
+		return defaultHostFailureRetries
+	}
+	return t.Retries
+}
+
 // ChiCleanup defines cleanup
 type ChiCleanup struct {
 	// UnknownObjects specifies cleanup of unknown objects
@@ -465,6 +740,28 @@ type ChiReconciling struct {
 	ConfigMapPropagationTimeout int `json:"configMapPropagationTimeout,omitempty" yaml:"configMapPropagationTimeout,omitempty"`
 	// Cleanup specifies cleanup behavior
 	Cleanup *ChiCleanup `json:"cleanup,omitempty" yaml:"cleanup,omitempty"`
+	// MembershipWebhook specifies external endpoint to be notified about hosts excluded from and included into the cluster
+	MembershipWebhook *ChiMembershipWebhook `json:"membershipWebhook,omitempty" yaml:"membershipWebhook,omitempty"`
+	// PlanApproval specifies whether reconcile has to wait for ActionPlan to be approved via annotation
+	PlanApproval *StringBool `json:"planApproval,omitempty" yaml:"planApproval,omitempty"`
+	// ReadinessQuery specifies SQL query, which gates inclusion of the host into the cluster
+	ReadinessQuery *ChiReadinessQuery `json:"readinessQuery,omitempty" yaml:"readinessQuery,omitempty"`
+	// MaxConcurrentHosts specifies max number of hosts reconciled concurrently.
+	// Replicas of a shard are reconciled sequentially, so hosts of different shards are reconciled in parallel.
+	// Is capped by operator's reconcileShardsThreadsNumber
+	MaxConcurrentHosts int `json:"maxConcurrentHosts,omitempty" yaml:"maxConcurrentHosts,omitempty"`
+	// DryRun specifies whether reconcile only publishes ActionPlan, without applying anything
+	DryRun *StringBool `json:"dryRun,omitempty" yaml:"dryRun,omitempty"`
+	// Strategy specifies how hosts are rolled out
+	Strategy string `json:"strategy,omitempty" yaml:"strategy,omitempty"`
+	// CanarySoakPeriod specifies how long (in seconds) canary host runs before it is verified and rollout proceeds
+	CanarySoakPeriod int `json:"canarySoakPeriod,omitempty" yaml:"canarySoakPeriod,omitempty"`
+	// HostTimeout specifies how long (in seconds) reconcile of a single host may take
+	HostTimeout int `json:"hostTimeout,omitempty" yaml:"hostTimeout,omitempty"`
+	// OnHostTimeout specifies what to do in case reconcile of a host does not complete in time
+	OnHostTimeout string `json:"onHostTimeout,omitempty" yaml:"onHostTimeout,omitempty"`
+	// OnHostFailure specifies what to do in case reconcile of a host fails
+	OnHostFailure *ChiHostFailurePolicy `json:"onHostFailure,omitempty" yaml:"onHostFailure,omitempty"`
 }
 
 // NewChiReconciling creates new reconciling
@@ -490,6 +787,21 @@ func (t *ChiReconciling) MergeFrom(from *ChiReconciling, _type MergeType) *ChiRe
 		if t.ConfigMapPropagationTimeout == 0 {
 			t.ConfigMapPropagationTimeout = from.ConfigMapPropagationTimeout
 		}
+		if t.MaxConcurrentHosts == 0 {
+			t.MaxConcurrentHosts = from.MaxConcurrentHosts
+		}
+		if t.Strategy == "" {
+			t.Strategy = from.Strategy
+		}
+		if t.CanarySoakPeriod == 0 {
+			t.CanarySoakPeriod = from.CanarySoakPeriod
+		}
+		if t.HostTimeout == 0 {
+			t.HostTimeout = from.HostTimeout
+		}
+		if t.OnHostTimeout == "" {
+			t.OnHostTimeout = from.OnHostTimeout
+		}
 	case MergeTypeOverrideByNonEmptyValues:
 		if from.Policy != "" {
 			// Override by non-empty values only
@@ -499,9 +811,34 @@ func (t *ChiReconciling) MergeFrom(from *ChiReconciling, _type MergeType) *ChiRe
 			// Override by non-empty values only
 			t.ConfigMapPropagationTimeout = from.ConfigMapPropagationTimeout
 		}
+		if from.MaxConcurrentHosts != 0 {
+			// Override by non-empty values only
+			t.MaxConcurrentHosts = from.MaxConcurrentHosts
+		}
+		if from.Strategy != "" {
+			// Override by non-empty values only
+			t.Strategy = from.Strategy
+		}
+		if from.CanarySoakPeriod != 0 {
+			// Override by non-empty values only
+			t.CanarySoakPeriod = from.CanarySoakPeriod
+		}
+		if from.HostTimeout != 0 {
+			// Override by non-empty values only
+			t.HostTimeout = from.HostTimeout
+		}
+		if from.OnHostTimeout != "" {
+			// Override by non-empty values only
+			t.OnHostTimeout = from.OnHostTimeout
+		}
 	}
 
 	t.Cleanup = t.Cleanup.MergeFrom(from.Cleanup, _type)
+	t.MembershipWebhook = t.MembershipWebhook.MergeFrom(from.MembershipWebhook, _type)
+	t.PlanApproval = t.PlanApproval.MergeFrom(from.PlanApproval)
+	t.ReadinessQuery = t.ReadinessQuery.MergeFrom(from.ReadinessQuery, _type)
+	t.DryRun = t.DryRun.MergeFrom(from.DryRun)
+	t.OnHostFailure = t.OnHostFailure.MergeFrom(from.OnHostFailure, _type)
 
 	return t
 }
@@ -517,6 +854,46 @@ func (t *ChiReconciling) SetDefaults() *ChiReconciling {
 	return t
 }
 
+// GetMembershipWebhook gets membership webhook
+func (t *ChiReconciling) GetMembershipWebhook() *ChiMembershipWebhook {
+	if t == nil {
+		return nil
+	}
+	return t.MembershipWebhook
+}
+
+// GetReadinessQuery gets readiness query
+func (t *ChiReconciling) GetReadinessQuery() *ChiReadinessQuery {
+	if t == nil {
+		return nil
+	}
+	return t.ReadinessQuery
+}
+
+// GetMaxConcurrentHosts gets max number of hosts reconciled concurrently. 0 means not specified
+func (t *ChiReconciling) GetMaxConcurrentHosts() int {
+	if t == nil {
+		return 0
+	}
+	return t.MaxConcurrentHosts
+}
+
+// IsPlanApprovalRequired checks whether reconcile has to wait for ActionPlan to be approved
+func (t *ChiReconciling) IsPlanApprovalRequired() bool {
+	if t == nil {
+		return false
+	}
+	return t.PlanApproval.IsTrue()
+}
+
+// IsDryRun checks whether reconcile only publishes ActionPlan, without applying anything
+func (t *ChiReconciling) IsDryRun() bool {
+	if t == nil {
+		return false
+	}
+	return t.DryRun.IsTrue()
+}
+
 // GetPolicy gets policy
 func (t *ChiReconciling) GetPolicy() string {
 	if t == nil {
@@ -564,6 +941,66 @@ const (
 	ReconcilingPolicyNoWait      = "nowait"
 )
 
+// Possible reconcile strategy values
+const (
+	// ReconcilingStrategyRolling rolls hosts out one pass, shard by shard
+	ReconcilingStrategyRolling = "rolling"
+	// ReconcilingStrategyCanary rolls out one host of each cluster first and proceeds with the rest of the hosts
+	// only after the canary host survives soak period and passes verification
+	ReconcilingStrategyCanary = "canary"
+)
+
+// defaultCanarySoakPeriod specifies how long canary host runs before verification by default
+const defaultCanarySoakPeriod = 5 * time.Minute
+
+// GetStrategy gets reconcile strategy
+func (t *ChiReconciling) GetStrategy() string {
+	if t == nil {
+		return ""
+	}
+	return t.Strategy
+}
+
+// IsReconcilingStrategyCanary checks whether reconcile strategy is "canary"
+func (t *ChiReconciling) IsReconcilingStrategyCanary() bool {
+	return strings.ToLower(t.GetStrategy()) == ReconcilingStrategyCanary
+}
+
+// GetCanarySoakPeriod gets how long canary host runs before verification
+func (t *ChiReconciling) GetCanarySoakPeriod() time.Duration {
+	if t == nil {
+		return 0
+	}
+	if t.CanarySoakPeriod <= 0 {
+		return defaultCanarySoakPeriod
+	}
+	return time.Duration(t.CanarySoakPeriod) * time.Second
+}
+
+// GetHostTimeout gets how long reconcile of a single host may take. 0 means not specified
+func (t *ChiReconciling) GetHostTimeout() time.Duration {
+	if t == nil {
+		return 0
+	}
+	return time.Duration(t.HostTimeout) * time.Second
+}
+
+// GetOnHostTimeout gets what to do in case reconcile of a host does not complete in time
+func (t *ChiReconciling) GetOnHostTimeout() string {
+	if t == nil {
+		return ""
+	}
+	return t.OnHostTimeout
+}
+
+// GetOnHostFailure gets what to do in case reconcile of a host fails
+func (t *ChiReconciling) GetOnHostFailure() *ChiHostFailurePolicy {
+	if t == nil {
+		return nil
+	}
+	return t.OnHostFailure
+}
+
 // IsReconcilingPolicyWait checks whether reconcile policy is "wait"
 func (t *ChiReconciling) IsReconcilingPolicyWait() bool {
 	return strings.ToLower(t.GetPolicy()) == ReconcilingPolicyWait
@@ -604,6 +1041,7 @@ type ChiShard struct {
 	Name                string            `json:"name,omitempty"                yaml:"name,omitempty"`
 	Weight              *int              `json:"weight,omitempty"              yaml:"weight,omitempty"`
 	InternalReplication *StringBool       `json:"internalReplication,omitempty" yaml:"internalReplication,omitempty"`
+	Maintenance         *StringBool       `json:"maintenance,omitempty"         yaml:"maintenance,omitempty"`
 	Settings            *Settings         `json:"settings,omitempty"            yaml:"settings,omitempty"`
 	Files               *Settings         `json:"files,omitempty"               yaml:"files,omitempty"`
 	Templates           *ChiTemplateNames `json:"templates,omitempty"           yaml:"templates,omitempty"`
@@ -701,8 +1139,68 @@ type PodTemplate struct {
 	GenerateName    string            `json:"generateName,omitempty"    yaml:"generateName,omitempty"`
 	Zone            PodTemplateZone   `json:"zone,omitempty"            yaml:"zone,omitempty"`
 	PodDistribution []PodDistribution `json:"podDistribution,omitempty" yaml:"podDistribution,omitempty"`
-	ObjectMeta      meta.ObjectMeta   `json:"metadata,omitempty"        yaml:"metadata,omitempty"`
-	Spec            core.PodSpec      `json:"spec,omitempty"            yaml:"spec,omitempty"`
+	// ImagePullPolicy is applied to all containers of the pod, which do not specify own policy
+	ImagePullPolicy core.PullPolicy `json:"imagePullPolicy,omitempty" yaml:"imagePullPolicy,omitempty"`
+	// ImagePullSecrets are appended to .spec.imagePullSecrets of the pod
+	ImagePullSecrets []core.LocalObjectReference `json:"imagePullSecrets,omitempty" yaml:"imagePullSecrets,omitempty"`
+	// ServiceAccountName overrides .spec.serviceAccountName of the pod
+	ServiceAccountName string `json:"serviceAccountName,omitempty" yaml:"serviceAccountName,omitempty"`
+	// ManageServiceAccount specifies whether ServiceAccount is created and deleted by the operator along with the CHI
+	ManageServiceAccount *StringBool `json:"manageServiceAccount,omitempty" yaml:"manageServiceAccount,omitempty"`
+	// ServiceAccountAnnotations are applied to ServiceAccount managed by the operator, ex.: IAM role to be assumed by the pod
+	ServiceAccountAnnotations map[string]string `json:"serviceAccountAnnotations,omitempty" yaml:"serviceAccountAnnotations,omitempty"`
+	// Volumes are appended to .spec.volumes of the pod, ex.: ConfigMaps with dictionaries or UDFs
+	Volumes []core.Volume `json:"volumes,omitempty" yaml:"volumes,omitempty"`
+	// VolumeMounts are appended to volume mounts of the ClickHouse container
+	VolumeMounts []core.VolumeMount `json:"volumeMounts,omitempty" yaml:"volumeMounts,omitempty"`
+	// Env is appended to env of the ClickHouse container, ex.: credentials from Secret.
+	// Env vars specified by the operator or explicitly in the container prevail over the ones with the same name.
+	Env []core.EnvVar `json:"env,omitempty" yaml:"env,omitempty"`
+	// EnvFrom is appended to envFrom of the ClickHouse container
+	EnvFrom []core.EnvFromSource `json:"envFrom,omitempty" yaml:"envFrom,omitempty"`
+	// EphemeralStorage specifies ephemeral storage of the ClickHouse container and its tmp/cache directories
+	EphemeralStorage *PodTemplateEphemeralStorage `json:"ephemeralStorage,omitempty" yaml:"ephemeralStorage,omitempty"`
+	// SecurityContext specifies security context of the pod and the ClickHouse container
+	SecurityContext *PodTemplateSecurityContext `json:"securityContext,omitempty" yaml:"securityContext,omitempty"`
+	// Probes specifies explicit timings of the ClickHouse container probes, which always win over computed ones
+	Probes     *PodTemplateProbes `json:"probes,omitempty"   yaml:"probes,omitempty"`
+	ObjectMeta meta.ObjectMeta    `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	Spec       core.PodSpec       `json:"spec,omitempty"     yaml:"spec,omitempty"`
+}
+
+// PodTemplateEphemeralStorage defines ephemeral storage of the ClickHouse container
+type PodTemplateEphemeralStorage struct {
+	// Request specifies ephemeral-storage request of the ClickHouse container
+	Request *resource.Quantity `json:"request,omitempty" yaml:"request,omitempty"`
+	// Limit specifies ephemeral-storage limit of the ClickHouse container
+	Limit *resource.Quantity `json:"limit,omitempty" yaml:"limit,omitempty"`
+	// Tmp specifies emptyDir volume to be mounted at ClickHouse tmp path
+	Tmp *core.EmptyDirVolumeSource `json:"tmp,omitempty" yaml:"tmp,omitempty"`
+	// Cache specifies emptyDir volume to be mounted at ClickHouse cache path
+	Cache *core.EmptyDirVolumeSource `json:"cache,omitempty" yaml:"cache,omitempty"`
+}
+
+// PodTemplateSecurityContext defines security context of the pod and the ClickHouse container
+type PodTemplateSecurityContext struct {
+	// Pod overrides .spec.securityContext of the pod, ex.: runAsNonRoot with fsGroup to make PVCs writable.
+	// Consider fsGroupChangePolicy: OnRootMismatch to avoid recursive chown of existing PVCs on every pod start.
+	Pod *core.PodSecurityContext `json:"pod,omitempty" yaml:"pod,omitempty"`
+	// Container overrides securityContext of the ClickHouse container
+	Container *core.SecurityContext `json:"container,omitempty" yaml:"container,omitempty"`
+}
+
+// PodTemplateProbes defines explicit timings of the ClickHouse container probes
+type PodTemplateProbes struct {
+	Readiness *PodTemplateProbe `json:"readiness,omitempty" yaml:"readiness,omitempty"`
+	Startup   *PodTemplateProbe `json:"startup,omitempty"   yaml:"startup,omitempty"`
+}
+
+// PodTemplateProbe defines explicit timings of a probe. Unspecified fields keep their computed values
+type PodTemplateProbe struct {
+	InitialDelaySeconds *int32 `json:"initialDelaySeconds,omitempty" yaml:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       *int32 `json:"periodSeconds,omitempty"       yaml:"periodSeconds,omitempty"`
+	TimeoutSeconds      *int32 `json:"timeoutSeconds,omitempty"      yaml:"timeoutSeconds,omitempty"`
+	FailureThreshold    *int32 `json:"failureThreshold,omitempty"    yaml:"failureThreshold,omitempty"`
 }
 
 // PodTemplateZone defines pod template zone
@@ -721,10 +1219,15 @@ type PodDistribution struct {
 
 // ServiceTemplate defines CHI service template
 type ServiceTemplate struct {
-	Name         string           `json:"name"                   yaml:"name"`
-	GenerateName string           `json:"generateName,omitempty" yaml:"generateName,omitempty"`
-	ObjectMeta   meta.ObjectMeta  `json:"metadata,omitempty"     yaml:"metadata,omitempty"`
-	Spec         core.ServiceSpec `json:"spec,omitempty"         yaml:"spec,omitempty"`
+	Name         string `json:"name"                   yaml:"name"`
+	GenerateName string `json:"generateName,omitempty" yaml:"generateName,omitempty"`
+	// SessionAffinity overrides .spec.sessionAffinity of the service.
+	// Unless specified here or in .spec, session affinity of already existing service is preserved.
+	SessionAffinity core.ServiceAffinity `json:"sessionAffinity,omitempty" yaml:"sessionAffinity,omitempty"`
+	// SessionAffinityConfig overrides .spec.sessionAffinityConfig of the service, ex.: ClientIP timeout
+	SessionAffinityConfig *core.SessionAffinityConfig `json:"sessionAffinityConfig,omitempty" yaml:"sessionAffinityConfig,omitempty"`
+	ObjectMeta            meta.ObjectMeta             `json:"metadata,omitempty"     yaml:"metadata,omitempty"`
+	Spec                  core.ServiceSpec            `json:"spec,omitempty"         yaml:"spec,omitempty"`
 }
 
 // ChiDistributedDDL defines distributedDDL section of .spec.defaults
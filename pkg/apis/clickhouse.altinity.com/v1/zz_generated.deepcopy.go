@@ -25,6 +25,8 @@ import (
 	swversion "github.com/altinity/clickhouse-operator/pkg/apis/swversion"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -50,6 +52,230 @@ func (in CHISelector) DeepCopy() CHISelector {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChbHostStatus) DeepCopyInto(out *ChbHostStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChbHostStatus.
+func (in *ChbHostStatus) DeepCopy() *ChbHostStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChbHostStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChbRetention) DeepCopyInto(out *ChbRetention) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChbRetention.
+func (in *ChbRetention) DeepCopy() *ChbRetention {
+	if in == nil {
+		return nil
+	}
+	out := new(ChbRetention)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChbSpec) DeepCopyInto(out *ChbSpec) {
+	*out = *in
+	if in.Upload != nil {
+		in, out := &in.Upload, &out.Upload
+		*out = new(StringBool)
+		**out = **in
+	}
+	if in.Retention != nil {
+		in, out := &in.Retention, &out.Retention
+		*out = new(ChbRetention)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChbSpec.
+func (in *ChbSpec) DeepCopy() *ChbSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ChbSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChbStatus) DeepCopyInto(out *ChbStatus) {
+	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make(map[string]*ChbHostStatus, len(*in))
+		for key, val := range *in {
+			var outVal *ChbHostStatus
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(ChbHostStatus)
+				**out = **in
+			}
+			(*out)[key] = outVal
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChbStatus.
+func (in *ChbStatus) DeepCopy() *ChbStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChbStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiAccess) DeepCopyInto(out *ChiAccess) {
+	*out = *in
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(ChiAccessTLS)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MetricsCredentials != nil {
+		in, out := &in.MetricsCredentials, &out.MetricsCredentials
+		*out = new(ChiAccessCredentials)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiAccess.
+func (in *ChiAccess) DeepCopy() *ChiAccess {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiAccess)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiAccessCredentials) DeepCopyInto(out *ChiAccessCredentials) {
+	*out = *in
+	if in.Username != nil {
+		in, out := &in.Username, &out.Username
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Password != nil {
+		in, out := &in.Password, &out.Password
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiAccessCredentials.
+func (in *ChiAccessCredentials) DeepCopy() *ChiAccessCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiAccessCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiAccessTLS) DeepCopyInto(out *ChiAccessTLS) {
+	*out = *in
+	if in.CA != nil {
+		in, out := &in.CA, &out.CA
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Cert != nil {
+		in, out := &in.Cert, &out.Cert
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Key != nil {
+		in, out := &in.Key, &out.Key
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InsecureSkipVerify != nil {
+		in, out := &in.InsecureSkipVerify, &out.InsecureSkipVerify
+		*out = new(StringBool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiAccessTLS.
+func (in *ChiAccessTLS) DeepCopy() *ChiAccessTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiAccessTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiCertManager) DeepCopyInto(out *ChiCertManager) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(StringBool)
+		**out = **in
+	}
+	if in.IssuerRef != nil {
+		in, out := &in.IssuerRef, &out.IssuerRef
+		*out = new(ChiCertManagerIssuerRef)
+		**out = **in
+	}
+	if in.InterserverSecure != nil {
+		in, out := &in.InterserverSecure, &out.InterserverSecure
+		*out = new(StringBool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiCertManager.
+func (in *ChiCertManager) DeepCopy() *ChiCertManager {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiCertManager)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiCertManagerIssuerRef) DeepCopyInto(out *ChiCertManagerIssuerRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiCertManagerIssuerRef.
+func (in *ChiCertManagerIssuerRef) DeepCopy() *ChiCertManagerIssuerRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiCertManagerIssuerRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiCleanup) DeepCopyInto(out *ChiCleanup) {
 	*out = *in
@@ -127,6 +353,27 @@ func (in *ChiClusterLayout) DeepCopy() *ChiClusterLayout {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiDNS) DeepCopyInto(out *ChiDNS) {
+	*out = *in
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiDNS.
+func (in *ChiDNS) DeepCopy() *ChiDNS {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiDNS)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiDefaults) DeepCopyInto(out *ChiDefaults) {
 	*out = *in
@@ -135,6 +382,16 @@ func (in *ChiDefaults) DeepCopyInto(out *ChiDefaults) {
 		*out = new(StringBool)
 		**out = **in
 	}
+	if in.InterserverService != nil {
+		in, out := &in.InterserverService, &out.InterserverService
+		*out = new(StringBool)
+		**out = **in
+	}
+	if in.CreateDistributedTables != nil {
+		in, out := &in.CreateDistributedTables, &out.CreateDistributedTables
+		*out = new(StringBool)
+		**out = **in
+	}
 	if in.DistributedDDL != nil {
 		in, out := &in.DistributedDDL, &out.DistributedDDL
 		*out = new(ChiDistributedDDL)
@@ -150,6 +407,11 @@ func (in *ChiDefaults) DeepCopyInto(out *ChiDefaults) {
 		*out = new(ChiTemplateNames)
 		**out = **in
 	}
+	if in.Keeper != nil {
+		in, out := &in.Keeper, &out.Keeper
+		*out = new(ChiKeeper)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -207,6 +469,11 @@ func (in *ChiHost) DeepCopyInto(out *ChiHost) {
 		*out = new(ChiTemplateNames)
 		**out = **in
 	}
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(int)
+		**out = **in
+	}
 	in.Runtime.DeepCopyInto(&out.Runtime)
 	return
 }
@@ -253,6 +520,22 @@ func (in *ChiHostConfig) DeepCopy() *ChiHostConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiHostProgress) DeepCopyInto(out *ChiHostProgress) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiHostProgress.
+func (in *ChiHostProgress) DeepCopy() *ChiHostProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiHostProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiHostReconcileAttributesCounters) DeepCopyInto(out *ChiHostReconcileAttributesCounters) {
 	*out = *in
@@ -306,6 +589,13 @@ func (in *ChiHostRuntime) DeepCopyInto(out *ChiHostRuntime) {
 		*out = new(ClickHouseInstallation)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.AdoptedVolumeClaimTemplates != nil {
+		in, out := &in.AdoptedVolumeClaimTemplates, &out.AdoptedVolumeClaimTemplates
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -320,108 +610,256 @@ func (in *ChiHostRuntime) DeepCopy() *ChiHostRuntime {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiObjectsCleanup) DeepCopyInto(out *ChiObjectsCleanup) {
+func (in *ChiMembershipWebhook) DeepCopyInto(out *ChiMembershipWebhook) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiObjectsCleanup.
-func (in *ChiObjectsCleanup) DeepCopy() *ChiObjectsCleanup {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiMembershipWebhook.
+func (in *ChiMembershipWebhook) DeepCopy() *ChiMembershipWebhook {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiObjectsCleanup)
+	out := new(ChiMembershipWebhook)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiReconciling) DeepCopyInto(out *ChiReconciling) {
+func (in *ChiObjectsCleanup) DeepCopyInto(out *ChiObjectsCleanup) {
 	*out = *in
-	if in.Cleanup != nil {
-		in, out := &in.Cleanup, &out.Cleanup
-		*out = new(ChiCleanup)
-		(*in).DeepCopyInto(*out)
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiReconciling.
-func (in *ChiReconciling) DeepCopy() *ChiReconciling {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiObjectsCleanup.
+func (in *ChiObjectsCleanup) DeepCopy() *ChiObjectsCleanup {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiReconciling)
+	out := new(ChiObjectsCleanup)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiReplica) DeepCopyInto(out *ChiReplica) {
+func (in *ChiHostFailurePolicy) DeepCopyInto(out *ChiHostFailurePolicy) {
 	*out = *in
-	if in.Settings != nil {
-		in, out := &in.Settings, &out.Settings
-		*out = new(Settings)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.Files != nil {
-		in, out := &in.Files, &out.Files
-		*out = new(Settings)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.Templates != nil {
-		in, out := &in.Templates, &out.Templates
-		*out = new(ChiTemplateNames)
-		**out = **in
-	}
-	if in.Hosts != nil {
-		in, out := &in.Hosts, &out.Hosts
-		*out = make([]*ChiHost, len(*in))
-		for i := range *in {
-			if (*in)[i] != nil {
-				in, out := &(*in)[i], &(*out)[i]
-				*out = new(ChiHost)
-				(*in).DeepCopyInto(*out)
-			}
-		}
-	}
-	in.Runtime.DeepCopyInto(&out.Runtime)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiReplica.
-func (in *ChiReplica) DeepCopy() *ChiReplica {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiHostFailurePolicy.
+func (in *ChiHostFailurePolicy) DeepCopy() *ChiHostFailurePolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiReplica)
+	out := new(ChiHostFailurePolicy)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiReplicaAddress) DeepCopyInto(out *ChiReplicaAddress) {
+func (in *ChiReadinessQuery) DeepCopyInto(out *ChiReadinessQuery) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiReplicaAddress.
-func (in *ChiReplicaAddress) DeepCopy() *ChiReplicaAddress {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiReadinessQuery.
+func (in *ChiReadinessQuery) DeepCopy() *ChiReadinessQuery {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiReplicaAddress)
+	out := new(ChiReadinessQuery)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiReplicaRuntime) DeepCopyInto(out *ChiReplicaRuntime) {
+func (in *ChiNetworkPolicy) DeepCopyInto(out *ChiNetworkPolicy) {
 	*out = *in
-	out.Address = in.Address
-	if in.CHI != nil {
-		in, out := &in.CHI, &out.CHI
-		*out = new(ClickHouseInstallation)
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(StringBool)
+		**out = **in
+	}
+	if in.From != nil {
+		in, out := &in.From, &out.From
+		*out = make([]networkingv1.NetworkPolicyPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiNetworkPolicy.
+func (in *ChiNetworkPolicy) DeepCopy() *ChiNetworkPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiNetworkPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiReconciling) DeepCopyInto(out *ChiReconciling) {
+	*out = *in
+	if in.Cleanup != nil {
+		in, out := &in.Cleanup, &out.Cleanup
+		*out = new(ChiCleanup)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MembershipWebhook != nil {
+		in, out := &in.MembershipWebhook, &out.MembershipWebhook
+		*out = new(ChiMembershipWebhook)
+		**out = **in
+	}
+	if in.PlanApproval != nil {
+		in, out := &in.PlanApproval, &out.PlanApproval
+		*out = new(StringBool)
+		**out = **in
+	}
+	if in.ReadinessQuery != nil {
+		in, out := &in.ReadinessQuery, &out.ReadinessQuery
+		*out = new(ChiReadinessQuery)
+		**out = **in
+	}
+	if in.DryRun != nil {
+		in, out := &in.DryRun, &out.DryRun
+		*out = new(StringBool)
+		**out = **in
+	}
+	if in.OnHostFailure != nil {
+		in, out := &in.OnHostFailure, &out.OnHostFailure
+		*out = new(ChiHostFailurePolicy)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiReconciling.
+func (in *ChiReconciling) DeepCopy() *ChiReconciling {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiReconciling)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiInterserverCredentials) DeepCopyInto(out *ChiInterserverCredentials) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(StringBool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiInterserverCredentials.
+func (in *ChiInterserverCredentials) DeepCopy() *ChiInterserverCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiInterserverCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiKeeper) DeepCopyInto(out *ChiKeeper) {
+	*out = *in
+	if in.Settings != nil {
+		in, out := &in.Settings, &out.Settings
+		*out = new(Settings)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Templates != nil {
+		in, out := &in.Templates, &out.Templates
+		*out = new(Templates)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiKeeper.
+func (in *ChiKeeper) DeepCopy() *ChiKeeper {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiKeeper)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiReplica) DeepCopyInto(out *ChiReplica) {
+	*out = *in
+	if in.Settings != nil {
+		in, out := &in.Settings, &out.Settings
+		*out = new(Settings)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Files != nil {
+		in, out := &in.Files, &out.Files
+		*out = new(Settings)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Templates != nil {
+		in, out := &in.Templates, &out.Templates
+		*out = new(ChiTemplateNames)
+		**out = **in
+	}
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]*ChiHost, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ChiHost)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	in.Runtime.DeepCopyInto(&out.Runtime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiReplica.
+func (in *ChiReplica) DeepCopy() *ChiReplica {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiReplica)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiReplicaAddress) DeepCopyInto(out *ChiReplicaAddress) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiReplicaAddress.
+func (in *ChiReplicaAddress) DeepCopy() *ChiReplicaAddress {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiReplicaAddress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiReplicaRuntime) DeepCopyInto(out *ChiReplicaRuntime) {
+	*out = *in
+	out.Address = in.Address
+	if in.CHI != nil {
+		in, out := &in.CHI, &out.CHI
+		*out = new(ClickHouseInstallation)
 		(*in).DeepCopyInto(*out)
 	}
 	return
@@ -437,6 +875,32 @@ func (in *ChiReplicaRuntime) DeepCopy() *ChiReplicaRuntime {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiScale) DeepCopyInto(out *ChiScale) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.AllowShardsScaleDown != nil {
+		in, out := &in.AllowShardsScaleDown, &out.AllowShardsScaleDown
+		*out = new(StringBool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiScale.
+func (in *ChiScale) DeepCopy() *ChiScale {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiScale)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiShard) DeepCopyInto(out *ChiShard) {
 	*out = *in
@@ -450,6 +914,11 @@ func (in *ChiShard) DeepCopyInto(out *ChiShard) {
 		*out = new(StringBool)
 		**out = **in
 	}
+	if in.Maintenance != nil {
+		in, out := &in.Maintenance, &out.Maintenance
+		*out = new(StringBool)
+		**out = **in
+	}
 	if in.Settings != nil {
 		in, out := &in.Settings, &out.Settings
 		*out = new(Settings)
@@ -546,6 +1015,36 @@ func (in *ChiSpec) DeepCopyInto(out *ChiSpec) {
 		*out = new(StringBool)
 		**out = **in
 	}
+	if in.DNS != nil {
+		in, out := &in.DNS, &out.DNS
+		*out = new(ChiDNS)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Access != nil {
+		in, out := &in.Access, &out.Access
+		*out = new(ChiAccess)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CertManager != nil {
+		in, out := &in.CertManager, &out.CertManager
+		*out = new(ChiCertManager)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NetworkPolicy != nil {
+		in, out := &in.NetworkPolicy, &out.NetworkPolicy
+		*out = new(ChiNetworkPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Scale != nil {
+		in, out := &in.Scale, &out.Scale
+		*out = new(ChiScale)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RevisionHistoryLimit != nil {
+		in, out := &in.RevisionHistoryLimit, &out.RevisionHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
 	if in.Templating != nil {
 		in, out := &in.Templating, &out.Templating
 		*out = new(ChiTemplating)
@@ -595,6 +1094,22 @@ func (in *ChiSpec) DeepCopy() *ChiSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiStatefulSetRollout) DeepCopyInto(out *ChiStatefulSetRollout) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiStatefulSetRollout.
+func (in *ChiStatefulSetRollout) DeepCopy() *ChiStatefulSetRollout {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiStatefulSetRollout)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiStatus) DeepCopyInto(out *ChiStatus) {
 	*out = *in
@@ -659,6 +1174,55 @@ func (in *ChiStatus) DeepCopyInto(out *ChiStatus) {
 			}
 		}
 	}
+	if in.EffectiveStorage != nil {
+		in, out := &in.EffectiveStorage, &out.EffectiveStorage
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.FailedRollouts != nil {
+		in, out := &in.FailedRollouts, &out.FailedRollouts
+		*out = make(map[string]ChiStatefulSetRollout, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.StuckRollouts != nil {
+		in, out := &in.StuckRollouts, &out.StuckRollouts
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.HostFailures != nil {
+		in, out := &in.HostFailures, &out.HostFailures
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PendingCanaries != nil {
+		in, out := &in.PendingCanaries, &out.PendingCanaries
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.HostsProgress != nil {
+		in, out := &in.HostsProgress, &out.HostsProgress
+		*out = make(map[string]ChiHostProgress, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	out.mu = in.mu
 	return
 }
@@ -674,30 +1238,132 @@ func (in *ChiStatus) DeepCopy() *ChiStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiTemplateNames) DeepCopyInto(out *ChiTemplateNames) {
+func (in *ChiStorageConfiguration) DeepCopyInto(out *ChiStorageConfiguration) {
 	*out = *in
+	if in.Disks != nil {
+		in, out := &in.Disks, &out.Disks
+		*out = make([]*ChiStorageDisk, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ChiStorageDisk)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = make([]*ChiStoragePolicy, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ChiStoragePolicy)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiTemplateNames.
-func (in *ChiTemplateNames) DeepCopy() *ChiTemplateNames {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiStorageConfiguration.
+func (in *ChiStorageConfiguration) DeepCopy() *ChiStorageConfiguration {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiTemplateNames)
+	out := new(ChiStorageConfiguration)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiTemplating) DeepCopyInto(out *ChiTemplating) {
+func (in *ChiStorageDisk) DeepCopyInto(out *ChiStorageDisk) {
 	*out = *in
-	if in.CHISelector != nil {
-		in, out := &in.CHISelector, &out.CHISelector
-		*out = make(CHISelector, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiStorageDisk.
+func (in *ChiStorageDisk) DeepCopy() *ChiStorageDisk {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiStorageDisk)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiStoragePolicy) DeepCopyInto(out *ChiStoragePolicy) {
+	*out = *in
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]*ChiStoragePolicyVolume, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ChiStoragePolicyVolume)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiStoragePolicy.
+func (in *ChiStoragePolicy) DeepCopy() *ChiStoragePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiStoragePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiStoragePolicyVolume) DeepCopyInto(out *ChiStoragePolicyVolume) {
+	*out = *in
+	if in.Disks != nil {
+		in, out := &in.Disks, &out.Disks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiStoragePolicyVolume.
+func (in *ChiStoragePolicyVolume) DeepCopy() *ChiStoragePolicyVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiStoragePolicyVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiTemplateNames) DeepCopyInto(out *ChiTemplateNames) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiTemplateNames.
+func (in *ChiTemplateNames) DeepCopy() *ChiTemplateNames {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiTemplateNames)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiTemplating) DeepCopyInto(out *ChiTemplating) {
+	*out = *in
+	if in.CHISelector != nil {
+		in, out := &in.CHISelector, &out.CHISelector
+		*out = make(CHISelector, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
 	}
 	return
 }
@@ -751,44 +1417,372 @@ func (in *ChiZookeeperNode) DeepCopy() *ChiZookeeperNode {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiZookeeperNode)
+	out := new(ChiZookeeperNode)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChrSpec) DeepCopyInto(out *ChrSpec) {
+	*out = *in
+	if in.Remote != nil {
+		in, out := &in.Remote, &out.Remote
+		*out = new(StringBool)
+		**out = **in
+	}
+	if in.DropExisting != nil {
+		in, out := &in.DropExisting, &out.DropExisting
+		*out = new(StringBool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChrSpec.
+func (in *ChrSpec) DeepCopy() *ChrSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ChrSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChuNetworks) DeepCopyInto(out *ChuNetworks) {
+	*out = *in
+	if in.IP != nil {
+		in, out := &in.IP, &out.IP
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChuNetworks.
+func (in *ChuNetworks) DeepCopy() *ChuNetworks {
+	if in == nil {
+		return nil
+	}
+	out := new(ChuNetworks)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChuSpec) DeepCopyInto(out *ChuSpec) {
+	*out = *in
+	if in.PasswordSecretRef != nil {
+		in, out := &in.PasswordSecretRef, &out.PasswordSecretRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Networks != nil {
+		in, out := &in.Networks, &out.Networks
+		*out = new(ChuNetworks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Grants != nil {
+		in, out := &in.Grants, &out.Grants
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChuSpec.
+func (in *ChuSpec) DeepCopy() *ChuSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ChuSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChuStatus) DeepCopyInto(out *ChuStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChuStatus.
+func (in *ChuStatus) DeepCopy() *ChuStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChuStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClickHouseBackup) DeepCopyInto(out *ClickHouseBackup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(ChbStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseBackup.
+func (in *ClickHouseBackup) DeepCopy() *ClickHouseBackup {
+	if in == nil {
+		return nil
+	}
+	out := new(ClickHouseBackup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClickHouseBackup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClickHouseBackupList) DeepCopyInto(out *ClickHouseBackupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClickHouseBackup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseBackupList.
+func (in *ClickHouseBackupList) DeepCopy() *ClickHouseBackupList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClickHouseBackupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClickHouseBackupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClickHouseInstallation) DeepCopyInto(out *ClickHouseInstallation) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(ChiStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.runtime != nil {
+		in, out := &in.runtime, &out.runtime
+		*out = new(ClickHouseInstallationRuntime)
+		(*in).DeepCopyInto(*out)
+	}
+	out.statusCreatorMutex = in.statusCreatorMutex
+	out.runtimeCreatorMutex = in.runtimeCreatorMutex
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseInstallation.
+func (in *ClickHouseInstallation) DeepCopy() *ClickHouseInstallation {
+	if in == nil {
+		return nil
+	}
+	out := new(ClickHouseInstallation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClickHouseInstallation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClickHouseInstallationList) DeepCopyInto(out *ClickHouseInstallationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClickHouseInstallation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseInstallationList.
+func (in *ClickHouseInstallationList) DeepCopy() *ClickHouseInstallationList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClickHouseInstallationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClickHouseInstallationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClickHouseInstallationRuntime) DeepCopyInto(out *ClickHouseInstallationRuntime) {
+	*out = *in
+	if in.attributes != nil {
+		in, out := &in.attributes, &out.attributes
+		*out = new(ComparableAttributes)
+		(*in).DeepCopyInto(*out)
+	}
+	out.commonConfigMutex = in.commonConfigMutex
+	if in.pendingGeneratedPasswords != nil {
+		in, out := &in.pendingGeneratedPasswords, &out.pendingGeneratedPasswords
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.interserverCredentials != nil {
+		in, out := &in.interserverCredentials, &out.interserverCredentials
+		*out = new(InterserverCredentialsState)
+		**out = **in
+	}
+	if in.referencedSecrets != nil {
+		in, out := &in.referencedSecrets, &out.referencedSecrets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseInstallationRuntime.
+func (in *ClickHouseInstallationRuntime) DeepCopy() *ClickHouseInstallationRuntime {
+	if in == nil {
+		return nil
+	}
+	out := new(ClickHouseInstallationRuntime)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClickHouseInstallationTemplate) DeepCopyInto(out *ClickHouseInstallationTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(ChiStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.runtime != nil {
+		in, out := &in.runtime, &out.runtime
+		*out = new(ClickHouseInstallationRuntime)
+		(*in).DeepCopyInto(*out)
+	}
+	out.statusCreatorMutex = in.statusCreatorMutex
+	out.runtimeCreatorMutex = in.runtimeCreatorMutex
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseInstallationTemplate.
+func (in *ClickHouseInstallationTemplate) DeepCopy() *ClickHouseInstallationTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(ClickHouseInstallationTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClickHouseInstallationTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClickHouseInstallationTemplateList) DeepCopyInto(out *ClickHouseInstallationTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClickHouseInstallationTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseInstallationTemplateList.
+func (in *ClickHouseInstallationTemplateList) DeepCopy() *ClickHouseInstallationTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClickHouseInstallationTemplateList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClickHouseInstallationTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ClickHouseInstallation) DeepCopyInto(out *ClickHouseInstallation) {
+func (in *ClickHouseOperatorConfiguration) DeepCopyInto(out *ClickHouseOperatorConfiguration) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	if in.Status != nil {
-		in, out := &in.Status, &out.Status
-		*out = new(ChiStatus)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.runtime != nil {
-		in, out := &in.runtime, &out.runtime
-		*out = new(ClickHouseInstallationRuntime)
-		(*in).DeepCopyInto(*out)
-	}
-	out.statusCreatorMutex = in.statusCreatorMutex
-	out.runtimeCreatorMutex = in.runtimeCreatorMutex
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseInstallation.
-func (in *ClickHouseInstallation) DeepCopy() *ClickHouseInstallation {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseOperatorConfiguration.
+func (in *ClickHouseOperatorConfiguration) DeepCopy() *ClickHouseOperatorConfiguration {
 	if in == nil {
 		return nil
 	}
-	out := new(ClickHouseInstallation)
+	out := new(ClickHouseOperatorConfiguration)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ClickHouseInstallation) DeepCopyObject() runtime.Object {
+func (in *ClickHouseOperatorConfiguration) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -796,13 +1790,13 @@ func (in *ClickHouseInstallation) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ClickHouseInstallationList) DeepCopyInto(out *ClickHouseInstallationList) {
+func (in *ClickHouseOperatorConfigurationList) DeepCopyInto(out *ClickHouseOperatorConfigurationList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]ClickHouseInstallation, len(*in))
+		*out = make([]ClickHouseOperatorConfiguration, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -810,18 +1804,18 @@ func (in *ClickHouseInstallationList) DeepCopyInto(out *ClickHouseInstallationLi
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseInstallationList.
-func (in *ClickHouseInstallationList) DeepCopy() *ClickHouseInstallationList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseOperatorConfigurationList.
+func (in *ClickHouseOperatorConfigurationList) DeepCopy() *ClickHouseOperatorConfigurationList {
 	if in == nil {
 		return nil
 	}
-	out := new(ClickHouseInstallationList)
+	out := new(ClickHouseOperatorConfigurationList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ClickHouseInstallationList) DeepCopyObject() runtime.Object {
+func (in *ClickHouseOperatorConfigurationList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -829,60 +1823,31 @@ func (in *ClickHouseInstallationList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ClickHouseInstallationRuntime) DeepCopyInto(out *ClickHouseInstallationRuntime) {
-	*out = *in
-	if in.attributes != nil {
-		in, out := &in.attributes, &out.attributes
-		*out = new(ComparableAttributes)
-		(*in).DeepCopyInto(*out)
-	}
-	out.commonConfigMutex = in.commonConfigMutex
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseInstallationRuntime.
-func (in *ClickHouseInstallationRuntime) DeepCopy() *ClickHouseInstallationRuntime {
-	if in == nil {
-		return nil
-	}
-	out := new(ClickHouseInstallationRuntime)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ClickHouseInstallationTemplate) DeepCopyInto(out *ClickHouseInstallationTemplate) {
+func (in *ClickHouseRestore) DeepCopyInto(out *ClickHouseRestore) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
 	if in.Status != nil {
 		in, out := &in.Status, &out.Status
-		*out = new(ChiStatus)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.runtime != nil {
-		in, out := &in.runtime, &out.runtime
-		*out = new(ClickHouseInstallationRuntime)
+		*out = new(ChbStatus)
 		(*in).DeepCopyInto(*out)
 	}
-	out.statusCreatorMutex = in.statusCreatorMutex
-	out.runtimeCreatorMutex = in.runtimeCreatorMutex
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseInstallationTemplate.
-func (in *ClickHouseInstallationTemplate) DeepCopy() *ClickHouseInstallationTemplate {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseRestore.
+func (in *ClickHouseRestore) DeepCopy() *ClickHouseRestore {
 	if in == nil {
 		return nil
 	}
-	out := new(ClickHouseInstallationTemplate)
+	out := new(ClickHouseRestore)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ClickHouseInstallationTemplate) DeepCopyObject() runtime.Object {
+func (in *ClickHouseRestore) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -890,13 +1855,13 @@ func (in *ClickHouseInstallationTemplate) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ClickHouseInstallationTemplateList) DeepCopyInto(out *ClickHouseInstallationTemplateList) {
+func (in *ClickHouseRestoreList) DeepCopyInto(out *ClickHouseRestoreList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]ClickHouseInstallationTemplate, len(*in))
+		*out = make([]ClickHouseRestore, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -904,18 +1869,18 @@ func (in *ClickHouseInstallationTemplateList) DeepCopyInto(out *ClickHouseInstal
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseInstallationTemplateList.
-func (in *ClickHouseInstallationTemplateList) DeepCopy() *ClickHouseInstallationTemplateList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseRestoreList.
+func (in *ClickHouseRestoreList) DeepCopy() *ClickHouseRestoreList {
 	if in == nil {
 		return nil
 	}
-	out := new(ClickHouseInstallationTemplateList)
+	out := new(ClickHouseRestoreList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ClickHouseInstallationTemplateList) DeepCopyObject() runtime.Object {
+func (in *ClickHouseRestoreList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -923,26 +1888,31 @@ func (in *ClickHouseInstallationTemplateList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ClickHouseOperatorConfiguration) DeepCopyInto(out *ClickHouseOperatorConfiguration) {
+func (in *ClickHouseUser) DeepCopyInto(out *ClickHouseUser) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(ChuStatus)
+		**out = **in
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseOperatorConfiguration.
-func (in *ClickHouseOperatorConfiguration) DeepCopy() *ClickHouseOperatorConfiguration {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseUser.
+func (in *ClickHouseUser) DeepCopy() *ClickHouseUser {
 	if in == nil {
 		return nil
 	}
-	out := new(ClickHouseOperatorConfiguration)
+	out := new(ClickHouseUser)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ClickHouseOperatorConfiguration) DeepCopyObject() runtime.Object {
+func (in *ClickHouseUser) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -950,13 +1920,13 @@ func (in *ClickHouseOperatorConfiguration) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ClickHouseOperatorConfigurationList) DeepCopyInto(out *ClickHouseOperatorConfigurationList) {
+func (in *ClickHouseUserList) DeepCopyInto(out *ClickHouseUserList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]ClickHouseOperatorConfiguration, len(*in))
+		*out = make([]ClickHouseUser, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -964,18 +1934,18 @@ func (in *ClickHouseOperatorConfigurationList) DeepCopyInto(out *ClickHouseOpera
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseOperatorConfigurationList.
-func (in *ClickHouseOperatorConfigurationList) DeepCopy() *ClickHouseOperatorConfigurationList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClickHouseUserList.
+func (in *ClickHouseUserList) DeepCopy() *ClickHouseUserList {
 	if in == nil {
 		return nil
 	}
-	out := new(ClickHouseOperatorConfigurationList)
+	out := new(ClickHouseUserList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ClickHouseOperatorConfigurationList) DeepCopyObject() runtime.Object {
+func (in *ClickHouseUserList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1000,6 +1970,13 @@ func (in *Cluster) DeepCopyInto(out *Cluster) {
 		*out = new(Settings)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Macros != nil {
+		in, out := &in.Macros, &out.Macros
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.Templates != nil {
 		in, out := &in.Templates, &out.Templates
 		*out = new(ChiTemplateNames)
@@ -1153,6 +2130,11 @@ func (in *Configuration) DeepCopyInto(out *Configuration) {
 		*out = new(ChiZookeeperConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Keeper != nil {
+		in, out := &in.Keeper, &out.Keeper
+		*out = new(ChiKeeper)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Users != nil {
 		in, out := &in.Users, &out.Users
 		*out = new(Settings)
@@ -1178,6 +2160,23 @@ func (in *Configuration) DeepCopyInto(out *Configuration) {
 		*out = new(Settings)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Macros != nil {
+		in, out := &in.Macros, &out.Macros
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(ChiStorageConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InterserverCredentials != nil {
+		in, out := &in.InterserverCredentials, &out.InterserverCredentials
+		*out = new(ChiInterserverCredentials)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Clusters != nil {
 		in, out := &in.Clusters, &out.Clusters
 		*out = make([]*Cluster, len(*in))
@@ -1446,6 +2445,22 @@ func (in *HostsField) DeepCopy() *HostsField {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InterserverCredentialsState) DeepCopyInto(out *InterserverCredentialsState) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InterserverCredentialsState.
+func (in *InterserverCredentialsState) DeepCopy() *InterserverCredentialsState {
+	if in == nil {
+		return nil
+	}
+	out := new(InterserverCredentialsState)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ObjectAddress) DeepCopyInto(out *ObjectAddress) {
 	*out = *in
@@ -1608,6 +2623,15 @@ func (in *OperatorConfigClickHouse) DeepCopyInto(out *OperatorConfigClickHouse)
 	in.ConfigRestartPolicy.DeepCopyInto(&out.ConfigRestartPolicy)
 	out.Access = in.Access
 	out.Metrics = in.Metrics
+	if in.Metrics.Queries != nil {
+		in, out := &in.Metrics.Queries, &out.Metrics.Queries
+		*out = make([]OperatorConfigMetricsQuery, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.Probes = in.Probes
+	in.SystemCommands.DeepCopyInto(&out.SystemCommands)
 	return
 }
 
@@ -1651,113 +2675,228 @@ func (in *OperatorConfigDefault) DeepCopyInto(out *OperatorConfigDefault) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigDefault.
-func (in *OperatorConfigDefault) DeepCopy() *OperatorConfigDefault {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigDefault.
+func (in *OperatorConfigDefault) DeepCopy() *OperatorConfigDefault {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorConfigDefault)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorConfigFile) DeepCopyInto(out *OperatorConfigFile) {
+	*out = *in
+	out.Path = in.Path
+	in.Runtime.DeepCopyInto(&out.Runtime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigFile.
+func (in *OperatorConfigFile) DeepCopy() *OperatorConfigFile {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorConfigFile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorConfigFileRuntime) DeepCopyInto(out *OperatorConfigFileRuntime) {
+	*out = *in
+	if in.CommonConfigFiles != nil {
+		in, out := &in.CommonConfigFiles, &out.CommonConfigFiles
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.HostConfigFiles != nil {
+		in, out := &in.HostConfigFiles, &out.HostConfigFiles
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.UsersConfigFiles != nil {
+		in, out := &in.UsersConfigFiles, &out.UsersConfigFiles
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigFileRuntime.
+func (in *OperatorConfigFileRuntime) DeepCopy() *OperatorConfigFileRuntime {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorConfigFileRuntime)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorConfigLabel) DeepCopyInto(out *OperatorConfigLabel) {
+	*out = *in
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.Runtime = in.Runtime
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigLabel.
+func (in *OperatorConfigLabel) DeepCopy() *OperatorConfigLabel {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorConfigLabel)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorConfigManagedUser) DeepCopyInto(out *OperatorConfigManagedUser) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigManagedUser.
+func (in *OperatorConfigManagedUser) DeepCopy() *OperatorConfigManagedUser {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorConfigManagedUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorConfigProbe) DeepCopyInto(out *OperatorConfigProbe) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigProbe.
+func (in *OperatorConfigProbe) DeepCopy() *OperatorConfigProbe {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorConfigProbe)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorConfigProbeScaling) DeepCopyInto(out *OperatorConfigProbeScaling) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigProbeScaling.
+func (in *OperatorConfigProbeScaling) DeepCopy() *OperatorConfigProbeScaling {
 	if in == nil {
 		return nil
 	}
-	out := new(OperatorConfigDefault)
+	out := new(OperatorConfigProbeScaling)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OperatorConfigFile) DeepCopyInto(out *OperatorConfigFile) {
+func (in *OperatorConfigProbes) DeepCopyInto(out *OperatorConfigProbes) {
 	*out = *in
-	out.Path = in.Path
-	in.Runtime.DeepCopyInto(&out.Runtime)
+	out.Readiness = in.Readiness
+	out.Startup = in.Startup
+	out.Scaling = in.Scaling
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigFile.
-func (in *OperatorConfigFile) DeepCopy() *OperatorConfigFile {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigProbes.
+func (in *OperatorConfigProbes) DeepCopy() *OperatorConfigProbes {
 	if in == nil {
 		return nil
 	}
-	out := new(OperatorConfigFile)
+	out := new(OperatorConfigProbes)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OperatorConfigFileRuntime) DeepCopyInto(out *OperatorConfigFileRuntime) {
+func (in *OperatorConfigReconcile) DeepCopyInto(out *OperatorConfigReconcile) {
 	*out = *in
-	if in.CommonConfigFiles != nil {
-		in, out := &in.CommonConfigFiles, &out.CommonConfigFiles
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.HostConfigFiles != nil {
-		in, out := &in.HostConfigFiles, &out.HostConfigFiles
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	out.Runtime = in.Runtime
+	out.StatefulSet = in.StatefulSet
+	in.StatefulSet.Update.AutoRollback.DeepCopyInto(&out.StatefulSet.Update.AutoRollback)
+	if in.StatefulSet.Update.InPlaceResize != nil {
+		in, out := &in.StatefulSet.Update.InPlaceResize, &out.StatefulSet.Update.InPlaceResize
+		*out = new(StringBool)
+		**out = **in
 	}
-	if in.UsersConfigFiles != nil {
-		in, out := &in.UsersConfigFiles, &out.UsersConfigFiles
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	in.Host.DeepCopyInto(&out.Host)
+	out.Cluster = in.Cluster
+	out.Events = in.Events
+	if in.AdoptUnmanagedObjects != nil {
+		in, out := &in.AdoptUnmanagedObjects, &out.AdoptUnmanagedObjects
+		*out = new(StringBool)
+		**out = **in
 	}
+	in.Zookeeper.DeepCopyInto(&out.Zookeeper)
+	in.MembershipWebhook.DeepCopyInto(&out.MembershipWebhook)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigFileRuntime.
-func (in *OperatorConfigFileRuntime) DeepCopy() *OperatorConfigFileRuntime {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigReconcile.
+func (in *OperatorConfigReconcile) DeepCopy() *OperatorConfigReconcile {
 	if in == nil {
 		return nil
 	}
-	out := new(OperatorConfigFileRuntime)
+	out := new(OperatorConfigReconcile)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OperatorConfigLabel) DeepCopyInto(out *OperatorConfigLabel) {
+func (in *OperatorConfigReconcileCluster) DeepCopyInto(out *OperatorConfigReconcileCluster) {
 	*out = *in
-	if in.Include != nil {
-		in, out := &in.Include, &out.Include
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	if in.Exclude != nil {
-		in, out := &in.Exclude, &out.Exclude
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	out.Runtime = in.Runtime
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigLabel.
-func (in *OperatorConfigLabel) DeepCopy() *OperatorConfigLabel {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigReconcileCluster.
+func (in *OperatorConfigReconcileCluster) DeepCopy() *OperatorConfigReconcileCluster {
 	if in == nil {
 		return nil
 	}
-	out := new(OperatorConfigLabel)
+	out := new(OperatorConfigReconcileCluster)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OperatorConfigReconcile) DeepCopyInto(out *OperatorConfigReconcile) {
+func (in *OperatorConfigReconcileEvents) DeepCopyInto(out *OperatorConfigReconcileEvents) {
 	*out = *in
-	out.Runtime = in.Runtime
-	out.StatefulSet = in.StatefulSet
-	in.Host.DeepCopyInto(&out.Host)
+	out.Aggregation = in.Aggregation
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigReconcile.
-func (in *OperatorConfigReconcile) DeepCopy() *OperatorConfigReconcile {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigReconcileEvents.
+func (in *OperatorConfigReconcileEvents) DeepCopy() *OperatorConfigReconcileEvents {
 	if in == nil {
 		return nil
 	}
-	out := new(OperatorConfigReconcile)
+	out := new(OperatorConfigReconcileEvents)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -1766,6 +2905,11 @@ func (in *OperatorConfigReconcile) DeepCopy() *OperatorConfigReconcile {
 func (in *OperatorConfigReconcileHost) DeepCopyInto(out *OperatorConfigReconcileHost) {
 	*out = *in
 	in.Wait.DeepCopyInto(&out.Wait)
+	if in.ManageMergesOnRestart != nil {
+		in, out := &in.ManageMergesOnRestart, &out.ManageMergesOnRestart
+		*out = new(StringBool)
+		**out = **in
+	}
 	return
 }
 
@@ -1810,6 +2954,74 @@ func (in *OperatorConfigReconcileHostWait) DeepCopy() *OperatorConfigReconcileHo
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorConfigReconcileMembershipWebhook) DeepCopyInto(out *OperatorConfigReconcileMembershipWebhook) {
+	*out = *in
+	if in.AllowedSchemes != nil {
+		in, out := &in.AllowedSchemes, &out.AllowedSchemes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedHosts != nil {
+		in, out := &in.AllowedHosts, &out.AllowedHosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigReconcileMembershipWebhook.
+func (in *OperatorConfigReconcileMembershipWebhook) DeepCopy() *OperatorConfigReconcileMembershipWebhook {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorConfigReconcileMembershipWebhook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorConfigReconcileStatefulSetAutoRollback) DeepCopyInto(out *OperatorConfigReconcileStatefulSetAutoRollback) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(StringBool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigReconcileStatefulSetAutoRollback.
+func (in *OperatorConfigReconcileStatefulSetAutoRollback) DeepCopy() *OperatorConfigReconcileStatefulSetAutoRollback {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorConfigReconcileStatefulSetAutoRollback)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorConfigReconcileZookeeper) DeepCopyInto(out *OperatorConfigReconcileZookeeper) {
+	*out = *in
+	if in.Preflight != nil {
+		in, out := &in.Preflight, &out.Preflight
+		*out = new(StringBool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigReconcileZookeeper.
+func (in *OperatorConfigReconcileZookeeper) DeepCopy() *OperatorConfigReconcileZookeeper {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorConfigReconcileZookeeper)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OperatorConfigRestartPolicy) DeepCopyInto(out *OperatorConfigRestartPolicy) {
 	*out = *in
@@ -1905,6 +3117,53 @@ func (in *OperatorConfigRuntime) DeepCopy() *OperatorConfigRuntime {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorConfigMetricsQuery) DeepCopyInto(out *OperatorConfigMetricsQuery) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigMetricsQuery.
+func (in *OperatorConfigMetricsQuery) DeepCopy() *OperatorConfigMetricsQuery {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorConfigMetricsQuery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorConfigSystemCommands) DeepCopyInto(out *OperatorConfigSystemCommands) {
+	*out = *in
+	if in.Allow != nil {
+		in, out := &in.Allow, &out.Allow
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Deny != nil {
+		in, out := &in.Deny, &out.Deny
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigSystemCommands.
+func (in *OperatorConfigSystemCommands) DeepCopy() *OperatorConfigSystemCommands {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorConfigSystemCommands)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OperatorConfigTemplate) DeepCopyInto(out *OperatorConfigTemplate) {
 	*out = *in
@@ -1985,6 +3244,66 @@ func (in *PodTemplate) DeepCopyInto(out *PodTemplate) {
 		*out = make([]PodDistribution, len(*in))
 		copy(*out, *in)
 	}
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]corev1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.ManageServiceAccount != nil {
+		in, out := &in.ManageServiceAccount, &out.ManageServiceAccount
+		*out = new(StringBool)
+		**out = **in
+	}
+	if in.ServiceAccountAnnotations != nil {
+		in, out := &in.ServiceAccountAnnotations, &out.ServiceAccountAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]corev1.Volume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VolumeMounts != nil {
+		in, out := &in.VolumeMounts, &out.VolumeMounts
+		*out = make([]corev1.VolumeMount, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]corev1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EnvFrom != nil {
+		in, out := &in.EnvFrom, &out.EnvFrom
+		*out = make([]corev1.EnvFromSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EphemeralStorage != nil {
+		in, out := &in.EphemeralStorage, &out.EphemeralStorage
+		*out = new(PodTemplateEphemeralStorage)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(PodTemplateSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Probes != nil {
+		in, out := &in.Probes, &out.Probes
+		*out = new(PodTemplateProbes)
+		(*in).DeepCopyInto(*out)
+	}
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
 	return
@@ -2000,6 +3319,130 @@ func (in *PodTemplate) DeepCopy() *PodTemplate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodTemplateEphemeralStorage) DeepCopyInto(out *PodTemplateEphemeralStorage) {
+	*out = *in
+	if in.Request != nil {
+		in, out := &in.Request, &out.Request
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Limit != nil {
+		in, out := &in.Limit, &out.Limit
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Tmp != nil {
+		in, out := &in.Tmp, &out.Tmp
+		*out = new(corev1.EmptyDirVolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Cache != nil {
+		in, out := &in.Cache, &out.Cache
+		*out = new(corev1.EmptyDirVolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodTemplateEphemeralStorage.
+func (in *PodTemplateEphemeralStorage) DeepCopy() *PodTemplateEphemeralStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(PodTemplateEphemeralStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodTemplateProbe) DeepCopyInto(out *PodTemplateProbe) {
+	*out = *in
+	if in.InitialDelaySeconds != nil {
+		in, out := &in.InitialDelaySeconds, &out.InitialDelaySeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PeriodSeconds != nil {
+		in, out := &in.PeriodSeconds, &out.PeriodSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailureThreshold != nil {
+		in, out := &in.FailureThreshold, &out.FailureThreshold
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodTemplateProbe.
+func (in *PodTemplateProbe) DeepCopy() *PodTemplateProbe {
+	if in == nil {
+		return nil
+	}
+	out := new(PodTemplateProbe)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodTemplateProbes) DeepCopyInto(out *PodTemplateProbes) {
+	*out = *in
+	if in.Readiness != nil {
+		in, out := &in.Readiness, &out.Readiness
+		*out = new(PodTemplateProbe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Startup != nil {
+		in, out := &in.Startup, &out.Startup
+		*out = new(PodTemplateProbe)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodTemplateProbes.
+func (in *PodTemplateProbes) DeepCopy() *PodTemplateProbes {
+	if in == nil {
+		return nil
+	}
+	out := new(PodTemplateProbes)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodTemplateSecurityContext) DeepCopyInto(out *PodTemplateSecurityContext) {
+	*out = *in
+	if in.Pod != nil {
+		in, out := &in.Pod, &out.Pod
+		*out = new(corev1.PodSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Container != nil {
+		in, out := &in.Container, &out.Container
+		*out = new(corev1.SecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodTemplateSecurityContext.
+func (in *PodTemplateSecurityContext) DeepCopy() *PodTemplateSecurityContext {
+	if in == nil {
+		return nil
+	}
+	out := new(PodTemplateSecurityContext)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodTemplateZone) DeepCopyInto(out *PodTemplateZone) {
 	*out = *in
@@ -2113,6 +3556,11 @@ func (in *ScopeAddress) DeepCopy() *ScopeAddress {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceTemplate) DeepCopyInto(out *ServiceTemplate) {
 	*out = *in
+	if in.SessionAffinityConfig != nil {
+		in, out := &in.SessionAffinityConfig, &out.SessionAffinityConfig
+		*out = new(corev1.SessionAffinityConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
 	return
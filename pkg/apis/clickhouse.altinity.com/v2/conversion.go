@@ -0,0 +1,96 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	v1 "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+// ConvertTo converts CHI of version 2 into the hub version 1
+func (chi *ClickHouseInstallation) ConvertTo(dst *v1.ClickHouseInstallation) {
+	dst.TypeMeta = chi.TypeMeta
+	dst.APIVersion = v1.SchemeGroupVersion.String()
+	chi.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	chi.Spec.convertTo(&dst.Spec)
+	// Status is carried over as a whole, including fields status copy options do not cover
+	dst.Status = chi.Status.DeepCopy()
+}
+
+// ConvertFrom converts CHI of the hub version 1 into version 2
+func (chi *ClickHouseInstallation) ConvertFrom(src *v1.ClickHouseInstallation) {
+	chi.TypeMeta = src.TypeMeta
+	chi.APIVersion = SchemeGroupVersion.String()
+	src.ObjectMeta.DeepCopyInto(&chi.ObjectMeta)
+	chi.Spec.convertFrom(&src.Spec)
+	// Status is carried over as a whole, including fields status copy options do not cover
+	chi.Status = src.Status.DeepCopy()
+}
+
+// convertTo converts spec of version 2 into spec of version 1
+func (spec *ChiSpec) convertTo(dst *v1.ChiSpec) {
+	in := spec.DeepCopy()
+	*dst = v1.ChiSpec{
+		TaskID:                 in.TaskID,
+		NamespaceDomainPattern: in.NamespaceDomainPattern,
+		DNS:                    in.DNS,
+		Access:                 in.Access,
+		CertManager:            in.CertManager,
+		NetworkPolicy:          in.NetworkPolicy,
+		Scale:                  in.Scale,
+		RevisionHistoryLimit:   in.RevisionHistoryLimit,
+		RolloutBreakpoint:      in.RolloutBreakpoint,
+		Templating:             in.Templating,
+		Reconciling:            in.Reconciling,
+		Defaults:               in.Defaults,
+		Configuration:          in.Configuration,
+		Templates:              in.Templates,
+		UseTemplates:           in.UseTemplates,
+	}
+	if lifecycle := in.Lifecycle; lifecycle != nil {
+		dst.Stop = lifecycle.Stop
+		dst.Restart = lifecycle.Restart
+		dst.Troubleshoot = lifecycle.Troubleshoot
+	}
+}
+
+// convertFrom converts spec of version 1 into spec of version 2
+func (spec *ChiSpec) convertFrom(src *v1.ChiSpec) {
+	in := src.DeepCopy()
+	*spec = ChiSpec{
+		TaskID:                 in.TaskID,
+		NamespaceDomainPattern: in.NamespaceDomainPattern,
+		DNS:                    in.DNS,
+		Access:                 in.Access,
+		CertManager:            in.CertManager,
+		NetworkPolicy:          in.NetworkPolicy,
+		Scale:                  in.Scale,
+		RevisionHistoryLimit:   in.RevisionHistoryLimit,
+		RolloutBreakpoint:      in.RolloutBreakpoint,
+		Templating:             in.Templating,
+		Reconciling:            in.Reconciling,
+		Defaults:               in.Defaults,
+		Configuration:          in.Configuration,
+		Templates:              in.Templates,
+		UseTemplates:           in.UseTemplates,
+	}
+	// Lifecycle section is specified only in case any of its fields is specified
+	if (in.Stop != nil) || (in.Restart != "") || (in.Troubleshoot != nil) {
+		spec.Lifecycle = &ChiLifecycle{
+			Stop:         in.Stop,
+			Restart:      in.Restart,
+			Troubleshoot: in.Troubleshoot,
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package v2
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
+func Test_ClickHouseInstallation_RoundTrip(t *testing.T) {
+	taskID := "task"
+	src := &v1.ClickHouseInstallation{
+		TypeMeta: meta.TypeMeta{
+			Kind:       v1.ClickHouseInstallationCRDResourceKind,
+			APIVersion: v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+			Labels:    map[string]string{"label": "value"},
+		},
+		Spec: v1.ChiSpec{
+			TaskID:                 &taskID,
+			Stop:                   v1.NewStringBool(true),
+			Restart:                "RollingUpdate",
+			Troubleshoot:           v1.NewStringBool(false),
+			NamespaceDomainPattern: "%s.svc.cluster.local",
+			DNS:                    &v1.ChiDNS{},
+			Access:                 &v1.ChiAccess{},
+			CertManager:            &v1.ChiCertManager{},
+			NetworkPolicy:          &v1.ChiNetworkPolicy{},
+			Scale:                  &v1.ChiScale{},
+			RevisionHistoryLimit:   new(int32),
+			RolloutBreakpoint:      "breakpoint",
+			Templating:             &v1.ChiTemplating{Policy: "auto"},
+			Reconciling:            &v1.ChiReconciling{},
+			Defaults:               &v1.ChiDefaults{},
+			Configuration:          &v1.Configuration{},
+			Templates:              &v1.Templates{},
+			UseTemplates:           []*v1.TemplateRef{{Name: "template"}},
+		},
+		Status: &v1.ChiStatus{
+			Status:             v1.StatusCompleted,
+			TaskIDsCompleted:   []string{taskID},
+			FQDNs:              []string{"host"},
+			EffectiveStorage:   map[string]string{"data": "10Gi"},
+			ScaleReplicas:      2,
+			ScaleSelector:      "clickhouse.altinity.com/cluster=cluster",
+			HostFailures:       map[string]string{"host": "failed"},
+			PendingPlanHash:    "hash",
+			ObservedGeneration: 3,
+			FailedRollouts: map[string]v1.ChiStatefulSetRollout{
+				"sts": {Version: "version", Failures: 1},
+			},
+			HostsProgress: map[string]v1.ChiHostProgress{
+				"host": {Phase: "phase"},
+			},
+			Conditions: []meta.Condition{
+				{Type: "Ready", Status: meta.ConditionTrue, Reason: "Completed"},
+			},
+			NormalizedCHICompleted: &v1.ClickHouseInstallation{
+				ObjectMeta: meta.ObjectMeta{Name: "chi"},
+			},
+		},
+	}
+
+	chi := &ClickHouseInstallation{}
+	chi.ConvertFrom(src)
+	require.Equal(t, "clickhouse.altinity.com/v2", chi.APIVersion)
+	require.Equal(t, v1.ClickHouseInstallationCRDResourceKind, chi.Kind)
+	require.Equal(t, &ChiLifecycle{
+		Stop:         v1.NewStringBool(true),
+		Restart:      "RollingUpdate",
+		Troubleshoot: v1.NewStringBool(false),
+	}, chi.Spec.Lifecycle)
+	require.Equal(t, "breakpoint", chi.Spec.RolloutBreakpoint)
+
+	dst := &v1.ClickHouseInstallation{}
+	chi.ConvertTo(dst)
+	require.Equal(t, src.TypeMeta, dst.TypeMeta)
+	require.Equal(t, src.ObjectMeta, dst.ObjectMeta)
+	require.Equal(t, src.Spec, dst.Spec)
+	require.Equal(t, src.Status, dst.Status)
+
+	// Converted status does not share data with the source
+	dst.Status.HostFailures["host"] = "changed"
+	require.Equal(t, "failed", src.Status.HostFailures["host"])
+
+	// Absent status stays absent
+	src.Status = nil
+	chi.ConvertFrom(src)
+	require.Nil(t, chi.Status)
+}
+
+func Test_ClickHouseInstallation_RoundTripFromV2(t *testing.T) {
+	src := &ClickHouseInstallation{
+		TypeMeta: meta.TypeMeta{
+			Kind:       v1.ClickHouseInstallationTemplateCRDResourceKind,
+			APIVersion: SchemeGroupVersion.String(),
+		},
+		ObjectMeta: meta.ObjectMeta{Name: "chit"},
+		Spec: ChiSpec{
+			Lifecycle:     &ChiLifecycle{Stop: v1.NewStringBool(true)},
+			Configuration: &v1.Configuration{},
+		},
+	}
+
+	hub := &v1.ClickHouseInstallation{}
+	src.ConvertTo(hub)
+	require.Equal(t, "clickhouse.altinity.com/v1", hub.APIVersion)
+	require.Equal(t, v1.ClickHouseInstallationTemplateCRDResourceKind, hub.Kind)
+	require.True(t, hub.Spec.Stop.Value())
+	require.Empty(t, hub.Spec.Restart)
+	require.Nil(t, hub.Spec.Troubleshoot)
+
+	chi := &ClickHouseInstallation{}
+	chi.ConvertFrom(hub)
+	require.Equal(t, src, chi)
+
+	// Lifecycle section is omitted unless any of its fields is specified
+	src.Spec.Lifecycle = nil
+	src.ConvertTo(hub)
+	chi.ConvertFrom(hub)
+	require.Nil(t, chi.Spec.Lifecycle)
+}
+
+// Test_ChiSpec_Fields checks each field of spec of version 1 is converted, either as is or as a part of lifecycle
+func Test_ChiSpec_Fields(t *testing.T) {
+	fields := func(typ reflect.Type) []string {
+		var names []string
+		for i := 0; i < typ.NumField(); i++ {
+			name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	v1Fields := fields(reflect.TypeOf(v1.ChiSpec{}))
+	v2Fields := fields(reflect.TypeOf(ChiSpec{}))
+	lifecycleFields := fields(reflect.TypeOf(ChiLifecycle{}))
+
+	expected := []string{"lifecycle"}
+	for _, name := range v1Fields {
+		if !util.InArray(name, lifecycleFields) {
+			expected = append(expected, name)
+		}
+	}
+	sort.Strings(expected)
+	require.Equal(t, expected, v2Fields)
+}
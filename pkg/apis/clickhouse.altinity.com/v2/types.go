@@ -0,0 +1,73 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClickHouseInstallation defines the Installation of a ClickHouse Database Cluster.
+// Schema of version 2 starts as the schema of version 1 and evolves by replacing its sections,
+// each change being accompanied by the conversion to and from version 1.
+// The same type serves ClickHouseInstallationTemplate, as it does in version 1.
+type ClickHouseInstallation struct {
+	meta.TypeMeta   `json:",inline"            yaml:",inline"`
+	meta.ObjectMeta `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+
+	Spec   ChiSpec       `json:"spec"               yaml:"spec"`
+	Status *v1.ChiStatus `json:"status,omitempty"   yaml:"status,omitempty"`
+}
+
+// ChiSpec defines spec section of ClickHouseInstallation resource.
+// Differs from version 1 by lifecycle section, which groups stop, restart and troubleshoot fields of version 1.
+type ChiSpec struct {
+	TaskID                 *string              `json:"taskID,omitempty"                 yaml:"taskID,omitempty"`
+	Lifecycle              *ChiLifecycle        `json:"lifecycle,omitempty"              yaml:"lifecycle,omitempty"`
+	NamespaceDomainPattern string               `json:"namespaceDomainPattern,omitempty" yaml:"namespaceDomainPattern,omitempty"`
+	DNS                    *v1.ChiDNS           `json:"dns,omitempty"                    yaml:"dns,omitempty"`
+	Access                 *v1.ChiAccess        `json:"access,omitempty"                 yaml:"access,omitempty"`
+	CertManager            *v1.ChiCertManager   `json:"certManager,omitempty"            yaml:"certManager,omitempty"`
+	NetworkPolicy          *v1.ChiNetworkPolicy `json:"networkPolicy,omitempty"          yaml:"networkPolicy,omitempty"`
+	Scale                  *v1.ChiScale         `json:"scale,omitempty"                  yaml:"scale,omitempty"`
+	RevisionHistoryLimit   *int32               `json:"revisionHistoryLimit,omitempty"   yaml:"revisionHistoryLimit,omitempty"`
+	RolloutBreakpoint      string               `json:"rolloutBreakpoint,omitempty"      yaml:"rolloutBreakpoint,omitempty"`
+	Templating             *v1.ChiTemplating    `json:"templating,omitempty"             yaml:"templating,omitempty"`
+	Reconciling            *v1.ChiReconciling   `json:"reconciling,omitempty"            yaml:"reconciling,omitempty"`
+	Defaults               *v1.ChiDefaults      `json:"defaults,omitempty"               yaml:"defaults,omitempty"`
+	Configuration          *v1.Configuration    `json:"configuration,omitempty"          yaml:"configuration,omitempty"`
+	Templates              *v1.Templates        `json:"templates,omitempty"              yaml:"templates,omitempty"`
+	UseTemplates           []*v1.TemplateRef    `json:"useTemplates,omitempty"           yaml:"useTemplates,omitempty"`
+}
+
+// ChiLifecycle defines lifecycle section of ClickHouseInstallation resource,
+// which is specified by stop, restart and troubleshoot fields of spec in version 1
+type ChiLifecycle struct {
+	Stop         *v1.StringBool `json:"stop,omitempty"         yaml:"stop,omitempty"`
+	Restart      string         `json:"restart,omitempty"      yaml:"restart,omitempty"`
+	Troubleshoot *v1.StringBool `json:"troubleshoot,omitempty" yaml:"troubleshoot,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClickHouseInstallationList defines a list of ClickHouseInstallation resources
+type ClickHouseInstallationList struct {
+	meta.TypeMeta `json:",inline"  yaml:",inline"`
+	meta.ListMeta `json:"metadata" yaml:"metadata"`
+	Items         []ClickHouseInstallation `json:"items" yaml:"items"`
+}
@@ -0,0 +1,120 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"fmt"
+
+	log "github.com/golang/glog"
+
+	core "k8s.io/api/core/v1"
+
+	"github.com/altinity/clickhouse-operator/pkg/model/clickhouse"
+)
+
+// secretKeyGetter gets value of the key of the secret in the specified namespace
+type secretKeyGetter func(namespace string, selector *core.SecretKeySelector) ([]byte, error)
+
+// resolvedAccess specifies how the exporter accesses ClickHouse instances of the CHI.
+// Material is read out of secrets specified in `spec.access` of the CHI
+type resolvedAccess struct {
+	scheme string
+
+	// hasCredentials specifies whether CHI credentials are used instead of operator's ones
+	hasCredentials bool
+	username       string
+	password       string
+
+	tls *clickhouse.TLS
+
+	// err specifies why the CHI can not be accessed with its own access material
+	err error
+}
+
+// newResolvedAccess reads access material of the CHI out of secrets.
+// CHI metrics credentials of which can not be read is not accessed at all, since operator's credentials
+// may have more privileges than the CHI is meant to be accessed with. TLS material which can not be read is skipped.
+func newResolvedAccess(chi *WatchedCHI, get secretKeyGetter) *resolvedAccess {
+	access := &resolvedAccess{}
+	if chi == nil || chi.Access == nil {
+		return access
+	}
+	access.scheme = chi.Access.GetScheme()
+
+	if credentials := chi.Access.GetMetricsCredentials(); credentials.HasUsername() {
+		username, err := get(chi.Namespace, credentials.Username)
+		var password []byte
+		if (err == nil) && (credentials.Password != nil) {
+			password, err = get(chi.Namespace, credentials.Password)
+		}
+		if err == nil {
+			access.hasCredentials = true
+			access.username = string(username)
+			access.password = string(password)
+		} else {
+			access.err = fmt.Errorf("unable to get metrics credentials of CHI %s/%s err: %v", chi.Namespace, chi.Name, err)
+			log.Warningf("Unable to get metrics credentials of CHI %s/%s, skip scraping it. err: %v", chi.Namespace, chi.Name, err)
+		}
+	}
+
+	if spec := chi.Access.GetTLS(); spec != nil {
+		access.tls = clickhouse.NewTLS(nil, nil, nil, spec.IsInsecureSkipVerify())
+		var err error
+		if spec.CA != nil {
+			if access.tls.CA, err = get(chi.Namespace, spec.CA); err != nil {
+				// Server certificates are still verified, against system CAs though
+				log.Warningf("Unable to get CA certificates of CHI %s/%s, verify with system CAs. err: %v", chi.Namespace, chi.Name, err)
+			}
+		}
+		if spec.HasClientCert() {
+			cert, errCert := get(chi.Namespace, spec.Cert)
+			key, errKey := get(chi.Namespace, spec.Key)
+			if (errCert == nil) && (errKey == nil) {
+				access.tls.Cert = cert
+				access.tls.Key = key
+			} else {
+				log.Warningf("Unable to get client certificate of CHI %s/%s, connect without it. err: %v %v", chi.Namespace, chi.Name, errCert, errKey)
+			}
+		}
+	}
+
+	return access
+}
+
+// getError gets error the CHI can not be accessed with
+func (a *resolvedAccess) getError() error {
+	if a == nil {
+		return nil
+	}
+	return a.err
+}
+
+// apply adjusts base cluster connection params with access material of the CHI
+func (a *resolvedAccess) apply(params *clickhouse.ClusterConnectionParams) *clickhouse.ClusterConnectionParams {
+	if a == nil {
+		return params
+	}
+	if a.scheme != "" {
+		params.Scheme = a.scheme
+	}
+	if a.hasCredentials {
+		params.Username = a.username
+		params.Password = a.password
+	}
+	if a.tls != nil {
+		params.SetTLS(a.tls)
+	}
+	return params
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/model/clickhouse"
+)
+
+func newSecretKeySelector(name, key string) *core.SecretKeySelector {
+	return &core.SecretKeySelector{
+		LocalObjectReference: core.LocalObjectReference{Name: name},
+		Key:                  key,
+	}
+}
+
+func newSecretKeyGetter(secrets map[string]string) secretKeyGetter {
+	return func(namespace string, selector *core.SecretKeySelector) ([]byte, error) {
+		if value, ok := secrets[namespace+"/"+selector.Name+"/"+selector.Key]; ok {
+			return []byte(value), nil
+		}
+		return nil, fmt.Errorf("not found")
+	}
+}
+
+func Test_ResolvedAccess(t *testing.T) {
+	chi := &WatchedCHI{
+		Namespace: "ns",
+		Name:      "chi",
+		Access: &api.ChiAccess{
+			Scheme: "https",
+			TLS: &api.ChiAccessTLS{
+				CA: newSecretKeySelector("tls", "ca.crt"),
+			},
+			MetricsCredentials: &api.ChiAccessCredentials{
+				Username: newSecretKeySelector("metrics", "username"),
+				Password: newSecretKeySelector("metrics", "password"),
+			},
+		},
+	}
+	get := newSecretKeyGetter(map[string]string{
+		"ns/tls/ca.crt":       "ca",
+		"ns/metrics/username": "metrics",
+		"ns/metrics/password": "secret",
+	})
+
+	params := newResolvedAccess(chi, get).apply(clickhouse.NewClusterConnectionParams("http", "clickhouse_operator", "pwd", "", 8123))
+	require.Equal(t, "https", params.Scheme)
+	require.Equal(t, "metrics", params.Username)
+	require.Equal(t, "secret", params.Password)
+	require.Equal(t, []byte("ca"), params.TLS.CA)
+
+	// CHI credentials of which can not be read is not accessed with operator credentials
+	get = newSecretKeyGetter(map[string]string{
+		"ns/metrics/username": "metrics",
+	})
+	access := newResolvedAccess(chi, get)
+	require.Error(t, access.getError())
+	e := &Exporter{}
+	require.Nil(t, e.newHostFetcher(access, &WatchedHost{Hostname: "host", HTTPPort: 8123}))
+
+	// Fetch error is reported instead of metrics
+	out := make(chan prometheus.Metric, 10)
+	e.collectHostMetrics(context.Background(), chi, &WatchedHost{Hostname: "host"}, nil, out)
+	close(out)
+	var metrics []prometheus.Metric
+	for metric := range out {
+		metrics = append(metrics, metric)
+	}
+	require.Len(t, metrics, 1)
+	require.Contains(t, metrics[0].Desc().String(), "metric_fetch_errors")
+
+	// CHI with no access specified is accessed with operator settings
+	params = newResolvedAccess(&WatchedCHI{Namespace: "ns", Name: "plain"}, get).apply(clickhouse.NewClusterConnectionParams("http", "clickhouse_operator", "pwd", "", 8123))
+	require.Equal(t, "http", params.Scheme)
+	require.Equal(t, "clickhouse_operator", params.Username)
+	require.Nil(t, params.TLS)
+
+	var none *resolvedAccess
+	require.Equal(t, "http", none.apply(clickhouse.NewClusterConnectionParams("http", "u", "p", "", 8123)).Scheme)
+}
@@ -17,8 +17,10 @@ package metrics
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"github.com/MakeNowJust/heredoc"
 
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
 	"github.com/altinity/clickhouse-operator/pkg/model/clickhouse"
 	"github.com/altinity/clickhouse-operator/pkg/util"
 )
@@ -248,6 +250,64 @@ func (f *ClickHouseMetricsFetcher) getClickHouseQueryDetachedParts(ctx context.C
 	)
 }
 
+// getClickHouseQueryCustom requests data of the custom metrics query from ClickHouse
+// Expected data structure: value, labels as specified by the query.
+// Query is run in readonly mode, since it is specified by the user
+func (f *ClickHouseMetricsFetcher) getClickHouseQueryCustom(ctx context.Context, query api.OperatorConfigMetricsQuery) (Table, error) {
+	ctx = clickhouse.WithSettings(ctx, clickhouse.NewReadonlySettings())
+	columns := append([]string{query.GetValue()}, query.Labels...)
+	var scanErr error
+	data, err := f.clickHouseQueryScanRows(
+		ctx,
+		query.SQL,
+		func(rows *sql.Rows, data *Table) error {
+			row, err := scanColumns(rows, columns)
+			if err == nil {
+				*data = append(*data, row)
+			} else {
+				scanErr = err
+			}
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return data, scanErr
+}
+
+// scanColumns scans values of the specified columns of the current row. NULLs are scanned as empty strings
+func scanColumns(rows *sql.Rows, columns []string) ([]string, error) {
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]sql.NullString, len(names))
+	dest := make([]interface{}, len(names))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	row := make([]string, len(columns))
+	for i, column := range columns {
+		found := false
+		for j, name := range names {
+			if name == column {
+				row[i] = values[j].String
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("column %s not found in the result", column)
+		}
+	}
+	return row, nil
+}
+
 // ScanFunction defines function to scan rows
 type ScanFunction func(rows *sql.Rows, data *Table) error
 
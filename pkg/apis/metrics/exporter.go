@@ -45,6 +45,16 @@ type Exporter struct {
 
 	mutex               sync.RWMutex
 	toRemoveFromWatched sync.Map
+
+	// kubeClient is used to read secrets access of CHIs is specified with
+	kubeClient kube.Interface
+
+	// scraper fetches metrics in background, in case enabled
+	scraper *scraper
+
+	// customMetrics maps name of the custom metric to the query which described the metric first.
+	// Metrics of the same name have to be described consistently by all CHIs, otherwise prometheus fails to gather them
+	customMetrics sync.Map
 }
 
 // Type compatibility
@@ -58,6 +68,21 @@ func NewExporter(collectorTimeout time.Duration) *Exporter {
 	}
 }
 
+// EnableScraper makes hosts be scraped in background by the specified number of workers, each CHI with own interval,
+// which is not less than minInterval. Prometheus scrapes are served with metrics fetched by the last scrape of each host then.
+// Has to be called before the exporter is registered
+func (e *Exporter) EnableScraper(ctx context.Context, workers int, interval, minInterval time.Duration) *Exporter {
+	e.scraper = newScraper(e, workers, interval, minInterval)
+	go e.scraper.run(ctx)
+	return e
+}
+
+// SetKubeClient sets k8s client, which is used to read secrets access of CHIs is specified with
+func (e *Exporter) SetKubeClient(kubeClient kube.Interface) *Exporter {
+	e.kubeClient = kubeClient
+	return e
+}
+
 // getWatchedCHIs
 func (e *Exporter) getWatchedCHIs() []*WatchedCHI {
 	return e.chInstallations.slice()
@@ -80,6 +105,14 @@ func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 		log.V(1).Infof("Collect completed [%s]", time.Now().Sub(start))
 	}()
 
+	if e.scraper != nil {
+		// Hosts are scraped in background, serve metrics fetched by the last scrape
+		e.mutex.RLock()
+		defer e.mutex.RUnlock()
+		e.scraper.collect(e.getWatchedCHIs(), ch, time.Now())
+		return
+	}
+
 	// Collect should have timeout
 	ctx, cancel := context.WithTimeout(context.Background(), e.collectorTimeout)
 	defer cancel()
@@ -95,7 +128,7 @@ func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 		wg.Add(1)
 		go func(ctx context.Context, chi *WatchedCHI, host *WatchedHost, ch chan<- prometheus.Metric) {
 			defer wg.Done()
-			e.collectHostMetrics(ctx, chi, host, ch)
+			e.collectHostMetrics(ctx, chi, host, e.newHostFetcher(chi.resolvedAccess, host), ch)
 		}(ctx, chi, host, ch)
 	})
 	wg.Wait()
@@ -137,16 +170,71 @@ func (e *Exporter) removeFromWatched(chi *WatchedCHI) {
 
 // updateWatched updates Exporter.chInstallation map with values from chInstances slice
 func (e *Exporter) updateWatched(chi *WatchedCHI) {
+	// Secrets are read before the lock is taken, so collect is not blocked
+	chi.resolvedAccess = newResolvedAccess(chi, e.getSecretKey)
+
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
 	log.V(1).Infof("Update ClickHouseInstallation (%s/%s): %s", chi.Namespace, chi.Name, chi)
 	e.chInstallations.set(chi.indexKey(), chi)
 }
 
-// newFetcher returns new Metrics Fetcher for specified host
-func (e *Exporter) newHostFetcher(host *WatchedHost) *ClickHouseMetricsFetcher {
+// RefreshAccess re-reads secrets access of watched CHIs is specified with, so rotated material is picked up
+func (e *Exporter) RefreshAccess() {
+	e.mutex.RLock()
+	chis := e.getWatchedCHIs()
+	e.mutex.RUnlock()
+
+	for _, chi := range chis {
+		access := newResolvedAccess(chi, e.getSecretKey)
+		e.mutex.Lock()
+		chi.resolvedAccess = access
+		e.mutex.Unlock()
+	}
+}
+
+// getSecretKey gets value of the key of the secret
+func (e *Exporter) getSecretKey(namespace string, selector *core.SecretKeySelector) ([]byte, error) {
+	if e.kubeClient == nil {
+		return nil, fmt.Errorf("unable to get secret %s/%s, no k8s client", namespace, selector.Name)
+	}
+	secret, err := e.kubeClient.CoreV1().Secrets(namespace).Get(context.TODO(), selector.Name, controller.NewGetOptions())
+	if err != nil {
+		return nil, err
+	}
+	value, ok := secret.Data[selector.Key]
+	if !ok {
+		return nil, fmt.Errorf("no key %s in secret %s/%s", selector.Key, namespace, selector.Name)
+	}
+	return value, nil
+}
+
+// newScrapeTasks makes scrape tasks of all watched hosts
+func (e *Exporter) newScrapeTasks() (tasks []*scrapeTask) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	e.chInstallations.walk(func(chi *WatchedCHI, _ *WatchedCluster, host *WatchedHost) {
+		tasks = append(tasks, &scrapeTask{
+			key:     hostKey(chi, host),
+			chi:     chi,
+			host:    host,
+			fetcher: e.newHostFetcher(chi.resolvedAccess, host),
+		})
+	})
+	return tasks
+}
+
+// newFetcher returns new Metrics Fetcher for specified host.
+// Returns nil in case the host can not be accessed with access material of the CHI
+func (e *Exporter) newHostFetcher(access *resolvedAccess, host *WatchedHost) *ClickHouseMetricsFetcher {
+	if access.getError() != nil {
+		return nil
+	}
 	// Make base cluster connection params
 	clusterConnectionParams := clickhouse.NewClusterConnectionParamsFromCHOpConfig(chop.Config())
+	// Adjust base cluster connection params with per-CHI access props
+	clusterConnectionParams = access.apply(clusterConnectionParams)
 	// Adjust base cluster connection params with per-host props
 	switch clusterConnectionParams.Scheme {
 	case api.ChSchemeAuto:
@@ -168,12 +256,23 @@ func (e *Exporter) newHostFetcher(host *WatchedHost) *ClickHouseMetricsFetcher {
 }
 
 // collectHostMetrics collects metrics from one host and writes them into chan
-func (e *Exporter) collectHostMetrics(ctx context.Context, chi *WatchedCHI, host *WatchedHost, c chan<- prometheus.Metric) {
-	fetcher := e.newHostFetcher(host)
+func (e *Exporter) collectHostMetrics(
+	ctx context.Context,
+	chi *WatchedCHI,
+	host *WatchedHost,
+	fetcher *ClickHouseMetricsFetcher,
+	c chan<- prometheus.Metric,
+) {
 	writer := NewCHIPrometheusWriter(c, chi, host)
+	if fetcher == nil {
+		// Host is not accessed with operator credentials instead of the ones of the CHI
+		writer.WriteErrorFetch("access")
+		return
+	}
+	queries := chi.getQueries(chop.Config().ClickHouse.Metrics.Queries)
 
 	wg := sync.WaitGroup{}
-	wg.Add(6)
+	wg.Add(6 + len(queries))
 	go func(ctx context.Context, host *WatchedHost, fetcher *ClickHouseMetricsFetcher, writer *CHIPrometheusWriter) {
 		e.collectHostSystemMetrics(ctx, host, fetcher, writer)
 		wg.Done()
@@ -198,6 +297,12 @@ func (e *Exporter) collectHostMetrics(ctx context.Context, chi *WatchedCHI, host
 		e.collectHostDetachedPartsMetrics(ctx, host, fetcher, writer)
 		wg.Done()
 	}(ctx, host, fetcher, writer)
+	for _, query := range queries {
+		go func(ctx context.Context, host *WatchedHost, fetcher *ClickHouseMetricsFetcher, writer *CHIPrometheusWriter, query api.OperatorConfigMetricsQuery) {
+			e.collectHostCustomMetrics(ctx, host, fetcher, writer, query)
+			wg.Done()
+		}(ctx, host, fetcher, writer, query)
+	}
 	wg.Wait()
 }
 
@@ -330,6 +435,33 @@ func (e *Exporter) collectHostDetachedPartsMetrics(
 	}
 }
 
+func (e *Exporter) collectHostCustomMetrics(
+	ctx context.Context,
+	host *WatchedHost,
+	fetcher *ClickHouseMetricsFetcher,
+	writer *CHIPrometheusWriter,
+	query api.OperatorConfigMetricsQuery,
+) {
+	fetchType := "custom." + query.Name
+	if !e.claimCustomMetric(query) {
+		log.Warningf("Skip custom metric %s for host %s, it is described with other help, type or labels by another CHI", query.Name, host.Hostname)
+		writer.WriteErrorFetch(fetchType)
+		return
+	}
+	log.V(1).Infof("Querying custom metric %s for host %s", query.Name, host.Hostname)
+	start := time.Now()
+	data, err := fetcher.getClickHouseQueryCustom(ctx, query)
+	elapsed := time.Now().Sub(start)
+	if err == nil {
+		log.V(1).Infof("Extracted [%s] %d rows of custom metric %s for host %s", elapsed, len(data), query.Name, host.Hostname)
+		writer.WriteCustomQuery(query, data)
+		writer.WriteOKFetch(fetchType)
+	} else {
+		log.Warningf("Error [%s] querying custom metric %s for host %s err: %s", elapsed, query.Name, host.Hostname, err)
+		writer.WriteErrorFetch(fetchType)
+	}
+}
+
 // getWatchedCHI serves HTTP request to get list of watched CHIs
 func (e *Exporter) getWatchedCHI(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -406,3 +538,10 @@ func (e *Exporter) DiscoveryWatchedCHIs(kubeClient kube.Interface, chopClient *c
 		e.updateWatched(watchedCHI)
 	}
 }
+
+// claimCustomMetric checks whether custom metric of the query is described consistently with the same metric of other CHIs.
+// The first description of the metric claims the metric name
+func (e *Exporter) claimCustomMetric(query api.OperatorConfigMetricsQuery) bool {
+	claimed, _ := e.customMetrics.LoadOrStore(query.Name, query)
+	return query.IsDescribedAs(claimed.(api.OperatorConfigMetricsQuery))
+}
@@ -24,6 +24,7 @@ import (
 	// log "k8s.io/klog"
 	"github.com/prometheus/client_golang/prometheus"
 
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
 	"github.com/altinity/clickhouse-operator/pkg/util"
 )
 
@@ -201,6 +202,36 @@ func (w *CHIPrometheusWriter) WriteDetachedParts(data [][]string) {
 	}
 }
 
+// WriteCustomQuery writes metric of the custom query
+// Expected data structure: value, labels as specified by the query
+func (w *CHIPrometheusWriter) WriteCustomQuery(query api.OperatorConfigMetricsQuery, data [][]string) {
+	metricType := prometheus.GaugeValue
+	if query.IsCounter() {
+		metricType = prometheus.CounterValue
+	}
+	for _, metric := range data {
+		if len(metric) < len(query.Labels)+1 {
+			continue
+		}
+		w.writeSingleMetricToPrometheus(
+			"custom_"+query.Name, query.Help,
+			metricType, metric[0],
+			query.Labels, metric[1:])
+	}
+}
+
+// WriteScrape writes staleness of metrics fetched from the host by background scrape
+func (w *CHIPrometheusWriter) WriteScrape(age, duration time.Duration) {
+	w.writeSingleMetricToPrometheus(
+		"metrics_scrape_age_seconds", "Seconds passed since metrics of the host were fetched",
+		prometheus.GaugeValue, fmt.Sprintf("%f", age.Seconds()),
+		nil, nil)
+	w.writeSingleMetricToPrometheus(
+		"metrics_scrape_duration_seconds", "Seconds the last fetch of metrics of the host took",
+		prometheus.GaugeValue, fmt.Sprintf("%f", duration.Seconds()),
+		nil, nil)
+}
+
 // WriteErrorFetch writes error fetch
 func (w *CHIPrometheusWriter) WriteErrorFetch(fetchType string) {
 	labelNames := []string{"fetch_type"}
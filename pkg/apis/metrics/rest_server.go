@@ -15,6 +15,7 @@
 package metrics
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"time"
@@ -23,13 +24,18 @@ import (
 	// log "k8s.io/klog"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
 )
 
 // StartMetricsREST start Prometheus metrics exporter in background
 func StartMetricsREST(
+	ctx context.Context,
+
 	metricsAddress string,
 	metricsPath string,
 	collectorTimeout time.Duration,
+	scrape api.OperatorConfigMetricsScrape,
 
 	chiListAddress string,
 	chiListPath string,
@@ -37,6 +43,9 @@ func StartMetricsREST(
 	log.V(1).Infof("Starting metrics exporter at '%s%s'\n", metricsAddress, metricsPath)
 
 	exporter := NewExporter(collectorTimeout)
+	if scrape.IsEnabled() {
+		exporter.EnableScraper(ctx, scrape.Workers, scrape.Interval, scrape.MinInterval)
+	}
 	prometheus.MustRegister(exporter)
 
 	http.Handle(metricsPath, promhttp.Handler())
@@ -0,0 +1,194 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// scraperSchedulePeriod specifies how often hosts due to be scraped are looked for
+	scraperSchedulePeriod = time.Second
+)
+
+// scrapeResult specifies metrics fetched from the host by the last scrape
+type scrapeResult struct {
+	metrics   []prometheus.Metric
+	scrapedAt time.Time
+	duration  time.Duration
+}
+
+// scrapeTask specifies host to be scraped
+type scrapeTask struct {
+	key     string
+	chi     *WatchedCHI
+	host    *WatchedHost
+	fetcher *ClickHouseMetricsFetcher
+}
+
+// scraper fetches metrics from hosts in background by the pool of workers, each CHI with own interval.
+// Prometheus scrapes are served with metrics fetched by the last scrape of each host,
+// so the number of watched hosts does not make prometheus scrapes time out
+type scraper struct {
+	exporter *Exporter
+	workers  int
+	interval time.Duration
+	// minInterval specifies minimum interval CHI may specify hosts to be scraped with
+	minInterval time.Duration
+	tasks       chan *scrapeTask
+
+	mutex sync.RWMutex
+	// results maps host key to metrics fetched by the last scrape of the host
+	results map[string]*scrapeResult
+	// due maps host key to time the host is due to be scraped next time at
+	due map[string]time.Time
+	// inFlight specifies hosts being scraped
+	inFlight map[string]bool
+}
+
+// newScraper creates new scraper
+func newScraper(exporter *Exporter, workers int, interval, minInterval time.Duration) *scraper {
+	return &scraper{
+		exporter:    exporter,
+		workers:     workers,
+		interval:    interval,
+		minInterval: minInterval,
+		tasks:       make(chan *scrapeTask, workers),
+		results:     make(map[string]*scrapeResult),
+		due:         make(map[string]time.Time),
+		inFlight:    make(map[string]bool),
+	}
+}
+
+// hostKey makes key the host is identified by
+func hostKey(chi *WatchedCHI, host *WatchedHost) string {
+	return chi.indexKey() + "/" + host.Hostname
+}
+
+// run launches workers and schedules hosts due to be scraped till context is done
+func (s *scraper) run(ctx context.Context) {
+	log.V(1).Infof("Starting scraper with %d workers, interval %s", s.workers, s.interval)
+	for i := 0; i < s.workers; i++ {
+		go s.work(ctx)
+	}
+
+	ticker := time.NewTicker(scraperSchedulePeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.schedule(s.exporter.newScrapeTasks(), now)
+		}
+	}
+}
+
+// schedule enqueues hosts due to be scraped and forgets hosts which are not watched anymore.
+// Hosts which do not fit into the queue are enqueued on the next schedule, their metrics get stale meanwhile
+func (s *scraper) schedule(tasks []*scrapeTask, now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	watched := make(map[string]bool)
+	for _, task := range tasks {
+		watched[task.key] = true
+		if s.inFlight[task.key] || now.Before(s.due[task.key]) {
+			continue
+		}
+		select {
+		case s.tasks <- task:
+			s.inFlight[task.key] = true
+			s.due[task.key] = now.Add(task.chi.getScrapeInterval(s.interval, s.minInterval))
+		default:
+			log.V(2).Infof("Scrape queue is full, postpone host %s", task.host.Hostname)
+		}
+	}
+
+	for key := range s.due {
+		if !watched[key] {
+			delete(s.due, key)
+			delete(s.results, key)
+		}
+	}
+}
+
+// work scrapes enqueued hosts till context is done
+func (s *scraper) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case task := <-s.tasks:
+			result := s.scrape(ctx, task)
+			s.mutex.Lock()
+			delete(s.inFlight, task.key)
+			if _, ok := s.due[task.key]; ok {
+				// Host is still watched
+				s.results[task.key] = result
+			}
+			s.mutex.Unlock()
+		}
+	}
+}
+
+// scrape fetches metrics from the host
+func (s *scraper) scrape(ctx context.Context, task *scrapeTask) *scrapeResult {
+	ctx, cancel := context.WithTimeout(ctx, s.exporter.collectorTimeout)
+	defer cancel()
+
+	start := time.Now()
+	result := &scrapeResult{}
+	out := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	go func() {
+		for metric := range out {
+			result.metrics = append(result.metrics, metric)
+		}
+		close(done)
+	}()
+	s.exporter.collectHostMetrics(ctx, task.chi, task.host, task.fetcher, out)
+	close(out)
+	<-done
+
+	result.scrapedAt = time.Now()
+	result.duration = result.scrapedAt.Sub(start)
+	return result
+}
+
+// collect writes metrics fetched by the last scrape of each host into chan along with staleness of them
+func (s *scraper) collect(chis []*WatchedCHI, out chan<- prometheus.Metric, now time.Time) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, chi := range chis {
+		chi.walkHosts(func(chi *WatchedCHI, _ *WatchedCluster, host *WatchedHost) {
+			result, ok := s.results[hostKey(chi, host)]
+			if !ok {
+				// Host is not scraped yet
+				return
+			}
+			for _, metric := range result.metrics {
+				out <- metric
+			}
+			NewCHIPrometheusWriter(out, chi, host).WriteScrape(now.Sub(result.scrapedAt), result.duration)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func newScrapeTasks(chis ...*WatchedCHI) (tasks []*scrapeTask) {
+	for _, chi := range chis {
+		chi.walkHosts(func(chi *WatchedCHI, _ *WatchedCluster, host *WatchedHost) {
+			tasks = append(tasks, &scrapeTask{key: hostKey(chi, host), chi: chi, host: host})
+		})
+	}
+	return tasks
+}
+
+func newWatchedCHI(name string, interval time.Duration, hostnames ...string) *WatchedCHI {
+	cluster := &WatchedCluster{Name: "cluster"}
+	for _, hostname := range hostnames {
+		cluster.Hosts = append(cluster.Hosts, &WatchedHost{Hostname: hostname})
+	}
+	return &WatchedCHI{Namespace: "ns", Name: name, Clusters: []*WatchedCluster{cluster}, ScrapeInterval: interval}
+}
+
+// complete simulates workers completing all enqueued tasks
+func (s *scraper) complete(now time.Time) {
+	for {
+		select {
+		case task := <-s.tasks:
+			delete(s.inFlight, task.key)
+			s.results[task.key] = &scrapeResult{scrapedAt: now}
+		default:
+			return
+		}
+	}
+}
+
+func Test_ScraperSchedule(t *testing.T) {
+	s := newScraper(&Exporter{}, 2, 30*time.Second, 10*time.Second)
+	fast := newWatchedCHI("fast", 10*time.Second, "fast-0", "fast-1")
+	slow := newWatchedCHI("slow", 0, "slow-0")
+	now := time.Now()
+
+	// Queue fits 2 hosts, the rest is postponed
+	s.schedule(newScrapeTasks(fast, slow), now)
+	require.Len(t, s.tasks, 2)
+	require.Len(t, s.inFlight, 2)
+
+	// Hosts in flight are not enqueued again
+	s.schedule(newScrapeTasks(fast, slow), now)
+	require.Len(t, s.tasks, 2)
+
+	s.complete(now)
+	s.schedule(newScrapeTasks(fast, slow), now.Add(time.Second))
+	require.Len(t, s.tasks, 1)
+	s.complete(now.Add(time.Second))
+	require.Len(t, s.results, 3)
+
+	// CHI interval overrides configured one
+	s.schedule(newScrapeTasks(fast, slow), now.Add(15*time.Second))
+	require.Len(t, s.tasks, 2)
+	s.complete(now.Add(15 * time.Second))
+	s.schedule(newScrapeTasks(fast, slow), now.Add(20*time.Second))
+	require.Len(t, s.tasks, 0)
+	s.schedule(newScrapeTasks(fast, slow), now.Add(26*time.Second))
+	require.Len(t, s.tasks, 2)
+	require.NotContains(t, s.inFlight, hostKey(slow, slow.Clusters[0].Hosts[0]))
+	s.complete(now.Add(26 * time.Second))
+
+	// Hosts not watched anymore are forgotten
+	s.schedule(newScrapeTasks(slow), now.Add(32*time.Second))
+	require.Len(t, s.results, 1)
+	require.Contains(t, s.results, hostKey(slow, slow.Clusters[0].Hosts[0]))
+}
+
+func Test_ScraperSchedule_MinInterval(t *testing.T) {
+	s := newScraper(&Exporter{}, 1, 30*time.Second, 10*time.Second)
+	chi := newWatchedCHI("chi", time.Millisecond, "chi-0")
+	now := time.Now()
+
+	// CHI interval less than the minimum one does not make the host be scraped on each schedule
+	s.schedule(newScrapeTasks(chi), now)
+	s.complete(now)
+	s.schedule(newScrapeTasks(chi), now.Add(time.Second))
+	require.Len(t, s.tasks, 0)
+	s.schedule(newScrapeTasks(chi), now.Add(10*time.Second))
+	require.Len(t, s.tasks, 1)
+}
+
+func Test_ScraperCollect(t *testing.T) {
+	s := newScraper(&Exporter{}, 1, 30*time.Second, 10*time.Second)
+	chi := newWatchedCHI("chi", 0, "host-0", "host-1")
+	now := time.Now()
+
+	metric := prometheus.MustNewConstMetric(newMetricDescriptor("metric", "", nil), prometheus.GaugeValue, 1)
+	s.results[hostKey(chi, chi.Clusters[0].Hosts[0])] = &scrapeResult{
+		metrics:   []prometheus.Metric{metric, metric},
+		scrapedAt: now.Add(-5 * time.Second),
+		duration:  time.Second,
+	}
+
+	// Host not scraped yet is skipped, scraped host is served with cached metrics along with staleness
+	out := make(chan prometheus.Metric, 10)
+	s.collect([]*WatchedCHI{chi}, out, now)
+	close(out)
+	var collected []prometheus.Metric
+	for m := range out {
+		collected = append(collected, m)
+	}
+	require.Len(t, collected, 4)
+	require.Same(t, metric, collected[0])
+	require.Contains(t, collected[2].Desc().String(), "chi_clickhouse_metrics_scrape_age_seconds")
+	require.Contains(t, collected[3].Desc().String(), "chi_clickhouse_metrics_scrape_duration_seconds")
+}
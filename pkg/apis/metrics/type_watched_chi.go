@@ -16,8 +16,14 @@ package metrics
 
 import (
 	"encoding/json"
+	"time"
+
+	log "github.com/golang/glog"
+	"github.com/kubernetes-sigs/yaml"
 
 	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	"github.com/altinity/clickhouse-operator/pkg/util"
 )
 
 // WatchedCHI specifies watched ClickHouseInstallation
@@ -27,6 +33,15 @@ type WatchedCHI struct {
 	Labels      map[string]string `json:"labels"`
 	Annotations map[string]string `json:"annotations"`
 	Clusters    []*WatchedCluster `json:"clusters"`
+	// Queries specifies custom metrics queries of the CHI
+	Queries []api.OperatorConfigMetricsQuery `json:"queries,omitempty"`
+	// Access specifies how ClickHouse instances of the CHI are accessed. Carries references to secrets only
+	Access *api.ChiAccess `json:"access,omitempty"`
+	// ScrapeInterval specifies interval hosts of the CHI are scraped with in background
+	ScrapeInterval time.Duration `json:"scrapeInterval,omitempty"`
+
+	// resolvedAccess specifies access material read out of secrets referenced by Access
+	resolvedAccess *resolvedAccess
 }
 
 // WatchedCluster specifies watched cluster
@@ -59,7 +74,11 @@ func (chi *WatchedCHI) readFrom(c *api.ClickHouseInstallation) {
 	chi.Namespace = c.Namespace
 	chi.Name = c.Name
 	chi.Labels = c.Labels
-	chi.Annotations = c.Annotations
+	// Metrics settings are not exported as labels
+	chi.Annotations = util.CopyMapExclude(c.Annotations, model.AnnotationMetricsQueries, model.AnnotationMetricsScrapeInterval)
+	chi.Queries = parseMetricsQueries(c.Annotations[model.AnnotationMetricsQueries])
+	chi.ScrapeInterval = parseScrapeInterval(c.Annotations[model.AnnotationMetricsScrapeInterval])
+	chi.Access = c.GetAccess()
 
 	c.WalkClusters(func(cl *api.Cluster) error {
 		cluster := &WatchedCluster{}
@@ -120,6 +139,82 @@ func (chi *WatchedCHI) GetAnnotations() map[string]string {
 	return chi.Annotations
 }
 
+// getQueries gets custom metrics queries to be run against hosts of the CHI.
+// Queries of the CHI override queries of the same name specified in the operator config.
+// Override is allowed to change SQL only, overrides describing metric with other help, type or labels are skipped
+func (chi *WatchedCHI) getQueries(configured []api.OperatorConfigMetricsQuery) []api.OperatorConfigMetricsQuery {
+	var own []api.OperatorConfigMetricsQuery
+	if chi != nil {
+		own = chi.Queries
+	}
+
+	byName := make(map[string]api.OperatorConfigMetricsQuery)
+	for _, query := range configured {
+		if _, found := byName[query.Name]; query.IsValid() && !found {
+			byName[query.Name] = query
+		}
+	}
+
+	var queries []api.OperatorConfigMetricsQuery
+	overridden := make(map[string]bool)
+	for _, query := range own {
+		if !query.IsValid() || overridden[query.Name] {
+			continue
+		}
+		if base, found := byName[query.Name]; found && !query.IsDescribedAs(base) {
+			log.Warningf("Skip custom metric %s of CHI %s/%s, it has to have help, type and labels of the configured one", query.Name, chi.Namespace, chi.Name)
+			continue
+		}
+		queries = append(queries, query)
+		overridden[query.Name] = true
+	}
+	for _, query := range configured {
+		if query.IsValid() && !overridden[query.Name] {
+			queries = append(queries, query)
+			overridden[query.Name] = true
+		}
+	}
+	return queries
+}
+
+// parseMetricsQueries parses custom metrics queries specified by YAML (or JSON) list
+func parseMetricsQueries(str string) []api.OperatorConfigMetricsQuery {
+	if str == "" {
+		return nil
+	}
+	var queries []api.OperatorConfigMetricsQuery
+	if err := yaml.Unmarshal([]byte(str), &queries); err != nil {
+		log.Warningf("Unable to parse %s annotation err: %v", model.AnnotationMetricsQueries, err)
+		return nil
+	}
+	return queries
+}
+
+// getScrapeInterval gets interval hosts of the CHI are scraped with.
+// Interval specified by the CHI is not allowed to be less than the minimum one
+func (chi *WatchedCHI) getScrapeInterval(configured, min time.Duration) time.Duration {
+	switch {
+	case (chi == nil) || (chi.ScrapeInterval <= 0):
+		return configured
+	case chi.ScrapeInterval < min:
+		return min
+	}
+	return chi.ScrapeInterval
+}
+
+// parseScrapeInterval parses scrape interval specified as duration, ex.: "2m"
+func parseScrapeInterval(str string) time.Duration {
+	if str == "" {
+		return 0
+	}
+	interval, err := time.ParseDuration(str)
+	if err != nil {
+		log.Warningf("Unable to parse %s annotation err: %v", model.AnnotationMetricsScrapeInterval, err)
+		return 0
+	}
+	return interval
+}
+
 // String is a stringifier
 func (chi *WatchedCHI) String() string {
 	if chi == nil {
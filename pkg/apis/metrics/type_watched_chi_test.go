@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+)
+
+func Test_WatchedCHI_Queries(t *testing.T) {
+	chi := NewWatchedCHI(&api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "orders",
+			Annotations: map[string]string{
+				"team": "shop",
+				model.AnnotationMetricsQueries: `
+- name: orders_pending
+  sql: SELECT count() AS value FROM shop.orders WHERE status = 'pending'
+- name: table_rows
+  type: counter
+  sql: SELECT database, table, sum(rows) AS rows FROM system.parts GROUP BY database, table
+  value: rows
+  labels: [database, table]
+`,
+			},
+		},
+		Spec: api.ChiSpec{
+			Configuration: api.NewConfiguration(),
+		},
+	})
+
+	// Queries are not exported as label
+	require.Equal(t, map[string]string{"team": "shop"}, chi.Annotations)
+	require.Len(t, chi.Queries, 2)
+	require.Equal(t, "value", chi.Queries[0].GetValue())
+	require.False(t, chi.Queries[0].IsCounter())
+	require.Equal(t, "rows", chi.Queries[1].GetValue())
+	require.True(t, chi.Queries[1].IsCounter())
+	require.Equal(t, []string{"database", "table"}, chi.Queries[1].Labels)
+
+	// Queries of the CHI override configured ones of the same name, invalid queries are skipped
+	queries := chi.getQueries([]api.OperatorConfigMetricsQuery{
+		{Name: "table_rows", Type: "counter", SQL: "SELECT 1 AS value", Labels: []string{"database", "table"}},
+		{Name: "uptime", SQL: "SELECT uptime() AS value"},
+		{Name: "no_sql"},
+	})
+	require.Len(t, queries, 3)
+	require.Equal(t, "orders_pending", queries[0].Name)
+	require.Equal(t, "table_rows", queries[1].Name)
+	require.Equal(t, "rows", queries[1].GetValue())
+	require.Equal(t, "uptime", queries[2].Name)
+
+	// Overrides describing metric with other help, type or labels are skipped in favor of configured ones
+	queries = chi.getQueries([]api.OperatorConfigMetricsQuery{
+		{Name: "orders_pending", Help: "Pending orders", SQL: "SELECT 1 AS value"},
+		{Name: "table_rows", SQL: "SELECT 1 AS value"},
+	})
+	require.Len(t, queries, 2)
+	require.Equal(t, "orders_pending", queries[0].Name)
+	require.Equal(t, "SELECT 1 AS value", queries[0].SQL)
+	require.Equal(t, "table_rows", queries[1].Name)
+	require.Equal(t, "SELECT 1 AS value", queries[1].SQL)
+}
+
+func Test_Exporter_ClaimCustomMetric(t *testing.T) {
+	e := NewExporter(time.Second)
+	query := api.OperatorConfigMetricsQuery{Name: "orders_pending", Help: "Pending orders", SQL: "SELECT 1 AS value"}
+
+	// The first description claims the metric, the same description is accepted from other CHIs
+	require.True(t, e.claimCustomMetric(query))
+	other := query
+	other.SQL = "SELECT 2 AS value"
+	require.True(t, e.claimCustomMetric(other))
+
+	// Descriptions with other help, type or labels are refused
+	other.Help = "Orders"
+	require.False(t, e.claimCustomMetric(other))
+	other = query
+	other.Type = "counter"
+	require.False(t, e.claimCustomMetric(other))
+	other = query
+	other.Labels = []string{"shop"}
+	require.False(t, e.claimCustomMetric(other))
+}
+
+func Test_WatchedCHI_QueriesMalformed(t *testing.T) {
+	chi := NewWatchedCHI(&api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "orders",
+			Annotations: map[string]string{
+				model.AnnotationMetricsQueries: "name: not a list",
+			},
+		},
+		Spec: api.ChiSpec{
+			Configuration: api.NewConfiguration(),
+		},
+	})
+	require.Empty(t, chi.Queries)
+
+	var none *WatchedCHI
+	require.Len(t, none.getQueries([]api.OperatorConfigMetricsQuery{{Name: "uptime", SQL: "SELECT uptime() AS value"}}), 1)
+}
@@ -403,13 +403,7 @@ func (cm *ConfigManager) fetchSecretCredentials() {
 	// We have secret name specified, let's move on and read credentials
 
 	// Figure out namespace where to look for the secret
-	namespace := cm.config.ClickHouse.Access.Secret.Namespace
-	if namespace == "" {
-		// No namespace explicitly specified, let's look into namespace where pod is running
-		if cm.HasRuntimeParam(deployment.OPERATOR_POD_NAMESPACE) {
-			namespace, _ = cm.GetRuntimeParam(deployment.OPERATOR_POD_NAMESPACE)
-		}
-	}
+	namespace := cm.getSecretCredentialsNamespace()
 
 	log.V(1).Info("Going to search for username/password in the secret '%s/%s'", namespace, name)
 
@@ -443,6 +437,49 @@ func (cm *ConfigManager) fetchSecretCredentials() {
 	}
 }
 
+// getSecretCredentialsNamespace gets namespace of the secret with ClickHouse access credentials
+func (cm *ConfigManager) getSecretCredentialsNamespace() string {
+	namespace := cm.config.ClickHouse.Access.Secret.Namespace
+	if namespace == "" {
+		// No namespace explicitly specified, let's look into namespace where pod is running
+		if cm.HasRuntimeParam(deployment.OPERATOR_POD_NAMESPACE) {
+			namespace, _ = cm.GetRuntimeParam(deployment.OPERATOR_POD_NAMESPACE)
+		}
+	}
+	return namespace
+}
+
+// IsSecretCredentials checks whether specified secret is the one ClickHouse access credentials are read from
+func (cm *ConfigManager) IsSecretCredentials(namespace, name string) bool {
+	if cm.config.ClickHouse.Access.Secret.Name == "" {
+		return false
+	}
+	return (name == cm.config.ClickHouse.Access.Secret.Name) && (namespace == cm.getSecretCredentialsNamespace())
+}
+
+// UpdateSecretCredentials reads ClickHouse access credentials from the secret once again,
+// so credentials rotated in the secret are used with no restart.
+// Returns true in case credentials are changed
+func (cm *ConfigManager) UpdateSecretCredentials() bool {
+	if cm.config.ClickHouse.Access.Secret.Name == "" {
+		return false
+	}
+
+	runtime := &cm.config.ClickHouse.Access.Secret.Runtime
+	runtime.Username = ""
+	runtime.Password = ""
+	runtime.Fetched = false
+	runtime.Error = ""
+	cm.fetchSecretCredentials()
+
+	if (runtime.Username == "") || (runtime.Password == "") {
+		// Credentials in use are kept till the secret is fixed
+		log.V(1).Warning("No username/password in the secret, keep credentials in use")
+		return false
+	}
+	return cm.config.SetAccessCredentials(runtime.Username, runtime.Password)
+}
+
 // Postprocess performs postprocessing of the configuration
 func (cm *ConfigManager) Postprocess() {
 	cm.config.Postprocess()
@@ -0,0 +1,46 @@
+package chop
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/apis/deployment"
+)
+
+func Test_IsSecretCredentials(t *testing.T) {
+	t.Setenv(deployment.OPERATOR_POD_NAMESPACE, "operator")
+	cm := &ConfigManager{config: &api.OperatorConfig{}}
+	require.False(t, cm.IsSecretCredentials("operator", ""))
+
+	cm.config.ClickHouse.Access.Secret.Name = "credentials"
+	require.True(t, cm.IsSecretCredentials("operator", "credentials"))
+	require.False(t, cm.IsSecretCredentials("other", "credentials"))
+	require.False(t, cm.IsSecretCredentials("operator", "other"))
+
+	cm.config.ClickHouse.Access.Secret.Namespace = "other"
+	require.True(t, cm.IsSecretCredentials("other", "credentials"))
+	require.False(t, cm.IsSecretCredentials("operator", "credentials"))
+}
+
+func Test_SetAccessCredentials(t *testing.T) {
+	config := &api.OperatorConfig{}
+	require.True(t, config.SetAccessCredentials("operator", "old"))
+	require.False(t, config.SetAccessCredentials("operator", "old"))
+	require.True(t, config.SetAccessCredentials("operator", "new"))
+
+	username, password := config.GetAccessCredentials()
+	require.Equal(t, "operator", username)
+	require.Equal(t, "new", password)
+
+	// Hosts accept credentials in use before the rotation till rotated ones are picked up
+	username, password = config.GetPreviousAccessCredentials()
+	require.Equal(t, "operator", username)
+	require.Equal(t, "old", password)
+
+	config.ClearPreviousAccessCredentials()
+	username, password = config.GetPreviousAccessCredentials()
+	require.Empty(t, username)
+	require.Empty(t, password)
+}
@@ -23,6 +23,7 @@ import (
 	"strconv"
 
 	apiextensions "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/client-go/dynamic"
 	kube "k8s.io/client-go/kubernetes"
 	kuberest "k8s.io/client-go/rest"
 	kubeclientcmd "k8s.io/client-go/tools/clientcmd"
@@ -65,12 +66,8 @@ func getKubeConfig(kubeConfigFile, masterURL string) (*kuberest.Config, error) {
 	return conf, nil
 }
 
-// GetClientset gets k8s API clients - both kube native client and our custom client
-func GetClientset(kubeConfigFile, masterURL string) (
-	*kube.Clientset,
-	*apiextensions.Clientset,
-	*chopclientset.Clientset,
-) {
+// getKubeConfigWithRateLimits creates kuberest.Config object with k8s client rate limiting overrides applied
+func getKubeConfigWithRateLimits(kubeConfigFile, masterURL string) *kuberest.Config {
 	kubeConfig, err := getKubeConfig(kubeConfigFile, masterURL)
 	if err != nil {
 		log.F().Fatal("Unable to build kubeconf: %s", err.Error())
@@ -101,6 +98,17 @@ func GetClientset(kubeConfigFile, masterURL string) (
 		kubeConfig.Burst = int(parsedBurst)
 	}
 
+	return kubeConfig
+}
+
+// GetClientset gets k8s API clients - both kube native client and our custom client
+func GetClientset(kubeConfigFile, masterURL string) (
+	*kube.Clientset,
+	*apiextensions.Clientset,
+	*chopclientset.Clientset,
+) {
+	kubeConfig := getKubeConfigWithRateLimits(kubeConfigFile, masterURL)
+
 	kubeClientset, err := kube.NewForConfig(kubeConfig)
 	if err != nil {
 		log.F().Fatal("Unable to initialize kubernetes API clientset: %s", err.Error())
@@ -119,6 +127,18 @@ func GetClientset(kubeConfigFile, masterURL string) (
 	return kubeClientset, apiextensionsClientset, chopClientset
 }
 
+// GetDynamicClient gets k8s API client for resources, which have no typed client, such as cert-manager Certificates
+func GetDynamicClient(kubeConfigFile, masterURL string) dynamic.Interface {
+	kubeConfig := getKubeConfigWithRateLimits(kubeConfigFile, masterURL)
+
+	dynamicClient, err := dynamic.NewForConfig(kubeConfig)
+	if err != nil {
+		log.F().Fatal("Unable to initialize kubernetes API dynamic client: %s", err.Error())
+	}
+
+	return dynamicClient
+}
+
 var chop *CHOp
 
 // New creates chop instance
@@ -133,6 +153,13 @@ func New(kubeClient *kube.Clientset, chopClient *chopclientset.Clientset, initCH
 	chop.SetupLog()
 }
 
+// NewWithConfig creates chop instance with the specified config, which is not read from any source.
+// Used in case config is prepared in-place, ex.: by tests
+func NewWithConfig(config *v1.OperatorConfig) {
+	chop = NewCHOp(version.Version, version.GitSHA, version.BuiltAt, nil, nil, "")
+	chop.ConfigManager.config = config
+}
+
 // Get gets global CHOp
 func Get() *CHOp {
 	return chop
@@ -28,9 +28,12 @@ import (
 
 type ClickhouseV1Interface interface {
 	RESTClient() rest.Interface
+	ClickHouseBackupsGetter
 	ClickHouseInstallationsGetter
 	ClickHouseInstallationTemplatesGetter
 	ClickHouseOperatorConfigurationsGetter
+	ClickHouseRestoresGetter
+	ClickHouseUsersGetter
 }
 
 // ClickhouseV1Client is used to interact with features provided by the clickhouse.altinity.com group.
@@ -38,6 +41,10 @@ type ClickhouseV1Client struct {
 	restClient rest.Interface
 }
 
+func (c *ClickhouseV1Client) ClickHouseBackups(namespace string) ClickHouseBackupInterface {
+	return newClickHouseBackups(c, namespace)
+}
+
 func (c *ClickhouseV1Client) ClickHouseInstallations(namespace string) ClickHouseInstallationInterface {
 	return newClickHouseInstallations(c, namespace)
 }
@@ -50,6 +57,14 @@ func (c *ClickhouseV1Client) ClickHouseOperatorConfigurations(namespace string)
 	return newClickHouseOperatorConfigurations(c, namespace)
 }
 
+func (c *ClickhouseV1Client) ClickHouseRestores(namespace string) ClickHouseRestoreInterface {
+	return newClickHouseRestores(c, namespace)
+}
+
+func (c *ClickhouseV1Client) ClickHouseUsers(namespace string) ClickHouseUserInterface {
+	return newClickHouseUsers(c, namespace)
+}
+
 // NewForConfig creates a new ClickhouseV1Client for the given config.
 // NewForConfig is equivalent to NewForConfigAndClient(c, httpClient),
 // where httpClient was generated with rest.HTTPClientFor(c).
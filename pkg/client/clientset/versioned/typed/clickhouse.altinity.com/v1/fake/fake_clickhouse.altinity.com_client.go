@@ -28,6 +28,10 @@ type FakeClickhouseV1 struct {
 	*testing.Fake
 }
 
+func (c *FakeClickhouseV1) ClickHouseBackups(namespace string) v1.ClickHouseBackupInterface {
+	return &FakeClickHouseBackups{c, namespace}
+}
+
 func (c *FakeClickhouseV1) ClickHouseInstallations(namespace string) v1.ClickHouseInstallationInterface {
 	return &FakeClickHouseInstallations{c, namespace}
 }
@@ -40,6 +44,14 @@ func (c *FakeClickhouseV1) ClickHouseOperatorConfigurations(namespace string) v1
 	return &FakeClickHouseOperatorConfigurations{c, namespace}
 }
 
+func (c *FakeClickhouseV1) ClickHouseRestores(namespace string) v1.ClickHouseRestoreInterface {
+	return &FakeClickHouseRestores{c, namespace}
+}
+
+func (c *FakeClickhouseV1) ClickHouseUsers(namespace string) v1.ClickHouseUserInterface {
+	return &FakeClickHouseUsers{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeClickhouseV1) RESTClient() rest.Interface {
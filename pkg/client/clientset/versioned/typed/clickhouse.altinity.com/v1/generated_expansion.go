@@ -18,8 +18,14 @@ limitations under the License.
 
 package v1
 
+type ClickHouseBackupExpansion interface{}
+
 type ClickHouseInstallationExpansion interface{}
 
 type ClickHouseInstallationTemplateExpansion interface{}
 
 type ClickHouseOperatorConfigurationExpansion interface{}
+
+type ClickHouseRestoreExpansion interface{}
+
+type ClickHouseUserExpansion interface{}
@@ -24,12 +24,18 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// ClickHouseBackups returns a ClickHouseBackupInformer.
+	ClickHouseBackups() ClickHouseBackupInformer
 	// ClickHouseInstallations returns a ClickHouseInstallationInformer.
 	ClickHouseInstallations() ClickHouseInstallationInformer
 	// ClickHouseInstallationTemplates returns a ClickHouseInstallationTemplateInformer.
 	ClickHouseInstallationTemplates() ClickHouseInstallationTemplateInformer
 	// ClickHouseOperatorConfigurations returns a ClickHouseOperatorConfigurationInformer.
 	ClickHouseOperatorConfigurations() ClickHouseOperatorConfigurationInformer
+	// ClickHouseRestores returns a ClickHouseRestoreInformer.
+	ClickHouseRestores() ClickHouseRestoreInformer
+	// ClickHouseUsers returns a ClickHouseUserInformer.
+	ClickHouseUsers() ClickHouseUserInformer
 }
 
 type version struct {
@@ -43,6 +49,11 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// ClickHouseBackups returns a ClickHouseBackupInformer.
+func (v *version) ClickHouseBackups() ClickHouseBackupInformer {
+	return &clickHouseBackupInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // ClickHouseInstallations returns a ClickHouseInstallationInformer.
 func (v *version) ClickHouseInstallations() ClickHouseInstallationInformer {
 	return &clickHouseInstallationInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -57,3 +68,13 @@ func (v *version) ClickHouseInstallationTemplates() ClickHouseInstallationTempla
 func (v *version) ClickHouseOperatorConfigurations() ClickHouseOperatorConfigurationInformer {
 	return &clickHouseOperatorConfigurationInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// ClickHouseRestores returns a ClickHouseRestoreInformer.
+func (v *version) ClickHouseRestores() ClickHouseRestoreInformer {
+	return &clickHouseRestoreInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// ClickHouseUsers returns a ClickHouseUserInformer.
+func (v *version) ClickHouseUsers() ClickHouseUserInformer {
+	return &clickHouseUserInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
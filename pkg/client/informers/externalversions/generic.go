@@ -53,12 +53,18 @@ func (f *genericInformer) Lister() cache.GenericLister {
 func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
 	switch resource {
 	// Group=clickhouse.altinity.com, Version=v1
+	case v1.SchemeGroupVersion.WithResource("clickhousebackups"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Clickhouse().V1().ClickHouseBackups().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("clickhouseinstallations"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Clickhouse().V1().ClickHouseInstallations().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("clickhouseinstallationtemplates"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Clickhouse().V1().ClickHouseInstallationTemplates().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("clickhouseoperatorconfigurations"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Clickhouse().V1().ClickHouseOperatorConfigurations().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("clickhouserestores"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Clickhouse().V1().ClickHouseRestores().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("clickhouseusers"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Clickhouse().V1().ClickHouseUsers().Informer()}, nil
 
 	}
 
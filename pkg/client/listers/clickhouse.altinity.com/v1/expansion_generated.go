@@ -18,6 +18,14 @@ limitations under the License.
 
 package v1
 
+// ClickHouseBackupListerExpansion allows custom methods to be added to
+// ClickHouseBackupLister.
+type ClickHouseBackupListerExpansion interface{}
+
+// ClickHouseBackupNamespaceListerExpansion allows custom methods to be added to
+// ClickHouseBackupNamespaceLister.
+type ClickHouseBackupNamespaceListerExpansion interface{}
+
 // ClickHouseInstallationListerExpansion allows custom methods to be added to
 // ClickHouseInstallationLister.
 type ClickHouseInstallationListerExpansion interface{}
@@ -41,3 +49,19 @@ type ClickHouseOperatorConfigurationListerExpansion interface{}
 // ClickHouseOperatorConfigurationNamespaceListerExpansion allows custom methods to be added to
 // ClickHouseOperatorConfigurationNamespaceLister.
 type ClickHouseOperatorConfigurationNamespaceListerExpansion interface{}
+
+// ClickHouseRestoreListerExpansion allows custom methods to be added to
+// ClickHouseRestoreLister.
+type ClickHouseRestoreListerExpansion interface{}
+
+// ClickHouseRestoreNamespaceListerExpansion allows custom methods to be added to
+// ClickHouseRestoreNamespaceLister.
+type ClickHouseRestoreNamespaceListerExpansion interface{}
+
+// ClickHouseUserListerExpansion allows custom methods to be added to
+// ClickHouseUserLister.
+type ClickHouseUserListerExpansion interface{}
+
+// ClickHouseUserNamespaceListerExpansion allows custom methods to be added to
+// ClickHouseUserNamespaceLister.
+type ClickHouseUserNamespaceListerExpansion interface{}
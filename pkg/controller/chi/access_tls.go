@@ -0,0 +1,108 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"sync"
+
+	"github.com/altinity/clickhouse-operator/pkg/model/clickhouse"
+)
+
+// accessTLSCache keeps TLS material hosts of CHIs are accessed with, so secrets are not read on each access.
+// Keys are 'namespace/name' of CHIs.
+type accessTLSCache struct {
+	mutex sync.Mutex
+	// tls maps CHI to TLS material read out of the secrets the CHI refers to
+	tls map[string]*clickhouse.TLS
+	// stale marks CHIs, TLS material of which is to be re-read, since either CHI or its secrets changed
+	stale map[string]bool
+}
+
+// newAccessTLSCache creates new TLS material cache
+func newAccessTLSCache() *accessTLSCache {
+	return &accessTLSCache{
+		tls:   make(map[string]*clickhouse.TLS),
+		stale: make(map[string]bool),
+	}
+}
+
+// Get gets TLS material of the CHI. Material is read by the read function in case it is not cached or is stale.
+// Material replaced by re-read one is dropped along with connections established with it.
+func (c *accessTLSCache) Get(namespace, name string, read func() *clickhouse.TLS) *clickhouse.TLS {
+	if c == nil {
+		return read()
+	}
+
+	key := namespace + "/" + name
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	cur, cached := c.tls[key]
+	if cached && !c.stale[key] {
+		return cur
+	}
+
+	tls := read()
+	c.tls[key] = tls
+	delete(c.stale, key)
+	if (cur != nil) && (cur.Name() != tls.Name()) {
+		c.drop(cur)
+	}
+	return tls
+}
+
+// Invalidate marks TLS material of the CHI as stale, so it is re-read on the next access
+func (c *accessTLSCache) Invalidate(namespace, name string) {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := namespace + "/" + name
+	if _, cached := c.tls[key]; cached {
+		c.stale[key] = true
+	}
+}
+
+// Remove drops TLS material of the deleted CHI along with connections established with it
+func (c *accessTLSCache) Remove(namespace, name string) {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := namespace + "/" + name
+	cur := c.tls[key]
+	delete(c.tls, key)
+	delete(c.stale, key)
+	c.drop(cur)
+}
+
+// drop drops TLS material, unless other CHIs are accessed with the same material. Is expected to be called under lock
+func (c *accessTLSCache) drop(tls *clickhouse.TLS) {
+	if tls == nil {
+		return
+	}
+	for _, other := range c.tls {
+		if (other != nil) && (other.Name() == tls.Name()) {
+			return
+		}
+	}
+	tls.Drop()
+}
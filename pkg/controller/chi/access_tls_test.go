@@ -0,0 +1,89 @@
+package chi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi/normalizer"
+)
+
+func Test_GetAccessTLS_Cached(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+
+	host := newTestShard(1)[0]
+	chi := host.GetCHI()
+	chi.ObjectMeta = meta.ObjectMeta{Namespace: "ns", Name: "chi"}
+	chi.Spec.Access = &api.ChiAccess{
+		TLS: &api.ChiAccessTLS{
+			CA: &core.SecretKeySelector{
+				LocalObjectReference: core.LocalObjectReference{Name: "tls"},
+				Key:                  "ca.crt",
+			},
+		},
+	}
+	kubeClient := kubeFake.NewSimpleClientset(&core.Secret{
+		ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "tls"},
+		Data:       map[string][]byte{"ca.crt": []byte("ca")},
+	})
+	c := &Controller{
+		kubeClient: kubeClient,
+		accessTLS:  newAccessTLSCache(),
+	}
+	w := &worker{c: c, a: NewAnnouncer()}
+
+	// Secret is read once, hosts are accessed with cached material
+	for i := 0; i < 3; i++ {
+		require.Equal(t, []byte("ca"), w.getAccessTLS(host).CA)
+		_ = w.newClusterSchemer(host)
+	}
+	require.Len(t, kubeClient.Actions(), 1)
+
+	// Rotated material is picked up
+	_, err := kubeClient.CoreV1().Secrets("ns").Update(context.Background(), &core.Secret{
+		ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "tls"},
+		Data:       map[string][]byte{"ca.crt": []byte("rotated")},
+	}, meta.UpdateOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []byte("ca"), w.getAccessTLS(host).CA)
+	c.accessTLS.Invalidate("ns", "chi")
+	require.Equal(t, []byte("rotated"), w.getAccessTLS(host).CA)
+	require.Equal(t, []byte("rotated"), w.getAccessTLS(host).CA)
+	require.Len(t, kubeClient.Actions(), 3)
+
+	// Material is re-read once CHI stops specifying it
+	chi.Spec.Access = nil
+	c.accessTLS.Invalidate("ns", "chi")
+	require.Nil(t, w.getAccessTLS(host))
+
+	// Material of deleted CHI is dropped
+	c.accessTLS.Remove("ns", "chi")
+	require.Empty(t, c.accessTLS.tls)
+}
+
+func Test_AccessTLSSecretsAreWatched(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+
+	chi := &api.ClickHouseInstallation{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "chi"}}
+	chi.Spec.Access = &api.ChiAccess{
+		TLS: &api.ChiAccessTLS{
+			CA:   &core.SecretKeySelector{LocalObjectReference: core.LocalObjectReference{Name: "ca"}, Key: "ca.crt"},
+			Cert: &core.SecretKeySelector{LocalObjectReference: core.LocalObjectReference{Name: "client"}, Key: "tls.crt"},
+			Key:  &core.SecretKeySelector{LocalObjectReference: core.LocalObjectReference{Name: "client"}, Key: "tls.key"},
+		},
+	}
+	normalized, err := normalizer.NewNormalizer(nil).CreateTemplatedCHI(chi, normalizer.NewOptions())
+	require.NoError(t, err)
+
+	// Rotation of TLS material is reconciled and data of the secrets is kept in the informer cache
+	c := &Controller{secretIndex: newSecretIndex()}
+	c.secretIndex.Update(normalized)
+	require.Equal(t, []string{"ns/chi"}, c.secretIndex.GetCHIs("ns", "ca"))
+	require.Equal(t, []string{"ns/chi"}, c.secretIndex.GetCHIs("ns", "client"))
+	require.True(t, c.isSecretOfInterest(&core.Secret{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "client"}}))
+}
@@ -0,0 +1,255 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	admission "k8s.io/api/admission/v1"
+	core "k8s.io/api/core/v1"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/controller"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi/normalizer"
+)
+
+// jsonPatchOperation describes one operation of JSON patch returned by the mutating webhook
+type jsonPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// MutatingWebhookHandler returns HTTP handler of the mutating admission webhook.
+// Webhook normalizes CHI being created or updated, so defaults, templates and generated names
+// are materialized in the stored object and the spec in etcd matches what the operator reconciles.
+func (c *Controller) MutatingWebhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		review := &admission.AdmissionReview{}
+		if err := json.NewDecoder(r.Body).Decode(review); err != nil || review.Request == nil {
+			http.Error(w, fmt.Sprintf("unable to decode admission review: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		review.Response = c.mutate(review.Request)
+		review.Response.UID = review.Request.UID
+		review.Request = nil
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(review)
+	})
+}
+
+// mutate builds admission response with JSON patch materializing defaults of the CHI.
+// CHI is admitted as is in case it can not be normalized, so the webhook never blocks CHI modifications.
+func (c *Controller) mutate(request *admission.AdmissionRequest) *admission.AdmissionResponse {
+	response := &admission.AdmissionResponse{
+		Allowed: true,
+	}
+
+	switch request.Operation {
+	case admission.Create, admission.Update:
+	default:
+		return response
+	}
+
+	chi := &api.ClickHouseInstallation{}
+	if err := json.Unmarshal(request.Object.Raw, chi); err != nil {
+		log.V(1).F().Warning("unable to decode CHI %s/%s err: %v", request.Namespace, request.Name, err)
+		return response
+	}
+	if chi.Namespace == "" {
+		chi.Namespace = request.Namespace
+	}
+	if !chi.GetDeletionTimestamp().IsZero() {
+		// Nothing to materialize for CHI being deleted
+		return response
+	}
+
+	patch, err := c.buildNormalizationPatch(chi, request.Object.Raw)
+	if err != nil {
+		log.V(1).M(chi).F().Warning("unable to normalize CHI err: %v", err)
+		return response
+	}
+	if patch == nil {
+		// Everything is specified already
+		return response
+	}
+
+	patchType := admission.PatchTypeJSONPatch
+	response.Patch = patch
+	response.PatchType = &patchType
+	return response
+}
+
+// buildNormalizationPatch builds JSON patch adding defaults and generated names, which are not specified in the CHI.
+// Fields specified in the CHI are never changed, and nothing else of the normalized CHI is stored:
+// templates are applied and secrets are resolved at every reconcile, so a stored copy would mask their later changes.
+// Returns nil in case there is nothing to add.
+func (c *Controller) buildNormalizationPatch(chi *api.ClickHouseInstallation, raw []byte) ([]byte, error) {
+	object := make(map[string]interface{})
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return nil, err
+	}
+
+	// Normalizer keeps context of the CHI being normalized, so each request has to have its own one
+	n := normalizer.NewNormalizer(func(namespace, name string) (*core.Secret, error) {
+		return c.kubeClient.CoreV1().Secrets(namespace).Get(context.TODO(), name, controller.NewGetOptions())
+	})
+	normalized, err := n.CreateTemplatedCHI(chi.DeepCopy(), normalizer.NewOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	patch := newJSONPatch(object)
+	materializeDefaults(patch, normalized)
+	if len(patch.ops) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(patch.ops)
+}
+
+// materializeDefaults adds to the patch defaults and generated names of the normalized CHI
+func materializeDefaults(patch *jsonPatch, normalized *api.ClickHouseInstallation) {
+	if defaults := normalized.Spec.Defaults; defaults != nil {
+		if defaults.ReplicasUseFQDN != nil {
+			patch.addIfAbsent(defaults.ReplicasUseFQDN, "spec", "defaults", "replicasUseFQDN")
+		}
+		if defaults.InterserverService != nil {
+			patch.addIfAbsent(defaults.InterserverService, "spec", "defaults", "interserverService")
+		}
+		if defaults.CreateDistributedTables != nil {
+			patch.addIfAbsent(defaults.CreateDistributedTables, "spec", "defaults", "createDistributedTables")
+		}
+	}
+
+	if normalized.Spec.Configuration == nil {
+		return
+	}
+	// Only clusters, shards and replicas specified in the CHI are named,
+	// clusters provided by templates and shards or replicas provided by counters are kept implicit
+	scale := normalized.GetScale()
+	for i, cluster := range normalized.Spec.Configuration.Clusters {
+		if cluster.Name != "" {
+			patch.addIfAbsent(cluster.Name, "spec", "configuration", "clusters", i, "name")
+		}
+		if cluster.Layout == nil {
+			continue
+		}
+		// Counters of the cluster scaled by /scale subresource are overridden and are not stored.
+		// Counters of the cluster with explicit shards or replicas are not stored either, since counters are
+		// not less than lengths of the lists, so stored counters would keep removed shards and replicas
+		explicit := patch.has("spec", "configuration", "clusters", i, "layout", "shards") ||
+			patch.has("spec", "configuration", "clusters", i, "layout", "replicas")
+		if !explicit && (!scale.HasReplicas() || !scale.IsScaled(cluster, i)) {
+			patch.addIfAbsent(cluster.Layout.ShardsCount, "spec", "configuration", "clusters", i, "layout", "shardsCount")
+			patch.addIfAbsent(cluster.Layout.ReplicasCount, "spec", "configuration", "clusters", i, "layout", "replicasCount")
+		}
+		for j := range cluster.Layout.Shards {
+			patch.addIfAbsent(cluster.Layout.Shards[j].Name, "spec", "configuration", "clusters", i, "layout", "shards", j, "name")
+		}
+		for j := range cluster.Layout.Replicas {
+			patch.addIfAbsent(cluster.Layout.Replicas[j].Name, "spec", "configuration", "clusters", i, "layout", "replicas", j, "name")
+		}
+	}
+}
+
+// jsonPatch accumulates operations of JSON patch adding fields absent in the object being admitted
+type jsonPatch struct {
+	object map[string]interface{}
+	ops    []jsonPatchOperation
+}
+
+// newJSONPatch creates new JSON patch of the object
+func newJSONPatch(object map[string]interface{}) *jsonPatch {
+	return &jsonPatch{
+		object: object,
+	}
+}
+
+// has checks whether the object has value at the path. Path is specified the same way as for addIfAbsent
+func (p *jsonPatch) has(path ...interface{}) bool {
+	var current interface{} = p.object
+	for _, segment := range path {
+		switch typed := segment.(type) {
+		case string:
+			container, ok := current.(map[string]interface{})
+			if !ok {
+				return false
+			}
+			current = container[typed]
+		case int:
+			container, ok := current.([]interface{})
+			if !ok || (typed >= len(container)) {
+				return false
+			}
+			current = container[typed]
+		default:
+			return false
+		}
+		if current == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// addIfAbsent adds operation setting value at the path, in case the object has no value there.
+// Path consists of field names and indexes of list items. Absent objects along the path are added as well,
+// while absent list items are not, so nothing is added for them.
+func (p *jsonPatch) addIfAbsent(value interface{}, path ...interface{}) {
+	var current interface{} = p.object
+	pointer := ""
+	for i, segment := range path {
+		pointer += fmt.Sprintf("/%v", segment)
+		last := i == len(path)-1
+
+		switch typed := segment.(type) {
+		case string:
+			container, ok := current.(map[string]interface{})
+			if !ok {
+				return
+			}
+			next, found := container[typed]
+			switch {
+			case found && last:
+				// Specified explicitly
+				return
+			case last:
+				p.ops = append(p.ops, jsonPatchOperation{Op: "add", Path: pointer, Value: value})
+				container[typed] = value
+				return
+			case !found || (next == nil):
+				// Object being tracked is filled along with subsequent operations, so the operation gets its own one
+				p.ops = append(p.ops, jsonPatchOperation{Op: "add", Path: pointer, Value: map[string]interface{}{}})
+				next = make(map[string]interface{})
+				container[typed] = next
+			}
+			current = next
+		case int:
+			container, ok := current.([]interface{})
+			if !ok || (typed >= len(container)) || last {
+				return
+			}
+			current = container[typed]
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package chi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	admission "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+func Test_MutatingWebhookHandler_SkipsDelete(t *testing.T) {
+	c := &Controller{}
+
+	body, _ := json.Marshal(&admission.AdmissionReview{
+		Request: &admission.AdmissionRequest{
+			UID:       types.UID("uid-1"),
+			Operation: admission.Delete,
+		},
+	})
+	recorder := httptest.NewRecorder()
+	c.MutatingWebhookHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/mutate-chi", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	review := &admission.AdmissionReview{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), review))
+	require.Nil(t, review.Request)
+	require.Equal(t, types.UID("uid-1"), review.Response.UID)
+	require.True(t, review.Response.Allowed)
+	require.Nil(t, review.Response.Patch)
+
+	// Malformed review is rejected
+	recorder = httptest.NewRecorder()
+	c.MutatingWebhookHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/mutate-chi", bytes.NewReader([]byte("{"))))
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func Test_Mutate_MaterializesDefaults(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+	c := &Controller{
+		kubeClient: kubeFake.NewSimpleClientset(),
+	}
+
+	raw := []byte(`{
+		"apiVersion": "clickhouse.altinity.com/v1",
+		"kind": "ClickHouseInstallation",
+		"metadata": {"namespace": "ns", "name": "chi"},
+		"spec": {
+			"defaults": {"replicasUseFQDN": "yes"},
+			"configuration": {
+				"users": {"user/k8s_secret_password": "ns/secret/password"},
+				"clusters": [
+					{"layout": {"shards": [{"replicasCount": 2}]}},
+					{"name": "second", "layout": {"shardsCount": 2}},
+					{"name": "third", "layout": {"replicas": [{}, {}]}}
+				]
+			}
+		}
+	}`)
+	response := c.mutate(&admission.AdmissionRequest{
+		Namespace: "ns",
+		Name:      "chi",
+		Operation: admission.Create,
+		Object:    runtime.RawExtension{Raw: raw},
+	})
+	require.True(t, response.Allowed)
+	require.NotNil(t, response.PatchType)
+
+	var ops []jsonPatchOperation
+	require.NoError(t, json.Unmarshal(response.Patch, &ops))
+	patch := make(map[string]interface{})
+	for _, op := range ops {
+		require.Equal(t, "add", op.Op)
+		patch[op.Path] = op.Value
+	}
+
+	// Absent defaults and generated names are added
+	require.Equal(t, "False", patch["/spec/defaults/interserverService"])
+	require.Equal(t, "0", patch["/spec/configuration/clusters/0/layout/shards/0/name"])
+	require.Equal(t, "1", patch["/spec/configuration/clusters/2/layout/replicas/1/name"])
+	require.EqualValues(t, 1, patch["/spec/configuration/clusters/1/layout/replicasCount"])
+
+	// Specified fields are kept as is, neither secrets nor implicit shards are materialized
+	require.NotContains(t, patch, "/spec/defaults")
+	require.NotContains(t, patch, "/spec/defaults/replicasUseFQDN")
+	require.NotContains(t, patch, "/spec/configuration/clusters/0/name")
+	require.NotContains(t, patch, "/spec/configuration/clusters/1/name")
+	require.NotContains(t, patch, "/spec/configuration/clusters/1/layout/shardsCount")
+	require.NotContains(t, patch, "/spec/configuration/clusters/1/layout/shards/0/name")
+	require.NotContains(t, patch, "/spec/configuration/users")
+
+	// Counters of clusters with explicit shards or replicas are not stored, so removal of shards or replicas
+	// from the lists shrinks the clusters
+	for _, path := range []string{
+		"/spec/configuration/clusters/0/layout/shardsCount",
+		"/spec/configuration/clusters/0/layout/replicasCount",
+		"/spec/configuration/clusters/2/layout/shardsCount",
+		"/spec/configuration/clusters/2/layout/replicasCount",
+	} {
+		require.NotContains(t, patch, path)
+	}
+	require.NotContains(t, patch, "/spec/taskID")
+
+	// Malformed object is not patched
+	_, err := c.buildNormalizationPatch(&api.ClickHouseInstallation{}, []byte("{"))
+	require.Error(t, err)
+}
+
+func Test_JSONPatch_AddIfAbsent(t *testing.T) {
+	object := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"list": []interface{}{map[string]interface{}{"name": "specified"}},
+		},
+	}
+	patch := newJSONPatch(object)
+	patch.addIfAbsent("a", "spec", "defaults", "first")
+	patch.addIfAbsent("b", "spec", "defaults", "second")
+	patch.addIfAbsent("c", "spec", "list", 0, "name")
+	patch.addIfAbsent("d", "spec", "list", 1, "name")
+	patch.addIfAbsent("e", "spec", "list", 0, "other")
+	require.True(t, patch.has("spec", "list", 0, "name"))
+	require.True(t, patch.has("spec", "defaults"))
+	require.False(t, patch.has("spec", "list", 1))
+	require.False(t, patch.has("spec", "absent"))
+	require.False(t, patch.has("spec", "defaults", "first", "nested"))
+
+	require.Equal(t, []jsonPatchOperation{
+		{Op: "add", Path: "/spec/defaults", Value: map[string]interface{}{}},
+		{Op: "add", Path: "/spec/defaults/first", Value: "a"},
+		{Op: "add", Path: "/spec/defaults/second", Value: "b"},
+		{Op: "add", Path: "/spec/list/0/other", Value: "e"},
+	}, patch.ops)
+}
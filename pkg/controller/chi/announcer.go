@@ -40,6 +40,8 @@ type Announcer struct {
 	eventAction string
 	// event reason specifies k8s event reason
 	eventReason string
+	// aggregateEvent specifies whether repetitive k8s event can be aggregated into periodic summary event
+	aggregateEvent bool
 
 	// writeStatusAction specifies whether to produce action into `ClickHouseInstallation.Status.Action` of chi,
 	// therefore requires chi to be specified
@@ -134,10 +136,14 @@ func (a Announcer) Info(format string, args ...interface{}) {
 
 	// Produce k8s event
 	if a.writeEvent && a.chiCapable() {
+		message := fmt.Sprint(format)
 		if len(args) > 0 {
-			a.ctrl.EventInfo(a.chi, a.eventAction, a.eventReason, fmt.Sprintf(format, args...))
+			message = fmt.Sprintf(format, args...)
+		}
+		if a.aggregateEvent {
+			a.ctrl.EventInfoAggregated(a.chi, a.eventAction, a.eventReason, message)
 		} else {
-			a.ctrl.EventInfo(a.chi, a.eventAction, a.eventReason, fmt.Sprint(format))
+			a.ctrl.EventInfo(a.chi, a.eventAction, a.eventReason, message)
 		}
 	}
 
@@ -152,6 +158,8 @@ func (a Announcer) Warning(format string, args ...interface{}) {
 
 	// Produce k8s event
 	if a.writeEvent && a.chiCapable() {
+		// Failures are never aggregated, however they should follow already aggregated events
+		a.ctrl.FlushEvents(a.chi)
 		if len(args) > 0 {
 			a.ctrl.EventWarning(a.chi, a.eventAction, a.eventReason, fmt.Sprintf(format, args...))
 		} else {
@@ -170,6 +178,8 @@ func (a Announcer) Error(format string, args ...interface{}) {
 
 	// Produce k8s event
 	if a.writeEvent && a.chiCapable() {
+		// Failures are never aggregated, however they should follow already aggregated events
+		a.ctrl.FlushEvents(a.chi)
 		if len(args) > 0 {
 			a.ctrl.EventError(a.chi, a.eventAction, a.eventReason, fmt.Sprintf(format, args...))
 		} else {
@@ -185,6 +195,8 @@ func (a Announcer) Error(format string, args ...interface{}) {
 func (a Announcer) Fatal(format string, args ...interface{}) {
 	// Produce k8s event
 	if a.writeEvent && a.chiCapable() {
+		// Failures are never aggregated, however they should follow already aggregated events
+		a.ctrl.FlushEvents(a.chi)
 		if len(args) > 0 {
 			a.ctrl.EventError(a.chi, a.eventAction, a.eventReason, fmt.Sprintf(format, args...))
 		} else {
@@ -227,6 +239,14 @@ func (a Announcer) WithEvent(
 	return b
 }
 
+// WithEventAggregation is used in chained calls in order to allow repetitive info event to be aggregated
+// into periodic summary event. Warning and error events are never aggregated.
+func (a Announcer) WithEventAggregation() Announcer {
+	b := a
+	b.aggregateEvent = true
+	return b
+}
+
 // WithStatusAction is used in chained calls in order to produce action into `ClickHouseInstallation.Status.Action`
 func (a Announcer) WithStatusAction(chi *api.ClickHouseInstallation) Announcer {
 	b := a
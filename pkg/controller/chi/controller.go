@@ -18,6 +18,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/sanity-io/litter"
@@ -30,6 +31,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	utilRuntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
 	kubeInformers "k8s.io/client-go/informers"
 	kube "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -56,6 +58,7 @@ func NewController(
 	chopClient chopClientSet.Interface,
 	extClient apiExtensions.Interface,
 	kubeClient kube.Interface,
+	dynamicClient dynamic.Interface,
 	chopInformerFactory chopInformers.SharedInformerFactory,
 	kubeInformerFactory kubeInformers.SharedInformerFactory,
 ) *Controller {
@@ -81,12 +84,16 @@ func NewController(
 	// Create Controller instance
 	controller := &Controller{
 		kubeClient:              kubeClient,
+		dynamicClient:           dynamicClient,
 		extClient:               extClient,
 		chopClient:              chopClient,
 		chiLister:               chopInformerFactory.Clickhouse().V1().ClickHouseInstallations().Lister(),
 		chiListerSynced:         chopInformerFactory.Clickhouse().V1().ClickHouseInstallations().Informer().HasSynced,
 		chitLister:              chopInformerFactory.Clickhouse().V1().ClickHouseInstallationTemplates().Lister(),
 		chitListerSynced:        chopInformerFactory.Clickhouse().V1().ClickHouseInstallationTemplates().Informer().HasSynced,
+		chbLister:               chopInformerFactory.Clickhouse().V1().ClickHouseBackups().Lister(),
+		chrLister:               chopInformerFactory.Clickhouse().V1().ClickHouseRestores().Lister(),
+		chuLister:               chopInformerFactory.Clickhouse().V1().ClickHouseUsers().Lister(),
 		serviceLister:           kubeInformerFactory.Core().V1().Services().Lister(),
 		serviceListerSynced:     kubeInformerFactory.Core().V1().Services().Informer().HasSynced,
 		endpointsLister:         kubeInformerFactory.Core().V1().Endpoints().Lister(),
@@ -97,18 +104,26 @@ func NewController(
 		statefulSetListerSynced: kubeInformerFactory.Apps().V1().StatefulSets().Informer().HasSynced,
 		podLister:               kubeInformerFactory.Core().V1().Pods().Lister(),
 		podListerSynced:         kubeInformerFactory.Core().V1().Pods().Informer().HasSynced,
+		secretLister:            kubeInformerFactory.Core().V1().Secrets().Lister(),
+		secretIndex:             newSecretIndex(),
+		accessTLS:               newAccessTLSCache(),
 		recorder:                recorder,
+		eventAggregator:         newEventAggregator(chop.Config().GetEventsAggregationWindow()),
+		health:                  newHealthTracker(),
+		chiLocker:               newKeyedLocker(),
+		statefulSetSemaphore:    newSemaphore(chop.Config().Reconcile.StatefulSet.MaxConcurrentOperations),
 	}
+	controller.debouncer = newDebouncer(chop.Config().GetReconcileCHIsDebounceWindow(), controller.enqueueReconcileCHI)
 	controller.initQueues()
 	controller.addEventHandlers(chopInformerFactory, kubeInformerFactory)
 
 	return controller
 }
 
-// initQueues
+// initQueues creates system queues, each one served by its own worker, followed by CHI queue,
+// which is served by multiple workers
 func (c *Controller) initQueues() {
-	queuesNum := chop.Config().Reconcile.Runtime.ReconcileCHIsThreadsNumber + api.DefaultReconcileSystemThreadsNumber
-	for i := 0; i < queuesNum; i++ {
+	for i := 0; i < api.DefaultReconcileSystemThreadsNumber; i++ {
 		c.queues = append(
 			c.queues,
 			queue.New(),
@@ -118,6 +133,16 @@ func (c *Controller) initQueues() {
 			//),
 		)
 	}
+	// CHI commands are keyed by CHI, so a slow CHI does not block reconcile of other CHIs
+	c.queues = append(c.queues, newKeyedQueue())
+	metricsObserveQueues("system", c.queues[:api.DefaultReconcileSystemThreadsNumber]...)
+	metricsObserveQueues("chi", c.getReconcileCHIQueue())
+	c.workersNum = api.DefaultReconcileSystemThreadsNumber + chop.Config().Reconcile.Runtime.ReconcileCHIsThreadsNumber
+}
+
+// getReconcileCHIQueue gets queue of CHI commands
+func (c *Controller) getReconcileCHIQueue() queue.PriorityQueue {
+	return c.queues[api.DefaultReconcileSystemThreadsNumber]
 }
 
 func (c *Controller) addEventHandlersCHI(
@@ -147,6 +172,8 @@ func (c *Controller) addEventHandlersCHI(
 				return
 			}
 			log.V(3).M(chi).Info("chiInformer.DeleteFunc")
+			c.secretIndex.Remove(chi.Namespace, chi.Name)
+			c.accessTLS.Remove(chi.Namespace, chi.Name)
 			c.enqueueObject(NewReconcileCHI(reconcileDelete, chi, nil))
 		},
 	})
@@ -216,6 +243,114 @@ func (c *Controller) addEventHandlersChopConfig(
 	})
 }
 
+func (c *Controller) addEventHandlersCHB(
+	chopInformerFactory chopInformers.SharedInformerFactory,
+) {
+	chopInformerFactory.Clickhouse().V1().ClickHouseBackups().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			chb := obj.(*api.ClickHouseBackup)
+			if !chop.Config().IsWatchedNamespace(chb.Namespace) {
+				return
+			}
+			log.V(3).M(chb).Info("chbInformer.AddFunc")
+			c.enqueueObject(NewReconcileBackup(reconcileAdd, nil, chb))
+		},
+		UpdateFunc: func(old, new interface{}) {
+			oldChb := old.(*api.ClickHouseBackup)
+			newChb := new.(*api.ClickHouseBackup)
+			if !chop.Config().IsWatchedNamespace(newChb.Namespace) {
+				return
+			}
+			if oldChb.GetGeneration() == newChb.GetGeneration() {
+				// Status update made by the operator itself, progress is polled on requeue
+				return
+			}
+			log.V(3).M(newChb).Info("chbInformer.UpdateFunc")
+			c.enqueueObject(NewReconcileBackup(reconcileUpdate, oldChb, newChb))
+		},
+		DeleteFunc: func(obj interface{}) {
+			chb, ok := obj.(*api.ClickHouseBackup)
+			if !ok || !chop.Config().IsWatchedNamespace(chb.Namespace) {
+				return
+			}
+			log.V(3).M(chb).Info("chbInformer.DeleteFunc")
+			c.enqueueObject(NewReconcileBackup(reconcileDelete, chb, nil))
+		},
+	})
+}
+
+func (c *Controller) addEventHandlersCHR(
+	chopInformerFactory chopInformers.SharedInformerFactory,
+) {
+	chopInformerFactory.Clickhouse().V1().ClickHouseRestores().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			chr := obj.(*api.ClickHouseRestore)
+			if !chop.Config().IsWatchedNamespace(chr.Namespace) {
+				return
+			}
+			log.V(3).M(chr).Info("chrInformer.AddFunc")
+			c.enqueueObject(NewReconcileRestore(reconcileAdd, nil, chr))
+		},
+		UpdateFunc: func(old, new interface{}) {
+			oldChr := old.(*api.ClickHouseRestore)
+			newChr := new.(*api.ClickHouseRestore)
+			if !chop.Config().IsWatchedNamespace(newChr.Namespace) {
+				return
+			}
+			if oldChr.GetGeneration() == newChr.GetGeneration() {
+				// Status update made by the operator itself, progress is polled on requeue
+				return
+			}
+			log.V(3).M(newChr).Info("chrInformer.UpdateFunc")
+			c.enqueueObject(NewReconcileRestore(reconcileUpdate, oldChr, newChr))
+		},
+		DeleteFunc: func(obj interface{}) {
+			chr, ok := obj.(*api.ClickHouseRestore)
+			if !ok || !chop.Config().IsWatchedNamespace(chr.Namespace) {
+				return
+			}
+			log.V(3).M(chr).Info("chrInformer.DeleteFunc")
+			c.enqueueObject(NewReconcileRestore(reconcileDelete, chr, nil))
+		},
+	})
+}
+
+func (c *Controller) addEventHandlersCHU(
+	chopInformerFactory chopInformers.SharedInformerFactory,
+) {
+	chopInformerFactory.Clickhouse().V1().ClickHouseUsers().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			chu := obj.(*api.ClickHouseUser)
+			if !chop.Config().IsWatchedNamespace(chu.Namespace) {
+				return
+			}
+			log.V(3).M(chu).Info("chuInformer.AddFunc")
+			c.enqueueObject(NewReconcileUser(reconcileAdd, nil, chu))
+		},
+		UpdateFunc: func(old, new interface{}) {
+			oldChu := old.(*api.ClickHouseUser)
+			newChu := new.(*api.ClickHouseUser)
+			if !chop.Config().IsWatchedNamespace(newChu.Namespace) {
+				return
+			}
+			if oldChu.GetGeneration() == newChu.GetGeneration() {
+				// Status update made by the operator itself
+				return
+			}
+			log.V(3).M(newChu).Info("chuInformer.UpdateFunc")
+			c.enqueueObject(NewReconcileUser(reconcileUpdate, oldChu, newChu))
+		},
+		DeleteFunc: func(obj interface{}) {
+			chu, ok := obj.(*api.ClickHouseUser)
+			if !ok || !chop.Config().IsWatchedNamespace(chu.Namespace) {
+				return
+			}
+			log.V(3).M(chu).Info("chuInformer.DeleteFunc")
+			c.enqueueObject(NewReconcileUser(reconcileDelete, chu, nil))
+		},
+	})
+}
+
 func (c *Controller) addEventHandlersService(
 	kubeInformerFactory kubeInformers.SharedInformerFactory,
 ) {
@@ -376,6 +511,37 @@ func (c *Controller) addEventHandlersConfigMap(
 	})
 }
 
+func (c *Controller) addEventHandlersSecret(
+	kubeInformerFactory kubeInformers.SharedInformerFactory,
+) {
+	informer := kubeInformerFactory.Core().V1().Secrets().Informer()
+	// Data of secrets no CHI refers to is not kept in cache
+	if err := informer.SetTransform(c.transformSecret); err != nil {
+		log.V(1).F().Error("unable to set secret transform err: %v", err)
+	}
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, new interface{}) {
+			oldSecret := old.(*core.Secret)
+			newSecret := new.(*core.Secret)
+			if reflect.DeepEqual(oldSecret.Data, newSecret.Data) {
+				return
+			}
+			switch {
+			case chop.Get().ConfigManager.IsSecretCredentials(newSecret.Namespace, newSecret.Name):
+				// Secret with credentials of the operator may reside in the namespace not watched
+			case !chop.Config().IsWatchedNamespace(newSecret.Namespace) || model.IsCHOPGeneratedObject(&newSecret.ObjectMeta):
+				// Secrets generated by the operator are not rotated
+				return
+			case !c.secretIndex.Has(newSecret.Namespace, newSecret.Name):
+				// No CHI renders values of the secret into config
+				return
+			}
+			log.V(3).M(newSecret).Info("secretInformer.UpdateFunc")
+			c.enqueueObject(NewSecretRotation(&newSecret.ObjectMeta))
+		},
+	})
+}
+
 func (c *Controller) addEventHandlersStatefulSet(
 	kubeInformerFactory kubeInformers.SharedInformerFactory,
 ) {
@@ -426,6 +592,9 @@ func (c *Controller) addEventHandlersPod(
 			}
 			log.V(3).M(newPod).Info("podInformer.UpdateFunc")
 			c.enqueueObject(NewReconcilePod(reconcileUpdate, oldPod, newPod))
+			if isPodDisruptionChanged(oldPod, newPod) {
+				c.enqueueObject(NewPodDisruption(&newPod.ObjectMeta))
+			}
 		},
 		DeleteFunc: func(obj interface{}) {
 			pod := obj.(*core.Pod)
@@ -446,9 +615,13 @@ func (c *Controller) addEventHandlers(
 	c.addEventHandlersCHI(chopInformerFactory)
 	c.addEventHandlersCHIT(chopInformerFactory)
 	c.addEventHandlersChopConfig(chopInformerFactory)
+	c.addEventHandlersCHB(chopInformerFactory)
+	c.addEventHandlersCHR(chopInformerFactory)
+	c.addEventHandlersCHU(chopInformerFactory)
 	c.addEventHandlersService(kubeInformerFactory)
 	c.addEventHandlersEndpoint(kubeInformerFactory)
 	c.addEventHandlersConfigMap(kubeInformerFactory)
+	c.addEventHandlersSecret(kubeInformerFactory)
 	c.addEventHandlersStatefulSet(kubeInformerFactory)
 	c.addEventHandlersPod(kubeInformerFactory)
 }
@@ -499,15 +672,18 @@ func (c *Controller) Run(ctx context.Context) {
 	//
 	// Start threads
 	//
-	workersNum := len(c.queues)
+	workersNum := c.workersNum
 	log.V(1).F().Info("ClickHouseInstallation controller: starting workers number: %d", workersNum)
 	for i := 0; i < workersNum; i++ {
 		log.V(1).F().Info("ClickHouseInstallation controller: starting worker %d out of %d", i+1, workersNum)
+		// System workers are served by own queue each, the rest of workers share CHI queue
+		q := c.getReconcileCHIQueue()
 		sys := false
 		if i < api.DefaultReconcileSystemThreadsNumber {
+			q = c.queues[i]
 			sys = true
 		}
-		worker := c.newWorker(c.queues[i], sys)
+		worker := c.newWorker(q, sys)
 		go wait.Until(worker.run, runWorkerPeriod, ctx.Done())
 	}
 	defer log.V(1).F().Info("ClickHouseInstallation controller: shutting down workers")
@@ -579,20 +755,24 @@ func (c *Controller) enqueueObject(obj queue.PriorityQueueItem) {
 	enqueue := false
 	switch command := obj.(type) {
 	case *ReconcileCHI:
-		variants := len(c.queues) - api.DefaultReconcileSystemThreadsNumber
-		index = api.DefaultReconcileSystemThreadsNumber + util.HashIntoIntTopped(handle, variants)
-		switch command.cmd {
-		case reconcileAdd:
-			enqueue = prepareCHIAdd(command)
-		case reconcileUpdate:
-			enqueue = prepareCHIUpdate(command)
+		if c.debouncer.postpone(command) {
+			// Command would be enqueued later, coalesced with subsequent commands of the same CHI
+			log.V(2).Info("enqueue postponed: %s", command.Handle())
+			return
 		}
+		c.enqueueReconcileCHI(command)
+		return
 	case
 		*ReconcileCHIT,
 		*ReconcileChopConfig,
+		*ReconcileBackup,
+		*ReconcileRestore,
+		*ReconcileUser,
 		*ReconcileEndpoints,
 		*ReconcilePod,
-		*DropDns:
+		*DropDns,
+		*PodDisruption,
+		*SecretRotation:
 		variants := api.DefaultReconcileSystemThreadsNumber
 		index = util.HashIntoIntTopped(handle, variants)
 		enqueue = true
@@ -603,6 +783,70 @@ func (c *Controller) enqueueObject(obj queue.PriorityQueueItem) {
 	}
 }
 
+// enqueueReconcileCHI enqueues CHI reconcile command
+func (c *Controller) enqueueReconcileCHI(command *ReconcileCHI) {
+	enqueue := false
+	switch command.cmd {
+	case reconcileAdd:
+		enqueue = prepareCHIAdd(command)
+	case reconcileUpdate:
+		enqueue = prepareCHIUpdate(command)
+	}
+	if enqueue {
+		c.getReconcileCHIQueue().Insert(command)
+	}
+}
+
+// requeueCHI enqueues reconcile of the CHI once again after the specified delay
+func (c *Controller) requeueCHI(chi *api.ClickHouseInstallation, delay time.Duration) {
+	namespace, name := chi.Namespace, chi.Name
+	time.AfterFunc(delay, func() {
+		cur, err := c.chiLister.ClickHouseInstallations(namespace).Get(name)
+		if err != nil {
+			log.V(1).Info("Unable to requeue CHI %s/%s err: %v", namespace, name, err)
+			return
+		}
+		log.V(1).Info("Requeue CHI %s/%s", namespace, name)
+		c.enqueueObject(NewReconcileCHI(reconcileAdd, nil, cur))
+	})
+}
+
+// requeueBackup enqueues reconcile of the backup once again after the specified delay
+func (c *Controller) requeueBackup(chb *api.ClickHouseBackup, delay time.Duration) {
+	namespace, name := chb.Namespace, chb.Name
+	time.AfterFunc(delay, func() {
+		cur, err := c.chbLister.ClickHouseBackups(namespace).Get(name)
+		if err != nil {
+			log.V(1).Info("Unable to requeue CHB %s/%s err: %v", namespace, name, err)
+			return
+		}
+		log.V(2).Info("Requeue CHB %s/%s", namespace, name)
+		c.enqueueObject(NewReconcileBackup(reconcileUpdate, nil, cur))
+	})
+}
+
+// requeueRestore enqueues reconcile of the restore once again after the specified delay
+func (c *Controller) requeueRestore(chr *api.ClickHouseRestore, delay time.Duration) {
+	namespace, name := chr.Namespace, chr.Name
+	time.AfterFunc(delay, func() {
+		cur, err := c.chrLister.ClickHouseRestores(namespace).Get(name)
+		if err != nil {
+			log.V(1).Info("Unable to requeue CHR %s/%s err: %v", namespace, name, err)
+			return
+		}
+		log.V(2).Info("Requeue CHR %s/%s", namespace, name)
+		c.enqueueObject(NewReconcileRestore(reconcileUpdate, nil, cur))
+	})
+}
+
+// requeueItem enqueues the item once again after the specified delay
+func (c *Controller) requeueItem(item queue.PriorityQueueItem, delay time.Duration) {
+	time.AfterFunc(delay, func() {
+		log.V(2).Info("Requeue %s", item.Handle())
+		c.enqueueObject(item)
+	})
+}
+
 // updateWatch
 func (c *Controller) updateWatch(chi *api.ClickHouseInstallation) {
 	watched := metrics.NewWatchedCHI(chi)
@@ -724,6 +968,9 @@ func (c *Controller) patchCHIFinalizers(ctx context.Context, chi *api.ClickHouse
 type UpdateCHIStatusOptions struct {
 	api.CopyCHIStatusOptions
 	TolerateAbsence bool
+	// ObservedGeneration is the generation of the CHI, reconcile of which completed.
+	// Being specified, it is published as observed generation in the status
+	ObservedGeneration int64
 }
 
 // updateCHIObjectStatus updates ClickHouseInstallation object's Status
@@ -784,6 +1031,9 @@ func (c *Controller) doUpdateCHIObjectStatus(ctx context.Context, chi *api.Click
 	// Update status of a real object.
 	cur.EnsureStatus().CopyFrom(chi.Status, opts.CopyCHIStatusOptions)
 	cur.EnsureStatus().SetPodIPs(podIPs)
+	if opts.ObservedGeneration > 0 {
+		cur.EnsureStatus().SetObservedGeneration(opts.ObservedGeneration)
+	}
 
 	_new, err := c.chopClient.ClickhouseV1().ClickHouseInstallations(chi.Namespace).UpdateStatus(ctx, cur, controller.NewUpdateOptions())
 	if err != nil {
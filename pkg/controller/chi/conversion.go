@@ -0,0 +1,145 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	apiExtensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	apiV2 "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v2"
+)
+
+// chiConverter converts CHI of one API version to and from the hub version
+type chiConverter struct {
+	toHub   func(raw []byte) (*api.ClickHouseInstallation, error)
+	fromHub func(hub *api.ClickHouseInstallation) interface{}
+}
+
+// chiConverters lists converters of all API versions of CHI, indexed by apiVersion.
+// Version v1 is the storage one and serves as the hub all other versions are converted through.
+var chiConverters = map[string]chiConverter{
+	api.SchemeGroupVersion.String(): {
+		toHub: func(raw []byte) (*api.ClickHouseInstallation, error) {
+			chi := &api.ClickHouseInstallation{}
+			err := json.Unmarshal(raw, chi)
+			return chi, err
+		},
+		fromHub: func(hub *api.ClickHouseInstallation) interface{} {
+			hub.APIVersion = api.SchemeGroupVersion.String()
+			return hub
+		},
+	},
+	apiV2.SchemeGroupVersion.String(): {
+		toHub: func(raw []byte) (*api.ClickHouseInstallation, error) {
+			chi := &apiV2.ClickHouseInstallation{}
+			if err := json.Unmarshal(raw, chi); err != nil {
+				return nil, err
+			}
+			hub := &api.ClickHouseInstallation{}
+			chi.ConvertTo(hub)
+			return hub, nil
+		},
+		fromHub: func(hub *api.ClickHouseInstallation) interface{} {
+			chi := &apiV2.ClickHouseInstallation{}
+			chi.ConvertFrom(hub)
+			return chi
+		},
+	},
+}
+
+// ConversionWebhookHandler returns HTTP handler of the CRD conversion webhook.
+// Webhook converts CHI between API versions through the hub version, so the schema is able to evolve
+// with round-trip conversion done in Go.
+func (c *Controller) ConversionWebhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		review := &apiExtensions.ConversionReview{}
+		if err := json.NewDecoder(r.Body).Decode(review); err != nil || review.Request == nil {
+			http.Error(w, fmt.Sprintf("unable to decode conversion review: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		review.Response = convert(review.Request)
+		review.Response.UID = review.Request.UID
+		review.Request = nil
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(review)
+	})
+}
+
+// convert converts all objects of the request into the desired API version.
+// Conversion fails as a whole in case any of the objects can not be converted.
+func convert(request *apiExtensions.ConversionRequest) *apiExtensions.ConversionResponse {
+	response := &apiExtensions.ConversionResponse{
+		Result: meta.Status{
+			Status: meta.StatusSuccess,
+		},
+	}
+
+	for _, obj := range request.Objects {
+		converted, err := convertCHI(obj.Raw, request.DesiredAPIVersion)
+		if err != nil {
+			log.V(1).F().Warning("unable to convert object to %s err: %v", request.DesiredAPIVersion, err)
+			response.ConvertedObjects = nil
+			response.Result = meta.Status{
+				Status:  meta.StatusFailure,
+				Message: err.Error(),
+			}
+			return response
+		}
+		response.ConvertedObjects = append(response.ConvertedObjects, runtime.RawExtension{Raw: converted})
+	}
+
+	return response
+}
+
+// convertCHI converts serialized CHI or CHIT into the desired API version
+func convertCHI(raw []byte, desiredAPIVersion string) ([]byte, error) {
+	typeMeta := meta.TypeMeta{}
+	if err := json.Unmarshal(raw, &typeMeta); err != nil {
+		return nil, err
+	}
+	// Both CRDs share the schema, so they share conversion as well
+	switch typeMeta.Kind {
+	case api.ClickHouseInstallationCRDResourceKind, api.ClickHouseInstallationTemplateCRDResourceKind:
+	default:
+		return nil, fmt.Errorf("unsupported kind %q", typeMeta.Kind)
+	}
+	if typeMeta.APIVersion == desiredAPIVersion {
+		return raw, nil
+	}
+
+	from, ok := chiConverters[typeMeta.APIVersion]
+	if !ok {
+		return nil, fmt.Errorf("unsupported apiVersion %q", typeMeta.APIVersion)
+	}
+	to, ok := chiConverters[desiredAPIVersion]
+	if !ok {
+		return nil, fmt.Errorf("unsupported desired apiVersion %q", desiredAPIVersion)
+	}
+
+	hub, err := from.toHub(raw)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(to.fromHub(hub))
+}
@@ -0,0 +1,68 @@
+package chi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apiExtensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func Test_Convert_RoundTrip(t *testing.T) {
+	v1 := []byte(`{"apiVersion":"clickhouse.altinity.com/v1","kind":"ClickHouseInstallation",` +
+		`"metadata":{"name":"chi","namespace":"ns"},"spec":{"taskID":"task","stop":"yes","restart":"RollingUpdate"}}`)
+
+	response := convert(&apiExtensions.ConversionRequest{
+		DesiredAPIVersion: "clickhouse.altinity.com/v2",
+		Objects:           []runtime.RawExtension{{Raw: v1}},
+	})
+	require.Equal(t, meta.StatusSuccess, response.Result.Status)
+	require.Len(t, response.ConvertedObjects, 1)
+
+	v2 := response.ConvertedObjects[0].Raw
+	typeMeta := meta.TypeMeta{}
+	require.NoError(t, json.Unmarshal(v2, &typeMeta))
+	require.Equal(t, "clickhouse.altinity.com/v2", typeMeta.APIVersion)
+
+	// Lifecycle fields are grouped in version 2
+	obj := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(v2, &obj))
+	spec := obj["spec"].(map[string]interface{})
+	require.Equal(t, map[string]interface{}{"stop": "yes", "restart": "RollingUpdate"}, spec["lifecycle"])
+	require.NotContains(t, spec, "stop")
+	require.NotContains(t, spec, "restart")
+	require.Equal(t, "task", spec["taskID"])
+
+	back, err := convertCHI(v2, "clickhouse.altinity.com/v1")
+	require.NoError(t, err)
+	// Metadata is serialized by apimachinery, which renders absent creation timestamp as null
+	expected := strings.Replace(string(v1), `"metadata":{`, `"metadata":{"creationTimestamp":null,`, 1)
+	require.JSONEq(t, expected, string(back))
+}
+
+func Test_Convert_Template(t *testing.T) {
+	v2 := []byte(`{"apiVersion":"clickhouse.altinity.com/v2","kind":"ClickHouseInstallationTemplate",` +
+		`"metadata":{"name":"chit"},"spec":{"lifecycle":{"troubleshoot":"no"}}}`)
+
+	v1, err := convertCHI(v2, "clickhouse.altinity.com/v1")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"apiVersion":"clickhouse.altinity.com/v1","kind":"ClickHouseInstallationTemplate",`+
+		`"metadata":{"creationTimestamp":null,"name":"chit"},"spec":{"troubleshoot":"no"}}`, string(v1))
+}
+
+func Test_Convert_FailsOnUnsupported(t *testing.T) {
+	response := convert(&apiExtensions.ConversionRequest{
+		DesiredAPIVersion: "clickhouse.altinity.com/v3",
+		Objects: []runtime.RawExtension{
+			{Raw: []byte(`{"apiVersion":"clickhouse.altinity.com/v1","kind":"ClickHouseInstallation"}`)},
+		},
+	})
+	require.Equal(t, meta.StatusFailure, response.Result.Status)
+	require.Empty(t, response.ConvertedObjects)
+
+	_, err := convertCHI([]byte(`{"apiVersion":"clickhouse.altinity.com/v1","kind":"ClickHouseKeeperInstallation"}`), "clickhouse.altinity.com/v2")
+	require.Error(t, err)
+}
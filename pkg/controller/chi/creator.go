@@ -42,7 +42,13 @@ func (c *Controller) createStatefulSet(ctx context.Context, host *api.ChiHost) E
 	statefulSet := host.Runtime.DesiredStatefulSet
 
 	log.V(1).Info("Create StatefulSet %s/%s", statefulSet.Namespace, statefulSet.Name)
-	if _, err := c.kubeClient.AppsV1().StatefulSets(statefulSet.Namespace).Create(ctx, statefulSet, controller.NewCreateOptions()); err != nil {
+	if err := c.statefulSetSemaphore.Acquire(ctx); err != nil {
+		log.V(2).Info("task is done")
+		return nil
+	}
+	_, err := c.kubeClient.AppsV1().StatefulSets(statefulSet.Namespace).Create(ctx, statefulSet, controller.NewCreateOptions())
+	c.statefulSetSemaphore.Release()
+	if err != nil {
 		log.V(1).M(host).F().Error("StatefulSet create failed. err: %v", err)
 		return errCRUDRecreate
 	}
@@ -72,7 +78,12 @@ func (c *Controller) updateStatefulSet(
 	}
 
 	// Apply newStatefulSet and wait for Generation to change
+	if err := c.statefulSetSemaphore.Acquire(ctx); err != nil {
+		log.V(2).Info("task is done")
+		return nil
+	}
 	updatedStatefulSet, err := c.kubeClient.AppsV1().StatefulSets(newStatefulSet.Namespace).Update(ctx, newStatefulSet, controller.NewUpdateOptions())
+	c.statefulSetSemaphore.Release()
 	if err != nil {
 		log.V(1).M(host).F().Error("StatefulSet update failed. err: %v", err)
 		diff, equal := messagediff.DeepDiff(oldStatefulSet.Spec, newStatefulSet.Spec)
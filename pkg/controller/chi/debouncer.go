@@ -0,0 +1,121 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"sync"
+	"time"
+
+	"github.com/altinity/queue"
+)
+
+// debouncedCommand is a reconcile command postponed within debounce window
+type debouncedCommand struct {
+	command *ReconcileCHI
+}
+
+// debouncer coalesces reconcile commands of a CHI arriving within debounce window into a single command,
+// which reconciles the latest state of the CHI.
+// The window starts with the first command, so continuous stream of updates does not postpone reconcile forever.
+type debouncer struct {
+	window  time.Duration
+	enqueue func(command *ReconcileCHI)
+	mutex   sync.Mutex
+	pending map[queue.T]*debouncedCommand
+}
+
+// newDebouncer creates new debouncer with specified debounce window.
+// Coalesced commands are passed to enqueue function when window is over.
+// Non-positive window means no debounce.
+func newDebouncer(window time.Duration, enqueue func(command *ReconcileCHI)) *debouncer {
+	return &debouncer{
+		window:  window,
+		enqueue: enqueue,
+		pending: make(map[queue.T]*debouncedCommand),
+	}
+}
+
+// isDebounceable checks whether command can be postponed.
+// Deletes are never postponed, because they should not be coalesced away.
+func isDebounceable(command *ReconcileCHI) bool {
+	switch command.cmd {
+	case reconcileAdd, reconcileUpdate:
+	default:
+		return false
+	}
+	if (command.new == nil) || !command.new.DeletionTimestamp.IsZero() {
+		// CHI is being deleted
+		return false
+	}
+	return true
+}
+
+// coalesceCommands coalesces two subsequent commands of the same CHI into one command.
+// The coalesced command transits CHI from the state before the first command to the state after the last one.
+func coalesceCommands(first, last *ReconcileCHI) *ReconcileCHI {
+	return NewReconcileCHI(first.cmd, first.old, last.new)
+}
+
+// postpone postpones command in order to coalesce it with subsequent commands of the same CHI.
+// Returns false in case command is not postponed and has to be enqueued right away.
+func (d *debouncer) postpone(command *ReconcileCHI) bool {
+	if d == nil {
+		return false
+	}
+
+	handle := command.Handle()
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if !isDebounceable(command) {
+		// Command supersedes pending one, which is dropped
+		delete(d.pending, handle)
+		return false
+	}
+
+	if pending, ok := d.pending[handle]; ok {
+		pending.command = coalesceCommands(pending.command, command)
+		return true
+	}
+
+	if d.window <= 0 {
+		return false
+	}
+
+	pending := &debouncedCommand{
+		command: command,
+	}
+	d.pending[handle] = pending
+	time.AfterFunc(d.window, func() {
+		d.flush(handle, pending)
+	})
+	return true
+}
+
+// flush enqueues pending command when debounce window is over
+func (d *debouncer) flush(handle queue.T, pending *debouncedCommand) {
+	d.mutex.Lock()
+	if d.pending[handle] != pending {
+		// Pending command was superseded
+		d.mutex.Unlock()
+		return
+	}
+	delete(d.pending, handle)
+	command := pending.command
+	d.mutex.Unlock()
+
+	d.enqueue(command)
+}
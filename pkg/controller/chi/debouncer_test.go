@@ -0,0 +1,100 @@
+package chi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/altinity/queue"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+func newDebounceTestController(window time.Duration) *Controller {
+	c := &Controller{
+		queues: []queue.PriorityQueue{queue.New(), queue.New()},
+	}
+	c.debouncer = newDebouncer(window, c.enqueueReconcileCHI)
+	return c
+}
+
+func newDebounceTestCHI(generation int64, taskID string) *api.ClickHouseInstallation {
+	return &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace:  "ns",
+			Name:       "chi",
+			Generation: generation,
+		},
+		Spec: api.ChiSpec{
+			TaskID: &taskID,
+		},
+	}
+}
+
+func Test_Debouncer_RapidUpdatesProduceOneReconcile(t *testing.T) {
+	const window = 100 * time.Millisecond
+	c := newDebounceTestController(window)
+	defer c.queues[1].Close()
+
+	chi1 := newDebounceTestCHI(1, "1")
+	chi2 := newDebounceTestCHI(2, "2")
+	chi3 := newDebounceTestCHI(3, "3")
+	chi4 := newDebounceTestCHI(4, "4")
+
+	c.enqueueObject(NewReconcileCHI(reconcileUpdate, chi1, chi2))
+	c.enqueueObject(NewReconcileCHI(reconcileUpdate, chi2, chi3))
+	c.enqueueObject(NewReconcileCHI(reconcileUpdate, chi3, chi4))
+
+	// Nothing is enqueued within the window
+	require.Equal(t, 0, c.queues[1].Len())
+
+	require.Eventually(t, func() bool {
+		return c.queues[1].Len() > 0
+	}, 10*window, window/10)
+	time.Sleep(2 * window)
+	require.Equal(t, 1, c.queues[1].Len())
+
+	item, _, ok := c.queues[1].Get()
+	require.True(t, ok)
+	command := item.(*ReconcileCHI)
+	require.Equal(t, reconcileUpdate, command.cmd)
+	// Coalesced command transits CHI from the initial state to the final one
+	require.Equal(t, "1", *command.old.Spec.TaskID)
+	require.Equal(t, "4", *command.new.Spec.TaskID)
+}
+
+func Test_Debouncer_DeleteIsNotDebounced(t *testing.T) {
+	const window = 100 * time.Millisecond
+	c := newDebounceTestController(window)
+	defer c.queues[1].Close()
+
+	chi1 := newDebounceTestCHI(1, "1")
+	chi2 := newDebounceTestCHI(2, "2")
+	deleted := newDebounceTestCHI(2, "2")
+	now := meta.Now()
+	deleted.DeletionTimestamp = &now
+
+	c.enqueueObject(NewReconcileCHI(reconcileUpdate, chi1, chi2))
+	c.enqueueObject(NewReconcileCHI(reconcileUpdate, chi2, deleted))
+
+	// Delete is enqueued right away
+	require.Equal(t, 1, c.queues[1].Len())
+
+	// and supersedes pending update
+	time.Sleep(2 * window)
+	require.Equal(t, 1, c.queues[1].Len())
+
+	item, _, ok := c.queues[1].Get()
+	require.True(t, ok)
+	require.False(t, item.(*ReconcileCHI).new.DeletionTimestamp.IsZero())
+}
+
+func Test_Debouncer_NoWindow(t *testing.T) {
+	c := newDebounceTestController(0)
+	defer c.queues[1].Close()
+
+	c.enqueueObject(NewReconcileCHI(reconcileUpdate, newDebounceTestCHI(1, "1"), newDebounceTestCHI(2, "2")))
+	require.Equal(t, 1, c.queues[1].Len())
+}
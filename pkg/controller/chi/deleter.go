@@ -21,6 +21,7 @@ import (
 	apps "k8s.io/api/apps/v1"
 	core "k8s.io/api/core/v1"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	log "github.com/altinity/clickhouse-operator/pkg/announcer"
 	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
@@ -38,6 +39,7 @@ func (c *Controller) deleteHost(ctx context.Context, host *api.ChiHost) error {
 	_ = c.deletePVC(ctx, host)
 	_ = c.deleteConfigMap(ctx, host)
 	_ = c.deleteServiceHost(ctx, host)
+	_ = c.deleteServiceHostInterserver(ctx, host)
 
 	log.V(1).M(host).E().Info(host.Runtime.Address.ClusterNameString())
 
@@ -111,6 +113,17 @@ func (c *Controller) statefulSetDeletePod(ctx context.Context, statefulSet *apps
 
 // deleteStatefulSet gracefully deletes StatefulSet through zeroing Pod's count
 func (c *Controller) deleteStatefulSet(ctx context.Context, host *api.ChiHost) error {
+	return c.deleteStatefulSetWithOptions(ctx, host, controller.NewDeleteOptions())
+}
+
+// deleteStatefulSetOrphanDependents deletes StatefulSet with orphan propagation policy,
+// so neither Pods nor PVCs are cascade-deleted along with the StatefulSet
+func (c *Controller) deleteStatefulSetOrphanDependents(ctx context.Context, host *api.ChiHost) error {
+	return c.deleteStatefulSetWithOptions(ctx, host, controller.NewDeleteOptionsOrphan())
+}
+
+// deleteStatefulSetWithOptions deletes StatefulSet with specified delete options
+func (c *Controller) deleteStatefulSetWithOptions(ctx context.Context, host *api.ChiHost, opts meta.DeleteOptions) error {
 	if util.IsContextDone(ctx) {
 		log.V(2).Info("task is done")
 		return nil
@@ -151,7 +164,17 @@ func (c *Controller) deleteStatefulSet(ctx context.Context, host *api.ChiHost) e
 	_ = c.waitHostReady(ctx, host)
 
 	// And now delete empty StatefulSet
-	if err := c.kubeClient.AppsV1().StatefulSets(namespace).Delete(ctx, name, controller.NewDeleteOptions()); err == nil {
+	c.deleteStatefulSetObject(ctx, host, opts)
+
+	return nil
+}
+
+// deleteStatefulSetObject deletes StatefulSet object and waits for it to be deleted
+func (c *Controller) deleteStatefulSetObject(ctx context.Context, host *api.ChiHost, opts meta.DeleteOptions) {
+	name := model.CreateStatefulSetName(host)
+	namespace := host.Runtime.Address.Namespace
+
+	if err := c.kubeClient.AppsV1().StatefulSets(namespace).Delete(ctx, name, opts); err == nil {
 		log.V(1).M(host).Info("OK delete StatefulSet %s/%s", namespace, name)
 		c.waitHostDeleted(host)
 	} else if apiErrors.IsNotFound(err) {
@@ -159,8 +182,6 @@ func (c *Controller) deleteStatefulSet(ctx context.Context, host *api.ChiHost) e
 	} else {
 		log.V(1).M(host).F().Error("FAIL delete StatefulSet %s/%s err: %v", namespace, name, err)
 	}
-
-	return nil
 }
 
 // syncStatefulSet
@@ -268,6 +289,19 @@ func (c *Controller) deleteServiceHost(ctx context.Context, host *api.ChiHost) e
 	return c.deleteServiceIfExists(ctx, namespace, serviceName)
 }
 
+// deleteServiceHostInterserver deletes host's interserver Service
+func (c *Controller) deleteServiceHostInterserver(ctx context.Context, host *api.ChiHost) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	serviceName := model.CreateInterserverServiceName(host)
+	namespace := host.Runtime.Address.Namespace
+	log.V(1).M(host).F().Info("%s/%s", namespace, serviceName)
+	return c.deleteServiceIfExists(ctx, namespace, serviceName)
+}
+
 // deleteServiceShard
 func (c *Controller) deleteServiceShard(ctx context.Context, shard *api.ChiShard) error {
 	if util.IsContextDone(ctx) {
@@ -347,6 +381,45 @@ func (c *Controller) deleteSecretCluster(ctx context.Context, cluster *api.Clust
 	return c.deleteSecretIfExists(ctx, namespace, secretName)
 }
 
+// deleteSecretManagedUser
+func (c *Controller) deleteSecretManagedUser(ctx context.Context, chi *api.ClickHouseInstallation) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	secretName := model.CreateManagedUserSecretName(chi)
+	namespace := chi.Namespace
+	log.V(1).M(chi).F().Info("%s/%s", namespace, secretName)
+	return c.deleteSecretIfExists(ctx, namespace, secretName)
+}
+
+// deleteSecretGeneratedPasswords
+func (c *Controller) deleteSecretGeneratedPasswords(ctx context.Context, chi *api.ClickHouseInstallation) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	secretName := model.CreateGeneratedPasswordsSecretName(chi)
+	namespace := chi.Namespace
+	log.V(1).M(chi).F().Info("%s/%s", namespace, secretName)
+	return c.deleteSecretIfExists(ctx, namespace, secretName)
+}
+
+// deleteSecretInterserverCredentials
+func (c *Controller) deleteSecretInterserverCredentials(ctx context.Context, chi *api.ClickHouseInstallation) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	secretName := model.CreateInterserverCredentialsSecretName(chi)
+	namespace := chi.Namespace
+	log.V(1).M(chi).F().Info("%s/%s", namespace, secretName)
+	return c.deleteSecretIfExists(ctx, namespace, secretName)
+}
+
 // deleteSecretIfExists deletes Secret in case it does not exist
 func (c *Controller) deleteSecretIfExists(ctx context.Context, namespace, name string) error {
 	if util.IsContextDone(ctx) {
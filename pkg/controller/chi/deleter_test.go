@@ -0,0 +1,69 @@
+package chi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+)
+
+func Test_DeleteServiceHostInterserver(t *testing.T) {
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+		},
+		Spec: api.ChiSpec{
+			Defaults: &api.ChiDefaults{
+				InterserverService: api.NewStringBool(true),
+			},
+		},
+	}
+	host := &api.ChiHost{Name: "0-0"}
+	host.Runtime.CHI = chi
+	host.Runtime.Address = api.ChiHostAddress{
+		Namespace:   "ns",
+		CHIName:     "chi",
+		ClusterName: "cluster",
+		HostName:    "0-0",
+	}
+
+	hostService := model.CreateStatefulSetServiceName(host)
+	interserverService := model.CreateInterserverServiceName(host)
+	require.NotEqual(t, hostService, interserverService)
+
+	kubeClient := kubeFake.NewSimpleClientset(
+		&core.Service{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: hostService}},
+		&core.Service{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: interserverService}},
+	)
+	c := &Controller{
+		kubeClient: kubeClient,
+	}
+	ctx := context.Background()
+
+	listServices := func() (names []string) {
+		services, err := kubeClient.CoreV1().Services("ns").List(ctx, meta.ListOptions{})
+		require.NoError(t, err)
+		for _, service := range services.Items {
+			names = append(names, service.Name)
+		}
+		return names
+	}
+
+	// Only the interserver Service of the host is deleted
+	require.NoError(t, c.deleteServiceHostInterserver(ctx, host))
+	require.Equal(t, []string{hostService}, listServices())
+
+	// Deleting absent interserver Service is not an error
+	require.NoError(t, c.deleteServiceHostInterserver(ctx, host))
+
+	// Host Service is deleted by its own
+	require.NoError(t, c.deleteServiceHost(ctx, host))
+	require.Empty(t, listServices())
+}
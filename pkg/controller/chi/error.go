@@ -45,3 +45,15 @@ func errIsDataLoss(err error) bool {
 	}
 	return false
 }
+
+// errObjectNotManaged specifies pre-existing object, which is not managed by the operator and is not to be overwritten
+var errObjectNotManaged = errors.New("object is not managed by the operator")
+
+// errClusterDegraded specifies cluster, which is too degraded to exclude one more host from
+var errClusterDegraded = errors.New("cluster is degraded")
+
+// errHostTimeout specifies host, which reconcile did not complete in time
+var errHostTimeout = errors.New("host reconcile timeout")
+
+// errZookeeperUnreachable specifies ZooKeeper ensemble, which is not reachable by quorum of its endpoints
+var errZookeeperUnreachable = errors.New("zookeeper is unreachable")
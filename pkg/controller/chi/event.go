@@ -39,27 +39,68 @@ const (
 	eventActionUpdate    = "Update"
 	eventActionDelete    = "Delete"
 	eventActionProgress  = "Progress"
+	eventActionBackup    = "Backup"
+	eventActionRestore   = "Restore"
 )
 
 const (
 	// Short, machine understandable string that gives the reason for the transition into the object's current status
-	eventReasonReconcileStarted       = "ReconcileStarted"
-	eventReasonReconcileInProgress    = "ReconcileInProgress"
-	eventReasonReconcileCompleted     = "ReconcileCompleted"
-	eventReasonReconcileFailed        = "ReconcileFailed"
-	eventReasonCreateStarted          = "CreateStarted"
-	eventReasonCreateInProgress       = "CreateInProgress"
-	eventReasonCreateCompleted        = "CreateCompleted"
-	eventReasonCreateFailed           = "CreateFailed"
-	eventReasonUpdateStarted          = "UpdateStarted"
-	eventReasonUpdateInProgress       = "UpdateInProgress"
-	eventReasonUpdateCompleted        = "UpdateCompleted"
-	eventReasonUpdateFailed           = "UpdateFailed"
-	eventReasonDeleteStarted          = "DeleteStarted"
-	eventReasonDeleteInProgress       = "DeleteInProgress"
-	eventReasonDeleteCompleted        = "DeleteCompleted"
-	eventReasonDeleteFailed           = "DeleteFailed"
-	eventReasonProgressHostsCompleted = "ProgressHostsCompleted"
+	eventReasonReconcileStarted        = "ReconcileStarted"
+	eventReasonReconcileInProgress     = "ReconcileInProgress"
+	eventReasonReconcileCompleted      = "ReconcileCompleted"
+	eventReasonReconcileFailed         = "ReconcileFailed"
+	eventReasonCreateStarted           = "CreateStarted"
+	eventReasonCreateInProgress        = "CreateInProgress"
+	eventReasonCreateCompleted         = "CreateCompleted"
+	eventReasonCreateFailed            = "CreateFailed"
+	eventReasonUpdateStarted           = "UpdateStarted"
+	eventReasonUpdateInProgress        = "UpdateInProgress"
+	eventReasonUpdateCompleted         = "UpdateCompleted"
+	eventReasonUpdateFailed            = "UpdateFailed"
+	eventReasonDeleteStarted           = "DeleteStarted"
+	eventReasonDeleteInProgress        = "DeleteInProgress"
+	eventReasonDeleteCompleted         = "DeleteCompleted"
+	eventReasonDeleteFailed            = "DeleteFailed"
+	eventReasonDeleteSkipped           = "DeleteSkipped"
+	eventReasonProgressHostsCompleted  = "ProgressHostsCompleted"
+	eventReasonMixedVersions           = "MixedVersions"
+	eventReasonPriorityClassNotFound   = "PriorityClassNotFound"
+	eventReasonSchemaDrift             = "SchemaDrift"
+	eventReasonImagePullSecretNotFound = "ImagePullSecretNotFound"
+	eventReasonDepartedReplicasDropped = "DepartedReplicasDropped"
+	eventReasonServiceAccountNotFound  = "ServiceAccountNotFound"
+	eventReasonVolumeProvisioningStuck = "VolumeProvisioningStuck"
+	eventReasonRolledBack              = "RolledBack"
+	eventReasonSystemCommandDenied     = "SystemCommandDenied"
+	eventReasonConflictingObject       = "ConflictingObject"
+	eventReasonInvalidConfigFile       = "InvalidConfigFile"
+	eventReasonHostNetworkPortConflict = "HostNetworkPortConflict"
+	eventReasonRolloutStuck            = "RolloutStuck"
+	eventReasonClusterDegraded         = "ClusterDegraded"
+	eventReasonShardMaintenance        = "ShardMaintenance"
+	eventReasonMembershipWebhookFailed = "MembershipWebhookFailed"
+	eventReasonTopologyMismatch        = "TopologyMismatch"
+	eventReasonPlanApprovalRequired    = "PlanApprovalRequired"
+	eventReasonEnvSourceNotFound       = "EnvSourceNotFound"
+	eventReasonAdoptObject             = "AdoptObject"
+	eventReasonZooKeeperUnreachable    = "ZooKeeperUnreachable"
+	eventReasonRolloutPaused           = "RolloutPaused"
+	eventReasonReadinessQueryTimeout   = "ReadinessQueryTimeout"
+	eventReasonServiceNotHeadless      = "ServiceNotHeadless"
+	eventReasonReconcilePaused         = "ReconcilePaused"
+	eventReasonDryRun                  = "DryRun"
+	eventReasonDegraded                = "Degraded"
+	eventReasonCanaryStarted           = "CanaryStarted"
+	eventReasonCanaryCompleted         = "CanaryCompleted"
+	eventReasonCanaryFailed            = "CanaryFailed"
+	eventReasonHostReconcileTimeout    = "HostReconcileTimeout"
+	eventReasonBackupStarted           = "BackupStarted"
+	eventReasonBackupCompleted         = "BackupCompleted"
+	eventReasonBackupFailed            = "BackupFailed"
+	eventReasonRestoreStarted          = "RestoreStarted"
+	eventReasonRestoreCompleted        = "RestoreCompleted"
+	eventReasonRestoreFailed           = "RestoreFailed"
+	eventReasonRestoreInProgress       = "RestoreInProgress"
 )
 
 // EventInfo emits event Info
@@ -92,6 +133,32 @@ func (c *Controller) EventError(
 	c.emitEvent(chi, eventTypeError, action, reason, message)
 }
 
+// EventInfoAggregated emits event Info, which can be aggregated with similar events into periodic summary event
+func (c *Controller) EventInfoAggregated(
+	chi *api.ClickHouseInstallation,
+	action string,
+	reason string,
+	message string,
+) {
+	events := c.eventAggregator.add(aggregatedEvent{
+		chi:     chi,
+		_type:   eventTypeInfo,
+		action:  action,
+		reason:  reason,
+		message: message,
+	})
+	for _, event := range events {
+		c.emitEvent(event.chi, event._type, event.action, event.reason, event.message)
+	}
+}
+
+// FlushEvents emits summary events for all events aggregated for the CHI so far
+func (c *Controller) FlushEvents(chi *api.ClickHouseInstallation) {
+	for _, event := range c.eventAggregator.flush(chi) {
+		c.emitEvent(event.chi, event._type, event.action, event.reason, event.message)
+	}
+}
+
 // emitEvent creates CHI-related event
 // typ - type of the event - Normal, Warning, etc, one of eventType*
 // action - what action was attempted, and then succeeded/failed regarding to the Involved Object. One of eventAction*
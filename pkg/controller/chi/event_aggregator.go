@@ -0,0 +1,132 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+// aggregatedEvent describes event to be emitted after aggregation
+type aggregatedEvent struct {
+	chi     *api.ClickHouseInstallation
+	_type   string
+	action  string
+	reason  string
+	message string
+}
+
+// eventAggregationBucket accumulates similar events within one aggregation window
+type eventAggregationBucket struct {
+	event      aggregatedEvent
+	start      time.Time
+	suppressed int
+}
+
+// eventAggregator coalesces repetitive events into periodic summary events.
+// The first event within an aggregation window is emitted as-is, similar events within the window are suppressed
+// and reported by one summary event, which carries the most recent message.
+type eventAggregator struct {
+	window  time.Duration
+	now     func() time.Time
+	mutex   sync.Mutex
+	buckets map[string]*eventAggregationBucket
+}
+
+// newEventAggregator creates new event aggregator with specified aggregation window.
+// Non-positive window means no aggregation.
+func newEventAggregator(window time.Duration) *eventAggregator {
+	return &eventAggregator{
+		window:  window,
+		now:     time.Now,
+		buckets: make(map[string]*eventAggregationBucket),
+	}
+}
+
+// chiKey builds key of the CHI events belong to
+func (a *eventAggregator) chiKey(chi *api.ClickHouseInstallation) string {
+	return chi.Namespace + "/" + chi.Name
+}
+
+// eventKey builds key to group similar events by
+func (a *eventAggregator) eventKey(event aggregatedEvent) string {
+	return a.chiKey(event.chi) + "/" + event._type + "/" + event.action + "/" + event.reason
+}
+
+// add registers an event and returns list of events to be emitted right now
+func (a *eventAggregator) add(event aggregatedEvent) (events []aggregatedEvent) {
+	if (a == nil) || (a.window <= 0) || (event.chi == nil) {
+		return []aggregatedEvent{event}
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	now := a.now()
+	key := a.eventKey(event)
+	if bucket, ok := a.buckets[key]; ok {
+		if now.Sub(bucket.start) < a.window {
+			// Within the aggregation window - accumulate
+			bucket.suppressed++
+			bucket.event = event
+			return nil
+		}
+		// Aggregation window is over - report what was accumulated and start new window
+		if summary, ok := a.summary(bucket); ok {
+			events = append(events, summary)
+		}
+	}
+
+	a.buckets[key] = &eventAggregationBucket{
+		event: event,
+		start: now,
+	}
+	return append(events, event)
+}
+
+// flush returns summary events for all events accumulated for the CHI and resets aggregation for the CHI
+func (a *eventAggregator) flush(chi *api.ClickHouseInstallation) (events []aggregatedEvent) {
+	if (a == nil) || (chi == nil) {
+		return nil
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	prefix := a.chiKey(chi) + "/"
+	for key, bucket := range a.buckets {
+		if (len(key) < len(prefix)) || (key[:len(prefix)] != prefix) {
+			continue
+		}
+		if summary, ok := a.summary(bucket); ok {
+			events = append(events, summary)
+		}
+		delete(a.buckets, key)
+	}
+	return events
+}
+
+// summary builds summary event for accumulated events, if any
+func (a *eventAggregator) summary(bucket *eventAggregationBucket) (aggregatedEvent, bool) {
+	if bucket.suppressed == 0 {
+		return aggregatedEvent{}, false
+	}
+	summary := bucket.event
+	summary.message = fmt.Sprintf("%s (%d similar events aggregated)", bucket.event.message, bucket.suppressed)
+	return summary, true
+}
@@ -0,0 +1,101 @@
+package chi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+	kubeTesting "k8s.io/client-go/testing"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+func Test_EventAggregator_RepetitiveEventsCollapseFailuresPassThrough(t *testing.T) {
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+		},
+	}
+	kubeClient := kubeFake.NewSimpleClientset()
+	// Fake client does not support generated names
+	generated := 0
+	kubeClient.PrependReactor("create", "events", func(action kubeTesting.Action) (bool, runtime.Object, error) {
+		event := action.(kubeTesting.CreateAction).GetObject().(*core.Event)
+		generated++
+		event.Name = fmt.Sprintf("%s%d", event.GenerateName, generated)
+		return false, nil, nil
+	})
+	c := &Controller{
+		kubeClient:      kubeClient,
+		eventAggregator: newEventAggregator(time.Minute),
+	}
+	now := time.Now()
+	c.eventAggregator.now = func() time.Time { return now }
+	a := NewAnnouncer().WithController(c)
+
+	listEvents := func() []string {
+		events, err := kubeClient.CoreV1().Events(chi.Namespace).List(context.Background(), meta.ListOptions{})
+		require.NoError(t, err)
+		var messages []string
+		for _, event := range events.Items {
+			messages = append(messages, event.Type+": "+event.Message)
+		}
+		return messages
+	}
+
+	// Only the first of repetitive events is emitted
+	for i := 1; i <= 10; i++ {
+		a.WithEvent(chi, eventActionProgress, eventReasonProgressHostsCompleted).
+			WithEventAggregation().
+			Info("%s: %d of %d", eventReasonProgressHostsCompleted, i, 20)
+	}
+	require.Equal(t, []string{
+		"Info: ProgressHostsCompleted: 1 of 20",
+	}, listEvents())
+
+	// Failure passes through and is preceded by the aggregated summary
+	a.WithEvent(chi, eventActionReconcile, eventReasonReconcileFailed).
+		WithEventAggregation().
+		Error("host failed")
+	require.Equal(t, []string{
+		"Info: ProgressHostsCompleted: 1 of 20",
+		"Info: ProgressHostsCompleted: 10 of 20 (9 similar events aggregated)",
+		"Error: host failed",
+	}, listEvents())
+
+	// New aggregation window starts after expiration
+	a.WithEvent(chi, eventActionProgress, eventReasonProgressHostsCompleted).
+		WithEventAggregation().
+		Info("%s: %d of %d", eventReasonProgressHostsCompleted, 11, 20)
+	a.WithEvent(chi, eventActionProgress, eventReasonProgressHostsCompleted).
+		WithEventAggregation().
+		Info("%s: %d of %d", eventReasonProgressHostsCompleted, 12, 20)
+	now = now.Add(2 * time.Minute)
+	a.WithEvent(chi, eventActionProgress, eventReasonProgressHostsCompleted).
+		WithEventAggregation().
+		Info("%s: %d of %d", eventReasonProgressHostsCompleted, 13, 20)
+	require.Equal(t, []string{
+		"Info: ProgressHostsCompleted: 1 of 20",
+		"Info: ProgressHostsCompleted: 10 of 20 (9 similar events aggregated)",
+		"Error: host failed",
+		"Info: ProgressHostsCompleted: 11 of 20",
+		"Info: ProgressHostsCompleted: 12 of 20 (1 similar events aggregated)",
+		"Info: ProgressHostsCompleted: 13 of 20",
+	}, listEvents())
+}
+
+func Test_EventAggregator_NoWindow(t *testing.T) {
+	chi := &api.ClickHouseInstallation{}
+	aggregator := newEventAggregator(0)
+	for i := 0; i < 3; i++ {
+		require.Len(t, aggregator.add(aggregatedEvent{chi: chi, message: "msg"}), 1)
+	}
+	require.Empty(t, aggregator.flush(chi))
+}
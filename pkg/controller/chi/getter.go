@@ -175,6 +175,37 @@ func (c *Controller) getSecret(secret *core.Secret) (*core.Secret, error) {
 	return c.kubeClient.CoreV1().Secrets(secret.Namespace).Get(controller.NewContext(), secret.Name, controller.NewGetOptions())
 }
 
+// getSecretCached gets the secret out of the informer cache. Secrets not cached or cached with data dropped,
+// which is the case for secrets the CHI starts referring to, are read from k8s API.
+func (c *Controller) getSecretCached(namespace, name string) (*core.Secret, error) {
+	if c.secretLister != nil {
+		secret, err := c.secretLister.Secrets(namespace).Get(name)
+		switch {
+		case err == nil:
+			if _, stripped := secret.Annotations[secretDataStrippedAnnotation]; !stripped {
+				return secret, nil
+			}
+		case !apiErrors.IsNotFound(err):
+			return nil, err
+		}
+	}
+	return c.kubeClient.CoreV1().Secrets(namespace).Get(controller.NewContext(), name, controller.NewGetOptions())
+}
+
+// getSecretKey gets value of the key of the secret referenced by the selector in the namespace.
+// Secret is read out of the informer cache, if available.
+func (c *Controller) getSecretKey(namespace string, selector *core.SecretKeySelector) ([]byte, error) {
+	secret, err := c.getSecretCached(namespace, selector.Name)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := secret.Data[selector.Key]
+	if !ok {
+		return nil, fmt.Errorf("no key %s in secret %s/%s", selector.Key, namespace, selector.Name)
+	}
+	return value, nil
+}
+
 // getPod gets pod. Accepted types:
 //  1. *apps.StatefulSet
 //  2. *chop.ChiHost
@@ -276,3 +307,38 @@ func (c *Controller) GetCHIByObjectMeta(objectMeta *meta.ObjectMeta, isCHI bool)
 
 	return c.chopClient.ClickhouseV1().ClickHouseInstallations(objectMeta.Namespace).Get(controller.NewContext(), chiName, controller.NewGetOptions())
 }
+
+// getRestoreInProgress gets restore of the CHI in progress, if any.
+// Restore in progress is looked up by status of ClickHouseRestore, so it survives restart of the operator.
+func (c *Controller) getRestoreInProgress(chi *api.ClickHouseInstallation) *api.ClickHouseRestore {
+	if (c.chrLister == nil) || (chi == nil) {
+		return nil
+	}
+	restores, err := c.chrLister.ClickHouseRestores(chi.Namespace).List(k8sLabels.Everything())
+	if err != nil {
+		return nil
+	}
+	for _, chr := range restores {
+		if (chr.Spec.CHI == chi.Name) && (chr.Status != nil) && (chr.Status.Status == api.BackupStatusInProgress) {
+			return chr
+		}
+	}
+	return nil
+}
+
+// getUsers gets ClickHouseUser resources of the CHI
+func (c *Controller) getUsers(namespace, chi string) (users []*api.ClickHouseUser) {
+	if c.chuLister == nil {
+		return nil
+	}
+	all, err := c.chuLister.ClickHouseUsers(namespace).List(k8sLabels.Everything())
+	if err != nil {
+		return nil
+	}
+	for _, chu := range all {
+		if chu.Spec.CHI == chi {
+			users = append(users, chu)
+		}
+	}
+	return users
+}
@@ -0,0 +1,143 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+const (
+	healthStatusOK       = "ok"
+	healthStatusStarting = "starting"
+)
+
+// healthTracker keeps track of controller workers liveness and reconcile results
+type healthTracker struct {
+	// workers specifies number of workers started
+	workers int32
+	// activeWorkers specifies number of workers processing a queue item right now
+	activeWorkers int32
+
+	mutex sync.RWMutex
+	// lastReconcile maps CHI namespace/name to the time of the last successful reconcile
+	lastReconcile map[string]time.Time
+}
+
+// newHealthTracker creates new health tracker
+func newHealthTracker() *healthTracker {
+	return &healthTracker{
+		lastReconcile: make(map[string]time.Time),
+	}
+}
+
+// workerStarted registers started worker
+func (h *healthTracker) workerStarted() {
+	atomic.AddInt32(&h.workers, 1)
+}
+
+// workerStopped registers stopped worker
+func (h *healthTracker) workerStopped() {
+	atomic.AddInt32(&h.workers, -1)
+}
+
+// itemStarted registers worker starting to process an item
+func (h *healthTracker) itemStarted() {
+	atomic.AddInt32(&h.activeWorkers, 1)
+}
+
+// itemDone registers worker completing to process an item
+func (h *healthTracker) itemDone() {
+	atomic.AddInt32(&h.activeWorkers, -1)
+}
+
+// reconcileSucceeded registers successful reconcile of the CHI
+func (h *healthTracker) reconcileSucceeded(chi *api.ClickHouseInstallation, when time.Time) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.lastReconcile[chi.Namespace+"/"+chi.Name] = when
+}
+
+// forget removes the CHI from tracking
+func (h *healthTracker) forget(chi *api.ClickHouseInstallation) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	delete(h.lastReconcile, chi.Namespace+"/"+chi.Name)
+}
+
+// HealthReport describes controller health as reported by the health endpoint
+type HealthReport struct {
+	// Status is "ok" when workers are running, "starting" otherwise
+	Status string `json:"status"`
+	// QueueDepth is the total number of items waiting in all queues
+	QueueDepth int `json:"queueDepth"`
+	// Queues lists depth of each queue
+	Queues []int `json:"queues"`
+	// Workers is the number of workers running
+	Workers int `json:"workers"`
+	// ActiveWorkers is the number of workers processing an item right now
+	ActiveWorkers int `json:"activeWorkers"`
+	// LastSuccessfulReconcile maps CHI namespace/name to the time of its last successful reconcile
+	LastSuccessfulReconcile map[string]time.Time `json:"lastSuccessfulReconcile"`
+}
+
+// Health builds health report of the controller
+func (c *Controller) Health() *HealthReport {
+	report := &HealthReport{
+		Status:                  healthStatusStarting,
+		Queues:                  make([]int, 0, len(c.queues)),
+		LastSuccessfulReconcile: make(map[string]time.Time),
+	}
+
+	for _, q := range c.queues {
+		depth := q.Len()
+		report.Queues = append(report.Queues, depth)
+		report.QueueDepth += depth
+	}
+
+	report.Workers = int(atomic.LoadInt32(&c.health.workers))
+	report.ActiveWorkers = int(atomic.LoadInt32(&c.health.activeWorkers))
+	if (report.Workers > 0) && (report.Workers == c.workersNum) {
+		report.Status = healthStatusOK
+	}
+
+	c.health.mutex.RLock()
+	defer c.health.mutex.RUnlock()
+	for key, when := range c.health.lastReconcile {
+		report.LastSuccessfulReconcile[key] = when
+	}
+
+	return report
+}
+
+// HealthHandler returns HTTP handler reporting controller health as JSON.
+// Responds with 503 Service Unavailable until all workers are running.
+func (c *Controller) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := c.Health()
+		w.Header().Set("Content-Type", "application/json")
+		if report.Status == healthStatusOK {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
@@ -0,0 +1,77 @@
+package chi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/altinity/queue"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+func Test_HealthHandler_ReportsQueueDepthAndWorkers(t *testing.T) {
+	newCHI := func(name string) *api.ClickHouseInstallation {
+		return &api.ClickHouseInstallation{
+			ObjectMeta: meta.ObjectMeta{
+				Namespace: "ns",
+				Name:      name,
+			},
+		}
+	}
+
+	c := &Controller{
+		queues:     []queue.PriorityQueue{queue.New(), queue.New()},
+		workersNum: 2,
+		health:     newHealthTracker(),
+	}
+	defer func() {
+		for _, q := range c.queues {
+			q.Close()
+		}
+	}()
+	c.queues[0].Insert(NewDropDns(&meta.ObjectMeta{Namespace: "ns", Name: "pod"}))
+	c.queues[1].Insert(NewReconcileCHI(reconcileAdd, nil, newCHI("chi1")))
+	c.queues[1].Insert(NewReconcileCHI(reconcileAdd, nil, newCHI("chi2")))
+
+	get := func() (int, *HealthReport) {
+		recorder := httptest.NewRecorder()
+		c.HealthHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+		report := &HealthReport{}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), report))
+		return recorder.Code, report
+	}
+
+	// Workers are not running yet
+	code, report := get()
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, healthStatusStarting, report.Status)
+	require.Equal(t, 3, report.QueueDepth)
+	require.Equal(t, []int{1, 2}, report.Queues)
+
+	// All workers are running, one of them is busy
+	c.health.workerStarted()
+	c.health.workerStarted()
+	c.health.itemStarted()
+	reconciled := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	c.health.reconcileSucceeded(newCHI("chi1"), reconciled)
+
+	code, report = get()
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, healthStatusOK, report.Status)
+	require.Equal(t, 2, report.Workers)
+	require.Equal(t, 1, report.ActiveWorkers)
+	require.Len(t, report.LastSuccessfulReconcile, 1)
+	require.True(t, reconciled.Equal(report.LastSuccessfulReconcile["ns/chi1"]))
+
+	// Deleted CHI is not reported anymore
+	c.health.forget(newCHI("chi1"))
+	_, report = get()
+	require.Empty(t, report.LastSuccessfulReconcile)
+}
@@ -0,0 +1,196 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"context"
+	"sync"
+
+	"github.com/altinity/queue"
+)
+
+// coalescer is a queue item, which is able to absorb subsequent item of the same key
+type coalescer interface {
+	// coalesce absorbs next item. Returns false in case items can not be coalesced
+	coalesce(next queue.PriorityQueueItem) bool
+}
+
+// superseder is a queue item, which decides whether processing of the current item of the same key is obsolete
+type superseder interface {
+	// supersedes checks whether processing of the current item is obsolete
+	supersedes(current queue.PriorityQueueItem) bool
+}
+
+// isSuperseding checks whether item makes processing of the current item of the same key obsolete.
+// Any item supersedes the current one, unless the item decides otherwise.
+func isSuperseding(item, current queue.PriorityQueueItem) bool {
+	if s, ok := item.(superseder); ok {
+		return s.supersedes(current)
+	}
+	return true
+}
+
+// keyedQueueItem is an item being processed
+type keyedQueueItem struct {
+	item   queue.PriorityQueueItem
+	cancel context.CancelFunc
+}
+
+// keyedQueue is a priority queue, which items are keyed by their handle.
+// Queue is served by multiple workers concurrently, while items of the same key are processed one at a time,
+// in the order they were inserted. Thus a long-running item does not block items of other keys.
+// Waiting items of the same key are coalesced, in case items are able to.
+type keyedQueue struct {
+	cond *sync.Cond
+	// ready contains the oldest pending item of each key, which is not being processed right now
+	ready queue.PriorityQueueItems
+	// pending contains items of each key waiting for processing, in the order of insertion
+	pending map[queue.T][]queue.PriorityQueueItem
+	// inProgress contains items being processed right now
+	inProgress map[queue.T]*keyedQueueItem
+	closed     bool
+}
+
+// Ensure keyedQueue implements PriorityQueue interface
+var _ queue.PriorityQueue = newKeyedQueue()
+
+// newKeyedQueue creates new keyed queue
+func newKeyedQueue() *keyedQueue {
+	return &keyedQueue{
+		cond:       sync.NewCond(&sync.Mutex{}),
+		ready:      queue.NewHeapPriorityQueueItems(),
+		pending:    make(map[queue.T][]queue.PriorityQueueItem),
+		inProgress: make(map[queue.T]*keyedQueueItem),
+	}
+}
+
+// Insert inserts item into the queue. Item is coalesced with the latest waiting item of the same key, if possible.
+// In case an item of the same key is being processed and the item supersedes it, current one is asked to complete ASAP.
+func (q *keyedQueue) Insert(item queue.PriorityQueueItem) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	if q.closed {
+		// Do not accept items into closed queue
+		return
+	}
+
+	key := item.Handle()
+	coalesced := false
+	if items := q.pending[key]; len(items) > 0 {
+		if c, ok := items[len(items)-1].(coalescer); ok {
+			coalesced = c.coalesce(item)
+		}
+	}
+	if !coalesced {
+		q.pending[key] = append(q.pending[key], item)
+	}
+
+	if current, ok := q.inProgress[key]; ok {
+		// Item would be made ready as soon as the current one is done
+		if isSuperseding(item, current.item) {
+			current.cancel()
+		}
+		return
+	}
+
+	if !coalesced && (len(q.pending[key]) == 1) {
+		// The only item of the key, ready to be picked up
+		q.ready.Insert(item)
+		q.cond.Signal()
+	}
+}
+
+// Get gets item to process along with the context of its processing.
+// Blocks until an item is available or the queue is closed.
+func (q *keyedQueue) Get() (queue.PriorityQueueItem, context.Context, bool) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	for (q.ready.Len() == 0) && !q.closed {
+		// Wait for items to come or the queue being closed
+		q.cond.Wait()
+	}
+
+	if q.closed {
+		return nil, nil, false
+	}
+
+	item, ok := q.ready.Get()
+	if !ok {
+		return nil, nil, false
+	}
+
+	key := item.Handle()
+	q.pending[key] = q.pending[key][1:]
+	ctx, cancel := context.WithCancel(context.Background())
+	q.inProgress[key] = &keyedQueueItem{
+		item:   item,
+		cancel: cancel,
+	}
+
+	return item, ctx, true
+}
+
+// Done informs the queue that worker has done with the item.
+// The next pending item of the same key, if any, is made ready.
+func (q *keyedQueue) Done(item queue.PriorityQueueItem) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	key := item.Handle()
+	if current, ok := q.inProgress[key]; ok {
+		current.cancel()
+		delete(q.inProgress, key)
+	}
+
+	if len(q.pending[key]) == 0 {
+		delete(q.pending, key)
+		return
+	}
+
+	q.ready.Insert(q.pending[key][0])
+	q.cond.Signal()
+}
+
+// Len gets number of items waiting for processing
+func (q *keyedQueue) Len() int {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	num := 0
+	for _, items := range q.pending {
+		num += len(items)
+	}
+	return num
+}
+
+// Close closes the queue. Workers waiting for items are released.
+func (q *keyedQueue) Close() {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	q.closed = true
+	// Notify all waiters to start shutdown process
+	q.cond.Broadcast()
+}
+
+// Closed reports whether the queue is closed
+func (q *keyedQueue) Closed() bool {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	return q.closed
+}
@@ -0,0 +1,126 @@
+package chi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/altinity/queue"
+	"github.com/stretchr/testify/require"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+func Test_KeyedQueue_SerializesKey(t *testing.T) {
+	q := newKeyedQueue()
+	defer q.Close()
+
+	a1 := NewReconcileCHI(reconcileAdd, nil, newLockerTestCHI("a"))
+	a2 := NewReconcileCHI(reconcileUpdate, a1.new, newLockerTestCHI("a"))
+	a2.new.Generation = 2
+	b1 := NewReconcileCHI(reconcileAdd, nil, newLockerTestCHI("b"))
+	q.Insert(a1)
+	q.Insert(b1)
+	require.Equal(t, 2, q.Len())
+
+	// Different keys are processed concurrently
+	got := map[queue.PriorityQueueItem]bool{}
+	item, ctxA, ok := q.Get()
+	require.True(t, ok)
+	got[item] = true
+	item, _, ok = q.Get()
+	require.True(t, ok)
+	got[item] = true
+	require.Equal(t, map[queue.PriorityQueueItem]bool{a1: true, b1: true}, got)
+	require.Equal(t, 0, q.Len())
+
+	// Next item of the key being processed waits and asks current one, which is of older generation, to complete
+	q.Insert(a2)
+	require.Equal(t, 1, q.Len())
+	require.Error(t, ctxA.Err())
+
+	next := make(chan queue.PriorityQueueItem)
+	go func() {
+		item, _, _ := q.Get()
+		next <- item
+	}()
+	select {
+	case <-next:
+		require.Fail(t, "item of the key being processed is returned")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Key is free, so the next item of the key is picked up
+	q.Done(a1)
+	select {
+	case item := <-next:
+		require.Equal(t, a2, item)
+	case <-time.After(time.Second):
+		require.Fail(t, "item is not returned")
+	}
+	q.Done(a2)
+	q.Done(b1)
+	require.Equal(t, 0, q.Len())
+}
+
+func Test_KeyedQueue_Coalesce(t *testing.T) {
+	q := newKeyedQueue()
+	defer q.Close()
+
+	newCHI := func(generation int64) *api.ClickHouseInstallation {
+		chi := newLockerTestCHI("a")
+		chi.Generation = generation
+		return chi
+	}
+
+	// Waiting commands are coalesced into the one, which reconciles the latest generation
+	q.Insert(NewReconcileCHI(reconcileAdd, nil, newCHI(1)))
+	q.Insert(NewReconcileCHI(reconcileUpdate, newCHI(1), newCHI(2)))
+	q.Insert(NewReconcileCHI(reconcileUpdate, newCHI(2), newCHI(3)))
+	require.Equal(t, 1, q.Len())
+	item, ctx, ok := q.Get()
+	require.True(t, ok)
+	command := item.(*ReconcileCHI)
+	require.Equal(t, reconcileAdd, command.cmd)
+	require.Nil(t, command.old)
+	require.Equal(t, int64(3), command.new.Generation)
+
+	// Same generation does not abort reconcile in progress
+	q.Insert(NewReconcileCHI(reconcileAdd, nil, newCHI(3)))
+	require.NoError(t, ctx.Err())
+
+	// Delete supersedes both waiting command and reconcile in progress
+	q.Insert(NewReconcileCHI(reconcileDelete, newCHI(3), nil))
+	require.Equal(t, 1, q.Len())
+	require.Error(t, ctx.Err())
+
+	// Waiting delete is not coalesced with subsequent commands
+	q.Insert(NewReconcileCHI(reconcileAdd, nil, newCHI(4)))
+	require.Equal(t, 2, q.Len())
+
+	q.Done(item)
+	item, _, ok = q.Get()
+	require.True(t, ok)
+	require.Equal(t, reconcileDelete, item.(*ReconcileCHI).cmd)
+}
+
+func Test_KeyedQueue_Close(t *testing.T) {
+	q := newKeyedQueue()
+
+	done := make(chan bool)
+	go func() {
+		_, _, ok := q.Get()
+		done <- ok
+	}()
+	q.Close()
+	select {
+	case ok := <-done:
+		require.False(t, ok)
+	case <-time.After(time.Second):
+		require.Fail(t, "worker is not released")
+	}
+
+	// Closed queue does not accept items
+	require.True(t, q.Closed())
+	q.Insert(NewReconcileCHI(reconcileAdd, nil, newLockerTestCHI("a")))
+	require.Equal(t, 0, q.Len())
+}
@@ -0,0 +1,161 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"context"
+	"sync"
+
+	"github.com/altinity/queue"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+// keyedLock is a lock of a key along with number of its holders and waiters
+type keyedLock struct {
+	mutex sync.Mutex
+	refs  int
+}
+
+// keyedLocker provides mutual exclusion per key.
+// Locks are allocated on demand and released as soon as nobody holds or waits for them.
+type keyedLocker struct {
+	mutex sync.Mutex
+	locks map[queue.T]*keyedLock
+}
+
+// newKeyedLocker creates new keyed locker
+func newKeyedLocker() *keyedLocker {
+	return &keyedLocker{
+		locks: make(map[queue.T]*keyedLock),
+	}
+}
+
+// Lock locks the key. Blocks until the key is unlocked by the current holder, if any.
+func (l *keyedLocker) Lock(key queue.T) {
+	if l == nil {
+		return
+	}
+
+	l.mutex.Lock()
+	lock, ok := l.locks[key]
+	if !ok {
+		lock = &keyedLock{}
+		l.locks[key] = lock
+	}
+	lock.refs++
+	l.mutex.Unlock()
+
+	lock.mutex.Lock()
+}
+
+// TryLock locks the key in case it is neither held nor waited for. Does not block.
+// Returns true in case the key is locked.
+func (l *keyedLocker) TryLock(key queue.T) bool {
+	if l == nil {
+		return true
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if _, ok := l.locks[key]; ok {
+		// Key is busy
+		return false
+	}
+	lock := &keyedLock{refs: 1}
+	lock.mutex.Lock()
+	l.locks[key] = lock
+	return true
+}
+
+// Unlock unlocks the key
+func (l *keyedLocker) Unlock(key queue.T) {
+	if l == nil {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	lock, ok := l.locks[key]
+	if !ok {
+		return
+	}
+	lock.refs--
+	if lock.refs == 0 {
+		delete(l.locks, key)
+	}
+	lock.mutex.Unlock()
+}
+
+// lockCHI locks CHI the command is about, so only one mutating operation runs per CHI at a time,
+// no matter which worker dispatches it. Returns function to unlock the CHI.
+func (c *Controller) lockCHI(command *ReconcileCHI) func() {
+	key := command.Handle()
+	c.chiLocker.Lock(key)
+	return func() {
+		c.chiLocker.Unlock(key)
+	}
+}
+
+// tryLockCHI locks CHI in case no mutating operation runs on it. Does not block.
+// Returns function to unlock the CHI and whether the CHI is locked.
+func (c *Controller) tryLockCHI(chi *api.ClickHouseInstallation) (func(), bool) {
+	key := (&ReconcileCHI{new: chi}).Handle()
+	if !c.chiLocker.TryLock(key) {
+		return nil, false
+	}
+	return func() {
+		c.chiLocker.Unlock(key)
+	}, true
+}
+
+// semaphore limits number of concurrently running operations. Nil semaphore imposes no limit.
+type semaphore struct {
+	slots chan struct{}
+}
+
+// newSemaphore creates new semaphore with specified number of slots. Non-positive limit means no limit.
+func newSemaphore(limit int) *semaphore {
+	if limit <= 0 {
+		return nil
+	}
+	return &semaphore{
+		slots: make(chan struct{}, limit),
+	}
+}
+
+// Acquire acquires a slot. Blocks until a slot is available or context is done.
+func (s *semaphore) Acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release releases previously acquired slot
+func (s *semaphore) Release() {
+	if s == nil {
+		return
+	}
+	<-s.slots
+}
@@ -0,0 +1,143 @@
+package chi
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+func newLockerTestCHI(name string) *api.ClickHouseInstallation {
+	return &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      name,
+		},
+	}
+}
+
+func Test_LockCHI_DeleteWaitsForUpdate(t *testing.T) {
+	c := &Controller{chiLocker: newKeyedLocker()}
+	chi := newLockerTestCHI("chi")
+
+	// Update is in flight
+	unlockUpdate := c.lockCHI(NewReconcileCHI(reconcileUpdate, chi, chi))
+
+	deleted := make(chan struct{})
+	go func() {
+		unlock := c.lockCHI(NewReconcileCHI(reconcileDelete, chi, nil))
+		defer unlock()
+		close(deleted)
+	}()
+
+	// Operations on other CHIs are not blocked
+	unlockOther := c.lockCHI(NewReconcileCHI(reconcileUpdate, nil, newLockerTestCHI("other")))
+	unlockOther()
+
+	select {
+	case <-deleted:
+		require.Fail(t, "delete has not waited for update to complete")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Update completes - delete proceeds
+	unlockUpdate()
+	select {
+	case <-deleted:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "delete has not proceeded after update completed")
+	}
+
+	// Locks are released as soon as not used
+	require.Eventually(t, func() bool {
+		c.chiLocker.mutex.Lock()
+		defer c.chiLocker.mutex.Unlock()
+		return len(c.chiLocker.locks) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func Test_StatefulSetSemaphore_BoundsConcurrency(t *testing.T) {
+	const limit = 2
+	const operations = 10
+
+	// Track number of operations in flight
+	s := newSemaphore(limit)
+	var inFlight, maxInFlight int32
+	var wg sync.WaitGroup
+	for i := 0; i < operations; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, s.Acquire(context.Background()))
+			defer s.Release()
+
+			cur := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for {
+				max := atomic.LoadInt32(&maxInFlight)
+				if (cur <= max) || atomic.CompareAndSwapInt32(&maxInFlight, max, cur) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(limit), atomic.LoadInt32(&maxInFlight))
+
+	// Context done while waiting for a slot - operation is not issued
+	s = newSemaphore(1)
+	require.NoError(t, s.Acquire(context.Background()))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, s.Acquire(ctx), context.DeadlineExceeded)
+	s.Release()
+
+	// No limit
+	require.Nil(t, newSemaphore(0))
+	require.NoError(t, newSemaphore(0).Acquire(context.Background()))
+
+	// StatefulSet is not updated as long as all slots are taken
+	kubeClient := kubeFake.NewSimpleClientset()
+	c := &Controller{
+		kubeClient:           kubeClient,
+		statefulSetSemaphore: newSemaphore(1),
+	}
+	require.NoError(t, c.statefulSetSemaphore.Acquire(context.Background()))
+	statefulSet := &apps.StatefulSet{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "sts"}}
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.Nil(t, c.updateStatefulSet(ctx, statefulSet, statefulSet, newTestShard(1)[0]))
+	require.Empty(t, kubeClient.Actions())
+}
+
+func Test_TryLockCHI(t *testing.T) {
+	c := &Controller{chiLocker: newKeyedLocker()}
+	chi := newLockerTestCHI("chi")
+
+	// CHI is being reconciled
+	unlockUpdate := c.lockCHI(NewReconcileCHI(reconcileUpdate, chi, chi))
+	_, ok := c.tryLockCHI(chi)
+	require.False(t, ok)
+
+	// Other CHIs are not blocked
+	unlockOther, ok := c.tryLockCHI(newLockerTestCHI("other"))
+	require.True(t, ok)
+	unlockOther()
+
+	// Reconcile completes - CHI can be locked
+	unlockUpdate()
+	unlock, ok := c.tryLockCHI(chi)
+	require.True(t, ok)
+	unlock()
+
+	require.Empty(t, c.chiLocker.locks)
+}
@@ -16,7 +16,9 @@ package chi
 
 import (
 	"context"
+	"sync"
 
+	"github.com/altinity/queue"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 
@@ -48,6 +50,14 @@ type Metrics struct {
 	HostReconcilesErrors metric.Int64Counter
 	// HostReconcilesTimings is a histogram of durations of successfully completed host reconciles
 	HostReconcilesTimings metric.Float64Histogram
+	// HostWaitInClusterTimings is a histogram of durations of waiting for the host to be included into the cluster
+	HostWaitInClusterTimings metric.Float64Histogram
+
+	// SchemerDDLErrors is a number (counter) of failed runs of SQL queries by the schemer
+	SchemerDDLErrors metric.Int64Counter
+
+	// QueueDepth is a number (gauge) of commands waiting in the operator queues
+	QueueDepth metric.Int64ObservableGauge
 
 	PodAddEvents    metric.Int64Counter
 	PodUpdateEvents metric.Int64Counter
@@ -105,6 +115,25 @@ func createMetrics() *Metrics {
 		metric.WithUnit("s"),
 	)
 
+	HostWaitInClusterTimings, _ := metrics.Meter().Float64Histogram(
+		"clickhouse_operator_host_wait_in_cluster_timings",
+		metric.WithDescription("timings of waiting for host to be included into the cluster"),
+		metric.WithUnit("s"),
+	)
+
+	SchemerDDLErrors, _ := metrics.Meter().Int64Counter(
+		"clickhouse_operator_schemer_ddl_errors",
+		metric.WithDescription("number of failed runs of SQL queries by the schemer"),
+		metric.WithUnit("items"),
+	)
+
+	QueueDepth, _ := metrics.Meter().Int64ObservableGauge(
+		"clickhouse_operator_queue_depth",
+		metric.WithDescription("number of commands waiting in the operator queues"),
+		metric.WithUnit("items"),
+		metric.WithInt64Callback(observeQueueDepth),
+	)
+
 	PodAddEvents, _ := metrics.Meter().Int64Counter(
 		"clickhouse_operator_pod_add_events",
 		metric.WithDescription("number PodAdd events"),
@@ -133,6 +162,12 @@ func createMetrics() *Metrics {
 		HostReconcilesErrors:    HostReconcilesErrors,
 		HostReconcilesTimings:   HostReconcilesTimings,
 
+		HostWaitInClusterTimings: HostWaitInClusterTimings,
+
+		SchemerDDLErrors: SchemerDDLErrors,
+
+		QueueDepth: QueueDepth,
+
 		PodAddEvents:    PodAddEvents,
 		PodUpdateEvents: PodUpdateEvents,
 		PodDeleteEvents: PodDeleteEvents,
@@ -146,6 +181,36 @@ func ensureMetrics() *Metrics {
 	return m
 }
 
+// observedQueues specifies queues, depth of which is reported, by the name of the queue
+var observedQueues struct {
+	sync.Mutex
+	queues map[string][]queue.PriorityQueue
+}
+
+// metricsObserveQueues registers queues, depth of which is reported under the specified name
+func metricsObserveQueues(name string, queues ...queue.PriorityQueue) {
+	observedQueues.Lock()
+	defer observedQueues.Unlock()
+	if observedQueues.queues == nil {
+		observedQueues.queues = make(map[string][]queue.PriorityQueue)
+	}
+	observedQueues.queues[name] = append(observedQueues.queues[name], queues...)
+}
+
+// observeQueueDepth reports total depth of the queues registered under each name
+func observeQueueDepth(_ context.Context, observer metric.Int64Observer) error {
+	observedQueues.Lock()
+	defer observedQueues.Unlock()
+	for name, queues := range observedQueues.queues {
+		depth := 0
+		for _, q := range queues {
+			depth += q.Len()
+		}
+		observer.Observe(int64(depth), metric.WithAttributes(attribute.String("queue", name)))
+	}
+	return nil
+}
+
 func prepareLabels(chi *api.ClickHouseInstallation) (attributes []attribute.KeyValue) {
 	labels, values := metrics.GetMandatoryLabelsAndValues(chi)
 	for i := range labels {
@@ -186,6 +251,14 @@ func metricsHostReconcilesTimings(ctx context.Context, chi *api.ClickHouseInstal
 	ensureMetrics().HostReconcilesTimings.Record(ctx, seconds, metric.WithAttributes(prepareLabels(chi)...))
 }
 
+func metricsHostWaitInClusterTimings(ctx context.Context, chi *api.ClickHouseInstallation, seconds float64) {
+	ensureMetrics().HostWaitInClusterTimings.Record(ctx, seconds, metric.WithAttributes(prepareLabels(chi)...))
+}
+
+func metricsSchemerDDLErrors(ctx context.Context, chi *api.ClickHouseInstallation) {
+	ensureMetrics().SchemerDDLErrors.Add(ctx, 1, metric.WithAttributes(prepareLabels(chi)...))
+}
+
 func metricsPodAdd(ctx context.Context) {
 	ensureMetrics().PodAddEvents.Add(ctx, 1)
 }
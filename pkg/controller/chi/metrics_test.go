@@ -0,0 +1,65 @@
+package chi
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/altinity/queue"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// setNoopMetrics makes the operator record metrics with no-op instruments till the end of the test,
+// since metrics exporter is not started in tests
+func setNoopMetrics(t *testing.T) {
+	instruments := []interface{}{noop.Int64Counter{}, noop.Float64Histogram{}, noop.Int64ObservableGauge{}}
+	metrics := &Metrics{}
+	fields := reflect.ValueOf(metrics).Elem()
+	for i := 0; i < fields.NumField(); i++ {
+		for _, instrument := range instruments {
+			if reflect.TypeOf(instrument).AssignableTo(fields.Field(i).Type()) {
+				fields.Field(i).Set(reflect.ValueOf(instrument))
+			}
+		}
+		require.False(t, fields.Field(i).IsNil(), fields.Type().Field(i).Name)
+	}
+
+	prev := m
+	m = metrics
+	t.Cleanup(func() {
+		m = prev
+	})
+}
+
+// fakeInt64Observer collects observed values by the value of the "queue" attribute
+type fakeInt64Observer struct {
+	metric.Int64Observer
+	observed map[string]int64
+}
+
+func (o *fakeInt64Observer) Observe(value int64, options ...metric.ObserveOption) {
+	attributes := metric.NewObserveConfig(options).Attributes()
+	name, _ := attributes.Value("queue")
+	o.observed[name.AsString()] = value
+}
+
+func Test_ObserveQueueDepth(t *testing.T) {
+	system := []queue.PriorityQueue{queue.New(), queue.New()}
+	chi := newKeyedQueue()
+	metricsObserveQueues("system", system...)
+	metricsObserveQueues("chi", chi)
+	defer func() {
+		observedQueues.queues = nil
+	}()
+
+	system[0].Insert(NewDropDns(&meta.ObjectMeta{Namespace: "ns", Name: "pod-0"}))
+	system[1].Insert(NewDropDns(&meta.ObjectMeta{Namespace: "ns", Name: "pod-1"}))
+	chi.Insert(NewReconcileCHI(reconcileAdd, nil, newLockerTestCHI("chi")))
+
+	observer := &fakeInt64Observer{observed: make(map[string]int64)}
+	require.NoError(t, observeQueueDepth(context.Background(), observer))
+	require.Equal(t, map[string]int64{"system": 2, "chi": 1}, observer.observed)
+}
@@ -26,6 +26,7 @@ import (
 	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
 	"github.com/altinity/clickhouse-operator/pkg/chop"
 	"github.com/altinity/clickhouse-operator/pkg/controller"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
 	"github.com/altinity/clickhouse-operator/pkg/model/k8s"
 	"github.com/altinity/clickhouse-operator/pkg/util"
 )
@@ -78,14 +79,23 @@ func (c *Controller) waitHostReady(ctx context.Context, host *api.ChiHost) error
 	}
 
 	// Wait StatefulSet to reach ready status
+	// PVCs, which are not able to be bound, would make pod to be Pending forever, so they are checked while waiting
+	pvcsWarned := make(map[string]bool)
 	err = c.pollHostStatefulSet(
 		ctx,
 		host,
-		nil, // rely on default options
+		newHostReadyPollerOptions(host),
 		func(_ctx context.Context, sts *apps.StatefulSet) bool {
 			_ = c.deleteLabelReadyPod(_ctx, host)
 			_ = c.deleteAnnotationReadyService(_ctx, host)
-			return k8s.IsStatefulSetReady(sts)
+			if k8s.IsStatefulSetReady(sts) {
+				c.updateRolloutStuckReason(_ctx, host, "")
+				return true
+			}
+			c.checkHostPVCsPending(_ctx, host, pvcsWarned)
+			// Explain what rollout is waiting for, reason follows changes of the pod state
+			c.updateRolloutStuckReason(_ctx, host, c.getRolloutStuckReason(_ctx, host))
+			return false
 		},
 		func(_ctx context.Context) {
 			_ = c.deleteLabelReadyPod(_ctx, host)
@@ -96,6 +106,24 @@ func (c *Controller) waitHostReady(ctx context.Context, host *api.ChiHost) error
 	return err
 }
 
+// newHostReadyPollerOptions makes poll options to wait for host to become ready.
+// Timeout is extended to cover the probes of the host, so hosts with probes scaled by the size of the cluster
+// are not given up on before k8s itself would do so.
+func newHostReadyPollerOptions(host *api.ChiHost) *controller.PollerOptions {
+	opts := controller.NewPollerOptions().FromConfig(chop.Config())
+	if !host.HasDesiredStatefulSet() {
+		return opts
+	}
+	container, ok := k8s.StatefulSetContainerGet(host.Runtime.DesiredStatefulSet, model.ClickHouseContainerName, 0)
+	if !ok {
+		return opts
+	}
+	if duration := k8s.ContainerReadyDuration(container); duration > opts.Timeout {
+		opts.Timeout = duration
+	}
+	return opts
+}
+
 // waitHostDeleted polls host's StatefulSet until it is not available
 func (c *Controller) waitHostDeleted(host *api.ChiHost) {
 	for {
@@ -0,0 +1,182 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/fields"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/chop"
+	"github.com/altinity/clickhouse-operator/pkg/controller"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	"github.com/altinity/clickhouse-operator/pkg/model/k8s"
+)
+
+// getRolloutStuckReason explains why StatefulSet rollout of the host does not progress.
+// Empty reason means nothing suspicious found, rollout may be just in progress.
+func (c *Controller) getRolloutStuckReason(ctx context.Context, host *api.ChiHost) string {
+	pod, err := c.getPod(host)
+	if err == nil {
+		return k8s.PodGetStuckReason(pod)
+	}
+	if !apiErrors.IsNotFound(err) {
+		return ""
+	}
+	// Pod is not created at all, StatefulSet controller reports why in StatefulSet's events
+	return c.getStatefulSetWarning(ctx, host)
+}
+
+// getStatefulSetWarning gets the latest warning event of the host's StatefulSet
+func (c *Controller) getStatefulSetWarning(ctx context.Context, host *api.ChiHost) string {
+	namespace := host.Runtime.Address.Namespace
+	name := host.Runtime.Address.StatefulSet
+	opts := controller.NewListOptions()
+	opts.FieldSelector = fields.Set{
+		"involvedObject.kind": "StatefulSet",
+		"involvedObject.name": name,
+		"type":                core.EventTypeWarning,
+	}.String()
+	events, err := c.kubeClient.CoreV1().Events(namespace).List(ctx, opts)
+	if err != nil {
+		log.V(1).M(host).F().Warning("Unable to list events of StatefulSet %s/%s err: %v", namespace, name, err)
+		return ""
+	}
+
+	var latest *core.Event
+	for i := range events.Items {
+		event := &events.Items[i]
+		if (event.InvolvedObject.Kind != "StatefulSet") || (event.InvolvedObject.Name != name) || (event.Type != core.EventTypeWarning) {
+			continue
+		}
+		if (latest == nil) || latest.LastTimestamp.Before(&event.LastTimestamp) {
+			latest = event
+		}
+	}
+	if latest == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s: %s", latest.Reason, latest.Message)
+}
+
+// updateRolloutStuckReason records reason StatefulSet rollout of the host is stuck in CHI status
+// and reports it with an event. Nothing is done in case reason is not changed.
+// Empty reason clears previously recorded one.
+func (c *Controller) updateRolloutStuckReason(ctx context.Context, host *api.ChiHost, reason string) {
+	chi := host.GetCHI()
+	if chi.EnsureStatus().GetRolloutStuckReason(host.GetName()) == reason {
+		return
+	}
+
+	chi.EnsureStatus().SetRolloutStuckReason(host.GetName(), reason)
+	if reason != "" {
+		log.V(1).M(host).F().Warning("StatefulSet %s rollout is stuck: %s", host.Runtime.Address.StatefulSet, reason)
+		c.EventWarning(
+			chi,
+			eventActionReconcile,
+			eventReasonRolloutStuck,
+			fmt.Sprintf("StatefulSet %s rollout is stuck: %s", host.Runtime.Address.StatefulSet, reason),
+		)
+	}
+	_ = c.updateCHIObjectStatus(ctx, chi, UpdateCHIStatusOptions{
+		CopyCHIStatusOptions: api.CopyCHIStatusOptions{
+			MainFields: true,
+		},
+	})
+}
+
+// isPVCStuckPending checks whether PVC is Pending for longer than the threshold
+func isPVCStuckPending(pvc *core.PersistentVolumeClaim, now time.Time, threshold time.Duration) bool {
+	if (pvc == nil) || (pvc.Status.Phase != core.ClaimPending) {
+		return false
+	}
+	return now.Sub(pvc.CreationTimestamp.Time) > threshold
+}
+
+// checkPVCPending warns about PVC stuck in Pending phase, along with the reason reported by k8s.
+// Returns whether PVC is stuck
+func (c *Controller) checkPVCPending(ctx context.Context, host *api.ChiHost, pvc *core.PersistentVolumeClaim) bool {
+	if !isPVCStuckPending(pvc, time.Now(), chop.Config().GetReconcileHostWaitPVCPendingTimeout()) {
+		return false
+	}
+
+	reason := c.getPVCPendingReason(ctx, pvc)
+	if reason == "" {
+		reason = "unknown reason"
+	}
+	message := fmt.Sprintf("PVC %s/%s of host %s is Pending since %s: %s",
+		pvc.Namespace, pvc.Name, host.GetName(), pvc.CreationTimestamp.Format(time.RFC3339), reason)
+	log.V(1).M(host).F().Warning(message)
+	c.EventWarning(host.GetCHI(), eventActionReconcile, eventReasonVolumeProvisioningStuck, message)
+	return true
+}
+
+// checkHostPVCsPending warns about PVCs of the host stuck in Pending phase while the host is waited for to become ready.
+// Each PVC is warned about once per wait, warned PVCs are tracked in the specified set
+func (c *Controller) checkHostPVCsPending(ctx context.Context, host *api.ChiHost, warned map[string]bool) {
+	host.WalkVolumeMounts(api.DesiredStatefulSet, func(volumeMount *core.VolumeMount) {
+		name, ok := model.CreatePVCNameByVolumeMount(host, volumeMount)
+		if !ok || warned[name] {
+			return
+		}
+		pvc, err := c.kubeClient.CoreV1().PersistentVolumeClaims(host.Runtime.Address.Namespace).Get(ctx, name, controller.NewGetOptions())
+		if err != nil {
+			return
+		}
+		if c.checkPVCPending(ctx, host, pvc) {
+			warned[name] = true
+		}
+	})
+}
+
+// getPVCPendingReason gets message of the latest event reported about the PVC, warning events are preferred
+func (c *Controller) getPVCPendingReason(ctx context.Context, pvc *core.PersistentVolumeClaim) string {
+	opts := controller.NewListOptions()
+	opts.FieldSelector = fields.SelectorFromSet(fields.Set{
+		"involvedObject.kind": "PersistentVolumeClaim",
+		"involvedObject.name": pvc.Name,
+	}).String()
+	events, err := c.kubeClient.CoreV1().Events(pvc.Namespace).List(ctx, opts)
+	if err != nil {
+		log.V(1).F().Info("unable to list events of PVC %s/%s err: %v", pvc.Namespace, pvc.Name, err)
+		return ""
+	}
+
+	var latest *core.Event
+	for i := range events.Items {
+		event := &events.Items[i]
+		if (event.InvolvedObject.Kind != "PersistentVolumeClaim") || (event.InvolvedObject.Name != pvc.Name) {
+			continue
+		}
+		switch {
+		case latest == nil:
+		case (event.Type == core.EventTypeWarning) && (latest.Type != core.EventTypeWarning):
+		case (event.Type == latest.Type) && event.LastTimestamp.After(latest.LastTimestamp.Time):
+		default:
+			continue
+		}
+		latest = event
+	}
+	if latest == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s: %s", latest.Reason, latest.Message)
+}
@@ -0,0 +1,262 @@
+package chi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+	k8sTesting "k8s.io/client-go/testing"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	chopFake "github.com/altinity/clickhouse-operator/pkg/client/clientset/versioned/fake"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	chiCreator "github.com/altinity/clickhouse-operator/pkg/model/chi/creator"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi/normalizer"
+)
+
+func newRolloutTestPod(name, reason, message string) *core.Pod {
+	return &core.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      name,
+		},
+		Status: core.PodStatus{
+			ContainerStatuses: []core.ContainerStatus{
+				{
+					Name: "clickhouse",
+					State: core.ContainerState{
+						Waiting: &core.ContainerStateWaiting{
+							Reason:  reason,
+							Message: message,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func Test_UpdateRolloutStuckReason(t *testing.T) {
+	host := newTestShard(1)[0]
+	chi := host.GetCHI()
+	chi.Namespace = "ns"
+	chi.Name = "chi"
+	host.Runtime.Address.Namespace = "ns"
+	host.Runtime.Address.StatefulSet = "chi-0-0"
+
+	pod := newRolloutTestPod(model.CreatePodName(host), "ImagePullBackOff", `Back-off pulling image "clickhouse:no-such-tag"`)
+	kubeClient := kubeFake.NewSimpleClientset(pod)
+	chopClient := chopFake.NewSimpleClientset(&api.ClickHouseInstallation{ObjectMeta: chi.ObjectMeta})
+	c := &Controller{
+		kubeClient: kubeClient,
+		chopClient: chopClient,
+	}
+	ctx := context.Background()
+
+	getStatusReason := func() string {
+		cur, err := chopClient.ClickhouseV1().ClickHouseInstallations("ns").Get(ctx, "chi", meta.GetOptions{})
+		require.NoError(t, err)
+		return cur.EnsureStatus().GetRolloutStuckReason(host.GetName())
+	}
+	getEvents := func() []string {
+		// Fake client does not generate names, thus created events are tracked by actions
+		var messages []string
+		for _, action := range kubeClient.Actions() {
+			if create, ok := action.(k8sTesting.CreateAction); ok && (action.GetResource().Resource == "events") {
+				event := create.GetObject().(*core.Event)
+				require.Equal(t, eventReasonRolloutStuck, event.Reason)
+				messages = append(messages, event.Message)
+			}
+		}
+		return messages
+	}
+
+	// Pod in ImagePullBackOff surfaces the reason
+	c.updateRolloutStuckReason(ctx, host, c.getRolloutStuckReason(ctx, host))
+	reason := `container clickhouse: ImagePullBackOff: Back-off pulling image "clickhouse:no-such-tag"`
+	require.Equal(t, reason, getStatusReason())
+	require.Equal(t, []string{"StatefulSet chi-0-0 rollout is stuck: " + reason}, getEvents())
+
+	// Same reason is not reported again
+	c.updateRolloutStuckReason(ctx, host, c.getRolloutStuckReason(ctx, host))
+	require.Len(t, getEvents(), 1)
+
+	// Reason follows the pod state
+	pod = newRolloutTestPod(pod.Name, "CrashLoopBackOff", "")
+	_, err := kubeClient.CoreV1().Pods("ns").Update(ctx, pod, meta.UpdateOptions{})
+	require.NoError(t, err)
+	c.updateRolloutStuckReason(ctx, host, c.getRolloutStuckReason(ctx, host))
+	require.Equal(t, "container clickhouse: CrashLoopBackOff", getStatusReason())
+	require.Len(t, getEvents(), 2)
+
+	// Completed rollout clears the reason
+	c.updateRolloutStuckReason(ctx, host, "")
+	require.Empty(t, getStatusReason())
+	require.Len(t, getEvents(), 2)
+}
+
+func Test_GetRolloutStuckReason_NoPod(t *testing.T) {
+	host := newTestShard(1)[0]
+	host.Runtime.Address.Namespace = "ns"
+	host.Runtime.Address.StatefulSet = "chi-0-0"
+	newEvent := func(name, kind, _type, reason string, seconds int64) *core.Event {
+		return &core.Event{
+			ObjectMeta: meta.ObjectMeta{
+				Namespace: "ns",
+				Name:      name,
+			},
+			InvolvedObject: core.ObjectReference{
+				Kind: kind,
+				Name: "chi-0-0",
+			},
+			Type:          _type,
+			Reason:        reason,
+			Message:       "message",
+			LastTimestamp: meta.Unix(seconds, 0),
+		}
+	}
+	c := &Controller{
+		kubeClient: kubeFake.NewSimpleClientset(
+			newEvent("old", "StatefulSet", core.EventTypeWarning, "FailedCreate", 1),
+			newEvent("new", "StatefulSet", core.EventTypeWarning, "FailedCreate", 2),
+			newEvent("normal", "StatefulSet", core.EventTypeNormal, "SuccessfulCreate", 3),
+			newEvent("service", "Service", core.EventTypeWarning, "Failed", 4),
+		),
+	}
+	require.Equal(t, "FailedCreate: message", c.getRolloutStuckReason(context.Background(), host))
+
+	// Pod just starting is not stuck
+	pod := newRolloutTestPod(model.CreatePodName(host), "ContainerCreating", "")
+	c.kubeClient = kubeFake.NewSimpleClientset(pod)
+	require.Empty(t, c.getRolloutStuckReason(context.Background(), host))
+}
+
+func newTestPendingPVC(age time.Duration) *core.PersistentVolumeClaim {
+	pvc := newTestPVC("100Gi", "")
+	pvc.CreationTimestamp = meta.NewTime(time.Now().Add(-age))
+	pvc.Status.Phase = core.ClaimPending
+	return pvc
+}
+
+func newTestPVCEvent(name, pvcName, eventType, reason, message string, age time.Duration) *core.Event {
+	return &core.Event{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      name,
+		},
+		InvolvedObject: core.ObjectReference{
+			Kind:      "PersistentVolumeClaim",
+			Namespace: "ns",
+			Name:      pvcName,
+		},
+		Type:          eventType,
+		Reason:        reason,
+		Message:       message,
+		LastTimestamp: meta.NewTime(time.Now().Add(-age)),
+	}
+}
+
+func Test_IsPVCStuckPending(t *testing.T) {
+	now := time.Now()
+	require.False(t, isPVCStuckPending(nil, now, 5*time.Minute))
+	require.False(t, isPVCStuckPending(newTestPendingPVC(time.Minute), now, 5*time.Minute))
+	require.True(t, isPVCStuckPending(newTestPendingPVC(time.Hour), now, 5*time.Minute))
+	require.True(t, isPVCStuckPending(newTestPendingPVC(time.Minute), now, 30*time.Second))
+
+	bound := newTestPendingPVC(time.Hour)
+	bound.Status.Phase = core.ClaimBound
+	require.False(t, isPVCStuckPending(bound, now, 5*time.Minute))
+}
+
+func Test_CheckPVCPending(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+	ctx := context.Background()
+	pvc := newTestPendingPVC(time.Hour)
+	kubeClient := kubeFake.NewSimpleClientset(
+		newTestPVCEvent("e1", pvc.Name, core.EventTypeNormal, "WaitForFirstConsumer", "waiting for first consumer", 0),
+		newTestPVCEvent("e2", pvc.Name, core.EventTypeWarning, "ProvisioningFailed", "old failure", time.Hour),
+		newTestPVCEvent("e3", pvc.Name, core.EventTypeWarning, "ProvisioningFailed", "storageclass.storage.k8s.io \"ssd\" not found", time.Minute),
+		newTestPVCEvent("e4", "another-pvc", core.EventTypeWarning, "ProvisioningFailed", "insufficient capacity", 0),
+	)
+	c := &Controller{
+		kubeClient: kubeClient,
+	}
+	host := &api.ChiHost{}
+	host.Runtime.CHI = &api.ClickHouseInstallation{}
+
+	// The latest warning is the reason
+	require.Equal(t, "ProvisioningFailed: storageclass.storage.k8s.io \"ssd\" not found", c.getPVCPendingReason(ctx, pvc))
+	require.True(t, c.checkPVCPending(ctx, host, pvc))
+
+	// Recently created PVC may be Pending for a while
+	require.False(t, c.checkPVCPending(ctx, host, newTestPendingPVC(time.Minute)))
+
+	// Threshold is taken from the operator config
+	setTestConfig(t, &api.OperatorConfig{
+		Reconcile: api.OperatorConfigReconcile{
+			Host: api.OperatorConfigReconcileHost{
+				Wait: api.OperatorConfigReconcileHostWait{PVCPendingTimeout: 30},
+			},
+		},
+	})
+	require.True(t, c.checkPVCPending(ctx, host, newTestPendingPVC(time.Minute)))
+
+	// No events reported
+	another := newTestPendingPVC(time.Hour)
+	another.Name = "data-chi-0-1-0"
+	require.Equal(t, "", c.getPVCPendingReason(ctx, another))
+	require.True(t, c.checkPVCPending(ctx, host, another))
+}
+
+func Test_CheckHostPVCsPending_WarnsOncePerWait(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+
+	chi := &api.ClickHouseInstallation{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "chi"}}
+	chi.Spec.Defaults = api.NewChiDefaults()
+	chi.Spec.Defaults.Templates = &api.ChiTemplateNames{DataVolumeClaimTemplate: "data"}
+	chi.Spec.Templates = &api.Templates{
+		VolumeClaimTemplates: []api.VolumeClaimTemplate{*newTestVolumeClaimTemplate("100Gi")},
+	}
+	chi.Spec.Configuration = &api.Configuration{Clusters: []*api.Cluster{{Name: "cluster"}}}
+	chi, err := normalizer.NewNormalizer(nil).CreateTemplatedCHI(chi, normalizer.NewOptions())
+	require.NoError(t, err)
+	host := chi.FindHost("cluster", 0, 0)
+	require.NotNil(t, host)
+	host.Runtime.DesiredStatefulSet = chiCreator.NewCreator(chi).CreateStatefulSet(host, false)
+
+	var name string
+	host.WalkVolumeMounts(api.DesiredStatefulSet, func(volumeMount *core.VolumeMount) {
+		if pvcName, ok := model.CreatePVCNameByVolumeMount(host, volumeMount); ok {
+			name = pvcName
+		}
+	})
+	require.NotEmpty(t, name)
+	pvc := newTestPendingPVC(time.Hour)
+	pvc.Name = name
+
+	kubeClient := kubeFake.NewSimpleClientset(pvc)
+	c := &Controller{
+		kubeClient: kubeClient,
+	}
+	ctx := context.Background()
+	countWarnings := func() (warnings int) {
+		// Fake client does not generate names, so events are counted by create actions
+		for _, action := range kubeClient.Actions() {
+			if (action.GetVerb() == "create") && (action.GetResource().Resource == "events") {
+				warnings++
+			}
+		}
+		return warnings
+	}
+
+	// PVC stuck Pending is reported while the host is waited for, once per wait
+	warned := make(map[string]bool)
+	c.checkHostPVCsPending(ctx, host, warned)
+	c.checkHostPVCsPending(ctx, host, warned)
+	require.Equal(t, 1, countWarnings())
+	require.True(t, warned[name])
+}
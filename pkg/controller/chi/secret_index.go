@@ -0,0 +1,141 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"sync"
+
+	core "k8s.io/api/core/v1"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/chop"
+)
+
+// secretDataStrippedAnnotation marks cached secrets, data of which is dropped, since no CHI refers to them
+const secretDataStrippedAnnotation = "clickhouse.altinity.com/data-stripped"
+
+// secretIndex keeps track of CHIs referring to secrets, values of which are rendered into config.
+// Keys are 'namespace/name' of both secrets and CHIs.
+type secretIndex struct {
+	mutex sync.RWMutex
+	// chis maps secret to CHIs referring to it
+	chis map[string]map[string]bool
+	// secrets maps CHI to secrets it refers to
+	secrets map[string][]string
+}
+
+// newSecretIndex creates new secret index
+func newSecretIndex() *secretIndex {
+	return &secretIndex{
+		chis:    make(map[string]map[string]bool),
+		secrets: make(map[string][]string),
+	}
+}
+
+// Update sets secrets the normalized CHI refers to
+func (i *secretIndex) Update(chi *api.ClickHouseInstallation) {
+	if i == nil {
+		return
+	}
+
+	key := chi.Namespace + "/" + chi.Name
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	i.remove(key)
+	secrets := chi.EnsureRuntime().GetReferencedSecrets()
+	for _, secret := range secrets {
+		if i.chis[secret] == nil {
+			i.chis[secret] = make(map[string]bool)
+		}
+		i.chis[secret][key] = true
+	}
+	if len(secrets) > 0 {
+		i.secrets[key] = append([]string(nil), secrets...)
+	}
+}
+
+// Remove drops the CHI from the index
+func (i *secretIndex) Remove(namespace, name string) {
+	if i == nil {
+		return
+	}
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	i.remove(namespace + "/" + name)
+}
+
+// remove drops the CHI from the index. Is expected to be called under lock
+func (i *secretIndex) remove(key string) {
+	for _, secret := range i.secrets[key] {
+		delete(i.chis[secret], key)
+		if len(i.chis[secret]) == 0 {
+			delete(i.chis, secret)
+		}
+	}
+	delete(i.secrets, key)
+}
+
+// Has checks whether any CHI refers to the secret
+func (i *secretIndex) Has(namespace, name string) bool {
+	if i == nil {
+		return false
+	}
+
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	_, ok := i.chis[namespace+"/"+name]
+	return ok
+}
+
+// GetCHIs gets CHIs referring to the secret, as 'namespace/name'
+func (i *secretIndex) GetCHIs(namespace, name string) (chis []string) {
+	if i == nil {
+		return nil
+	}
+
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	for chi := range i.chis[namespace+"/"+name] {
+		chis = append(chis, chi)
+	}
+	return chis
+}
+
+// isSecretOfInterest checks whether values of the secret are read by the operator
+func (c *Controller) isSecretOfInterest(secret *core.Secret) bool {
+	return chop.Get().ConfigManager.IsSecretCredentials(secret.Namespace, secret.Name) ||
+		c.secretIndex.Has(secret.Namespace, secret.Name)
+}
+
+// transformSecret drops data of the secret, values of which are not read by the operator,
+// so the informer does not keep data of all secrets in watched namespaces
+func (c *Controller) transformSecret(obj interface{}) (interface{}, error) {
+	secret, ok := obj.(*core.Secret)
+	if !ok || c.isSecretOfInterest(secret) {
+		return obj, nil
+	}
+	secret.Data = nil
+	secret.StringData = nil
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	secret.Annotations[secretDataStrippedAnnotation] = "true"
+	return secret, nil
+}
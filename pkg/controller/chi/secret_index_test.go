@@ -0,0 +1,140 @@
+package chi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+	coreListers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi/normalizer"
+)
+
+func newSecretIndexTestCHI(name string, secrets ...string) *api.ClickHouseInstallation {
+	chi := &api.ClickHouseInstallation{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: name}}
+	for _, secret := range secrets {
+		chi.EnsureRuntime().AddReferencedSecret("ns", secret)
+	}
+	return chi
+}
+
+func Test_SecretIndex(t *testing.T) {
+	index := newSecretIndex()
+	index.Update(newSecretIndexTestCHI("chi1", "shared", "own"))
+	index.Update(newSecretIndexTestCHI("chi2", "shared"))
+
+	require.True(t, index.Has("ns", "own"))
+	require.ElementsMatch(t, []string{"ns/chi1", "ns/chi2"}, index.GetCHIs("ns", "shared"))
+	require.False(t, index.Has("ns", "other"))
+	require.Empty(t, index.GetCHIs("ns", "other"))
+
+	// CHI which stops referring to the secret is not reconciled on its rotation
+	index.Update(newSecretIndexTestCHI("chi1", "shared"))
+	require.False(t, index.Has("ns", "own"))
+	require.ElementsMatch(t, []string{"ns/chi1", "ns/chi2"}, index.GetCHIs("ns", "shared"))
+
+	// Deleted CHI is dropped
+	index.Remove("ns", "chi1")
+	require.Equal(t, []string{"ns/chi2"}, index.GetCHIs("ns", "shared"))
+	index.Remove("ns", "chi2")
+	require.False(t, index.Has("ns", "shared"))
+}
+
+func Test_SecretIndex_NormalizerReferencedSecrets(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+
+	chi := &api.ClickHouseInstallation{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "chi"}}
+	chi.Spec.Configuration = &api.Configuration{Users: api.NewSettings()}
+	chi.Spec.Configuration.Users.Set("alice/password", api.NewSettingSource(&api.SettingSource{
+		ValueFrom: &api.DataSource{
+			SecretKeyRef: &core.SecretKeySelector{
+				LocalObjectReference: core.LocalObjectReference{Name: "alice-secret"},
+				Key:                  "pwd",
+			},
+		},
+	}))
+	n := normalizer.NewNormalizer(func(namespace, name string) (*core.Secret, error) {
+		return &core.Secret{Data: map[string][]byte{"pwd": []byte("qwerty")}}, nil
+	})
+	normalized, err := n.CreateTemplatedCHI(chi, normalizer.NewOptions())
+	require.NoError(t, err)
+
+	index := newSecretIndex()
+	index.Update(normalized)
+	require.Equal(t, []string{"ns/chi"}, index.GetCHIs("ns", "alice-secret"))
+}
+
+func Test_TransformSecret(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+	c := &Controller{secretIndex: newSecretIndex()}
+	c.secretIndex.Update(newSecretIndexTestCHI("chi", "referenced"))
+
+	newSecret := func(name string) *core.Secret {
+		return &core.Secret{
+			ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: name},
+			Data:       map[string][]byte{"pwd": []byte("qwerty")},
+		}
+	}
+
+	// Data of referenced secret is kept
+	obj, err := c.transformSecret(newSecret("referenced"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("qwerty"), obj.(*core.Secret).Data["pwd"])
+	require.NotContains(t, obj.(*core.Secret).Annotations, secretDataStrippedAnnotation)
+
+	// Data of any other secret is not cached
+	obj, err = c.transformSecret(newSecret("other"))
+	require.NoError(t, err)
+	require.Nil(t, obj.(*core.Secret).Data)
+	require.Contains(t, obj.(*core.Secret).Annotations, secretDataStrippedAnnotation)
+}
+
+func Test_GetSecretCached(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+	cached := &core.Secret{
+		ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "cached"},
+		Data:       map[string][]byte{"pwd": []byte("cached")},
+	}
+	stripped := &core.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace:   "ns",
+			Name:        "stripped",
+			Annotations: map[string]string{secretDataStrippedAnnotation: "true"},
+		},
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, indexer.Add(cached))
+	require.NoError(t, indexer.Add(stripped))
+
+	kubeClient := kubeFake.NewSimpleClientset(
+		&core.Secret{
+			ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "stripped"},
+			Data:       map[string][]byte{"pwd": []byte("live")},
+		},
+		&core.Secret{
+			ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "uncached"},
+			Data:       map[string][]byte{"pwd": []byte("live")},
+		},
+	)
+	c := &Controller{
+		kubeClient:   kubeClient,
+		secretLister: coreListers.NewSecretLister(indexer),
+	}
+
+	// Cached secret is not read from k8s API
+	secret, err := c.getSecretCached("ns", "cached")
+	require.NoError(t, err)
+	require.Equal(t, []byte("cached"), secret.Data["pwd"])
+	require.Empty(t, kubeClient.Actions())
+
+	// Secret cached with data dropped and secret not cached are read from k8s API
+	for _, name := range []string{"stripped", "uncached"} {
+		secret, err = c.getSecretCached("ns", name)
+		require.NoError(t, err)
+		require.Equal(t, []byte("live"), secret.Data["pwd"])
+	}
+}
@@ -43,8 +43,13 @@ const (
 	priorityReconcileCHI        int = 10
 	priorityReconcileCHIT       int = 5
 	priorityReconcileChopConfig int = 3
+	priorityReconcileBackup     int = 3
+	priorityReconcileRestore    int = 3
+	priorityReconcileUser       int = 3
 	priorityReconcileEndpoints  int = 15
 	priorityDropDNS             int = 7
+	priorityPodDisruption       int = 7
+	prioritySecretRotation      int = 7
 )
 
 // ReconcileCHI specifies reconcile request queue item
@@ -68,6 +73,42 @@ func (r ReconcileCHI) Handle() queue.T {
 	return ""
 }
 
+// coalesce absorbs subsequent command of the same CHI, so only the latest state of the CHI is reconciled.
+// Returns false in case commands can not be coalesced.
+func (r *ReconcileCHI) coalesce(next queue.PriorityQueueItem) bool {
+	command, ok := next.(*ReconcileCHI)
+	if !ok {
+		return false
+	}
+	switch {
+	case !isDebounceable(command):
+		// Delete supersedes waiting command
+		*r = *command
+	case !isDebounceable(r):
+		// Waiting delete has to be completed first
+		return false
+	default:
+		*r = *coalesceCommands(r, command)
+	}
+	return true
+}
+
+// supersedes checks whether command makes reconcile of the current command of the same CHI obsolete.
+// It is the case when newer generation of the CHI is observed or the CHI is deleted.
+func (r *ReconcileCHI) supersedes(current queue.PriorityQueueItem) bool {
+	command, ok := current.(*ReconcileCHI)
+	switch {
+	case !ok:
+		return true
+	case !isDebounceable(r):
+		return true
+	case !isDebounceable(command):
+		// Delete in progress has to be completed
+		return false
+	}
+	return r.new.GetGeneration() > command.new.GetGeneration()
+}
+
 // NewReconcileCHI creates new reconcile request queue item
 func NewReconcileCHI(cmd string, old, new *api.ClickHouseInstallation) *ReconcileCHI {
 	return &ReconcileCHI{
@@ -164,6 +205,105 @@ func NewReconcileChopConfig(cmd string, old, new *api.ClickHouseOperatorConfigur
 	}
 }
 
+// ReconcileBackup specifies reconcile backup queue item
+type ReconcileBackup struct {
+	PriorityQueueItem
+	cmd string
+	old *api.ClickHouseBackup
+	new *api.ClickHouseBackup
+}
+
+var _ queue.PriorityQueueItem = &ReconcileBackup{}
+
+// Handle returns handle of the queue item
+func (r ReconcileBackup) Handle() queue.T {
+	if r.new != nil {
+		return "ReconcileBackup" + ":" + r.new.Namespace + "/" + r.new.Name
+	}
+	if r.old != nil {
+		return "ReconcileBackup" + ":" + r.old.Namespace + "/" + r.old.Name
+	}
+	return ""
+}
+
+// NewReconcileBackup creates new reconcile backup queue item
+func NewReconcileBackup(cmd string, old, new *api.ClickHouseBackup) *ReconcileBackup {
+	return &ReconcileBackup{
+		PriorityQueueItem: PriorityQueueItem{
+			priority: priorityReconcileBackup,
+		},
+		cmd: cmd,
+		old: old,
+		new: new,
+	}
+}
+
+// ReconcileRestore specifies reconcile restore queue item
+type ReconcileRestore struct {
+	PriorityQueueItem
+	cmd string
+	old *api.ClickHouseRestore
+	new *api.ClickHouseRestore
+}
+
+var _ queue.PriorityQueueItem = &ReconcileRestore{}
+
+// Handle returns handle of the queue item
+func (r ReconcileRestore) Handle() queue.T {
+	if r.new != nil {
+		return "ReconcileRestore" + ":" + r.new.Namespace + "/" + r.new.Name
+	}
+	if r.old != nil {
+		return "ReconcileRestore" + ":" + r.old.Namespace + "/" + r.old.Name
+	}
+	return ""
+}
+
+// NewReconcileRestore creates new reconcile restore queue item
+func NewReconcileRestore(cmd string, old, new *api.ClickHouseRestore) *ReconcileRestore {
+	return &ReconcileRestore{
+		PriorityQueueItem: PriorityQueueItem{
+			priority: priorityReconcileRestore,
+		},
+		cmd: cmd,
+		old: old,
+		new: new,
+	}
+}
+
+// ReconcileUser specifies reconcile user queue item
+type ReconcileUser struct {
+	PriorityQueueItem
+	cmd string
+	old *api.ClickHouseUser
+	new *api.ClickHouseUser
+}
+
+var _ queue.PriorityQueueItem = &ReconcileUser{}
+
+// Handle returns handle of the queue item
+func (r ReconcileUser) Handle() queue.T {
+	if r.new != nil {
+		return "ReconcileUser" + ":" + r.new.Namespace + "/" + r.new.Name
+	}
+	if r.old != nil {
+		return "ReconcileUser" + ":" + r.old.Namespace + "/" + r.old.Name
+	}
+	return ""
+}
+
+// NewReconcileUser creates new reconcile user queue item
+func NewReconcileUser(cmd string, old, new *api.ClickHouseUser) *ReconcileUser {
+	return &ReconcileUser{
+		PriorityQueueItem: PriorityQueueItem{
+			priority: priorityReconcileUser,
+		},
+		cmd: cmd,
+		old: old,
+		new: new,
+	}
+}
+
 // ReconcileEndpoints specifies endpoint
 type ReconcileEndpoints struct {
 	PriorityQueueItem
@@ -223,6 +363,60 @@ func NewDropDns(initiator *meta.ObjectMeta) *DropDns {
 	}
 }
 
+// PodDisruption specifies pod disruption queue item.
+// Initiated by the pod, which starts terminating or is back to service.
+type PodDisruption struct {
+	PriorityQueueItem
+	initiator *meta.ObjectMeta
+}
+
+var _ queue.PriorityQueueItem = &PodDisruption{}
+
+// Handle returns handle of the queue item
+func (r PodDisruption) Handle() queue.T {
+	if r.initiator != nil {
+		return "PodDisruption" + ":" + r.initiator.Namespace + "/" + r.initiator.Name
+	}
+	return ""
+}
+
+// NewPodDisruption creates new pod disruption queue item
+func NewPodDisruption(initiator *meta.ObjectMeta) *PodDisruption {
+	return &PodDisruption{
+		PriorityQueueItem: PriorityQueueItem{
+			priority: priorityPodDisruption,
+		},
+		initiator: initiator,
+	}
+}
+
+// SecretRotation specifies secret rotation queue item.
+// Initiated by the secret, which data are changed.
+type SecretRotation struct {
+	PriorityQueueItem
+	initiator *meta.ObjectMeta
+}
+
+var _ queue.PriorityQueueItem = &SecretRotation{}
+
+// Handle returns handle of the queue item
+func (r SecretRotation) Handle() queue.T {
+	if r.initiator != nil {
+		return "SecretRotation" + ":" + r.initiator.Namespace + "/" + r.initiator.Name
+	}
+	return ""
+}
+
+// NewSecretRotation creates new secret rotation queue item
+func NewSecretRotation(initiator *meta.ObjectMeta) *SecretRotation {
+	return &SecretRotation{
+		PriorityQueueItem: PriorityQueueItem{
+			priority: prioritySecretRotation,
+		},
+		initiator: initiator,
+	}
+}
+
 // ReconcilePod specifies pod reconcile
 type ReconcilePod struct {
 	PriorityQueueItem
@@ -17,6 +17,7 @@ package chi
 import (
 	"time"
 
+	"k8s.io/client-go/dynamic"
 	kube "k8s.io/client-go/kubernetes"
 	appsListers "k8s.io/client-go/listers/apps/v1"
 	coreListers "k8s.io/client-go/listers/core/v1"
@@ -35,7 +36,9 @@ import (
 type Controller struct {
 	// kubeClient used to Create() k8s resources as c.kubeClient.AppsV1().StatefulSets(namespace).Create(name)
 	kubeClient kube.Interface
-	extClient  apiExtensions.Interface
+	// dynamicClient used to manage resources, which have no typed client, such as cert-manager Certificates
+	dynamicClient dynamic.Interface
+	extClient     apiExtensions.Interface
 	// chopClient used to Update() CRD k8s resource as c.chopClient.ClickhouseV1().ClickHouseInstallations(chi.Namespace).Update(chiCopy)
 	chopClient chopClientSet.Interface
 
@@ -47,6 +50,13 @@ type Controller struct {
 	chitLister       chopListers.ClickHouseInstallationTemplateLister
 	chitListerSynced cache.InformerSynced
 
+	// chbLister used as chbLister.ClickHouseBackups(namespace).Get(name)
+	chbLister chopListers.ClickHouseBackupLister
+	// chrLister used as chrLister.ClickHouseRestores(namespace).Get(name)
+	chrLister chopListers.ClickHouseRestoreLister
+	// chuLister used as chuLister.ClickHouseUsers(namespace).Get(name)
+	chuLister chopListers.ClickHouseUserLister
+
 	// serviceLister used as serviceLister.Services(namespace).Get(name)
 	serviceLister coreListers.ServiceLister
 	// serviceListerSynced used in waitForCacheSync()
@@ -67,9 +77,27 @@ type Controller struct {
 	podLister coreListers.PodLister
 	// podListerSynced used in waitForCacheSync()
 	podListerSynced cache.InformerSynced
+	// secretLister used as secretLister.Secrets(namespace).Get(name)
+	secretLister coreListers.SecretLister
+	// secretIndex used to find CHIs referring to secrets
+	secretIndex *secretIndex
+	// accessTLS used to keep TLS material hosts of CHIs are accessed with
+	accessTLS *accessTLSCache
 
 	// queues used to organize events queue processed by operator
 	queues []queue.PriorityQueue
+	// workersNum specifies number of workers serving the queues
+	workersNum int
+	// debouncer used to coalesce rapid updates of a CHI into a single reconcile
+	debouncer *debouncer
+	// chiLocker used to prevent concurrent mutating operations on the same CHI
+	chiLocker *keyedLocker
+	// statefulSetSemaphore used to limit concurrent StatefulSet create/update operations across all CHIs
+	statefulSetSemaphore *semaphore
+	// eventAggregator used to coalesce repetitive k8s events
+	eventAggregator *eventAggregator
+	// health used to track workers liveness and reconcile results
+	health *healthTracker
 	// not used explicitly
 	recorder record.EventRecorder
 }
@@ -15,7 +15,12 @@
 package chi
 
 import (
+	"context"
+	"fmt"
+	"strings"
+
 	core "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 
 	log "github.com/altinity/clickhouse-operator/pkg/announcer"
 	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
@@ -66,6 +71,35 @@ func (c *Controller) walkDiscoveredPVCs(host *api.ChiHost, f func(pvc *core.Pers
 	}
 }
 
+// getHostPVCNames gets names of PVCs the host has
+func (c *Controller) getHostPVCNames(host *api.ChiHost) (names []string) {
+	c.walkDiscoveredPVCs(host, func(pvc *core.PersistentVolumeClaim) {
+		names = append(names, pvc.Name)
+	})
+	return names
+}
+
+// checkHostPVCsExist checks whether all specified PVCs of the host exist and are not being deleted
+func (c *Controller) checkHostPVCsExist(ctx context.Context, host *api.ChiHost, names []string) error {
+	namespace := host.Runtime.Address.Namespace
+	var missing []string
+	for _, name := range names {
+		pvc, err := c.kubeClient.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, name, controller.NewGetOptions())
+		switch {
+		case apiErrors.IsNotFound(err):
+			missing = append(missing, name)
+		case err != nil:
+			return fmt.Errorf("unable to get PVC %s/%s err: %v", namespace, name, err)
+		case pvc.DeletionTimestamp != nil:
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("PVCs of the host %s/%s are lost: %s", namespace, host.GetName(), strings.Join(missing, ","))
+	}
+	return nil
+}
+
 // Comment out PV
 //func (c *Controller) walkPVs(host *api.ChiHost, f func(pv *core.PersistentVolume)) {
 //	c.walkPVCs(host, func(pvc *core.PersistentVolumeClaim) {
@@ -0,0 +1,302 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilRuntime "k8s.io/apimachinery/pkg/util/runtime"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/controller"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi/backup"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi/normalizer"
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
+// backupPollInterval specifies how often progress of backups running on the hosts is checked
+const backupPollInterval = 15 * time.Second
+
+// processReconcileBackup runs backup described by ClickHouseBackup.
+// Backup is taken by clickhouse-backup, which is expected to run as a sidecar of ClickHouse with REST API enabled.
+// Worker does not wait for backup to complete, it starts backup on the hosts and polls its progress
+// by requeueing the ClickHouseBackup, so long-running backups do not block the queue.
+func (w *worker) processReconcileBackup(ctx context.Context, cmd *ReconcileBackup) error {
+	switch cmd.cmd {
+	case reconcileAdd, reconcileUpdate:
+		return w.reconcileBackup(ctx, cmd.new)
+	case reconcileDelete:
+		w.a.V(1).M(cmd.old).F().Info("Delete CHB %s/%s. Backups are kept on the hosts", cmd.old.Namespace, cmd.old.Name)
+		return nil
+	}
+
+	// Unknown item type, don't know what to do with it
+	// Just skip it and behave like it never existed
+	utilRuntime.HandleError(fmt.Errorf("unexpected reconcile - %#v", cmd))
+	return nil
+}
+
+// reconcileBackup starts backup or checks progress of the backup being taken
+func (w *worker) reconcileBackup(ctx context.Context, chb *api.ClickHouseBackup) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	// Object of the command may be outdated, since status is updated by the operator in between
+	chb, err := w.c.chopClient.ClickhouseV1().ClickHouseBackups(chb.Namespace).Get(ctx, chb.Name, controller.NewGetOptions())
+	switch {
+	case apiErrors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	status := chb.EnsureStatus()
+	switch status.Status {
+	case "", api.BackupStatusPending:
+		w.startBackup(ctx, chb)
+	case api.BackupStatusInProgress:
+		w.pollBackup(ctx, chb)
+	default:
+		// Backup is finished already
+		return nil
+	}
+
+	if status.IsFinished() {
+		w.announceBackupFinished(chb)
+	}
+
+	if _, err := w.c.chopClient.ClickhouseV1().ClickHouseBackups(chb.Namespace).UpdateStatus(ctx, chb, controller.NewUpdateOptions()); err != nil {
+		w.a.M(chb).F().Error("unable to update status of CHB %s/%s err: %v", chb.Namespace, chb.Name, err)
+	}
+
+	if !status.IsFinished() {
+		w.c.requeueBackup(chb, backupPollInterval)
+	}
+	return nil
+}
+
+// startBackup starts creation of backup on one host of each shard of the CHI
+func (w *worker) startBackup(ctx context.Context, chb *api.ClickHouseBackup) {
+	status := chb.EnsureStatus()
+
+	chi, err := w.createCHIFromObjectMeta(&meta.ObjectMeta{Namespace: chb.Namespace, Name: chb.Spec.CHI}, true, normalizer.NewOptions())
+	if err != nil {
+		status.Fail(fmt.Sprintf("unable to find CHI %s err: %v", chb.Spec.CHI, err))
+		return
+	}
+
+	hosts := selectBackupHosts(chi, chb.Spec.Cluster)
+	if len(hosts) == 0 {
+		status.Fail(fmt.Sprintf("no hosts to back up in CHI %s cluster %q", chb.Spec.CHI, chb.Spec.Cluster))
+		return
+	}
+
+	w.reconcileBackupNetworkPolicy(ctx, chb.Namespace, chb.Spec.CHI)
+
+	w.a.V(1).
+		WithEvent(chi, eventActionBackup, eventReasonBackupStarted).
+		M(chi).F().
+		Info("Backup %s started on %d hosts", chb.Name, len(hosts))
+
+	status.Start()
+	for _, host := range hosts {
+		hostStatus := &api.ChbHostStatus{
+			Backup:    createBackupName(chb.Name, host),
+			Operation: backup.OperationCreate,
+			Status:    api.BackupStatusInProgress,
+		}
+		fqdn := model.CreateFQDN(host)
+		if err := backup.NewClient(fqdn, chb.Spec.GetPort()).Create(ctx, hostStatus.Backup, chb.Spec.Tables); err != nil {
+			hostStatus.Status = api.BackupStatusFailed
+			hostStatus.Error = err.Error()
+		}
+		status.SetHostStatus(fqdn, hostStatus)
+	}
+
+	if status.IsHostsFinished() {
+		status.Finish()
+	}
+}
+
+// pollBackup checks progress of backup on the hosts and starts subsequent operations on the hosts done with the current one
+func (w *worker) pollBackup(ctx context.Context, chb *api.ClickHouseBackup) {
+	status := chb.EnsureStatus()
+	for fqdn, hostStatus := range status.Hosts {
+		if hostStatus.Status != api.BackupStatusInProgress {
+			continue
+		}
+
+		client := backup.NewClient(fqdn, chb.Spec.GetPort())
+		action, err := client.GetAction(ctx, hostStatus.Operation, hostStatus.Backup)
+		if err != nil {
+			// Host may be unreachable for a while, check it again later
+			w.a.V(1).M(chb).F().Warning("unable to check backup %s on host %s err: %v", hostStatus.Backup, fqdn, err)
+			continue
+		}
+		if (action == nil) || (action.Status == backup.ActionStatusInProgress) {
+			continue
+		}
+		if action.Status != backup.ActionStatusSuccess {
+			hostStatus.Status = api.BackupStatusFailed
+			hostStatus.Error = action.Error
+			continue
+		}
+
+		if (hostStatus.Operation == backup.OperationCreate) && chb.Spec.Upload.IsTrue() {
+			hostStatus.Operation = backup.OperationUpload
+			if err := client.Upload(ctx, hostStatus.Backup); err != nil {
+				hostStatus.Status = api.BackupStatusFailed
+				hostStatus.Error = err.Error()
+			}
+			continue
+		}
+
+		hostStatus.Status = api.BackupStatusCompleted
+		w.applyBackupRetention(ctx, client, chb, hostStatus)
+	}
+
+	if status.IsHostsFinished() {
+		status.Finish()
+	}
+}
+
+// applyBackupRetention deletes backups of the same shard exceeding the number of backups to keep.
+// Only backups recorded in status of ClickHouseBackups of the CHI are deleted,
+// so backups taken manually or by ClickHouseBackups of other CHIs sharing the storage are left intact.
+// Backups failed to be deleted are left as they are, to be deleted along with the next backup.
+func (w *worker) applyBackupRetention(ctx context.Context, client *backup.Client, chb *api.ClickHouseBackup, hostStatus *api.ChbHostStatus) {
+	retention := chb.Spec.GetRetention()
+	if (retention.Local <= 0) && (retention.Remote <= 0) {
+		return
+	}
+	recorded, err := w.getRecordedBackups(ctx, chb)
+	if err != nil {
+		w.a.V(1).M(chb).F().Warning("unable to list backups of CHI %s err: %v", chb.Spec.CHI, err)
+		return
+	}
+	prefix := strings.TrimSuffix(hostStatus.Backup, chb.Name)
+	for location, keep := range map[string]int{
+		backup.LocationLocal:  retention.Local,
+		backup.LocationRemote: retention.Remote,
+	} {
+		if keep <= 0 {
+			continue
+		}
+		backups, err := client.List(ctx, location)
+		if err != nil {
+			w.a.V(1).M(chb).F().Warning("unable to list %s backups err: %v", location, err)
+			continue
+		}
+		for _, expired := range backup.SelectExpired(filterBackups(backups, prefix, recorded), keep) {
+			w.a.V(1).M(chb).F().Info("delete expired %s backup %s", location, expired.Name)
+			if err := client.Delete(ctx, location, expired.Name); err != nil {
+				w.a.V(1).M(chb).F().Warning("unable to delete %s backup %s err: %v", location, expired.Name, err)
+			}
+		}
+	}
+}
+
+// getRecordedBackups gets names of backups recorded in status of ClickHouseBackups of the same CHI as the specified one
+func (w *worker) getRecordedBackups(ctx context.Context, chb *api.ClickHouseBackup) (map[string]bool, error) {
+	list, err := w.c.chopClient.ClickhouseV1().ClickHouseBackups(chb.Namespace).List(ctx, controller.NewListOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := make(map[string]bool)
+	record := func(status *api.ChbStatus) {
+		if status == nil {
+			return
+		}
+		for _, hostStatus := range status.Hosts {
+			recorded[hostStatus.Backup] = true
+		}
+	}
+	for i := range list.Items {
+		if list.Items[i].Spec.CHI == chb.Spec.CHI {
+			record(list.Items[i].Status)
+		}
+	}
+	// Status of the backup being polled is not stored yet
+	record(chb.Status)
+	return recorded, nil
+}
+
+// announceBackupFinished emits event on the CHI backup is finished with
+func (w *worker) announceBackupFinished(chb *api.ClickHouseBackup) {
+	chi, err := w.c.GetCHIByObjectMeta(&meta.ObjectMeta{Namespace: chb.Namespace, Name: chb.Spec.CHI}, true)
+	if err != nil {
+		return
+	}
+	if chb.Status.Status == api.BackupStatusCompleted {
+		w.a.V(1).
+			WithEvent(chi, eventActionBackup, eventReasonBackupCompleted).
+			M(chi).F().
+			Info("Backup %s completed", chb.Name)
+	} else {
+		w.a.V(1).
+			WithEvent(chi, eventActionBackup, eventReasonBackupFailed).
+			M(chi).F().
+			Warning("Backup %s failed. %s", chb.Name, chb.Status.Error)
+	}
+}
+
+// selectBackupHosts selects one host of each shard of the cluster, or of all clusters in case cluster is not specified.
+// Replicas of the shard have the same data, so backup of one of them is enough.
+func selectBackupHosts(chi *api.ClickHouseInstallation, cluster string) (hosts []*api.ChiHost) {
+	chi.WalkShards(func(shard *api.ChiShard) error {
+		host := shard.FirstHost()
+		if host == nil {
+			return nil
+		}
+		if (cluster != "") && (host.Runtime.Address.ClusterName != cluster) {
+			return nil
+		}
+		hosts = append(hosts, host)
+		return nil
+	})
+	return hosts
+}
+
+// backupNameSeparator separates parts of the backup name.
+// Kubernetes object names and names of clusters and shards can not contain it, so names of different shards never clash
+const backupNameSeparator = "_"
+
+// createBackupName creates name of the backup taken on the host.
+// Name is prefixed by the namespace, the CHI and the shard, so backups of different shards do not clash
+// in the shared remote storage and backups of the same shard are found by the prefix for retention.
+func createBackupName(chb string, host *api.ChiHost) string {
+	address := host.Runtime.Address
+	return strings.Join([]string{address.Namespace, address.CHIName, address.ClusterName, address.ShardName, chb}, backupNameSeparator)
+}
+
+// filterBackups filters backups with names starting with the prefix, which are recorded as taken by the operator
+func filterBackups(backups []*backup.Backup, prefix string, recorded map[string]bool) (res []*backup.Backup) {
+	for _, b := range backups {
+		if strings.HasPrefix(b.Name, prefix) && recorded[b.Name] {
+			res = append(res, b)
+		}
+	}
+	return res
+}
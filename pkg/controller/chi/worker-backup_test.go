@@ -0,0 +1,103 @@
+package chi
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	chopFake "github.com/altinity/clickhouse-operator/pkg/client/clientset/versioned/fake"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi/backup"
+)
+
+func Test_SelectBackupHosts(t *testing.T) {
+	hosts := newTestShard(2)
+	chi := hosts[0].GetCHI()
+	for _, host := range hosts {
+		host.Runtime.Address.Namespace = "ns"
+		host.Runtime.Address.CHIName = "chi"
+	}
+
+	// One host of the shard is enough
+	selected := selectBackupHosts(chi, "")
+	require.Equal(t, []*api.ChiHost{hosts[0]}, selected)
+	require.Equal(t, selected, selectBackupHosts(chi, "cluster"))
+	require.Empty(t, selectBackupHosts(chi, "unknown"))
+
+	require.Equal(t, "ns_chi_cluster_0_daily", createBackupName("daily", hosts[0]))
+}
+
+func Test_PollBackup(t *testing.T) {
+	var uploaded, deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/backup/actions":
+			_, _ = w.Write([]byte(`{"command":"create ns_chi_cluster_0_daily","status":"success"}
+{"command":"create ns_chi_cluster_1_daily","status":"error","error":"no space left"}
+{"command":"create ns_chi_cluster_2_daily","status":"in progress"}
+`))
+		case "/backup/list/local":
+			_, _ = w.Write([]byte(`[{"name":"ns_chi_cluster_0_daily","created":"2024-01-03 00:00:00"},` +
+				`{"name":"ns_chi_cluster_0_old","created":"2024-01-01 00:00:00"},` +
+				`{"name":"ns_chi_cluster_0_manual","created":"2023-12-01 00:00:00"},` +
+				`{"name":"ns_chi_cluster_1_old","created":"2024-01-02 00:00:00"},` +
+				`{"name":"manual","created":"2023-01-01 00:00:00"}]`))
+		case "/backup/upload/ns_chi_cluster_0_daily":
+			uploaded = append(uploaded, r.URL.Path)
+		case "/backup/delete/local/ns_chi_cluster_0_old":
+			deleted = append(deleted, r.URL.Path)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	p, _ := strconv.Atoi(port)
+
+	// Previous backup of the CHI is recorded in status of its ClickHouseBackup
+	old := &api.ClickHouseBackup{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "old"}, Spec: api.ChbSpec{CHI: "chi"}}
+	old.EnsureStatus().SetHostStatus(host, &api.ChbHostStatus{Backup: "ns_chi_cluster_0_old", Status: api.BackupStatusCompleted})
+	w := &worker{
+		a: NewAnnouncer(),
+		c: &Controller{chopClient: chopFake.NewSimpleClientset(old)},
+	}
+	chb := &api.ClickHouseBackup{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "daily"}, Spec: api.ChbSpec{CHI: "chi"}}
+	chb.Spec.Port = int32(p)
+	chb.Spec.Upload = api.NewStringBool(true)
+	chb.Spec.Retention = &api.ChbRetention{Local: 1}
+	status := chb.EnsureStatus()
+	status.Start()
+	status.SetHostStatus(host, &api.ChbHostStatus{Backup: "ns_chi_cluster_0_daily", Operation: backup.OperationCreate, Status: api.BackupStatusInProgress})
+
+	// Created backup is uploaded
+	w.pollBackup(context.Background(), chb)
+	require.Equal(t, []string{"/backup/upload/ns_chi_cluster_0_daily"}, uploaded)
+	require.Equal(t, backup.OperationUpload, status.Hosts[host].Operation)
+	require.Equal(t, api.BackupStatusInProgress, status.Status)
+
+	// Upload is not reported yet
+	w.pollBackup(context.Background(), chb)
+	require.Equal(t, api.BackupStatusInProgress, status.Hosts[host].Status)
+
+	// Completed backup expires older backups of the same shard taken by the operator only
+	status.Hosts[host].Operation = backup.OperationCreate
+	chb.Spec.Upload = nil
+	w.pollBackup(context.Background(), chb)
+	require.Equal(t, api.BackupStatusCompleted, status.Hosts[host].Status)
+	require.Equal(t, []string{"/backup/delete/local/ns_chi_cluster_0_old"}, deleted)
+	require.Equal(t, api.BackupStatusCompleted, status.Status)
+
+	// Failure on the host fails the backup
+	status.Start()
+	status.SetHostStatus(host, &api.ChbHostStatus{Backup: "ns_chi_cluster_1_daily", Operation: backup.OperationCreate, Status: api.BackupStatusInProgress})
+	w.pollBackup(context.Background(), chb)
+	require.Equal(t, "no space left", status.Hosts[host].Error)
+	require.Equal(t, api.BackupStatusFailed, status.Status)
+}
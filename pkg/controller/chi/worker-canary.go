@@ -0,0 +1,158 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
+// isCanaryHost checks whether host is the canary one, which is rolled out ahead of the rest of the hosts of the cluster.
+func isCanaryHost(host *api.ChiHost) bool {
+	if !host.GetCHI().GetReconciling().IsReconcilingStrategyCanary() {
+		return false
+	}
+	return host == findCanaryHost(host.GetCluster())
+}
+
+// findCanaryHost finds canary host of the cluster.
+// Canary is the first host of the cluster, which StatefulSet is rolled out by reconcile.
+// Newly added hosts have nothing to compare to, so they are not canaries.
+// Canary, which has not passed verification, stays canary by the next reconciles, even though it is rolled out already.
+func findCanaryHost(cluster *api.Cluster) *api.ChiHost {
+	if cluster == nil {
+		return nil
+	}
+
+	var pending, modified *api.ChiHost
+	cluster.WalkHosts(func(host *api.ChiHost) error {
+		switch {
+		case pending != nil:
+		case host.GetCHI().EnsureStatus().IsCanaryPending(host.GetName()):
+			pending = host
+		case modified != nil:
+		case host.GetReconcileAttributes().IsAdd():
+		case host.GetReconcileAttributes().GetStatus() == api.ObjectStatusModified:
+			modified = host
+		case host.GetReconcileAttributes().GetStatus() == api.ObjectStatusUnknown:
+			modified = host
+		}
+		return nil
+	})
+
+	if pending != nil {
+		return pending
+	}
+	return modified
+}
+
+// getCanaryShardIndex gets index of the shard, which has canary host, 0 in case there is no canary.
+// Shards up to the canary one have to be reconciled one by one, so the canary gates the rest of the cluster.
+func getCanaryShardIndex(shards []*api.ChiShard) int {
+	for shardIndex, shard := range shards {
+		for _, host := range shard.Hosts {
+			if isCanaryHost(host) {
+				return shardIndex
+			}
+		}
+	}
+	return 0
+}
+
+// verifyCanaryHost lets canary host run for the soak period and verifies it afterwards.
+// Error means rollout must not proceed to the rest of the hosts.
+func (w *worker) verifyCanaryHost(
+	ctx context.Context,
+	host *api.ChiHost,
+	soakPeriod time.Duration,
+	check func(ctx context.Context, host *api.ChiHost) error,
+) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	w.a.V(1).
+		WithEvent(host.GetCHI(), eventActionReconcile, eventReasonCanaryStarted).
+		M(host).F().
+		Info("Canary host %s is rolled out. Soak for %s before rolling out the rest of the cluster %s",
+			host.GetName(), soakPeriod, host.Runtime.Address.ClusterName)
+
+	// Canary gates rollout of the cluster until it passes verification, whatever happens to the reconcile
+	w.setCanaryPending(ctx, host, "verification is in progress")
+
+	if util.WaitContextDoneOrTimeout(ctx, soakPeriod) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	if err := check(ctx, host); err != nil {
+		w.setCanaryPending(ctx, host, err.Error())
+		w.a.V(1).
+			WithEvent(host.GetCHI(), eventActionReconcile, eventReasonCanaryFailed).
+			M(host).F().
+			Warning("Canary host %s failed verification, rollout of the cluster %s is stopped. err: %v",
+				host.GetName(), host.Runtime.Address.ClusterName, err)
+		return fmt.Errorf("canary host %s failed verification: %w", host.GetName(), err)
+	}
+
+	w.setCanaryPending(ctx, host, "")
+	w.a.V(1).
+		WithEvent(host.GetCHI(), eventActionReconcile, eventReasonCanaryCompleted).
+		M(host).F().
+		Info("Canary host %s passed verification, roll out the rest of the cluster %s",
+			host.GetName(), host.Runtime.Address.ClusterName)
+	return nil
+}
+
+// setCanaryPending records canary host has not passed verification yet and propagates it into CHI status.
+// Empty reason clears the record.
+func (w *worker) setCanaryPending(ctx context.Context, host *api.ChiHost, reason string) {
+	host.GetCHI().EnsureStatus().SetCanaryPending(host.GetName(), reason)
+	_ = w.c.updateCHIObjectStatus(ctx, host.GetCHI(), UpdateCHIStatusOptions{
+		CopyCHIStatusOptions: api.CopyCHIStatusOptions{
+			MainFields: true,
+		},
+	})
+}
+
+// checkCanaryHost verifies canary host is ready, serves as a cluster member and replicates
+func (w *worker) checkCanaryHost(ctx context.Context, host *api.ChiHost) error {
+	pod, err := w.c.getPod(host)
+	if err != nil {
+		return err
+	}
+	if !isPodReady(pod) {
+		return fmt.Errorf("pod %s is not ready", pod.Name)
+	}
+
+	schemer := w.ensureClusterSchemer(host)
+	if !schemer.IsHostInCluster(ctx, host) {
+		return fmt.Errorf("host is not in the cluster")
+	}
+	readonly, err := schemer.HostReadonlyReplicasNum(ctx, host)
+	if err != nil {
+		return err
+	}
+	if readonly > 0 {
+		return fmt.Errorf("%d replicated tables are not able to replicate", readonly)
+	}
+	return nil
+}
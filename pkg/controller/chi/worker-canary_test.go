@@ -0,0 +1,104 @@
+package chi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	chopFake "github.com/altinity/clickhouse-operator/pkg/client/clientset/versioned/fake"
+)
+
+func Test_IsCanaryHost(t *testing.T) {
+	hosts := newTestShard(2)
+	for _, host := range hosts {
+		host.GetReconcileAttributes().SetStatus(api.ObjectStatusModified)
+	}
+
+	// Regular rollout has no canary
+	require.False(t, isCanaryHost(hosts[0]))
+
+	hosts[0].GetCHI().Spec.Reconciling = &api.ChiReconciling{Strategy: api.ReconcilingStrategyCanary}
+	require.True(t, isCanaryHost(hosts[0]))
+	require.False(t, isCanaryHost(hosts[1]))
+
+	// Canary is the first host, which StatefulSet is rolled out
+	hosts[0].GetReconcileAttributes().SetStatus(api.ObjectStatusSame)
+	require.False(t, isCanaryHost(hosts[0]))
+	require.True(t, isCanaryHost(hosts[1]))
+
+	// Newly added host is not a canary
+	hosts[1].GetReconcileAttributes().SetAdd()
+	require.False(t, isCanaryHost(hosts[1]))
+}
+
+func Test_GetCanaryShardIndex(t *testing.T) {
+	// Cluster of 2 shards with 2 replicas each
+	hosts := newTestShard(2)
+	chi := hosts[0].GetCHI()
+	chi.Spec.Reconciling = &api.ChiReconciling{Strategy: api.ReconcilingStrategyCanary}
+	cluster := chi.Spec.Configuration.Clusters[0]
+	shard := api.ChiShard{Name: "1"}
+	for i := 0; i < 2; i++ {
+		host := &api.ChiHost{Name: fmt.Sprintf("1-%d", i)}
+		host.Runtime.CHI = chi
+		host.Runtime.Address.ClusterName = cluster.Name
+		host.Runtime.Address.ShardName = shard.Name
+		shard.Hosts = append(shard.Hosts, host)
+	}
+	cluster.Layout.Shards = append(cluster.Layout.Shards, shard)
+	shards := []*api.ChiShard{&cluster.Layout.Shards[0], &cluster.Layout.Shards[1]}
+	chi.WalkHosts(func(host *api.ChiHost) error {
+		host.GetReconcileAttributes().SetStatus(api.ObjectStatusSame)
+		return nil
+	})
+
+	// No canary
+	require.Equal(t, 0, getCanaryShardIndex(shards))
+
+	// Canary is in the second shard, since nothing is rolled out in the first one
+	shard.Hosts[1].GetReconcileAttributes().SetStatus(api.ObjectStatusModified)
+	require.Equal(t, 1, getCanaryShardIndex(shards))
+	require.True(t, isCanaryHost(shard.Hosts[1]))
+}
+
+func Test_VerifyCanaryHost(t *testing.T) {
+	host := newTestShard(1)[0]
+	chi := host.GetCHI()
+	chi.Namespace = "ns"
+	chi.Name = "chi"
+	chi.Spec.Reconciling = &api.ChiReconciling{Strategy: api.ReconcilingStrategyCanary}
+	host.GetReconcileAttributes().SetStatus(api.ObjectStatusModified)
+	c := &Controller{
+		kubeClient: kubeFake.NewSimpleClientset(),
+		chopClient: chopFake.NewSimpleClientset(&api.ClickHouseInstallation{
+			ObjectMeta: meta.ObjectMeta{Namespace: chi.Namespace, Name: chi.Name},
+		}),
+	}
+	w := &worker{c: c, a: NewAnnouncer()}
+	ctx := context.Background()
+
+	require.Error(t, w.verifyCanaryHost(ctx, host, 0, func(ctx context.Context, host *api.ChiHost) error {
+		return fmt.Errorf("pod is not ready")
+	}))
+
+	// Failed canary stays canary by the next reconcile, even though its StatefulSet is rolled out already
+	host.GetReconcileAttributes().SetStatus(api.ObjectStatusSame)
+	require.True(t, isCanaryHost(host))
+	cur, err := c.chopClient.ClickhouseV1().ClickHouseInstallations("ns").Get(ctx, "chi", meta.GetOptions{})
+	require.NoError(t, err)
+	require.True(t, cur.EnsureStatus().IsCanaryPending(host.GetName()))
+
+	// Canary, which passes verification, releases the gate
+	require.NoError(t, w.verifyCanaryHost(ctx, host, 0, func(ctx context.Context, host *api.ChiHost) error {
+		return nil
+	}))
+	require.False(t, isCanaryHost(host))
+	cur, err = c.chopClient.ClickhouseV1().ClickHouseInstallations("ns").Get(ctx, "chi", meta.GetOptions{})
+	require.NoError(t, err)
+	require.False(t, cur.EnsureStatus().IsCanaryPending(host.GetName()))
+}
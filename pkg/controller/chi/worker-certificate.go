@@ -0,0 +1,183 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	core "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/controller"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi/creator"
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
+// certificateNameAnnotation is set by cert-manager on the Secret the certificate is issued into
+const certificateNameAnnotation = "cert-manager.io/certificate-name"
+
+// getCertificates collects cert-manager Certificates the hosts of the CHI are served with, mapped by name.
+// Certificate issued within CHI scope is shared by all hosts
+func (w *worker) getCertificates(chi *api.ClickHouseInstallation) map[string]*unstructured.Unstructured {
+	certificates := make(map[string]*unstructured.Unstructured)
+	chi.WalkHosts(func(host *api.ChiHost) error {
+		if certificate := w.task.creator.CreateCertificate(host); certificate != nil {
+			certificates[certificate.GetName()] = certificate
+		}
+		return nil
+	})
+	return certificates
+}
+
+// reconcileCertificates creates cert-manager Certificates the hosts are served with.
+// Certificates have to be in place before pods are created, since pods mount Secrets certificates are issued into
+func (w *worker) reconcileCertificates(ctx context.Context, chi *api.ClickHouseInstallation) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	certificates := w.getCertificates(chi)
+	var names []string
+	for name := range certificates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := w.reconcileCertificate(ctx, chi, certificates[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcileCertificate reconciles cert-manager Certificate. Certificate created by someone else is left intact
+func (w *worker) reconcileCertificate(ctx context.Context, chi *api.ClickHouseInstallation, certificate *unstructured.Unstructured) error {
+	client := w.c.dynamicClient.Resource(creator.CertificateGVR).Namespace(certificate.GetNamespace())
+	cur, err := client.Get(ctx, certificate.GetName(), controller.NewGetOptions())
+	switch {
+	case err == nil:
+		if !isCertificateOfCHI(chi, cur) {
+			// Hosts are not to be served with certificate issued by someone else
+			err = fmt.Errorf("certificate %s/%s is not managed by the operator, refuse to use it", cur.GetNamespace(), cur.GetName())
+			break
+		}
+		// Keep status and metadata maintained by cert-manager
+		cur = cur.DeepCopy()
+		cur.Object["spec"] = certificate.Object["spec"]
+		cur.SetLabels(certificate.GetLabels())
+		_, err = client.Update(ctx, cur, controller.NewUpdateOptions())
+	case apiErrors.IsNotFound(err):
+		if err = w.checkCertificateSecret(certificate); err != nil {
+			break
+		}
+		_, err = client.Create(ctx, certificate, controller.NewCreateOptions())
+	}
+
+	if err != nil {
+		w.a.WithEvent(chi, eventActionReconcile, eventReasonReconcileFailed).
+			WithStatusAction(chi).
+			WithStatusError(chi).
+			M(chi).F().
+			Error("FAILED to reconcile Certificate: %s/%s CHI: %s err: %v", certificate.GetNamespace(), certificate.GetName(), chi.Name, err)
+		return err
+	}
+
+	w.a.V(1).M(chi).F().Info("Certificate reconcile successful: %s/%s", certificate.GetNamespace(), certificate.GetName())
+	return nil
+}
+
+// checkCertificateSecret checks whether cert-manager is free to issue the certificate into the Secret.
+// Secret, which exists already and is not issued for the Certificate, belongs to someone else and is not to be overwritten
+func (w *worker) checkCertificateSecret(certificate *unstructured.Unstructured) error {
+	secret, err := w.c.getSecret(&core.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: certificate.GetNamespace(),
+			Name:      certificate.GetName(),
+		},
+	})
+	switch {
+	case apiErrors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return err
+	case secret.Annotations[certificateNameAnnotation] == certificate.GetName():
+		// Secret is issued for the Certificate already
+		return nil
+	}
+	return fmt.Errorf("secret %s/%s exists and is not issued for the Certificate, refuse to overwrite it", secret.Namespace, secret.Name)
+}
+
+// finalizeCertificates requeues the CHI in case hosts are served with certificates for the first time,
+// so replicas are switched to fetch parts over https, as soon as all hosts are rolled with certificates
+func (w *worker) finalizeCertificates(chi *api.ClickHouseInstallation) {
+	certManager := chi.GetCertManager()
+	if !certManager.IsEnabled() || certManager.IsInterserverSecure() {
+		return
+	}
+	if chi.GetAncestor().GetCertManager().IsEnabled() {
+		// Hosts are served with certificates already, thus replicas fetch parts over http as explicitly specified
+		return
+	}
+	w.a.V(1).M(chi).F().Info("Hosts of CHI %s/%s are served with certificates, switch replicas to https", chi.Namespace, chi.Name)
+	w.c.requeueCHI(chi, 0)
+}
+
+// cleanupCertificates deletes cert-manager Certificates of the CHI, which are not used by the hosts anymore.
+// Secrets certificates are issued into are left to cert-manager
+func (w *worker) cleanupCertificates(ctx context.Context, chi *api.ClickHouseInstallation) {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return
+	}
+
+	certificates := w.getCertificates(chi)
+	client := w.c.dynamicClient.Resource(creator.CertificateGVR).Namespace(chi.Namespace)
+	selector := model.NewLabeler(chi).GetSelectorCHIScope()
+	list, err := client.List(ctx, controller.NewListOptions(selector))
+	if err != nil {
+		if len(certificates) > 0 {
+			w.a.V(1).M(chi).F().Info("unable to list Certificates of CHI %s/%s err: %v", chi.Namespace, chi.Name, err)
+		}
+		return
+	}
+
+	for i := range list.Items {
+		certificate := &list.Items[i]
+		if _, ok := certificates[certificate.GetName()]; ok {
+			continue
+		}
+		err := client.Delete(ctx, certificate.GetName(), controller.NewDeleteOptions())
+		if (err != nil) && !apiErrors.IsNotFound(err) {
+			w.a.V(1).M(chi).F().Warning("unable to delete Certificate %s/%s err: %v", certificate.GetNamespace(), certificate.GetName(), err)
+			continue
+		}
+		w.a.V(1).M(chi).F().Info("Certificate %s/%s is not used anymore and deleted", certificate.GetNamespace(), certificate.GetName())
+	}
+}
+
+// isCertificateOfCHI checks whether Certificate is managed by the operator on behalf of the CHI
+func isCertificateOfCHI(chi *api.ClickHouseInstallation, certificate *unstructured.Unstructured) bool {
+	selector := labels.SelectorFromSet(model.NewLabeler(chi).GetSelectorCHIScope())
+	return selector.Matches(labels.Set(certificate.GetLabels()))
+}
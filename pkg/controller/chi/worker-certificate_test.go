@@ -0,0 +1,37 @@
+package chi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_CheckCertificateSecret(t *testing.T) {
+	certificate := &unstructured.Unstructured{}
+	certificate.SetNamespace("ns")
+	certificate.SetName("chi-chi-tls")
+
+	newWorker := func(objects ...runtime.Object) *worker {
+		return &worker{c: &Controller{kubeClient: kubeFake.NewSimpleClientset(objects...)}}
+	}
+
+	// cert-manager is free to issue certificate into absent Secret
+	require.NoError(t, newWorker().checkCertificateSecret(certificate))
+
+	// Secret issued for the Certificate already is reused
+	issued := &core.Secret{ObjectMeta: meta.ObjectMeta{
+		Namespace:   "ns",
+		Name:        "chi-chi-tls",
+		Annotations: map[string]string{certificateNameAnnotation: "chi-chi-tls"},
+	}}
+	require.NoError(t, newWorker(issued).checkCertificateSecret(certificate))
+
+	// Secret of the user is never overwritten
+	foreign := &core.Secret{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "chi-chi-tls"}}
+	require.Error(t, newWorker(foreign).checkCertificateSecret(certificate))
+}
@@ -19,6 +19,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"strings"
 	"sync"
 	"time"
 
@@ -36,6 +37,8 @@ import (
 	"github.com/altinity/clickhouse-operator/pkg/controller"
 	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
 	"github.com/altinity/clickhouse-operator/pkg/model/chi/creator"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi/schemer"
+	"github.com/altinity/clickhouse-operator/pkg/model/k8s"
 	"github.com/altinity/clickhouse-operator/pkg/util"
 )
 
@@ -48,9 +51,22 @@ func (w *worker) reconcileCHI(ctx context.Context, old, new *api.ClickHouseInsta
 
 	w.logOldAndNew("non-normalized yet (native)", old, new)
 
+	if isSchemaCheckRequested(old, new) {
+		w.a.M(new).F().Info("schema consistency check requested")
+		w.checkSchemaConsistency(ctx, w.normalize(new))
+	}
+	if departed, ok := isDropDepartedReplicasRequested(old, new); ok {
+		w.a.M(new).F().Info("ZooKeeper cleanup of departed CHI %s requested", departed)
+		w.dropDepartedReplicas(ctx, w.normalize(new), departed)
+	}
+
 	switch {
 	case w.isAfterFinalizerInstalled(old, new):
 		w.a.M(new).F().Info("isAfterFinalizerInstalled - continue reconcile-1")
+	case isPlanApprovalUpdated(old, new):
+		w.a.M(new).F().Info("isPlanApprovalUpdated - continue reconcile-1")
+	case isReconcileResumed(old, new):
+		w.a.M(new).F().Info("isReconcileResumed - continue reconcile-1")
 	case w.isGenerationTheSame(old, new):
 		w.a.M(new).F().Info("isGenerationTheSame() - nothing to do here, exit")
 		return nil
@@ -77,6 +93,10 @@ func (w *worker) reconcileCHI(ctx context.Context, old, new *api.ClickHouseInsta
 
 	w.a.M(new).F().Info("Normalized NEW CHI: %s/%s", new.Namespace, new.Name)
 	new = w.normalize(new)
+	// Secrets are watched for rotation as long as the CHI refers to them
+	w.c.secretIndex.Update(new)
+	// Access TLS may be changed along with the CHI
+	w.c.accessTLS.Invalidate(new.Namespace, new.Name)
 
 	new.SetAncestor(old)
 	w.logOldAndNew("normalized", old, new)
@@ -91,6 +111,20 @@ func (w *worker) reconcileCHI(ctx context.Context, old, new *api.ClickHouseInsta
 		w.a.M(new).F().Info("isAfterFinalizerInstalled - continue reconcile-2")
 	default:
 		w.a.M(new).F().Info("ActionPlan has no actions and not finalizer - nothing to do")
+		// Generation is observed, even though there is nothing to reconcile, so its watchers are not left waiting
+		w.publishObservedGeneration(ctx, new)
+		return nil
+	}
+
+	if new.GetReconciling().IsDryRun() {
+		w.a.M(new).F().Info("Dry run - publish ActionPlan without applying it")
+		w.dryRunCHI(ctx, new, actionPlan)
+		return nil
+	}
+	new.EnsureStatus().ClearDryRunPlan()
+
+	if !w.isPlanApproved(ctx, new, actionPlan) {
+		w.a.M(new).F().Info("ActionPlan is not approved - wait for approval")
 		return nil
 	}
 
@@ -99,7 +133,26 @@ func (w *worker) reconcileCHI(ctx context.Context, old, new *api.ClickHouseInsta
 		return nil
 	}
 
+	if err := w.checkMixedVersions(ctx, new); err != nil {
+		return err
+	}
+	if err := w.checkConfigFiles(ctx, new); err != nil {
+		return err
+	}
+	w.checkHostNetworkPorts(ctx, new)
+	if err := w.checkRolloutBreakpoint(ctx, new); err != nil {
+		return err
+	}
+	w.checkPriorityClasses(ctx, new)
+	w.checkImagePullSecrets(ctx, new)
+	w.checkEnvSources(ctx, new)
+
 	w.newTask(new)
+	w.task.zookeeperOnlyChange = actionPlan.IsZookeeperOnlyChange()
+	if err := w.reconcileInterserverCredentials(ctx, new); err != nil {
+		// Hosts are not to be rolled with no interserver credentials
+		return err
+	}
 	w.markReconcileStart(ctx, new, actionPlan)
 	w.excludeStoppedCHIFromMonitoring(new)
 	w.walkHosts(ctx, new, actionPlan)
@@ -114,6 +167,10 @@ func (w *worker) reconcileCHI(ctx context.Context, old, new *api.ClickHouseInsta
 		if errors.Is(err, errCRUDAbort) {
 			metricsCHIReconcilesAborted(ctx, new)
 		}
+		if errors.Is(err, errClusterDegraded) {
+			// Cluster may recover on its own, so try again later
+			w.c.requeueCHI(new, clusterDegradedRequeueDelay)
+		}
 	} else {
 		// Reconcile successful
 		// Post-process added items
@@ -121,8 +178,17 @@ func (w *worker) reconcileCHI(ctx context.Context, old, new *api.ClickHouseInsta
 			log.V(2).Info("task is done")
 			return nil
 		}
+		if model.IsRolloutPausedAtBreakpoint(new) {
+			// Hosts beyond the breakpoint are not reconciled yet, so post-processing waits for the rollout to resume
+			w.markReconcilePaused(ctx, new)
+			return nil
+		}
 		w.clean(ctx, new)
+		w.finalizeInterserverCredentials(ctx, new)
+		w.finalizeCertificates(new)
+		w.checkSchemaConsistency(ctx, new)
 		w.dropReplicas(ctx, new, actionPlan)
+		w.createDistributedTables(ctx, new, actionPlan)
 		w.addCHIToMonitoring(new)
 		w.waitForIPAddresses(ctx, new)
 		w.finalizeReconcileAndMarkCompleted(ctx, new)
@@ -131,16 +197,550 @@ func (w *worker) reconcileCHI(ctx context.Context, old, new *api.ClickHouseInsta
 		metricsCHIReconcilesTimings(ctx, new, time.Now().Sub(startTime).Seconds())
 	}
 
-	return nil
-}
-
-// ReconcileShardsAndHostsOptionsCtxKeyType specifies type for ReconcileShardsAndHostsOptionsCtxKey
-// More details here on why do we need special type
-// https://stackoverflow.com/questions/40891345/fix-should-not-use-basic-type-string-as-key-in-context-withvalue-golint
-type ReconcileShardsAndHostsOptionsCtxKeyType string
+	return nil
+}
+
+// ReconcileShardsAndHostsOptionsCtxKeyType specifies type for ReconcileShardsAndHostsOptionsCtxKey
+// More details here on why do we need special type
+// https://stackoverflow.com/questions/40891345/fix-should-not-use-basic-type-string-as-key-in-context-withvalue-golint
+type ReconcileShardsAndHostsOptionsCtxKeyType string
+
+// ReconcileShardsAndHostsOptionsCtxKey specifies name of the key to be used for ReconcileShardsAndHostsOptions
+const ReconcileShardsAndHostsOptionsCtxKey ReconcileShardsAndHostsOptionsCtxKeyType = "ReconcileShardsAndHostsOptions"
+
+// checkMixedVersions checks whether hosts of each cluster are configured to run the same ClickHouse image.
+// Returns error in case mixed versions are found and operator is configured to reject them.
+func (w *worker) checkMixedVersions(ctx context.Context, chi *api.ClickHouseInstallation) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	reject := chop.Config().Reconcile.Cluster.OnMixedVersions == api.OnMixedVersionsActionReject
+
+	var mixed error
+	chi.WalkClusters(func(cluster *api.Cluster) error {
+		err := model.ClusterCheckClickHouseImages(cluster)
+		if err == nil {
+			return nil
+		}
+		mixed = err
+		if reject {
+			w.a.V(1).
+				WithEvent(chi, eventActionReconcile, eventReasonMixedVersions).
+				WithStatusError(chi).
+				M(chi).F().
+				Error("Reconcile rejected: %v", err)
+		} else {
+			w.a.V(1).
+				WithEvent(chi, eventActionReconcile, eventReasonMixedVersions).
+				M(chi).F().
+				Warning("%v", err)
+		}
+		return nil
+	})
+
+	if (mixed == nil) || !reject {
+		return nil
+	}
+
+	_ = w.c.updateCHIObjectStatus(ctx, chi, UpdateCHIStatusOptions{
+		CopyCHIStatusOptions: api.CopyCHIStatusOptions{
+			Errors: true,
+		},
+	})
+	return mixed
+}
+
+// checkConfigFiles checks whether config files specified in the CHI can be parsed.
+// Broken file would prevent ClickHouse from loading config, so reconcile is rejected.
+func (w *worker) checkConfigFiles(ctx context.Context, chi *api.ClickHouseInstallation) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	err := model.CHICheckConfigFiles(chi)
+	if err == nil {
+		return nil
+	}
+
+	w.a.V(1).
+		WithEvent(chi, eventActionReconcile, eventReasonInvalidConfigFile).
+		WithStatusError(chi).
+		M(chi).F().
+		Error("Reconcile rejected: %v", err)
+	_ = w.c.updateCHIObjectStatus(ctx, chi, UpdateCHIStatusOptions{
+		CopyCHIStatusOptions: api.CopyCHIStatusOptions{
+			Errors: true,
+		},
+	})
+	return err
+}
+
+// checkHostNetworkPorts reports clusters with hostNetwork hosts, which may share a node and conflict on ports.
+// Hosts conflicting on ports fail to start once scheduled onto the same node, so the conflict is reported as status error.
+func (w *worker) checkHostNetworkPorts(ctx context.Context, chi *api.ClickHouseInstallation) {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return
+	}
+
+	chi.WalkClusters(func(cluster *api.Cluster) error {
+		if err := model.ClusterCheckHostNetworkPorts(cluster); err != nil {
+			w.a.V(1).
+				WithEvent(chi, eventActionReconcile, eventReasonHostNetworkPortConflict).
+				WithStatusError(chi).
+				M(chi).F().
+				Warning("%v", err)
+		}
+		return nil
+	})
+}
+
+// checkRolloutBreakpoint checks whether rollout breakpoint, in case specified, matches a host.
+// Rollout is not started in case it can not be paused as requested.
+func (w *worker) checkRolloutBreakpoint(ctx context.Context, chi *api.ClickHouseInstallation) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	err := model.CHICheckRolloutBreakpoint(chi)
+	if err == nil {
+		return nil
+	}
+
+	w.a.V(1).
+		WithEvent(chi, eventActionReconcile, eventReasonReconcileFailed).
+		WithStatusError(chi).
+		M(chi).F().
+		Error("Reconcile rejected: %v", err)
+	_ = w.c.updateCHIObjectStatus(ctx, chi, UpdateCHIStatusOptions{
+		CopyCHIStatusOptions: api.CopyCHIStatusOptions{
+			Errors: true,
+		},
+	})
+	return err
+}
+
+// checkPriorityClasses warns about PriorityClasses referenced by hosts, but not available in k8s.
+// Pods referencing unknown PriorityClass are rejected by k8s, so such a misconfiguration is worth to be noticed early.
+func (w *worker) checkPriorityClasses(ctx context.Context, chi *api.ClickHouseInstallation) {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return
+	}
+
+	checked := make(map[string]bool)
+	chi.WalkHosts(func(host *api.ChiHost) error {
+		name := host.GetPriorityClassName()
+		if (name == "") || checked[name] {
+			return nil
+		}
+		checked[name] = true
+
+		_, err := w.c.kubeClient.SchedulingV1().PriorityClasses().Get(ctx, name, controller.NewGetOptions())
+		switch {
+		case err == nil:
+			// PriorityClass is in place
+		case apiErrors.IsNotFound(err):
+			w.a.V(1).
+				WithEvent(chi, eventActionReconcile, eventReasonPriorityClassNotFound).
+				M(chi).F().
+				Warning("PriorityClass %s referenced by host %s not found. Pods would not be created", name, host.GetName())
+		default:
+			w.a.V(1).M(chi).F().Info("unable to check PriorityClass %s err: %v", name, err)
+		}
+		return nil
+	})
+}
+
+// checkImagePullSecrets warns about image pull secrets referenced by pod templates of hosts, but not available in k8s.
+// Pods are created anyway, but images from private registries would fail to be pulled.
+func (w *worker) checkImagePullSecrets(ctx context.Context, chi *api.ClickHouseInstallation) {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return
+	}
+
+	checked := make(map[string]bool)
+	chi.WalkHosts(func(host *api.ChiHost) error {
+		podTemplate, ok := host.GetPodTemplate()
+		if !ok {
+			return nil
+		}
+		for _, secret := range podTemplate.Spec.ImagePullSecrets {
+			name := secret.Name
+			if (name == "") || checked[name] {
+				continue
+			}
+			checked[name] = true
+
+			_, err := w.c.kubeClient.CoreV1().Secrets(chi.Namespace).Get(ctx, name, controller.NewGetOptions())
+			switch {
+			case err == nil:
+				// Secret is in place
+			case apiErrors.IsNotFound(err):
+				w.a.V(1).
+					WithEvent(chi, eventActionReconcile, eventReasonImagePullSecretNotFound).
+					M(chi).F().
+					Warning("Image pull secret %s/%s referenced by pod template %s not found", chi.Namespace, name, podTemplate.Name)
+			default:
+				w.a.V(1).M(chi).F().Info("unable to check image pull secret %s/%s err: %v", chi.Namespace, name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// envSource describes Secret or ConfigMap referenced by env of a pod template
+type envSource struct {
+	kind string
+	name string
+}
+
+// getPodTemplateEnvSources gets non-optional Secrets and ConfigMaps referenced by env and envFrom of the pod template
+func getPodTemplateEnvSources(template *api.PodTemplate) (sources []envSource) {
+	isRequired := func(optional *bool) bool {
+		return (optional == nil) || !*optional
+	}
+	for _, envVar := range template.Env {
+		switch from := envVar.ValueFrom; {
+		case from == nil:
+		case (from.SecretKeyRef != nil) && isRequired(from.SecretKeyRef.Optional):
+			sources = append(sources, envSource{kind: "Secret", name: from.SecretKeyRef.Name})
+		case (from.ConfigMapKeyRef != nil) && isRequired(from.ConfigMapKeyRef.Optional):
+			sources = append(sources, envSource{kind: "ConfigMap", name: from.ConfigMapKeyRef.Name})
+		}
+	}
+	for _, envFrom := range template.EnvFrom {
+		switch {
+		case (envFrom.SecretRef != nil) && isRequired(envFrom.SecretRef.Optional):
+			sources = append(sources, envSource{kind: "Secret", name: envFrom.SecretRef.Name})
+		case (envFrom.ConfigMapRef != nil) && isRequired(envFrom.ConfigMapRef.Optional):
+			sources = append(sources, envSource{kind: "ConfigMap", name: envFrom.ConfigMapRef.Name})
+		}
+	}
+	return sources
+}
+
+// checkEnvSources warns about Secrets and ConfigMaps referenced by env of pod templates of hosts, but not available in k8s.
+// StatefulSets are created anyway, but ClickHouse containers would fail to start.
+func (w *worker) checkEnvSources(ctx context.Context, chi *api.ClickHouseInstallation) {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return
+	}
+
+	checked := make(map[envSource]bool)
+	chi.WalkHosts(func(host *api.ChiHost) error {
+		podTemplate, ok := host.GetPodTemplate()
+		if !ok {
+			return nil
+		}
+		for _, source := range getPodTemplateEnvSources(podTemplate) {
+			if (source.name == "") || checked[source] {
+				continue
+			}
+			checked[source] = true
+
+			var err error
+			switch source.kind {
+			case "Secret":
+				_, err = w.c.kubeClient.CoreV1().Secrets(chi.Namespace).Get(ctx, source.name, controller.NewGetOptions())
+			case "ConfigMap":
+				_, err = w.c.kubeClient.CoreV1().ConfigMaps(chi.Namespace).Get(ctx, source.name, controller.NewGetOptions())
+			}
+			switch {
+			case err == nil:
+				// Env source is in place
+			case apiErrors.IsNotFound(err):
+				w.a.V(1).
+					WithEvent(chi, eventActionReconcile, eventReasonEnvSourceNotFound).
+					M(chi).F().
+					Warning("%s %s/%s referenced by env of pod template %s not found", source.kind, chi.Namespace, source.name, podTemplate.Name)
+			default:
+				w.a.V(1).M(chi).F().Info("unable to check %s %s/%s err: %v", source.kind, chi.Namespace, source.name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// isSchemaCheckRequested checks whether schema consistency check is requested via annotation
+func isSchemaCheckRequested(old, new *api.ClickHouseInstallation) bool {
+	if new == nil {
+		return false
+	}
+	requested, ok := new.GetAnnotations()[model.AnnotationCheckSchema]
+	if !ok || (requested == "") {
+		return false
+	}
+	if old == nil {
+		return true
+	}
+	return old.GetAnnotations()[model.AnnotationCheckSchema] != requested
+}
+
+// isReconcileResumed checks whether reconcile of the CHI is resumed after pause.
+// Changes made to the CHI during the pause have to be reconciled, even though generation is not bumped by resume.
+func isReconcileResumed(old, new *api.ClickHouseInstallation) bool {
+	return model.IsReconcilePaused(old) && !model.IsReconcilePaused(new)
+}
+
+// isPlanApprovalUpdated checks whether ActionPlan approval annotation is changed
+func isPlanApprovalUpdated(old, new *api.ClickHouseInstallation) bool {
+	if new == nil {
+		return false
+	}
+	approved, ok := new.GetAnnotations()[model.AnnotationApprovePlan]
+	if !ok || (approved == "") {
+		return false
+	}
+	if old == nil {
+		return true
+	}
+	return old.GetAnnotations()[model.AnnotationApprovePlan] != approved
+}
+
+// isPlanApproved checks whether ActionPlan is approved to be executed, in case CHI requires plan approval.
+// Not approved plan is published in status along with its hash, which has to be specified in approval annotation.
+// Any change of the spec changes the hash, so approval has to be granted anew.
+func (w *worker) isPlanApproved(ctx context.Context, chi *api.ClickHouseInstallation, ap *model.ActionPlan) bool {
+	if !chi.GetReconciling().IsPlanApprovalRequired() {
+		return true
+	}
+
+	hash := ap.Hash()
+	approved := chi.GetAnnotations()[model.AnnotationApprovePlan]
+	if approved == hash {
+		w.a.V(1).M(chi).F().Info("ActionPlan %s is approved", hash)
+		chi.EnsureStatus().ClearPendingPlan()
+		return true
+	}
+
+	chi.EnsureStatus().AwaitPlanApproval(hash, ap.String())
+	_ = w.c.updateCHIObjectStatus(ctx, chi, UpdateCHIStatusOptions{
+		CopyCHIStatusOptions: api.CopyCHIStatusOptions{
+			MainFields: true,
+		},
+	})
+
+	if approved == "" {
+		w.a.V(1).
+			WithEvent(chi, eventActionReconcile, eventReasonPlanApprovalRequired).
+			M(chi).F().
+			Info("ActionPlan %s waits for approval. Annotate CHI with %s: %s to approve", hash, model.AnnotationApprovePlan, hash)
+	} else {
+		w.a.V(1).
+			WithEvent(chi, eventActionReconcile, eventReasonPlanApprovalRequired).
+			M(chi).F().
+			Warning("Approved plan %s does not match ActionPlan %s, approval has to be granted anew", approved, hash)
+	}
+	return false
+}
+
+// checkSchemaConsistency checks whether table definitions are the same on all hosts of each cluster.
+// Divergence is reported as a warning, since it may be caused by a partially failed DDL and requires attention.
+func (w *worker) checkSchemaConsistency(ctx context.Context, chi *api.ClickHouseInstallation) {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return
+	}
+
+	chi.WalkClusters(func(cluster *api.Cluster) error {
+		host := cluster.FirstHost()
+		if host == nil {
+			return nil
+		}
+		drift, err := w.ensureClusterSchemer(host).ClusterCheckSchemaConsistency(ctx, cluster)
+		switch {
+		case err != nil:
+			w.a.V(1).M(chi).F().Warning("unable to check schema consistency of cluster %s err: %v", cluster.Name, err)
+		case drift.HasDrift():
+			w.a.V(1).
+				WithEvent(chi, eventActionReconcile, eventReasonSchemaDrift).
+				M(chi).F().
+				Warning("Schema drift in cluster %s: %s", cluster.Name, drift)
+		default:
+			w.a.V(1).M(chi).F().Info("Schema is consistent in cluster %s", cluster.Name)
+		}
+		return nil
+	})
+}
+
+// clusterTopologyChecker checks topology of a cluster as it is seen by a host
+type clusterTopologyChecker interface {
+	ClusterCheckTopology(ctx context.Context, host *api.ChiHost, cluster *api.Cluster) (schemer.TopologyMismatch, error)
+}
+
+// checkTopology checks whether clusters topology reported by ClickHouse in system.clusters matches the desired one.
+// Mismatch means ClickHouse has not picked up remote_servers config, which is reported as a warning.
+func (w *worker) checkTopology(ctx context.Context, chi *api.ClickHouseInstallation) {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return
+	}
+
+	if chi.IsStopped() {
+		// Stopped CHI has nobody to ask
+		return
+	}
+
+	chi.WalkClusters(func(cluster *api.Cluster) error {
+		host := getTopologyCheckHost(cluster)
+		if host == nil {
+			return nil
+		}
+		w.doCheckTopology(ctx, w.ensureClusterSchemer(host), host, cluster)
+		return nil
+	})
+}
+
+// getTopologyCheckHost gets running host of the cluster to be asked about the topology of the cluster
+func getTopologyCheckHost(cluster *api.Cluster) (res *api.ChiHost) {
+	cluster.WalkHosts(func(host *api.ChiHost) error {
+		if (res == nil) && !host.IsStopped() {
+			res = host
+		}
+		return nil
+	})
+	return res
+}
+
+// doCheckTopology checks topology of the cluster as it is seen by the host
+func (w *worker) doCheckTopology(ctx context.Context, checker clusterTopologyChecker, host *api.ChiHost, cluster *api.Cluster) {
+	chi := host.GetCHI()
+	mismatch, err := checker.ClusterCheckTopology(ctx, host, cluster)
+	switch {
+	case err != nil:
+		w.a.V(1).M(chi).F().Warning("unable to check topology of cluster %s on host %s err: %v", cluster.Name, host.GetName(), err)
+	case mismatch.HasMismatch():
+		w.a.V(1).
+			WithEvent(chi, eventActionReconcile, eventReasonTopologyMismatch).
+			M(chi).F().
+			Warning("Topology of cluster %s reported by host %s does not match the desired one: %s", cluster.Name, host.GetName(), mismatch)
+	default:
+		w.a.V(1).M(chi).F().Info("Topology of cluster %s matches the desired one", cluster.Name)
+	}
+}
+
+// createDistributedTables creates Distributed tables over replicated tables of clusters, which got new shards
+func (w *worker) createDistributedTables(ctx context.Context, chi *api.ClickHouseInstallation, ap *model.ActionPlan) {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return
+	}
+
+	if !chi.Spec.Defaults.CreateDistributedTables.IsTrue() {
+		return
+	}
+
+	// Clusters with added shards
+	var clusters []*api.Cluster
+	ap.WalkAdded(
+		func(cluster *api.Cluster) {
+		},
+		func(shard *api.ChiShard) {
+			cluster := shard.GetCluster()
+			for _, c := range clusters {
+				if c == cluster {
+					return
+				}
+			}
+			clusters = append(clusters, cluster)
+		},
+		func(host *api.ChiHost) {
+		},
+	)
+
+	for _, cluster := range clusters {
+		if !model.ClusterIsSchemaManaged(cluster) {
+			// Schema of the cluster is maintained outside of the operator
+			continue
+		}
+		host := cluster.FirstHost()
+		if host == nil {
+			continue
+		}
+		tables, err := w.ensureClusterSchemer(host).ClusterCreateDistributedTables(ctx, cluster)
+		if err != nil {
+			w.a.V(1).M(chi).F().Warning("unable to create distributed tables in cluster %s err: %v", cluster.Name, err)
+		}
+		if len(tables) > 0 {
+			w.a.V(1).M(chi).F().Info("Distributed tables in cluster %s: %v", cluster.Name, tables)
+		}
+	}
+}
+
+// isDropDepartedReplicasRequested checks whether ZooKeeper cleanup of a departed CHI is requested via annotation.
+// Returns name of the departed CHI
+func isDropDepartedReplicasRequested(old, new *api.ClickHouseInstallation) (string, bool) {
+	if new == nil {
+		return "", false
+	}
+	departed := new.GetAnnotations()[model.AnnotationDropDepartedReplicas]
+	if (departed == "") || (departed == new.Name) {
+		return "", false
+	}
+	if (old != nil) && (old.GetAnnotations()[model.AnnotationDropDepartedReplicas] == departed) {
+		return "", false
+	}
+	return departed, true
+}
+
+// dropDepartedReplicas drops from ZooKeeper replicas of the departed CHI, which are left registered
+// under paths of replicated tables of the chi. It is the case when departed CHI was deleted without
+// its tables being dropped, ex.: finalizer was removed manually.
+// Only replicas named after the departed CHI are dropped.
+func (w *worker) dropDepartedReplicas(ctx context.Context, chi *api.ClickHouseInstallation, departed string) {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return
+	}
+
+	// Departed CHI has to be really gone
+	_, err := w.c.chopClient.ClickhouseV1().ClickHouseInstallations(chi.Namespace).Get(ctx, departed, controller.NewGetOptions())
+	switch {
+	case err == nil:
+		w.a.V(1).M(chi).F().Warning("CHI %s/%s still exists. Skip ZooKeeper cleanup", chi.Namespace, departed)
+		return
+	case !apiErrors.IsNotFound(err):
+		w.a.V(1).M(chi).F().Warning("unable to check CHI %s/%s err: %v. Skip ZooKeeper cleanup", chi.Namespace, departed, err)
+		return
+	}
+
+	// Replicas of alive CHIs, named similar to the departed one, are not touched
+	list, err := w.c.chopClient.ClickhouseV1().ClickHouseInstallations("").List(ctx, controller.NewListOptions())
+	if err != nil {
+		w.a.V(1).M(chi).F().Warning("unable to list CHIs err: %v. Skip ZooKeeper cleanup", err)
+		return
+	}
+	var alive []string
+	for i := range list.Items {
+		alive = append(alive, model.CreateInstanceHostnamePrefix(list.Items[i].Name))
+	}
+	matcher := schemer.NewDepartedReplicaMatcher(model.CreateInstanceHostnamePrefix(departed), alive...)
+
+	var dropped []schemer.ReplicaPath
+	chi.WalkHosts(func(host *api.ChiHost) error {
+		if util.IsContextDone(ctx) {
+			return nil
+		}
+		replicaPaths, err := w.ensureClusterSchemer(host).HostDropDepartedReplicas(ctx, host, matcher)
+		if err != nil {
+			w.a.V(1).M(host).F().Warning("unable to drop replicas of departed CHI %s on host %s err: %v", departed, host.GetName(), err)
+		}
+		dropped = append(dropped, replicaPaths...)
+		return nil
+	})
 
-// ReconcileShardsAndHostsOptionsCtxKey specifies name of the key to be used for ReconcileShardsAndHostsOptions
-const ReconcileShardsAndHostsOptionsCtxKey ReconcileShardsAndHostsOptionsCtxKeyType = "ReconcileShardsAndHostsOptions"
+	w.a.V(1).
+		WithEvent(chi, eventActionReconcile, eventReasonDepartedReplicasDropped).
+		M(chi).F().
+		Info("Dropped %d replicas of departed CHI %s from ZooKeeper: %v", len(dropped), departed, dropped)
+}
 
 // reconcile reconciles ClickHouseInstallation
 func (w *worker) reconcile(ctx context.Context, chi *api.ClickHouseInstallation) error {
@@ -153,10 +753,13 @@ func (w *worker) reconcile(ctx context.Context, chi *api.ClickHouseInstallation)
 	defer w.a.V(2).M(chi).E().P()
 
 	counters := api.NewChiHostReconcileAttributesCounters()
+	var hosts []string
 	chi.WalkHosts(func(host *api.ChiHost) error {
 		counters.Add(host.GetReconcileAttributes())
+		hosts = append(hosts, host.GetName())
 		return nil
 	})
+	chi.EnsureStatus().ResetHostsProgress(hosts)
 
 	if counters.GetAdd() > 0 && counters.GetFound() == 0 && counters.GetModify() == 0 && counters.GetRemove() == 0 {
 		w.a.V(1).M(chi).Info(
@@ -167,13 +770,28 @@ func (w *worker) reconcile(ctx context.Context, chi *api.ClickHouseInstallation)
 		})
 	}
 
-	return chi.WalkTillError(
+	// Keeper goes first, since clusters may reference it
+	if err := w.reconcileKeeper(ctx, chi); err != nil {
+		return err
+	}
+
+	if err := chi.WalkTillError(
 		ctx,
 		w.reconcileCHIAuxObjectsPreliminary,
 		w.reconcileCluster,
 		w.reconcileShardsAndHosts,
 		w.reconcileCHIAuxObjectsFinal,
-	)
+	); err != nil {
+		return err
+	}
+
+	if model.IsRolloutPausedAtBreakpoint(chi) {
+		// Topology is incomplete until the rollout is resumed
+		return nil
+	}
+
+	w.checkTopology(ctx, chi)
+	return nil
 }
 
 // reconcileCHIAuxObjectsPreliminary reconciles CHI preliminary in order to ensure that ConfigMaps are in place
@@ -198,6 +816,46 @@ func (w *worker) reconcileCHIAuxObjectsPreliminary(ctx context.Context, chi *api
 		w.a.F().Error("failed to reconcile config map users. err: %v", err)
 	}
 
+	// ZooKeeper config is rendered, ensure ZooKeeper is reachable before hosts are reconciled
+	if err := w.checkZookeeper(ctx, chi); err != nil {
+		return err
+	}
+
+	// Secret of the managed user has to be in place before pods are created
+	if chop.Config().ClickHouse.Access.ManagedUser.IsEnabled() {
+		secret := w.task.creator.CreateManagedUserSecret()
+		if err := w.reconcileSecret(ctx, chi, secret); err == nil {
+			w.task.registryReconciled.RegisterSecret(secret.ObjectMeta)
+		} else {
+			w.task.registryFailed.RegisterSecret(secret.ObjectMeta)
+		}
+	}
+
+	// Certificates have to be in place before pods are created, hosts are not rolled with certificates of someone else
+	if err := w.reconcileCertificates(ctx, chi); err != nil {
+		w.a.F().Error("failed to reconcile certificates. err: %v", err)
+		return err
+	}
+
+	// ServiceAccounts have to be in place before pods are created
+	if err := w.reconcileServiceAccounts(ctx, chi); err != nil {
+		w.a.F().Error("failed to reconcile service accounts. err: %v", err)
+	}
+
+	// Ingress is restricted before pods are created, so new hosts are never exposed
+	if err := w.reconcileNetworkPolicy(ctx, chi); err != nil {
+		w.a.F().Error("failed to reconcile network policy. err: %v", err)
+	}
+
+	// Subdomain Service governs StatefulSets, so it has to be in place before pods are created
+	if service := w.task.creator.CreateServiceSubdomain(); service != nil {
+		if err := w.reconcileService(ctx, chi, service); err == nil {
+			w.task.registryReconciled.RegisterService(service.ObjectMeta)
+		} else {
+			w.task.registryFailed.RegisterService(service.ObjectMeta)
+		}
+	}
+
 	return nil
 }
 
@@ -240,11 +898,28 @@ func (w *worker) reconcileCHIAuxObjectsFinal(ctx context.Context, chi *api.Click
 	w.a.V(2).M(chi).S().P()
 	defer w.a.V(2).M(chi).E().P()
 
+	if model.IsRolloutPausedAtBreakpoint(chi) {
+		// Common ConfigMap would advertise hosts, which are not reconciled yet
+		w.a.V(1).M(chi).F().Info("Rollout is paused at the breakpoint %s, final objects wait for the rollout to resume", chi.GetRolloutBreakpoint())
+		return nil
+	}
+
+	// Pods are rolled over already, so unused ServiceAccounts and Certificates can be deleted
+	w.cleanupServiceAccounts(ctx, chi)
+	w.cleanupCertificates(ctx, chi)
+
 	// CHI ConfigMaps with update
 	chi.EnsureRuntime().LockCommonConfig()
 	err = w.reconcileCHIConfigMapCommon(ctx, chi, nil)
 	chi.EnsureRuntime().UnlockCommonConfig()
-	return err
+	if err != nil {
+		return err
+	}
+
+	if w.task.dictionariesChange {
+		w.reloadCHIDictionaries(ctx, chi)
+	}
+	return nil
 }
 
 // reconcileCHIConfigMapCommon reconciles all CHI's common ConfigMap
@@ -258,12 +933,20 @@ func (w *worker) reconcileCHIConfigMapCommon(
 		return nil
 	}
 
+	// Interserver credentials are rendered into common config, whatever path reconciles it
+	w.ensureInterserverCredentialsState(chi)
+
 	// ConfigMap common for all resources in CHI
 	// contains several sections, mapped as separated chopConfig files,
 	// such as remote servers, zookeeper setup, etc
 	configMapCommon := w.task.creator.CreateConfigMapCHICommon(options)
+	curConfigMapCommon, _ := w.c.getConfigMap(&configMapCommon.ObjectMeta, true)
 	err := w.reconcileConfigMap(ctx, chi, configMapCommon)
 	if err == nil {
+		if (curConfigMapCommon != nil) && model.IsDictionariesConfigChanged(curConfigMapCommon.Data, configMapCommon.Data) {
+			// Dictionaries are reloaded as the reconcile is completed
+			w.task.dictionariesChange = true
+		}
 		w.task.registryReconciled.RegisterConfigMap(configMapCommon.ObjectMeta)
 	} else {
 		w.task.registryFailed.RegisterConfigMap(configMapCommon.ObjectMeta)
@@ -279,6 +962,11 @@ func (w *worker) reconcileCHIConfigMapUsers(ctx context.Context, chi *api.ClickH
 		return nil
 	}
 
+	// Generated passwords have to be stored before being rendered, otherwise nobody knows them
+	if err := w.reconcileGeneratedPasswordsSecret(ctx, chi); err != nil {
+		return err
+	}
+
 	// ConfigMap common for all users resources in CHI
 	configMapUsers := w.task.creator.CreateConfigMapCHICommonUsers()
 	err := w.reconcileConfigMap(ctx, chi, configMapUsers)
@@ -370,6 +1058,83 @@ func (w *worker) pollHostForClickHouseVersion(ctx context.Context, host *api.Chi
 	return
 }
 
+// reconcileHostServerUUID stamps UUID of ClickHouse server running on the host onto StatefulSet and Pod of the host.
+// This helps to map replicas registered in ZooKeeper back to pods.
+func (w *worker) reconcileHostServerUUID(ctx context.Context, host *api.ChiHost) {
+	w.annotateHostServerUUID(ctx, host, w.ensureClusterSchemer(host).HostServerUUID)
+}
+
+// annotateHostServerUUID annotates StatefulSet and Pod of the host with server UUID provided by getServerUUID.
+// Read-only for ClickHouse, any failure is just logged and does not affect reconcile.
+func (w *worker) annotateHostServerUUID(
+	ctx context.Context,
+	host *api.ChiHost,
+	getServerUUID func(context.Context, *api.ChiHost) (string, error),
+) {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return
+	}
+
+	uuid, err := getServerUUID(ctx, host)
+	if (err != nil) || (uuid == "") {
+		w.a.V(1).M(host).F().Warning("Unable to get server UUID of host: %s err: %v", host.GetName(), err)
+		return
+	}
+
+	if sts, err := w.c.getStatefulSetByHost(host); err == nil {
+		if model.AnnotateServerUUID(&sts.ObjectMeta, uuid) {
+			if _, err := w.c.kubeClient.AppsV1().StatefulSets(sts.Namespace).Update(ctx, sts, controller.NewUpdateOptions()); err != nil {
+				w.a.V(1).M(host).F().Warning("Unable to annotate StatefulSet %s with server UUID: %s err: %v", sts.Name, uuid, err)
+			}
+		}
+	} else {
+		w.a.V(1).M(host).F().Warning("Unable to get StatefulSet of host: %s err: %v", host.GetName(), err)
+	}
+
+	if pod, err := w.c.getPod(host); err == nil {
+		if model.AnnotateServerUUID(&pod.ObjectMeta, uuid) {
+			if _, err := w.c.kubeClient.CoreV1().Pods(pod.Namespace).Update(ctx, pod, controller.NewUpdateOptions()); err != nil {
+				w.a.V(1).M(host).F().Warning("Unable to annotate Pod %s with server UUID: %s err: %v", pod.Name, uuid, err)
+			}
+		}
+	} else {
+		w.a.V(1).M(host).F().Warning("Unable to get Pod of host: %s err: %v", host.GetName(), err)
+	}
+}
+
+// annotateHostWeight annotates Pod and Service of the host with weight of the host for external load balancers.
+// Annotation is stamped onto live objects instead of Pod template of StatefulSet, so weight change does not restart the host.
+func (w *worker) annotateHostWeight(ctx context.Context, host *api.ChiHost) {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return
+	}
+
+	if pod, err := w.c.getPod(host); err == nil {
+		if model.AnnotateWeight(&pod.ObjectMeta, host) {
+			if _, err := w.c.kubeClient.CoreV1().Pods(pod.Namespace).Update(ctx, pod, controller.NewUpdateOptions()); err != nil {
+				w.a.V(1).M(host).F().Warning("Unable to annotate Pod %s with weight. err: %v", pod.Name, err)
+			}
+		}
+	} else {
+		w.a.V(1).M(host).F().Warning("Unable to get Pod of host: %s err: %v", host.GetName(), err)
+	}
+
+	// Service is fetched from API server and not from lister cache, since it is about to be modified
+	serviceName := model.CreateStatefulSetServiceName(host)
+	if service, err := w.c.kubeClient.CoreV1().Services(host.Runtime.Address.Namespace).Get(ctx, serviceName, controller.NewGetOptions()); err == nil {
+		if model.AnnotateWeight(&service.ObjectMeta, host) {
+			if _, err := w.c.kubeClient.CoreV1().Services(service.Namespace).Update(ctx, service, controller.NewUpdateOptions()); err != nil {
+				w.a.V(1).M(host).F().Warning("Unable to annotate Service %s with weight. err: %v", service.Name, err)
+			}
+		}
+	} else if !apiErrors.IsNotFound(err) {
+		// Host may have no Service of its own
+		w.a.V(1).M(host).F().Warning("Unable to get Service of host: %s err: %v", host.GetName(), err)
+	}
+}
+
 type reconcileHostStatefulSetOptions struct {
 	forceRecreate bool
 }
@@ -412,7 +1177,8 @@ func (w *worker) reconcileHostStatefulSet(ctx context.Context, host *api.ChiHost
 	w.a.V(1).M(host).F().Info("Reconcile host: %s. ClickHouse version: %s", host.GetName(), version)
 	// In case we have to force-restart host
 	// We'll do it via replicas: 0 in StatefulSet.
-	if w.shouldForceRestartHost(host) {
+	forceRestart := w.shouldForceRestartHost(host)
+	if forceRestart {
 		w.a.V(1).M(host).F().Info("Reconcile host: %s. Shutting host down due to force restart", host.GetName())
 		w.prepareHostStatefulSetWithStatus(ctx, host, true)
 		_ = w.reconcileStatefulSet(ctx, host, false)
@@ -427,6 +1193,10 @@ func (w *worker) reconcileHostStatefulSet(ctx context.Context, host *api.ChiHost
 	err := w.reconcileStatefulSet(ctx, host, true, opts...)
 	if err == nil {
 		w.task.registryReconciled.RegisterStatefulSet(host.Runtime.DesiredStatefulSet.ObjectMeta)
+		if !forceRestart {
+			// Host has not been restarted, so configuration changes have to be applied live
+			w.reloadHostConfig(ctx, host)
+		}
 	} else {
 		w.task.registryFailed.RegisterStatefulSet(host.Runtime.DesiredStatefulSet.ObjectMeta)
 		if err == errCRUDIgnore {
@@ -456,6 +1226,7 @@ func (w *worker) reconcileHostService(ctx context.Context, host *api.ChiHost) er
 		// This is not a problem, service may be omitted
 		return nil
 	}
+	w.verifyGoverningService(host, service)
 	err := w.reconcileService(ctx, host.GetCHI(), service)
 	if err == nil {
 		w.a.V(1).M(host).F().Info("DONE Reconcile service of the host: %s", host.GetName())
@@ -467,6 +1238,47 @@ func (w *worker) reconcileHostService(ctx context.Context, host *api.ChiHost) er
 	return err
 }
 
+// verifyGoverningService warns in case Service of the host governs StatefulSet of the host, but is not headless.
+// Pod of such a host has no stable DNS name, so replication may fail as the pod restarts.
+// Returns true in case Service is fine to govern StatefulSet.
+func (w *worker) verifyGoverningService(host *api.ChiHost, service *core.Service) bool {
+	if service.Name != model.CreateStatefulSetGoverningServiceName(host) {
+		// Service does not govern StatefulSet
+		return true
+	}
+	if service.Spec.ClusterIP == core.ClusterIPNone {
+		return true
+	}
+	w.a.V(1).
+		WithEvent(host.GetCHI(), eventActionReconcile, eventReasonServiceNotHeadless).
+		M(host).F().
+		Warning("Service %s/%s governs StatefulSet of the host %s, but is not headless. Pod has no stable DNS name. "+
+			"Specify headless service template or subdomain", service.Namespace, service.Name, host.GetName())
+	return false
+}
+
+// reconcileHostInterserverService reconciles host's interserver Service
+func (w *worker) reconcileHostInterserverService(ctx context.Context, host *api.ChiHost) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+	service := w.task.creator.CreateServiceHostInterserver(host)
+	if service == nil {
+		// Interserver Service is not enabled, delete it in case it has been enabled previously
+		return w.c.deleteServiceHostInterserver(ctx, host)
+	}
+	err := w.reconcileService(ctx, host.GetCHI(), service)
+	if err == nil {
+		w.a.V(1).M(host).F().Info("DONE Reconcile interserver service of the host: %s", host.GetName())
+		w.task.registryReconciled.RegisterService(service.ObjectMeta)
+	} else {
+		w.a.V(1).M(host).F().Warning("FAILED Reconcile interserver service of the host: %s", host.GetName())
+		w.task.registryFailed.RegisterService(service.ObjectMeta)
+	}
+	return err
+}
+
 // reconcileCluster reconciles ChkCluster, excluding nested shards
 func (w *worker) reconcileCluster(ctx context.Context, cluster *api.Cluster) error {
 	if util.IsContextDone(ctx) {
@@ -497,19 +1309,21 @@ func (w *worker) reconcileCluster(ctx context.Context, cluster *api.Cluster) err
 		}
 	}
 
-	pdb := w.task.creator.NewPodDisruptionBudget(cluster)
-	if err := w.reconcilePDB(ctx, cluster, pdb); err == nil {
-		w.task.registryReconciled.RegisterPDB(pdb.ObjectMeta)
-	} else {
-		w.task.registryFailed.RegisterPDB(pdb.ObjectMeta)
-	}
-
 	return nil
 }
 
 // getReconcileShardsWorkersNum calculates how many workers are allowed to be used for concurrent shard reconcile
 func (w *worker) getReconcileShardsWorkersNum(shards []*api.ChiShard, opts *ReconcileShardsAndHostsOptions) int {
 	availableWorkers := float64(chop.Config().Reconcile.Runtime.ReconcileShardsThreadsNumber)
+
+	// Replicas of a shard are reconciled sequentially, thus number of concurrently reconciled hosts
+	// equals number of concurrently reconciled shards.
+	// Being specified explicitly for the CHI, it is not counterbalanced by .Reconcile.Runtime.ReconcileShardsMaxConcurrencyPercent,
+	// however it can not exceed .Reconcile.Runtime.ReconcileShardsThreadsNumber, which is the operator-wide limit.
+	if maxConcurrentHosts := shards[0].GetCHI().GetReconciling().GetMaxConcurrentHosts(); maxConcurrentHosts > 0 {
+		return int(math.Min(float64(maxConcurrentHosts), math.Max(availableWorkers, 1)))
+	}
+
 	maxConcurrencyPercent := float64(chop.Config().Reconcile.Runtime.ReconcileShardsMaxConcurrencyPercent)
 	_100Percent := float64(100)
 	shardsNum := float64(len(shards))
@@ -565,14 +1379,17 @@ func (w *worker) reconcileShardsAndHosts(ctx context.Context, shards []*api.ChiS
 		// For non-full fan-out scenarios, we'll process the first shard separately.
 		// This gives us some early indicator on whether the reconciliation would fail,
 		// and for large clusters it is a small price to pay before performing concurrent fan-out.
+		// Shards up to the one with canary host are processed separately as well, so the canary gates the rest of them.
 		w.a.V(1).Info("starting first shard separately")
-		if err := w.reconcileShardWithHosts(ctx, shards[0]); err != nil {
-			w.a.V(1).Warning("first shard failed, skipping rest of shards due to an error: %v", err)
-			return err
+		canaryShard := getCanaryShardIndex(shards)
+		for shardIndex := 0; shardIndex <= canaryShard; shardIndex++ {
+			if err := w.reconcileShardWithHosts(ctx, shards[shardIndex]); err != nil {
+				w.a.V(1).Warning("first shard failed, skipping rest of shards due to an error: %v", err)
+				return err
+			}
+			// Since shard is already done, we'll proceed with the next one
+			startShard = shardIndex + 1
 		}
-
-		// Since shard with 0 index is already done, we'll proceed with the 1-st
-		startShard = 1
 	}
 
 	// Process shards using specified concurrency level while maintaining specified max concurrency percentage.
@@ -615,14 +1432,28 @@ func (w *worker) reconcileShardsAndHosts(ctx context.Context, shards []*api.ChiS
 }
 
 func (w *worker) reconcileShardWithHosts(ctx context.Context, shard *api.ChiShard) error {
+	if model.IsHostBeyondRolloutBreakpoint(shard.FirstHost()) {
+		// The whole shard goes after the rollout breakpoint
+		return nil
+	}
 	if err := w.reconcileShard(ctx, shard); err != nil {
 		return err
 	}
 	for replicaIndex := range shard.Hosts {
 		host := shard.Hosts[replicaIndex]
-		if err := w.reconcileHost(ctx, host); err != nil {
+		if model.IsHostBeyondRolloutBreakpoint(host) {
+			w.a.V(1).M(host).F().Info("Rollout is paused at the breakpoint, host is not reconciled. Host: %s", host.GetName())
+			continue
+		}
+		if err := w.reconcileHostWithFailurePolicy(ctx, host, w.reconcileHost); err != nil {
 			return err
 		}
+		if isCanaryHost(host) {
+			soakPeriod := host.GetCHI().GetReconciling().GetCanarySoakPeriod()
+			if err := w.verifyCanaryHost(ctx, host, soakPeriod, w.checkCanaryHost); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
@@ -637,6 +1468,16 @@ func (w *worker) reconcileShard(ctx context.Context, shard *api.ChiShard) error
 	w.a.V(2).M(shard).S().P()
 	defer w.a.V(2).M(shard).E().P()
 
+	w.reportShardMaintenance(shard)
+
+	// Add Shard's PodDisruptionBudget
+	pdb := w.task.creator.NewPodDisruptionBudget(shard)
+	if err := w.reconcilePDB(ctx, shard.GetCluster(), pdb); err == nil {
+		w.task.registryReconciled.RegisterPDB(pdb.ObjectMeta)
+	} else {
+		w.task.registryFailed.RegisterPDB(pdb.ObjectMeta)
+	}
+
 	// Add Shard's Service
 	service := w.task.creator.CreateServiceShard(shard)
 	if service == nil {
@@ -652,8 +1493,198 @@ func (w *worker) reconcileShard(ctx context.Context, shard *api.ChiShard) error
 	return err
 }
 
+// reportShardMaintenance reports whether shard is excluded from the cluster for maintenance.
+// Hosts of the shard in maintenance are left out of remote_servers by the config generator.
+func (w *worker) reportShardMaintenance(shard *api.ChiShard) {
+	if !shard.IsInMaintenance() {
+		return
+	}
+
+	cluster := shard.GetCluster()
+	if model.ClusterIsShardCordoned(cluster, shard) {
+		w.a.V(1).
+			WithEvent(shard.GetCHI(), eventActionReconcile, eventReasonShardMaintenance).
+			WithStatusAction(shard.GetCHI()).
+			M(shard).F().
+			Info("Shard %s of cluster %s is in maintenance and is excluded from the cluster", shard.Name, cluster.Name)
+		return
+	}
+
+	w.a.V(1).
+		WithEvent(shard.GetCHI(), eventActionReconcile, eventReasonShardMaintenance).
+		WithStatusAction(shard.GetCHI()).
+		M(shard).F().
+		Warning("Shard %s of cluster %s is in maintenance, but is kept in the cluster. Can not exclude the last serving shard of the cluster", shard.Name, cluster.Name)
+}
+
+// getHostTimeout gets how long reconcile of the host may take and what to do in case it does not complete in time.
+// CHI settings prevail over operator's ones
+func getHostTimeout(host *api.ChiHost) (timeout time.Duration, onTimeout string) {
+	reconciling := host.GetCHI().GetReconciling()
+	timeout = reconciling.GetHostTimeout()
+	if timeout == 0 {
+		timeout = time.Duration(chop.Config().Reconcile.Host.Timeout) * time.Second
+	}
+	onTimeout = reconciling.GetOnHostTimeout()
+	if onTimeout == "" {
+		onTimeout = chop.Config().Reconcile.Host.OnTimeout
+	}
+	return timeout, onTimeout
+}
+
+// reconcileHostWithTimeout reconciles host within the deadline, in case one is specified.
+// Waits for the host to leave or join the cluster may last forever otherwise.
+func (w *worker) reconcileHostWithTimeout(
+	ctx context.Context,
+	host *api.ChiHost,
+	reconcile func(ctx context.Context, host *api.ChiHost) error,
+) error {
+	timeout, onTimeout := getHostTimeout(host)
+
+	hostCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		hostCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	err := reconcile(hostCtx, host)
+	if !util.IsContextDone(ctx) && errors.Is(hostCtx.Err(), context.DeadlineExceeded) {
+		return w.onHostTimeout(ctx, host, timeout, onTimeout)
+	}
+	if err == nil {
+		w.clearHostFailure(ctx, host)
+	}
+	return err
+}
+
+// clearHostFailure clears failure of the host, which reconcile succeeded.
+// Cleared failure is propagated into CHI status right away, since status is re-read by reconcile finalization
+func (w *worker) clearHostFailure(ctx context.Context, host *api.ChiHost) {
+	chi := host.GetCHI()
+	if chi.EnsureStatus().GetHostFailure(host.GetName()) == "" {
+		return
+	}
+	chi.EnsureStatus().SetHostFailure(host.GetName(), "")
+	_ = w.c.updateCHIObjectStatus(ctx, chi, UpdateCHIStatusOptions{
+		CopyCHIStatusOptions: api.CopyCHIStatusOptions{
+			MainFields: true,
+		},
+	})
+}
+
+var (
+	// hostFailureRetryBackoff specifies delay before the first retry of the failed host. Delay is doubled with each retry
+	hostFailureRetryBackoff = 10 * time.Second
+	// hostFailureRetryMaxBackoff specifies max delay between retries of the failed host
+	hostFailureRetryMaxBackoff = 2 * time.Minute
+)
+
+// reconcileHostWithFailurePolicy reconciles host and handles its failure as specified by the host failure policy of the CHI.
+// Returns error in case reconcile of the CHI has to be aborted.
+func (w *worker) reconcileHostWithFailurePolicy(
+	ctx context.Context,
+	host *api.ChiHost,
+	reconcile func(ctx context.Context, host *api.ChiHost) error,
+) error {
+	policy := host.GetCHI().GetReconciling().GetOnHostFailure()
+	retries := policy.GetRetries()
+
+	var err error
+	backoff := hostFailureRetryBackoff
+	for attempt := 0; ; attempt++ {
+		err = w.reconcileHostWithTimeout(ctx, host, reconcile)
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err, errHostTimeout):
+			// Host timeout is handled by its own policy and is recorded already
+			return err
+		case util.IsContextDone(ctx):
+			return err
+		}
+		if attempt >= retries {
+			break
+		}
+		w.a.V(1).
+			M(host).F().
+			Warning("Reconcile of the host %s failed. Retry %d of %d in %s. Err: %v", host.GetName(), attempt+1, retries, backoff, err)
+		if util.WaitContextDoneOrTimeout(ctx, backoff) {
+			return err
+		}
+		if backoff *= 2; backoff > hostFailureRetryMaxBackoff {
+			backoff = hostFailureRetryMaxBackoff
+		}
+	}
+
+	return w.onHostFailure(ctx, host, policy.GetAction(), err)
+}
+
+// onHostFailure records failure of the host.
+// Returns error in case reconcile of the CHI has to be aborted.
+func (w *worker) onHostFailure(ctx context.Context, host *api.ChiHost, action string, err error) error {
+	chi := host.GetCHI()
+	chi.EnsureStatus().SetHostFailure(host.GetName(), err.Error())
+	w.a.V(1).
+		WithEvent(chi, eventActionReconcile, eventReasonReconcileFailed).
+		WithStatusError(chi).
+		M(host).F().
+		Warning("Reconcile of the host %s failed. On failure: %s. Err: %v", host.GetName(), action, err)
+	_ = w.c.updateCHIObjectStatus(ctx, chi, UpdateCHIStatusOptions{
+		CopyCHIStatusOptions: api.CopyCHIStatusOptions{
+			MainFields: true,
+		},
+	})
+
+	if action == api.HostFailureActionContinue {
+		return nil
+	}
+	return err
+}
+
+// onHostTimeout records failure of the host, which reconcile did not complete in time.
+// Returns error in case reconcile of the CHI has to be aborted.
+func (w *worker) onHostTimeout(ctx context.Context, host *api.ChiHost, timeout time.Duration, onTimeout string) error {
+	// Merges are started and the host is included back by host reconcile on its way out, but within expired context
+	w.startHostMerges(ctx, host)
+	w.restoreHostMembership(ctx, host)
+
+	chi := host.GetCHI()
+	chi.EnsureStatus().HostFailed()
+	reason := fmt.Sprintf("reconcile did not complete in %s", timeout)
+	chi.EnsureStatus().SetHostFailure(host.GetName(), reason)
+	chi.EnsureStatus().SetHostProgressError(host.GetName(), reason)
+	w.a.V(1).
+		WithEvent(chi, eventActionReconcile, eventReasonHostReconcileTimeout).
+		WithStatusError(chi).
+		M(host).F().
+		Warning("Reconcile of the host %s did not complete in %s. On timeout: %s", host.GetName(), timeout, onTimeout)
+	_ = w.c.updateCHIObjectStatus(ctx, chi, UpdateCHIStatusOptions{
+		CopyCHIStatusOptions: api.CopyCHIStatusOptions{
+			MainFields: true,
+		},
+	})
+
+	if strings.EqualFold(onTimeout, api.OnHostTimeoutActionContinue) {
+		return nil
+	}
+	return fmt.Errorf("%w: host %s did not complete in %s", errHostTimeout, host.GetName(), timeout)
+}
+
+// setHostPhase sets phase of the host reconcile and propagates it into CHI status,
+// so it is visible which host reconcile is stuck on
+func (w *worker) setHostPhase(ctx context.Context, host *api.ChiHost, phase string) {
+	host.GetCHI().EnsureStatus().SetHostPhase(host.GetName(), phase)
+	_ = w.c.updateCHIObjectStatus(ctx, host.GetCHI(), UpdateCHIStatusOptions{
+		TolerateAbsence: true,
+		CopyCHIStatusOptions: api.CopyCHIStatusOptions{
+			Actions: true,
+		},
+	})
+}
+
 // reconcileHost reconciles specified ClickHouse host
-func (w *worker) reconcileHost(ctx context.Context, host *api.ChiHost) error {
+func (w *worker) reconcileHost(ctx context.Context, host *api.ChiHost) (err error) {
 	var (
 		reconcileHostStatefulSetOpts *reconcileHostStatefulSetOptions
 		migrateTableOpts             *migrateTableOptions
@@ -667,6 +1698,12 @@ func (w *worker) reconcileHost(ctx context.Context, host *api.ChiHost) error {
 	w.a.V(2).M(host).S().P()
 	defer w.a.V(2).M(host).E().P()
 
+	defer func() {
+		if err != nil {
+			host.GetCHI().EnsureStatus().SetHostProgressError(host.GetName(), err.Error())
+		}
+	}()
+
 	metricsHostReconcilesStarted(ctx, host.GetCHI())
 	startTime := time.Now()
 
@@ -679,6 +1716,7 @@ func (w *worker) reconcileHost(ctx context.Context, host *api.ChiHost) error {
 	if version, err := w.getHostClickHouseVersion(ctx, host, versionOptions{skipNew: true, skipStoppedAncestor: true}); err == nil {
 		w.a.V(1).
 			WithEvent(host.GetCHI(), eventActionReconcile, eventReasonReconcileStarted).
+			WithEventAggregation().
 			WithStatusAction(host.GetCHI()).
 			M(host).F().
 			Info("Reconcile Host start. Host: %s ClickHouse version running: %s", host.GetName(), version)
@@ -693,6 +1731,7 @@ func (w *worker) reconcileHost(ctx context.Context, host *api.ChiHost) error {
 	// Create artifacts
 	w.prepareHostStatefulSetWithStatus(ctx, host, false)
 
+	w.setHostPhase(ctx, host, api.HostPhaseExcluding)
 	if err := w.excludeHost(ctx, host); err != nil {
 		metricsHostReconcilesErrors(ctx, host.GetCHI())
 		w.a.V(1).
@@ -700,9 +1739,12 @@ func (w *worker) reconcileHost(ctx context.Context, host *api.ChiHost) error {
 			Warning("Reconcile Host interrupted with an error 1. Host: %s Err: %v", host.GetName(), err)
 		return err
 	}
+	// Merges stopped on exclude have to be started again whatever happens to the host
+	defer w.startHostMerges(ctx, host)
 
 	_ = w.completeQueries(ctx, host)
 
+	w.setHostPhase(ctx, host, api.HostPhaseUpdating)
 	if err := w.reconcileHostConfigMap(ctx, host); err != nil {
 		metricsHostReconcilesErrors(ctx, host.GetCHI())
 		w.a.V(1).
@@ -741,6 +1783,7 @@ func (w *worker) reconcileHost(ctx context.Context, host *api.ChiHost) error {
 	_ = w.reconcilePVCs(ctx, host, api.DesiredStatefulSet)
 
 	_ = w.reconcileHostService(ctx, host)
+	_ = w.reconcileHostInterserverService(ctx, host)
 
 	host.GetReconcileAttributes().UnsetAdd()
 
@@ -758,6 +1801,7 @@ func (w *worker) reconcileHost(ctx context.Context, host *api.ChiHost) error {
 	}
 	_ = w.migrateTables(ctx, host, migrateTableOpts)
 
+	w.setHostPhase(ctx, host, api.HostPhaseWaitingInCluster)
 	if err := w.includeHost(ctx, host); err != nil {
 		metricsHostReconcilesErrors(ctx, host.GetCHI())
 		w.a.V(1).
@@ -766,14 +1810,19 @@ func (w *worker) reconcileHost(ctx context.Context, host *api.ChiHost) error {
 		return err
 	}
 
+	// Weight is stamped onto live objects, so weight change does not restart the host
+	w.annotateHostWeight(ctx, host)
+
 	// Ensure host is running and accessible and what version is available.
 	// Sometimes service needs some time to start after creation|modification before being accessible for usage
 	if version, err := w.pollHostForClickHouseVersion(ctx, host); err == nil {
 		w.a.V(1).
 			WithEvent(host.GetCHI(), eventActionReconcile, eventReasonReconcileCompleted).
+			WithEventAggregation().
 			WithStatusAction(host.GetCHI()).
 			M(host).F().
 			Info("Reconcile Host completed. Host: %s ClickHouse version running: %s", host.GetName(), version)
+		w.reconcileHostServerUUID(ctx, host)
 	} else {
 		w.a.V(1).
 			WithEvent(host.GetCHI(), eventActionReconcile, eventReasonReconcileCompleted).
@@ -785,6 +1834,7 @@ func (w *worker) reconcileHost(ctx context.Context, host *api.ChiHost) error {
 	now := time.Now()
 	hostsCompleted := 0
 	hostsCount := 0
+	host.GetCHI().EnsureStatus().SetHostPhase(host.GetName(), api.HostPhaseDone)
 	host.GetCHI().EnsureStatus().HostCompleted()
 	if host.GetCHI() != nil && host.GetCHI().Status != nil {
 		hostsCompleted = host.GetCHI().Status.GetHostsCompletedCount()
@@ -792,6 +1842,7 @@ func (w *worker) reconcileHost(ctx context.Context, host *api.ChiHost) error {
 	}
 	w.a.V(1).
 		WithEvent(host.GetCHI(), eventActionProgress, eventReasonProgressHostsCompleted).
+		WithEventAggregation().
 		WithStatusAction(host.GetCHI()).
 		M(host).F().
 		Info("[now: %s] %s: %d of %d", now, eventReasonProgressHostsCompleted, hostsCompleted, hostsCount)
@@ -855,7 +1906,10 @@ func (w *worker) reconcileConfigMap(
 	curConfigMap, err := w.c.getConfigMap(&configMap.ObjectMeta, true)
 
 	if curConfigMap != nil {
-		// We have ConfigMap - try to update it
+		// We have ConfigMap - try to update it, unless it belongs to someone else
+		if err := w.verifyObjectManaged(chi, "ConfigMap", &curConfigMap.ObjectMeta); err != nil {
+			return err
+		}
 		err = w.updateConfigMap(ctx, chi, configMap)
 	}
 
@@ -892,11 +1946,16 @@ func (w *worker) reconcileService(ctx context.Context, chi *api.ClickHouseInstal
 	w.a.V(2).M(chi).S().Info(service.Name)
 	defer w.a.V(2).M(chi).E().Info(service.Name)
 
+	service = w.ensureServiceExternalDNS(chi, service)
+
 	// Check whether this object already exists
 	curService, err := w.c.getService(service)
 
 	if curService != nil {
-		// We have the Service - try to update it
+		// We have the Service - try to update it, unless it belongs to someone else
+		if err := w.verifyObjectManaged(chi, "Service", &curService.ObjectMeta); err != nil {
+			return err
+		}
 		w.a.V(1).M(chi).F().Info("Service found: %s/%s. Will try to update", service.Namespace, service.Name)
 		err = w.updateService(ctx, chi, curService, service)
 	}
@@ -914,6 +1973,13 @@ func (w *worker) reconcileService(ctx context.Context, chi *api.ClickHouseInstal
 				Error("Update Service: %s/%s failed with error: %v", service.Namespace, service.Name, err)
 		}
 
+		if curService != nil {
+			// Try to keep already allocated node ports and session affinity on the re-created service
+			service = service.DeepCopy()
+			k8s.ServicePreserveNodePorts(curService, service)
+			k8s.ServicePreserveSessionAffinity(curService, service)
+		}
+
 		_ = w.c.deleteServiceIfExists(ctx, service.Namespace, service.Name)
 		err = w.createService(ctx, chi, service)
 	}
@@ -931,6 +1997,22 @@ func (w *worker) reconcileService(ctx context.Context, chi *api.ClickHouseInstal
 	return err
 }
 
+// ensureServiceExternalDNS templates external-dns annotations, specified by .spec.dns, into the CHI entry point Service.
+// Annotations provided by the user explicitly prevail over the templated ones.
+func (w *worker) ensureServiceExternalDNS(chi *api.ClickHouseInstallation, service *core.Service) *core.Service {
+	if service.Name != model.CreateCHIServiceName(chi) {
+		// DNS record is registered for the CHI entry point only
+		return service
+	}
+	annotations := model.NewAnnotator(chi).GetServiceCHIExternalDNS()
+	if len(annotations) == 0 {
+		return service
+	}
+	service = service.DeepCopy()
+	service.Annotations = util.MergeStringMapsPreserve(service.Annotations, annotations)
+	return service
+}
+
 // reconcileSecret reconciles core.Secret
 func (w *worker) reconcileSecret(ctx context.Context, chi *api.ClickHouseInstallation, secret *core.Secret) error {
 	if util.IsContextDone(ctx) {
@@ -1051,6 +2133,16 @@ func (w *worker) reconcileStatefulSet(
 	case opt.ForceRecreate():
 		// Force recreate prevails over all other requests
 		w.recreateStatefulSet(ctx, host, register)
+	case k8s.IsStatefulSetPodNetworkChanged(host.Runtime.CurStatefulSet, newStatefulSet):
+		// Pod networking can not be changed by rolling update
+		w.a.V(1).M(host).F().Info("Pod network changed, need to recreate StatefulSet: %s", util.NamespaceNameString(newStatefulSet.ObjectMeta))
+		err = w.recreateStatefulSet(ctx, host, register)
+	case w.shouldResizeStatefulSetInPlace(host):
+		// Resources requests/limits are the only change, no need to roll pods
+		err = w.resizeStatefulSetInPlace(ctx, host, register)
+	case w.isStatefulSetRolledBack(host):
+		// Spec, which repeatedly failed to roll out, is not applied again until it is edited
+		w.a.V(1).M(host).F().Warning("StatefulSet %s was rolled back after repeated rollout failures. Edit spec to retry", util.NamespaceNameString(newStatefulSet.ObjectMeta))
 	default:
 		// We have (or had in the past) StatefulSet - try to update|recreate it
 		err = w.updateStatefulSet(ctx, host, register)
@@ -1155,6 +2247,11 @@ func (w *worker) reconcilePVCFromVolumeMount(
 	w.a.V(2).M(host).S().Info("reconcile volumeMount (%s/%s/%s/%s)", namespace, host.GetName(), volumeMount.Name, pvcName)
 	defer w.a.V(2).M(host).E().Info("reconcile volumeMount (%s/%s/%s/%s)", namespace, host.GetName(), volumeMount.Name, pvcName)
 
+	// PVC, which is not able to be bound, would make pod to be Pending forever
+	if !isModelCreated {
+		w.c.checkPVCPending(ctx, host, pvc)
+	}
+
 	// Check scenario 1 - no PVC available
 	// Such a PVC should be re-created
 	if isLostPVC(pvc, isModelCreated, host) {
@@ -1274,7 +2371,25 @@ func (w *worker) reconcilePVC(
 		return nil, fmt.Errorf("task is done")
 	}
 
-	w.applyPVCResourcesRequests(pvc, template)
+	cur := pvc.DeepCopy()
+	resourcesUpdated := w.applyPVCResourcesRequests(pvc, host, template)
 	pvc = w.task.creator.PreparePersistentVolumeClaim(pvc, host, template)
+	if isPVCExisting(pvc) && !resourcesUpdated && !isPVCMetaUpdated(cur, pvc) {
+		w.a.V(2).M(host).F().Info("PVC (%s/%s/%s) is up to date, no need to update", pvc.Namespace, pvc.Name, host.GetName())
+		return pvc, nil
+	}
 	return w.c.updatePersistentVolumeClaim(ctx, pvc)
 }
+
+// isPVCExisting checks whether the PVC is fetched from k8s, rather than modelled out of the template
+func isPVCExisting(pvc *core.PersistentVolumeClaim) bool {
+	return pvc.ResourceVersion != ""
+}
+
+// isPVCMetaUpdated checks whether labels or annotations of the PVC are updated.
+// Object version label is not taken into account, since it is fingerprint of the whole object as fetched
+func isPVCMetaUpdated(cur, pvc *core.PersistentVolumeClaim) bool {
+	curLabels := util.CopyMapExclude(cur.Labels, model.LabelObjectVersion)
+	labels := util.CopyMapExclude(pvc.Labels, model.LabelObjectVersion)
+	return !util.MapsAreTheSame(curLabels, labels) || !util.MapsAreTheSame(cur.Annotations, pvc.Annotations)
+}
@@ -0,0 +1,1054 @@
+package chi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1"
+	scheduling "k8s.io/api/scheduling/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+	appsListers "k8s.io/client-go/listers/apps/v1"
+	coreListers "k8s.io/client-go/listers/core/v1"
+	k8sTesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	chopFake "github.com/altinity/clickhouse-operator/pkg/client/clientset/versioned/fake"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	chiCreator "github.com/altinity/clickhouse-operator/pkg/model/chi/creator"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi/normalizer"
+)
+
+func Test_ReconcileService_ExternalDNS(t *testing.T) {
+	ttl := int32(60)
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+		},
+		Spec: api.ChiSpec{
+			DNS: &api.ChiDNS{
+				Hostname: "{chi}.{namespace}.db.example.com",
+				TTL:      &ttl,
+			},
+			Defaults: api.NewChiDefaults(),
+		},
+	}
+	newService := func(name string, annotations map[string]string) *core.Service {
+		return &core.Service{
+			ObjectMeta: meta.ObjectMeta{
+				Namespace:   "ns",
+				Name:        name,
+				Annotations: annotations,
+			},
+		}
+	}
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	kubeClient := kubeFake.NewSimpleClientset()
+	w := &worker{
+		c: &Controller{
+			kubeClient:    kubeClient,
+			serviceLister: coreListers.NewServiceLister(indexer),
+		},
+		a: NewAnnouncer(),
+	}
+	ctx := context.Background()
+	getAnnotations := func(name string) map[string]string {
+		service, err := kubeClient.CoreV1().Services("ns").Get(ctx, name, meta.GetOptions{})
+		require.NoError(t, err)
+		return service.Annotations
+	}
+
+	// CHI entry point Service is annotated, user-provided annotations are kept
+	service := newService(model.CreateCHIServiceName(chi), map[string]string{"user": "annotation"})
+	require.NoError(t, w.reconcileService(ctx, chi, service))
+	require.Equal(t, map[string]string{
+		"user":                              "annotation",
+		model.AnnotationExternalDNSHostname: "chi.ns.db.example.com",
+		model.AnnotationExternalDNSTTL:      "60",
+	}, getAnnotations(service.Name))
+	// Desired Service is not modified
+	require.Equal(t, map[string]string{"user": "annotation"}, service.Annotations)
+
+	// Annotations specified explicitly prevail
+	require.NoError(t, kubeClient.CoreV1().Services("ns").Delete(ctx, service.Name, meta.DeleteOptions{}))
+	service = newService(model.CreateCHIServiceName(chi), map[string]string{model.AnnotationExternalDNSHostname: "custom.example.com"})
+	require.NoError(t, w.reconcileService(ctx, chi, service))
+	require.Equal(t, "custom.example.com", getAnnotations(service.Name)[model.AnnotationExternalDNSHostname])
+
+	// Other Services are not annotated
+	service = newService("cluster-default", nil)
+	require.NoError(t, w.reconcileService(ctx, chi, service))
+	require.Empty(t, getAnnotations(service.Name))
+}
+
+func Test_ReconcileService_AddedPortsKeepNodePorts(t *testing.T) {
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+		},
+		Spec: api.ChiSpec{
+			Defaults: api.NewChiDefaults(),
+		},
+	}
+	newService := func(ports ...core.ServicePort) *core.Service {
+		return &core.Service{
+			ObjectMeta: meta.ObjectMeta{
+				Namespace: "ns",
+				Name:      "chi-chi-cluster-0-0",
+				Labels:    map[string]string{model.LabelAppName: model.LabelAppValue},
+			},
+			Spec: core.ServiceSpec{
+				Type:  core.ServiceTypeNodePort,
+				Ports: ports,
+			},
+		}
+	}
+	tcp := core.ServicePort{Name: model.ChDefaultTCPPortName, Port: model.ChDefaultTCPPortNumber}
+	grpc := core.ServicePort{Name: model.ChDefaultGRPCPortName, Port: model.ChDefaultGRPCPortNumber}
+	prometheus := core.ServicePort{Name: model.ChDefaultPrometheusPortName, Port: model.ChDefaultPrometheusPortNumber}
+
+	curTCP := tcp
+	curTCP.NodePort = 30900
+	cur := newService(curTCP)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	require.NoError(t, indexer.Add(cur))
+	kubeClient := kubeFake.NewSimpleClientset(cur.DeepCopy())
+	w := &worker{
+		c: &Controller{
+			kubeClient:    kubeClient,
+			serviceLister: coreListers.NewServiceLister(indexer),
+		},
+		a: NewAnnouncer(),
+	}
+	ctx := context.Background()
+
+	require.NoError(t, w.reconcileService(ctx, chi, newService(tcp, grpc, prometheus)))
+	updated, err := kubeClient.CoreV1().Services("ns").Get(ctx, cur.Name, meta.GetOptions{})
+	require.NoError(t, err)
+	// Already exposed port keeps its node port, added ports are exposed as well
+	require.Equal(t, []core.ServicePort{curTCP, grpc, prometheus}, updated.Spec.Ports)
+}
+
+func Test_ReconcileService_SessionAffinitySurvives(t *testing.T) {
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+		},
+		Spec: api.ChiSpec{
+			Defaults: api.NewChiDefaults(),
+		},
+	}
+	newService := func() *core.Service {
+		return &core.Service{
+			ObjectMeta: meta.ObjectMeta{
+				Namespace: "ns",
+				Name:      "clickhouse-chi",
+				Labels:    map[string]string{model.LabelAppName: model.LabelAppValue},
+			},
+			Spec: core.ServiceSpec{
+				Type: core.ServiceTypeClusterIP,
+				Ports: []core.ServicePort{
+					{Name: model.ChDefaultHTTPPortName, Port: model.ChDefaultHTTPPortNumber},
+				},
+			},
+		}
+	}
+
+	timeout := int32(600)
+	cur := newService()
+	cur.Spec.ClusterIP = "10.0.0.10"
+	cur.Spec.SessionAffinity = core.ServiceAffinityClientIP
+	cur.Spec.SessionAffinityConfig = &core.SessionAffinityConfig{
+		ClientIP: &core.ClientIPConfig{TimeoutSeconds: &timeout},
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	require.NoError(t, indexer.Add(cur))
+	kubeClient := kubeFake.NewSimpleClientset(cur.DeepCopy())
+	w := &worker{
+		c: &Controller{
+			kubeClient:    kubeClient,
+			serviceLister: coreListers.NewServiceLister(indexer),
+		},
+		a: NewAnnouncer(),
+	}
+	ctx := context.Background()
+
+	// Reconcile does not mention session affinity
+	require.NoError(t, w.reconcileService(ctx, chi, newService()))
+	updated, err := kubeClient.CoreV1().Services("ns").Get(ctx, cur.Name, meta.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.10", updated.Spec.ClusterIP)
+	require.Equal(t, core.ServiceAffinityClientIP, updated.Spec.SessionAffinity)
+	require.Equal(t, cur.Spec.SessionAffinityConfig, updated.Spec.SessionAffinityConfig)
+}
+
+func Test_Clean_PurgesUnassignedService(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+		},
+	}
+	chi.Spec.Configuration = &api.Configuration{Clusters: []*api.Cluster{{Name: "cluster"}}}
+	chi, err := normalizer.NewNormalizer(nil).CreateTemplatedCHI(chi, normalizer.NewOptions())
+	require.NoError(t, err)
+
+	newService := func(name string, labels map[string]string) *core.Service {
+		return &core.Service{
+			ObjectMeta: meta.ObjectMeta{
+				Namespace: "ns",
+				Name:      name,
+				Labels:    labels,
+			},
+		}
+	}
+	labels := model.NewLabeler(chi).GetSelectorCHIScope()
+	// Service of the scope with service template assigned, which is reconciled
+	assigned := newService("cluster-chi-cluster", labels)
+	// Service of the scope with service template unassigned, including one with generated name
+	unassigned := newService("shard-chi-cluster-0", labels)
+	generated := newService("shard-chi-cluster-0-x7k2p", labels)
+	// Service not managed by the operator
+	foreign := newService("foreign", nil)
+	kubeClient := kubeFake.NewSimpleClientset(assigned, unassigned, generated, foreign)
+	w := &worker{
+		c:    &Controller{kubeClient: kubeClient},
+		a:    NewAnnouncer(),
+		task: newTask(chiCreator.NewCreator(chi)),
+	}
+	w.task.registryReconciled.RegisterService(assigned.ObjectMeta)
+	ctx := context.Background()
+
+	w.clean(ctx, chi)
+
+	services, err := kubeClient.CoreV1().Services("ns").List(ctx, meta.ListOptions{})
+	require.NoError(t, err)
+	var names []string
+	for _, service := range services.Items {
+		names = append(names, service.Name)
+	}
+	require.ElementsMatch(t, []string{assigned.Name, foreign.Name}, names)
+}
+
+func Test_AnnotateHostServerUUID(t *testing.T) {
+	const uuid = "6a4f9d3c-4b1e-4bd4-9a54-1f3f1e6b4c2d"
+	host := newTestShard(1)[0]
+	host.Runtime.Address.Namespace = "ns"
+	sts := &apps.StatefulSet{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      model.CreateStatefulSetName(host),
+		},
+	}
+	pod := &core.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace:   "ns",
+			Name:        model.CreatePodName(host),
+			Annotations: map[string]string{"user": "annotation"},
+		},
+	}
+	kubeClient := kubeFake.NewSimpleClientset(sts, pod)
+	w := &worker{
+		c: &Controller{
+			kubeClient: kubeClient,
+		},
+		a: NewAnnouncer(),
+	}
+	ctx := context.Background()
+	getAnnotations := func() (map[string]string, map[string]string) {
+		sts, err := kubeClient.AppsV1().StatefulSets("ns").Get(ctx, sts.Name, meta.GetOptions{})
+		require.NoError(t, err)
+		pod, err := kubeClient.CoreV1().Pods("ns").Get(ctx, pod.Name, meta.GetOptions{})
+		require.NoError(t, err)
+		return sts.Annotations, pod.Annotations
+	}
+
+	// Failed query is a no-op
+	w.annotateHostServerUUID(ctx, host, func(context.Context, *api.ChiHost) (string, error) {
+		return "", fmt.Errorf("connection refused")
+	})
+	stsAnnotations, podAnnotations := getAnnotations()
+	require.NotContains(t, stsAnnotations, model.AnnotationServerUUID)
+	require.NotContains(t, podAnnotations, model.AnnotationServerUUID)
+
+	// Server UUID reported by the host is stamped onto both StatefulSet and Pod
+	w.annotateHostServerUUID(ctx, host, func(context.Context, *api.ChiHost) (string, error) {
+		return uuid, nil
+	})
+	stsAnnotations, podAnnotations = getAnnotations()
+	require.Equal(t, uuid, stsAnnotations[model.AnnotationServerUUID])
+	require.Equal(t, uuid, podAnnotations[model.AnnotationServerUUID])
+	require.Equal(t, "annotation", podAnnotations["user"])
+
+	// Unchanged UUID does not update objects
+	kubeClient.ClearActions()
+	w.annotateHostServerUUID(ctx, host, func(context.Context, *api.ChiHost) (string, error) {
+		return uuid, nil
+	})
+	for _, action := range kubeClient.Actions() {
+		require.NotEqual(t, "update", action.GetVerb())
+	}
+}
+
+func Test_AnnotateHostWeight(t *testing.T) {
+	host := newTestShard(1)[0]
+	host.Runtime.Address.Namespace = "ns"
+	pod := &core.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace:   "ns",
+			Name:        model.CreatePodName(host),
+			Annotations: map[string]string{"user": "annotation"},
+		},
+	}
+	service := &core.Service{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      model.CreateStatefulSetServiceName(host),
+		},
+	}
+	kubeClient := kubeFake.NewSimpleClientset(pod, service)
+	w := &worker{
+		c: &Controller{
+			kubeClient: kubeClient,
+		},
+		a: NewAnnouncer(),
+	}
+	ctx := context.Background()
+	getAnnotations := func() (map[string]string, map[string]string) {
+		pod, err := kubeClient.CoreV1().Pods("ns").Get(ctx, pod.Name, meta.GetOptions{})
+		require.NoError(t, err)
+		service, err := kubeClient.CoreV1().Services("ns").Get(ctx, service.Name, meta.GetOptions{})
+		require.NoError(t, err)
+		return pod.Annotations, service.Annotations
+	}
+
+	// Weight is stamped onto both Pod and Service
+	weight := 3
+	host.Weight = &weight
+	w.annotateHostWeight(ctx, host)
+	podAnnotations, serviceAnnotations := getAnnotations()
+	require.Equal(t, "3", podAnnotations[model.AnnotationWeight])
+	require.Equal(t, "3", serviceAnnotations[model.AnnotationWeight])
+	require.Equal(t, "annotation", podAnnotations["user"])
+
+	// Unchanged weight does not update objects
+	kubeClient.ClearActions()
+	w.annotateHostWeight(ctx, host)
+	for _, action := range kubeClient.Actions() {
+		require.NotEqual(t, "update", action.GetVerb())
+	}
+
+	// Changed weight is updated in place
+	weight = 5
+	w.annotateHostWeight(ctx, host)
+	podAnnotations, serviceAnnotations = getAnnotations()
+	require.Equal(t, "5", podAnnotations[model.AnnotationWeight])
+	require.Equal(t, "5", serviceAnnotations[model.AnnotationWeight])
+
+	// Removed weight removes annotation
+	host.Weight = nil
+	w.annotateHostWeight(ctx, host)
+	podAnnotations, serviceAnnotations = getAnnotations()
+	require.NotContains(t, podAnnotations, model.AnnotationWeight)
+	require.NotContains(t, serviceAnnotations, model.AnnotationWeight)
+	require.Equal(t, "annotation", podAnnotations["user"])
+}
+
+func Test_IsPlanApproved(t *testing.T) {
+	newCHI := func(replicas int, annotations map[string]string) *api.ClickHouseInstallation {
+		return &api.ClickHouseInstallation{
+			ObjectMeta: meta.ObjectMeta{
+				Namespace:   "ns",
+				Name:        "chi",
+				Annotations: annotations,
+			},
+			Spec: api.ChiSpec{
+				Reconciling: &api.ChiReconciling{
+					PlanApproval: api.NewStringBool(true),
+				},
+				Configuration: &api.Configuration{
+					Clusters: []*api.Cluster{
+						{
+							Name:   "cluster",
+							Layout: &api.ChiClusterLayout{ReplicasCount: replicas},
+						},
+					},
+				},
+			},
+		}
+	}
+	kubeClient := kubeFake.NewSimpleClientset()
+	chopClient := chopFake.NewSimpleClientset(newCHI(1, nil))
+	c := &Controller{
+		kubeClient: kubeClient,
+		chopClient: chopClient,
+	}
+	w := &worker{c: c, a: NewAnnouncer().WithController(c)}
+	ctx := context.Background()
+	old := newCHI(1, nil)
+	getStatus := func() *api.ChiStatus {
+		cur, err := chopClient.ClickhouseV1().ClickHouseInstallations("ns").Get(ctx, "chi", meta.GetOptions{})
+		require.NoError(t, err)
+		return cur.Status
+	}
+
+	// Plan is published in status and waits for approval
+	chi := newCHI(2, nil)
+	ap := model.NewActionPlan(old, chi)
+	require.False(t, w.isPlanApproved(ctx, chi, ap))
+	status := getStatus()
+	require.Equal(t, api.StatusAwaitingApproval, status.Status)
+	require.Equal(t, ap.Hash(), status.GetPendingPlanHash())
+	require.NotEmpty(t, status.PendingPlan)
+
+	// Approval of the previous plan does not match the plan of the changed spec
+	approved := ap.Hash()
+	chi = newCHI(3, map[string]string{model.AnnotationApprovePlan: approved})
+	ap = model.NewActionPlan(old, chi)
+	require.False(t, w.isPlanApproved(ctx, chi, ap))
+	require.NotEqual(t, approved, getStatus().GetPendingPlanHash())
+
+	// Approval of the current plan lets reconcile continue
+	chi = newCHI(3, map[string]string{model.AnnotationApprovePlan: ap.Hash()})
+	require.True(t, isPlanApprovalUpdated(newCHI(3, map[string]string{model.AnnotationApprovePlan: approved}), chi))
+	require.True(t, w.isPlanApproved(ctx, chi, ap))
+	require.Empty(t, chi.EnsureStatus().GetPendingPlanHash())
+
+	var reasons []string
+	for _, action := range kubeClient.Actions() {
+		if create, ok := action.(k8sTesting.CreateAction); ok && (action.GetResource().Resource == "events") {
+			reasons = append(reasons, create.GetObject().(*core.Event).Reason)
+		}
+	}
+	require.Equal(t, []string{eventReasonPlanApprovalRequired, eventReasonPlanApprovalRequired}, reasons)
+
+	// CHI, which does not require approval, is reconciled right away
+	chi = newCHI(4, nil)
+	chi.Spec.Reconciling = nil
+	require.True(t, w.isPlanApproved(ctx, chi, model.NewActionPlan(old, chi)))
+}
+
+func Test_GetPodTemplateEnvSources(t *testing.T) {
+	optional := true
+	template := &api.PodTemplate{
+		Env: []core.EnvVar{
+			{Name: "TZ", Value: "UTC"},
+			{
+				Name: "AWS_ACCESS_KEY_ID",
+				ValueFrom: &core.EnvVarSource{
+					SecretKeyRef: &core.SecretKeySelector{
+						LocalObjectReference: core.LocalObjectReference{Name: "s3-credentials"},
+						Key:                  "access-key-id",
+					},
+				},
+			},
+			{
+				Name: "LOG_LEVEL",
+				ValueFrom: &core.EnvVarSource{
+					ConfigMapKeyRef: &core.ConfigMapKeySelector{
+						LocalObjectReference: core.LocalObjectReference{Name: "logging"},
+						Key:                  "level",
+						Optional:             &optional,
+					},
+				},
+			},
+		},
+		EnvFrom: []core.EnvFromSource{
+			{ConfigMapRef: &core.ConfigMapEnvSource{LocalObjectReference: core.LocalObjectReference{Name: "settings"}}},
+			{SecretRef: &core.SecretEnvSource{LocalObjectReference: core.LocalObjectReference{Name: "extra"}, Optional: &optional}},
+		},
+	}
+
+	// Optional sources are not required to exist
+	require.Equal(t, []envSource{
+		{kind: "Secret", name: "s3-credentials"},
+		{kind: "ConfigMap", name: "settings"},
+	}, getPodTemplateEnvSources(template))
+}
+
+func Test_VerifyGoverningService(t *testing.T) {
+	host := newTestShard(1)[0]
+	host.Runtime.Address.Namespace = "ns"
+	w := &worker{
+		a: NewAnnouncer(),
+	}
+	service := &core.Service{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      model.CreateStatefulSetServiceName(host),
+		},
+	}
+
+	// Service of the host governs StatefulSet and has to be headless
+	require.False(t, w.verifyGoverningService(host, service))
+	service.Spec.ClusterIP = core.ClusterIPNone
+	require.True(t, w.verifyGoverningService(host, service))
+
+	// Subdomain Service governs StatefulSet, so Service of the host is free to have cluster IP
+	service.Spec.ClusterIP = ""
+	host.GetCHI().Spec.Defaults = &api.ChiDefaults{Subdomain: "sub"}
+	require.True(t, w.verifyGoverningService(host, service))
+}
+
+func Test_GetReconcileShardsWorkersNum_MaxConcurrentHosts(t *testing.T) {
+	config := &api.OperatorConfig{}
+	config.Reconcile.Runtime.ReconcileShardsThreadsNumber = 5
+	setTestConfig(t, config)
+
+	chi := &api.ClickHouseInstallation{
+		Spec: api.ChiSpec{
+			Reconciling: &api.ChiReconciling{
+				MaxConcurrentHosts: 3,
+			},
+		},
+	}
+	var shards []*api.ChiShard
+	for i := 0; i < 10; i++ {
+		shard := &api.ChiShard{Name: fmt.Sprintf("%d", i)}
+		shard.Runtime.CHI = chi
+		shards = append(shards, shard)
+	}
+	w := &worker{
+		a: NewAnnouncer(),
+	}
+
+	// Concurrency specified by the CHI is used for both regular and full fan-out reconcile
+	require.Equal(t, 3, w.getReconcileShardsWorkersNum(shards, &ReconcileShardsAndHostsOptions{}))
+	require.Equal(t, 3, w.getReconcileShardsWorkersNum(shards, &ReconcileShardsAndHostsOptions{fullFanOut: true}))
+
+	// Concurrency specified by the CHI is clamped to the operator-wide limit
+	chi.Spec.Reconciling.MaxConcurrentHosts = 8
+	require.Equal(t, 5, w.getReconcileShardsWorkersNum(shards, &ReconcileShardsAndHostsOptions{}))
+	require.Equal(t, 5, w.getReconcileShardsWorkersNum(shards, &ReconcileShardsAndHostsOptions{fullFanOut: true}))
+}
+
+func Test_ReconcileHostWithTimeout(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+	host := newTestShard(1)[0]
+	chi := host.GetCHI()
+	chi.Namespace = "ns"
+	chi.Name = "chi"
+	chi.Spec.Defaults = api.NewChiDefaults()
+	chi.Spec.Reconciling = &api.ChiReconciling{
+		HostTimeout:   1,
+		OnHostTimeout: api.OnHostTimeoutActionAbort,
+	}
+	host.Runtime.Address.Namespace = "ns"
+	host.Runtime.Address.CHIName = "chi"
+	pod := &core.Pod{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: model.CreatePodName(host)}}
+	kubeClient := kubeFake.NewSimpleClientset(pod)
+	indexers := cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}
+	configMaps := cache.NewIndexer(cache.MetaNamespaceKeyFunc, indexers)
+	c := &Controller{
+		kubeClient: kubeClient,
+		chopClient: chopFake.NewSimpleClientset(&api.ClickHouseInstallation{
+			ObjectMeta: meta.ObjectMeta{Namespace: chi.Namespace, Name: chi.Name},
+		}),
+		configMapLister: coreListers.NewConfigMapLister(configMaps),
+		serviceLister:   coreListers.NewServiceLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, indexers)),
+	}
+	w := &worker{c: c, a: NewAnnouncer().WithController(c), task: newTask(chiCreator.NewCreator(chi))}
+	ctx := context.Background()
+	hang := func(ctx context.Context, host *api.ChiHost) error {
+		// Host is excluded from the cluster and is not included back within expired context
+		host.GetReconcileAttributes().SetExclude()
+		_ = w.c.deleteLabelReadyPod(ctx, host)
+		<-ctx.Done()
+		return nil
+	}
+	complete := func(ctx context.Context, host *api.ChiHost) error {
+		return nil
+	}
+
+	// Hanging host aborts reconcile and is recorded as failed
+	err := w.reconcileHostWithTimeout(ctx, host, hang)
+	require.ErrorIs(t, err, errHostTimeout)
+	require.NotEmpty(t, chi.EnsureStatus().GetHostFailure(host.GetName()))
+	require.Equal(t, 1, chi.EnsureStatus().GetHostsFailedCount())
+
+	// Host is included back into the cluster
+	require.False(t, host.GetReconcileAttributes().IsExclude())
+	pod, err = kubeClient.CoreV1().Pods("ns").Get(ctx, pod.Name, meta.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, model.LabelReadyValueReady, pod.Labels[model.LabelReadyName])
+	cms, err := kubeClient.CoreV1().ConfigMaps("ns").List(ctx, meta.ListOptions{})
+	require.NoError(t, err)
+	for i := range cms.Items {
+		require.NoError(t, configMaps.Add(&cms.Items[i]))
+	}
+
+	// Reconcile may proceed to the next host
+	chi.Spec.Reconciling.OnHostTimeout = api.OnHostTimeoutActionContinue
+	require.NoError(t, w.reconcileHostWithTimeout(ctx, host, hang))
+	require.Equal(t, 2, chi.EnsureStatus().GetHostsFailedCount())
+	require.False(t, host.GetReconcileAttributes().IsExclude())
+
+	// Host, which completes in time, clears failure
+	require.NoError(t, w.reconcileHostWithTimeout(ctx, host, complete))
+	require.Empty(t, chi.EnsureStatus().GetHostFailure(host.GetName()))
+
+	var reasons []string
+	for _, action := range kubeClient.Actions() {
+		if create, ok := action.(k8sTesting.CreateAction); ok && (action.GetResource().Resource == "events") {
+			if reason := create.GetObject().(*core.Event).Reason; reason == eventReasonHostReconcileTimeout {
+				reasons = append(reasons, reason)
+			}
+		}
+	}
+	require.Len(t, reasons, 2)
+}
+
+func Test_ReconcileHostWithFailurePolicy(t *testing.T) {
+	host := newTestShard(1)[0]
+	chi := host.GetCHI()
+	chi.Namespace = "ns"
+	chi.Name = "chi"
+	chi.Spec.Reconciling = &api.ChiReconciling{
+		HostTimeout:   60,
+		OnHostTimeout: api.OnHostTimeoutActionAbort,
+	}
+	c := &Controller{
+		kubeClient: kubeFake.NewSimpleClientset(),
+		chopClient: chopFake.NewSimpleClientset(&api.ClickHouseInstallation{
+			ObjectMeta: meta.ObjectMeta{Namespace: chi.Namespace, Name: chi.Name},
+		}),
+	}
+	w := &worker{c: c, a: NewAnnouncer().WithController(c)}
+	ctx := context.Background()
+	backoff := hostFailureRetryBackoff
+	hostFailureRetryBackoff = time.Millisecond
+	defer func() {
+		hostFailureRetryBackoff = backoff
+	}()
+	attempts := 0
+	failing := func(failures int) func(ctx context.Context, host *api.ChiHost) error {
+		attempts = 0
+		return func(ctx context.Context, host *api.ChiHost) error {
+			attempts++
+			if attempts <= failures {
+				return fmt.Errorf("failure %d", attempts)
+			}
+			return nil
+		}
+	}
+
+	// Abort is the default
+	err := w.reconcileHostWithFailurePolicy(ctx, host, failing(1))
+	require.EqualError(t, err, "failure 1")
+	require.Equal(t, 1, attempts)
+	require.Equal(t, "failure 1", chi.EnsureStatus().GetHostFailure(host.GetName()))
+
+	// Failed host is recorded and reconcile proceeds
+	chi.Spec.Reconciling.OnHostFailure = &api.ChiHostFailurePolicy{Action: api.HostFailureActionContinue}
+	require.NoError(t, w.reconcileHostWithFailurePolicy(ctx, host, failing(1)))
+	require.Equal(t, 1, attempts)
+	require.Equal(t, []string{host.GetName()}, chi.EnsureStatus().GetFailedHosts())
+	cur, err := c.chopClient.ClickhouseV1().ClickHouseInstallations("ns").Get(ctx, "chi", meta.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []string{host.GetName()}, cur.EnsureStatus().GetFailedHosts())
+
+	// Host, which succeeds within retries, clears failure
+	chi.Spec.Reconciling.OnHostFailure = &api.ChiHostFailurePolicy{Action: api.HostFailureActionRetry, Retries: 2}
+	require.NoError(t, w.reconcileHostWithFailurePolicy(ctx, host, failing(2)))
+	require.Equal(t, 3, attempts)
+	require.Empty(t, chi.EnsureStatus().GetFailedHosts())
+	// Cleared failure is propagated into the CHI status
+	cur, err = c.chopClient.ClickhouseV1().ClickHouseInstallations("ns").Get(ctx, "chi", meta.GetOptions{})
+	require.NoError(t, err)
+	require.Empty(t, cur.EnsureStatus().GetFailedHosts())
+
+	// Host, which does not succeed within retries, aborts reconcile
+	err = w.reconcileHostWithFailurePolicy(ctx, host, failing(3))
+	require.EqualError(t, err, "failure 3")
+	require.Equal(t, 3, attempts)
+	require.Equal(t, "failure 3", chi.EnsureStatus().GetHostFailure(host.GetName()))
+}
+
+func Test_ReconcileHostPVC_DataSourceOfShard(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+
+	apiGroup := "snapshot.storage.k8s.io"
+	newCHI := func(provisioner api.PVCProvisioner) *api.ClickHouseInstallation {
+		chi := &api.ClickHouseInstallation{
+			ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "chi"},
+			Spec: api.ChiSpec{
+				Defaults: &api.ChiDefaults{
+					Templates: &api.ChiTemplateNames{DataVolumeClaimTemplate: "data"},
+				},
+				Templates: &api.Templates{
+					VolumeClaimTemplates: []api.VolumeClaimTemplate{
+						{
+							Name:              "data",
+							StorageManagement: api.StorageManagement{PVCProvisioner: provisioner},
+							Spec: core.PersistentVolumeClaimSpec{
+								Resources: core.ResourceRequirements{
+									Requests: core.ResourceList{core.ResourceStorage: resource.MustParse("1Gi")},
+								},
+								DataSource: &core.TypedLocalObjectReference{
+									APIGroup: &apiGroup,
+									Kind:     "VolumeSnapshot",
+									Name:     "data-snapshot-{shard}",
+								},
+							},
+						},
+					},
+				},
+				Configuration: &api.Configuration{
+					Clusters: []*api.Cluster{{Name: "cluster", Layout: &api.ChiClusterLayout{ShardsCount: 2}}},
+				},
+			},
+		}
+		chi, err := normalizer.NewNormalizer(nil).CreateTemplatedCHI(chi, normalizer.NewOptions())
+		require.NoError(t, err)
+		return chi
+	}
+	newWorker := func(chi *api.ClickHouseInstallation, kubeClient *kubeFake.Clientset) *worker {
+		return &worker{
+			c: &Controller{
+				kubeClient: kubeClient,
+				statefulSetLister: appsListers.NewStatefulSetLister(
+					cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}),
+				),
+			},
+			a:    NewAnnouncer(),
+			task: newTask(chiCreator.NewCreator(chi)),
+		}
+	}
+	ctx := context.Background()
+
+	// PVC created by the operator for a new host is provisioned from the snapshot of the host's shard
+	chi := newCHI(api.PVCProvisionerOperator)
+	kubeClient := kubeFake.NewSimpleClientset()
+	w := newWorker(chi, kubeClient)
+	for shard := 0; shard < 2; shard++ {
+		host := chi.FindHost("cluster", shard, 0)
+		require.NotNil(t, host)
+		w.prepareHostStatefulSetWithStatus(ctx, host, false)
+		require.Empty(t, host.Runtime.DesiredStatefulSet.Spec.VolumeClaimTemplates)
+		require.Nil(t, w.reconcilePVCs(ctx, host, api.DesiredStatefulSet))
+
+		pvc, err := kubeClient.CoreV1().PersistentVolumeClaims("ns").Get(ctx, "data-"+model.CreatePodName(host), meta.GetOptions{})
+		require.NoError(t, err)
+		require.Equal(t, &core.TypedLocalObjectReference{
+			APIGroup: &apiGroup,
+			Kind:     "VolumeSnapshot",
+			Name:     fmt.Sprintf("data-snapshot-%d", shard),
+		}, pvc.Spec.DataSource)
+	}
+	// Template of the CHI is not modified
+	require.Equal(t, "data-snapshot-{shard}", chi.Spec.Templates.VolumeClaimTemplates[0].Spec.DataSource.Name)
+
+	// PVC created by StatefulSet for a new host is provisioned from the snapshot of the host's shard
+	chi = newCHI(api.PVCProvisionerStatefulSet)
+	w = newWorker(chi, kubeFake.NewSimpleClientset())
+	for shard := 0; shard < 2; shard++ {
+		host := chi.FindHost("cluster", shard, 0)
+		require.NotNil(t, host)
+		w.prepareHostStatefulSetWithStatus(ctx, host, false)
+		templates := host.Runtime.DesiredStatefulSet.Spec.VolumeClaimTemplates
+		require.Len(t, templates, 1)
+		require.Equal(t, fmt.Sprintf("data-snapshot-%d", shard), templates[0].Spec.DataSource.Name)
+	}
+}
+
+func Test_ReconcileHostInterserverService_Lifecycle(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "chi"},
+		Spec: api.ChiSpec{
+			Defaults: &api.ChiDefaults{InterserverService: api.NewStringBool(true)},
+			Configuration: &api.Configuration{
+				Clusters: []*api.Cluster{{Name: "cluster", Layout: &api.ChiClusterLayout{ReplicasCount: 2}}},
+			},
+		},
+	}
+	chi, err := normalizer.NewNormalizer(nil).CreateTemplatedCHI(chi, normalizer.NewOptions())
+	require.NoError(t, err)
+	host := chi.FindHost("cluster", 0, 0)
+	require.NotNil(t, host)
+
+	kubeClient := kubeFake.NewSimpleClientset()
+	w := &worker{
+		c: &Controller{
+			kubeClient: kubeClient,
+			serviceLister: coreListers.NewServiceLister(
+				cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}),
+			),
+		},
+		a:    NewAnnouncer(),
+		task: newTask(chiCreator.NewCreator(chi)),
+	}
+	ctx := context.Background()
+	getService := func() (*core.Service, error) {
+		return kubeClient.CoreV1().Services("ns").Get(ctx, "chi-chi-cluster-0-0-is", meta.GetOptions{})
+	}
+
+	// Reconciled host gets its own interserver Service, which routes interserver port to the pod of the host only
+	require.NoError(t, w.reconcileHostInterserverService(ctx, host))
+	service, err := getService()
+	require.NoError(t, err)
+	require.Equal(t, core.ClusterIPNone, service.Spec.ClusterIP)
+	require.True(t, service.Spec.PublishNotReadyAddresses)
+	require.Len(t, service.Spec.Ports, 1)
+	require.Equal(t, model.ChDefaultInterserverHTTPPortNumber, service.Spec.Ports[0].Port)
+	require.Equal(t, model.ChDefaultInterserverHTTPPortNumber, service.Spec.Ports[0].TargetPort.IntVal)
+	require.NotEmpty(t, service.Spec.Selector)
+	podLabels := w.task.creator.CreateStatefulSet(host, false).Spec.Template.Labels
+	for name, value := range service.Spec.Selector {
+		require.Equal(t, value, podLabels[name], name)
+	}
+	otherPodLabels := w.task.creator.CreateStatefulSet(chi.FindHost("cluster", 0, 1), false).Spec.Template.Labels
+	require.NotSubset(t, otherPodLabels, service.Spec.Selector)
+
+	// Replicas fetch parts of the host via the interserver Service
+	config := ""
+	for _, file := range w.task.creator.CreateConfigMapHost(host).Data {
+		config += file
+	}
+	require.Contains(t, config, "<interserver_http_host>chi-chi-cluster-0-0-is</interserver_http_host>")
+
+	// Removed host does not leave its interserver Service behind
+	require.NoError(t, w.c.deleteHost(ctx, host))
+	_, err = getService()
+	require.True(t, apiErrors.IsNotFound(err))
+
+	// Interserver Service is deleted once disabled
+	require.NoError(t, w.reconcileHostInterserverService(ctx, host))
+	_, err = getService()
+	require.NoError(t, err)
+	chi.Spec.Defaults.InterserverService = api.NewStringBool(false)
+	require.NoError(t, w.reconcileHostInterserverService(ctx, host))
+	_, err = getService()
+	require.True(t, apiErrors.IsNotFound(err))
+}
+
+func Test_ReconcileShard_PodDisruptionBudget(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "chi"},
+		Spec: api.ChiSpec{
+			Configuration: &api.Configuration{
+				Clusters: []*api.Cluster{{Name: "cluster", Layout: &api.ChiClusterLayout{ShardsCount: 2, ReplicasCount: 2}}},
+			},
+		},
+	}
+	chi, err := normalizer.NewNormalizer(nil).CreateTemplatedCHI(chi, normalizer.NewOptions())
+	require.NoError(t, err)
+	shard := chi.FindShard("cluster", 1)
+	require.NotNil(t, shard)
+
+	kubeClient := kubeFake.NewSimpleClientset()
+	w := &worker{
+		c: &Controller{
+			kubeClient: kubeClient,
+			serviceLister: coreListers.NewServiceLister(
+				cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}),
+			),
+		},
+		a:    NewAnnouncer(),
+		task: newTask(chiCreator.NewCreator(chi)),
+	}
+	ctx := context.Background()
+	getPDB := func() (*policy.PodDisruptionBudget, error) {
+		return kubeClient.PolicyV1().PodDisruptionBudgets("ns").Get(ctx, "chi-cluster-1", meta.GetOptions{})
+	}
+
+	// Reconciled shard gets PDB of its own, which lets one replica of the shard be disrupted at a time
+	require.NoError(t, w.reconcileShard(ctx, shard))
+	pdb, err := getPDB()
+	require.NoError(t, err)
+	require.Equal(t, model.GetSelectorShardScope(shard), pdb.Spec.Selector.MatchLabels)
+	require.Equal(t, 1, pdb.Spec.MaxUnavailable.IntValue())
+	require.True(t, w.task.registryReconciled.HasPDB(pdb.ObjectMeta))
+	require.Equal(t, 1, w.task.registryReconciled.NumPDB())
+
+	// PDB of the shard is brought back in line with the spec
+	pdb.Spec.MaxUnavailable = &intstr.IntOrString{Type: intstr.Int, IntVal: 2}
+	_, err = kubeClient.PolicyV1().PodDisruptionBudgets("ns").Update(ctx, pdb, meta.UpdateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, w.reconcileShard(ctx, shard))
+	pdb, err = getPDB()
+	require.NoError(t, err)
+	require.Equal(t, 1, pdb.Spec.MaxUnavailable.IntValue())
+	require.Equal(t, 1, w.task.registryReconciled.NumPDB())
+
+	// PDB of the other shard is not touched
+	_, err = kubeClient.PolicyV1().PodDisruptionBudgets("ns").Get(ctx, "chi-cluster-0", meta.GetOptions{})
+	require.True(t, apiErrors.IsNotFound(err))
+}
+
+func Test_CheckPriorityClasses(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "chi"},
+		Spec: api.ChiSpec{
+			Configuration: &api.Configuration{
+				Clusters: []*api.Cluster{
+					{Name: "present", PriorityClassName: "clickhouse-high", Layout: &api.ChiClusterLayout{ReplicasCount: 2}},
+					{Name: "missing", PriorityClassName: "clickhouse-critical", Layout: &api.ChiClusterLayout{ReplicasCount: 2}},
+					{Name: "default"},
+				},
+			},
+		},
+	}
+	chi, err := normalizer.NewNormalizer(nil).CreateTemplatedCHI(chi, normalizer.NewOptions())
+	require.NoError(t, err)
+
+	kubeClient := kubeFake.NewSimpleClientset(&scheduling.PriorityClass{ObjectMeta: meta.ObjectMeta{Name: "clickhouse-high"}})
+	// Fake client does not support generated names
+	generated := 0
+	kubeClient.PrependReactor("create", "events", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+		event := action.(k8sTesting.CreateAction).GetObject().(*core.Event)
+		generated++
+		event.Name = fmt.Sprintf("%s%d", event.GenerateName, generated)
+		return false, nil, nil
+	})
+	c := &Controller{kubeClient: kubeClient}
+	w := &worker{c: c, a: NewAnnouncer().WithController(c)}
+
+	// Missing PriorityClass is warned about once, PriorityClass in place is not
+	w.checkPriorityClasses(context.Background(), chi)
+	events, err := kubeClient.CoreV1().Events("ns").List(context.Background(), meta.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, events.Items, 1)
+	require.Equal(t, core.EventTypeWarning, events.Items[0].Type)
+	require.Equal(t, eventReasonPriorityClassNotFound, events.Items[0].Reason)
+	require.Contains(t, events.Items[0].Message, "clickhouse-critical")
+	require.NotContains(t, events.Items[0].Message, "clickhouse-high")
+}
+
+func Test_CheckHostNetworkPorts(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "chi"},
+		Spec: api.ChiSpec{
+			Defaults: &api.ChiDefaults{Templates: &api.ChiTemplateNames{PodTemplate: "host-network"}},
+			Configuration: &api.Configuration{
+				Clusters: []*api.Cluster{{Name: "cluster", Layout: &api.ChiClusterLayout{ReplicasCount: 2}}},
+			},
+			Templates: &api.Templates{
+				PodTemplates: []api.PodTemplate{{Name: "host-network", Spec: core.PodSpec{HostNetwork: true}}},
+			},
+		},
+	}
+	chi, err := normalizer.NewNormalizer(nil).CreateTemplatedCHI(chi, normalizer.NewOptions())
+	require.NoError(t, err)
+
+	kubeClient := kubeFake.NewSimpleClientset()
+	// Fake client does not support generated names
+	generated := 0
+	kubeClient.PrependReactor("create", "events", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+		event := action.(k8sTesting.CreateAction).GetObject().(*core.Event)
+		generated++
+		event.Name = fmt.Sprintf("%s%d", event.GenerateName, generated)
+		return false, nil, nil
+	})
+	c := &Controller{
+		kubeClient: kubeClient,
+		chopClient: chopFake.NewSimpleClientset(&api.ClickHouseInstallation{
+			ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "chi"},
+		}),
+	}
+	w := &worker{c: c, a: NewAnnouncer().WithController(c)}
+	getEvents := func() []core.Event {
+		events, err := kubeClient.CoreV1().Events("ns").List(context.Background(), meta.ListOptions{})
+		require.NoError(t, err)
+		return events.Items
+	}
+
+	// Ports distributed over hosts do not conflict
+	w.checkHostNetworkPorts(context.Background(), chi)
+	require.Empty(t, getEvents())
+	require.Empty(t, chi.EnsureStatus().GetErrors())
+
+	// Hosts, which may share a node, using the same ports are reported
+	chi.WalkHosts(func(host *api.ChiHost) error {
+		host.TCPPort = 9000
+		return nil
+	})
+	w.checkHostNetworkPorts(context.Background(), chi)
+	events := getEvents()
+	require.Len(t, events, 1)
+	require.Equal(t, core.EventTypeWarning, events[0].Type)
+	require.Equal(t, eventReasonHostNetworkPortConflict, events[0].Reason)
+	require.Contains(t, events[0].Message, "9000")
+	require.Len(t, chi.EnsureStatus().GetErrors(), 1)
+	require.Contains(t, chi.EnsureStatus().GetErrors()[0], "9000")
+	cur, err := c.chopClient.ClickhouseV1().ClickHouseInstallations("ns").Get(context.Background(), "chi", meta.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, cur.EnsureStatus().GetErrors(), 1)
+}
+
+func Test_ReconcileCHI_NoActionsPublishesObservedGeneration(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+	setNoopMetrics(t)
+	newCHI := func(generation int64) *api.ClickHouseInstallation {
+		// Task ID is set explicitly, otherwise every normalization generates its own one
+		taskID := "task"
+		return &api.ClickHouseInstallation{
+			ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "chi", Generation: generation},
+			Spec: api.ChiSpec{
+				TaskID: &taskID,
+				Configuration: &api.Configuration{
+					Clusters: []*api.Cluster{{Name: "cluster"}},
+				},
+			},
+		}
+	}
+	normalized, err := normalizer.NewNormalizer(nil).CreateTemplatedCHI(newCHI(1), normalizer.NewOptions())
+	require.NoError(t, err)
+	// Completed CHI is read out of the status, so it has no runtime links
+	b, err := json.Marshal(normalized)
+	require.NoError(t, err)
+	completed := &api.ClickHouseInstallation{}
+	require.NoError(t, json.Unmarshal(b, completed))
+
+	// Generation 2 normalizes to the spec completed already, so it requires no reconcile
+	old := newCHI(1)
+	old.SetAncestor(completed)
+	old.EnsureStatus().SetObservedGeneration(1)
+	new := newCHI(2)
+	new.SetAncestor(completed)
+	new.EnsureStatus().SetObservedGeneration(1)
+
+	chopClient := chopFake.NewSimpleClientset(new.DeepCopy())
+	indexers := cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}
+	c := &Controller{
+		kubeClient:  kubeFake.NewSimpleClientset(),
+		chopClient:  chopClient,
+		podLister:   coreListers.NewPodLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, indexers)),
+		secretIndex: newSecretIndex(),
+	}
+	w := &worker{
+		c:          c,
+		a:          NewAnnouncer().WithController(c),
+		normalizer: normalizer.NewNormalizer(nil),
+	}
+
+	require.NoError(t, w.reconcileCHI(context.Background(), old, new))
+	cur, err := chopClient.ClickhouseV1().ClickHouseInstallations("ns").Get(context.Background(), "chi", meta.GetOptions{})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, cur.EnsureStatus().GetObservedGeneration())
+	// Nothing is reconciled, so the rest of the status is kept intact
+	require.NotNil(t, cur.EnsureStatus().GetNormalizedCHICompleted())
+}
@@ -24,6 +24,7 @@ import (
 
 	log "github.com/altinity/clickhouse-operator/pkg/announcer"
 	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/chop"
 	"github.com/altinity/clickhouse-operator/pkg/controller"
 	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
 	"github.com/altinity/clickhouse-operator/pkg/model/chi/normalizer"
@@ -54,6 +55,7 @@ func (w *worker) clean(ctx context.Context, chi *api.ClickHouseInstallation) {
 		w.c.enqueueObject(NewDropDns(&chi.ObjectMeta))
 		util.WaitContextDoneOrTimeout(ctx, 1*time.Minute)
 	}
+	w.deleteRemovedKeeper(ctx, chi)
 
 	chi.EnsureStatus().SyncHostTablesCreated()
 }
@@ -298,6 +300,7 @@ func (w *worker) deleteCHIProtocol(ctx context.Context, chi *api.ClickHouseInsta
 
 	// Exclude this CHI from monitoring
 	w.c.deleteWatch(chi)
+	w.c.health.forget(chi)
 
 	// Delete Service
 	_ = w.c.deleteServiceCHI(ctx, chi)
@@ -307,10 +310,16 @@ func (w *worker) deleteCHIProtocol(ctx context.Context, chi *api.ClickHouseInsta
 		return nil
 	})
 
-	// Delete all clusters
-	chi.WalkClusters(func(cluster *api.Cluster) error {
-		return w.deleteCluster(ctx, chi, cluster)
-	})
+	switch chop.Config().Reconcile.DeletionOrder {
+	case api.DeletionOrderStaged:
+		// Delete all clusters stage by stage
+		w.deleteCHIStaged(ctx, chi)
+	default:
+		// Delete all clusters host by host
+		chi.WalkClusters(func(cluster *api.Cluster) error {
+			return w.deleteCluster(ctx, chi, cluster)
+		})
+	}
 
 	if util.IsContextDone(ctx) {
 		log.V(2).Info("task is done")
@@ -320,6 +329,13 @@ func (w *worker) deleteCHIProtocol(ctx context.Context, chi *api.ClickHouseInsta
 	// Delete ConfigMap(s)
 	_ = w.c.deleteConfigMapsCHI(ctx, chi)
 
+	// Delete Secret of the managed user
+	_ = w.c.deleteSecretManagedUser(ctx, chi)
+
+	// Delete Secret of the generated passwords
+	_ = w.c.deleteSecretGeneratedPasswords(ctx, chi)
+	_ = w.c.deleteSecretInterserverCredentials(ctx, chi)
+
 	w.a.V(1).
 		WithEvent(chi, eventActionDelete, eventReasonDeleteCompleted).
 		WithStatusAction(chi).
@@ -329,6 +345,165 @@ func (w *worker) deleteCHIProtocol(ctx context.Context, chi *api.ClickHouseInsta
 	return nil
 }
 
+const (
+	// Steps of the staged CHI deletion
+	deletionStepTables       = "tables"
+	deletionStepStatefulSets = "statefulSets"
+	deletionStepPVCs         = "pvcs"
+	deletionStepServices     = "services"
+)
+
+// deletionStep specifies one step of the staged CHI deletion
+type deletionStep struct {
+	name string
+	// skipFailedHosts specifies whether hosts, which failed any of the previous steps, are skipped by this step
+	skipFailedHosts bool
+}
+
+// stagedDeletionSteps lists steps of the staged CHI deletion in order of execution.
+// Tables are dropped first in order to clean ZooKeeper. PVCs are deleted only for hosts, which completed all
+// previous steps, in order not to leave ZooKeeper paths behind when storage is destroyed.
+var stagedDeletionSteps = []deletionStep{
+	{name: deletionStepTables},
+	{name: deletionStepStatefulSets},
+	{name: deletionStepPVCs, skipFailedHosts: true},
+	{name: deletionStepServices},
+}
+
+// walkDeletionSteps runs deletion steps one after another. Each step is completed over all hosts
+// before the next step starts. Returns hosts, which failed any of the steps.
+func walkDeletionSteps(
+	steps []deletionStep,
+	hosts []*api.ChiHost,
+	do func(step string, host *api.ChiHost) error,
+	done func(step string, failed []*api.ChiHost),
+) (failed []*api.ChiHost) {
+	isFailed := make(map[*api.ChiHost]bool)
+	for _, step := range steps {
+		var stepFailed []*api.ChiHost
+		for _, host := range hosts {
+			if step.skipFailedHosts && isFailed[host] {
+				continue
+			}
+			if err := do(step.name, host); err != nil {
+				stepFailed = append(stepFailed, host)
+				if !isFailed[host] {
+					isFailed[host] = true
+					failed = append(failed, host)
+				}
+			}
+		}
+		if done != nil {
+			done(step.name, stepFailed)
+		}
+	}
+	return failed
+}
+
+// deleteCHIStaged deletes all clusters of the CHI stage by stage over all hosts
+func (w *worker) deleteCHIStaged(ctx context.Context, chi *api.ClickHouseInstallation) {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return
+	}
+
+	// Hosts without StatefulSet are considered to be already deleted
+	var hosts, deleted []*api.ChiHost
+	chi.WalkHosts(func(host *api.ChiHost) error {
+		var err error
+		if host.Runtime.CurStatefulSet, err = w.c.getStatefulSet(host); err != nil {
+			w.a.V(1).M(host).F().Info("Delete host: %s/%s - StatefulSet not found - already deleted? err: %v",
+				host.Runtime.Address.ClusterName, host.GetName(), err)
+			deleted = append(deleted, host)
+			return nil
+		}
+		hosts = append(hosts, host)
+		return nil
+	})
+
+	// Replicas of already deleted hosts have to be cleaned from Zookeeper by surviving replicas,
+	// before surviving replicas drop their own tables
+	for _, host := range deleted {
+		_ = w.dropReplica(ctx, host)
+	}
+
+	failed := walkDeletionSteps(
+		stagedDeletionSteps,
+		hosts,
+		func(step string, host *api.ChiHost) error {
+			return w.deleteHostStep(ctx, step, host)
+		},
+		func(step string, failed []*api.ChiHost) {
+			if len(failed) == 0 {
+				w.a.V(1).
+					WithEvent(chi, eventActionDelete, eventReasonDeleteInProgress).
+					WithStatusAction(chi).
+					M(chi).F().
+					Info("Delete CHI step: %s - completed on %d hosts", step, len(hosts))
+			} else {
+				w.a.V(1).
+					WithEvent(chi, eventActionDelete, eventReasonDeleteInProgress).
+					WithStatusAction(chi).
+					M(chi).F().
+					Warning("Delete CHI step: %s - failed on %d of %d hosts", step, len(failed), len(hosts))
+			}
+		},
+	)
+
+	for _, host := range failed {
+		w.a.V(1).
+			WithEvent(chi, eventActionDelete, eventReasonDeleteFailed).
+			M(host).F().
+			Warning("Delete host: %s/%s - incomplete, PVCs are kept", host.Runtime.Address.ClusterName, host.GetName())
+	}
+
+	// Delete shard- and cluster-level objects
+	chi.WalkClusters(func(cluster *api.Cluster) error {
+		cluster.WalkShards(func(index int, shard *api.ChiShard) error {
+			return w.c.deleteServiceShard(ctx, shard)
+		})
+		_ = w.c.deleteServiceCluster(ctx, cluster)
+		if cluster.Secret.Source() == api.ClusterSecretSourceAuto {
+			_ = w.c.deleteSecretCluster(ctx, cluster)
+		}
+		return nil
+	})
+
+	for range hosts {
+		chi.EnsureStatus().HostDeleted()
+	}
+	_ = w.c.updateCHIObjectStatus(ctx, chi, UpdateCHIStatusOptions{
+		TolerateAbsence: true,
+		CopyCHIStatusOptions: api.CopyCHIStatusOptions{
+			MainFields: true,
+		},
+	})
+}
+
+// deleteHostStep runs one step of the staged deletion on the host
+func (w *worker) deleteHostStep(ctx context.Context, step string, host *api.ChiHost) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	switch step {
+	case deletionStepTables:
+		// Tables are dropped in order to clean ZooKeeper data
+		return w.deleteTables(ctx, host)
+	case deletionStepStatefulSets:
+		err := w.c.deleteStatefulSet(ctx, host)
+		_ = w.c.deleteConfigMap(ctx, host)
+		return err
+	case deletionStepPVCs:
+		return w.c.deletePVC(ctx, host)
+	case deletionStepServices:
+		_ = w.c.deleteServiceHostInterserver(ctx, host)
+		return w.c.deleteServiceHost(ctx, host)
+	}
+	return nil
+}
+
 // canDropReplica
 func (w *worker) canDropReplica(host *api.ChiHost, opts ...*dropReplicaOptions) (can bool) {
 	o := NewDropReplicaOptionsArr(opts...).First()
@@ -342,7 +517,7 @@ func (w *worker) canDropReplica(host *api.ChiHost, opts ...*dropReplicaOptions)
 		// Replica's state has to be kept in Zookeeper for retained volumes.
 		// ClickHouse expects to have state of the non-empty replica in-place when replica rejoins.
 		if model.GetReclaimPolicy(pvc.ObjectMeta) == api.PVCReclaimPolicyRetain {
-			w.a.V(1).F().Info("PVC: %s/%s blocks drop replica. Reclaim policy: %s", api.PVCReclaimPolicyRetain.String())
+			w.a.V(1).F().Info("PVC: %s/%s blocks drop replica. Reclaim policy: %s", pvc.Namespace, pvc.Name, api.PVCReclaimPolicyRetain.String())
 			can = false
 		}
 	})
@@ -393,18 +568,48 @@ func (w *worker) dropReplica(ctx context.Context, hostToDrop *api.ChiHost, opts
 		return nil
 	}
 
-	// Sometimes host to drop is already unavailable, so let's run SQL statement of the first replica in the shard
-	var hostToRunOn *api.ChiHost
-	if shard := hostToDrop.GetShard(); shard != nil {
-		hostToRunOn = shard.FirstHost()
-	}
-
+	// Sometimes host to drop is already unavailable, so let's run SQL statement on a surviving replica in the shard
+	hostToRunOn := w.getSurvivingReplica(hostToDrop)
 	if hostToRunOn == nil {
 		w.a.V(1).F().Error("FAILED to drop replica. hostToRunOn: %s, hostToDrop: %s", hostToRunOn.GetName(), hostToDrop.GetName())
 		return nil
 	}
 
-	err := w.ensureClusterSchemer(hostToRunOn).HostDropReplica(ctx, hostToRunOn, hostToDrop)
+	return w.doDropReplica(ctx, newReplicaDropper(w, hostToRunOn), hostToRunOn, hostToDrop)
+}
+
+// replicaDropper drops replica's info from Zookeeper
+type replicaDropper interface {
+	HostDropReplica(ctx context.Context, hostToRunOn, hostToDrop *api.ChiHost) error
+}
+
+// newReplicaDropper creates dropper running SQL statements on the specified host
+var newReplicaDropper = func(w *worker, hostToRunOn *api.ChiHost) replicaDropper {
+	return w.ensureClusterSchemer(hostToRunOn)
+}
+
+// getSurvivingReplica finds replica of the shard of the host, which still has StatefulSet in place
+// and thus is able to run SQL statements on behalf of the host
+func (w *worker) getSurvivingReplica(host *api.ChiHost) (survivor *api.ChiHost) {
+	shard := host.GetShard()
+	if shard == nil {
+		return nil
+	}
+	shard.WalkHosts(func(replica *api.ChiHost) error {
+		if (survivor != nil) || (replica == host) || (replica.GetName() == host.GetName()) {
+			return nil
+		}
+		if _, err := w.c.getStatefulSet(replica); err == nil {
+			survivor = replica
+		}
+		return nil
+	})
+	return survivor
+}
+
+// doDropReplica drops replica's info from Zookeeper by running SQL statement on the specified host
+func (w *worker) doDropReplica(ctx context.Context, dropper replicaDropper, hostToRunOn, hostToDrop *api.ChiHost) error {
+	err := dropper.HostDropReplica(ctx, hostToRunOn, hostToDrop)
 
 	if err == nil {
 		w.a.V(1).
@@ -470,6 +675,9 @@ func (w *worker) deleteHost(ctx context.Context, chi *api.ClickHouseInstallation
 
 	var err error
 	if host.Runtime.CurStatefulSet, err = w.c.getStatefulSet(host); err != nil {
+		// StatefulSet may be deleted bypassing the operator, ex.: manually,
+		// still replica of the host has to be cleaned from Zookeeper
+		_ = w.dropReplica(ctx, host)
 		w.a.WithEvent(host.GetCHI(), eventActionDelete, eventReasonDeleteCompleted).
 			WithStatusAction(host.GetCHI()).
 			M(host).F().
@@ -603,6 +811,20 @@ func (w *worker) deleteCHI(ctx context.Context, old, new *api.ClickHouseInstalla
 	w.a.V(3).M(new).S().P()
 	defer w.a.V(3).M(new).E().P()
 
+	// When the whole namespace is being deleted, k8s deletes all child resources of the CHI anyway,
+	// and ClickHouse may already be gone, so cleanup is futile and would only block deletion of the namespace.
+	if w.isNamespaceTerminating(ctx, new.Namespace) {
+		w.a.V(1).
+			WithEvent(new, eventActionDelete, eventReasonDeleteSkipped).
+			M(new).F().
+			Info("Namespace %s is terminating, skip cleanup of CHI %s/%s", new.Namespace, new.Namespace, new.Name)
+		w.a.V(2).M(new).F().Info("uninstall finalizer")
+		if err := w.c.uninstallFinalizer(ctx, new); err != nil {
+			w.a.V(1).M(new).F().Error("unable to uninstall finalizer. err: %v", err)
+		}
+		return true
+	}
+
 	// Ok, we have pending request for CHI to be deleted.
 	// However, we need to decide, should CHI's child resources be deleted or not.
 	// There is a curious situation, when CRD is deleted and k8s starts to delete all resources of the type,
@@ -662,6 +884,16 @@ func (w *worker) deleteCHI(ctx context.Context, old, new *api.ClickHouseInstalla
 	return true
 }
 
+// isNamespaceTerminating checks whether specified namespace is being deleted
+func (w *worker) isNamespaceTerminating(ctx context.Context, namespace string) bool {
+	ns, err := w.c.kubeClient.CoreV1().Namespaces().Get(ctx, namespace, controller.NewGetOptions())
+	if err != nil {
+		// Unable to tell, consider namespace to be alive
+		return false
+	}
+	return (ns.Status.Phase == core.NamespaceTerminating) || !ns.ObjectMeta.DeletionTimestamp.IsZero()
+}
+
 func (w *worker) isLostPV(pvc *core.PersistentVolumeClaim) bool {
 	if pvc == nil {
 		return false
@@ -0,0 +1,179 @@
+package chi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+	k8sTesting "k8s.io/client-go/testing"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	chopFake "github.com/altinity/clickhouse-operator/pkg/client/clientset/versioned/fake"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+)
+
+func Test_WalkDeletionSteps_Order(t *testing.T) {
+	hosts := []*api.ChiHost{{Name: "host-0"}, {Name: "host-1"}}
+
+	var calls []string
+	var stepsDone []string
+	failed := walkDeletionSteps(
+		stagedDeletionSteps,
+		hosts,
+		func(step string, host *api.ChiHost) error {
+			calls = append(calls, step+":"+host.Name)
+			return nil
+		},
+		func(step string, failed []*api.ChiHost) {
+			stepsDone = append(stepsDone, step)
+		},
+	)
+
+	require.Empty(t, failed)
+	require.Equal(t, []string{
+		deletionStepTables, deletionStepStatefulSets, deletionStepPVCs, deletionStepServices,
+	}, stepsDone)
+	// Each step is completed over all hosts before the next step starts
+	require.Equal(t, []string{
+		"tables:host-0", "tables:host-1",
+		"statefulSets:host-0", "statefulSets:host-1",
+		"pvcs:host-0", "pvcs:host-1",
+		"services:host-0", "services:host-1",
+	}, calls)
+}
+
+func Test_WalkDeletionSteps_ZooKeeperCleanupPrecedesPVCDeletion(t *testing.T) {
+	hosts := []*api.ChiHost{{Name: "host-0"}, {Name: "host-1"}, {Name: "host-2"}}
+
+	tablesDropped := make(map[string]bool)
+	var pvcsDeleted []string
+	failed := walkDeletionSteps(
+		stagedDeletionSteps,
+		hosts,
+		func(step string, host *api.ChiHost) error {
+			switch step {
+			case deletionStepTables:
+				if host.Name == "host-1" {
+					return fmt.Errorf("unable to drop tables")
+				}
+				tablesDropped[host.Name] = true
+			case deletionStepPVCs:
+				// Tables have to be dropped on all hosts before any PVC is deleted
+				require.Len(t, tablesDropped, 2)
+				pvcsDeleted = append(pvcsDeleted, host.Name)
+			}
+			return nil
+		},
+		nil,
+	)
+
+	// PVCs of the host, which failed to drop tables, are kept
+	require.Equal(t, []*api.ChiHost{hosts[1]}, failed)
+	require.Equal(t, []string{"host-0", "host-2"}, pvcsDeleted)
+}
+
+type testReplicaDropper struct {
+	dropped []string
+}
+
+func (d *testReplicaDropper) HostDropReplica(ctx context.Context, hostToRunOn, hostToDrop *api.ChiHost) error {
+	d.dropped = append(d.dropped, hostToRunOn.GetName()+":"+hostToDrop.GetName())
+	return nil
+}
+
+// fakeReplicaDropper makes replicas be dropped by the returned dropper instead of ClickHouse
+func fakeReplicaDropper(t *testing.T) *testReplicaDropper {
+	dropper := &testReplicaDropper{}
+	newDropper := newReplicaDropper
+	newReplicaDropper = func(w *worker, hostToRunOn *api.ChiHost) replicaDropper {
+		return dropper
+	}
+	t.Cleanup(func() {
+		newReplicaDropper = newDropper
+	})
+	return dropper
+}
+
+func Test_DeleteHost_StatefulSetAbsent(t *testing.T) {
+	hosts := newTestShard(3)
+	chi := hosts[0].GetCHI()
+	chi.ObjectMeta = meta.ObjectMeta{Namespace: "ns", Name: "chi"}
+	for _, host := range hosts {
+		host.Runtime.Address.Namespace = "ns"
+		host.Runtime.Address.CHIName = "chi"
+		host.Runtime.Address.HostName = host.Name
+	}
+
+	// StatefulSets of the first two hosts are deleted bypassing the operator
+	kubeClient := kubeFake.NewSimpleClientset(
+		&apps.StatefulSet{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: model.CreateStatefulSetName(hosts[2])}},
+	)
+	c := &Controller{
+		kubeClient: kubeClient,
+		chopClient: chopFake.NewSimpleClientset(&api.ClickHouseInstallation{ObjectMeta: chi.ObjectMeta}),
+	}
+	w := &worker{c: c, a: NewAnnouncer().WithController(c)}
+	dropper := fakeReplicaDropper(t)
+	ctx := context.Background()
+
+	// Host being removed from the CHI is cleaned from Zookeeper via surviving replica
+	require.NoError(t, w.deleteHost(ctx, chi, hosts[0]))
+	require.Equal(t, []string{"0-2:0-0"}, dropper.dropped)
+	require.NoError(t, w.deleteHost(ctx, chi, hosts[1]))
+	require.Equal(t, []string{"0-2:0-0", "0-2:0-1"}, dropper.dropped)
+
+	// No surviving replicas - nobody to run DROP REPLICA on
+	require.NoError(t, kubeClient.AppsV1().StatefulSets("ns").Delete(ctx, model.CreateStatefulSetName(hosts[2]), meta.DeleteOptions{}))
+	require.NoError(t, w.deleteHost(ctx, chi, hosts[0]))
+	require.Len(t, dropper.dropped, 2)
+}
+
+func Test_DeleteCHI_NamespaceTerminating(t *testing.T) {
+	now := meta.Now()
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace:         "ns",
+			Name:              "chi",
+			DeletionTimestamp: &now,
+			Finalizers:        []string{FinalizerName},
+		},
+	}
+	namespace := &core.Namespace{
+		ObjectMeta: meta.ObjectMeta{
+			Name: "ns",
+		},
+	}
+	kubeClient := kubeFake.NewSimpleClientset(namespace)
+	chopClient := chopFake.NewSimpleClientset(chi)
+	c := &Controller{kubeClient: kubeClient, chopClient: chopClient}
+	w := &worker{c: c, a: NewAnnouncer().WithController(c)}
+	ctx := context.Background()
+
+	// Namespace is alive
+	require.False(t, w.isNamespaceTerminating(ctx, "ns"))
+	// Namespace is absent
+	require.False(t, w.isNamespaceTerminating(ctx, "absent"))
+
+	// Terminating namespace does not block deletion of the CHI
+	namespace.Status.Phase = core.NamespaceTerminating
+	_, err := kubeClient.CoreV1().Namespaces().Update(ctx, namespace, meta.UpdateOptions{})
+	require.NoError(t, err)
+	require.True(t, w.deleteCHI(ctx, chi, chi))
+
+	cur, err := chopClient.ClickhouseV1().ClickHouseInstallations("ns").Get(ctx, "chi", meta.GetOptions{})
+	require.NoError(t, err)
+	require.NotContains(t, cur.ObjectMeta.Finalizers, FinalizerName)
+
+	var reasons []string
+	for _, action := range kubeClient.Actions() {
+		if create, ok := action.(k8sTesting.CreateAction); ok && (action.GetResource().Resource == "events") {
+			reasons = append(reasons, create.GetObject().(*core.Event).Reason)
+		}
+	}
+	require.Equal(t, []string{eventReasonDeleteSkipped}, reasons)
+}
@@ -0,0 +1,113 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
+// dryRunHosts specifies hosts, which would be affected by reconcile, referenced by StatefulSet names
+type dryRunHosts struct {
+	add    []string
+	modify []string
+	remove []string
+}
+
+// String stringifies dry-run hosts
+func (h *dryRunHosts) String() string {
+	list := func(names []string) string {
+		if len(names) == 0 {
+			return "none"
+		}
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf(
+		"hosts to add: %s\nhosts to modify: %s\nhosts to remove: %s\n",
+		list(h.add), list(h.modify), list(h.remove),
+	)
+}
+
+// dryRunCHI previews reconcile of the CHI without applying anything.
+// ActionPlan along with hosts to be added, modified and removed is published in status and reported as an event.
+func (w *worker) dryRunCHI(ctx context.Context, chi *api.ClickHouseInstallation, ap *model.ActionPlan) {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return
+	}
+
+	w.newTask(chi)
+	w.walkHosts(ctx, chi, ap)
+	hosts := w.getDryRunHosts(ctx, chi, ap)
+
+	chi.EnsureStatus().PublishDryRunPlan(hosts.String() + ap.String())
+	_ = w.c.updateCHIObjectStatus(ctx, chi, UpdateCHIStatusOptions{
+		CopyCHIStatusOptions: api.CopyCHIStatusOptions{
+			MainFields: true,
+		},
+	})
+
+	w.a.V(1).
+		WithEvent(chi, eventActionReconcile, eventReasonDryRun).
+		M(chi).F().
+		Info("Dry run. Hosts to add: %d, modify: %d, remove: %d. ActionPlan is published in status",
+			len(hosts.add), len(hosts.modify), len(hosts.remove))
+}
+
+// getDryRunHosts finds hosts, which would be affected by reconcile.
+// Hosts are expected to be walked by walkHosts already.
+func (w *worker) getDryRunHosts(ctx context.Context, chi *api.ClickHouseInstallation, ap *model.ActionPlan) *dryRunHosts {
+	hosts := &dryRunHosts{}
+
+	chi.WalkHosts(func(host *api.ChiHost) error {
+		if host.GetReconcileAttributes().IsAdd() {
+			hosts.add = append(hosts.add, model.CreateStatefulSetName(host))
+			return nil
+		}
+		// Compare desired StatefulSet with the one in place, the same way reconcile does
+		w.prepareHostStatefulSetWithStatus(ctx, host, host.IsStopped())
+		switch host.GetReconcileAttributes().GetStatus() {
+		case api.ObjectStatusNew:
+			hosts.add = append(hosts.add, model.CreateStatefulSetName(host))
+		case api.ObjectStatusModified, api.ObjectStatusUnknown:
+			hosts.modify = append(hosts.modify, model.CreateStatefulSetName(host))
+		}
+		return nil
+	})
+
+	remove := func(host *api.ChiHost) error {
+		hosts.remove = append(hosts.remove, model.CreateStatefulSetName(host))
+		return nil
+	}
+	ap.WalkRemoved(
+		func(cluster *api.Cluster) {
+			cluster.WalkHosts(remove)
+		},
+		func(shard *api.ChiShard) {
+			shard.WalkHosts(remove)
+		},
+		func(host *api.ChiHost) {
+			_ = remove(host)
+		},
+	)
+
+	return hosts
+}
@@ -0,0 +1,32 @@
+package chi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+)
+
+func Test_GetDryRunHosts(t *testing.T) {
+	hosts := newTestShard(2)
+	chi := hosts[0].GetCHI()
+	for _, host := range hosts {
+		host.GetReconcileAttributes().SetAdd()
+	}
+	w := &worker{
+		a: NewAnnouncer(),
+	}
+
+	dryRun := w.getDryRunHosts(context.Background(), chi, model.NewActionPlan(chi, chi))
+	require.Equal(t, []string{model.CreateStatefulSetName(hosts[0]), model.CreateStatefulSetName(hosts[1])}, dryRun.add)
+	require.Empty(t, dryRun.modify)
+	require.Empty(t, dryRun.remove)
+	require.Equal(t,
+		"hosts to add: "+model.CreateStatefulSetName(hosts[0])+", "+model.CreateStatefulSetName(hosts[1])+"\n"+
+			"hosts to modify: none\n"+
+			"hosts to remove: none\n",
+		dryRun.String(),
+	)
+}
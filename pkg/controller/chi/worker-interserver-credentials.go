@@ -0,0 +1,285 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/controller"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
+// interserverUsername specifies username hosts authenticate with to fetch parts from each other
+const interserverUsername = "interserver"
+
+// reconcileInterserverCredentials reconciles the Secret interserver credentials are kept in
+// and sets state of the credentials hosts are to run with.
+// Credentials are generated in case there are none yet and rotated in case rotation token is changed.
+// New credentials are distributed to the hosts alongside current ones, so hosts are rolled
+// with no interruption of replication. Rotation is completed by finalizeInterserverCredentials.
+func (w *worker) reconcileInterserverCredentials(ctx context.Context, chi *api.ClickHouseInstallation) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	spec := chi.Spec.Configuration.GetInterserverCredentials()
+	if !spec.IsEnabled() {
+		return nil
+	}
+
+	secret := w.task.creator.CreateInterserverCredentialsSecret(nil)
+	cur, err := w.c.getSecret(secret)
+	switch {
+	case apiErrors.IsNotFound(err):
+		secret.Data = startInterserverCredentialsRotation(nil, spec.GetRotation())
+		err = w.createSecret(ctx, chi, secret)
+	case err == nil:
+		secret = cur.DeepCopy()
+		if data := startInterserverCredentialsRotation(cur.Data, spec.GetRotation()); data != nil {
+			w.a.V(1).M(chi).F().Info("Rotate interserver credentials of CHI %s/%s", chi.Namespace, chi.Name)
+			secret.Data = data
+			_, err = w.c.kubeClient.CoreV1().Secrets(secret.Namespace).Update(ctx, secret, controller.NewUpdateOptions())
+		}
+	}
+
+	if err != nil {
+		w.task.registryFailed.RegisterSecret(secret.ObjectMeta)
+		w.a.M(chi).F().Error("unable to reconcile interserver credentials Secret %s/%s err: %v", secret.Namespace, secret.Name, err)
+		return err
+	}
+	w.task.registryReconciled.RegisterSecret(secret.ObjectMeta)
+	chi.EnsureRuntime().SetInterserverCredentials(getInterserverCredentialsState(secret.Data))
+	return nil
+}
+
+// finalizeInterserverCredentials completes rotation of interserver credentials in progress, if any.
+// Expected to be called after all hosts are rolled, thus have new credentials available.
+// Hosts are switched to new credentials and previous credentials are dropped by config updates,
+// which ClickHouse picks up on the fly, so hosts are not restarted.
+func (w *worker) finalizeInterserverCredentials(ctx context.Context, chi *api.ClickHouseInstallation) {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return
+	}
+
+	if !chi.Spec.Configuration.GetInterserverCredentials().IsEnabled() {
+		return
+	}
+
+	secret, err := w.c.getSecret(w.task.creator.CreateInterserverCredentialsSecret(nil))
+	if err != nil {
+		w.a.M(chi).F().Error("unable to get interserver credentials Secret of CHI %s/%s err: %v", chi.Namespace, chi.Name, err)
+		return
+	}
+	secret = secret.DeepCopy()
+
+	if len(secret.Data[model.InterserverCredentialsPhaseKey]) > 0 {
+		// Hosts are switched to new credentials only as all of them are rolled with new credentials available
+		revision := string(secret.Data[model.InterserverCredentialsRevisionKey])
+		if hosts := w.getHostsWithoutInterserverCredentialsRevision(chi, revision); len(hosts) > 0 {
+			w.a.V(1).M(chi).F().Info("Rotation of interserver credentials of CHI %s/%s waits for hosts to be rolled: %s",
+				chi.Namespace, chi.Name, strings.Join(hosts, ","))
+			w.c.requeueCHI(chi, interserverCredentialsRolloutRecheckInterval)
+			return
+		}
+	}
+
+	for {
+		data := advanceInterserverCredentialsRotation(secret.Data)
+		if data == nil {
+			// No rotation in progress
+			break
+		}
+
+		// Config is updated prior to the Secret, so hosts never refer to credentials dropped from the Secret
+		chi.EnsureRuntime().SetInterserverCredentials(getInterserverCredentialsState(data))
+		chi.EnsureRuntime().LockCommonConfig()
+		err = w.reconcileCHIConfigMapCommon(ctx, chi, w.options())
+		chi.EnsureRuntime().UnlockCommonConfig()
+		if err != nil {
+			w.a.M(chi).F().Error("unable to switch interserver credentials of CHI %s/%s err: %v", chi.Namespace, chi.Name, err)
+			return
+		}
+
+		secret.Data = data
+		if secret, err = w.updateInterserverCredentialsSecret(ctx, secret); err != nil {
+			w.a.M(chi).F().Error("unable to update interserver credentials Secret of CHI %s/%s err: %v", chi.Namespace, chi.Name, err)
+			return
+		}
+		w.a.V(1).M(chi).F().Info("Interserver credentials of CHI %s/%s advanced to phase: %q",
+			chi.Namespace, chi.Name, string(data[model.InterserverCredentialsPhaseKey]))
+
+		if string(data[model.InterserverCredentialsPhaseKey]) == model.InterserverCredentialsPhaseSwitching {
+			// Hosts, which have not picked up switched config yet, authenticate with previous credentials,
+			// so previous credentials are dropped after config is propagated to all hosts
+			timeout := chi.GetReconciling().GetConfigMapPropagationTimeoutDuration()
+			w.a.V(1).M(chi).F().Info("Wait for ConfigMap propagation for %s", timeout)
+			if util.WaitContextDoneOrTimeout(ctx, timeout) {
+				log.V(2).Info("task is done")
+				return
+			}
+		}
+	}
+
+	if startInterserverCredentialsRotation(secret.Data, chi.Spec.Configuration.GetInterserverCredentials().GetRotation()) != nil {
+		// Rotation token was changed while previous rotation was in progress
+		w.a.V(1).M(chi).F().Info("Interserver credentials of CHI %s/%s are to be rotated again", chi.Namespace, chi.Name)
+		w.c.requeueCHI(chi, 0)
+	}
+}
+
+// interserverCredentialsRolloutRecheckInterval specifies how often hosts are checked to be rolled with new interserver credentials
+const interserverCredentialsRolloutRecheckInterval = time.Minute
+
+// getHostsWithoutInterserverCredentialsRevision gets names of running hosts, which pods are not rolled
+// with the specified revision of interserver credentials yet
+func (w *worker) getHostsWithoutInterserverCredentialsRevision(chi *api.ClickHouseInstallation, revision string) (hosts []string) {
+	chi.WalkHosts(func(host *api.ChiHost) error {
+		if host.IsStopped() {
+			// Stopped host has no pod and gets up-to-date credentials as started
+			return nil
+		}
+		pod, err := w.c.getPod(host)
+		if (err != nil) || (pod.Annotations[model.AnnotationInterserverCredentialsRevision] != revision) {
+			hosts = append(hosts, host.GetName())
+		}
+		return nil
+	})
+	return hosts
+}
+
+// ensureInterserverCredentialsState sets state of interserver credentials hosts run with out of the Secret,
+// in case the state is not set by the reconcile. Every path rendering config of the hosts is expected to call it,
+// so interserver credentials are never dropped from the config.
+func (w *worker) ensureInterserverCredentialsState(chi *api.ClickHouseInstallation) {
+	if !chi.Spec.Configuration.GetInterserverCredentials().IsEnabled() {
+		return
+	}
+	if chi.EnsureRuntime().GetInterserverCredentials() != nil {
+		// State is set already
+		return
+	}
+	secret, err := w.c.getSecret(w.task.creator.CreateInterserverCredentialsSecret(nil))
+	if err != nil {
+		// Credentials are not generated yet
+		w.a.V(1).M(chi).F().Info("No interserver credentials of CHI %s/%s yet err: %v", chi.Namespace, chi.Name, err)
+		return
+	}
+	chi.EnsureRuntime().SetInterserverCredentials(getInterserverCredentialsState(secret.Data))
+}
+
+// updateInterserverCredentialsSecret updates the Secret interserver credentials are kept in
+func (w *worker) updateInterserverCredentialsSecret(ctx context.Context, secret *core.Secret) (*core.Secret, error) {
+	updated, err := w.c.kubeClient.CoreV1().Secrets(secret.Namespace).Update(ctx, secret, controller.NewUpdateOptions())
+	if err != nil {
+		return nil, err
+	}
+	return updated.DeepCopy(), nil
+}
+
+// startInterserverCredentialsRotation makes data of the Secret with new interserver credentials.
+// New credentials are generated in case there are none yet or rotation token is changed.
+// Rotation in progress is never interrupted, changed rotation token is picked up after it is completed.
+// Returns nil in case no rotation is to be started.
+func startInterserverCredentialsRotation(cur map[string][]byte, rotation string) map[string][]byte {
+	if len(cur[model.InterserverCredentialsPhaseKey]) > 0 {
+		// Rotation is in progress
+		return nil
+	}
+
+	active := string(cur[model.InterserverCredentialsActiveKey])
+	_, hasActive := cur[model.InterserverCredentialsPasswordKeyPrefix+active]
+	if hasActive && (string(cur[model.InterserverCredentialsRotationKey]) == rotation) {
+		// Credentials are in place and are not to be rotated
+		return nil
+	}
+
+	// New credentials are put into the slot, which is not used by the hosts
+	revision, _ := strconv.Atoi(string(cur[model.InterserverCredentialsRevisionKey]))
+	slot, phase := model.InterserverCredentialsSlot0, model.InterserverCredentialsPhaseInitial
+	if hasActive {
+		slot, phase = model.InterserverCredentialsOtherSlot(active), model.InterserverCredentialsPhaseAdding
+	} else {
+		active = slot
+	}
+
+	next := make(map[string][]byte)
+	for key, value := range cur {
+		next[key] = value
+	}
+	next[model.InterserverCredentialsUsernameKeyPrefix+slot] = []byte(interserverUsername)
+	next[model.InterserverCredentialsPasswordKeyPrefix+slot] = []byte(util.RandStringRange(20, 30))
+	next[model.InterserverCredentialsActiveKey] = []byte(active)
+	next[model.InterserverCredentialsPhaseKey] = []byte(phase)
+	next[model.InterserverCredentialsRotationKey] = []byte(rotation)
+	next[model.InterserverCredentialsRevisionKey] = []byte(strconv.Itoa(revision + 1))
+	return next
+}
+
+// advanceInterserverCredentialsRotation makes data of the Secret for the next phase of rotation in progress.
+// Returns nil in case no rotation is in progress.
+func advanceInterserverCredentialsRotation(cur map[string][]byte) map[string][]byte {
+	phase := string(cur[model.InterserverCredentialsPhaseKey])
+	if phase == "" {
+		return nil
+	}
+
+	next := make(map[string][]byte)
+	for key, value := range cur {
+		next[key] = value
+	}
+	active := string(cur[model.InterserverCredentialsActiveKey])
+	switch phase {
+	case model.InterserverCredentialsPhaseAdding:
+		// Hosts authenticate with new credentials
+		next[model.InterserverCredentialsActiveKey] = []byte(model.InterserverCredentialsOtherSlot(active))
+		next[model.InterserverCredentialsPhaseKey] = []byte(model.InterserverCredentialsPhaseSwitching)
+	case model.InterserverCredentialsPhaseSwitching:
+		// Previous credentials are dropped
+		previous := model.InterserverCredentialsOtherSlot(active)
+		delete(next, model.InterserverCredentialsUsernameKeyPrefix+previous)
+		delete(next, model.InterserverCredentialsPasswordKeyPrefix+previous)
+		delete(next, model.InterserverCredentialsPhaseKey)
+	default:
+		delete(next, model.InterserverCredentialsPhaseKey)
+	}
+	return next
+}
+
+// getInterserverCredentialsState gets state of interserver credentials hosts are to run with out of the Secret data
+func getInterserverCredentialsState(data map[string][]byte) *api.InterserverCredentialsState {
+	active := string(data[model.InterserverCredentialsActiveKey])
+	state := &api.InterserverCredentialsState{
+		Revision: string(data[model.InterserverCredentialsRevisionKey]),
+		Active:   active,
+	}
+	switch string(data[model.InterserverCredentialsPhaseKey]) {
+	case model.InterserverCredentialsPhaseInitial:
+		state.AllowEmpty = true
+	case model.InterserverCredentialsPhaseAdding, model.InterserverCredentialsPhaseSwitching:
+		state.Accepted = model.InterserverCredentialsOtherSlot(active)
+	}
+	return state
+}
@@ -0,0 +1,124 @@
+package chi
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+	coreListers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	chopListers "github.com/altinity/clickhouse-operator/pkg/client/listers/clickhouse.altinity.com/v1"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	chiCreator "github.com/altinity/clickhouse-operator/pkg/model/chi/creator"
+)
+
+func Test_InterserverCredentialsRotation(t *testing.T) {
+	// Credentials are generated for the first time, replicas accept requests without credentials until rolled
+	data := startInterserverCredentialsRotation(nil, "")
+	require.NotNil(t, data)
+	state := getInterserverCredentialsState(data)
+	require.Equal(t, "1", state.Revision)
+	require.Equal(t, model.InterserverCredentialsSlot0, state.Active)
+	require.Empty(t, state.Accepted)
+	require.True(t, state.AllowEmpty)
+
+	data = advanceInterserverCredentialsRotation(data)
+	require.Nil(t, advanceInterserverCredentialsRotation(data))
+	require.Nil(t, startInterserverCredentialsRotation(data, ""))
+	state = getInterserverCredentialsState(data)
+	require.False(t, state.AllowEmpty)
+	first := string(data[model.InterserverCredentialsPasswordKeyPrefix+model.InterserverCredentialsSlot0])
+
+	// New credentials are added alongside current ones, replicas authenticate with current ones
+	data = startInterserverCredentialsRotation(data, "next")
+	require.NotNil(t, data)
+	require.Nil(t, startInterserverCredentialsRotation(data, "next-next"), "rotation in progress is not interrupted")
+	require.Equal(t, first, string(data[model.InterserverCredentialsPasswordKeyPrefix+model.InterserverCredentialsSlot0]))
+	require.NotEmpty(t, data[model.InterserverCredentialsPasswordKeyPrefix+model.InterserverCredentialsSlot1])
+	state = getInterserverCredentialsState(data)
+	require.Equal(t, "2", state.Revision)
+	require.Equal(t, model.InterserverCredentialsSlot0, state.Active)
+	require.Equal(t, model.InterserverCredentialsSlot1, state.Accepted)
+
+	// Replicas switch to new credentials and still accept current ones
+	data = advanceInterserverCredentialsRotation(data)
+	state = getInterserverCredentialsState(data)
+	require.Equal(t, "2", state.Revision)
+	require.Equal(t, model.InterserverCredentialsSlot1, state.Active)
+	require.Equal(t, model.InterserverCredentialsSlot0, state.Accepted)
+
+	// Previous credentials are dropped
+	data = advanceInterserverCredentialsRotation(data)
+	require.Nil(t, advanceInterserverCredentialsRotation(data))
+	require.NotContains(t, data, model.InterserverCredentialsPasswordKeyPrefix+model.InterserverCredentialsSlot0)
+	state = getInterserverCredentialsState(data)
+	require.Equal(t, "2", state.Revision)
+	require.Equal(t, model.InterserverCredentialsSlot1, state.Active)
+	require.Empty(t, state.Accepted)
+	require.NotNil(t, startInterserverCredentialsRotation(data, "next-next"))
+}
+
+func Test_InterserverCredentials_RenderAndFinalize(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+	hosts := newTestShard(2)
+	chi := hosts[0].GetCHI()
+	chi.Namespace = "ns"
+	chi.Name = "chi"
+	chi.Spec.Defaults = api.NewChiDefaults()
+	chi.Spec.Configuration.InterserverCredentials = &api.ChiInterserverCredentials{Enabled: api.NewStringBool(true)}
+	for i, host := range hosts {
+		host.Runtime.Address.Namespace = "ns"
+		host.Runtime.Address.CHIName = "chi"
+		host.Runtime.Address.HostName = "0-" + strconv.Itoa(i)
+	}
+
+	// Rotation is in progress, new credentials are added alongside current ones
+	data := advanceInterserverCredentialsRotation(startInterserverCredentialsRotation(nil, ""))
+	data = startInterserverCredentialsRotation(data, "next")
+	secret := chiCreator.NewCreator(chi).CreateInterserverCredentialsSecret(data)
+	pods := []*core.Pod{
+		{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: model.CreatePodName(hosts[0]), Annotations: map[string]string{
+			model.AnnotationInterserverCredentialsRevision: "2",
+		}}},
+		{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: model.CreatePodName(hosts[1]), Annotations: map[string]string{
+			model.AnnotationInterserverCredentialsRevision: "1",
+		}}},
+	}
+	kubeClient := kubeFake.NewSimpleClientset(secret, pods[0], pods[1])
+	indexers := cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}
+	w := &worker{
+		c: &Controller{
+			kubeClient:      kubeClient,
+			configMapLister: coreListers.NewConfigMapLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, indexers)),
+			chiLister:       chopListers.NewClickHouseInstallationLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, indexers)),
+		},
+		a:    NewAnnouncer(),
+		task: newTask(chiCreator.NewCreator(chi)),
+	}
+	ctx := context.Background()
+
+	// Config rendered outside of the reconcile carries interserver credentials read out of the Secret
+	require.NoError(t, w.reconcileCHIConfigMapCommon(ctx, chi, w.options()))
+	configMap, err := kubeClient.CoreV1().ConfigMaps("ns").Get(ctx, model.CreateConfigMapCommonName(chi), meta.GetOptions{})
+	require.NoError(t, err)
+	require.Contains(t, configMap.Data["chop-generated-interserver.xml"], "CLICKHOUSE_INTERSERVER_PASSWORD_1")
+	require.Equal(t, model.InterserverCredentialsSlot1, chi.EnsureRuntime().GetInterserverCredentials().Accepted)
+
+	// Rotation is not advanced until all hosts are rolled with new credentials
+	require.Equal(t, []string{hosts[1].GetName()}, w.getHostsWithoutInterserverCredentialsRevision(chi, "2"))
+	w.finalizeInterserverCredentials(ctx, chi)
+	cur, err := kubeClient.CoreV1().Secrets("ns").Get(ctx, secret.Name, meta.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, model.InterserverCredentialsPhaseAdding, string(cur.Data[model.InterserverCredentialsPhaseKey]))
+	require.Equal(t, model.InterserverCredentialsSlot0, string(cur.Data[model.InterserverCredentialsActiveKey]))
+
+	// Stopped host has no pod to be rolled
+	hosts[1].GetCHI().Spec.Stop = api.NewStringBool(true)
+	require.Empty(t, w.getHostsWithoutInterserverCredentialsRevision(chi, "2"))
+}
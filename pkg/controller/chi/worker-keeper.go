@@ -0,0 +1,329 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+	apiChk "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse-keeper.altinity.com/v1"
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/chop"
+	"github.com/altinity/clickhouse-operator/pkg/controller"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi/normalizer"
+	chkModel "github.com/altinity/clickhouse-operator/pkg/model/chk"
+	"github.com/altinity/clickhouse-operator/pkg/model/k8s"
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
+// reconcileKeeper reconciles Keeper ensemble, specified in the CHI.
+// Keeper is reconciled before ClickHouse clusters, so clusters are able to reference it.
+func (w *worker) reconcileKeeper(ctx context.Context, chi *api.ClickHouseInstallation) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	chk := chkModel.CreateCHIKeeper(chi)
+	if chk == nil {
+		// No Keeper ensemble specified
+		return nil
+	}
+
+	w.a.V(2).M(chi).S().P()
+	defer w.a.V(2).M(chi).E().P()
+
+	chk, err := chkModel.NewNormalizer().CreateTemplatedCHK(chk, normalizer.NewOptions())
+	if err != nil {
+		w.a.WithEvent(chi, eventActionReconcile, eventReasonReconcileFailed).
+			WithStatusAction(chi).
+			WithStatusError(chi).
+			M(chi).F().
+			Error("FAILED to normalize Keeper of CHI: %s err: %v", chi.Name, err)
+		return err
+	}
+
+	current := w.getKeeperReplicas(ctx, chk)
+	desired := chkModel.GetReplicasCount(chk)
+	if current != desired {
+		w.a.V(1).M(chi).F().Info("Keeper %s/%s scale from %d to %d nodes", chk.Namespace, chk.Name, current, desired)
+	}
+
+	// Keeper ensemble is scaled node-by-node, so quorum is not lost during membership change
+	for _, replicas := range keeperScaleSteps(current, desired) {
+		if util.IsContextDone(ctx) {
+			log.V(2).Info("task is done")
+			return nil
+		}
+		chkModel.SetReplicasCount(chk, replicas)
+		if err := w.reconcileKeeperStep(ctx, chi, chk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// keeperScaleSteps builds sequence of Keeper ensemble sizes the ensemble has to go through
+// in order to be scaled from current number of nodes to the desired one.
+// New ensemble is created at once, existing ensemble is scaled one node at a time.
+func keeperScaleSteps(current, desired int) []int {
+	if (current == 0) || (current == desired) {
+		return []int{desired}
+	}
+
+	var steps []int
+	for replicas := current; replicas != desired; {
+		if replicas < desired {
+			replicas++
+		} else {
+			replicas--
+		}
+		steps = append(steps, replicas)
+	}
+	return steps
+}
+
+// getKeeperReplicas gets number of nodes of the Keeper ensemble as it is deployed in k8s
+func (w *worker) getKeeperReplicas(ctx context.Context, chk *apiChk.ClickHouseKeeperInstallation) int {
+	cur, err := w.c.kubeClient.AppsV1().StatefulSets(chk.Namespace).Get(ctx, chk.Name, controller.NewGetOptions())
+	if (err != nil) || (cur.Spec.Replicas == nil) {
+		return 0
+	}
+	return int(*cur.Spec.Replicas)
+}
+
+// reconcileKeeperStep reconciles all objects of the Keeper ensemble of the specified size and waits for it to be ready
+func (w *worker) reconcileKeeperStep(ctx context.Context, chi *api.ClickHouseInstallation, chk *apiChk.ClickHouseKeeperInstallation) error {
+	statefulSet, err := w.reconcileKeeperObjects(ctx, chi, chk)
+	if err != nil {
+		return err
+	}
+	return w.waitKeeperStatefulSetReady(ctx, statefulSet)
+}
+
+// reconcileKeeperObjects reconciles all objects of the Keeper ensemble of the specified size.
+// Returns StatefulSet of the Keeper ensemble
+func (w *worker) reconcileKeeperObjects(
+	ctx context.Context,
+	chi *api.ClickHouseInstallation,
+	chk *apiChk.ClickHouseKeeperInstallation,
+) (*apps.StatefulSet, error) {
+	// Config goes first, so new nodes are able to find the ensemble
+	configMap := chkModel.CreateConfigMap(chk)
+	w.setupKeeperObjectMeta(chi, &configMap.ObjectMeta)
+	if err := w.reconcileConfigMap(ctx, chi, configMap); err != nil {
+		w.task.registryFailed.RegisterConfigMap(configMap.ObjectMeta)
+		return nil, err
+	}
+	w.task.registryReconciled.RegisterConfigMap(configMap.ObjectMeta)
+
+	for _, service := range []*core.Service{
+		chkModel.CreateHeadlessService(chk),
+		chkModel.CreateClientService(chk),
+	} {
+		w.setupKeeperObjectMeta(chi, &service.ObjectMeta)
+		if err := w.reconcileService(ctx, chi, service); err != nil {
+			w.task.registryFailed.RegisterService(service.ObjectMeta)
+			return nil, err
+		}
+		w.task.registryReconciled.RegisterService(service.ObjectMeta)
+	}
+
+	pdb := chkModel.CreatePodDisruptionBudget(chk)
+	w.setupKeeperObjectMeta(chi, &pdb.ObjectMeta)
+	if err := w.reconcilePDB(ctx, nil, pdb); err != nil {
+		w.task.registryFailed.RegisterPDB(pdb.ObjectMeta)
+		return nil, err
+	}
+	w.task.registryReconciled.RegisterPDB(pdb.ObjectMeta)
+
+	statefulSet := chkModel.CreateStatefulSet(chk)
+	w.setupKeeperObjectMeta(chi, &statefulSet.ObjectMeta)
+	model.MakeObjectVersion(&statefulSet.ObjectMeta, statefulSet)
+	if err := w.reconcileKeeperStatefulSet(ctx, chi, statefulSet); err != nil {
+		w.task.registryFailed.RegisterStatefulSet(statefulSet.ObjectMeta)
+		return nil, err
+	}
+	w.task.registryReconciled.RegisterStatefulSet(statefulSet.ObjectMeta)
+
+	return statefulSet, nil
+}
+
+// setupKeeperObjectMeta labels object of the Keeper ensemble as CHI-scoped one and makes it owned by the CHI.
+// Thus the object is recognized as managed by the operator on further reconciles.
+// Labels of pods of the Keeper ensemble are not touched, so CHI-scoped selectors do not match Keeper pods
+func (w *worker) setupKeeperObjectMeta(chi *api.ClickHouseInstallation, objMeta *meta.ObjectMeta) {
+	// Labels map may be shared with selectors and pod template, do not modify it in-place
+	objMeta.Labels = util.MergeStringMapsOverwrite(util.CopyMap(objMeta.Labels), model.NewLabeler(chi).GetKeeperCHI())
+	objMeta.OwnerReferences = w.task.creator.GetOwnerReferences()
+}
+
+// reconcileKeeperStatefulSet reconciles StatefulSet of the Keeper ensemble
+func (w *worker) reconcileKeeperStatefulSet(ctx context.Context, chi *api.ClickHouseInstallation, statefulSet *apps.StatefulSet) error {
+	cur, err := w.c.kubeClient.AppsV1().StatefulSets(statefulSet.Namespace).Get(ctx, statefulSet.Name, controller.NewGetOptions())
+	switch {
+	case (err == nil) && model.IsObjectTheSame(&cur.ObjectMeta, &statefulSet.ObjectMeta):
+		w.a.V(2).M(chi).F().Info("Keeper StatefulSet is up to date: %s/%s", statefulSet.Namespace, statefulSet.Name)
+		return nil
+	case err == nil:
+		statefulSet.ResourceVersion = cur.ResourceVersion
+		_, err = w.c.kubeClient.AppsV1().StatefulSets(statefulSet.Namespace).Update(ctx, statefulSet, controller.NewUpdateOptions())
+	case apiErrors.IsNotFound(err):
+		_, err = w.c.kubeClient.AppsV1().StatefulSets(statefulSet.Namespace).Create(ctx, statefulSet, controller.NewCreateOptions())
+	}
+
+	if err != nil {
+		w.a.WithEvent(chi, eventActionReconcile, eventReasonReconcileFailed).
+			WithStatusAction(chi).
+			WithStatusError(chi).
+			M(chi).F().
+			Error("FAILED to reconcile Keeper StatefulSet: %s/%s CHI: %s err: %v", statefulSet.Namespace, statefulSet.Name, chi.Name, err)
+		return err
+	}
+
+	w.a.V(1).M(chi).F().Info("Keeper StatefulSet reconcile successful: %s/%s", statefulSet.Namespace, statefulSet.Name)
+	return nil
+}
+
+// waitKeeperStatefulSetReady waits for all nodes of the Keeper ensemble to be ready
+func (w *worker) waitKeeperStatefulSetReady(ctx context.Context, statefulSet *apps.StatefulSet) error {
+	err := controller.Poll(
+		ctx,
+		statefulSet.Namespace, statefulSet.Name,
+		controller.NewPollerOptions().FromConfig(chop.Config()),
+		&controller.PollerFunctions{
+			Get: func(_ctx context.Context) (any, error) {
+				return w.c.kubeClient.AppsV1().StatefulSets(statefulSet.Namespace).Get(_ctx, statefulSet.Name, controller.NewGetOptions())
+			},
+			IsDone: func(_ctx context.Context, a any) bool {
+				return k8s.IsStatefulSetReady(a.(*apps.StatefulSet))
+			},
+			ShouldContinue: func(_ctx context.Context, _ any, e error) bool {
+				return apiErrors.IsNotFound(e)
+			},
+		},
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("keeper %s/%s is not ready: %v", statefulSet.Namespace, statefulSet.Name, err)
+	}
+	return nil
+}
+
+// deleteRemovedKeeper deletes objects of the Keeper ensemble, which was specified in the previous version of the CHI
+// and is removed from the current one.
+// Objects are looked up by names, so objects created before Keeper objects were labeled are deleted as well.
+func (w *worker) deleteRemovedKeeper(ctx context.Context, chi *api.ClickHouseInstallation) {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return
+	}
+
+	if (chkModel.CreateCHIKeeper(chi) != nil) || !chi.HasAncestor() {
+		// Keeper is either still specified or was never reconciled
+		return
+	}
+	chk := chkModel.CreateCHIKeeper(chi.GetAncestor())
+	if chk == nil {
+		return
+	}
+	chk, err := chkModel.NewNormalizer().CreateTemplatedCHK(chk, normalizer.NewOptions())
+	if err != nil {
+		w.a.V(1).M(chi).F().Error("FAILED to normalize removed Keeper of CHI: %s err: %v", chi.Name, err)
+		return
+	}
+
+	w.a.V(1).M(chi).F().Info("Delete removed Keeper %s/%s", chk.Namespace, chk.Name)
+
+	// StatefulSet goes first, so nodes do not run with config and storage deleted
+	statefulSet := chkModel.CreateStatefulSet(chk)
+	if cur, err := w.c.kubeClient.AppsV1().StatefulSets(statefulSet.Namespace).Get(ctx, statefulSet.Name, controller.NewGetOptions()); err == nil {
+		if w.isKeeperObjectManaged(chi, &cur.ObjectMeta) {
+			_ = w.c.kubeClient.AppsV1().StatefulSets(cur.Namespace).Delete(ctx, cur.Name, controller.NewDeleteOptions())
+		}
+	}
+
+	pdb := chkModel.CreatePodDisruptionBudget(chk)
+	if cur, err := w.c.kubeClient.PolicyV1().PodDisruptionBudgets(pdb.Namespace).Get(ctx, pdb.Name, controller.NewGetOptions()); err == nil {
+		if w.isKeeperObjectManaged(chi, &cur.ObjectMeta) {
+			_ = w.c.kubeClient.PolicyV1().PodDisruptionBudgets(cur.Namespace).Delete(ctx, cur.Name, controller.NewDeleteOptions())
+		}
+	}
+
+	for _, service := range []*core.Service{
+		chkModel.CreateHeadlessService(chk),
+		chkModel.CreateClientService(chk),
+	} {
+		if cur, err := w.c.kubeClient.CoreV1().Services(service.Namespace).Get(ctx, service.Name, controller.NewGetOptions()); err == nil {
+			if w.isKeeperObjectManaged(chi, &cur.ObjectMeta) {
+				_ = w.c.deleteServiceIfExists(ctx, cur.Namespace, cur.Name)
+			}
+		}
+	}
+
+	configMap := chkModel.CreateConfigMap(chk)
+	if cur, err := w.c.kubeClient.CoreV1().ConfigMaps(configMap.Namespace).Get(ctx, configMap.Name, controller.NewGetOptions()); err == nil {
+		if w.isKeeperObjectManaged(chi, &cur.ObjectMeta) {
+			_ = w.c.kubeClient.CoreV1().ConfigMaps(cur.Namespace).Delete(ctx, cur.Name, controller.NewDeleteOptions())
+		}
+	}
+
+	// PVCs are created by the StatefulSet out of its volume claim templates and are labeled with its selector
+	pvcs, err := w.c.kubeClient.CoreV1().PersistentVolumeClaims(chk.Namespace).List(ctx, controller.NewListOptions(statefulSet.Spec.Selector.MatchLabels))
+	if err != nil {
+		w.a.V(1).M(chi).F().Error("FAILED to list PVCs of removed Keeper %s/%s err: %v", chk.Namespace, chk.Name, err)
+		return
+	}
+	for i := range pvcs.Items {
+		pvc := &pvcs.Items[i]
+		if !isKeeperPVC(statefulSet, pvc) {
+			continue
+		}
+		if model.GetReclaimPolicy(pvc.ObjectMeta) == api.PVCReclaimPolicyDelete {
+			w.a.V(1).M(chi).F().Info("Delete PVC of removed Keeper: %s/%s", pvc.Namespace, pvc.Name)
+			_ = w.c.kubeClient.CoreV1().PersistentVolumeClaims(pvc.Namespace).Delete(ctx, pvc.Name, controller.NewDeleteOptions())
+		}
+	}
+}
+
+// isKeeperPVC checks whether PVC is created by the StatefulSet of the Keeper ensemble out of its volume claim template.
+// Such PVCs are named as <volume claim template>-<StatefulSet>-<ordinal>
+func isKeeperPVC(statefulSet *apps.StatefulSet, pvc *core.PersistentVolumeClaim) bool {
+	for _, template := range statefulSet.Spec.VolumeClaimTemplates {
+		if strings.HasPrefix(pvc.Name, template.Name+"-"+statefulSet.Name+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// isKeeperObjectManaged checks whether object of the Keeper ensemble is managed by the operator on behalf of the CHI,
+// so it can be deleted along with the Keeper ensemble
+func (w *worker) isKeeperObjectManaged(chi *api.ClickHouseInstallation, objMeta *meta.ObjectMeta) bool {
+	if model.IsCHOPGeneratedObject(objMeta) || isObjectOwnedBy(chi, objMeta) {
+		return true
+	}
+	w.a.V(1).M(chi).F().Warning("Object %s/%s is not managed by the operator, keep it", objMeta.Namespace, objMeta.Name)
+	return false
+}
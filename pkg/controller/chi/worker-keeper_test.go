@@ -0,0 +1,250 @@
+package chi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	apiMeta "k8s.io/apimachinery/pkg/api/meta"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+	coreListers "k8s.io/client-go/listers/core/v1"
+	k8sTesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	chiCreator "github.com/altinity/clickhouse-operator/pkg/model/chi/creator"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi/normalizer"
+	chkModel "github.com/altinity/clickhouse-operator/pkg/model/chk"
+)
+
+func Test_KeeperScaleSteps(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  int
+		desired  int
+		expected []int
+	}{
+		{"create", 0, 3, []int{3}},
+		{"unchanged", 3, 3, []int{3}},
+		{"scale up", 1, 3, []int{2, 3}},
+		{"scale down", 5, 3, []int{4, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, keeperScaleSteps(tt.current, tt.desired))
+		})
+	}
+}
+
+func Test_ReconcileKeeperObjects_Twice(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+			UID:       "chi-uid",
+		},
+		Spec: api.ChiSpec{
+			Defaults: api.NewChiDefaults(),
+			Configuration: &api.Configuration{
+				Keeper: &api.ChiKeeper{Replicas: 3},
+			},
+		},
+	}
+	chk := chkModel.CreateCHIKeeper(chi)
+	w, kubeClient, syncListers := newKeeperTestWorker(t, chi)
+	ctx := context.Background()
+
+	_, err := w.reconcileKeeperObjects(ctx, chi, chk)
+	require.NoError(t, err)
+	syncListers()
+
+	// Keeper objects are recognized as managed by the operator on the next reconcile
+	w.task = newTask(chiCreator.NewCreator(chi))
+	statefulSet, err := w.reconcileKeeperObjects(ctx, chi, chk)
+	require.NoError(t, err)
+
+	configMap, err := kubeClient.CoreV1().ConfigMaps("ns").Get(ctx, chk.Name, meta.GetOptions{})
+	require.NoError(t, err)
+	require.True(t, model.IsCHOPGeneratedObject(&configMap.ObjectMeta))
+	require.Equal(t, types.UID("chi-uid"), configMap.OwnerReferences[0].UID)
+	require.True(t, w.task.registryReconciled.HasConfigMap(configMap.ObjectMeta))
+	svcs, err := kubeClient.CoreV1().Services("ns").List(ctx, meta.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, svcs.Items, 2)
+	for i := range svcs.Items {
+		require.True(t, model.IsCHOPGeneratedObject(&svcs.Items[i].ObjectMeta))
+		require.True(t, w.task.registryReconciled.HasService(svcs.Items[i].ObjectMeta))
+	}
+	require.True(t, model.IsCHOPGeneratedObject(&statefulSet.ObjectMeta))
+	require.True(t, w.task.registryReconciled.HasStatefulSet(statefulSet.ObjectMeta))
+
+	// Keeper pods are not selected by CHI-scoped selectors
+	require.NotContains(t, statefulSet.Spec.Template.Labels, model.LabelCHIName)
+	require.NotContains(t, statefulSet.Spec.Selector.MatchLabels, model.LabelCHIName)
+}
+
+// newKeeperTestWorker creates worker over the fake client.
+// Returned function makes listers see objects created in the fake client
+func newKeeperTestWorker(t *testing.T, chi *api.ClickHouseInstallation) (*worker, *kubeFake.Clientset, func()) {
+	kubeClient := kubeFake.NewSimpleClientset()
+	configMaps := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	services := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	w := &worker{
+		c: &Controller{
+			kubeClient:      kubeClient,
+			configMapLister: coreListers.NewConfigMapLister(configMaps),
+			serviceLister:   coreListers.NewServiceLister(services),
+		},
+		a:    NewAnnouncer(),
+		task: newTask(chiCreator.NewCreator(chi)),
+	}
+	syncListers := func() {
+		ctx := context.Background()
+		cms, err := kubeClient.CoreV1().ConfigMaps("ns").List(ctx, meta.ListOptions{})
+		require.NoError(t, err)
+		for i := range cms.Items {
+			require.NoError(t, configMaps.Add(&cms.Items[i]))
+		}
+		svcs, err := kubeClient.CoreV1().Services("ns").List(ctx, meta.ListOptions{})
+		require.NoError(t, err)
+		for i := range svcs.Items {
+			require.NoError(t, services.Add(&svcs.Items[i]))
+		}
+	}
+	return w, kubeClient, syncListers
+}
+
+func newKeeperTestCHI(keeper *api.ChiKeeper) *api.ClickHouseInstallation {
+	return &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+			UID:       "chi-uid",
+		},
+		Spec: api.ChiSpec{
+			Defaults: api.NewChiDefaults(),
+			Configuration: &api.Configuration{
+				Clusters: []*api.Cluster{{Name: "cluster"}},
+				Keeper:   keeper,
+			},
+		},
+	}
+}
+
+func countKeeperTestActions(kubeClient *kubeFake.Clientset, verb, resource string) (cnt int) {
+	for _, action := range kubeClient.Actions() {
+		if action.Matches(verb, resource) {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+func Test_ReconcileKeeperStatefulSet_UpdatesOnlyChanged(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+	chi := newKeeperTestCHI(&api.ChiKeeper{Replicas: 3})
+	w, kubeClient, syncListers := newKeeperTestWorker(t, chi)
+	ctx := context.Background()
+	chk := chkModel.CreateCHIKeeper(chi)
+
+	_, err := w.reconcileKeeperObjects(ctx, chi, chk)
+	require.NoError(t, err)
+	syncListers()
+	require.Equal(t, 1, countKeeperTestActions(kubeClient, "create", "statefulsets"))
+
+	// Unchanged StatefulSet is not updated
+	_, err = w.reconcileKeeperObjects(ctx, chi, chkModel.CreateCHIKeeper(chi))
+	require.NoError(t, err)
+	require.Equal(t, 0, countKeeperTestActions(kubeClient, "update", "statefulsets"))
+
+	// Changed StatefulSet is updated
+	chk = chkModel.CreateCHIKeeper(chi)
+	chkModel.SetReplicasCount(chk, 5)
+	_, err = w.reconcileKeeperObjects(ctx, chi, chk)
+	require.NoError(t, err)
+	require.Equal(t, 1, countKeeperTestActions(kubeClient, "update", "statefulsets"))
+}
+
+func Test_DeleteRemovedKeeper(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+	old := newKeeperTestCHI(&api.ChiKeeper{
+		Replicas: 1,
+		Templates: &api.Templates{
+			VolumeClaimTemplates: []api.VolumeClaimTemplate{{Name: "data"}},
+		},
+	})
+	w, kubeClient, _ := newKeeperTestWorker(t, old)
+	ctx := context.Background()
+	chk := chkModel.CreateCHIKeeper(old)
+	statefulSet, err := w.reconcileKeeperObjects(ctx, old, chk)
+	require.NoError(t, err)
+
+	// PVC created by the Keeper StatefulSet and unrelated PVC, which happens to match the selector
+	for _, name := range []string{"data-" + statefulSet.Name + "-0", "other"} {
+		_, err = kubeClient.CoreV1().PersistentVolumeClaims("ns").Create(ctx, &core.PersistentVolumeClaim{
+			ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: name, Labels: statefulSet.Spec.Selector.MatchLabels},
+		}, meta.CreateOptions{})
+		require.NoError(t, err)
+	}
+	// Unmanaged object, which happens to have the name of the Keeper object, is kept
+	_, err = kubeClient.CoreV1().ConfigMaps("ns").Update(ctx, &core.ConfigMap{
+		ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: chkModel.CreateConfigMap(chk).Name},
+	}, meta.UpdateOptions{})
+	require.NoError(t, err)
+
+	chi := newKeeperTestCHI(nil)
+	chi.SetAncestor(old)
+	w.deleteRemovedKeeper(ctx, chi)
+
+	statefulSets, err := kubeClient.AppsV1().StatefulSets("ns").List(ctx, meta.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, statefulSets.Items)
+	services, err := kubeClient.CoreV1().Services("ns").List(ctx, meta.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, services.Items)
+	pdbs, err := kubeClient.PolicyV1().PodDisruptionBudgets("ns").List(ctx, meta.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, pdbs.Items)
+	configMaps, err := kubeClient.CoreV1().ConfigMaps("ns").List(ctx, meta.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, configMaps.Items, 1)
+	pvcs, err := kubeClient.CoreV1().PersistentVolumeClaims("ns").List(ctx, meta.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, pvcs.Items, 1)
+	require.Equal(t, "other", pvcs.Items[0].Name)
+
+	// Keeper, which is still specified, is not deleted
+	kubeClient.ClearActions()
+	old.SetAncestor(old.DeepCopy())
+	w.deleteRemovedKeeper(ctx, old)
+	require.Equal(t, 0, countKeeperTestActions(kubeClient, "delete", "persistentvolumeclaims"))
+}
+
+func Test_Reconcile_KeeperGoesBeforeHosts(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+	chi, err := normalizer.NewNormalizer(nil).CreateTemplatedCHI(newKeeperTestCHI(&api.ChiKeeper{Replicas: 1}), normalizer.NewOptions())
+	require.NoError(t, err)
+	w, kubeClient, _ := newKeeperTestWorker(t, chi)
+	keeperName := chkModel.CreateCHIKeeper(chi).Name
+	kubeClient.PrependReactor("create", "statefulsets", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("keeper is not deployable")
+	})
+
+	// Hosts are not touched in case Keeper fails to reconcile
+	require.Error(t, w.reconcile(context.Background(), chi))
+	for _, action := range kubeClient.Actions() {
+		if create, ok := action.(k8sTesting.CreateAction); ok {
+			objMeta, err := apiMeta.Accessor(create.GetObject())
+			require.NoError(t, err)
+			require.Contains(t, objMeta.GetName(), keeperName)
+		}
+	}
+	require.Equal(t, 1, countKeeperTestActions(kubeClient, "create", "statefulsets"))
+}
@@ -0,0 +1,138 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/chop"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+)
+
+const (
+	hostMembershipExclude = "exclude"
+	hostMembershipInclude = "include"
+)
+
+// membershipWebhookClient calls membership webhooks. URL of the webhook is specified by the CHI,
+// so redirects are not followed - only the endpoint allowed by the operator config is called
+var membershipWebhookClient = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// hostMembershipEvent is a payload sent to membership webhook
+type hostMembershipEvent struct {
+	Event     string `json:"event"`
+	Namespace string `json:"namespace"`
+	CHI       string `json:"chi"`
+	Cluster   string `json:"cluster"`
+	Shard     string `json:"shard"`
+	Replica   string `json:"replica"`
+	Host      string `json:"host"`
+	FQDN      string `json:"fqdn"`
+	TCPPort   int32  `json:"tcpPort"`
+	HTTPPort  int32  `json:"httpPort"`
+}
+
+// newHostMembershipEvent creates membership event of the host
+func newHostMembershipEvent(host *api.ChiHost, event string) *hostMembershipEvent {
+	return &hostMembershipEvent{
+		Event:     event,
+		Namespace: host.Runtime.Address.Namespace,
+		CHI:       host.Runtime.Address.CHIName,
+		Cluster:   host.Runtime.Address.ClusterName,
+		Shard:     host.Runtime.Address.ShardName,
+		Replica:   host.Runtime.Address.ReplicaName,
+		Host:      host.Runtime.Address.HostName,
+		FQDN:      model.CreateFQDN(host),
+		TCPPort:   host.TCPPort,
+		HTTPPort:  host.HTTPPort,
+	}
+}
+
+// notifyHostMembership notifies external endpoint, such as a load balancer, about the host excluded from
+// or included into the cluster. Failure to notify is reported, but does not block reconcile.
+func (w *worker) notifyHostMembership(ctx context.Context, host *api.ChiHost, event string) {
+	webhook := host.GetCHI().GetReconciling().GetMembershipWebhook()
+	if !webhook.IsEnabled() {
+		return
+	}
+
+	url := model.Macro(host).Line(webhook.URL)
+	if err := callMembershipWebhook(ctx, url, webhook, newHostMembershipEvent(host, event)); err != nil {
+		w.a.V(1).
+			WithEvent(host.GetCHI(), eventActionReconcile, eventReasonMembershipWebhookFailed).
+			M(host).F().
+			Warning("Unable to notify %s about %s of host %s. err: %v", url, event, host.GetName(), err)
+		return
+	}
+
+	w.a.V(1).M(host).F().Info("Notified %s about %s of host %s", url, event, host.GetName())
+}
+
+// callMembershipWebhook posts membership event to the webhook
+func callMembershipWebhook(ctx context.Context, url string, webhook *api.ChiMembershipWebhook, event *hostMembershipEvent) error {
+	if err := checkMembershipWebhookURL(url); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if timeout := webhook.GetTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := membershipWebhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if (resp.StatusCode < http.StatusOK) || (resp.StatusCode >= http.StatusMultipleChoices) {
+		return fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+	return nil
+}
+
+// checkMembershipWebhookURL checks webhook URL is allowed by the operator config
+func checkMembershipWebhookURL(str string) error {
+	u, err := url.Parse(str)
+	if err != nil {
+		return err
+	}
+	if !chop.Config().Reconcile.MembershipWebhook.IsAllowed(u) {
+		return fmt.Errorf("URL %s is not allowed by reconcile.membershipWebhook of the operator config", str)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package chi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+	k8sTesting "k8s.io/client-go/testing"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/chop"
+)
+
+func newMembershipWebhookTestHost(t *testing.T, url string) *api.ChiHost {
+	config := &api.OperatorConfig{}
+	config.Reconcile.MembershipWebhook.AllowedSchemes = []string{"http"}
+	config.Reconcile.MembershipWebhook.AllowedHosts = []string{"127.0.0.1"}
+	setTestConfig(t, config)
+
+	host := newTestShard(1)[0]
+	chi := host.GetCHI()
+	chi.ObjectMeta = meta.ObjectMeta{Namespace: "ns", Name: "chi"}
+	chi.Spec.Defaults = &api.ChiDefaults{}
+	chi.Spec.Reconciling = &api.ChiReconciling{
+		MembershipWebhook: &api.ChiMembershipWebhook{URL: url, Timeout: 5},
+	}
+	host.Runtime.Address.Namespace = "ns"
+	host.Runtime.Address.CHIName = "chi"
+	host.Runtime.Address.ReplicaName = "0"
+	host.Runtime.Address.HostName = host.Name
+	host.TCPPort = 9000
+	host.HTTPPort = 8123
+	return host
+}
+
+func Test_NotifyHostMembership_ExcludeInclude(t *testing.T) {
+	var paths []string
+	var events []hostMembershipEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var event hostMembershipEvent
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		paths = append(paths, r.URL.Path)
+		events = append(events, event)
+	}))
+	defer server.Close()
+
+	host := newMembershipWebhookTestHost(t, server.URL+"/members/{chi}/{cluster}/{host}")
+	w := &worker{a: NewAnnouncer()}
+
+	w.notifyHostMembership(context.Background(), host, hostMembershipExclude)
+	w.notifyHostMembership(context.Background(), host, hostMembershipInclude)
+
+	require.Equal(t, []string{"/members/chi/cluster/0-0", "/members/chi/cluster/0-0"}, paths)
+	expected := hostMembershipEvent{
+		Namespace: "ns",
+		CHI:       "chi",
+		Cluster:   "cluster",
+		Shard:     "0",
+		Replica:   "0",
+		Host:      "0-0",
+		FQDN:      "chi-chi-cluster-0-0.ns.svc.cluster.local",
+		TCPPort:   9000,
+		HTTPPort:  8123,
+	}
+	expected.Event = hostMembershipExclude
+	require.Equal(t, expected, events[0])
+	expected.Event = hostMembershipInclude
+	require.Equal(t, expected, events[1])
+}
+
+func Test_NotifyHostMembership_FailureWarns(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	host := newMembershipWebhookTestHost(t, server.URL)
+	kubeClient := kubeFake.NewSimpleClientset()
+	c := &Controller{kubeClient: kubeClient}
+	w := &worker{c: c, a: NewAnnouncer().WithController(c)}
+
+	// Failure does not block, it is reported with an event
+	w.notifyHostMembership(context.Background(), host, hostMembershipExclude)
+	var reasons []string
+	for _, action := range kubeClient.Actions() {
+		if create, ok := action.(k8sTesting.CreateAction); ok && (action.GetResource().Resource == "events") {
+			reasons = append(reasons, create.GetObject().(*core.Event).Reason)
+		}
+	}
+	require.Equal(t, []string{eventReasonMembershipWebhookFailed}, reasons)
+
+	// Webhook is not specified - nothing to notify
+	host.GetCHI().Spec.Reconciling.MembershipWebhook = nil
+	w.notifyHostMembership(context.Background(), host, hostMembershipExclude)
+	require.Len(t, kubeClient.Actions(), 1)
+}
+
+func Test_NotifyHostMembership_NotAllowed(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/internal", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	host := newMembershipWebhookTestHost(t, server.URL)
+	webhook := host.GetCHI().GetReconciling().GetMembershipWebhook()
+	event := newHostMembershipEvent(host, hostMembershipExclude)
+
+	// Redirects are not followed
+	require.Error(t, callMembershipWebhook(context.Background(), server.URL+"/redirect", webhook, event))
+	require.Equal(t, 1, calls)
+
+	// Hosts and schemes not allowed by the operator config are not called
+	chop.Config().Reconcile.MembershipWebhook.AllowedHosts = []string{"*.example.com"}
+	require.Error(t, callMembershipWebhook(context.Background(), server.URL, webhook, event))
+	chop.Config().Reconcile.MembershipWebhook.AllowedHosts = []string{"127.0.0.1"}
+	chop.Config().Reconcile.MembershipWebhook.AllowedSchemes = []string{"https"}
+	require.Error(t, callMembershipWebhook(context.Background(), server.URL, webhook, event))
+	require.Equal(t, 1, calls)
+}
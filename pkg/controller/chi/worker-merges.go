@@ -0,0 +1,69 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"context"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/chop"
+)
+
+// hostMerges stops and starts merges on a host
+type hostMerges interface {
+	HostStopMerges(ctx context.Context, host *api.ChiHost) error
+	HostStartMerges(ctx context.Context, host *api.ChiHost) error
+}
+
+// stopHostMerges stops merges on the host, which is about to be restarted, in case it is configured to do so
+func (w *worker) stopHostMerges(ctx context.Context, host *api.ChiHost) {
+	if !chop.Config().Reconcile.Host.ManageMergesOnRestart.IsTrue() {
+		return
+	}
+	w.doStopHostMerges(ctx, host, w.ensureClusterSchemer(host))
+}
+
+// startHostMerges starts merges on the host, in case they were stopped by the operator.
+// Is safe to be called multiple times, merges are started once.
+func (w *worker) startHostMerges(ctx context.Context, host *api.ChiHost) {
+	if !host.Runtime.MergesStopped {
+		return
+	}
+	w.doStartHostMerges(ctx, host, w.ensureClusterSchemer(host))
+}
+
+// doStopHostMerges stops merges on the host and remembers merges have to be started again
+func (w *worker) doStopHostMerges(ctx context.Context, host *api.ChiHost, merges hostMerges) {
+	if err := merges.HostStopMerges(ctx, host); err != nil {
+		w.a.V(1).M(host).F().Warning("Unable to stop merges on host: %s err: %v", host.GetName(), err)
+		return
+	}
+	host.Runtime.MergesStopped = true
+	w.a.V(1).M(host).F().Info("Merges stopped on host: %s", host.GetName())
+}
+
+// doStartHostMerges starts merges on the host, in case they were stopped by the operator
+func (w *worker) doStartHostMerges(ctx context.Context, host *api.ChiHost, merges hostMerges) {
+	if !host.Runtime.MergesStopped {
+		return
+	}
+	if err := merges.HostStartMerges(ctx, host); err != nil {
+		// Keep merges marked as stopped, so start is retried
+		w.a.V(1).M(host).F().Warning("Unable to start merges on host: %s err: %v", host.GetName(), err)
+		return
+	}
+	host.Runtime.MergesStopped = false
+	w.a.V(1).M(host).F().Info("Merges started on host: %s", host.GetName())
+}
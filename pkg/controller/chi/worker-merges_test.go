@@ -0,0 +1,94 @@
+package chi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+// fakeHostMerges records merges commands and fails the ones it is told to
+type fakeHostMerges struct {
+	calls     []string
+	failStop  bool
+	failStart int
+}
+
+func (m *fakeHostMerges) HostStopMerges(ctx context.Context, host *api.ChiHost) error {
+	m.calls = append(m.calls, "stop")
+	if m.failStop {
+		return fmt.Errorf("stop failed")
+	}
+	return nil
+}
+
+func (m *fakeHostMerges) HostStartMerges(ctx context.Context, host *api.ChiHost) error {
+	m.calls = append(m.calls, "start")
+	if m.failStart > 0 {
+		m.failStart--
+		return fmt.Errorf("start failed")
+	}
+	return nil
+}
+
+func Test_HostMerges_StopStartArePaired(t *testing.T) {
+	w := &worker{a: NewAnnouncer()}
+	host := newTestShard(1)[0]
+	ctx := context.Background()
+
+	// Merges are stopped on exclude and started on include only
+	merges := &fakeHostMerges{}
+	w.doStopHostMerges(ctx, host, merges)
+	require.True(t, host.Runtime.MergesStopped)
+	w.doStartHostMerges(ctx, host, merges)
+	w.doStartHostMerges(ctx, host, merges)
+	require.Equal(t, []string{"stop", "start"}, merges.calls)
+	require.False(t, host.Runtime.MergesStopped)
+
+	// Merges were not stopped - nothing to start
+	merges = &fakeHostMerges{failStop: true}
+	w.doStopHostMerges(ctx, host, merges)
+	require.False(t, host.Runtime.MergesStopped)
+	w.doStartHostMerges(ctx, host, merges)
+	require.Equal(t, []string{"stop"}, merges.calls)
+}
+
+func Test_HostMerges_StartIsRetried(t *testing.T) {
+	w := &worker{a: NewAnnouncer()}
+	host := newTestShard(1)[0]
+	ctx := context.Background()
+
+	// Start on include fails - merges are kept marked as stopped and started on the next attempt
+	merges := &fakeHostMerges{failStart: 1}
+	w.doStopHostMerges(ctx, host, merges)
+	w.doStartHostMerges(ctx, host, merges)
+	require.True(t, host.Runtime.MergesStopped)
+	w.doStartHostMerges(ctx, host, merges)
+	require.Equal(t, []string{"stop", "start", "start"}, merges.calls)
+	require.False(t, host.Runtime.MergesStopped)
+}
+
+func Test_StopHostMerges_Denied(t *testing.T) {
+	for _, denied := range []string{"STOP MERGES", "START MERGES"} {
+		t.Run(denied, func(t *testing.T) {
+			setTestConfig(t, &api.OperatorConfig{
+				ClickHouse: api.OperatorConfigClickHouse{
+					SystemCommands: api.OperatorConfigSystemCommands{Deny: []string{denied}},
+				},
+				Reconcile: api.OperatorConfigReconcile{
+					Host: api.OperatorConfigReconcileHost{ManageMergesOnRestart: api.NewStringBool(true)},
+				},
+			})
+			w := &worker{a: NewAnnouncer(), c: &Controller{kubeClient: kubeFake.NewSimpleClientset()}}
+			host := newTestShard(2)[0]
+
+			// Denied command is not run, so merges keep running and are not reported as stopped
+			w.stopHostMerges(context.Background(), host)
+			require.False(t, host.Runtime.MergesStopped)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"context"
+
+	networking "k8s.io/api/networking/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/controller"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi/normalizer"
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
+// reconcileNetworkPolicy reconciles NetworkPolicy ingress to ClickHouse instances of the CHI is restricted with.
+// NetworkPolicy is deleted in case it is not requested anymore.
+// NetworkPolicy created by someone else is left intact
+func (w *worker) reconcileNetworkPolicy(ctx context.Context, chi *api.ClickHouseInstallation) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	networkPolicy := w.task.creator.CreateNetworkPolicy(w.getBackupAPIPorts(chi)...)
+	cur, err := w.c.kubeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).Get(ctx, networkPolicy.Name, controller.NewGetOptions())
+	switch {
+	case err == nil:
+		if !isNetworkPolicyOfCHI(chi, cur) {
+			w.a.V(1).M(chi).F().Info("NetworkPolicy %s/%s is not managed by the operator, leave it intact", cur.Namespace, cur.Name)
+			return nil
+		}
+		if !chi.GetNetworkPolicy().IsEnabled() {
+			err = w.c.kubeClient.NetworkingV1().NetworkPolicies(cur.Namespace).Delete(ctx, cur.Name, controller.NewDeleteOptions())
+			if (err == nil) || apiErrors.IsNotFound(err) {
+				w.a.V(1).M(chi).F().Info("NetworkPolicy %s/%s is not requested anymore and deleted", cur.Namespace, cur.Name)
+				return nil
+			}
+			break
+		}
+		networkPolicy.ResourceVersion = cur.ResourceVersion
+		_, err = w.c.kubeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).Update(ctx, networkPolicy, controller.NewUpdateOptions())
+	case apiErrors.IsNotFound(err):
+		if !chi.GetNetworkPolicy().IsEnabled() {
+			return nil
+		}
+		_, err = w.c.kubeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).Create(ctx, networkPolicy, controller.NewCreateOptions())
+	}
+
+	if err != nil {
+		w.a.WithEvent(chi, eventActionReconcile, eventReasonReconcileFailed).
+			WithStatusAction(chi).
+			WithStatusError(chi).
+			M(chi).F().
+			Error("FAILED to reconcile NetworkPolicy: %s/%s CHI: %s err: %v", networkPolicy.Namespace, networkPolicy.Name, chi.Name, err)
+		return err
+	}
+
+	w.a.V(1).M(chi).F().Info("NetworkPolicy reconcile successful: %s/%s", networkPolicy.Namespace, networkPolicy.Name)
+	return nil
+}
+
+// getBackupAPIPorts gets ports of clickhouse-backup REST API backups and restores of the CHI are run on
+func (w *worker) getBackupAPIPorts(chi *api.ClickHouseInstallation) (ports []int32) {
+	if w.c.chbLister != nil {
+		chbs, _ := w.c.chbLister.ClickHouseBackups(chi.Namespace).List(labels.Everything())
+		for _, chb := range chbs {
+			if chb.Spec.CHI == chi.Name {
+				ports = append(ports, chb.Spec.GetPort())
+			}
+		}
+	}
+	if w.c.chrLister != nil {
+		chrs, _ := w.c.chrLister.ClickHouseRestores(chi.Namespace).List(labels.Everything())
+		for _, chr := range chrs {
+			if chr.Spec.CHI == chi.Name {
+				ports = append(ports, chr.Spec.GetPort())
+			}
+		}
+	}
+	return ports
+}
+
+// reconcileBackupNetworkPolicy reconciles NetworkPolicy of the CHI, so the operator is allowed to reach
+// clickhouse-backup REST API on the port backup or restore is run on, which may be not known to the last reconcile
+func (w *worker) reconcileBackupNetworkPolicy(ctx context.Context, namespace, name string) {
+	chi, err := w.createCompletedCHIFromObjectMeta(&meta.ObjectMeta{Namespace: namespace, Name: name}, normalizer.NewOptions())
+	if (err != nil) || !chi.GetNetworkPolicy().IsEnabled() {
+		return
+	}
+	w.newTask(chi)
+	_ = w.reconcileNetworkPolicy(ctx, chi)
+}
+
+// isNetworkPolicyOfCHI checks whether NetworkPolicy is managed by the operator on behalf of the CHI
+func isNetworkPolicyOfCHI(chi *api.ClickHouseInstallation, networkPolicy *networking.NetworkPolicy) bool {
+	selector := labels.SelectorFromSet(model.NewLabeler(chi).GetSelectorCHIScope())
+	return selector.Matches(labels.Set(networkPolicy.Labels))
+}
@@ -0,0 +1,131 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	apps "k8s.io/api/apps/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/chop"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	"github.com/altinity/clickhouse-operator/pkg/model/k8s"
+)
+
+// verifyObjectManaged checks whether pre-existing object is managed by the operator and thus can be updated.
+// Returns errObjectNotManaged in case object is not to be touched.
+func (w *worker) verifyObjectManaged(chi *api.ClickHouseInstallation, kind string, objMeta *meta.ObjectMeta) error {
+	if model.IsCHOPGeneratedObject(objMeta) || isObjectOwnedBy(chi, objMeta) {
+		return nil
+	}
+	return w.adoptObject(chi, kind, objMeta, isAdoptionAllowed(chi))
+}
+
+// isObjectOwnedBy checks whether object is owned by the CHI.
+// Objects created by the operator earlier without labels, such as ones of Keeper ensemble, are recognized this way
+func isObjectOwnedBy(chi *api.ClickHouseInstallation, objMeta *meta.ObjectMeta) bool {
+	if chi.UID == "" {
+		return false
+	}
+	for _, ref := range objMeta.OwnerReferences {
+		if ref.UID == chi.UID {
+			return true
+		}
+	}
+	return false
+}
+
+// isAdoptionAllowed checks whether pre-existing objects, which are not managed by the operator, can be adopted.
+// Adoption is either allowed by the operator config or requested by the CHI annotation.
+func isAdoptionAllowed(chi *api.ClickHouseInstallation) bool {
+	if isAdoptionRequested(chi) {
+		return true
+	}
+	return chop.Config().Reconcile.AdoptUnmanagedObjects.IsTrue()
+}
+
+// isAdoptionRequested checks whether CHI requests adoption of pre-existing objects via annotation
+func isAdoptionRequested(chi *api.ClickHouseInstallation) bool {
+	value, ok := chi.GetAnnotations()[model.AnnotationAdopt]
+	if !ok {
+		return false
+	}
+	adopt := api.StringBool(value)
+	return adopt.IsTrue()
+}
+
+// adoptStatefulSet prepares new StatefulSet to be applied in-place over pre-existing StatefulSet,
+// which is not managed by the operator. Pre-existing StatefulSet is adopted in case adoption is allowed,
+// otherwise it is left to regular update flow.
+// Adopted StatefulSet keeps its immutable fields, such as selector and volume claim templates, on all further updates,
+// so it is never re-created because of them. Volumes are mounted from volume claim templates of the adopted StatefulSet,
+// so pods keep their PVCs, which are adopted along with volume claim templates.
+// Returns volume claim templates of the CHI, keyed by volume claim templates of the adopted StatefulSet they are mapped onto.
+func (w *worker) adoptStatefulSet(chi *api.ClickHouseInstallation, curStatefulSet, newStatefulSet *apps.StatefulSet) map[string]string {
+	if (curStatefulSet == nil) || (newStatefulSet == nil) {
+		return nil
+	}
+
+	_, adopted := curStatefulSet.GetAnnotations()[model.AnnotationAdopted]
+	if !adopted {
+		if model.IsCHOPGeneratedObject(&curStatefulSet.ObjectMeta) || !isAdoptionAllowed(chi) {
+			return nil
+		}
+		w.a.V(1).
+			WithEvent(chi, eventActionReconcile, eventReasonAdoptObject).
+			M(chi).F().
+			Info("StatefulSet %s/%s is not managed by the operator. Adopt it in-place",
+				curStatefulSet.Namespace, curStatefulSet.Name)
+	}
+
+	volumeClaimTemplates := k8s.StatefulSetKeepImmutableFields(curStatefulSet, newStatefulSet)
+	if newStatefulSet.Annotations == nil {
+		newStatefulSet.Annotations = make(map[string]string)
+	}
+	newStatefulSet.Annotations[model.AnnotationAdopted] = "true"
+	return volumeClaimTemplates
+}
+
+// adoptHostStatefulSet adopts pre-existing StatefulSet of the host, in case it is not managed by the operator.
+// Desired StatefulSet of the host is prepared to be applied in-place and PVCs of the host are looked up
+// by volume claim templates of the adopted StatefulSet.
+func (w *worker) adoptHostStatefulSet(host *api.ChiHost) {
+	host.Runtime.AdoptedVolumeClaimTemplates = nil
+	curStatefulSet, err := w.c.getStatefulSet(&host.Runtime.DesiredStatefulSet.ObjectMeta, false)
+	if err != nil {
+		return
+	}
+	host.Runtime.AdoptedVolumeClaimTemplates = w.adoptStatefulSet(host.GetCHI(), curStatefulSet, host.Runtime.DesiredStatefulSet)
+}
+
+// adoptObject decides what to do with pre-existing object, which is not managed by the operator.
+// Such object is overwritten only in case adoption is explicitly allowed, otherwise ConflictingObject event is produced.
+func (w *worker) adoptObject(chi *api.ClickHouseInstallation, kind string, objMeta *meta.ObjectMeta, adopt bool) error {
+	if adopt {
+		w.a.V(1).M(chi).F().Warning(
+			"%s %s/%s is not managed by the operator. Adopt it",
+			kind, objMeta.Namespace, objMeta.Name)
+		return nil
+	}
+
+	w.a.V(1).
+		WithEvent(chi, eventActionReconcile, eventReasonConflictingObject).
+		WithStatusAction(chi).
+		WithStatusError(chi).
+		M(chi).F().
+		Error("%s %s/%s already exists and is not managed by the operator. Refuse to overwrite it",
+			kind, objMeta.Namespace, objMeta.Name)
+	return errObjectNotManaged
+}
@@ -0,0 +1,296 @@
+package chi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+	appsListers "k8s.io/client-go/listers/apps/v1"
+	coreListers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	chiCreator "github.com/altinity/clickhouse-operator/pkg/model/chi/creator"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi/normalizer"
+	"github.com/altinity/clickhouse-operator/pkg/model/k8s"
+)
+
+func newOwnershipTestConfigMap(labels map[string]string, value string) *core.ConfigMap {
+	return &core.ConfigMap{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi-common-configd",
+			Labels:    labels,
+		},
+		Data: map[string]string{
+			"remote_servers.xml": value,
+		},
+	}
+}
+
+func Test_ReconcileConfigMap_UpdatesManagedObject(t *testing.T) {
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+		},
+	}
+	managed := map[string]string{model.LabelAppName: model.LabelAppValue}
+	cur := newOwnershipTestConfigMap(managed, "old")
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	require.NoError(t, indexer.Add(cur))
+	kubeClient := kubeFake.NewSimpleClientset(cur.DeepCopy())
+	w := &worker{
+		c: &Controller{
+			kubeClient:      kubeClient,
+			configMapLister: coreListers.NewConfigMapLister(indexer),
+		},
+		a: NewAnnouncer(),
+	}
+	ctx := context.Background()
+
+	require.NoError(t, w.reconcileConfigMap(ctx, chi, newOwnershipTestConfigMap(managed, "new")))
+	updated, err := kubeClient.CoreV1().ConfigMaps("ns").Get(ctx, cur.Name, meta.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "new", updated.Data["remote_servers.xml"])
+}
+
+func Test_VerifyObjectManaged(t *testing.T) {
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+		},
+	}
+	w := &worker{
+		c: &Controller{},
+		a: NewAnnouncer(),
+	}
+
+	// Object managed by the operator is updated
+	managed := newOwnershipTestConfigMap(map[string]string{model.LabelAppName: model.LabelAppValue}, "")
+	require.NoError(t, w.verifyObjectManaged(chi, "ConfigMap", &managed.ObjectMeta))
+
+	// User's object of the same name is not adopted by default
+	unmanaged := newOwnershipTestConfigMap(map[string]string{"app": "user"}, "")
+	require.ErrorIs(t, w.adoptObject(chi, "ConfigMap", &unmanaged.ObjectMeta, false), errObjectNotManaged)
+
+	// Unless adoption is forced
+	require.NoError(t, w.adoptObject(chi, "ConfigMap", &unmanaged.ObjectMeta, true))
+}
+
+func newAdoptionTestCHI() *api.ClickHouseInstallation {
+	return &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace:   "ns",
+			Name:        "chi",
+			Annotations: map[string]string{model.AnnotationAdopt: "true"},
+		},
+		Spec: api.ChiSpec{
+			Defaults: api.NewChiDefaults(),
+		},
+	}
+}
+
+func Test_ReconcileService_AdoptsUnmanagedObject(t *testing.T) {
+	chi := newAdoptionTestCHI()
+	newService := func(labels map[string]string) *core.Service {
+		return &core.Service{
+			ObjectMeta: meta.ObjectMeta{
+				Namespace: "ns",
+				Name:      "clickhouse-chi",
+				Labels:    labels,
+			},
+			Spec: core.ServiceSpec{
+				Type: core.ServiceTypeClusterIP,
+				Ports: []core.ServicePort{
+					{Name: model.ChDefaultHTTPPortName, Port: model.ChDefaultHTTPPortNumber},
+				},
+			},
+		}
+	}
+	// Manually deployed service
+	cur := newService(map[string]string{"app": "clickhouse"})
+	cur.Spec.ClusterIP = "10.0.0.10"
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	require.NoError(t, indexer.Add(cur))
+	kubeClient := kubeFake.NewSimpleClientset(cur.DeepCopy())
+	w := &worker{
+		c: &Controller{
+			kubeClient:    kubeClient,
+			serviceLister: coreListers.NewServiceLister(indexer),
+		},
+		a: NewAnnouncer(),
+	}
+	ctx := context.Background()
+
+	require.NoError(t, w.reconcileService(ctx, chi, newService(map[string]string{model.LabelAppName: model.LabelAppValue})))
+	adopted, err := kubeClient.CoreV1().Services("ns").Get(ctx, cur.Name, meta.GetOptions{})
+	require.NoError(t, err)
+	// Service is labeled as managed and is updated in-place
+	require.True(t, model.IsCHOPGeneratedObject(&adopted.ObjectMeta))
+	require.Equal(t, "10.0.0.10", adopted.Spec.ClusterIP)
+	for _, action := range kubeClient.Actions() {
+		require.NotEqual(t, "delete", action.GetVerb())
+	}
+}
+
+func Test_AdoptStatefulSet(t *testing.T) {
+	chi := newAdoptionTestCHI()
+	w := &worker{
+		c: &Controller{},
+		a: NewAnnouncer(),
+	}
+	newStatefulSet := func(labels map[string]string, vct string) *apps.StatefulSet {
+		return &apps.StatefulSet{
+			ObjectMeta: meta.ObjectMeta{
+				Namespace: "ns",
+				Name:      "chi-chi-cluster-0-0",
+				Labels:    labels,
+			},
+			Spec: apps.StatefulSetSpec{
+				Selector: &meta.LabelSelector{MatchLabels: labels},
+				Template: core.PodTemplateSpec{
+					ObjectMeta: meta.ObjectMeta{Labels: labels},
+				},
+				VolumeClaimTemplates: []core.PersistentVolumeClaim{
+					{ObjectMeta: meta.ObjectMeta{Name: vct}},
+				},
+				ServiceName: "chi-chi-cluster-0-0",
+			},
+		}
+	}
+	managed := map[string]string{model.LabelAppName: model.LabelAppValue, model.LabelCHIName: "chi"}
+
+	// Manually deployed StatefulSet
+	cur := newStatefulSet(map[string]string{"app": "clickhouse"}, "data")
+	desired := newStatefulSet(managed, "data")
+	desired.Spec.Template.Spec.Containers = []core.Container{{Name: "clickhouse", Image: "clickhouse/clickhouse-server:23.8"}}
+	w.adoptStatefulSet(chi, cur, desired)
+
+	// Immutable fields are kept, so StatefulSet is updated in-place rather than re-created
+	require.Equal(t, cur.Spec.Selector, desired.Spec.Selector)
+	require.Equal(t, cur.Spec.VolumeClaimTemplates, desired.Spec.VolumeClaimTemplates)
+	// Object is labeled as managed, pods keep matching the selector
+	require.True(t, model.IsCHOPGeneratedObject(&desired.ObjectMeta))
+	require.Equal(t, "clickhouse", desired.Spec.Template.Labels["app"])
+	require.Equal(t, model.LabelAppValue, desired.Spec.Template.Labels[model.LabelAppName])
+	require.Equal(t, "clickhouse/clickhouse-server:23.8", desired.Spec.Template.Spec.Containers[0].Image)
+	require.Equal(t, "true", desired.Annotations[model.AnnotationAdopted])
+
+	// Adopted StatefulSet keeps immutable fields on further reconciles
+	adopted := desired
+	desired = newStatefulSet(managed, "data")
+	chi.Annotations = nil
+	w.adoptStatefulSet(chi, adopted, desired)
+	require.Equal(t, cur.Spec.Selector, desired.Spec.Selector)
+
+	// StatefulSet managed by the operator is left intact
+	cur = newStatefulSet(managed, "data")
+	desired = newStatefulSet(managed, "data-v2")
+	w.adoptStatefulSet(newAdoptionTestCHI(), cur, desired)
+	require.Equal(t, "data-v2", desired.Spec.VolumeClaimTemplates[0].Name)
+	require.NotContains(t, desired.Annotations, model.AnnotationAdopted)
+}
+
+func Test_AdoptHostStatefulSet_MapsVolumesAndAdoptsPVCs(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+
+	chi := newAdoptionTestCHI()
+	chi.Spec.Defaults.Templates = &api.ChiTemplateNames{DataVolumeClaimTemplate: "data"}
+	chi.Spec.Templates = &api.Templates{
+		VolumeClaimTemplates: []api.VolumeClaimTemplate{
+			{
+				Name: "data",
+				Spec: core.PersistentVolumeClaimSpec{
+					Resources: core.ResourceRequirements{
+						Requests: core.ResourceList{core.ResourceStorage: resource.MustParse("1Gi")},
+					},
+				},
+			},
+		},
+	}
+	chi.Spec.Configuration = &api.Configuration{Clusters: []*api.Cluster{{Name: "cluster"}}}
+	chi, err := normalizer.NewNormalizer(nil).CreateTemplatedCHI(chi, normalizer.NewOptions())
+	require.NoError(t, err)
+	host := chi.FindHost("cluster", 0, 0)
+	require.NotNil(t, host)
+
+	// Manually deployed StatefulSet mounts data volume from its own volume claim template
+	unmanaged := map[string]string{"app": "clickhouse"}
+	cur := &apps.StatefulSet{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      model.CreateStatefulSetName(host),
+			Labels:    unmanaged,
+		},
+		Spec: apps.StatefulSetSpec{
+			Selector: &meta.LabelSelector{MatchLabels: unmanaged},
+			Template: core.PodTemplateSpec{
+				ObjectMeta: meta.ObjectMeta{Labels: unmanaged},
+				Spec: core.PodSpec{
+					Containers: []core.Container{
+						{
+							Name:         model.ClickHouseContainerName,
+							VolumeMounts: []core.VolumeMount{{Name: "clickhouse-data", MountPath: model.DirPathClickHouseData}},
+						},
+					},
+				},
+			},
+			VolumeClaimTemplates: []core.PersistentVolumeClaim{
+				{ObjectMeta: meta.ObjectMeta{Name: "clickhouse-data"}},
+			},
+		},
+	}
+	pvc := &core.PersistentVolumeClaim{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "clickhouse-data-" + model.CreatePodName(host),
+			Labels:    unmanaged,
+		},
+		Status: core.PersistentVolumeClaimStatus{Phase: core.ClaimBound},
+	}
+
+	statefulSets := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	require.NoError(t, statefulSets.Add(cur))
+	kubeClient := kubeFake.NewSimpleClientset(cur.DeepCopy(), pvc)
+	w := &worker{
+		c: &Controller{
+			kubeClient:        kubeClient,
+			statefulSetLister: appsListers.NewStatefulSetLister(statefulSets),
+		},
+		a:    NewAnnouncer(),
+		task: newTask(chiCreator.NewCreator(chi)),
+	}
+	ctx := context.Background()
+
+	w.prepareHostStatefulSetWithStatus(ctx, host, false)
+
+	// Volume claim templates of the adopted StatefulSet are kept and data volume is mounted from them
+	desired := host.Runtime.DesiredStatefulSet
+	require.Equal(t, cur.Spec.VolumeClaimTemplates, desired.Spec.VolumeClaimTemplates)
+	container, ok := k8s.StatefulSetContainerGet(desired, model.ClickHouseContainerName, 0)
+	require.True(t, ok)
+	require.Contains(t, container.VolumeMounts, core.VolumeMount{Name: "clickhouse-data", MountPath: model.DirPathClickHouseData})
+	for _, volumeMount := range container.VolumeMounts {
+		require.NotEqual(t, "data", volumeMount.Name)
+	}
+
+	// PVC of the adopted StatefulSet is adopted, no new PVC is introduced
+	w.reconcilePVCs(ctx, host, api.DesiredStatefulSet)
+	adopted, err := kubeClient.CoreV1().PersistentVolumeClaims("ns").Get(ctx, pvc.Name, meta.GetOptions{})
+	require.NoError(t, err)
+	require.True(t, model.IsCHOPGeneratedObject(&adopted.ObjectMeta))
+	require.True(t, w.task.registryReconciled.HasPVC(adopted.ObjectMeta))
+	pvcs, err := kubeClient.CoreV1().PersistentVolumeClaims("ns").List(ctx, meta.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, pvcs.Items, 1)
+}
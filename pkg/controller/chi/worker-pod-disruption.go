@@ -0,0 +1,138 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"context"
+
+	core "k8s.io/api/core/v1"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi/normalizer"
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
+// isPodTerminating checks whether pod is being terminated, e.g. evicted during node drain
+func isPodTerminating(pod *core.Pod) bool {
+	return (pod != nil) && !pod.ObjectMeta.DeletionTimestamp.IsZero()
+}
+
+// isPodReady checks whether pod is ready to serve
+func isPodReady(pod *core.Pod) bool {
+	if pod == nil {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == core.PodReady {
+			return condition.Status == core.ConditionTrue
+		}
+	}
+	return false
+}
+
+// isPodDisrupted checks whether pod is not able to serve queries
+func isPodDisrupted(pod *core.Pod) bool {
+	return isPodTerminating(pod) || !isPodReady(pod)
+}
+
+// isPodDisruptionChanged checks whether pod starts terminating or is back to service
+func isPodDisruptionChanged(old, new *core.Pod) bool {
+	if (old == nil) || (new == nil) {
+		return false
+	}
+	if !isPodTerminating(old) && isPodTerminating(new) {
+		// Pod is being evicted
+		return true
+	}
+	if !isPodReady(old) && isPodReady(new) && !isPodTerminating(new) {
+		// Pod is back to service
+		return true
+	}
+	return false
+}
+
+// processPodDisruption excludes hosts with disrupted pods from ClickHouse clusters
+// and includes hosts with pods back to service into ClickHouse clusters.
+// This keeps queries from being routed to the hosts being evicted during voluntary disruptions, such as node drain.
+func (w *worker) processPodDisruption(ctx context.Context, cmd *PodDisruption) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	cur, err := w.c.GetCHIByObjectMeta(cmd.initiator, false)
+	if err != nil {
+		w.a.M(cmd.initiator).F().Error("unable to find CHI by %v err: %v", cmd.initiator.Labels, err)
+		return nil
+	}
+
+	if model.IsReconcilePaused(cur) {
+		// Clusters are frozen along with the rest of the CHI
+		w.a.V(1).M(cur).F().Info("CHI %s/%s reconcile is paused, skip pod disruption of %s", cur.Namespace, cur.Name, cmd.initiator.Name)
+		return nil
+	}
+
+	// Clusters are rendered as they are reconciled, spec changes are left to the reconcile
+	chi, err := w.createCompletedCHI(cur, normalizer.NewOptions())
+	if err != nil {
+		w.a.V(1).M(cur).F().Info("Skip pod disruption of %s. err: %v", cmd.initiator.Name, err)
+		return nil
+	}
+
+	if chr := w.c.getRestoreInProgress(chi); chr != nil {
+		// Restore excludes hosts from the clusters on its own
+		w.a.V(1).M(chi).F().Info("CHI %s/%s is being restored, skip pod disruption of %s", chi.Namespace, chi.Name, cmd.initiator.Name)
+		return nil
+	}
+
+	unlock, ok := w.c.tryLockCHI(chi)
+	if !ok {
+		// Reconcile excludes and includes hosts on its own
+		w.a.V(1).M(chi).F().Info("CHI %s/%s is being reconciled, skip pod disruption of %s", chi.Namespace, chi.Name, cmd.initiator.Name)
+		return nil
+	}
+	defer unlock()
+
+	if w.excludeDisruptedHosts(chi) == 0 {
+		w.a.V(1).M(chi).F().Warning("No host of CHI %s/%s is able to serve, keep clusters intact", chi.Namespace, chi.Name)
+		return nil
+	}
+
+	w.newTask(chi)
+	return w.reconcileCHIConfigMapCommon(ctx, chi, w.options())
+}
+
+// excludeDisruptedHosts marks hosts with disrupted pods to be excluded from ClickHouse clusters.
+// Stopped hosts are left as they are.
+// Returns number of hosts able to serve.
+func (w *worker) excludeDisruptedHosts(chi *api.ClickHouseInstallation) (serving int) {
+	chi.WalkHosts(func(host *api.ChiHost) error {
+		if host.IsStopped() {
+			return nil
+		}
+		pod, err := w.c.getPod(host)
+		if (err == nil) && !isPodDisrupted(pod) {
+			host.GetReconcileAttributes().UnsetExclude()
+			serving++
+			return nil
+		}
+		w.a.V(1).M(host).F().Info("Pod of the host %s is disrupted, exclude host from the cluster", host.GetName())
+		host.GetReconcileAttributes().SetExclude()
+		return nil
+	})
+	return serving
+}
@@ -0,0 +1,132 @@
+package chi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+	coreListers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	chopFake "github.com/altinity/clickhouse-operator/pkg/client/clientset/versioned/fake"
+	chopListers "github.com/altinity/clickhouse-operator/pkg/client/listers/clickhouse.altinity.com/v1"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi/normalizer"
+)
+
+func newPodDisruptionTestPod(name string, ready, terminating bool) *core.Pod {
+	pod := &core.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      name,
+		},
+	}
+	if ready {
+		pod.Status.Conditions = []core.PodCondition{{Type: core.PodReady, Status: core.ConditionTrue}}
+	}
+	if terminating {
+		now := meta.Now()
+		pod.ObjectMeta.DeletionTimestamp = &now
+	}
+	return pod
+}
+
+func Test_IsPodDisruptionChanged(t *testing.T) {
+	ready := newPodDisruptionTestPod("pod", true, false)
+	evicted := newPodDisruptionTestPod("pod", true, true)
+	starting := newPodDisruptionTestPod("pod", false, false)
+
+	// Eviction starts
+	require.True(t, isPodDisruptionChanged(ready, evicted))
+	// Pod is back to service
+	require.True(t, isPodDisruptionChanged(starting, ready))
+	// Nothing changed
+	require.False(t, isPodDisruptionChanged(ready, ready))
+	require.False(t, isPodDisruptionChanged(evicted, evicted))
+	// Ready pod is to be terminated anyway
+	require.False(t, isPodDisruptionChanged(evicted, newPodDisruptionTestPod("pod", true, true)))
+	require.False(t, isPodDisruptionChanged(nil, ready))
+}
+
+func Test_ExcludeDisruptedHosts(t *testing.T) {
+	hosts := newTestShard(3)
+	for _, host := range hosts {
+		host.Runtime.Address.Namespace = "ns"
+		host.Runtime.Address.HostName = host.Name
+	}
+	evicted := newPodDisruptionTestPod(model.CreatePodName(hosts[0]), true, true)
+	ready := newPodDisruptionTestPod(model.CreatePodName(hosts[1]), true, false)
+	// Pod of the third host is gone
+	kubeClient := kubeFake.NewSimpleClientset(evicted, ready)
+	w := &worker{
+		c: &Controller{
+			kubeClient: kubeClient,
+		},
+		a: NewAnnouncer(),
+	}
+	chi := hosts[0].GetCHI()
+
+	// Eviction triggers exclusion of the host from the cluster
+	require.Equal(t, 1, w.excludeDisruptedHosts(chi))
+	options := w.getRemoteServersGeneratorOptions()
+	require.False(t, options.Include(hosts[0]))
+	require.True(t, options.Include(hosts[1]))
+	require.False(t, options.Include(hosts[2]))
+
+	// Host is included back as its pod is back to service
+	require.NoError(t, kubeClient.CoreV1().Pods("ns").Delete(context.Background(), evicted.Name, meta.DeleteOptions{}))
+	_, err := kubeClient.CoreV1().Pods("ns").Create(context.Background(), newPodDisruptionTestPod(evicted.Name, true, false), meta.CreateOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 2, w.excludeDisruptedHosts(chi))
+	require.True(t, options.Include(hosts[0]))
+	require.True(t, options.Include(hosts[1]))
+}
+
+func Test_ProcessPodDisruption_RendersCompletedCHI(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+
+	completed := &api.ClickHouseInstallation{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "chi"}}
+	completed.Spec.Configuration = &api.Configuration{Clusters: []*api.Cluster{{Name: "completed"}}}
+	chi := &api.ClickHouseInstallation{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "chi"}}
+	chi.Spec.Configuration = &api.Configuration{Clusters: []*api.Cluster{{Name: "pending"}}}
+	chi.SetAncestor(completed)
+
+	w := &worker{
+		normalizer: normalizer.NewNormalizer(nil),
+		a:          NewAnnouncer(),
+	}
+	rendered, err := w.createCompletedCHI(chi, normalizer.NewOptions())
+	require.NoError(t, err)
+	host := rendered.FindHost("completed", 0, 0)
+	require.NotNil(t, host)
+	pod := newPodDisruptionTestPod(model.CreatePodName(host), true, false)
+	pod.Labels = map[string]string{model.LabelCHIName: "chi"}
+
+	kubeClient := kubeFake.NewSimpleClientset(pod)
+	indexers := cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}
+	w.c = &Controller{
+		kubeClient:      kubeClient,
+		chopClient:      chopFake.NewSimpleClientset(chi),
+		configMapLister: coreListers.NewConfigMapLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, indexers)),
+		chiLister:       chopListers.NewClickHouseInstallationLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, indexers)),
+		chiLocker:       newKeyedLocker(),
+	}
+	ctx := context.Background()
+
+	// Spec changes not reconciled yet are not rendered into clusters
+	require.NoError(t, w.processPodDisruption(ctx, &PodDisruption{initiator: &pod.ObjectMeta}))
+	configMap, err := kubeClient.CoreV1().ConfigMaps("ns").Get(ctx, model.CreateConfigMapCommonName(rendered), meta.GetOptions{})
+	require.NoError(t, err)
+	require.Contains(t, configMap.Data["chop-generated-remote_servers.xml"], "<completed>")
+	require.NotContains(t, configMap.Data["chop-generated-remote_servers.xml"], "<pending>")
+
+	// CHI not reconciled yet is left to the reconcile
+	unreconciled := &api.ClickHouseInstallation{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "unreconciled"}}
+	w.c.chopClient = chopFake.NewSimpleClientset(unreconciled)
+	pod.Labels[model.LabelCHIName] = "unreconciled"
+	require.NoError(t, w.processPodDisruption(ctx, &PodDisruption{initiator: &pod.ObjectMeta}))
+}
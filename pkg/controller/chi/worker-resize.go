@@ -0,0 +1,258 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/chop"
+	"github.com/altinity/clickhouse-operator/pkg/controller"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	"github.com/altinity/clickhouse-operator/pkg/model/k8s"
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
+const (
+	// podResizeSubresource is the pod subresource in-place resize is requested through.
+	// It is served only by k8s clusters having InPlacePodVerticalScaling feature enabled.
+	podResizeSubresource = "resize"
+	// Pod conditions reporting resize state
+	podConditionResizePending    core.PodConditionType = "PodResizePending"
+	podConditionResizeInProgress core.PodConditionType = "PodResizeInProgress"
+	podResizeReasonInfeasible                          = "Infeasible"
+)
+
+// isInPlaceResizeSupported checks whether core API resources list has pods resize subresource
+func isInPlaceResizeSupported(resources *meta.APIResourceList) bool {
+	if resources == nil {
+		return false
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Name == "pods/"+podResizeSubresource {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldResizeInPlace decides whether StatefulSet change can be applied by in-place resize of the pods instead of rollout.
+// It is possible in case resources requests/limits of the containers are the only change and k8s supports in-place resize.
+func shouldResizeInPlace(supported bool, cur, desired *apps.StatefulSet) bool {
+	if (cur == nil) || (desired == nil) {
+		return false
+	}
+	if !k8s.IsStatefulSetReady(cur) {
+		// Pods have to be up and running to be resized
+		return false
+	}
+	if !supported {
+		return false
+	}
+	return model.IsObjectResourcesChangedOnly(&cur.ObjectMeta, &desired.ObjectMeta)
+}
+
+// shouldResizeStatefulSetInPlace checks whether host's StatefulSet is to be resized in-place
+func (w *worker) shouldResizeStatefulSetInPlace(host *api.ChiHost) bool {
+	if !chop.Config().Reconcile.StatefulSet.Update.InPlaceResize.IsTrue() {
+		return false
+	}
+	cur := host.Runtime.CurStatefulSet
+	desired := host.Runtime.DesiredStatefulSet
+	if (cur == nil) || !model.IsObjectResourcesChangedOnly(&cur.ObjectMeta, &desired.ObjectMeta) {
+		// Do not bother k8s with discovery request
+		return false
+	}
+	resources, err := w.c.kubeClient.Discovery().ServerResourcesForGroupVersion(core.SchemeGroupVersion.String())
+	if err != nil {
+		w.a.V(1).M(host).F().Warning("Unable to discover pods resize subresource. err: %v", err)
+		return false
+	}
+	return shouldResizeInPlace(isInPlaceResizeSupported(resources), cur, desired)
+}
+
+// resizeStatefulSetInPlace applies resources requests/limits changes to the host's pods without restart.
+// Falls back to StatefulSet rollout in case in-place resize fails.
+func (w *worker) resizeStatefulSetInPlace(ctx context.Context, host *api.ChiHost, register bool) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	namespace := host.Runtime.DesiredStatefulSet.Namespace
+	name := host.Runtime.DesiredStatefulSet.Name
+
+	w.a.V(1).
+		WithEvent(host.GetCHI(), eventActionUpdate, eventReasonUpdateStarted).
+		WithStatusAction(host.GetCHI()).
+		M(host).F().
+		Info("Resize StatefulSet(%s/%s) in-place - started", namespace, name)
+
+	if err := w.doResizeStatefulSetInPlace(ctx, host); err != nil {
+		w.a.V(1).M(host).F().Warning("Unable to resize StatefulSet(%s/%s) in-place, fall back to rollout. err: %v", namespace, name, err)
+		return w.updateStatefulSet(ctx, host, register)
+	}
+
+	if register {
+		host.GetCHI().EnsureStatus().HostUpdated()
+		_ = w.c.updateCHIObjectStatus(ctx, host.GetCHI(), UpdateCHIStatusOptions{
+			CopyCHIStatusOptions: api.CopyCHIStatusOptions{
+				MainFields: true,
+			},
+		})
+	}
+	w.a.V(1).
+		WithEvent(host.GetCHI(), eventActionUpdate, eventReasonUpdateCompleted).
+		WithStatusAction(host.GetCHI()).
+		M(host).F().
+		Info("Resize StatefulSet(%s/%s) in-place - completed", namespace, name)
+	return nil
+}
+
+// doResizeStatefulSetInPlace applies desired StatefulSet with pods being updated on delete only
+// and resizes pods in-place through pods resize subresource, so neither StatefulSet controller nor the operator
+// touch pods' revisions. Pods are rolled to the current revision by the next rollout of the StatefulSet.
+func (w *worker) doResizeStatefulSetInPlace(ctx context.Context, host *api.ChiHost) error {
+	statefulSet := host.Runtime.DesiredStatefulSet.DeepCopy()
+	statefulSet.Spec.UpdateStrategy = apps.StatefulSetUpdateStrategy{
+		Type: apps.OnDeleteStatefulSetStrategyType,
+	}
+	if _, err := w.c.kubeClient.AppsV1().StatefulSets(statefulSet.Namespace).Update(ctx, statefulSet, controller.NewUpdateOptions()); err != nil {
+		return err
+	}
+
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+	for i := int32(0); i < replicas; i++ {
+		podName := fmt.Sprintf("%s-%d", statefulSet.Name, i)
+		if err := w.resizePod(ctx, statefulSet, podName); err != nil {
+			return err
+		}
+		w.a.V(1).M(host).F().Info("Pod %s/%s resized in-place", statefulSet.Namespace, podName)
+	}
+	return nil
+}
+
+// resizePod requests in-place resize of the pod's containers to the StatefulSet's resources and waits for it to complete
+func (w *worker) resizePod(ctx context.Context, statefulSet *apps.StatefulSet, podName string) error {
+	patch, err := newPodResizePatch(statefulSet)
+	if err != nil {
+		return err
+	}
+	pods := w.c.kubeClient.CoreV1().Pods(statefulSet.Namespace)
+	if _, err := pods.Patch(ctx, podName, types.StrategicMergePatchType, patch, controller.NewPatchOptions(), podResizeSubresource); err != nil {
+		return err
+	}
+
+	var resizeErr error
+	err = controller.Poll(
+		ctx,
+		statefulSet.Namespace, podName,
+		controller.NewPollerOptions().FromConfig(chop.Config()),
+		&controller.PollerFunctions{
+			Get: func(_ctx context.Context) (any, error) {
+				return pods.Get(_ctx, podName, controller.NewGetOptions())
+			},
+			IsDone: func(_ctx context.Context, a any) bool {
+				var done bool
+				done, resizeErr = isPodResized(a.(*core.Pod), statefulSet)
+				return done || (resizeErr != nil)
+			},
+		},
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+	return resizeErr
+}
+
+// podResizePatch is a strategic merge patch of pod's containers resources
+type podResizePatch struct {
+	Spec struct {
+		Containers []podResizePatchContainer `json:"containers"`
+	} `json:"spec"`
+}
+
+type podResizePatchContainer struct {
+	Name      string                    `json:"name"`
+	Resources core.ResourceRequirements `json:"resources"`
+}
+
+// newPodResizePatch builds resize subresource patch with containers' resources of the StatefulSet
+func newPodResizePatch(statefulSet *apps.StatefulSet) ([]byte, error) {
+	patch := podResizePatch{}
+	for _, container := range statefulSet.Spec.Template.Spec.Containers {
+		patch.Spec.Containers = append(patch.Spec.Containers, podResizePatchContainer{
+			Name:      container.Name,
+			Resources: container.Resources,
+		})
+	}
+	return json.Marshal(patch)
+}
+
+// isPodResized checks whether pod's containers run with the StatefulSet's resources and pod is ready.
+// Returns error in case resize can not be done by the node.
+func isPodResized(pod *core.Pod, statefulSet *apps.StatefulSet) (bool, error) {
+	if pod.Status.Resize == core.PodResizeStatusInfeasible {
+		return false, fmt.Errorf("resize of pod %s/%s is infeasible", pod.Namespace, pod.Name)
+	}
+	if pod.Status.Resize != "" {
+		return false, nil
+	}
+	ready := false
+	for _, condition := range pod.Status.Conditions {
+		switch condition.Type {
+		case podConditionResizePending:
+			if condition.Reason == podResizeReasonInfeasible {
+				return false, fmt.Errorf("resize of pod %s/%s is infeasible: %s", pod.Namespace, pod.Name, condition.Message)
+			}
+			return false, nil
+		case podConditionResizeInProgress:
+			return false, nil
+		case core.PodReady:
+			ready = condition.Status == core.ConditionTrue
+		}
+	}
+	if !ready {
+		return false, nil
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		container, ok := k8s.StatefulSetContainerGet(statefulSet, status.Name, -1)
+		if !ok {
+			continue
+		}
+		if status.Resources == nil {
+			// Resources are not reported by kubelet yet
+			return false, nil
+		}
+		if !apiequality.Semantic.DeepEqual(status.Resources.Requests, container.Resources.Requests) ||
+			!apiequality.Semantic.DeepEqual(status.Resources.Limits, container.Resources.Limits) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,165 @@
+package chi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	"github.com/altinity/clickhouse-operator/pkg/model/k8s"
+)
+
+// newResizeTestStatefulSet builds ready StatefulSet versioned the way creator does
+func newResizeTestStatefulSet(image, cpu, memory string) *apps.StatefulSet {
+	replicas := int32(1)
+	statefulSet := &apps.StatefulSet{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi-0-0",
+		},
+		Spec: apps.StatefulSetSpec{
+			Replicas: &replicas,
+			Template: core.PodTemplateSpec{
+				Spec: core.PodSpec{
+					Containers: []core.Container{
+						{
+							Name:  "clickhouse",
+							Image: image,
+							Resources: core.ResourceRequirements{
+								Requests: core.ResourceList{
+									core.ResourceCPU:    resource.MustParse(cpu),
+									core.ResourceMemory: resource.MustParse(memory),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Status: apps.StatefulSetStatus{
+			ReadyReplicas: replicas,
+		},
+	}
+	model.MakeObjectBaseVersion(&statefulSet.ObjectMeta, k8s.StatefulSetWithoutContainersResources(statefulSet))
+	model.MakeObjectVersion(&statefulSet.ObjectMeta, statefulSet)
+	return statefulSet
+}
+
+func Test_IsInPlaceResizeSupported(t *testing.T) {
+	require.False(t, isInPlaceResizeSupported(nil))
+	require.False(t, isInPlaceResizeSupported(&meta.APIResourceList{
+		APIResources: []meta.APIResource{
+			{Name: "pods"},
+			{Name: "pods/status"},
+		},
+	}))
+	require.True(t, isInPlaceResizeSupported(&meta.APIResourceList{
+		APIResources: []meta.APIResource{
+			{Name: "pods"},
+			{Name: "pods/resize"},
+		},
+	}))
+}
+
+func Test_ShouldResizeInPlace(t *testing.T) {
+	cur := newResizeTestStatefulSet("clickhouse:23.8", "1", "4Gi")
+
+	// Resources only changed
+	require.True(t, shouldResizeInPlace(true, cur, newResizeTestStatefulSet("clickhouse:23.8", "2", "8Gi")))
+
+	// Resources changed, but k8s does not support in-place resize
+	require.False(t, shouldResizeInPlace(false, cur, newResizeTestStatefulSet("clickhouse:23.8", "2", "8Gi")))
+
+	// Not only resources changed
+	require.False(t, shouldResizeInPlace(true, cur, newResizeTestStatefulSet("clickhouse:24.3", "2", "8Gi")))
+	require.False(t, shouldResizeInPlace(true, cur, newResizeTestStatefulSet("clickhouse:24.3", "1", "4Gi")))
+
+	// Nothing changed
+	require.False(t, shouldResizeInPlace(true, cur, newResizeTestStatefulSet("clickhouse:23.8", "1", "4Gi")))
+
+	// Current StatefulSet is not ready
+	notReady := newResizeTestStatefulSet("clickhouse:23.8", "1", "4Gi")
+	notReady.Status.ReadyReplicas = 0
+	require.False(t, shouldResizeInPlace(true, notReady, newResizeTestStatefulSet("clickhouse:23.8", "2", "8Gi")))
+
+	// Current StatefulSet is created by the operator, which does not track base version
+	legacy := newResizeTestStatefulSet("clickhouse:23.8", "1", "4Gi")
+	delete(legacy.Labels, model.LabelObjectBaseVersion)
+	require.False(t, shouldResizeInPlace(true, legacy, newResizeTestStatefulSet("clickhouse:23.8", "2", "8Gi")))
+
+	require.False(t, shouldResizeInPlace(true, nil, cur))
+}
+
+// newResizeTestPod builds ready pod running with the specified resources
+func newResizeTestPod(cpu, memory string) *core.Pod {
+	return &core.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi-0-0-0",
+		},
+		Status: core.PodStatus{
+			Conditions: []core.PodCondition{
+				{Type: core.PodReady, Status: core.ConditionTrue},
+			},
+			ContainerStatuses: []core.ContainerStatus{
+				{
+					Name: "clickhouse",
+					Resources: &core.ResourceRequirements{
+						Requests: core.ResourceList{
+							core.ResourceCPU:    resource.MustParse(cpu),
+							core.ResourceMemory: resource.MustParse(memory),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func Test_IsPodResized(t *testing.T) {
+	desired := newResizeTestStatefulSet("clickhouse:23.8", "2", "8Gi")
+
+	done, err := isPodResized(newResizeTestPod("2", "8Gi"), desired)
+	require.NoError(t, err)
+	require.True(t, done)
+
+	// Still running with old resources
+	done, err = isPodResized(newResizeTestPod("1", "4Gi"), desired)
+	require.NoError(t, err)
+	require.False(t, done)
+
+	// Resize is in progress
+	pod := newResizeTestPod("2", "8Gi")
+	pod.Status.Conditions = append(pod.Status.Conditions, core.PodCondition{Type: podConditionResizeInProgress, Status: core.ConditionTrue})
+	done, err = isPodResized(pod, desired)
+	require.NoError(t, err)
+	require.False(t, done)
+
+	// Resize can not be done by the node
+	pod = newResizeTestPod("1", "4Gi")
+	pod.Status.Conditions = append(pod.Status.Conditions, core.PodCondition{Type: podConditionResizePending, Status: core.ConditionTrue, Reason: podResizeReasonInfeasible})
+	_, err = isPodResized(pod, desired)
+	require.Error(t, err)
+
+	pod = newResizeTestPod("1", "4Gi")
+	pod.Status.Resize = core.PodResizeStatusInfeasible
+	_, err = isPodResized(pod, desired)
+	require.Error(t, err)
+
+	// Pod is not ready
+	pod = newResizeTestPod("2", "8Gi")
+	pod.Status.Conditions = nil
+	done, err = isPodResized(pod, desired)
+	require.NoError(t, err)
+	require.False(t, done)
+}
+
+func Test_NewPodResizePatch(t *testing.T) {
+	patch, err := newPodResizePatch(newResizeTestStatefulSet("clickhouse:23.8", "2", "8Gi"))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"spec":{"containers":[{"name":"clickhouse","resources":{"requests":{"cpu":"2","memory":"8Gi"}}}]}}`, string(patch))
+}
@@ -0,0 +1,334 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilRuntime "k8s.io/apimachinery/pkg/util/runtime"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/controller"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi/backup"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi/normalizer"
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
+// restorePollInterval specifies how often progress of restore running on the hosts is checked
+const restorePollInterval = 15 * time.Second
+
+// processReconcileRestore runs restore described by ClickHouseRestore.
+// Restore is run by clickhouse-backup on the same hosts backup is taken on, one host of each shard.
+// Hosts being restored are excluded from the clusters, so queries are not routed to partially restored data.
+// Once data are restored, the rest of replicas of the shard are synced and hosts are included back.
+// Reconcile of the CHI is postponed while restore is in progress, so restore does not race with it.
+func (w *worker) processReconcileRestore(ctx context.Context, cmd *ReconcileRestore) error {
+	switch cmd.cmd {
+	case reconcileAdd, reconcileUpdate:
+		return w.reconcileRestore(ctx, cmd.new)
+	case reconcileDelete:
+		w.a.V(1).M(cmd.old).F().Info("Delete CHR %s/%s", cmd.old.Namespace, cmd.old.Name)
+		return nil
+	}
+
+	// Unknown item type, don't know what to do with it
+	// Just skip it and behave like it never existed
+	utilRuntime.HandleError(fmt.Errorf("unexpected reconcile - %#v", cmd))
+	return nil
+}
+
+// reconcileRestore starts restore or checks progress of the restore being run
+func (w *worker) reconcileRestore(ctx context.Context, chr *api.ClickHouseRestore) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	// Object of the command may be outdated, since status is updated by the operator in between
+	chr, err := w.c.chopClient.ClickhouseV1().ClickHouseRestores(chr.Namespace).Get(ctx, chr.Name, controller.NewGetOptions())
+	switch {
+	case apiErrors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	status := chr.EnsureStatus()
+	switch status.Status {
+	case "", api.BackupStatusPending:
+		w.prepareRestore(ctx, chr)
+	case api.BackupStatusInProgress:
+		w.pollRestore(ctx, chr)
+	default:
+		// Restore is finished already
+		return nil
+	}
+
+	if status.IsFinished() {
+		w.announceRestoreFinished(chr)
+	}
+
+	if _, err := w.c.chopClient.ClickhouseV1().ClickHouseRestores(chr.Namespace).UpdateStatus(ctx, chr, controller.NewUpdateOptions()); err != nil {
+		w.a.M(chr).F().Error("unable to update status of CHR %s/%s err: %v", chr.Namespace, chr.Name, err)
+	}
+
+	if !status.IsFinished() {
+		w.c.requeueRestore(chr, restorePollInterval)
+	}
+	return nil
+}
+
+// prepareRestore selects hosts to be restored. Restore is marked as in progress before any host is touched,
+// so reconcile of the CHI started in between is postponed.
+func (w *worker) prepareRestore(ctx context.Context, chr *api.ClickHouseRestore) {
+	status := chr.EnsureStatus()
+
+	chi, err := w.createCompletedCHIFromObjectMeta(&meta.ObjectMeta{Namespace: chr.Namespace, Name: chr.Spec.CHI}, normalizer.NewOptions())
+	if err != nil {
+		status.Fail(fmt.Sprintf("unable to find CHI %s err: %v", chr.Spec.CHI, err))
+		return
+	}
+
+	hosts := selectBackupHosts(chi, chr.Spec.Cluster)
+	if len(hosts) == 0 {
+		status.Fail(fmt.Sprintf("no hosts to restore in CHI %s cluster %q", chr.Spec.CHI, chr.Spec.Cluster))
+		return
+	}
+
+	w.reconcileBackupNetworkPolicy(ctx, chr.Namespace, chr.Spec.CHI)
+
+	w.a.V(1).
+		WithEvent(chi, eventActionRestore, eventReasonRestoreStarted).
+		M(chi).F().
+		Info("Restore %s from backup %s started on %d hosts", chr.Name, chr.Spec.Backup, len(hosts))
+
+	status.Start()
+	for _, host := range hosts {
+		// Operation is assigned as soon as restore is started on the host
+		status.SetHostStatus(model.CreateFQDN(host), &api.ChbHostStatus{
+			Backup: createBackupName(chr.Spec.Backup, host),
+			Status: api.BackupStatusInProgress,
+		})
+	}
+}
+
+// pollRestore quiesces hosts and starts restore on them, checks progress of restore running on the hosts
+// and includes hosts back into the clusters once restore is finished on all of them
+func (w *worker) pollRestore(ctx context.Context, chr *api.ClickHouseRestore) {
+	status := chr.EnsureStatus()
+
+	chi, err := w.createCompletedCHIFromObjectMeta(&meta.ObjectMeta{Namespace: chr.Namespace, Name: chr.Spec.CHI}, normalizer.NewOptions())
+	if err != nil {
+		status.Fail(fmt.Sprintf("unable to find CHI %s err: %v", chr.Spec.CHI, err))
+		return
+	}
+
+	if status.IsTimedOut(chr.Spec.GetTimeout()) {
+		// Hosts are included back into the clusters along with the hosts restore is finished on
+		w.a.V(1).M(chr).F().Warning("Restore %s is not finished in %s", chr.Name, chr.Spec.GetTimeout())
+		status.FailHostsInProgress(fmt.Sprintf("restore is not finished in %s", chr.Spec.GetTimeout()))
+	}
+
+	unlock, ok := w.c.tryLockCHI(chi)
+	if !ok {
+		// Some other mutating operation runs on the CHI, check it again later
+		w.a.V(1).M(chr).F().Info("CHI %s/%s is busy, restore %s waits", chi.Namespace, chi.Name, chr.Name)
+		return
+	}
+	defer unlock()
+
+	if hasPendingRestoreHosts(status) {
+		if err := w.quiesceRestoreHosts(ctx, chi, status); err != nil {
+			w.a.V(1).M(chr).F().Warning("unable to exclude hosts from the clusters err: %v", err)
+			return
+		}
+		if chr.Spec.DropExisting.IsTrue() {
+			w.dropRestoreReplicasTables(ctx, chi, chr)
+		}
+		w.startRestore(ctx, chr)
+	}
+
+	for fqdn, hostStatus := range status.Hosts {
+		if (hostStatus.Status != api.BackupStatusInProgress) || (hostStatus.Operation == "") {
+			continue
+		}
+
+		action, err := backup.NewClient(fqdn, chr.Spec.GetPort()).GetAction(ctx, hostStatus.Operation, hostStatus.Backup)
+		if err != nil {
+			// Host may be unreachable for a while, check it again later
+			w.a.V(1).M(chr).F().Warning("unable to check restore %s on host %s err: %v", hostStatus.Backup, fqdn, err)
+			continue
+		}
+		if (action == nil) || (action.Status == backup.ActionStatusInProgress) {
+			continue
+		}
+		if action.Status != backup.ActionStatusSuccess {
+			hostStatus.Status = api.BackupStatusFailed
+			hostStatus.Error = action.Error
+			continue
+		}
+
+		if err := w.syncRestoredShard(ctx, chi, fqdn, chr.Spec.DropExisting.IsTrue()); err != nil {
+			hostStatus.Status = api.BackupStatusFailed
+			hostStatus.Error = err.Error()
+			continue
+		}
+		hostStatus.Status = api.BackupStatusCompleted
+	}
+
+	if !status.IsHostsFinished() {
+		return
+	}
+
+	// Freshly normalized CHI has no hosts excluded
+	w.newTask(chi)
+	if err := w.reconcileCHIConfigMapCommon(ctx, chi, w.options()); err != nil {
+		w.a.V(1).M(chr).F().Warning("unable to include hosts back into the clusters err: %v", err)
+		return
+	}
+	status.Finish()
+}
+
+// hasPendingRestoreHosts checks whether there are hosts restore is not started on yet
+func hasPendingRestoreHosts(status *api.ChbStatus) bool {
+	for _, hostStatus := range status.Hosts {
+		if (hostStatus.Status == api.BackupStatusInProgress) && (hostStatus.Operation == "") {
+			return true
+		}
+	}
+	return false
+}
+
+// quiesceRestoreHosts excludes hosts being restored from the clusters
+func (w *worker) quiesceRestoreHosts(ctx context.Context, chi *api.ClickHouseInstallation, status *api.ChbStatus) error {
+	chi.WalkHosts(func(host *api.ChiHost) error {
+		if hostStatus, ok := status.Hosts[model.CreateFQDN(host)]; ok && (hostStatus.Status == api.BackupStatusInProgress) {
+			w.a.V(1).M(host).F().Info("Host %s is being restored, exclude host from the cluster", host.GetName())
+			host.GetReconcileAttributes().SetExclude()
+		}
+		return nil
+	})
+	w.newTask(chi)
+	return w.reconcileCHIConfigMapCommon(ctx, chi, w.options())
+}
+
+// startRestore starts restore on the hosts restore is not started on yet
+func (w *worker) startRestore(ctx context.Context, chr *api.ClickHouseRestore) {
+	operation := backup.OperationRestore
+	if chr.Spec.Remote.IsTrue() {
+		operation = backup.OperationRestoreRemote
+	}
+	for fqdn, hostStatus := range chr.Status.Hosts {
+		if (hostStatus.Status != api.BackupStatusInProgress) || (hostStatus.Operation != "") {
+			continue
+		}
+		hostStatus.Operation = operation
+		client := backup.NewClient(fqdn, chr.Spec.GetPort())
+		if err := client.Restore(ctx, operation, hostStatus.Backup, chr.Spec.Tables, chr.Spec.DropExisting.IsTrue()); err != nil {
+			hostStatus.Status = api.BackupStatusFailed
+			hostStatus.Error = err.Error()
+		}
+	}
+}
+
+// dropRestoreReplicasTables drops existing tables on the rest of replicas of the shards restore is not started on yet.
+// clickhouse-backup drops tables on the host being restored only, while the rest of replicas would keep
+// the existing data and replicate it back along with the restored one.
+// Restore is not started on the host in case tables of some of its replicas fail to be dropped.
+func (w *worker) dropRestoreReplicasTables(ctx context.Context, chi *api.ClickHouseInstallation, chr *api.ClickHouseRestore) {
+	for fqdn, hostStatus := range chr.Status.Hosts {
+		if (hostStatus.Status != api.BackupStatusInProgress) || (hostStatus.Operation != "") {
+			continue
+		}
+		restored := findHostByFQDN(chi, fqdn)
+		if restored == nil {
+			hostStatus.Status = api.BackupStatusFailed
+			hostStatus.Error = fmt.Sprintf("host %s is not found in CHI", fqdn)
+			continue
+		}
+		restored.GetShard().WalkHosts(func(host *api.ChiHost) error {
+			if (host == restored) || (hostStatus.Status != api.BackupStatusInProgress) {
+				return nil
+			}
+			if err := w.ensureClusterSchemer(host).HostDropTablesMatching(ctx, host, chr.Spec.Tables); err != nil {
+				hostStatus.Status = api.BackupStatusFailed
+				hostStatus.Error = fmt.Sprintf("unable to drop existing tables of replica %s err: %v", host.GetName(), err)
+			}
+			return nil
+		})
+	}
+}
+
+// syncRestoredShard syncs the rest of replicas of the shard with the restored host.
+// Tables dropped on the replicas before restore are created back beforehand.
+func (w *worker) syncRestoredShard(ctx context.Context, chi *api.ClickHouseInstallation, fqdn string, dropped bool) error {
+	restored := findHostByFQDN(chi, fqdn)
+	if restored == nil {
+		return fmt.Errorf("host %s is not found in CHI", fqdn)
+	}
+	var err error
+	restored.GetShard().WalkHosts(func(host *api.ChiHost) error {
+		if (host == restored) || (err != nil) {
+			return nil
+		}
+		if dropped {
+			if e := w.ensureClusterSchemer(host).HostCreateTables(ctx, host); e != nil {
+				err = fmt.Errorf("unable to create tables of replica %s err: %v", host.GetName(), e)
+				return nil
+			}
+		}
+		if e := w.ensureClusterSchemer(host).HostSyncTables(ctx, host); e != nil {
+			err = fmt.Errorf("unable to sync replica %s err: %v", host.GetName(), e)
+		}
+		return nil
+	})
+	return err
+}
+
+// announceRestoreFinished emits event on the CHI restore is finished with
+func (w *worker) announceRestoreFinished(chr *api.ClickHouseRestore) {
+	chi, err := w.c.GetCHIByObjectMeta(&meta.ObjectMeta{Namespace: chr.Namespace, Name: chr.Spec.CHI}, true)
+	if err != nil {
+		return
+	}
+	if chr.Status.Status == api.BackupStatusCompleted {
+		w.a.V(1).
+			WithEvent(chi, eventActionRestore, eventReasonRestoreCompleted).
+			M(chi).F().
+			Info("Restore %s completed", chr.Name)
+	} else {
+		w.a.V(1).
+			WithEvent(chi, eventActionRestore, eventReasonRestoreFailed).
+			M(chi).F().
+			Warning("Restore %s failed. %s", chr.Name, chr.Status.Error)
+	}
+}
+
+// findHostByFQDN finds host of the CHI by FQDN
+func findHostByFQDN(chi *api.ClickHouseInstallation, fqdn string) (found *api.ChiHost) {
+	chi.WalkHosts(func(host *api.ChiHost) error {
+		if (found == nil) && (model.CreateFQDN(host) == fqdn) {
+			found = host
+		}
+		return nil
+	})
+	return found
+}
@@ -0,0 +1,142 @@
+package chi
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	chopFake "github.com/altinity/clickhouse-operator/pkg/client/clientset/versioned/fake"
+	chopListers "github.com/altinity/clickhouse-operator/pkg/client/listers/clickhouse.altinity.com/v1"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi/backup"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi/normalizer"
+)
+
+func Test_GetRestoreInProgress(t *testing.T) {
+	chi := &api.ClickHouseInstallation{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "chi"}}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	c := &Controller{chrLister: chopListers.NewClickHouseRestoreLister(indexer)}
+
+	completed := &api.ClickHouseRestore{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "completed"}}
+	completed.Spec.CHI = "chi"
+	completed.EnsureStatus().Finish()
+	other := &api.ClickHouseRestore{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "other"}}
+	other.Spec.CHI = "other"
+	other.EnsureStatus().Start()
+	require.NoError(t, indexer.Add(completed))
+	require.NoError(t, indexer.Add(other))
+	require.Nil(t, c.getRestoreInProgress(chi))
+
+	running := &api.ClickHouseRestore{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "running"}}
+	running.Spec.CHI = "chi"
+	running.EnsureStatus().Start()
+	require.NoError(t, indexer.Add(running))
+	require.Equal(t, running, c.getRestoreInProgress(chi))
+
+	// Controller without restores
+	require.Nil(t, (&Controller{}).getRestoreInProgress(chi))
+}
+
+func Test_StartRestore(t *testing.T) {
+	var started []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/backup/restore_remote/chi-cluster-0-daily":
+			started = append(started, r.URL.String())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	p, _ := strconv.Atoi(port)
+
+	w := &worker{a: NewAnnouncer()}
+	chr := &api.ClickHouseRestore{}
+	chr.Spec.Port = int32(p)
+	chr.Spec.Remote = api.NewStringBool(true)
+	chr.Spec.DropExisting = api.NewStringBool(true)
+	chr.Spec.Tables = "db.*"
+	status := chr.EnsureStatus()
+	status.Start()
+	status.SetHostStatus(host, &api.ChbHostStatus{Backup: "chi-cluster-0-daily", Status: api.BackupStatusInProgress})
+	status.SetHostStatus("unreachable.invalid", &api.ChbHostStatus{Backup: "chi-cluster-1-daily", Status: api.BackupStatusInProgress})
+	require.True(t, hasPendingRestoreHosts(status))
+
+	w.startRestore(context.Background(), chr)
+	require.Equal(t, []string{"/backup/restore_remote/chi-cluster-0-daily?rm=true&table=db.%2A"}, started)
+	require.False(t, hasPendingRestoreHosts(status))
+	require.Equal(t, backup.OperationRestoreRemote, status.Hosts[host].Operation)
+	require.Equal(t, api.BackupStatusInProgress, status.Hosts[host].Status)
+	require.Equal(t, api.BackupStatusFailed, status.Hosts["unreachable.invalid"].Status)
+
+	// Restore is not started twice
+	w.startRestore(context.Background(), chr)
+	require.Len(t, started, 1)
+}
+
+func Test_FindHostByFQDN(t *testing.T) {
+	hosts := newTestShard(2)
+	for _, host := range hosts {
+		host.Runtime.Address.Namespace = "ns"
+		host.Runtime.Address.HostName = host.Name
+	}
+	chi := hosts[0].GetCHI()
+	require.Equal(t, hosts[1], findHostByFQDN(chi, model.CreateFQDN(hosts[1])))
+	require.Nil(t, findHostByFQDN(chi, "unknown"))
+}
+
+func Test_CreateCompletedCHIFromObjectMeta(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+
+	completed := &api.ClickHouseInstallation{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "chi"}}
+	completed.Spec.Configuration = &api.Configuration{Clusters: []*api.Cluster{{Name: "completed"}}}
+	chi := &api.ClickHouseInstallation{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "chi"}}
+	chi.Spec.Configuration = &api.Configuration{Clusters: []*api.Cluster{{Name: "pending"}}}
+	chi.SetAncestor(completed)
+	unreconciled := &api.ClickHouseInstallation{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "unreconciled"}}
+
+	w := &worker{
+		c:          &Controller{chopClient: chopFake.NewSimpleClientset(chi, unreconciled)},
+		normalizer: normalizer.NewNormalizer(nil),
+	}
+
+	// Spec changes not reconciled yet are not rendered
+	rendered, err := w.createCompletedCHIFromObjectMeta(&chi.ObjectMeta, normalizer.NewOptions())
+	require.NoError(t, err)
+	require.Len(t, rendered.Spec.Configuration.Clusters, 1)
+	require.Equal(t, "completed", rendered.Spec.Configuration.Clusters[0].Name)
+
+	_, err = w.createCompletedCHIFromObjectMeta(&unreconciled.ObjectMeta, normalizer.NewOptions())
+	require.Error(t, err)
+}
+
+func Test_RestoreTimeout(t *testing.T) {
+	chr := &api.ClickHouseRestore{}
+	require.Equal(t, api.DefaultRestoreTimeout*time.Second, chr.Spec.GetTimeout())
+	chr.Spec.Timeout = 60
+	status := chr.EnsureStatus()
+	status.Start()
+	status.SetHostStatus("restored", &api.ChbHostStatus{Status: api.BackupStatusCompleted})
+	status.SetHostStatus("running", &api.ChbHostStatus{Status: api.BackupStatusInProgress})
+	require.False(t, status.IsTimedOut(chr.Spec.GetTimeout()))
+
+	status.StartedAt = time.Now().Add(-2 * time.Minute).Format(time.RFC3339)
+	require.True(t, status.IsTimedOut(chr.Spec.GetTimeout()))
+	status.FailHostsInProgress("timed out")
+	require.True(t, status.IsHostsFinished())
+	require.Equal(t, api.BackupStatusCompleted, status.Hosts["restored"].Status)
+	require.Equal(t, "timed out", status.Hosts["running"].Error)
+	status.Finish()
+	require.Equal(t, api.BackupStatusFailed, status.Status)
+}
@@ -0,0 +1,232 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/chop"
+	"github.com/altinity/clickhouse-operator/pkg/controller"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
+// nextFailedRollout accounts one more failed rollout attempt of the StatefulSet version.
+// Failures of the previous versions are not taken into account.
+func nextFailedRollout(prev api.ChiStatefulSetRollout, version string) api.ChiStatefulSetRollout {
+	if prev.Version != version {
+		return api.ChiStatefulSetRollout{
+			Version:  version,
+			Failures: 1,
+		}
+	}
+	prev.Failures++
+	return prev
+}
+
+// isStatefulSetRolledBack checks whether desired StatefulSet of the host was rolled back after repeated rollout failures.
+// Such a StatefulSet spec is not applied again until it is edited.
+func (w *worker) isStatefulSetRolledBack(host *api.ChiHost) bool {
+	if !chop.Config().Reconcile.StatefulSet.Update.AutoRollback.IsEnabled() {
+		return false
+	}
+	statefulSet := host.Runtime.DesiredStatefulSet
+	version, _ := model.GetObjectVersion(statefulSet.ObjectMeta)
+	rollout, ok := host.GetCHI().EnsureStatus().GetFailedRollout(statefulSet.Name)
+	return ok && rollout.RolledBack && (rollout.Version == version)
+}
+
+// hasFailedRollout checks whether host's StatefulSet failed to roll out and auto-rollback is enabled
+func (w *worker) hasFailedRollout(host *api.ChiHost) bool {
+	if !chop.Config().Reconcile.StatefulSet.Update.AutoRollback.IsEnabled() {
+		return false
+	}
+	_, ok := host.GetCHI().EnsureStatus().GetFailedRollout(host.Runtime.DesiredStatefulSet.Name)
+	return ok
+}
+
+// retryStatefulSetRollout updates StatefulSet, which failed to roll out previously, and waits for it to be ready
+func (w *worker) retryStatefulSetRollout(
+	ctx context.Context,
+	curStatefulSet *apps.StatefulSet,
+	newStatefulSet *apps.StatefulSet,
+	host *api.ChiHost,
+) ErrorCRUD {
+	if action := w.c.updateStatefulSet(ctx, curStatefulSet, newStatefulSet, host); action != nil {
+		return action
+	}
+
+	// Generation is not changed in case the same spec is applied again, so wait for the rollout explicitly
+	if err := w.c.waitHostReady(ctx, host); err != nil {
+		w.a.V(1).M(host).F().Warning("StatefulSet rollout wait failed. err: %v", err)
+		return w.c.onStatefulSetUpdateFailed(ctx, curStatefulSet, host)
+	}
+	return nil
+}
+
+// onStatefulSetRolledOut clears failed rollouts of the host's StatefulSet
+func (w *worker) onStatefulSetRolledOut(host *api.ChiHost) {
+	host.GetCHI().EnsureStatus().SetFailedRollout(host.Runtime.DesiredStatefulSet.Name, nil)
+}
+
+// onStatefulSetRolloutFailed accounts failed rollout of the host's StatefulSet in case auto-rollback is enabled
+func (w *worker) onStatefulSetRolloutFailed(ctx context.Context, host *api.ChiHost) {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return
+	}
+
+	autoRollback := chop.Config().Reconcile.StatefulSet.Update.AutoRollback
+	if !autoRollback.IsEnabled() {
+		return
+	}
+
+	w.rollbackStatefulSetOnRepeatedFailures(ctx, host, autoRollback.FailedAttempts)
+	_ = w.c.updateCHIObjectStatus(ctx, host.GetCHI(), UpdateCHIStatusOptions{
+		CopyCHIStatusOptions: api.CopyCHIStatusOptions{
+			MainFields: true,
+		},
+	})
+}
+
+// rollbackStatefulSetOnRepeatedFailures accounts failed rollout of the host's StatefulSet and rolls StatefulSet back
+// to the last known-good revision in case the same spec failed to roll out failedAttempts times.
+// Returns true in case StatefulSet was rolled back.
+func (w *worker) rollbackStatefulSetOnRepeatedFailures(ctx context.Context, host *api.ChiHost, failedAttempts int) bool {
+	statefulSet := host.Runtime.DesiredStatefulSet
+	version, _ := model.GetObjectVersion(statefulSet.ObjectMeta)
+	status := host.GetCHI().EnsureStatus()
+
+	prev, _ := status.GetFailedRollout(statefulSet.Name)
+	rollout := nextFailedRollout(prev, version)
+	defer status.SetFailedRollout(statefulSet.Name, &rollout)
+
+	w.a.V(1).M(host).F().Warning(
+		"StatefulSet %s failed to roll out. Failed attempts: %d of %d",
+		util.NamespaceNameString(statefulSet.ObjectMeta), rollout.Failures, failedAttempts)
+
+	if rollout.RolledBack || (rollout.Failures < failedAttempts) {
+		return false
+	}
+
+	revision, err := w.rollbackStatefulSet(ctx, host)
+	if err != nil {
+		w.a.V(1).M(host).F().Warning(
+			"Unable to roll back StatefulSet %s. err: %v",
+			util.NamespaceNameString(statefulSet.ObjectMeta), err)
+		return false
+	}
+
+	rollout.RolledBack = true
+	w.a.V(1).
+		WithEvent(host.GetCHI(), eventActionUpdate, eventReasonRolledBack).
+		WithStatusAction(host.GetCHI()).
+		M(host).F().
+		Warning("StatefulSet %s failed to roll out %d times and was rolled back to revision %s. "+
+			"Spec is not applied until edited",
+			util.NamespaceNameString(statefulSet.ObjectMeta), rollout.Failures, revision)
+	return true
+}
+
+// rollbackStatefulSet reverts pod template of the host's StatefulSet to the last known-good revision.
+// Returns name of the revision StatefulSet was rolled back to.
+func (w *worker) rollbackStatefulSet(ctx context.Context, host *api.ChiHost) (string, error) {
+	namespace := host.Runtime.DesiredStatefulSet.Namespace
+	name := host.Runtime.DesiredStatefulSet.Name
+
+	statefulSet, err := w.c.kubeClient.AppsV1().StatefulSets(namespace).Get(ctx, name, controller.NewGetOptions())
+	if err != nil {
+		return "", err
+	}
+
+	selector, err := meta.LabelSelectorAsSelector(statefulSet.Spec.Selector)
+	if err != nil {
+		return "", err
+	}
+	opts := controller.NewListOptions()
+	opts.LabelSelector = selector.String()
+	revisions, err := w.c.kubeClient.AppsV1().ControllerRevisions(namespace).List(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+
+	revision := selectKnownGoodRevision(statefulSet, revisions.Items)
+	if revision == nil {
+		return "", fmt.Errorf("no known-good revision found")
+	}
+	template, err := getRevisionPodTemplate(revision)
+	if err != nil {
+		return "", err
+	}
+
+	statefulSet.Spec.Template = *template
+	statefulSet, err = w.c.kubeClient.AppsV1().StatefulSets(namespace).Update(ctx, statefulSet, controller.NewUpdateOptions())
+	if err != nil {
+		return "", err
+	}
+
+	// Pod, which is stuck with the failed revision, may not be replaced by StatefulSet controller.
+	// Delete Pod to get it recreated with the rolled back pod template.
+	_ = w.c.statefulSetDeletePod(ctx, statefulSet, host)
+
+	return revision.Name, nil
+}
+
+// selectKnownGoodRevision selects the last revision of the StatefulSet, which is not the one being rolled out
+func selectKnownGoodRevision(statefulSet *apps.StatefulSet, revisions []apps.ControllerRevision) *apps.ControllerRevision {
+	var known *apps.ControllerRevision
+	for i := range revisions {
+		revision := &revisions[i]
+		if !meta.IsControlledBy(revision, statefulSet) {
+			continue
+		}
+		if revision.Name == statefulSet.Status.UpdateRevision {
+			continue
+		}
+		if revision.Name == statefulSet.Status.CurrentRevision {
+			// Revision, which pods are running, prevails
+			return revision
+		}
+		if (known == nil) || (revision.Revision > known.Revision) {
+			known = revision
+		}
+	}
+	return known
+}
+
+// getRevisionPodTemplate extracts pod template from the StatefulSet's controller revision.
+// StatefulSet controller revision stores pod template as a patch of the StatefulSet's spec.
+func getRevisionPodTemplate(revision *apps.ControllerRevision) (*core.PodTemplateSpec, error) {
+	var patch struct {
+		Spec struct {
+			Template *core.PodTemplateSpec `json:"template"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(revision.Data.Raw, &patch); err != nil {
+		return nil, err
+	}
+	if patch.Spec.Template == nil {
+		return nil, fmt.Errorf("revision %s has no pod template", revision.Name)
+	}
+	return patch.Spec.Template, nil
+}
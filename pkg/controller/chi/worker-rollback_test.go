@@ -0,0 +1,173 @@
+package chi
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+)
+
+func newRollbackTestPodTemplate(image string) core.PodTemplateSpec {
+	return core.PodTemplateSpec{
+		ObjectMeta: meta.ObjectMeta{
+			Labels: map[string]string{"app": "chi"},
+		},
+		Spec: core.PodSpec{
+			Containers: []core.Container{
+				{
+					Name:  "clickhouse",
+					Image: image,
+				},
+			},
+		},
+	}
+}
+
+func newRollbackTestRevision(t *testing.T, statefulSet *apps.StatefulSet, name string, revision int64, image string) *apps.ControllerRevision {
+	template := newRollbackTestPodTemplate(image)
+	raw, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": template,
+		},
+	})
+	require.NoError(t, err)
+	return &apps.ControllerRevision{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace:       statefulSet.Namespace,
+			Name:            name,
+			Labels:          map[string]string{"app": "chi"},
+			OwnerReferences: []meta.OwnerReference{*meta.NewControllerRef(statefulSet, apps.SchemeGroupVersion.WithKind("StatefulSet"))},
+		},
+		Data:     runtime.RawExtension{Raw: raw},
+		Revision: revision,
+	}
+}
+
+// newRollbackTestHost builds host, which StatefulSet rollout of the "broken" image fails,
+// while "good" image is the current revision
+func newRollbackTestHost(t *testing.T) (*api.ChiHost, *worker, *kubeFake.Clientset) {
+	statefulSet := &apps.StatefulSet{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi-0-0",
+			UID:       types.UID("sts-uid"),
+		},
+		Spec: apps.StatefulSetSpec{
+			Selector: &meta.LabelSelector{MatchLabels: map[string]string{"app": "chi"}},
+			Template: newRollbackTestPodTemplate("broken"),
+		},
+		Status: apps.StatefulSetStatus{
+			CurrentRevision: "chi-0-0-good",
+			UpdateRevision:  "chi-0-0-broken",
+		},
+	}
+	model.MakeObjectVersion(&statefulSet.ObjectMeta, statefulSet.Spec)
+
+	good := newRollbackTestRevision(t, statefulSet, "chi-0-0-good", 1, "good")
+	broken := newRollbackTestRevision(t, statefulSet, "chi-0-0-broken", 2, "broken")
+
+	kubeClient := kubeFake.NewSimpleClientset(statefulSet.DeepCopy(), good, broken)
+	w := &worker{
+		c: &Controller{
+			kubeClient: kubeClient,
+		},
+		a: NewAnnouncer(),
+	}
+
+	host := &api.ChiHost{Name: "0-0"}
+	host.Runtime.CHI = &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+		},
+	}
+	host.Runtime.DesiredStatefulSet = statefulSet
+	return host, w, kubeClient
+}
+
+func Test_NextFailedRollout(t *testing.T) {
+	rollout := nextFailedRollout(api.ChiStatefulSetRollout{}, "v1")
+	require.Equal(t, api.ChiStatefulSetRollout{Version: "v1", Failures: 1}, rollout)
+
+	rollout = nextFailedRollout(rollout, "v1")
+	require.Equal(t, api.ChiStatefulSetRollout{Version: "v1", Failures: 2}, rollout)
+
+	// Edited spec starts from scratch
+	rollout.RolledBack = true
+	rollout = nextFailedRollout(rollout, "v2")
+	require.Equal(t, api.ChiStatefulSetRollout{Version: "v2", Failures: 1}, rollout)
+}
+
+func Test_RollbackStatefulSetOnRepeatedFailures(t *testing.T) {
+	host, w, kubeClient := newRollbackTestHost(t)
+	ctx := context.Background()
+	name := host.Runtime.DesiredStatefulSet.Name
+
+	getImage := func() string {
+		statefulSet, err := kubeClient.AppsV1().StatefulSets("ns").Get(ctx, name, meta.GetOptions{})
+		require.NoError(t, err)
+		return statefulSet.Spec.Template.Spec.Containers[0].Image
+	}
+
+	// Rollout failures below the threshold are only accounted
+	for attempt := 1; attempt < 3; attempt++ {
+		require.False(t, w.rollbackStatefulSetOnRepeatedFailures(ctx, host, 3))
+		rollout, ok := host.GetCHI().EnsureStatus().GetFailedRollout(name)
+		require.True(t, ok)
+		require.Equal(t, attempt, rollout.Failures)
+		require.False(t, rollout.RolledBack)
+		require.Equal(t, "broken", getImage())
+	}
+
+	// StatefulSet is rolled back to the last known-good revision
+	require.True(t, w.rollbackStatefulSetOnRepeatedFailures(ctx, host, 3))
+	rollout, ok := host.GetCHI().EnsureStatus().GetFailedRollout(name)
+	require.True(t, ok)
+	require.Equal(t, 3, rollout.Failures)
+	require.True(t, rollout.RolledBack)
+	require.Equal(t, "good", getImage())
+
+	// User's spec is preserved
+	require.Equal(t, "broken", host.Runtime.DesiredStatefulSet.Spec.Template.Spec.Containers[0].Image)
+
+	// Rolled back StatefulSet is not rolled back again
+	require.False(t, w.rollbackStatefulSetOnRepeatedFailures(ctx, host, 3))
+
+	// Successful rollout clears failures
+	w.onStatefulSetRolledOut(host)
+	_, ok = host.GetCHI().EnsureStatus().GetFailedRollout(name)
+	require.False(t, ok)
+}
+
+func Test_SelectKnownGoodRevision(t *testing.T) {
+	host, _, _ := newRollbackTestHost(t)
+	statefulSet := host.Runtime.DesiredStatefulSet.DeepCopy()
+	rev1 := newRollbackTestRevision(t, statefulSet, "rev-1", 1, "v1")
+	rev2 := newRollbackTestRevision(t, statefulSet, "rev-2", 2, "v2")
+	rev3 := newRollbackTestRevision(t, statefulSet, "rev-3", 3, "v3")
+	foreign := newRollbackTestRevision(t, statefulSet, "foreign", 4, "v4")
+	foreign.OwnerReferences = nil
+	revisions := []apps.ControllerRevision{*rev1, *rev2, *rev3, *foreign}
+
+	// Current revision prevails
+	statefulSet.Status.CurrentRevision = "rev-1"
+	statefulSet.Status.UpdateRevision = "rev-3"
+	require.Equal(t, "rev-1", selectKnownGoodRevision(statefulSet, revisions).Name)
+
+	// The latest revision, which is not being rolled out
+	statefulSet.Status.CurrentRevision = "rev-3"
+	require.Equal(t, "rev-2", selectKnownGoodRevision(statefulSet, revisions).Name)
+
+	// No revision to roll back to
+	require.Nil(t, selectKnownGoodRevision(statefulSet, []apps.ControllerRevision{*rev3, *foreign}))
+}
@@ -0,0 +1,222 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	k8sLabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/chop"
+	"github.com/altinity/clickhouse-operator/pkg/controller"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi/normalizer"
+	"github.com/altinity/clickhouse-operator/pkg/model/clickhouse"
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
+// credentialsRetryInterval specifies interval to check whether hosts accept rotated ClickHouse access credentials
+const credentialsRetryInterval = 30 * time.Second
+
+// processSecretRotation re-renders users of the CHIs having user passwords read from the rotated secret.
+// Passwords read from secrets are rendered into users ConfigMap, which ClickHouse picks up on the fly,
+// so hosts are not restarted.
+func (w *worker) processSecretRotation(ctx context.Context, cmd *SecretRotation) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	if chop.Get().ConfigManager.IsSecretCredentials(cmd.initiator.Namespace, cmd.initiator.Name) {
+		return w.rotateCredentials(ctx, cmd)
+	}
+
+	// Only CHIs rendering values of the secret into config are reconciled, so no other CHI is normalized
+	for _, key := range w.c.secretIndex.GetCHIs(cmd.initiator.Namespace, cmd.initiator.Name) {
+		namespace, name, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil {
+			continue
+		}
+		w.a.V(1).M(namespace, name).F().Info("Secret %s/%s rotated, reconcile users of CHI %s/%s",
+			cmd.initiator.Namespace, cmd.initiator.Name, namespace, name)
+		// Rotated secret may keep TLS material hosts of the CHI are accessed with
+		w.c.accessTLS.Invalidate(namespace, name)
+		if err := w.reconcileUsers(ctx, cmd, namespace, name); err != nil {
+			w.a.M(namespace, name).F().Error("unable to reconcile users of CHI %s/%s err: %v", namespace, name, err)
+		}
+	}
+	return nil
+}
+
+// rotateCredentials switches the operator to credentials rotated in the secret and re-renders users of all CHIs,
+// so the user the operator accesses ClickHouse instances with has the rotated password.
+// Schemer is built out of the operator config for each operation, thus picks up rotated credentials right away.
+// Hosts accept rotated credentials only after users config is picked up, so previous credentials are kept
+// as a fallback till all hosts accept rotated ones.
+func (w *worker) rotateCredentials(ctx context.Context, cmd *SecretRotation) error {
+	if chop.Get().ConfigManager.UpdateSecretCredentials() {
+		w.a.V(1).Info("Secret %s/%s rotated, switch to rotated ClickHouse access credentials",
+			cmd.initiator.Namespace, cmd.initiator.Name)
+	}
+
+	// Users are re-rendered even in case credentials are already switched, since the command may be requeued
+	// because of some CHIs being busy
+	chis, err := w.c.chiLister.List(k8sLabels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, chi := range chis {
+		if !chop.Config().IsWatchedNamespace(chi.Namespace) {
+			continue
+		}
+		if err := w.reconcileUsers(ctx, cmd, chi.Namespace, chi.Name); err != nil {
+			w.a.M(chi).F().Error("unable to reconcile users of CHI %s/%s err: %v", chi.Namespace, chi.Name, err)
+		}
+	}
+
+	if username, _ := chop.Config().GetPreviousAccessCredentials(); username == "" {
+		// Nothing to fallback to
+		return nil
+	}
+	for _, chi := range chis {
+		if !chop.Config().IsWatchedNamespace(chi.Namespace) {
+			continue
+		}
+		if w.isAccessCredentialsRejected(ctx, chi) {
+			w.a.V(1).M(chi).F().Info("CHI %s/%s does not accept rotated ClickHouse access credentials yet, keep previous ones",
+				chi.Namespace, chi.Name)
+			w.c.requeueItem(cmd, credentialsRetryInterval)
+			return nil
+		}
+	}
+	w.a.V(1).Info("Rotated ClickHouse access credentials are accepted, drop previous ones")
+	chop.Config().ClearPreviousAccessCredentials()
+	return nil
+}
+
+// isAccessCredentialsRejected checks whether any host of the CHI rejects credentials the operator accesses
+// ClickHouse instances with. Hosts, which are not reachable, pick up rotated credentials as soon as they are up
+func (w *worker) isAccessCredentialsRejected(ctx context.Context, chi *api.ClickHouseInstallation) bool {
+	if !chi.HasAncestor() {
+		// CHI is not reconciled yet, thus has no hosts to check
+		return false
+	}
+	completed, err := w.createCompletedCHIFromObjectMeta(&chi.ObjectMeta, normalizer.NewOptions())
+	if err != nil {
+		w.a.M(chi).F().Error("unable to normalize CHI %s/%s err: %v", chi.Namespace, chi.Name, err)
+		return false
+	}
+
+	rejected := false
+	completed.WalkHosts(func(host *api.ChiHost) error {
+		if rejected {
+			return nil
+		}
+		s := w.ensureClusterSchemer(host)
+		// Check rotated credentials alone
+		s.SetFallbackCredentials("", "")
+		opts := clickhouse.NewQueryOptions().SetSilent(true).SetRetry(false)
+		_, err := s.QueryHostString(ctx, host, "SELECT 1", opts)
+		rejected = clickhouse.IsAuthenticationError(err)
+		return nil
+	})
+	return rejected
+}
+
+// reconcileGeneratedPasswordsSecret generates passwords of the users, which do not have passwords in the Secret of the CHI yet,
+// stores them into the Secret and provides the users with passwords the Secret holds after being written.
+// Passwords already stored are never overwritten, so users keep passwords consumers read from the Secret.
+// In case the Secret is not available users config must not be rendered, since the Secret may have passwords already.
+func (w *worker) reconcileGeneratedPasswordsSecret(ctx context.Context, chi *api.ClickHouseInstallation) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	runtime := chi.EnsureRuntime()
+	if err := runtime.GetGeneratedPasswordsError(); err != nil {
+		return fmt.Errorf("unable to read generated passwords err: %v", err)
+	}
+	usernames := runtime.GetPendingGeneratedPasswords()
+	if len(usernames) == 0 {
+		return nil
+	}
+
+	secret := w.task.creator.CreateGeneratedPasswordsSecret(nil)
+	cur, err := w.c.kubeClient.CoreV1().Secrets(secret.Namespace).Get(ctx, secret.Name, controller.NewGetOptions())
+	switch {
+	case apiErrors.IsNotFound(err):
+		secret.Data = generatePasswords(nil, usernames)
+		cur, err = w.c.kubeClient.CoreV1().Secrets(secret.Namespace).Create(ctx, secret, controller.NewCreateOptions())
+	case err == nil:
+		cur = cur.DeepCopy()
+		cur.Labels = util.MergeStringMapsOverwrite(cur.Labels, secret.Labels)
+		if len(cur.OwnerReferences) == 0 {
+			cur.OwnerReferences = secret.OwnerReferences
+		}
+		cur.Data = generatePasswords(cur.Data, usernames)
+		// Update is conditional on resource version, so passwords stored concurrently are not overwritten
+		cur, err = w.c.kubeClient.CoreV1().Secrets(cur.Namespace).Update(ctx, cur, controller.NewUpdateOptions())
+	}
+
+	if err != nil {
+		w.task.registryFailed.RegisterSecret(secret.ObjectMeta)
+		w.a.M(chi).F().Error("unable to store generated passwords into Secret %s/%s err: %v", secret.Namespace, secret.Name, err)
+		return err
+	}
+	w.task.registryReconciled.RegisterSecret(secret.ObjectMeta)
+
+	// Users get passwords the Secret holds, which are not necessarily the ones generated right now
+	for _, username := range usernames {
+		password, ok := cur.Data[username]
+		if !ok {
+			return fmt.Errorf("generated password of the user %s is not stored in Secret %s/%s", username, secret.Namespace, secret.Name)
+		}
+		setUserPassword(chi, username, string(password))
+	}
+	return nil
+}
+
+// generatePasswords generates passwords of the users, which do not have passwords yet
+func generatePasswords(passwords map[string][]byte, usernames []string) map[string][]byte {
+	if passwords == nil {
+		passwords = make(map[string][]byte)
+	}
+	for _, username := range usernames {
+		if _, ok := passwords[username]; !ok {
+			passwords[username] = []byte(util.RandStringRange(20, 30))
+		}
+	}
+	return passwords
+}
+
+// setUserPassword sets password of the user of the normalized CHI, replacing password specified by normalization
+func setUserPassword(chi *api.ClickHouseInstallation, username, password string) {
+	if (chi.Spec.Configuration == nil) || (chi.Spec.Configuration.Users == nil) {
+		return
+	}
+	user := api.NewSettingsUser(chi.Spec.Configuration.Users, username)
+	sum := sha256.Sum256([]byte(password))
+	user.Set("password_sha256_hex", api.NewSettingScalar(hex.EncodeToString(sum[:])))
+	user.Delete("password")
+	user.Delete("password_double_sha1_hex")
+}
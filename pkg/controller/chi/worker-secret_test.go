@@ -0,0 +1,86 @@
+package chi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	chiCreator "github.com/altinity/clickhouse-operator/pkg/model/chi/creator"
+)
+
+func newGeneratedPasswordsTestCHI(usernames ...string) *api.ClickHouseInstallation {
+	chi := &api.ClickHouseInstallation{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "chi"}}
+	chi.Spec.Configuration = &api.Configuration{Users: api.NewSettings()}
+	for _, username := range usernames {
+		chi.EnsureRuntime().AddPendingGeneratedPassword(username)
+	}
+	return chi
+}
+
+func requireUserPassword(t *testing.T, chi *api.ClickHouseInstallation, username string, password []byte) {
+	sum := sha256.Sum256(password)
+	user := api.NewSettingsUser(chi.Spec.Configuration.Users, username)
+	require.Equal(t, hex.EncodeToString(sum[:]), user.Get("password_sha256_hex").String())
+	require.False(t, user.Has("password"))
+}
+
+func Test_ReconcileGeneratedPasswordsSecret(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+	ctx := context.Background()
+
+	// Password stored in the Secret already is kept, missing one is generated
+	chi := newGeneratedPasswordsTestCHI("alice", "bob")
+	stored := &core.Secret{
+		ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: model.CreateGeneratedPasswordsSecretName(chi)},
+		Data:       map[string][]byte{"bob": []byte("stored")},
+	}
+	kubeClient := kubeFake.NewSimpleClientset(stored)
+	w := &worker{
+		c:    &Controller{kubeClient: kubeClient},
+		a:    NewAnnouncer(),
+		task: newTask(chiCreator.NewCreator(chi)),
+	}
+	require.NoError(t, w.reconcileGeneratedPasswordsSecret(ctx, chi))
+	secret, err := kubeClient.CoreV1().Secrets("ns").Get(ctx, stored.Name, meta.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []byte("stored"), secret.Data["bob"])
+	require.NotEmpty(t, secret.Data["alice"])
+	require.Equal(t, "chi", secret.Labels[model.LabelCHIName])
+	require.Len(t, secret.OwnerReferences, 1)
+
+	// Users are rendered with passwords the Secret holds
+	requireUserPassword(t, chi, "alice", secret.Data["alice"])
+	requireUserPassword(t, chi, "bob", []byte("stored"))
+
+	// Secret is created with CHI labels and owner
+	chi = newGeneratedPasswordsTestCHI("carol")
+	kubeClient = kubeFake.NewSimpleClientset()
+	w.c.kubeClient = kubeClient
+	w.task = newTask(chiCreator.NewCreator(chi))
+	require.NoError(t, w.reconcileGeneratedPasswordsSecret(ctx, chi))
+	secret, err = kubeClient.CoreV1().Secrets("ns").Get(ctx, stored.Name, meta.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "chi", secret.Labels[model.LabelCHIName])
+	require.Len(t, secret.OwnerReferences, 1)
+	requireUserPassword(t, chi, "carol", secret.Data["carol"])
+
+	// Secret not available is neither written nor made up for
+	chi = newGeneratedPasswordsTestCHI("dave")
+	chi.EnsureRuntime().SetGeneratedPasswordsError(fmt.Errorf("connection refused"))
+	kubeClient = kubeFake.NewSimpleClientset()
+	w.c.kubeClient = kubeClient
+	w.task = newTask(chiCreator.NewCreator(chi))
+	require.Error(t, w.reconcileGeneratedPasswordsSecret(ctx, chi))
+	_, err = kubeClient.CoreV1().Secrets("ns").Get(ctx, stored.Name, meta.GetOptions{})
+	require.Error(t, err)
+	require.False(t, api.NewSettingsUser(chi.Spec.Configuration.Users, "dave").Has("password_sha256_hex"))
+}
@@ -0,0 +1,176 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"context"
+	"sort"
+
+	core "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/controller"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
+// getServiceAccounts collects ServiceAccounts referenced by pod templates of the hosts.
+// Returns ServiceAccounts to be managed by the operator along with their annotations
+// and ServiceAccounts expected to be provided by the user
+func getServiceAccounts(chi *api.ClickHouseInstallation) (managed map[string]map[string]string, provided []string) {
+	managed = make(map[string]map[string]string)
+	checked := make(map[string]bool)
+	chi.WalkHosts(func(host *api.ChiHost) error {
+		podTemplate, ok := host.GetPodTemplate()
+		if !ok {
+			return nil
+		}
+		name := podTemplate.ServiceAccountName
+		if name == "" {
+			name = podTemplate.Spec.ServiceAccountName
+		}
+		if name == "" {
+			return nil
+		}
+		if podTemplate.ManageServiceAccount.IsTrue() {
+			managed[name] = util.MergeStringMapsOverwrite(managed[name], podTemplate.ServiceAccountAnnotations)
+			return nil
+		}
+		if !checked[name] {
+			checked[name] = true
+			provided = append(provided, name)
+		}
+		return nil
+	})
+
+	// ServiceAccount managed by one pod template is not expected to be provided for another one
+	var res []string
+	for _, name := range provided {
+		if _, ok := managed[name]; !ok {
+			res = append(res, name)
+		}
+	}
+	return managed, res
+}
+
+// reconcileServiceAccounts creates ServiceAccounts managed by the operator
+// and checks ServiceAccounts provided by the user are in place
+func (w *worker) reconcileServiceAccounts(ctx context.Context, chi *api.ClickHouseInstallation) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	managed, provided := getServiceAccounts(chi)
+
+	var names []string
+	for name := range managed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		serviceAccount := w.task.creator.CreateServiceAccount(name, managed[name])
+		if err := w.reconcileServiceAccount(ctx, chi, serviceAccount); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range provided {
+		_, err := w.c.kubeClient.CoreV1().ServiceAccounts(chi.Namespace).Get(ctx, name, controller.NewGetOptions())
+		switch {
+		case err == nil:
+			// ServiceAccount is in place
+		case apiErrors.IsNotFound(err):
+			w.a.V(1).
+				WithEvent(chi, eventActionReconcile, eventReasonServiceAccountNotFound).
+				M(chi).F().
+				Warning("ServiceAccount %s/%s referenced by pod template not found", chi.Namespace, name)
+		default:
+			w.a.V(1).M(chi).F().Info("unable to check ServiceAccount %s/%s err: %v", chi.Namespace, name, err)
+		}
+	}
+
+	return nil
+}
+
+// reconcileServiceAccount reconciles ServiceAccount managed by the operator.
+// ServiceAccount created by someone else is left intact
+func (w *worker) reconcileServiceAccount(ctx context.Context, chi *api.ClickHouseInstallation, serviceAccount *core.ServiceAccount) error {
+	cur, err := w.c.kubeClient.CoreV1().ServiceAccounts(serviceAccount.Namespace).Get(ctx, serviceAccount.Name, controller.NewGetOptions())
+	switch {
+	case err == nil:
+		if !isServiceAccountOfCHI(chi, cur) {
+			w.a.V(1).M(chi).F().Info("ServiceAccount %s/%s is not managed by the operator, leave it intact", cur.Namespace, cur.Name)
+			return nil
+		}
+		// Keep token secrets maintained by k8s
+		serviceAccount.ResourceVersion = cur.ResourceVersion
+		serviceAccount.Secrets = cur.Secrets
+		serviceAccount.ImagePullSecrets = cur.ImagePullSecrets
+		_, err = w.c.kubeClient.CoreV1().ServiceAccounts(serviceAccount.Namespace).Update(ctx, serviceAccount, controller.NewUpdateOptions())
+	case apiErrors.IsNotFound(err):
+		_, err = w.c.kubeClient.CoreV1().ServiceAccounts(serviceAccount.Namespace).Create(ctx, serviceAccount, controller.NewCreateOptions())
+	}
+
+	if err != nil {
+		w.a.WithEvent(chi, eventActionReconcile, eventReasonReconcileFailed).
+			WithStatusAction(chi).
+			WithStatusError(chi).
+			M(chi).F().
+			Error("FAILED to reconcile ServiceAccount: %s/%s CHI: %s err: %v", serviceAccount.Namespace, serviceAccount.Name, chi.Name, err)
+		return err
+	}
+
+	w.a.V(1).M(chi).F().Info("ServiceAccount reconcile successful: %s/%s", serviceAccount.Namespace, serviceAccount.Name)
+	return nil
+}
+
+// cleanupServiceAccounts deletes ServiceAccounts managed by the operator, which are not referenced by the CHI anymore
+func (w *worker) cleanupServiceAccounts(ctx context.Context, chi *api.ClickHouseInstallation) {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return
+	}
+
+	managed, _ := getServiceAccounts(chi)
+	selector := model.NewLabeler(chi).GetSelectorCHIScope()
+	list, err := w.c.kubeClient.CoreV1().ServiceAccounts(chi.Namespace).List(ctx, controller.NewListOptions(selector))
+	if err != nil {
+		w.a.V(1).M(chi).F().Info("unable to list ServiceAccounts of CHI %s/%s err: %v", chi.Namespace, chi.Name, err)
+		return
+	}
+
+	for i := range list.Items {
+		serviceAccount := &list.Items[i]
+		if _, ok := managed[serviceAccount.Name]; ok {
+			continue
+		}
+		err := w.c.kubeClient.CoreV1().ServiceAccounts(serviceAccount.Namespace).Delete(ctx, serviceAccount.Name, controller.NewDeleteOptions())
+		if (err != nil) && !apiErrors.IsNotFound(err) {
+			w.a.V(1).M(chi).F().Warning("unable to delete ServiceAccount %s/%s err: %v", serviceAccount.Namespace, serviceAccount.Name, err)
+			continue
+		}
+		w.a.V(1).M(chi).F().Info("ServiceAccount %s/%s is not used anymore and deleted", serviceAccount.Namespace, serviceAccount.Name)
+	}
+}
+
+// isServiceAccountOfCHI checks whether ServiceAccount is managed by the operator on behalf of the CHI
+func isServiceAccountOfCHI(chi *api.ClickHouseInstallation, serviceAccount *core.ServiceAccount) bool {
+	selector := labels.SelectorFromSet(model.NewLabeler(chi).GetSelectorCHIScope())
+	return selector.Matches(labels.Set(serviceAccount.Labels))
+}
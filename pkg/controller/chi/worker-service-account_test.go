@@ -0,0 +1,157 @@
+package chi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+)
+
+// newServiceAccountTestCHI builds CHI with a host per pod template
+func newServiceAccountTestCHI(templates ...*api.PodTemplate) *api.ClickHouseInstallation {
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+		},
+		Spec: api.ChiSpec{
+			Configuration: &api.Configuration{
+				Clusters: []*api.Cluster{
+					{
+						Name:   "cluster",
+						Layout: api.NewChiClusterLayout(),
+					},
+				},
+			},
+			Templates: &api.Templates{},
+		},
+	}
+	shard := api.ChiShard{}
+	for _, template := range templates {
+		chi.Spec.Templates.EnsurePodTemplatesIndex().Set(template.Name, template)
+		host := &api.ChiHost{
+			Name: template.Name,
+			Templates: &api.ChiTemplateNames{
+				PodTemplate: template.Name,
+			},
+		}
+		host.Runtime.CHI = chi
+		shard.Hosts = append(shard.Hosts, host)
+	}
+	chi.Spec.Configuration.Clusters[0].Layout.Shards = append(chi.Spec.Configuration.Clusters[0].Layout.Shards, shard)
+	return chi
+}
+
+func newServiceAccountTestWorker(objects ...runtime.Object) (*worker, *kubeFake.Clientset) {
+	kubeClient := kubeFake.NewSimpleClientset(objects...)
+	w := &worker{
+		c: &Controller{
+			kubeClient: kubeClient,
+		},
+		a: NewAnnouncer(),
+	}
+	return w, kubeClient
+}
+
+func listServiceAccounts(t *testing.T, kubeClient *kubeFake.Clientset) map[string]core.ServiceAccount {
+	list, err := kubeClient.CoreV1().ServiceAccounts("ns").List(context.Background(), meta.ListOptions{})
+	require.NoError(t, err)
+	res := make(map[string]core.ServiceAccount)
+	for _, serviceAccount := range list.Items {
+		res[serviceAccount.Name] = serviceAccount
+	}
+	return res
+}
+
+func Test_GetServiceAccounts(t *testing.T) {
+	chi := newServiceAccountTestCHI(
+		&api.PodTemplate{
+			Name:                      "managed",
+			ServiceAccountName:        "clickhouse-s3",
+			ManageServiceAccount:      api.NewStringBool(true),
+			ServiceAccountAnnotations: map[string]string{"eks.amazonaws.com/role-arn": "arn:aws:iam::1:role/ch"},
+		},
+		&api.PodTemplate{
+			Name:               "provided",
+			ServiceAccountName: "clickhouse-gcs",
+		},
+		&api.PodTemplate{
+			Name: "spec",
+			Spec: core.PodSpec{ServiceAccountName: "clickhouse-spec"},
+		},
+		&api.PodTemplate{
+			Name: "none",
+		},
+	)
+
+	managed, provided := getServiceAccounts(chi)
+	require.Equal(t, map[string]map[string]string{
+		"clickhouse-s3": {"eks.amazonaws.com/role-arn": "arn:aws:iam::1:role/ch"},
+	}, managed)
+	require.Equal(t, []string{"clickhouse-gcs", "clickhouse-spec"}, provided)
+}
+
+// newServiceAccountTestManaged builds ServiceAccount the way Creator does, short of CHI-provided labels
+func newServiceAccountTestManaged(chi *api.ClickHouseInstallation, name string, annotations map[string]string) *core.ServiceAccount {
+	return &core.ServiceAccount{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace:   chi.Namespace,
+			Name:        name,
+			Labels:      model.NewLabeler(chi).GetSelectorCHIScope(),
+			Annotations: annotations,
+		},
+	}
+}
+
+func Test_ReconcileServiceAccount_CreateAndCleanup(t *testing.T) {
+	ctx := context.Background()
+	template := &api.PodTemplate{
+		Name:                 "managed",
+		ServiceAccountName:   "clickhouse-s3",
+		ManageServiceAccount: api.NewStringBool(true),
+	}
+	chi := newServiceAccountTestCHI(template)
+	foreign := &core.ServiceAccount{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "foreign"}}
+	w, kubeClient := newServiceAccountTestWorker(foreign)
+
+	// Managed ServiceAccount is created with annotations
+	annotations := map[string]string{"eks.amazonaws.com/role-arn": "arn:aws:iam::1:role/ch"}
+	require.NoError(t, w.reconcileServiceAccount(ctx, chi, newServiceAccountTestManaged(chi, "clickhouse-s3", annotations)))
+	serviceAccounts := listServiceAccounts(t, kubeClient)
+	require.Len(t, serviceAccounts, 2)
+	require.Equal(t, annotations, serviceAccounts["clickhouse-s3"].Annotations)
+
+	// Annotations are updated
+	annotations = map[string]string{"eks.amazonaws.com/role-arn": "arn:aws:iam::1:role/ch2"}
+	require.NoError(t, w.reconcileServiceAccount(ctx, chi, newServiceAccountTestManaged(chi, "clickhouse-s3", annotations)))
+	require.Equal(t, annotations, listServiceAccounts(t, kubeClient)["clickhouse-s3"].Annotations)
+
+	// Managed ServiceAccount is still in use
+	w.cleanupServiceAccounts(ctx, chi)
+	require.Len(t, listServiceAccounts(t, kubeClient), 2)
+
+	// ServiceAccount is not managed anymore - it is deleted, while foreign one is kept
+	template.ManageServiceAccount = api.NewStringBool(false)
+	w.cleanupServiceAccounts(ctx, chi)
+	serviceAccounts = listServiceAccounts(t, kubeClient)
+	require.Len(t, serviceAccounts, 1)
+	require.Contains(t, serviceAccounts, "foreign")
+}
+
+func Test_ReconcileServiceAccount_ForeignIsLeftIntact(t *testing.T) {
+	ctx := context.Background()
+	chi := newServiceAccountTestCHI()
+	existing := &core.ServiceAccount{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "clickhouse-s3"}}
+	w, kubeClient := newServiceAccountTestWorker(existing)
+
+	annotations := map[string]string{"eks.amazonaws.com/role-arn": "arn:aws:iam::1:role/ch"}
+	require.NoError(t, w.reconcileServiceAccount(ctx, chi, newServiceAccountTestManaged(chi, "clickhouse-s3", annotations)))
+	require.Empty(t, listServiceAccounts(t, kubeClient)["clickhouse-s3"].Annotations)
+}
@@ -0,0 +1,154 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilRuntime "k8s.io/apimachinery/pkg/util/runtime"
+
+	"github.com/altinity/queue"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+	"github.com/altinity/clickhouse-operator/pkg/controller"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi/normalizer"
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
+// userRetryInterval specifies how soon users of the CHI being busy with another operation are reconciled once again
+const userRetryInterval = 15 * time.Second
+
+// processReconcileUser renders users specified by ClickHouseUser resources into users ConfigMap of the CHI.
+// ClickHouse picks up users config on the fly, so hosts are neither restarted nor reconciled.
+func (w *worker) processReconcileUser(ctx context.Context, cmd *ReconcileUser) error {
+	switch cmd.cmd {
+	case reconcileAdd, reconcileUpdate:
+		w.a.V(1).M(cmd.new).F().Info("Reconcile CHU %s/%s", cmd.new.Namespace, cmd.new.Name)
+		return w.reconcileUsers(ctx, cmd, cmd.new.Namespace, cmd.new.Spec.CHI)
+	case reconcileDelete:
+		w.a.V(1).M(cmd.old).F().Info("Delete CHU %s/%s", cmd.old.Namespace, cmd.old.Name)
+		return w.reconcileUsers(ctx, cmd, cmd.old.Namespace, cmd.old.Spec.CHI)
+	}
+
+	// Unknown item type, don't know what to do with it
+	// Just skip it and behave like it never existed
+	utilRuntime.HandleError(fmt.Errorf("unexpected reconcile - %#v", cmd))
+	return nil
+}
+
+// reconcileUsers reconciles users ConfigMap of the CHI and publishes status of ClickHouseUser resources of the CHI
+func (w *worker) reconcileUsers(ctx context.Context, cmd queue.PriorityQueueItem, namespace, name string) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	w.updateUsersStatus(ctx, namespace, name)
+
+	chi, err := w.c.GetCHIByObjectMeta(&meta.ObjectMeta{Namespace: namespace, Name: name}, true)
+	if err != nil {
+		// Users are rendered along with the CHI as soon as it is created
+		w.a.V(1).F().Info("CHI %s/%s is not found, users are not reconciled", namespace, name)
+		return nil
+	}
+
+	if model.IsReconcilePaused(chi) {
+		// Users are rendered once reconcile is resumed
+		w.a.V(1).M(chi).F().Info("CHI %s/%s reconcile is paused, users are not reconciled", namespace, name)
+		return nil
+	}
+
+	unlock, ok := w.c.tryLockCHI(chi)
+	if !ok {
+		// Reconcile running on the CHI may have rendered users before the change, so check it again later
+		w.a.V(1).M(chi).F().Info("CHI %s/%s is busy, users are reconciled later", namespace, name)
+		w.c.requeueItem(cmd, userRetryInterval)
+		return nil
+	}
+	defer unlock()
+
+	if !chi.HasAncestor() {
+		// Users are rendered along with the CHI as soon as it is reconciled
+		w.a.V(1).M(chi).F().Info("CHI %s/%s is not reconciled yet, users are not reconciled", namespace, name)
+		return nil
+	}
+
+	// Users are rendered along with the CHI as it is reconciled, so changes of the CHI,
+	// which are not reconciled or not approved yet, are not applied behind the reconcile
+	normalized, err := w.createCompletedCHIFromObjectMeta(&chi.ObjectMeta, normalizer.NewOptions())
+	if err != nil {
+		w.a.M(chi).F().Error("unable to normalize CHI %s/%s err: %v", namespace, name, err)
+		return nil
+	}
+	// Default user is allowed to connect from the pods of the CHI
+	opts := normalizer.NewOptions()
+	opts.DefaultUserAdditionalIPs = w.c.getPodsIPs(normalized)
+	normalized, err = w.createCompletedCHIFromObjectMeta(&chi.ObjectMeta, opts)
+	if err != nil {
+		w.a.M(chi).F().Error("unable to normalize CHI %s/%s err: %v", namespace, name, err)
+		return nil
+	}
+
+	w.c.secretIndex.Update(normalized)
+
+	w.newTask(normalized)
+	return w.reconcileCHIConfigMapUsers(ctx, normalized)
+}
+
+// updateUsersStatus publishes whether ClickHouseUser resources of the CHI are rendered into users config of the CHI
+func (w *worker) updateUsersStatus(ctx context.Context, namespace, name string) {
+	chus := w.c.getUsers(namespace, name)
+	if len(chus) == 0 {
+		return
+	}
+
+	var rejected map[string]error
+	chi, chiErr := w.c.GetCHIByObjectMeta(&meta.ObjectMeta{Namespace: namespace, Name: name}, true)
+	if chiErr == nil {
+		// Users of the CHI as it is reconciled are rendered along with ClickHouseUser resources
+		if chi.HasAncestor() {
+			chi = chi.GetAncestor()
+		}
+		var usernames []string
+		if chi.Spec.Configuration != nil {
+			usernames = chi.Spec.Configuration.Users.Groups()
+		}
+		rejected = normalizer.CheckClickHouseUsers(chus, usernames, normalizer.ReservedUsernames())
+	}
+
+	for _, chu := range chus {
+		// Objects of the lister are shared, thus have to be copied before being modified
+		chu = chu.DeepCopy()
+		prev := chu.Status
+		switch e, ok := rejected[chu.Name]; {
+		case chiErr != nil:
+			chu.SetRejected(fmt.Sprintf("CHI %s is not found", name))
+		case ok:
+			chu.SetRejected(e.Error())
+		default:
+			chu.SetApplied()
+		}
+		if (prev != nil) && (*prev == *chu.Status) {
+			continue
+		}
+		if _, err := w.c.chopClient.ClickhouseV1().ClickHouseUsers(namespace).UpdateStatus(ctx, chu, controller.NewUpdateOptions()); err != nil {
+			w.a.M(chu).F().Error("unable to update status of CHU %s/%s err: %v", chu.Namespace, chu.Name, err)
+		}
+	}
+}
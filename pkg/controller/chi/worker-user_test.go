@@ -0,0 +1,65 @@
+package chi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	chopFake "github.com/altinity/clickhouse-operator/pkg/client/clientset/versioned/fake"
+	chopListers "github.com/altinity/clickhouse-operator/pkg/client/listers/clickhouse.altinity.com/v1"
+)
+
+func Test_GetUsers(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	c := &Controller{chuLister: chopListers.NewClickHouseUserLister(indexer)}
+
+	app := &api.ClickHouseUser{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "app"}}
+	app.Spec.CHI = "chi"
+	other := &api.ClickHouseUser{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "other"}}
+	other.Spec.CHI = "other"
+	foreign := &api.ClickHouseUser{ObjectMeta: meta.ObjectMeta{Namespace: "foreign", Name: "app"}}
+	foreign.Spec.CHI = "chi"
+	require.NoError(t, indexer.Add(app))
+	require.NoError(t, indexer.Add(other))
+	require.NoError(t, indexer.Add(foreign))
+
+	require.Equal(t, []*api.ClickHouseUser{app}, c.getUsers("ns", "chi"))
+	require.Empty(t, c.getUsers("ns", "unknown"))
+
+	// Controller without users
+	require.Nil(t, (&Controller{}).getUsers("ns", "chi"))
+
+	require.Equal(t, "app", app.GetUsername())
+	app.Spec.Username = "reader"
+	require.Equal(t, "reader", app.GetUsername())
+}
+
+func Test_UpdateUsersStatus(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+
+	app := &api.ClickHouseUser{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "app"}}
+	app.Spec.CHI = "chi"
+	require.NoError(t, indexer.Add(app))
+
+	// User specified in the CHI, which is not reconciled yet, does not clash with ClickHouseUser
+	users := api.NewSettings()
+	users.Set("app/profile", api.NewSettingScalar("default"))
+	chi := &api.ClickHouseInstallation{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "chi"}}
+	chi.Spec.Configuration = &api.Configuration{Users: users}
+	chi.SetAncestor(&api.ClickHouseInstallation{ObjectMeta: chi.ObjectMeta})
+
+	chopClient := chopFake.NewSimpleClientset(chi, app)
+	w := &worker{
+		a: NewAnnouncer(),
+		c: &Controller{chopClient: chopClient, chuLister: chopListers.NewClickHouseUserLister(indexer)},
+	}
+	w.updateUsersStatus(context.Background(), "ns", "chi")
+	updated, err := chopClient.ClickhouseV1().ClickHouseUsers("ns").Get(context.Background(), "app", meta.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, api.UserStatusApplied, updated.Status.Status)
+}
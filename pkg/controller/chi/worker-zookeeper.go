@@ -0,0 +1,101 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/chop"
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
+// checkZookeeper verifies ZooKeeper ensembles used by clusters of the CHI are reachable.
+// Hosts are not able to attach Replicated tables without ZooKeeper, thus there is no reason to reconcile them.
+func (w *worker) checkZookeeper(ctx context.Context, chi *api.ClickHouseInstallation) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	if !chop.Config().Reconcile.Zookeeper.Preflight.IsTrue() {
+		// Pre-flight check is skipped
+		return nil
+	}
+
+	timeout := time.Duration(chop.Config().Reconcile.Zookeeper.Timeout) * time.Second
+	checked := make(map[string]bool)
+	var err error
+	chi.WalkClusters(func(cluster *api.Cluster) error {
+		endpoints := getZookeeperEndpoints(cluster.Zookeeper)
+		ensemble := strings.Join(endpoints, ",")
+		if (len(endpoints) == 0) || checked[ensemble] {
+			return nil
+		}
+		checked[ensemble] = true
+
+		unreachable, ok := checkZookeeperEndpoints(ctx, endpoints, timeout)
+		switch {
+		case len(unreachable) == 0:
+			w.a.V(1).M(chi).F().Info("ZooKeeper ensemble %s is reachable", ensemble)
+		case ok:
+			w.a.V(1).M(chi).F().Warning("ZooKeeper ensemble %s has unreachable endpoints: %v", ensemble, unreachable)
+		default:
+			w.a.V(1).
+				WithEvent(chi, eventActionReconcile, eventReasonZooKeeperUnreachable).
+				WithStatusAction(chi).
+				WithStatusError(chi).
+				M(chi).F().
+				Error("ZooKeeper ensemble %s used by cluster %s is unreachable, unreachable endpoints: %v", ensemble, cluster.Name, unreachable)
+			err = errZookeeperUnreachable
+		}
+		return nil
+	})
+
+	return err
+}
+
+// getZookeeperEndpoints returns host:port endpoints of ZooKeeper ensemble
+func getZookeeperEndpoints(zk *api.ChiZookeeperConfig) (endpoints []string) {
+	if zk.IsEmpty() {
+		return nil
+	}
+	for _, node := range zk.Nodes {
+		endpoints = append(endpoints, net.JoinHostPort(node.Host, strconv.Itoa(int(node.Port))))
+	}
+	return endpoints
+}
+
+// checkZookeeperEndpoints connects to each ZooKeeper endpoint and returns endpoints, which are not reachable,
+// along with whether ensemble is reachable. Ensemble is reachable as long as quorum of its endpoints is reachable
+func checkZookeeperEndpoints(ctx context.Context, endpoints []string, timeout time.Duration) (unreachable []string, ok bool) {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+	}
+	for _, endpoint := range endpoints {
+		conn, err := dialer.DialContext(ctx, "tcp", endpoint)
+		if err != nil {
+			unreachable = append(unreachable, endpoint)
+			continue
+		}
+		_ = conn.Close()
+	}
+	return unreachable, 2*(len(endpoints)-len(unreachable)) > len(endpoints)
+}
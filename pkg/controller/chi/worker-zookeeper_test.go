@@ -0,0 +1,64 @@
+package chi
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+// newZookeeperTestEndpoints returns endpoints, which accept connections, and endpoints, which refuse them
+func newZookeeperTestEndpoints(t *testing.T, reachable, unreachable int) (endpoints []string) {
+	for i := 0; i < reachable; i++ {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = listener.Close() })
+		endpoints = append(endpoints, listener.Addr().String())
+	}
+	for i := 0; i < unreachable; i++ {
+		// Take free port and release it, so nobody listens on it
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		endpoints = append(endpoints, listener.Addr().String())
+		require.NoError(t, listener.Close())
+	}
+	return endpoints
+}
+
+func Test_CheckZookeeperEndpoints(t *testing.T) {
+	tests := []struct {
+		name        string
+		reachable   int
+		unreachable int
+		want        bool
+	}{
+		{"all reachable", 3, 0, true},
+		{"quorum reachable", 2, 1, true},
+		{"quorum unreachable", 1, 2, false},
+		{"single unreachable", 0, 1, false},
+		{"half of even ensemble unreachable", 2, 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoints := newZookeeperTestEndpoints(t, tt.reachable, tt.unreachable)
+			unreachable, ok := checkZookeeperEndpoints(context.Background(), endpoints, time.Second)
+			require.Equal(t, tt.want, ok)
+			require.Equal(t, endpoints[tt.reachable:], append([]string{}, unreachable...))
+		})
+	}
+}
+
+func Test_GetZookeeperEndpoints(t *testing.T) {
+	require.Empty(t, getZookeeperEndpoints(nil))
+	require.Empty(t, getZookeeperEndpoints(&api.ChiZookeeperConfig{}))
+	require.Equal(t, []string{"zk-0.zk:2181", "[::1]:2182"}, getZookeeperEndpoints(&api.ChiZookeeperConfig{
+		Nodes: []api.ChiZookeeperNode{
+			{Host: "zk-0.zk", Port: 2181},
+			{Host: "::1", Port: 2182},
+		},
+	}))
+}
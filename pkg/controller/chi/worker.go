@@ -18,6 +18,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/juliangruber/go-intersect"
@@ -65,6 +67,10 @@ type task struct {
 	registryFailed     *model.Registry
 	cmUpdate           time.Time
 	start              time.Time
+	// zookeeperOnlyChange specifies reconcile of changes limited to ZooKeeper config
+	zookeeperOnlyChange bool
+	// dictionariesChange specifies external dictionaries config is changed by the reconcile
+	dictionariesChange bool
 }
 
 // newTask creates new context
@@ -86,14 +92,12 @@ func (c *Controller) newWorker(q queue.PriorityQueue, sys bool) *worker {
 		start = start.Add(api.DefaultReconcileThreadsWarmup)
 	}
 	return &worker{
-		c:     c,
-		a:     NewAnnouncer().WithController(c),
-		queue: q,
-		normalizer: normalizer.NewNormalizer(func(namespace, name string) (*core.Secret, error) {
-			return c.kubeClient.CoreV1().Secrets(namespace).Get(context.TODO(), name, controller.NewGetOptions())
-		}),
-		schemer: nil,
-		start:   start,
+		c:          c,
+		a:          NewAnnouncer().WithController(c),
+		queue:      q,
+		normalizer: normalizer.NewNormalizer(c.getSecretCached).SetUsersGet(c.getUsers),
+		schemer:    nil,
+		start:      start,
 	}
 }
 
@@ -114,6 +118,70 @@ func (w *worker) isConfigurationChangeRequiresReboot(host *api.ChiHost) bool {
 	return model.IsConfigurationChangeRequiresReboot(host)
 }
 
+// reloadHostConfig applies configuration changes, which do not require restart, by reloading host's config
+func (w *worker) reloadHostConfig(ctx context.Context, host *api.ChiHost) {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return
+	}
+
+	if host.IsStopped() || (host.GetReconcileAttributes().GetStatus() == api.ObjectStatusNew) || !host.HasAncestor() {
+		// Host is not running or has nothing to compare configuration with
+		return
+	}
+
+	if change := model.GetConfigurationChange(host); change != model.ConfigurationChangeReload {
+		w.a.V(2).M(host).F().Info("Config reload is not applicable. Config change: %s Host: %s", change, host.GetName())
+		return
+	}
+
+	// Config files have to reach the host before the reload, otherwise ClickHouse reloads the stale ones
+	if w.waitConfigMapPropagation(ctx, host) {
+		return
+	}
+
+	// ClickHouse picks up config files changes on its own, but it may take a while, so reload config explicitly
+	if err := newConfigReloader(w, host).HostReloadConfig(ctx, host); err != nil {
+		w.a.V(1).M(host).F().Warning("Config reload failed. Config change(s) will be applied by ClickHouse on its own. Host: %s Err: %v", host.GetName(), err)
+		return
+	}
+	w.a.V(1).M(host).F().Info("Config change(s) applied by config reload, no restart required. Host: %s", host.GetName())
+}
+
+// configReloader reloads config of ClickHouse host
+type configReloader interface {
+	HostReloadConfig(ctx context.Context, host *api.ChiHost) error
+}
+
+// newConfigReloader creates reloader running SQL statements on the host
+var newConfigReloader = func(w *worker, host *api.ChiHost) configReloader {
+	return w.ensureClusterSchemer(host)
+}
+
+// reloadCHIDictionaries applies changes of external dictionaries config by reloading dictionaries on all running hosts
+func (w *worker) reloadCHIDictionaries(ctx context.Context, chi *api.ClickHouseInstallation) {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return
+	}
+
+	chi.WalkHosts(func(host *api.ChiHost) error {
+		if host.IsStopped() {
+			return nil
+		}
+		// Dictionaries config has to reach the host before the reload
+		if w.waitConfigMapPropagation(ctx, host) {
+			return nil
+		}
+		if err := w.ensureClusterSchemer(host).HostReloadDictionaries(ctx, host); err != nil {
+			w.a.V(1).M(host).F().Warning("Dictionaries reload failed. Dictionaries config change(s) will be applied by ClickHouse on its own. Host: %s Err: %v", host.GetName(), err)
+			return nil
+		}
+		w.a.V(1).M(host).F().Info("Dictionaries config change(s) applied by dictionaries reload. Host: %s", host.GetName())
+		return nil
+	})
+}
+
 // shouldForceRestartHost checks whether cluster requires hosts restart
 func (w *worker) shouldForceRestartHost(host *api.ChiHost) bool {
 	// RollingUpdate purpose is to always shut the host down.
@@ -168,6 +236,9 @@ func (w *worker) run() {
 	// For system thread let's wait its 'official start time', thus giving it time to bootstrap
 	util.WaitContextDoneUntil(context.Background(), w.start)
 
+	w.c.health.workerStarted()
+	defer w.c.health.workerStopped()
+
 	// Events loop
 	for {
 		// Get() blocks until it can return an item
@@ -184,11 +255,13 @@ func (w *worker) run() {
 		//	return
 		//}
 
+		w.c.health.itemStarted()
 		if err := w.processItem(ctx, item); err != nil {
 			// Item not processed
 			// this code cannot return an error and needs to indicate error has been ignored
 			utilRuntime.HandleError(err)
 		}
+		w.c.health.itemDone()
 
 		// Forget indicates that an item is finished being retried.  Doesn't matter whether its for perm failing
 		// or for success, we'll stop the rate limiter from tracking it.  This only clears the `rateLimiter`, you
@@ -201,6 +274,10 @@ func (w *worker) run() {
 }
 
 func (w *worker) processReconcileCHI(ctx context.Context, cmd *ReconcileCHI) error {
+	// Updates and deletes of the same CHI must not race on the same objects
+	unlock := w.c.lockCHI(cmd)
+	defer unlock()
+
 	switch cmd.cmd {
 	case reconcileAdd:
 		return w.updateCHI(ctx, nil, cmd.new)
@@ -310,12 +387,22 @@ func (w *worker) processItem(ctx context.Context, item interface{}) error {
 		return w.processReconcileCHIT(cmd)
 	case *ReconcileChopConfig:
 		return w.processReconcileChopConfig(cmd)
+	case *ReconcileBackup:
+		return w.processReconcileBackup(ctx, cmd)
+	case *ReconcileRestore:
+		return w.processReconcileRestore(ctx, cmd)
+	case *ReconcileUser:
+		return w.processReconcileUser(ctx, cmd)
 	case *ReconcileEndpoints:
 		return w.processReconcileEndpoints(ctx, cmd)
 	case *ReconcilePod:
 		return w.processReconcilePod(ctx, cmd)
 	case *DropDns:
 		return w.processDropDns(ctx, cmd)
+	case *PodDisruption:
+		return w.processPodDisruption(ctx, cmd)
+	case *SecretRotation:
+		return w.processSecretRotation(ctx, cmd)
 	}
 
 	// Unknown item type, don't know what to do with it
@@ -454,6 +541,25 @@ func (w *worker) updateCHI(ctx context.Context, old, new *api.ClickHouseInstalla
 		return nil
 	}
 
+	if model.IsReconcilePaused(new) {
+		// Deletion is requested explicitly, so it is not blocked by pause, while reconcile is
+		w.a.V(1).
+			WithEvent(new, eventActionReconcile, eventReasonReconcilePaused).
+			M(new).F().
+			Info("Reconcile is paused. Remove annotation %s to resume. CHI: %s/%s", model.AnnotationReconcile, new.Namespace, new.Name)
+		return nil
+	}
+
+	if chr := w.c.getRestoreInProgress(new); chr != nil {
+		// Restore excludes hosts from the clusters and syncs replicas on its own
+		w.a.V(1).
+			WithEvent(new, eventActionReconcile, eventReasonRestoreInProgress).
+			M(new).F().
+			Info("Restore %s is in progress, reconcile is postponed. CHI: %s/%s", chr.Name, new.Namespace, new.Name)
+		w.c.requeueCHI(new, restorePollInterval)
+		return nil
+	}
+
 	if w.isCHIProcessedOnTheSameIP(new) {
 		// First minute after restart do not reconcile already reconciled generations
 		w.a.V(1).M(new).F().Info("Will not reconcile known generation after restart. Generation %d", new.Generation)
@@ -664,6 +770,18 @@ func (w *worker) markReconcileStart(ctx context.Context, chi *api.ClickHouseInst
 	w.a.V(2).M(chi).F().Info("action plan\n%s\n", ap.String())
 }
 
+// publishObservedGeneration publishes generation of the CHI as observed one, leaving the rest of the status intact.
+// Is used in case generation requires no reconcile, since observed generation is published by completed reconcile otherwise
+func (w *worker) publishObservedGeneration(ctx context.Context, chi *api.ClickHouseInstallation) {
+	if chi.GetGeneration() <= chi.EnsureStatus().GetObservedGeneration() {
+		return
+	}
+	w.c.updateCHIObjectStatus(ctx, chi, UpdateCHIStatusOptions{
+		TolerateAbsence:    true,
+		ObservedGeneration: chi.GetGeneration(),
+	})
+}
+
 func (w *worker) finalizeReconcileAndMarkCompleted(ctx context.Context, _chi *api.ClickHouseInstallation) {
 	if util.IsContextDone(ctx) {
 		log.V(2).Info("task is done")
@@ -681,16 +799,22 @@ func (w *worker) finalizeReconcileAndMarkCompleted(ctx context.Context, _chi *ap
 		opts.DefaultUserAdditionalIPs = ips
 		if chi, err := w.createCHIFromObjectMeta(&_chi.ObjectMeta, true, opts); err == nil {
 			w.a.V(1).M(chi).Info("Update users IPS-2")
-			chi.SetAncestor(chi.GetTarget())
+			if len(_chi.EnsureStatus().GetFailedHosts()) == 0 {
+				chi.SetAncestor(chi.GetTarget())
+			}
+			// Otherwise ancestor is not advanced, so failed hosts are seen as changed and reconciled by the next reconcile
 			chi.SetTarget(nil)
 			chi.EnsureStatus().ReconcileComplete()
 			// TODO unify with update endpoints
 			w.newTask(chi)
 			w.reconcileCHIConfigMapUsers(ctx, chi)
+			w.updateUsersStatus(ctx, chi.Namespace, chi.Name)
 			w.c.updateCHIObjectStatus(ctx, chi, UpdateCHIStatusOptions{
 				CopyCHIStatusOptions: api.CopyCHIStatusOptions{
 					WholeStatus: true,
 				},
+				// Generation of the fetched CHI may be ahead of the reconciled one
+				ObservedGeneration: _chi.GetGeneration(),
 			})
 		} else {
 			w.a.M(&_chi.ObjectMeta).F().Error("internal unable to find CHI by %v err: %v", _chi.Labels, err)
@@ -699,6 +823,24 @@ func (w *worker) finalizeReconcileAndMarkCompleted(ctx context.Context, _chi *ap
 		w.a.M(&_chi.ObjectMeta).F().Error("external unable to find CHI by %v err %v", _chi.Labels, err)
 	}
 
+	// Report per-host events aggregated during reconcile before reporting completion
+	w.c.FlushEvents(_chi)
+	w.c.health.reconcileSucceeded(_chi, time.Now())
+
+	if rolledBack := _chi.EnsureStatus().GetRolledBackStatefulSets(); len(rolledBack) > 0 {
+		w.a.V(1).
+			WithEvent(_chi, eventActionReconcile, eventReasonDegraded).
+			M(_chi).F().
+			Warning("CHI is degraded. StatefulSets rolled back to the last known-good revision: %s. "+
+				"Spec is not applied until edited", strings.Join(rolledBack, ", "))
+	}
+	if failed := _chi.EnsureStatus().GetFailedHosts(); len(failed) > 0 {
+		w.a.V(1).
+			WithEvent(_chi, eventActionReconcile, eventReasonDegraded).
+			M(_chi).F().
+			Warning("CHI is degraded. Reconcile of hosts failed: %s", strings.Join(failed, ", "))
+	}
+
 	w.a.V(1).
 		WithEvent(_chi, eventActionReconcile, eventReasonReconcileCompleted).
 		WithStatusAction(_chi).
@@ -707,6 +849,32 @@ func (w *worker) finalizeReconcileAndMarkCompleted(ctx context.Context, _chi *ap
 		Info("reconcile completed successfully, task id: %s", _chi.Spec.GetTaskID())
 }
 
+// markReconcilePaused marks reconcile paused at the rollout breakpoint.
+// CHI is not marked as completed, so hosts beyond the breakpoint are reconciled as the breakpoint is advanced or cleared.
+func (w *worker) markReconcilePaused(ctx context.Context, chi *api.ClickHouseInstallation) {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return
+	}
+
+	chi.EnsureStatus().ReconcilePause()
+	w.c.updateCHIObjectStatus(ctx, chi, UpdateCHIStatusOptions{
+		CopyCHIStatusOptions: api.CopyCHIStatusOptions{
+			MainFields: true,
+		},
+	})
+
+	// Report per-host events aggregated during reconcile before reporting the pause
+	w.c.FlushEvents(chi)
+
+	w.a.V(1).
+		WithEvent(chi, eventActionReconcile, eventReasonRolloutPaused).
+		WithStatusAction(chi).
+		WithStatusActions(chi).
+		M(chi).F().
+		Info("reconcile paused at the rollout breakpoint %s, task id: %s", chi.GetRolloutBreakpoint(), chi.Spec.GetTaskID())
+}
+
 func (w *worker) markReconcileCompletedUnsuccessfully(ctx context.Context, chi *api.ClickHouseInstallation, err error) {
 	if util.IsContextDone(ctx) {
 		log.V(2).Info("task is done")
@@ -718,6 +886,8 @@ func (w *worker) markReconcileCompletedUnsuccessfully(ctx context.Context, chi *
 		chi.EnsureStatus().ReconcileComplete()
 	case errors.Is(err, errCRUDAbort):
 		chi.EnsureStatus().ReconcileAbort()
+	default:
+		chi.EnsureStatus().ReconcileFail(err.Error())
 	}
 	w.c.updateCHIObjectStatus(ctx, chi, UpdateCHIStatusOptions{
 		CopyCHIStatusOptions: api.CopyCHIStatusOptions{
@@ -853,11 +1023,15 @@ func (w *worker) prepareHostStatefulSetWithStatus(ctx context.Context, host *api
 	}
 
 	w.prepareDesiredStatefulSet(host, shutdown)
+	// Adoption affects volumes of the StatefulSet, so it has to be done before PVCs are reconciled
+	w.adoptHostStatefulSet(host)
 	host.GetReconcileAttributes().SetStatus(w.getStatefulSetStatus(host))
 }
 
 // prepareDesiredStatefulSet prepares desired StatefulSet
 func (w *worker) prepareDesiredStatefulSet(host *api.ChiHost, shutdown bool) {
+	// Interserver credentials are passed into pods, whatever path prepares StatefulSet
+	w.ensureInterserverCredentialsState(host.GetCHI())
 	host.Runtime.DesiredStatefulSet = w.task.creator.CreateStatefulSet(host, shutdown)
 }
 
@@ -960,6 +1134,10 @@ func (w *worker) shouldMigrateTables(host *api.ChiHost, opts ...*migrateTableOpt
 		// Stopped host is not able to receive any data, migration is inapplicable
 		return false
 
+	case !model.ClusterIsSchemaManaged(host.GetCluster()):
+		// Schema of the cluster is maintained outside of the operator, even forced migration is inapplicable
+		return false
+
 	case o.ForceMigrate():
 		// Force migration requested
 		return true
@@ -1005,6 +1183,15 @@ func (w *worker) excludeHost(ctx context.Context, host *api.ChiHost) error {
 		return nil
 	}
 
+	if err := w.checkClusterHealth(ctx, host, chop.Config().Reconcile.Cluster.HealthThreshold, func(replica *api.ChiHost) bool {
+		return w.isHostReachable(ctx, replica)
+	}); err != nil {
+		return err
+	}
+	if err := w.waitMinHealthyReplicas(ctx, host); err != nil {
+		return err
+	}
+
 	w.a.V(1).
 		M(host).F().
 		Info("Exclude from cluster host %d shard %d cluster %s",
@@ -1012,9 +1199,239 @@ func (w *worker) excludeHost(ctx context.Context, host *api.ChiHost) error {
 
 	_ = w.excludeHostFromService(ctx, host)
 	w.excludeHostFromClickHouseCluster(ctx, host)
+	w.notifyHostMembership(ctx, host, hostMembershipExclude)
+	w.stopHostMerges(ctx, host)
 	return nil
 }
 
+// isHostHealthy checks whether host is ready and included into the cluster
+func (w *worker) isHostHealthy(host *api.ChiHost) bool {
+	pod, err := w.c.getPod(host)
+	if err != nil {
+		return false
+	}
+	return isPodHealthy(pod)
+}
+
+// isPodHealthy checks whether pod is ready and included into the cluster
+func isPodHealthy(pod *core.Pod) bool {
+	if pod.Labels[model.LabelReadyName] != model.LabelReadyValueReady {
+		// Host is excluded from the cluster
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == core.PodReady {
+			return condition.Status == core.ConditionTrue
+		}
+	}
+	return false
+}
+
+// getMinHealthyReplicas gets minimum number of other healthy replicas of the host's shard to be kept.
+// Minimum is capped by the number of other replicas in the shard, so a host can always be excluded eventually.
+func getMinHealthyReplicas(host *api.ChiHost, min int) int {
+	shard := host.GetShard()
+	if (min <= 0) || (shard == nil) {
+		return 0
+	}
+	if others := shard.HostsCount() - 1; min > others {
+		min = others
+	}
+	return min
+}
+
+// countOtherHealthyReplicas counts healthy replicas of the host's shard except the host itself
+func countOtherHealthyReplicas(host *api.ChiHost, isHealthy func(*api.ChiHost) bool) (healthy int) {
+	host.GetShard().WalkHosts(func(replica *api.ChiHost) error {
+		if (replica != host) && isHealthy(replica) {
+			healthy++
+		}
+		return nil
+	})
+	return healthy
+}
+
+// excludeHostKeepingMinHealthyReplicas excludes the host from the service in case the shard keeps
+// minimum healthy replicas without it. Returns true in case the host is excluded or is not healthy already.
+// Replicas are checked and the host is excluded by separate requests, so other replica may be excluded in between.
+// To not act on the stale check, the pod is updated conditionally on the resourceVersion observed before the check,
+// and the check is repeated after the update, which is reverted in case other replica has been excluded meanwhile.
+func (w *worker) excludeHostKeepingMinHealthyReplicas(ctx context.Context, host *api.ChiHost, min int) bool {
+	pod, err := w.c.getPod(host)
+	if err != nil {
+		// Host without pod is not healthy, excluding it does not affect the number of healthy replicas
+		return apiErrors.IsNotFound(err)
+	}
+	if !isPodHealthy(pod) {
+		// Host is not healthy already, excluding it does not affect the number of healthy replicas
+		return true
+	}
+	min = getMinHealthyReplicas(host, min)
+	if countOtherHealthyReplicas(host, w.isHostHealthy) < min {
+		return false
+	}
+
+	model.DeleteLabelReady(&pod.ObjectMeta)
+	// Update is rejected with conflict in case the pod has been modified since it was observed
+	if _, err := w.c.kubeClient.CoreV1().Pods(pod.Namespace).Update(ctx, pod, controller.NewUpdateOptions()); err != nil {
+		w.a.V(1).M(host).F().Info("Unable to exclude host %s from the service, retry. err: %v", host.GetName(), err)
+		return false
+	}
+	if countOtherHealthyReplicas(host, w.isHostHealthy) < min {
+		// Other replica has been excluded concurrently, give way to it
+		_ = w.c.appendLabelReadyOnPod(ctx, host)
+		return false
+	}
+	return true
+}
+
+// waitMinHealthyReplicas waits until the host can be excluded without dropping number of healthy replicas
+// of the shard below configured minimum and excludes the host from the service
+func (w *worker) waitMinHealthyReplicas(ctx context.Context, host *api.ChiHost) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	min := chop.Config().Reconcile.Host.MinHealthyReplicasPerShard
+	if getMinHealthyReplicas(host, min) <= 0 {
+		return nil
+	}
+	if w.excludeHostKeepingMinHealthyReplicas(ctx, host, min) {
+		return nil
+	}
+
+	w.a.V(1).
+		M(host).F().
+		Info("Exclude of host %d shard %d cluster %s postponed. Other healthy replicas: %d min healthy replicas: %d",
+			host.Runtime.Address.ReplicaIndex, host.Runtime.Address.ShardIndex, host.Runtime.Address.ClusterName,
+			countOtherHealthyReplicas(host, w.isHostHealthy), min)
+
+	err := w.c.pollHost(ctx, host, nil, func(ctx context.Context, host *api.ChiHost) bool {
+		return w.excludeHostKeepingMinHealthyReplicas(ctx, host, min)
+	})
+	if err != nil {
+		w.a.V(1).
+			WithEvent(host.GetCHI(), eventActionReconcile, eventReasonReconcileFailed).
+			WithStatusAction(host.GetCHI()).
+			WithStatusError(host.GetCHI()).
+			M(host).F().
+			Error("Unable to exclude host %d shard %d cluster %s - not enough healthy replicas in the shard. Err: %v",
+				host.Runtime.Address.ReplicaIndex, host.Runtime.Address.ShardIndex, host.Runtime.Address.ClusterName, err)
+	}
+	return err
+}
+
+// isHostReachable checks whether ClickHouse on the host is reachable.
+// Is safe to be called concurrently, since own schemer is used for each call
+func (w *worker) isHostReachable(ctx context.Context, host *api.ChiHost) bool {
+	_, err := w.newClusterSchemer(host).HostClickHouseVersion(ctx, host)
+	return err == nil
+}
+
+// isClusterDegraded checks whether percentage of reachable replicas in any shard of the host's cluster
+// is below the specified threshold. Returns the least healthy shard along with its health.
+// Hosts, which are not created yet, are not taken into account.
+func isClusterDegraded(host *api.ChiHost, threshold int, isReachable func(*api.ChiHost) bool) (shard string, health int, degraded bool) {
+	cluster := host.GetCluster()
+	if (threshold <= 0) || (cluster == nil) {
+		return "", 100, false
+	}
+
+	hosts := []*api.ChiHost{host}
+	cluster.WalkShards(func(_ int, s *api.ChiShard) error {
+		s.WalkHosts(func(replica *api.ChiHost) error {
+			if (replica != host) && (replica.GetReconcileAttributes().GetStatus() != api.ObjectStatusNew) {
+				hosts = append(hosts, replica)
+			}
+			return nil
+		})
+		return nil
+	})
+	reachable := checkHostsReachable(hosts, isReachable)
+	if !reachable[host] {
+		// Host is not reachable already, excluding it does not make the cluster any worse
+		return "", 100, false
+	}
+
+	health = 100
+	cluster.WalkShards(func(_ int, s *api.ChiShard) error {
+		total := 0
+		reachableNum := 0
+		s.WalkHosts(func(replica *api.ChiHost) error {
+			if replica.GetReconcileAttributes().GetStatus() == api.ObjectStatusNew {
+				return nil
+			}
+			total++
+			if (replica == host) || reachable[replica] {
+				reachableNum++
+			}
+			return nil
+		})
+		if total == 0 {
+			return nil
+		}
+		if h := reachableNum * 100 / total; h < health {
+			shard = s.Name
+			health = h
+		}
+		return nil
+	})
+	return shard, health, health < threshold
+}
+
+// checkHostsReachable checks which of the hosts are reachable.
+// Hosts are checked concurrently, so the check takes as long as the slowest host does, whatever the cluster size is
+func checkHostsReachable(hosts []*api.ChiHost, isReachable func(*api.ChiHost) bool) map[*api.ChiHost]bool {
+	results := make([]bool, len(hosts))
+	wg := sync.WaitGroup{}
+	wg.Add(len(hosts))
+	for i := range hosts {
+		i := i
+		go func() {
+			defer wg.Done()
+			results[i] = isReachable(hosts[i])
+		}()
+	}
+	wg.Wait()
+
+	reachable := make(map[*api.ChiHost]bool, len(hosts))
+	for i, host := range hosts {
+		reachable[host] = results[i]
+	}
+	return reachable
+}
+
+// clusterDegradedRequeueDelay specifies how long to wait before reconcile of the CHI, postponed due to degraded cluster,
+// is tried again
+const clusterDegradedRequeueDelay = 1 * time.Minute
+
+// checkClusterHealth refuses to exclude the host from the cluster, which is already degraded below the threshold
+func (w *worker) checkClusterHealth(
+	ctx context.Context,
+	host *api.ChiHost,
+	threshold int,
+	isReachable func(*api.ChiHost) bool,
+) error {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return nil
+	}
+
+	shard, health, degraded := isClusterDegraded(host, threshold, isReachable)
+	if !degraded {
+		return nil
+	}
+
+	w.a.V(1).
+		WithEvent(host.GetCHI(), eventActionReconcile, eventReasonClusterDegraded).
+		WithStatusAction(host.GetCHI()).
+		M(host).F().
+		Warning("Exclude of host %s postponed. Cluster %s is degraded: %d%% of replicas of shard %s are reachable, threshold: %d%%",
+			host.GetName(), host.Runtime.Address.ClusterName, health, shard, threshold)
+	return errClusterDegraded
+}
+
 // completeQueries wait for running queries to complete
 func (w *worker) completeQueries(ctx context.Context, host *api.ChiHost) error {
 	log.V(1).M(host).F().S().Info("complete queries start")
@@ -1057,12 +1474,62 @@ func (w *worker) includeHost(ctx context.Context, host *api.ChiHost) error {
 		Info("Include into cluster host %d shard %d cluster %s",
 			host.Runtime.Address.ReplicaIndex, host.Runtime.Address.ShardIndex, host.Runtime.Address.ClusterName)
 
+	w.waitHostReadinessQuery(ctx, host, w.ensureClusterSchemer(host).IsHostReadyByQuery, readinessQueryPollInterval)
+	w.startHostMerges(ctx, host)
 	w.includeHostIntoClickHouseCluster(ctx, host)
+	w.includeHostIntoPeers(ctx, host)
 	_ = w.includeHostIntoService(ctx, host)
+	w.notifyHostMembership(ctx, host, hostMembershipInclude)
 
 	return nil
 }
 
+// readinessQueryPollInterval specifies how often readiness query is run on the host
+const readinessQueryPollInterval = 5 * time.Second
+
+// waitHostReadinessQuery waits for readiness query, specified by the CHI, to return truthy result on the host.
+// Timeout is not an error - host is included into the cluster anyway.
+func (w *worker) waitHostReadinessQuery(
+	ctx context.Context,
+	host *api.ChiHost,
+	isReady func(ctx context.Context, host *api.ChiHost, sql string) bool,
+	interval time.Duration,
+) {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return
+	}
+
+	readinessQuery := host.GetCHI().GetReconciling().GetReadinessQuery()
+	if !readinessQuery.IsEnabled() {
+		return
+	}
+
+	w.a.V(1).M(host).F().Info("Wait for readiness query on the host %s", host.GetName())
+	opts := &controller.PollerOptions{
+		Timeout:      readinessQuery.GetTimeout(),
+		MainInterval: interval,
+	}
+	err := controller.Poll(
+		ctx,
+		host.Runtime.Address.Namespace, host.GetName(),
+		opts,
+		&controller.PollerFunctions{
+			IsDone: func(_ctx context.Context, _ any) bool {
+				return isReady(_ctx, host, readinessQuery.Query)
+			},
+		},
+		nil,
+	)
+	if err != nil {
+		w.a.V(1).
+			WithEvent(host.GetCHI(), eventActionReconcile, eventReasonReadinessQueryTimeout).
+			M(host).F().
+			Warning("Readiness query has not returned truthy result on the host %s in %s. Include host anyway",
+				host.GetName(), opts.Timeout)
+	}
+}
+
 // excludeHostFromService
 func (w *worker) excludeHostFromService(ctx context.Context, host *api.ChiHost) error {
 	if util.IsContextDone(ctx) {
@@ -1124,17 +1591,118 @@ func (w *worker) includeHostIntoClickHouseCluster(ctx context.Context, host *api
 		Info("going to include host %d shard %d cluster %s",
 			host.Runtime.Address.ReplicaIndex, host.Runtime.Address.ShardIndex, host.Runtime.Address.ClusterName)
 
+	w.includeHostIntoClickHouseClusterConfig(ctx, host)
+
+	if !w.shouldWaitIncludeHost(host) {
+		return
+	}
+	// Wait for ClickHouse to pick-up the change
+	_ = w.waitHostInCluster(ctx, host)
+}
+
+// includeHostIntoClickHouseClusterConfig includes host into ClickHouse configuration without waiting for ClickHouse to pick-up the change
+func (w *worker) includeHostIntoClickHouseClusterConfig(ctx context.Context, host *api.ChiHost) {
 	// Specify in options to add this host into ClickHouse config file
 	host.GetCHI().EnsureRuntime().LockCommonConfig()
 	host.GetReconcileAttributes().UnsetExclude()
 	_ = w.reconcileCHIConfigMapCommon(ctx, host.GetCHI(), w.options())
 	host.GetCHI().EnsureRuntime().UnlockCommonConfig()
+}
 
-	if !w.shouldWaitIncludeHost(host) {
+// restoreHostMembership includes host, which reconcile did not complete in time, back into the cluster.
+// Host reconcile skips inclusion within expired context, so the host would stay excluded otherwise.
+// Inclusion is not waited for, since the host may be unable to join the cluster.
+func (w *worker) restoreHostMembership(ctx context.Context, host *api.ChiHost) {
+	if util.IsContextDone(ctx) || !w.shouldIncludeHost(host) {
 		return
 	}
-	// Wait for ClickHouse to pick-up the change
-	_ = w.waitHostInCluster(ctx, host)
+
+	w.a.V(1).
+		M(host).F().
+		Info("Restore membership of host %d shard %d cluster %s",
+			host.Runtime.Address.ReplicaIndex, host.Runtime.Address.ShardIndex, host.Runtime.Address.ClusterName)
+
+	w.includeHostIntoClickHouseClusterConfig(ctx, host)
+	_ = w.includeHostIntoService(ctx, host)
+	w.notifyHostMembership(ctx, host, hostMembershipInclude)
+}
+
+// getHostPeers gets running hosts of the CHI, which have to learn about the host via remote_servers config
+func getHostPeers(host *api.ChiHost) (peers []*api.ChiHost) {
+	host.GetCHI().WalkHosts(func(peer *api.ChiHost) error {
+		switch {
+		case peer == host:
+			// The host itself
+		case peer.IsStopped():
+			// Stopped peer has nothing to learn
+		case peer.GetReconcileAttributes().IsAdd():
+			// Peer is not created yet and will get up-to-date config on its own
+		default:
+			peers = append(peers, peer)
+		}
+		return nil
+	})
+	return peers
+}
+
+// includeHostIntoPeers makes running hosts learn about newly added host before it is included into the service,
+// so the topology is consistent across the cluster by the time the new host starts to serve
+func (w *worker) includeHostIntoPeers(ctx context.Context, host *api.ChiHost) {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("task is done")
+		return
+	}
+
+	if host.GetReconcileAttributes().GetStatus() != api.ObjectStatusNew {
+		// Existing host is already known to peers
+		return
+	}
+
+	if model.IsHostCordoned(host) {
+		// Host of the shard in maintenance is not advertised to peers
+		return
+	}
+
+	peers := getHostPeers(host)
+	if len(peers) == 0 {
+		return
+	}
+
+	w.a.V(1).
+		M(host).F().
+		Info("going to propagate host %d shard %d cluster %s to %d peer(s)",
+			host.Runtime.Address.ReplicaIndex, host.Runtime.Address.ShardIndex, host.Runtime.Address.ClusterName, len(peers))
+
+	// Peers are polled concurrently, so the wait does not grow with the number of peers
+	wg := sync.WaitGroup{}
+	wg.Add(len(peers))
+	for _, peer := range peers {
+		peer := peer
+		go func() {
+			defer wg.Done()
+			w.includeHostIntoPeer(ctx, host, peer)
+		}()
+	}
+	wg.Wait()
+}
+
+// includeHostIntoPeer waits for the peer to learn about the host
+func (w *worker) includeHostIntoPeer(ctx context.Context, host, peer *api.ChiHost) {
+	schemer := w.newClusterSchemer(peer)
+	err := w.c.pollHost(ctx, peer, nil, func(ctx context.Context, peer *api.ChiHost) bool {
+		if schemer.IsHostKnownToPeer(ctx, peer, host) {
+			return true
+		}
+		// ClickHouse picks up config files changes on its own, but it may take a while, so reload config explicitly
+		_ = schemer.HostReloadConfig(ctx, peer)
+		return schemer.IsHostKnownToPeer(ctx, peer, host)
+	})
+	if err != nil {
+		w.a.V(1).
+			M(host).F().
+			Warning("Host %s is not known to the peer %s yet. Peer will pick up the change on its own. Err: %v",
+				host.GetName(), peer.GetName(), err)
+	}
 }
 
 // shouldExcludeHost determines whether host to be excluded from cluster before reconciling
@@ -1158,6 +1726,12 @@ func (w *worker) shouldExcludeHost(host *api.ChiHost) bool {
 			Info("Host should be restarted, need to exclude. Host/shard/cluster: %d/%d/%s",
 				host.Runtime.Address.ReplicaIndex, host.Runtime.Address.ShardIndex, host.Runtime.Address.ClusterName)
 		return true
+	case w.task.zookeeperOnlyChange:
+		w.a.V(1).
+			M(host).F().
+			Info("Only ZooKeeper config changed, applied by config reload, no need to exclude. Host/shard/cluster: %d/%d/%s",
+				host.Runtime.Address.ReplicaIndex, host.Runtime.Address.ShardIndex, host.Runtime.Address.ClusterName)
+		return false
 	case host.GetReconcileAttributes().GetStatus() == api.ObjectStatusNew:
 		w.a.V(1).
 			M(host).F().
@@ -1249,6 +1823,9 @@ func (w *worker) shouldWaitIncludeHost(host *api.ChiHost) bool {
 	case host.GetShard().HostsCount() == 1:
 		// No need to wait one-host-shard
 		return false
+	case model.IsHostCordoned(host):
+		// Shard of the host is in maintenance and the host is not expected to appear in the cluster
+		return false
 	case host.GetCHI().GetReconciling().IsReconcilingPolicyWait():
 		// Check CHI settings - explicitly requested to wait
 		return true
@@ -1263,7 +1840,33 @@ func (w *worker) shouldWaitIncludeHost(host *api.ChiHost) bool {
 
 // waitHostInCluster
 func (w *worker) waitHostInCluster(ctx context.Context, host *api.ChiHost) error {
-	return w.c.pollHost(ctx, host, nil, w.ensureClusterSchemer(host).IsHostInCluster)
+	startTime := time.Now()
+	defer func() {
+		metricsHostWaitInClusterTimings(ctx, host.GetCHI(), time.Now().Sub(startTime).Seconds())
+	}()
+
+	stableChecks := chop.Config().Reconcile.Host.Wait.StableChecks
+	return w.c.pollHost(ctx, host, nil, w.newStableCheck(stableChecks, w.ensureClusterSchemer(host).IsHostInCluster))
+}
+
+// newStableCheck wraps check, so it is considered done only after it succeeds specified number of consecutive times.
+// Host may flap in and out of the cluster while ClickHouse picks up configuration changes.
+func (w *worker) newStableCheck(
+	stableChecks int,
+	check func(ctx context.Context, host *api.ChiHost) bool,
+) func(ctx context.Context, host *api.ChiHost) bool {
+	consecutive := 0
+	return func(ctx context.Context, host *api.ChiHost) bool {
+		if !check(ctx, host) {
+			if consecutive > 0 {
+				w.a.V(1).M(host).F().Info("host is not stable, passed %d of %d checks. Restart checks", consecutive, stableChecks)
+			}
+			consecutive = 0
+			return false
+		}
+		consecutive++
+		return consecutive >= stableChecks
+	}
 }
 
 // waitHostNotInCluster
@@ -1299,6 +1902,31 @@ func (w *worker) createCHIFromObjectMeta(objectMeta *meta.ObjectMeta, isCHI bool
 	return chi, nil
 }
 
+// createCompletedCHIFromObjectMeta creates CHI as it is reconciled by the last completed reconcile.
+// Objects rendered out of reconcile are rendered from it, so changes of the spec, which are not reconciled
+// or not approved yet, are not applied behind the reconcile.
+func (w *worker) createCompletedCHIFromObjectMeta(objectMeta *meta.ObjectMeta, options *normalizer.Options) (*api.ClickHouseInstallation, error) {
+	chi, err := w.c.GetCHIByObjectMeta(objectMeta, true)
+	if err != nil {
+		return nil, err
+	}
+	return w.createCompletedCHI(chi, options)
+}
+
+// createCompletedCHI creates CHI as it is reconciled by the last completed reconcile out of the CHI fetched already
+func (w *worker) createCompletedCHI(chi *api.ClickHouseInstallation, options *normalizer.Options) (*api.ClickHouseInstallation, error) {
+	if !chi.HasAncestor() {
+		return nil, fmt.Errorf("CHI %s/%s is not reconciled yet", chi.Namespace, chi.Name)
+	}
+
+	completed := chi.GetAncestor().DeepCopy()
+	// Runtime state, such as failed hosts, is tracked in status of the CHI
+	completed.EnsureStatus().CopyFrom(chi.Status, api.CopyCHIStatusOptions{
+		InheritableFields: true,
+	})
+	return w.normalizer.CreateTemplatedCHI(completed, options)
+}
+
 // updateConfigMap
 func (w *worker) updateConfigMap(ctx context.Context, chi *api.ClickHouseInstallation, configMap *core.ConfigMap) error {
 	if util.IsContextDone(ctx) {
@@ -1370,6 +1998,26 @@ func (w *worker) updateService(
 			curService.Spec.Type, targetService.Spec.Type)
 	}
 
+	if k8s.ServiceIPFamiliesChanged(curService, targetService) {
+		// spec.ipFamilies and spec.ipFamilyPolicy can not be changed in-place
+		w.a.V(1).M(chi).F().Warning(
+			"Service: %s/%s IP families change can not be applied in-place, service will be re-created",
+			curService.Namespace, curService.Name)
+		return fmt.Errorf(
+			"just recreate the service in case of ip families change '%v'=>'%v'",
+			curService.Spec.IPFamilies, targetService.Spec.IPFamilies)
+	}
+
+	if k8s.ServiceClusterIPChanged(curService, targetService) {
+		// spec.clusterIP can not be changed in-place, ex.: switch to/from headless service
+		w.a.V(1).M(chi).F().Warning(
+			"Service: %s/%s cluster IP change can not be applied in-place, service will be re-created",
+			curService.Namespace, curService.Name)
+		return fmt.Errorf(
+			"just recreate the service in case of cluster IP change '%s'=>'%s'",
+			curService.Spec.ClusterIP, targetService.Spec.ClusterIP)
+	}
+
 	// Updating a Service is a complicated business
 
 	newService := targetService.DeepCopy()
@@ -1439,6 +2087,13 @@ func (w *worker) updateService(
 		newService.Spec.LoadBalancerClass = curService.Spec.LoadBalancerClass
 	}
 
+	//
+	// Migrate session affinity to the new service
+	//
+	// spec.sessionAffinity is defaulted to None by the cluster, so ClientIP affinity set up on the service
+	// would be silently reset by the update, unless the new service specifies session affinity explicitly
+	k8s.ServicePreserveSessionAffinity(curService, newService)
+
 	//
 	// Migrate labels, annotations and finalizers to the new service
 	//
@@ -1458,7 +2113,7 @@ func (w *worker) updateService(
 			M(chi).F().
 			Info("Update Service success: %s/%s", newService.Namespace, newService.Name)
 	} else {
-		w.a.M(chi).F().Error("Update Service fail: %s/%s failed with error %v", newService.Namespace, newService.Name)
+		w.a.M(chi).F().Error("Update Service fail: %s/%s failed with error %v", newService.Namespace, newService.Name, err)
 	}
 
 	return err
@@ -1709,12 +2364,18 @@ func (w *worker) updateStatefulSet(ctx context.Context, host *api.ChiHost, regis
 	}
 
 	action := errCRUDRecreate
-	if k8s.IsStatefulSetReady(curStatefulSet) {
+	switch {
+	case k8s.IsStatefulSetReady(curStatefulSet):
 		action = w.c.updateStatefulSet(ctx, curStatefulSet, newStatefulSet, host)
+	case w.hasFailedRollout(host):
+		// StatefulSet is not ready due to failed rollout. Retry rollout instead of recreate,
+		// because recreate drops revision history StatefulSet can be rolled back to
+		action = w.retryStatefulSetRollout(ctx, curStatefulSet, newStatefulSet, host)
 	}
 
 	switch action {
 	case nil:
+		w.onStatefulSetRolledOut(host)
 		if register {
 			host.GetCHI().EnsureStatus().HostUpdated()
 			_ = w.c.updateCHIObjectStatus(ctx, host.GetCHI(), UpdateCHIStatusOptions{
@@ -1731,9 +2392,11 @@ func (w *worker) updateStatefulSet(ctx context.Context, host *api.ChiHost, regis
 		return nil
 	case errCRUDAbort:
 		w.a.V(1).M(host).Info("Update StatefulSet(%s/%s) - got abort. Abort", namespace, name)
+		w.onStatefulSetRolloutFailed(ctx, host)
 		return errCRUDAbort
 	case errCRUDIgnore:
 		w.a.V(1).M(host).Info("Update StatefulSet(%s/%s) - got ignore. Ignore", namespace, name)
+		w.onStatefulSetRolloutFailed(ctx, host)
 		return nil
 	case errCRUDRecreate:
 		w.a.WithEvent(host.GetCHI(), eventActionUpdate, eventReasonUpdateInProgress).
@@ -1758,17 +2421,80 @@ func (w *worker) recreateStatefulSet(ctx context.Context, host *api.ChiHost, reg
 		return nil
 	}
 
-	_ = w.c.deleteStatefulSet(ctx, host)
+	// PVCs of the host have to survive StatefulSet recreation
+	pvcs := w.c.getHostPVCNames(host)
+
+	// Orphan dependents, so PVCs are not cascade-deleted along with the StatefulSet
+	_ = w.c.deleteStatefulSetOrphanDependents(ctx, host)
+
+	if err := w.c.checkHostPVCsExist(ctx, host, pvcs); err != nil {
+		w.a.V(1).
+			WithEvent(host.GetCHI(), eventActionUpdate, eventReasonUpdateFailed).
+			WithStatusAction(host.GetCHI()).
+			WithStatusError(host.GetCHI()).
+			M(host).F().
+			Error("Recreate StatefulSet(%s) aborted to protect data. Err: %v", util.NamespaceNameString(host.Runtime.DesiredStatefulSet.ObjectMeta), err)
+		return err
+	}
+
 	_ = w.reconcilePVCs(ctx, host, api.DesiredStatefulSet)
 	return w.createStatefulSet(ctx, host, register)
 }
 
-// applyPVCResourcesRequests
+// applyPVCResourcesRequests applies resources requests of the template to the PVC.
+// PVC can not be shrunk, thus storage request below PVC's current size is not applied.
+// Storage size PVC is actually provisioned with is recorded in CHI status.
 func (w *worker) applyPVCResourcesRequests(
 	pvc *core.PersistentVolumeClaim,
+	host *api.ChiHost,
 	template *api.VolumeClaimTemplate,
 ) bool {
-	return w.applyResourcesList(pvc.Spec.Resources.Requests, template.Spec.Resources.Requests)
+	desired := template.Spec.Resources.Requests
+	if requested, ok := desired[core.ResourceStorage]; ok {
+		if current, ok := getPVCStorageSize(pvc); ok && (requested.Cmp(current) < 0) {
+			w.a.V(1).M(host).F().Warning(
+				"PVC %s/%s can not be shrunk, requested storage %s is less than current size %s. Keep current size",
+				pvc.Namespace, pvc.Name, requested.String(), current.String(),
+			)
+			desired = desired.DeepCopy()
+			delete(desired, core.ResourceStorage)
+		}
+	}
+
+	updated := w.applyResourcesList(pvc.Spec.Resources.Requests, desired)
+	host.GetCHI().EnsureStatus().SetEffectiveStorage(pvc.Name, getPVCEffectiveStorage(pvc, template))
+	return updated
+}
+
+// getPVCStorageSize gets current storage size of the PVC, which is the largest of requested and provisioned ones
+func getPVCStorageSize(pvc *core.PersistentVolumeClaim) (resource.Quantity, bool) {
+	requested, requestedOk := pvc.Spec.Resources.Requests[core.ResourceStorage]
+	capacity, capacityOk := pvc.Status.Capacity[core.ResourceStorage]
+	switch {
+	case requestedOk && capacityOk:
+		if capacity.Cmp(requested) > 0 {
+			return capacity, true
+		}
+		return requested, true
+	case capacityOk:
+		return capacity, true
+	case requestedOk:
+		return requested, true
+	}
+	return resource.Quantity{}, false
+}
+
+// getPVCEffectiveStorage gets storage size PVC is actually provisioned with, in case it differs from the one requested by the template.
+// Returns empty string in case PVC matches the template.
+func getPVCEffectiveStorage(pvc *core.PersistentVolumeClaim, template *api.VolumeClaimTemplate) string {
+	current, ok := getPVCStorageSize(pvc)
+	if !ok {
+		return ""
+	}
+	if requested, ok := template.Spec.Resources.Requests[core.ResourceStorage]; ok && requested.Equal(current) {
+		return ""
+	}
+	return current.String()
 }
 
 // applyResourcesList
@@ -1833,8 +2559,19 @@ func (w *worker) ensureClusterSchemer(host *api.ChiHost) *schemer.ClusterSchemer
 	if w == nil {
 		return nil
 	}
+	w.schemer = w.newClusterSchemer(host)
+	return w.schemer
+}
+
+// newClusterSchemer creates schemer to access the host with. Worker's schemer is not modified
+func (w *worker) newClusterSchemer(host *api.ChiHost) *schemer.ClusterSchemer {
 	// Make base cluster connection params
 	clusterConnectionParams := clickhouse.NewClusterConnectionParamsFromCHOpConfig(chop.Config())
+	// Adjust base cluster connection params with per-CHI access props
+	if scheme := host.GetCHI().GetAccess().GetScheme(); scheme != "" {
+		clusterConnectionParams.Scheme = scheme
+	}
+	clusterConnectionParams.SetTLS(w.getAccessTLS(host))
 	// Adjust base cluster connection params with per-host props
 	switch clusterConnectionParams.Scheme {
 	case api.ChSchemeAuto:
@@ -1851,7 +2588,103 @@ func (w *worker) ensureClusterSchemer(host *api.ChiHost) *schemer.ClusterSchemer
 	case api.ChSchemeHTTPS:
 		clusterConnectionParams.Port = int(host.HTTPSPort)
 	}
-	w.schemer = schemer.NewClusterSchemer(clusterConnectionParams, host.Runtime.Version)
+	s := schemer.NewClusterSchemer(clusterConnectionParams, host.Runtime.Version)
+	if params := w.newManagedUserConnectionParams(host, clusterConnectionParams); params != nil {
+		s.SetManagedUserConnectionParams(params)
+	}
+	s.SetSystemCommandsPolicy(
+		schemer.NewSystemCommandsPolicy(
+			chop.Config().ClickHouse.SystemCommands.Allow,
+			chop.Config().ClickHouse.SystemCommands.Deny,
+		),
+		func(sql string) {
+			w.a.V(1).
+				WithEvent(host.GetCHI(), eventActionReconcile, eventReasonSystemCommandDenied).
+				WithStatusAction(host.GetCHI()).
+				M(host).F().
+				Warning("SYSTEM command is denied by the operator configuration, skip it: %s", sql)
+		},
+	)
+	s.SetExecFailedHandler(func(ctx context.Context, err error) {
+		metricsSchemerDDLErrors(ctx, host.GetCHI())
+	})
 
-	return w.schemer
+	return s
+}
+
+// newManagedUserConnectionParams makes connection params of the least-privilege user managed by the operator
+// out of base connection params. Returns nil in case managed user is not enabled or its password is not available
+func (w *worker) newManagedUserConnectionParams(
+	host *api.ChiHost,
+	base *clickhouse.ClusterConnectionParams,
+) *clickhouse.ClusterConnectionParams {
+	managedUser := chop.Config().ClickHouse.Access.ManagedUser
+	if !managedUser.IsEnabled() {
+		return nil
+	}
+
+	chi := host.GetCHI()
+	secret, err := w.c.getSecret(&core.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: chi.Namespace,
+			Name:      model.CreateManagedUserSecretName(chi),
+		},
+	})
+	if err != nil {
+		w.a.V(1).M(host).F().Warning("Unable to get password of the managed user, fallback to the operator user. err: %v", err)
+		return nil
+	}
+	password, ok := secret.Data[model.ManagedUserSecretPasswordKey]
+	if !ok {
+		w.a.V(1).M(host).F().Warning("No password of the managed user in secret %s, fallback to the operator user", secret.Name)
+		return nil
+	}
+
+	return clickhouse.NewClusterConnectionParams(
+		base.Scheme,
+		managedUser.Username,
+		string(password),
+		base.RootCA,
+		base.Port,
+	).SetTimeouts(base.Timeouts).SetTLS(base.TLS)
+}
+
+// getAccessTLS gets TLS material to connect to the host with. Material is cached per CHI,
+// so secrets are read only in case either CHI or the secrets change
+func (w *worker) getAccessTLS(host *api.ChiHost) *clickhouse.TLS {
+	chi := host.GetCHI()
+	return w.c.accessTLS.Get(chi.Namespace, chi.Name, func() *clickhouse.TLS {
+		return w.newAccessTLS(host)
+	})
+}
+
+// newAccessTLS makes TLS material to connect to the host with out of secrets specified in the CHI.
+// Returns nil in case CHI does not specify TLS material
+func (w *worker) newAccessTLS(host *api.ChiHost) *clickhouse.TLS {
+	spec := host.GetCHI().GetAccess().GetTLS()
+	if spec == nil {
+		return nil
+	}
+
+	namespace := host.GetCHI().Namespace
+	tls := clickhouse.NewTLS(nil, nil, nil, spec.IsInsecureSkipVerify())
+	var err error
+	if spec.CA != nil {
+		if tls.CA, err = w.c.getSecretKey(namespace, spec.CA); err != nil {
+			// Server certificates are still verified, against system CAs though
+			w.a.V(1).M(host).F().Warning("Unable to get CA certificates, verify with system CAs. err: %v", err)
+		}
+	}
+	if spec.HasClientCert() {
+		cert, errCert := w.c.getSecretKey(namespace, spec.Cert)
+		key, errKey := w.c.getSecretKey(namespace, spec.Key)
+		if (errCert == nil) && (errKey == nil) {
+			tls.Cert = cert
+			tls.Key = key
+		} else {
+			w.a.V(1).M(host).F().Warning("Unable to get client certificate, connect without it. err: %v %v", errCert, errKey)
+		}
+	}
+
+	return tls
 }
@@ -0,0 +1,776 @@
+package chi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeFake "k8s.io/client-go/kubernetes/fake"
+	appsListers "k8s.io/client-go/listers/apps/v1"
+	coreListers "k8s.io/client-go/listers/core/v1"
+	k8sTesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/chop"
+	chopFake "github.com/altinity/clickhouse-operator/pkg/client/clientset/versioned/fake"
+	"github.com/altinity/clickhouse-operator/pkg/controller"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	chiCreator "github.com/altinity/clickhouse-operator/pkg/model/chi/creator"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi/normalizer"
+)
+
+// setTestConfig makes the operator run with the specified config till the end of the test
+func setTestConfig(t *testing.T, config *api.OperatorConfig) {
+	chop.NewWithConfig(config)
+	t.Cleanup(func() {
+		chop.NewWithConfig(nil)
+	})
+}
+
+// newTestShard creates CHI with one shard of specified number of replicas and returns hosts of the shard
+func newTestShard(replicas int) []*api.ChiHost {
+	chi := &api.ClickHouseInstallation{
+		Spec: api.ChiSpec{
+			Configuration: &api.Configuration{},
+		},
+	}
+	cluster := &api.Cluster{
+		Name:   "cluster",
+		Layout: api.NewChiClusterLayout(),
+	}
+	shard := api.ChiShard{Name: "0"}
+	for i := 0; i < replicas; i++ {
+		host := &api.ChiHost{Name: fmt.Sprintf("0-%d", i)}
+		host.Runtime.CHI = chi
+		host.Runtime.Address.ClusterName = cluster.Name
+		host.Runtime.Address.ShardName = shard.Name
+		shard.Hosts = append(shard.Hosts, host)
+	}
+	cluster.Layout.Shards = append(cluster.Layout.Shards, shard)
+	chi.Spec.Configuration.Clusters = append(chi.Spec.Configuration.Clusters, cluster)
+	return shard.Hosts
+}
+
+func Test_MinHealthyReplicas_FloorIsNeverViolatedDuringRollout(t *testing.T) {
+	const min = 2
+	ctx := context.Background()
+	hosts := newTestShard(3)
+	kubeClient := kubeFake.NewSimpleClientset(newMinHealthyReplicasTestPods(hosts)...)
+	w := &worker{c: &Controller{kubeClient: kubeClient}, a: NewAnnouncer()}
+	healthyNum := func() (num int) {
+		for _, host := range hosts {
+			if w.isHostHealthy(host) {
+				num++
+			}
+		}
+		return num
+	}
+
+	// The last replica is down due to parallel activity and returns after a while
+	require.NoError(t, w.c.deleteLabelReadyPod(ctx, hosts[2]))
+	ticks := 0
+	const recoverAfterTicks = 3
+
+	for _, host := range hosts {
+		// Wait until the host is excluded
+		for !w.excludeHostKeepingMinHealthyReplicas(ctx, host, min) {
+			ticks++
+			require.Less(t, ticks, 10, "rollout is stuck")
+			if ticks >= recoverAfterTicks {
+				require.NoError(t, w.c.appendLabelReadyOnPod(ctx, hosts[2]))
+			}
+		}
+
+		// The host is excluded and is restarted
+		require.False(t, w.isHostHealthy(host))
+		require.GreaterOrEqual(t, healthyNum(), min, "floor violated by excluding host %s", host.Name)
+		require.NoError(t, w.c.appendLabelReadyOnPod(ctx, host))
+	}
+
+	// The first host had to wait for the replica to return
+	require.Equal(t, recoverAfterTicks, ticks)
+}
+
+func Test_MinHealthyReplicas_Violation(t *testing.T) {
+	ctx := context.Background()
+	hosts := newTestShard(2)
+	kubeClient := kubeFake.NewSimpleClientset(newMinHealthyReplicasTestPods(hosts)...)
+	w := &worker{c: &Controller{kubeClient: kubeClient}, a: NewAnnouncer()}
+	require.NoError(t, w.c.deleteLabelReadyPod(ctx, hosts[1]))
+
+	// The only other replica is down
+	require.False(t, w.excludeHostKeepingMinHealthyReplicas(ctx, hosts[0], 1))
+	require.True(t, w.isHostHealthy(hosts[0]))
+
+	// Unhealthy host can be excluded anyway
+	require.True(t, w.excludeHostKeepingMinHealthyReplicas(ctx, hosts[1], 1))
+
+	// No limit specified
+	require.True(t, w.excludeHostKeepingMinHealthyReplicas(ctx, hosts[0], 0))
+	require.False(t, w.isHostHealthy(hosts[0]))
+
+	// Limit is capped by the number of other replicas
+	require.NoError(t, w.c.appendLabelReadyOnPod(ctx, hosts[0]))
+	require.NoError(t, w.c.appendLabelReadyOnPod(ctx, hosts[1]))
+	require.True(t, w.excludeHostKeepingMinHealthyReplicas(ctx, hosts[0], 5))
+	require.False(t, w.isHostHealthy(hosts[0]))
+}
+
+func Test_IncludeHost_PeersLearnNewHostBeforeInclusion(t *testing.T) {
+	hosts := newTestShard(3)
+	for _, host := range hosts {
+		host.Runtime.Address.CHIName = "chi"
+		host.Runtime.Address.HostName = host.Name
+	}
+	newHost := hosts[2]
+	newHost.GetCHI().Spec.Defaults = api.NewChiDefaults()
+	newHost.GetReconcileAttributes().SetAdd().SetStatus(api.ObjectStatusNew)
+
+	w := &worker{}
+	generator := model.NewClickHouseConfigGenerator(newHost.GetCHI())
+	hostname := "<host>" + model.CreateInstanceHostname(newHost) + "</host>"
+
+	// Preliminary remote_servers config does not list the new host
+	require.Contains(t, generator.GetRemoteServers(w.getRemoteServersGeneratorOptions()), model.CreateInstanceHostname(hosts[0]))
+	require.NotContains(t, generator.GetRemoteServers(w.getRemoteServersGeneratorOptions()), hostname)
+
+	// New host is created and included into remote_servers config
+	newHost.GetReconcileAttributes().UnsetAdd()
+	newHost.GetReconcileAttributes().UnsetExclude()
+	require.Contains(t, generator.GetRemoteServers(w.getRemoteServersGeneratorOptions()), hostname)
+
+	// All running hosts have to learn about the new host before it is included into the service
+	require.Equal(t, hosts[:2], getHostPeers(newHost))
+
+	// Host, which is not created yet, gets up-to-date config on its own
+	hosts[1].GetReconcileAttributes().SetAdd()
+	require.Equal(t, hosts[:1], getHostPeers(newHost))
+}
+
+func newTestPVC(requested, capacity string) *core.PersistentVolumeClaim {
+	pvc := &core.PersistentVolumeClaim{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "data-chi-0-0-0",
+		},
+		Spec: core.PersistentVolumeClaimSpec{
+			Resources: core.ResourceRequirements{
+				Requests: core.ResourceList{
+					core.ResourceStorage: resource.MustParse(requested),
+				},
+			},
+		},
+	}
+	if capacity != "" {
+		pvc.Status.Capacity = core.ResourceList{
+			core.ResourceStorage: resource.MustParse(capacity),
+		}
+	}
+	return pvc
+}
+
+func newTestVolumeClaimTemplate(storage string) *api.VolumeClaimTemplate {
+	return &api.VolumeClaimTemplate{
+		Name: "data",
+		Spec: core.PersistentVolumeClaimSpec{
+			Resources: core.ResourceRequirements{
+				Requests: core.ResourceList{
+					core.ResourceStorage: resource.MustParse(storage),
+				},
+			},
+		},
+	}
+}
+
+func Test_ApplyPVCResourcesRequests_CapacityIsFloor(t *testing.T) {
+	w := &worker{
+		a: NewAnnouncer(),
+	}
+	host := &api.ChiHost{}
+	host.Runtime.CHI = &api.ClickHouseInstallation{}
+
+	// Template storage is below the provisioned PVC
+	pvc := newTestPVC("500Gi", "500Gi")
+	require.False(t, w.applyPVCResourcesRequests(pvc, host, newTestVolumeClaimTemplate("100Gi")))
+	require.Equal(t, "500Gi", pvc.Spec.Resources.Requests.Storage().String())
+	require.Equal(t, "500Gi", host.GetCHI().EnsureStatus().GetEffectiveStorage(pvc.Name))
+
+	// Provisioner allocated more than requested
+	pvc = newTestPVC("100Gi", "120Gi")
+	require.False(t, w.applyPVCResourcesRequests(pvc, host, newTestVolumeClaimTemplate("110Gi")))
+	require.Equal(t, "100Gi", pvc.Spec.Resources.Requests.Storage().String())
+	require.Equal(t, "120Gi", host.GetCHI().EnsureStatus().GetEffectiveStorage(pvc.Name))
+
+	// Template storage grows
+	pvc = newTestPVC("500Gi", "500Gi")
+	require.True(t, w.applyPVCResourcesRequests(pvc, host, newTestVolumeClaimTemplate("600Gi")))
+	require.Equal(t, "600Gi", pvc.Spec.Resources.Requests.Storage().String())
+	require.Equal(t, "", host.GetCHI().EnsureStatus().GetEffectiveStorage(pvc.Name))
+
+	// PVC matches template
+	pvc = newTestPVC("100Gi", "100Gi")
+	require.False(t, w.applyPVCResourcesRequests(pvc, host, newTestVolumeClaimTemplate("100Gi")))
+	require.Equal(t, "", host.GetCHI().EnsureStatus().GetEffectiveStorage(pvc.Name))
+}
+
+func Test_WaitHostInCluster_RequiresStableMembership(t *testing.T) {
+	host := newTestShard(1)[0]
+	w := &worker{a: NewAnnouncer()}
+
+	// waitInCluster polls fake schemer, which reports host membership in the cluster in the specified sequence
+	waitInCluster := func(stableChecks int, membership ...bool) (checks int) {
+		isHostInCluster := func(ctx context.Context, host *api.ChiHost) bool {
+			inCluster := membership[checks]
+			checks++
+			return inCluster
+		}
+		isDone := w.newStableCheck(stableChecks, isHostInCluster)
+		err := controller.Poll(
+			context.Background(),
+			"ns", "host",
+			&controller.PollerOptions{
+				Timeout:      5 * time.Second,
+				MainInterval: time.Millisecond,
+			},
+			&controller.PollerFunctions{
+				IsDone: func(ctx context.Context, _ any) bool {
+					return isDone(ctx, host)
+				},
+			},
+			nil,
+		)
+		require.NoError(t, err)
+		return checks
+	}
+
+	// Host flaps in, out and in again - wait for it to stay in the cluster
+	require.Equal(t, 5, waitInCluster(3, true, false, true, true, true))
+
+	// Single check is enough by default
+	require.Equal(t, 1, waitInCluster(1, true, false, true))
+	require.Equal(t, 2, waitInCluster(1, false, true))
+}
+
+func Test_ShouldMigrateTables_SchemaManagement(t *testing.T) {
+	w := &worker{a: NewAnnouncer()}
+	host := newTestShard(1)[0]
+	force := &migrateTableOptions{forceMigrate: true}
+
+	// Managed cluster migrates
+	host.GetCluster().SchemaManagement = model.SchemaManagementManaged
+	require.True(t, w.shouldMigrateTables(host, force))
+
+	// Cluster, which schema is managed outside of the operator, never migrates
+	host.GetCluster().SchemaManagement = model.SchemaManagementNone
+	require.False(t, w.shouldMigrateTables(host))
+	require.False(t, w.shouldMigrateTables(host, force))
+
+	// Schemer is not even created, thus no DDL is run
+	require.NoError(t, w.migrateTables(context.Background(), host, force))
+	require.Nil(t, w.schemer)
+	require.Empty(t, host.GetCHI().EnsureStatus().GetHostsWithTablesCreated())
+}
+
+// newMinHealthyReplicasTestPods creates healthy pods of the hosts
+func newMinHealthyReplicasTestPods(hosts []*api.ChiHost) (objects []runtime.Object) {
+	for i, host := range hosts {
+		host.Runtime.Address.Namespace = "ns"
+		host.Runtime.Address.CHIName = "chi"
+		host.Runtime.Address.HostName = fmt.Sprintf("0-%d", i)
+		objects = append(objects, &core.Pod{
+			ObjectMeta: meta.ObjectMeta{
+				Namespace: "ns",
+				Name:      model.CreatePodName(host),
+				Labels:    map[string]string{model.LabelReadyName: model.LabelReadyValueReady},
+			},
+			Status: core.PodStatus{
+				Conditions: []core.PodCondition{{Type: core.PodReady, Status: core.ConditionTrue}},
+			},
+		})
+	}
+	return objects
+}
+
+func Test_ExcludeHostKeepingMinHealthyReplicas(t *testing.T) {
+	ctx := context.Background()
+	hosts := newTestShard(2)
+	kubeClient := kubeFake.NewSimpleClientset(newMinHealthyReplicasTestPods(hosts)...)
+	w := &worker{c: &Controller{kubeClient: kubeClient}, a: NewAnnouncer()}
+
+	// Other replica is excluded concurrently, right between the check and the exclusion of the host
+	concurrent := true
+	kubeClient.PrependReactor("update", "pods", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+		pod := action.(k8sTesting.UpdateAction).GetObject().(*core.Pod)
+		if concurrent && (pod.Name == model.CreatePodName(hosts[0])) {
+			concurrent = false
+			pods := core.SchemeGroupVersion.WithResource("pods")
+			obj, err := kubeClient.Tracker().Get(pods, "ns", model.CreatePodName(hosts[1]))
+			require.NoError(t, err)
+			other := obj.(*core.Pod).DeepCopy()
+			model.DeleteLabelReady(&other.ObjectMeta)
+			require.NoError(t, kubeClient.Tracker().Update(pods, other, "ns"))
+		}
+		return false, nil, nil
+	})
+	require.False(t, w.excludeHostKeepingMinHealthyReplicas(ctx, hosts[0], 1))
+	// Exclusion is reverted, so the shard is not left without healthy replicas
+	require.True(t, w.isHostHealthy(hosts[0]))
+	require.False(t, w.isHostHealthy(hosts[1]))
+	// The host is excluded, once the other replica is back
+	_ = w.c.appendLabelReadyOnPod(ctx, hosts[1])
+	require.True(t, w.excludeHostKeepingMinHealthyReplicas(ctx, hosts[0], 1))
+	require.False(t, w.isHostHealthy(hosts[0]))
+	require.True(t, w.isHostHealthy(hosts[1]))
+}
+
+func Test_ExcludeHostKeepingMinHealthyReplicas_Conflict(t *testing.T) {
+	ctx := context.Background()
+	hosts := newTestShard(2)
+	kubeClient := kubeFake.NewSimpleClientset(newMinHealthyReplicasTestPods(hosts)...)
+	w := &worker{c: &Controller{kubeClient: kubeClient}, a: NewAnnouncer()}
+
+	// Pod is modified after it has been checked
+	kubeClient.PrependReactor("update", "pods", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apiErrors.NewConflict(core.Resource("pods"), model.CreatePodName(hosts[0]), fmt.Errorf("modified"))
+	})
+	require.False(t, w.excludeHostKeepingMinHealthyReplicas(ctx, hosts[0], 1))
+	require.True(t, w.isHostHealthy(hosts[0]))
+}
+
+func Test_CheckClusterHealth_DegradedClusterBlocksExclusion(t *testing.T) {
+	// Cluster of 2 shards with 2 replicas each
+	hosts := newTestShard(2)
+	chi := hosts[0].GetCHI()
+	chi.Namespace = "ns"
+	chi.Name = "chi"
+	cluster := chi.Spec.Configuration.Clusters[0]
+	shard := api.ChiShard{Name: "1"}
+	for i := 0; i < 2; i++ {
+		host := &api.ChiHost{Name: fmt.Sprintf("1-%d", i)}
+		host.Runtime.CHI = chi
+		host.Runtime.Address.ClusterName = cluster.Name
+		host.Runtime.Address.ShardName = shard.Name
+		shard.Hosts = append(shard.Hosts, host)
+	}
+	cluster.Layout.Shards = append(cluster.Layout.Shards, shard)
+	host := hosts[0]
+	down := shard.Hosts[0]
+
+	reachable := map[*api.ChiHost]bool{}
+	chi.WalkHosts(func(host *api.ChiHost) error {
+		reachable[host] = true
+		return nil
+	})
+	isReachable := func(host *api.ChiHost) bool {
+		return reachable[host]
+	}
+
+	// Healthy cluster does not block exclusion
+	_, _, degraded := isClusterDegraded(host, 100, isReachable)
+	require.False(t, degraded)
+
+	// One replica of another shard is down already
+	reachable[down] = false
+	name, health, degraded := isClusterDegraded(host, 100, isReachable)
+	require.True(t, degraded)
+	require.Equal(t, "1", name)
+	require.Equal(t, 50, health)
+	_, _, degraded = isClusterDegraded(host, 50, isReachable)
+	require.False(t, degraded)
+	// No threshold - no limit
+	_, _, degraded = isClusterDegraded(host, 0, isReachable)
+	require.False(t, degraded)
+
+	// Unreachable host can be excluded, cluster does not get any worse
+	_, _, degraded = isClusterDegraded(down, 100, isReachable)
+	require.False(t, degraded)
+
+	// Host, which is not created yet, does not degrade the cluster
+	down.GetReconcileAttributes().SetStatus(api.ObjectStatusNew)
+	_, _, degraded = isClusterDegraded(host, 100, isReachable)
+	require.False(t, degraded)
+	down.GetReconcileAttributes().SetStatus(api.ObjectStatusSame)
+
+	// Exclusion is refused with ClusterDegraded event
+	kubeClient := kubeFake.NewSimpleClientset()
+	c := &Controller{
+		kubeClient: kubeClient,
+		chopClient: chopFake.NewSimpleClientset(&api.ClickHouseInstallation{ObjectMeta: chi.ObjectMeta}),
+	}
+	w := &worker{c: c, a: NewAnnouncer().WithController(c)}
+	err := w.checkClusterHealth(context.Background(), host, 100, isReachable)
+	require.ErrorIs(t, err, errClusterDegraded)
+	var reasons []string
+	for _, action := range kubeClient.Actions() {
+		if create, ok := action.(k8sTesting.CreateAction); ok && (action.GetResource().Resource == "events") {
+			reasons = append(reasons, create.GetObject().(*core.Event).Reason)
+		}
+	}
+	require.Equal(t, []string{eventReasonClusterDegraded}, reasons)
+
+	// Cluster recovered
+	reachable[down] = true
+	require.NoError(t, w.checkClusterHealth(context.Background(), host, 100, isReachable))
+}
+
+func Test_IsClusterDegraded_ChecksHostsConcurrently(t *testing.T) {
+	hosts := newTestShard(5)
+
+	// Each check waits for all other checks to start, so serial checks would never complete
+	var started sync.WaitGroup
+	started.Add(len(hosts))
+	isReachable := func(host *api.ChiHost) bool {
+		started.Done()
+		started.Wait()
+		return host != hosts[4]
+	}
+
+	var (
+		shard    string
+		health   int
+		degraded bool
+	)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		shard, health, degraded = isClusterDegraded(hosts[0], 100, isReachable)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "hosts are not checked concurrently")
+	}
+	require.True(t, degraded)
+	require.Equal(t, hosts[0].GetShard().Name, shard)
+	require.Equal(t, 80, health)
+}
+
+func Test_WaitHostReadinessQuery(t *testing.T) {
+	const query = "SELECT absolute_delay < 10 FROM system.replicas"
+	host := newTestShard(1)[0]
+	kubeClient := kubeFake.NewSimpleClientset()
+	c := &Controller{kubeClient: kubeClient}
+	w := &worker{c: c, a: NewAnnouncer().WithController(c)}
+	ctx := context.Background()
+
+	// No readiness query specified - nothing to wait for
+	w.waitHostReadinessQuery(ctx, host, func(context.Context, *api.ChiHost, string) bool {
+		require.Fail(t, "readiness query is not specified")
+		return false
+	}, time.Millisecond)
+
+	// Inclusion is gated until the query returns truthy result
+	host.GetCHI().Spec.Reconciling = &api.ChiReconciling{
+		ReadinessQuery: &api.ChiReadinessQuery{Query: query, Timeout: 60},
+	}
+	calls := 0
+	w.waitHostReadinessQuery(ctx, host, func(_ context.Context, _ *api.ChiHost, sql string) bool {
+		require.Equal(t, query, sql)
+		calls++
+		return calls == 3
+	}, time.Millisecond)
+	require.Equal(t, 3, calls)
+	require.Empty(t, kubeClient.Actions())
+
+	// Timeout is reported, host is included anyway
+	host.GetCHI().Spec.Reconciling.ReadinessQuery.Timeout = 1
+	w.waitHostReadinessQuery(ctx, host, func(context.Context, *api.ChiHost, string) bool {
+		return false
+	}, 10*time.Millisecond)
+	var reasons []string
+	for _, action := range kubeClient.Actions() {
+		if create, ok := action.(k8sTesting.CreateAction); ok && (action.GetResource().Resource == "events") {
+			reasons = append(reasons, create.GetObject().(*core.Event).Reason)
+		}
+	}
+	require.Equal(t, []string{eventReasonReadinessQueryTimeout}, reasons)
+}
+
+func Test_UpdateCHI_ReconcilePaused(t *testing.T) {
+	newPausedCHI := func(resourceVersion string) *api.ClickHouseInstallation {
+		return &api.ClickHouseInstallation{
+			ObjectMeta: meta.ObjectMeta{
+				Namespace:       "ns",
+				Name:            "chi",
+				ResourceVersion: resourceVersion,
+				Finalizers:      []string{FinalizerName},
+				Annotations:     map[string]string{model.AnnotationReconcile: model.AnnotationReconcilePaused},
+			},
+		}
+	}
+	old := newPausedCHI("1")
+	new := newPausedCHI("2")
+	kubeClient := kubeFake.NewSimpleClientset()
+	chopClient := chopFake.NewSimpleClientset(new)
+	c := &Controller{kubeClient: kubeClient, chopClient: chopClient}
+	w := &worker{c: c, a: NewAnnouncer().WithController(c)}
+
+	// Paused CHI is not reconciled, pause is reported
+	require.NoError(t, w.updateCHI(context.Background(), old, new))
+	var reasons []string
+	for _, action := range kubeClient.Actions() {
+		if create, ok := action.(k8sTesting.CreateAction); ok && (action.GetResource().Resource == "events") {
+			reasons = append(reasons, create.GetObject().(*core.Event).Reason)
+		}
+	}
+	require.Equal(t, []string{eventReasonReconcilePaused}, reasons)
+	for _, action := range chopClient.Actions() {
+		require.Equal(t, "get", action.GetVerb())
+	}
+
+	// Removal of the annotation resumes reconcile, even though generation is the same
+	resumed := newPausedCHI("3")
+	resumed.Annotations = nil
+	require.False(t, isReconcileResumed(old, new))
+	require.True(t, isReconcileResumed(new, resumed))
+	require.False(t, isReconcileResumed(resumed, resumed))
+}
+
+func Test_ReconcilePVC_UpdatesOnlyChangedPVC(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+
+	chi := &api.ClickHouseInstallation{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "chi"}}
+	chi.Spec.Configuration = &api.Configuration{Clusters: []*api.Cluster{{Name: "cluster"}}}
+	chi, err := normalizer.NewNormalizer(nil).CreateTemplatedCHI(chi, normalizer.NewOptions())
+	require.NoError(t, err)
+	host := chi.FindHost("cluster", 0, 0)
+	require.NotNil(t, host)
+
+	pvc := newTestPVC("100Gi", "100Gi")
+	pvc.ResourceVersion = "1"
+	kubeClient := kubeFake.NewSimpleClientset(pvc.DeepCopy())
+	w := &worker{
+		c:    &Controller{kubeClient: kubeClient},
+		a:    NewAnnouncer(),
+		task: newTask(chiCreator.NewCreator(chi)),
+	}
+	ctx := context.Background()
+	countUpdates := func() (updates int) {
+		for _, action := range kubeClient.Actions() {
+			if action.GetVerb() == "update" {
+				updates++
+			}
+		}
+		return updates
+	}
+
+	// Labels of the PVC are brought in line with the CHI
+	reconciled, err := w.reconcilePVC(ctx, pvc, host, newTestVolumeClaimTemplate("100Gi"))
+	require.NoError(t, err)
+	require.Equal(t, 1, countUpdates())
+
+	// PVC matching the template is not updated
+	kubeClient.ClearActions()
+	_, err = w.reconcilePVC(ctx, reconciled.DeepCopy(), host, newTestVolumeClaimTemplate("100Gi"))
+	require.NoError(t, err)
+	require.Equal(t, 0, countUpdates())
+
+	// Storage request below current size is not applied, so PVC is not updated either
+	_, err = w.reconcilePVC(ctx, reconciled.DeepCopy(), host, newTestVolumeClaimTemplate("50Gi"))
+	require.NoError(t, err)
+	require.Equal(t, 0, countUpdates())
+
+	// Grown storage request is applied
+	_, err = w.reconcilePVC(ctx, reconciled.DeepCopy(), host, newTestVolumeClaimTemplate("200Gi"))
+	require.NoError(t, err)
+	require.Equal(t, 1, countUpdates())
+	updated, err := kubeClient.CoreV1().PersistentVolumeClaims("ns").Get(ctx, pvc.Name, meta.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "200Gi", updated.Spec.Resources.Requests.Storage().String())
+}
+
+func Test_RecreateStatefulSet_KeepsPVCs(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "chi"},
+		Spec: api.ChiSpec{
+			Defaults: &api.ChiDefaults{
+				Templates: &api.ChiTemplateNames{DataVolumeClaimTemplate: "data"},
+			},
+			Templates: &api.Templates{
+				VolumeClaimTemplates: []api.VolumeClaimTemplate{
+					{
+						Name: "data",
+						Spec: core.PersistentVolumeClaimSpec{
+							Resources: core.ResourceRequirements{
+								Requests: core.ResourceList{core.ResourceStorage: resource.MustParse("1Gi")},
+							},
+						},
+					},
+				},
+			},
+			Configuration: &api.Configuration{Clusters: []*api.Cluster{{Name: "cluster"}}},
+		},
+	}
+	chi, err := normalizer.NewNormalizer(nil).CreateTemplatedCHI(chi, normalizer.NewOptions())
+	require.NoError(t, err)
+	host := chi.FindHost("cluster", 0, 0)
+	require.NotNil(t, host)
+	ctx := context.Background()
+
+	// newWorker creates worker of k8s, where the host has StatefulSet to be recreated along with the PVC.
+	// onDelete is called on deletion of the StatefulSet with propagation policy of the deletion.
+	newWorker := func(onDelete func(kubeClient *kubeFake.Clientset, propagation meta.DeletionPropagation)) (*worker, *kubeFake.Clientset) {
+		pvc := &core.PersistentVolumeClaim{
+			ObjectMeta: meta.ObjectMeta{
+				Namespace: "ns",
+				Name:      "data-" + model.CreatePodName(host),
+				Labels:    model.GetSelectorHostScope(host),
+			},
+			Status: core.PersistentVolumeClaimStatus{Phase: core.ClaimBound},
+		}
+		kubeClient := kubeFake.NewSimpleClientset(pvc)
+		kubeClient.PrependReactor("delete", "statefulsets", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+			onDelete(kubeClient, *action.(k8sTesting.DeleteAction).GetDeleteOptions().PropagationPolicy)
+			return false, nil, nil
+		})
+		// Fake client does not run pods, so StatefulSet is reported ready as soon as it is created
+		kubeClient.PrependReactor("create", "statefulsets", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+			statefulSet := action.(k8sTesting.CreateAction).GetObject().(*apps.StatefulSet)
+			statefulSet.Status.ReadyReplicas = *statefulSet.Spec.Replicas
+			statefulSet.Status.CurrentReplicas = *statefulSet.Spec.Replicas
+			statefulSet.Status.UpdatedReplicas = *statefulSet.Spec.Replicas
+			return false, nil, nil
+		})
+		c := &Controller{
+			kubeClient: kubeClient,
+			chopClient: chopFake.NewSimpleClientset(&api.ClickHouseInstallation{ObjectMeta: chi.ObjectMeta}),
+			statefulSetLister: appsListers.NewStatefulSetLister(
+				cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}),
+			),
+			serviceLister: coreListers.NewServiceLister(
+				cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}),
+			),
+		}
+		w := &worker{
+			c:    c,
+			a:    NewAnnouncer().WithController(c),
+			task: newTask(chiCreator.NewCreator(chi)),
+		}
+		w.prepareHostStatefulSetWithStatus(ctx, host, false)
+		_, err := kubeClient.AppsV1().StatefulSets("ns").Create(ctx, host.Runtime.DesiredStatefulSet.DeepCopy(), meta.CreateOptions{})
+		require.NoError(t, err)
+		return w, kubeClient
+	}
+	deletePVCs := func(kubeClient *kubeFake.Clientset) {
+		pvcs, err := kubeClient.Tracker().List(
+			core.SchemeGroupVersion.WithResource("persistentvolumeclaims"),
+			core.SchemeGroupVersion.WithKind("PersistentVolumeClaim"),
+			"ns",
+		)
+		require.NoError(t, err)
+		for _, pvc := range pvcs.(*core.PersistentVolumeClaimList).Items {
+			require.NoError(t, kubeClient.Tracker().Delete(core.SchemeGroupVersion.WithResource("persistentvolumeclaims"), "ns", pvc.Name))
+		}
+	}
+	getPVC := func(kubeClient *kubeFake.Clientset) error {
+		_, err := kubeClient.CoreV1().PersistentVolumeClaims("ns").Get(ctx, "data-"+model.CreatePodName(host), meta.GetOptions{})
+		return err
+	}
+
+	// StatefulSet is deleted orphaning its PVCs and is recreated on top of them.
+	// Fake client does not implement garbage collection, so cascade deletion of PVCs is emulated.
+	var propagations []meta.DeletionPropagation
+	w, kubeClient := newWorker(func(kubeClient *kubeFake.Clientset, propagation meta.DeletionPropagation) {
+		propagations = append(propagations, propagation)
+		if propagation != meta.DeletePropagationOrphan {
+			deletePVCs(kubeClient)
+		}
+	})
+	require.NoError(t, w.recreateStatefulSet(ctx, host, false))
+	require.Equal(t, []meta.DeletionPropagation{meta.DeletePropagationOrphan}, propagations)
+	require.NoError(t, getPVC(kubeClient))
+	statefulSet, err := kubeClient.AppsV1().StatefulSets("ns").Get(ctx, model.CreateStatefulSetName(host), meta.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), *statefulSet.Spec.Replicas)
+
+	// StatefulSet is not recreated without PVCs it had, so the host does not start with empty volumes
+	w, kubeClient = newWorker(func(kubeClient *kubeFake.Clientset, propagation meta.DeletionPropagation) {
+		deletePVCs(kubeClient)
+	})
+	require.Error(t, w.recreateStatefulSet(ctx, host, false))
+	require.True(t, apiErrors.IsNotFound(getPVC(kubeClient)))
+	_, err = kubeClient.AppsV1().StatefulSets("ns").Get(ctx, model.CreateStatefulSetName(host), meta.GetOptions{})
+	require.True(t, apiErrors.IsNotFound(err))
+}
+
+// testConfigReloader records config reloads of hosts
+type testConfigReloader struct {
+	reloads []time.Time
+}
+
+// HostReloadConfig records the reload
+func (r *testConfigReloader) HostReloadConfig(_ context.Context, _ *api.ChiHost) error {
+	r.reloads = append(r.reloads, time.Now())
+	return nil
+}
+
+// fakeConfigReloader makes the worker reload config by test reloader till the end of the test
+func fakeConfigReloader(t *testing.T) *testConfigReloader {
+	reloader := &testConfigReloader{}
+	newReloader := newConfigReloader
+	newConfigReloader = func(w *worker, host *api.ChiHost) configReloader {
+		return reloader
+	}
+	t.Cleanup(func() {
+		newConfigReloader = newReloader
+	})
+	return reloader
+}
+
+func Test_ReloadHostConfig_WaitsConfigMapPropagation(t *testing.T) {
+	setTestConfig(t, &api.OperatorConfig{})
+	newCHI := func(maxConcurrentQueries string) *api.ClickHouseInstallation {
+		chi := &api.ClickHouseInstallation{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "chi"}}
+		chi.Spec.Reconciling = &api.ChiReconciling{ConfigMapPropagationTimeout: 1}
+		chi.Spec.Configuration = &api.Configuration{
+			Settings: api.NewSettings(),
+			Clusters: []*api.Cluster{{Name: "cluster"}},
+		}
+		chi.Spec.Configuration.Settings.Set("max_concurrent_queries", api.NewSettingScalar(maxConcurrentQueries))
+		normalized, err := normalizer.NewNormalizer(nil).CreateTemplatedCHI(chi, normalizer.NewOptions())
+		require.NoError(t, err)
+		return normalized
+	}
+	// Setting is changed, which requires config reload only
+	chi := newCHI("200")
+	chi.SetAncestor(newCHI("100"))
+	host := chi.FindHost("cluster", 0, 0)
+	require.NotNil(t, host)
+	require.Equal(t, model.ConfigurationChangeReload, model.GetConfigurationChange(host))
+
+	reloader := fakeConfigReloader(t)
+	w := &worker{
+		a:    NewAnnouncer(),
+		task: newTask(chiCreator.NewCreator(chi)),
+	}
+
+	// Config is reloaded once the updated ConfigMap has propagated to the host
+	w.task.cmUpdate = time.Now()
+	w.reloadHostConfig(context.Background(), host)
+	require.Len(t, reloader.reloads, 1)
+	require.GreaterOrEqual(t, reloader.reloads[0].Sub(w.task.cmUpdate), time.Second)
+
+	// Config is not reloaded in case reconcile is aborted while waiting for the propagation
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	w.task.cmUpdate = time.Now()
+	w.reloadHostConfig(ctx, host)
+	require.Len(t, reloader.reloads, 1)
+
+	// Config is reloaded right away in case ConfigMap is not updated
+	w.task.cmUpdate = time.Time{}
+	w.reloadHostConfig(context.Background(), host)
+	require.Len(t, reloader.reloads, 2)
+}
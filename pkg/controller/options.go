@@ -61,3 +61,13 @@ func NewDeleteOptions() meta.DeleteOptions {
 		PropagationPolicy:  &propagationPolicy,
 	}
 }
+
+// NewDeleteOptionsOrphan returns filled *metav1.DeleteOptions, which orphan dependents of the deleted object
+func NewDeleteOptionsOrphan() meta.DeleteOptions {
+	gracePeriodSeconds := int64(0)
+	propagationPolicy := meta.DeletePropagationOrphan
+	return meta.DeleteOptions{
+		GracePeriodSeconds: &gracePeriodSeconds,
+		PropagationPolicy:  &propagationPolicy,
+	}
+}
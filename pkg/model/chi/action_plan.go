@@ -15,6 +15,8 @@
 package chi
 
 import (
+	"encoding/json"
+
 	"gopkg.in/d4l3k/messagediff.v1"
 
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -183,6 +185,53 @@ func (ap *ActionPlan) HasActionsToDo() bool {
 	return !ap.deletionTimestampEqual || !ap.finalizersEqual || !ap.attributesEqual
 }
 
+// IsZookeeperOnlyChange checks whether changes between states are limited to ZooKeeper config,
+// either CHI-level or cluster-level one
+func (ap *ActionPlan) IsZookeeperOnlyChange() bool {
+	if !ap.HasActionsToDo() {
+		return false
+	}
+	if !ap.labelsEqual || !ap.deletionTimestampEqual || !ap.finalizersEqual || !ap.attributesEqual {
+		return false
+	}
+	if ap.specDiff == nil {
+		return false
+	}
+	for _, paths := range []map[*messagediff.Path]interface{}{
+		ap.specDiff.Added,
+		ap.specDiff.Removed,
+		ap.specDiff.Modified,
+	} {
+		for ptrPath := range paths {
+			if !ap.isZookeeperPath(ptrPath) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isZookeeperPath checks whether path points into ZooKeeper config
+func (ap *ActionPlan) isZookeeperPath(ptrPath *messagediff.Path) bool {
+	for _, node := range *ptrPath {
+		if node.String() == ".Zookeeper" {
+			return true
+		}
+	}
+	return false
+}
+
+// isReplicasViewPath checks whether path points into replicas view of the cluster layout.
+// Hosts of replicas view are the same hosts as in shards view, thus they should not be walked twice
+func (ap *ActionPlan) isReplicasViewPath(ptrPath *messagediff.Path) bool {
+	for _, node := range *ptrPath {
+		if node.String() == ".Replicas" {
+			return true
+		}
+	}
+	return false
+}
+
 // String stringifies ActionPlan
 func (ap *ActionPlan) String() string {
 	if !ap.HasActionsToDo() {
@@ -234,6 +283,23 @@ func (ap *ActionPlan) String() string {
 	return str
 }
 
+// Hash creates hash of the ActionPlan. Hash changes whenever spec of any of CHIs the plan is built from changes.
+// Task ID is excluded, since it is generated anew on each reconcile, unless specified explicitly.
+func (ap *ActionPlan) Hash() string {
+	b, _ := json.Marshal([]*api.ChiSpec{actionPlanSpec(ap.old), actionPlanSpec(ap.new)})
+	return util.HashIntoString(b)
+}
+
+// actionPlanSpec prepares spec of the CHI to be hashed as a part of ActionPlan
+func actionPlanSpec(chi *api.ClickHouseInstallation) *api.ChiSpec {
+	if chi == nil {
+		return nil
+	}
+	spec := chi.Spec.DeepCopy()
+	spec.TaskID = nil
+	return spec
+}
+
 // GetNewHostsNum - total number of hosts to be achieved
 func (ap *ActionPlan) GetNewHostsNum() int {
 	return ap.new.HostsCount()
@@ -264,6 +330,9 @@ func (ap *ActionPlan) WalkRemoved(
 ) {
 	// TODO refactor to map[string]object handling, instead of slice
 	for path := range ap.specDiff.Removed {
+		if ap.isReplicasViewPath(path) {
+			continue
+		}
 		switch ap.specDiff.Removed[path].(type) {
 		case api.Cluster:
 			cluster := ap.specDiff.Removed[path].(api.Cluster)
@@ -295,6 +364,9 @@ func (ap *ActionPlan) WalkAdded(
 ) {
 	// TODO refactor to map[string]object handling, instead of slice
 	for path := range ap.specDiff.Added {
+		if ap.isReplicasViewPath(path) {
+			continue
+		}
 		switch ap.specDiff.Added[path].(type) {
 		case api.Cluster:
 			cluster := ap.specDiff.Added[path].(api.Cluster)
@@ -326,6 +398,9 @@ func (ap *ActionPlan) WalkModified(
 ) {
 	// TODO refactor to map[string]object handling, instead of slice
 	for path := range ap.specDiff.Modified {
+		if ap.isReplicasViewPath(path) {
+			continue
+		}
 		switch ap.specDiff.Modified[path].(type) {
 		case api.Cluster:
 			cluster := ap.specDiff.Modified[path].(api.Cluster)
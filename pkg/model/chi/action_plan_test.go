@@ -0,0 +1,84 @@
+package chi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+func Test_ActionPlan_IsZookeeperOnlyChange(t *testing.T) {
+	newCHI := func(zkHosts []string, settings map[string]string) *api.ClickHouseInstallation {
+		zk := &api.ChiZookeeperConfig{}
+		for _, host := range zkHosts {
+			zk.Nodes = append(zk.Nodes, api.ChiZookeeperNode{Host: host, Port: 2181})
+		}
+		s := api.NewSettings()
+		for name, value := range settings {
+			s.Set(name, api.NewSettingScalar(value))
+		}
+		return &api.ClickHouseInstallation{
+			Spec: api.ChiSpec{
+				Configuration: &api.Configuration{
+					Zookeeper: zk,
+					Settings:  s,
+					Clusters: []*api.Cluster{
+						{
+							Name:      "cluster",
+							Zookeeper: zk.MergeFrom(nil, api.MergeTypeFillEmptyValues),
+						},
+					},
+				},
+			},
+		}
+	}
+	ensemble := []string{"zk-0", "zk-1", "zk-2"}
+	scaled := []string{"zk-0", "zk-1", "zk-2", "zk-3", "zk-4"}
+	settings := map[string]string{"max_concurrent_queries": "100"}
+
+	old := newCHI(ensemble, settings)
+
+	// Nothing changed - nothing to do at all
+	require.False(t, NewActionPlan(old, newCHI(ensemble, settings)).IsZookeeperOnlyChange())
+
+	// ZooKeeper ensemble scaled
+	require.True(t, NewActionPlan(old, newCHI(scaled, settings)).IsZookeeperOnlyChange())
+
+	// ZooKeeper ensemble scaled along with other config changes
+	require.False(t, NewActionPlan(old, newCHI(scaled, map[string]string{"max_concurrent_queries": "200"})).IsZookeeperOnlyChange())
+
+	// Other config changes only
+	require.False(t, NewActionPlan(old, newCHI(ensemble, map[string]string{"max_concurrent_queries": "200"})).IsZookeeperOnlyChange())
+
+	// New CHI
+	require.False(t, NewActionPlan(nil, old).IsZookeeperOnlyChange())
+}
+
+func Test_ActionPlan_Hash(t *testing.T) {
+	newCHI := func(taskID string, replicas int) *api.ClickHouseInstallation {
+		return &api.ClickHouseInstallation{
+			Spec: api.ChiSpec{
+				TaskID: &taskID,
+				Configuration: &api.Configuration{
+					Clusters: []*api.Cluster{
+						{
+							Name:   "cluster",
+							Layout: &api.ChiClusterLayout{ReplicasCount: replicas},
+						},
+					},
+				},
+			},
+		}
+	}
+	old := newCHI("task-0", 1)
+	hash := NewActionPlan(old, newCHI("task-1", 2)).Hash()
+	require.NotEmpty(t, hash)
+
+	// Generated task ID does not affect the hash
+	require.Equal(t, hash, NewActionPlan(old, newCHI("task-2", 2)).Hash())
+
+	// Spec change changes the hash
+	require.NotEqual(t, hash, NewActionPlan(old, newCHI("task-1", 3)).Hash())
+	require.NotEqual(t, hash, NewActionPlan(nil, newCHI("task-1", 2)).Hash())
+}
@@ -15,13 +15,82 @@
 package chi
 
 import (
+	"strconv"
+	"strings"
+
 	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com"
 	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
 	"github.com/altinity/clickhouse-operator/pkg/chop"
 	"github.com/altinity/clickhouse-operator/pkg/util"
 )
 
+// Set of kubernetes annotations used by the operator
+const (
+	// AnnotationCheckSchema requests schema consistency check of CHI clusters.
+	// Check is performed each time value of the annotation changes.
+	AnnotationCheckSchema = clickhouse_altinity_com.APIGroupName + "/" + "check-schema"
+	// AnnotationDropDepartedReplicas requests ZooKeeper cleanup of replicas of the departed CHI, specified by name,
+	// which shares ZooKeeper with the annotated CHI. Cleanup is performed each time value of the annotation changes.
+	AnnotationDropDepartedReplicas = clickhouse_altinity_com.APIGroupName + "/" + "drop-departed-replicas"
+	// AnnotationApprovePlan approves ActionPlan, specified by hash, to be executed by reconcile.
+	// Used in case CHI requires plan approval. Hash of the pending plan is published in CHI status.
+	AnnotationApprovePlan = clickhouse_altinity_com.APIGroupName + "/" + "approve-plan"
+	// AnnotationAdopt requests adoption of pre-existing objects, which are not managed by the operator,
+	// ex.: manually deployed StatefulSets, Services and ConfigMaps of the same names.
+	AnnotationAdopt = clickhouse_altinity_com.APIGroupName + "/" + "adopt"
+	// AnnotationAdopted marks StatefulSet adopted by the operator. Immutable fields of the adopted StatefulSet are kept.
+	AnnotationAdopted = clickhouse_altinity_com.APIGroupName + "/" + "adopted"
+	// AnnotationServerUUID carries UUID of ClickHouse server running on the host.
+	// Stamped by the operator onto StatefulSet and Pod of the host.
+	AnnotationServerUUID = clickhouse_altinity_com.APIGroupName + "/" + "server-uuid"
+	// AnnotationReconcileGeneration carries generation of the CHI, which reconcile last touched the Pod.
+	// Stamped by the operator onto Pod template of StatefulSet of the host.
+	AnnotationReconcileGeneration = clickhouse_altinity_com.APIGroupName + "/" + "reconcile-generation"
+	// AnnotationInterserverCredentialsRevision carries revision of interserver credentials the Pod runs with.
+	// Stamped by the operator onto Pod template of StatefulSet of the host, so hosts are rolled on rotation.
+	AnnotationInterserverCredentialsRevision = clickhouse_altinity_com.APIGroupName + "/" + "interserver-credentials-revision"
+	// AnnotationWeight carries weight of the host for external load balancers.
+	// Stamped by the operator onto Pod and Service of the host.
+	AnnotationWeight = clickhouse_altinity_com.APIGroupName + "/" + "weight"
+	// AnnotationReconcile controls reconcile of the CHI. Being set to AnnotationReconcilePaused,
+	// makes the operator skip reconcile of the CHI, keeping finalizer and monitoring in place.
+	// Reconcile resumes as soon as the annotation is removed.
+	AnnotationReconcile       = clickhouse_altinity_com.APIGroupName + "/" + "reconcile"
+	AnnotationReconcilePaused = "paused"
+	// AnnotationMetricsQueries specifies YAML list of custom SQL queries the metrics exporter runs against
+	// each host of the CHI besides the ones specified in the operator config. Query of the same name overrides
+	// the one of the operator config.
+	AnnotationMetricsQueries = clickhouse_altinity_com.APIGroupName + "/" + "metrics-queries"
+	// AnnotationMetricsScrapeInterval specifies interval, ex.: "2m", hosts of the CHI are scraped with
+	// by the metrics exporter, in case background scraping is enabled in the operator config.
+	AnnotationMetricsScrapeInterval = clickhouse_altinity_com.APIGroupName + "/" + "metrics-scrape-interval"
+
+	// External-dns annotations, specifying DNS record of the CHI entry point
+	AnnotationExternalDNSHostname = "external-dns.alpha.kubernetes.io/hostname"
+	AnnotationExternalDNSTTL      = "external-dns.alpha.kubernetes.io/ttl"
+)
+
+// annotationsPredefined specifies annotations, which are not propagated from CHI to its artifacts
+var annotationsPredefined = append(
+	[]string{
+		AnnotationCheckSchema,
+		AnnotationDropDepartedReplicas,
+		AnnotationApprovePlan,
+		AnnotationAdopt,
+		AnnotationAdopted,
+		AnnotationServerUUID,
+		AnnotationReconcileGeneration,
+		AnnotationWeight,
+		AnnotationReconcile,
+		AnnotationMetricsQueries,
+		AnnotationMetricsScrapeInterval,
+	},
+	util.AnnotationsTobeSkipped...,
+)
+
 // Annotator is an entity which can annotate CHI artifacts
 type Annotator struct {
 	chi *api.ClickHouseInstallation
@@ -66,6 +135,21 @@ func (a *Annotator) GetServiceCHI(chi *api.ClickHouseInstallation) map[string]st
 	)
 }
 
+// GetServiceCHIExternalDNS gets external-dns annotations of the CHI entry point Service, as specified by .spec.dns
+func (a *Annotator) GetServiceCHIExternalDNS() map[string]string {
+	dns := a.chi.Spec.DNS
+	if !dns.HasHostname() {
+		return nil
+	}
+	annotations := map[string]string{
+		AnnotationExternalDNSHostname: Macro(a.chi).Line(dns.GetHostname()),
+	}
+	if dns.HasTTL() {
+		annotations[AnnotationExternalDNSTTL] = strconv.Itoa(int(dns.GetTTL()))
+	}
+	return annotations
+}
+
 // GetServiceCluster
 func (a *Annotator) GetServiceCluster(cluster *api.Cluster) map[string]string {
 	return util.MergeStringMapsOverwrite(
@@ -86,7 +170,7 @@ func (a *Annotator) GetServiceShard(shard *api.ChiShard) map[string]string {
 func (a *Annotator) GetServiceHost(host *api.ChiHost) map[string]string {
 	return util.MergeStringMapsOverwrite(
 		a.GetHostScope(host),
-		nil,
+		getHostWeight(host),
 	)
 }
 
@@ -96,6 +180,11 @@ func (a *Annotator) getCHIScope() map[string]string {
 	return a.filterOutPredefined(a.appendCHIProvidedTo(nil))
 }
 
+// GetPDBShard gets annotations for PodDisruptionBudget of the shard
+func (a *Annotator) GetPDBShard(shard *api.ChiShard) map[string]string {
+	return a.getShardScope(shard)
+}
+
 // GetClusterScope gets annotations for Cluster-scoped object
 func (a *Annotator) GetClusterScope(cluster *api.Cluster) map[string]string {
 	// Combine generated annotations and CHI-provided annotations
@@ -115,7 +204,7 @@ func (a *Annotator) GetHostScope(host *api.ChiHost) map[string]string {
 
 // filterOutPredefined filters out predefined values
 func (a *Annotator) filterOutPredefined(m map[string]string) map[string]string {
-	return util.CopyMapFilter(m, nil, util.AnnotationsTobeSkipped)
+	return util.CopyMapFilter(m, nil, annotationsPredefined)
 }
 
 // appendCHIProvidedTo appends CHI-provided annotations to specified annotations
@@ -138,3 +227,65 @@ func (a *Annotator) GetPVC(
 	annotations := util.MergeStringMapsOverwrite(pvc.Annotations, template.ObjectMeta.Annotations)
 	return util.MergeStringMapsOverwrite(annotations, a.GetHostScope(host))
 }
+
+// getHostWeight gets "weight" annotation of the host, in case weight is specified
+func getHostWeight(host *api.ChiHost) map[string]string {
+	if !host.HasWeight() {
+		return nil
+	}
+	return map[string]string{
+		AnnotationWeight: strconv.Itoa(host.GetWeight()),
+	}
+}
+
+// AnnotateWeight sets "weight" annotation of ObjectMeta.Annotations according to the weight of the host.
+// Annotation is removed in case host has no weight specified.
+// Returns true in case annotation was modified.
+func AnnotateWeight(meta *meta.ObjectMeta, host *api.ChiHost) bool {
+	if meta == nil {
+		// Nowhere to add to, not modified
+		return false
+	}
+	value, ok := meta.Annotations[AnnotationWeight]
+	if !host.HasWeight() {
+		if !ok {
+			// Nothing to delete
+			return false
+		}
+		delete(meta.Annotations, AnnotationWeight)
+		return true
+	}
+	if ok && (value == strconv.Itoa(host.GetWeight())) {
+		// Already in place
+		return false
+	}
+	// Need to set
+	meta.Annotations = util.MergeStringMapsOverwrite(meta.Annotations, getHostWeight(host))
+	return true
+}
+
+// AnnotateServerUUID sets "server-uuid" annotation of ObjectMeta.Annotations to the specified value.
+// Returns true in case annotation was modified.
+func AnnotateServerUUID(meta *meta.ObjectMeta, uuid string) bool {
+	if meta == nil {
+		// Nowhere to add to, not modified
+		return false
+	}
+	if value, ok := meta.Annotations[AnnotationServerUUID]; ok && (value == uuid) {
+		// Already in place
+		return false
+	}
+	// Need to set
+	meta.Annotations = util.MergeStringMapsOverwrite(meta.Annotations, map[string]string{
+		AnnotationServerUUID: uuid,
+	})
+	return true
+}
+
+// IsReconcilePaused checks whether reconcile of the CHI is paused via annotation
+func IsReconcilePaused(chi *api.ClickHouseInstallation) bool {
+	if chi == nil {
+		return false
+	}
+	return strings.ToLower(chi.GetAnnotations()[AnnotationReconcile]) == AnnotationReconcilePaused
+}
@@ -0,0 +1,225 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Operations of clickhouse-backup
+const (
+	OperationCreate        = "create"
+	OperationUpload        = "upload"
+	OperationRestore       = "restore"
+	OperationRestoreRemote = "restore_remote"
+)
+
+// Locations of backups
+const (
+	LocationLocal  = "local"
+	LocationRemote = "remote"
+)
+
+// Statuses of clickhouse-backup actions
+const (
+	ActionStatusInProgress = "in progress"
+	ActionStatusSuccess    = "success"
+	ActionStatusError      = "error"
+)
+
+const requestTimeout = 30 * time.Second
+
+// Action describes operation run by clickhouse-backup, as reported by /backup/actions
+type Action struct {
+	Command string `json:"command"`
+	Status  string `json:"status"`
+	Start   string `json:"start,omitempty"`
+	Finish  string `json:"finish,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// IsOf checks whether action is the specified operation on the specified backup.
+// Command consists of the operation, optional flags and the backup name, such as "create --tables=db.* name".
+func (a *Action) IsOf(operation, backup string) bool {
+	fields := strings.Fields(a.Command)
+	return (len(fields) > 1) && (fields[0] == operation) && (fields[len(fields)-1] == backup)
+}
+
+// Backup describes backup, as reported by /backup/list
+type Backup struct {
+	Name     string `json:"name"`
+	Created  string `json:"created"`
+	Location string `json:"location"`
+}
+
+// Client is a client of clickhouse-backup REST API
+type Client struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewClient creates new client of clickhouse-backup REST API running on the host
+func NewClient(host string, port int32) *Client {
+	return &Client{
+		endpoint: fmt.Sprintf("http://%s:%d", host, port),
+		client: &http.Client{
+			Timeout: requestTimeout,
+		},
+	}
+}
+
+// Create starts creation of the local backup of the tables matching the pattern
+func (c *Client) Create(ctx context.Context, name, tables string) error {
+	params := url.Values{}
+	params.Set("name", name)
+	if tables != "" {
+		params.Set("table", tables)
+	}
+	return c.post(ctx, "/backup/create?"+params.Encode())
+}
+
+// Upload starts upload of the local backup to the remote storage
+func (c *Client) Upload(ctx context.Context, name string) error {
+	return c.post(ctx, "/backup/upload/"+url.PathEscape(name))
+}
+
+// Restore starts restore of the tables matching the pattern from the backup.
+// Operation is either restore of the local backup or download and restore of the remote one.
+// Existing tables are dropped before restore in case requested.
+func (c *Client) Restore(ctx context.Context, operation, name, tables string, dropExisting bool) error {
+	params := url.Values{}
+	if tables != "" {
+		params.Set("table", tables)
+	}
+	if dropExisting {
+		params.Set("rm", "true")
+	}
+	return c.post(ctx, "/backup/"+operation+"/"+url.PathEscape(name)+"?"+params.Encode())
+}
+
+// Delete deletes backup from the location
+func (c *Client) Delete(ctx context.Context, location, name string) error {
+	return c.post(ctx, "/backup/delete/"+location+"/"+url.PathEscape(name))
+}
+
+// GetAction gets the latest action of the operation on the backup.
+// Returns nil in case no such action is known to clickhouse-backup.
+func (c *Client) GetAction(ctx context.Context, operation, backup string) (*Action, error) {
+	var actions []*Action
+	if err := c.get(ctx, "/backup/actions", &actions); err != nil {
+		return nil, err
+	}
+	var result *Action
+	for _, action := range actions {
+		if action.IsOf(operation, backup) {
+			result = action
+		}
+	}
+	return result, nil
+}
+
+// List lists backups of the location, ordered from the oldest to the most recent one
+func (c *Client) List(ctx context.Context, location string) ([]*Backup, error) {
+	var backups []*Backup
+	if err := c.get(ctx, "/backup/list/"+location, &backups); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].Created < backups[j].Created
+	})
+	return backups, nil
+}
+
+// post runs request, which starts asynchronous operation
+func (c *Client) post(ctx context.Context, path string) error {
+	_, err := c.do(ctx, http.MethodPost, path)
+	return err
+}
+
+// get runs request and decodes rows of the response
+func (c *Client) get(ctx context.Context, path string, rows interface{}) error {
+	body, err := c.do(ctx, http.MethodGet, path)
+	if err != nil {
+		return err
+	}
+	return decodeRows(body, rows)
+}
+
+// do runs request and returns body of the response
+func (c *Client) do(ctx context.Context, method, path string) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, method, c.endpoint+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s failed with %s: %s", method, path, response.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// decodeRows decodes rows into the slice. clickhouse-backup reports rows either as a JSON array
+// or as a stream of JSON objects, one per line, depending on its version, so both forms are accepted.
+func decodeRows(body []byte, rows interface{}) error {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil
+	}
+	if body[0] == '[' {
+		return json.Unmarshal(body, rows)
+	}
+
+	var items []json.RawMessage
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	for decoder.More() {
+		var item json.RawMessage
+		if err := decoder.Decode(&item); err != nil {
+			return err
+		}
+		items = append(items, item)
+	}
+	array, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(array, rows)
+}
+
+// SelectExpired selects backups exceeding the number of backups to keep, the oldest ones go first.
+// Backups are expected to be ordered from the oldest to the most recent one.
+func SelectExpired(backups []*Backup, keep int) []*Backup {
+	if (keep <= 0) || (len(backups) <= keep) {
+		return nil
+	}
+	return backups[:len(backups)-keep]
+}
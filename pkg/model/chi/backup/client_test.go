@@ -0,0 +1,38 @@
+package backup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ActionIsOf(t *testing.T) {
+	action := &Action{Command: "create --tables=db.* chi-cluster-0-daily"}
+	require.True(t, action.IsOf(OperationCreate, "chi-cluster-0-daily"))
+	require.False(t, action.IsOf(OperationUpload, "chi-cluster-0-daily"))
+	require.False(t, action.IsOf(OperationCreate, "chi-cluster-0"))
+	require.False(t, (&Action{Command: "create"}).IsOf(OperationCreate, "create"))
+}
+
+func Test_DecodeRows(t *testing.T) {
+	var rows []*Backup
+	require.NoError(t, decodeRows([]byte(`[{"name":"a"},{"name":"b"}]`), &rows))
+	require.Len(t, rows, 2)
+
+	rows = nil
+	require.NoError(t, decodeRows([]byte("{\"name\":\"a\"}\n{\"name\":\"b\"}\n"), &rows))
+	require.Equal(t, "b", rows[1].Name)
+
+	rows = nil
+	require.NoError(t, decodeRows([]byte("\n"), &rows))
+	require.Empty(t, rows)
+	require.Error(t, decodeRows([]byte("{"), &rows))
+}
+
+func Test_SelectExpired(t *testing.T) {
+	backups := []*Backup{{Name: "1"}, {Name: "2"}, {Name: "3"}}
+	require.Equal(t, backups[:1], SelectExpired(backups, 2))
+	require.Empty(t, SelectExpired(backups, 3))
+	// Zero keeps all backups
+	require.Empty(t, SelectExpired(backups, 0))
+}
@@ -23,10 +23,13 @@ const (
 const (
 	configMacros        = "macros"
 	configHostnamePorts = "hostname-ports"
+	configInterserver   = "interserver"
+	configOpenSSL       = "openssl"
 	configProfiles      = "profiles"
 	configQuotas        = "quotas"
 	configRemoteServers = "remote_servers"
 	configSettings      = "settings"
+	configStorage       = "storage"
 	configUsers         = "users"
 	configZookeeper     = "zookeeper"
 )
@@ -58,17 +61,37 @@ const (
 	// DirPathSecretFilesConfig specifies full path to folder, where secrets are mounted
 	DirPathSecretFilesConfig = "/etc/clickhouse-server/secrets.d/"
 
+	// DirPathCertificates specifies full path to folder, where Secret with certificate issued by cert-manager is mounted
+	DirPathCertificates = "/etc/clickhouse-server/tls/"
+
 	// DirPathClickHouseData specifies full path of data folder where ClickHouse would place its data storage
 	DirPathClickHouseData = "/var/lib/clickhouse"
 
 	// DirPathClickHouseLog  specifies full path of data folder where ClickHouse would place its log files
 	DirPathClickHouseLog = "/var/log/clickhouse-server"
 
+	// DirPathClickHouseDisks specifies full path of folder where PVCs of additional storage disks are mounted,
+	// each disk into its own sub-folder
+	DirPathClickHouseDisks = "/var/lib/clickhouse-disks"
+
+	// DirPathClickHouseTmp specifies full path of folder where ClickHouse would place temporary data of queries
+	DirPathClickHouseTmp = "/var/lib/clickhouse/tmp"
+
+	// DirPathClickHouseCache specifies full path of folder where ClickHouse would place filesystem cache
+	DirPathClickHouseCache = "/var/lib/clickhouse/caches"
+
 	// DirPathDockerEntrypointInit specified full path of docker-entrypoint-initdb.d
 	// For more details please check: https://github.com/ClickHouse/ClickHouse/issues/3319
 	DirPathDockerEntrypointInit = "/docker-entrypoint-initdb.d"
 )
 
+const (
+	// Files of the Secret with certificate issued by cert-manager
+	CertificateFile = "tls.crt"
+	PrivateKeyFile  = "tls.key"
+	CAFile          = "ca.crt"
+)
+
 const (
 	// DefaultClickHouseDockerImage specifies default ClickHouse docker image to be used
 	DefaultClickHouseDockerImage = "clickhouse/clickhouse-server:latest"
@@ -86,6 +109,9 @@ const (
 	ClickHouseContainerName = "clickhouse"
 	// ClickHouseLogContainerName specifies name of the logger container in the pod
 	ClickHouseLogContainerName = "clickhouse-log"
+
+	// StorageDiskDefault specifies name of the built-in ClickHouse disk, located in DirPathClickHouseData
+	StorageDiskDefault = "default"
 )
 
 const (
@@ -100,6 +126,10 @@ const (
 	ChDefaultHTTPSPortNumber           = int32(8443)
 	ChDefaultInterserverHTTPPortName   = "interserver"
 	ChDefaultInterserverHTTPPortNumber = int32(9009)
+	ChDefaultGRPCPortName              = "grpc"
+	ChDefaultGRPCPortNumber            = int32(9100)
+	ChDefaultPrometheusPortName        = "prometheus"
+	ChDefaultPrometheusPortNumber      = int32(9363)
 )
 
 const (
@@ -108,3 +138,46 @@ const (
 	// ZkDefaultRootTemplate specifies default ZK root - /clickhouse/{namespace}/{chi name}
 	ZkDefaultRootTemplate = "/clickhouse/%s/%s"
 )
+
+const (
+	// ManagedUserSecretPasswordKey specifies key of the Secret where password of the operator-managed user is kept
+	ManagedUserSecretPasswordKey = "password"
+)
+
+const (
+	// Slots of interserver credentials
+	InterserverCredentialsSlot0 = "0"
+	InterserverCredentialsSlot1 = "1"
+	// Prefixes of the keys of the Secret where interserver credentials are kept, followed by slot
+	InterserverCredentialsUsernameKeyPrefix = "username-"
+	InterserverCredentialsPasswordKeyPrefix = "password-"
+	// InterserverCredentialsActiveKey keeps slot of credentials hosts authenticate with
+	InterserverCredentialsActiveKey = "active"
+	// InterserverCredentialsPhaseKey keeps phase of rotation in progress
+	InterserverCredentialsPhaseKey = "phase"
+	// InterserverCredentialsRotationKey keeps rotation token credentials are rotated with
+	InterserverCredentialsRotationKey = "rotation"
+	// InterserverCredentialsRevisionKey keeps revision of credentials, each rotation makes new revision
+	InterserverCredentialsRevisionKey = "revision"
+)
+
+// Phases of interserver credentials rotation
+const (
+	// InterserverCredentialsPhaseInitial - credentials are distributed to the hosts for the first time,
+	// hosts accept requests without credentials until all hosts are rolled
+	InterserverCredentialsPhaseInitial = "Initial"
+	// InterserverCredentialsPhaseAdding - new credentials are distributed to the hosts alongside current ones,
+	// hosts authenticate with current credentials and accept both until all hosts are rolled
+	InterserverCredentialsPhaseAdding = "Adding"
+	// InterserverCredentialsPhaseSwitching - hosts authenticate with new credentials and accept both
+	// until config is propagated to all hosts
+	InterserverCredentialsPhaseSwitching = "Switching"
+)
+
+// InterserverCredentialsOtherSlot gets slot, which is not the specified one
+func InterserverCredentialsOtherSlot(slot string) string {
+	if slot == InterserverCredentialsSlot1 {
+		return InterserverCredentialsSlot0
+	}
+	return InterserverCredentialsSlot1
+}
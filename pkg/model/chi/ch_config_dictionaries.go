@@ -0,0 +1,47 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"regexp"
+
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
+// dictionariesConfigRegexp matches config files which define external dictionaries or specify where to look for them,
+// both XML and YAML ones
+var dictionariesConfigRegexp = regexp.MustCompile(`<(dictionary|dictionaries_config)[\s>]|(?m)^\s*(dictionary|dictionaries_config)\s*:`)
+
+// isDictionariesConfigFile checks whether config file content deals with external dictionaries
+func isDictionariesConfigFile(content string) bool {
+	return dictionariesConfigRegexp.MatchString(content)
+}
+
+// getDictionariesConfigFiles extracts config files dealing with external dictionaries
+func getDictionariesConfigFiles(files map[string]string) map[string]string {
+	res := make(map[string]string)
+	for filename, content := range files {
+		if isDictionariesConfigFile(content) {
+			res[filename] = content
+		}
+	}
+	return res
+}
+
+// IsDictionariesConfigChanged checks whether external dictionaries config differs between two sets of config files,
+// specified as filename->content, as kept in ConfigMap. Changes of config files unrelated to dictionaries are ignored.
+func IsDictionariesConfigChanged(cur, desired map[string]string) bool {
+	return !util.MapsAreTheSame(getDictionariesConfigFiles(cur), getDictionariesConfigFiles(desired))
+}
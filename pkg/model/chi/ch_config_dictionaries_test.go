@@ -0,0 +1,82 @@
+package chi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testDictionaryXML = `<clickhouse>
+    <dictionary>
+        <name>countries</name>
+        <source><http><url>http://dictionaries/countries.tsv</url><format>TSV</format></http></source>
+        <lifetime>300</lifetime>
+    </dictionary>
+</clickhouse>
+`
+
+func Test_IsDictionariesConfigChanged(t *testing.T) {
+	cur := map[string]string{
+		"chop-generated-remote_servers.xml": "<clickhouse><remote_servers/></clickhouse>",
+		"chop-generated-settings.xml":       "<clickhouse><max_concurrent_queries>200</max_concurrent_queries></clickhouse>",
+		"countries_dictionary.xml":          testDictionaryXML,
+	}
+
+	tests := []struct {
+		name    string
+		desired func(map[string]string)
+		want    bool
+	}{
+		{
+			name:    "same",
+			desired: func(m map[string]string) {},
+			want:    false,
+		},
+		{
+			name: "unrelated settings change",
+			desired: func(m map[string]string) {
+				m["chop-generated-settings.xml"] = "<clickhouse><max_concurrent_queries>300</max_concurrent_queries></clickhouse>"
+				m["chop-generated-remote_servers.xml"] = "<clickhouse><remote_servers><all/></remote_servers></clickhouse>"
+			},
+			want: false,
+		},
+		{
+			name: "dictionary changed",
+			desired: func(m map[string]string) {
+				m["countries_dictionary.xml"] = testDictionaryXML + "<!-- lifetime -->"
+			},
+			want: true,
+		},
+		{
+			name: "dictionary added",
+			desired: func(m map[string]string) {
+				m["cities_dictionary.yaml"] = "dictionary:\n    name: cities\n"
+			},
+			want: true,
+		},
+		{
+			name: "dictionary removed",
+			desired: func(m map[string]string) {
+				delete(m, "countries_dictionary.xml")
+			},
+			want: true,
+		},
+		{
+			name: "dictionaries config path changed",
+			desired: func(m map[string]string) {
+				m["chop-generated-settings.xml"] = "<clickhouse><dictionaries_config>config.d/*_dictionary.xml</dictionaries_config></clickhouse>"
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desired := make(map[string]string)
+			for filename, content := range cur {
+				desired[filename] = content
+			}
+			tt.desired(desired)
+			require.Equal(t, tt.want, IsDictionariesConfigChanged(cur, desired))
+		})
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+// ValidateConfigFile checks whether content of the config file can be parsed according to the format of the file.
+// Files of formats other than XML and YAML are not checked.
+func ValidateConfigFile(filename, content string) error {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".xml":
+		return validateConfigFileXML(content)
+	case ".yaml", ".yml":
+		return validateConfigFileYAML(content)
+	}
+	return nil
+}
+
+// validateConfigFileXML checks whether content is a well-formed XML document
+func validateConfigFileXML(content string) error {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	hasRoot := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if _, ok := token.(xml.StartElement); ok {
+			hasRoot = true
+		}
+	}
+	if !hasRoot {
+		return fmt.Errorf("no root element found")
+	}
+	return nil
+}
+
+// validateConfigFileYAML checks whether content is a valid YAML document
+func validateConfigFileYAML(content string) error {
+	var document any
+	return yaml.Unmarshal([]byte(content), &document)
+}
+
+// validateConfigFiles checks config files of all sections
+func validateConfigFiles(files *api.Settings) error {
+	for _, section := range []api.SettingsSection{api.SectionCommon, api.SectionUsers, api.SectionHost} {
+		contents := files.GetSection(section, true)
+		var filenames []string
+		for filename := range contents {
+			filenames = append(filenames, filename)
+		}
+		sort.Strings(filenames)
+		for _, filename := range filenames {
+			if err := ValidateConfigFile(filename, contents[filename]); err != nil {
+				return fmt.Errorf("config file %s can not be parsed: %v", filename, err)
+			}
+		}
+	}
+	return nil
+}
+
+// CHICheckConfigFiles checks whether config files specified in the CHI, on all levels, can be parsed.
+// Files are rendered into ClickHouse config verbatim, so broken file would prevent ClickHouse from loading config.
+func CHICheckConfigFiles(chi *api.ClickHouseInstallation) error {
+	if chi.Spec.Configuration != nil {
+		if err := validateConfigFiles(chi.Spec.Configuration.Files); err != nil {
+			return err
+		}
+	}
+	var err error
+	chi.WalkHosts(func(host *api.ChiHost) error {
+		if err != nil {
+			return nil
+		}
+		if e := validateConfigFiles(host.Files); e != nil {
+			err = fmt.Errorf("host %s: %v", host.GetName(), e)
+		}
+		return nil
+	})
+	return err
+}
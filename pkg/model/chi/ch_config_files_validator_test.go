@@ -0,0 +1,93 @@
+package chi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+const (
+	testConfigFileXML = `<clickhouse>
+    <!-- kept as is -->
+    <max_concurrent_queries>200</max_concurrent_queries>
+</clickhouse>
+`
+	testConfigFileYAML = `max_server_memory_usage_to_ram_ratio: 0.8
+logger:
+    level: debug
+`
+)
+
+func newConfigFilesTestCHI(files map[string]string) *api.ClickHouseInstallation {
+	chi := &api.ClickHouseInstallation{
+		Spec: api.ChiSpec{
+			Configuration: &api.Configuration{
+				Files: api.NewSettings(),
+			},
+		},
+	}
+	for filename, content := range files {
+		chi.Spec.Configuration.Files.Set(filename, api.NewSettingScalar(content))
+	}
+	return chi
+}
+
+func Test_ConfigFilesAreRenderedVerbatim(t *testing.T) {
+	chi := newConfigFilesTestCHI(map[string]string{
+		"config.d/custom.xml":  testConfigFileXML,
+		"config.d/custom.yaml": testConfigFileYAML,
+		"users.d/custom.xml":   testConfigFileXML,
+	})
+	generator := NewClickHouseConfigGenerator(chi)
+
+	require.Equal(t, map[string]string{
+		"custom.xml":  testConfigFileXML,
+		"custom.yaml": testConfigFileYAML,
+	}, generator.GetSectionFromFiles(api.SectionCommon, false, nil))
+	require.Equal(t, map[string]string{
+		"custom.xml": testConfigFileXML,
+	}, generator.GetSectionFromFiles(api.SectionUsers, false, nil))
+
+	require.NoError(t, CHICheckConfigFiles(chi))
+}
+
+func Test_ValidateConfigFile(t *testing.T) {
+	tests := []struct {
+		filename string
+		content  string
+		valid    bool
+	}{
+		{"custom.xml", testConfigFileXML, true},
+		{"custom.XML", testConfigFileXML, true},
+		{"custom.xml", "<clickhouse><max_concurrent_queries>200</clickhouse>", false},
+		{"custom.xml", "<clickhouse>", false},
+		{"custom.xml", "max_concurrent_queries: 200", false},
+		{"custom.yaml", testConfigFileYAML, true},
+		{"custom.yml", "logger:\n  level: [debug\n", false},
+		{"custom.yaml", "logger:\n\tlevel: debug\n", false},
+		// Format is unknown - nothing to check
+		{"dictionary.tsv", "<clickhouse>", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filename+":"+tt.content, func(t *testing.T) {
+			err := ValidateConfigFile(tt.filename, tt.content)
+			if tt.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func Test_CHICheckConfigFiles_RejectsUnparsable(t *testing.T) {
+	chi := newConfigFilesTestCHI(map[string]string{
+		"config.d/good.xml":   testConfigFileXML,
+		"config.d/broken.xml": "<clickhouse><logger></clickhouse>",
+	})
+	err := CHICheckConfigFiles(chi)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "broken.xml")
+}
@@ -66,14 +66,80 @@ func (c *ClickHouseConfigGenerator) GetQuotas() string {
 
 // GetSettingsGlobal creates data for "settings.xml"
 func (c *ClickHouseConfigGenerator) GetSettingsGlobal() string {
-	// No host specified means request to generate common config
-	return c.generateXMLConfig(c.chi.Spec.Configuration.Settings, "")
+	// No host specified means request to generate common config.
+	// Global settings overridden by hosts are moved into host configs, see GetSettings
+	overridden := c.getSettingsOverriddenByHosts()
+	settings := api.NewSettings().MergeFromCB(c.chi.Spec.Configuration.Settings, func(name string, _ *api.Setting) bool {
+		return !overridden[name]
+	})
+	return c.generateXMLConfig(settings, "")
+}
+
+// GetInterserverCredentials creates data for "interserver.xml".
+// Credentials are passed via ENV vars, so they are not exposed in ConfigMap
+func (c *ClickHouseConfigGenerator) GetInterserverCredentials() string {
+	state := c.chi.EnsureRuntime().GetInterserverCredentials()
+	if state == nil {
+		return ""
+	}
+
+	b := &bytes.Buffer{}
+
+	// <yandex>
+	//     <interserver_http_credentials>
+	util.Iline(b, 0, "<"+xmlTagYandex+">")
+	util.Iline(b, 4, "<interserver_http_credentials>")
+	util.Iline(b, 8, "<user from_env=\"%s\"/>", InterserverUsernameEnvNamePrefix+state.Active)
+	util.Iline(b, 8, "<password from_env=\"%s\"/>", InterserverPasswordEnvNamePrefix+state.Active)
+	if state.Accepted != "" {
+		// Credentials accepted in addition to the active ones
+		util.Iline(b, 8, "<old>")
+		util.Iline(b, 8, "    <user from_env=\"%s\"/>", InterserverUsernameEnvNamePrefix+state.Accepted)
+		util.Iline(b, 8, "    <password from_env=\"%s\"/>", InterserverPasswordEnvNamePrefix+state.Accepted)
+		util.Iline(b, 8, "</old>")
+	}
+	if state.AllowEmpty {
+		util.Iline(b, 8, "<allow_empty>true</allow_empty>")
+	}
+	//     </interserver_http_credentials>
+	// </yandex>
+	util.Iline(b, 4, "</interserver_http_credentials>")
+	util.Iline(b, 0, "</"+xmlTagYandex+">")
+
+	return b.String()
 }
 
 // GetSettings creates data for "settings.xml"
 func (c *ClickHouseConfigGenerator) GetSettings(host *api.ChiHost) string {
-	// Generate config for the specified host
-	return c.generateXMLConfig(host.Settings, "")
+	// Generate config for the specified host.
+	// Host config is merged by ClickHouse before the common one, thus global settings overridden by any host
+	// are not included into common config, but are resolved into host configs, where host settings take precedence.
+	overridden := c.getSettingsOverriddenByHosts()
+	settings := api.NewSettings().MergeFrom(host.Settings).MergeFrom(
+		api.NewSettings().MergeFromCB(c.chi.Spec.Configuration.Settings, func(name string, _ *api.Setting) bool {
+			return overridden[name]
+		}),
+	)
+	return c.generateXMLConfig(settings, "")
+}
+
+// getSettingsOverriddenByHosts gets names of global settings, which are overridden by settings of at least one host,
+// either explicitly or inherited from cluster, shard or replica
+func (c *ClickHouseConfigGenerator) getSettingsOverriddenByHosts() map[string]bool {
+	overridden := make(map[string]bool)
+	global := c.chi.Spec.Configuration.Settings
+	if global.Len() == 0 {
+		return overridden
+	}
+	c.chi.WalkHosts(func(host *api.ChiHost) error {
+		host.Settings.Walk(func(name string, _ *api.Setting) {
+			if global.Has(name) {
+				overridden[name] = true
+			}
+		})
+		return nil
+	})
+	return overridden
 }
 
 // GetSectionFromFiles creates data for custom common config files
@@ -213,6 +279,11 @@ func (o *RemoteServersGeneratorOptions) Exclude(host *api.ChiHost) bool {
 		return false
 	}
 
+	if IsHostCordoned(host) {
+		// Shard of the host is in maintenance
+		return true
+	}
+
 	if o.exclude.attributes.Any(host.GetReconcileAttributes()) {
 		// Reconcile attributes specify to exclude this host
 		return true
@@ -234,6 +305,11 @@ func (o *RemoteServersGeneratorOptions) Include(host *api.ChiHost) bool {
 		return false
 	}
 
+	if IsHostCordoned(host) {
+		// Shard of the host is in maintenance
+		return false
+	}
+
 	if o.exclude.attributes.Any(host.GetReconcileAttributes()) {
 		// Reconcile attributes specify to exclude this host
 		return false
@@ -426,8 +502,13 @@ func (c *ClickHouseConfigGenerator) GetRemoteServers(options *RemoteServersGener
 			if options.Include(host) {
 				// <shard>
 				//     <internal_replication>
+				//     <weight>X</weight>
 				util.Iline(b, 12, "<shard>")
 				util.Iline(b, 12, "    <internal_replication>false</internal_replication>")
+				if host.HasWeight() {
+					// Each host is a shard of its own, so host weight is the weight of the shard
+					util.Iline(b, 12, "    <weight>%d</weight>", host.GetWeight())
+				}
 
 				c.getRemoteServersReplica(host, b)
 
@@ -448,6 +529,74 @@ func (c *ClickHouseConfigGenerator) GetRemoteServers(options *RemoteServersGener
 	return b.String()
 }
 
+// GetStorageConfiguration creates "storage.xml" content with additional disks and storage policies
+func (c *ClickHouseConfigGenerator) GetStorageConfiguration() string {
+	storage := c.chi.Spec.Configuration.Storage
+	if storage.IsEmpty() {
+		return ""
+	}
+
+	b := &bytes.Buffer{}
+
+	// <yandex>
+	//     <storage_configuration>
+	util.Iline(b, 0, "<"+xmlTagYandex+">")
+	util.Iline(b, 0, "    <storage_configuration>")
+
+	if disks := storage.GetDisks(); len(disks) > 0 {
+		// <disks>
+		//     <cold>
+		//         <path>/var/lib/clickhouse-disks/cold/</path>
+		//     </cold>
+		// </disks>
+		util.Iline(b, 8, "<disks>")
+		for _, disk := range disks {
+			util.Iline(b, 12, "<%s>", disk.Name)
+			util.Iline(b, 12, "    <path>%s/</path>", CreateStorageDiskPath(disk))
+			util.Iline(b, 12, "</%s>", disk.Name)
+		}
+		util.Iline(b, 8, "</disks>")
+	}
+
+	if policies := storage.GetPolicies(); len(policies) > 0 {
+		// <policies>
+		//     <tiered>
+		//         <volumes>
+		//             <hot>
+		//                 <disk>default</disk>
+		//             </hot>
+		//         </volumes>
+		//         <move_factor>0.2</move_factor>
+		//     </tiered>
+		// </policies>
+		util.Iline(b, 8, "<policies>")
+		for _, policy := range policies {
+			util.Iline(b, 12, "<%s>", policy.Name)
+			util.Iline(b, 12, "    <volumes>")
+			for _, volume := range policy.Volumes {
+				util.Iline(b, 20, "<%s>", volume.Name)
+				for _, disk := range volume.Disks {
+					util.Iline(b, 20, "    <disk>%s</disk>", disk)
+				}
+				util.Iline(b, 20, "</%s>", volume.Name)
+			}
+			util.Iline(b, 12, "    </volumes>")
+			if policy.MoveFactor != "" {
+				util.Iline(b, 12, "    <move_factor>%s</move_factor>", policy.MoveFactor)
+			}
+			util.Iline(b, 12, "</%s>", policy.Name)
+		}
+		util.Iline(b, 8, "</policies>")
+	}
+
+	//     </storage_configuration>
+	// </yandex>
+	util.Iline(b, 0, "    </storage_configuration>")
+	util.Iline(b, 0, "</"+xmlTagYandex+">")
+
+	return b.String()
+}
+
 // GetHostMacros creates "macros.xml" content
 func (c *ClickHouseConfigGenerator) GetHostMacros(host *api.ChiHost) string {
 	b := &bytes.Buffer{}
@@ -478,6 +627,24 @@ func (c *ClickHouseConfigGenerator) GetHostMacros(host *api.ChiHost) string {
 	// full deployment id is unique to identify replica within the cluster
 	util.Iline(b, 8, "<replica>%s</replica>", CreatePodHostname(host))
 
+	// Custom macros. Operator-generated macros take precedence in order not to break replication paths
+	builtin := []string{
+		"installation",
+		AllShardsOneReplicaClusterName + "-shard",
+		"cluster",
+		"shard",
+		"replica",
+	}
+	keys, values := util.MapGetSortedKeysAndValues(util.CopyMapExclude(host.GetMacros(), builtin...))
+	for i := range keys {
+		if !xml.IsValidTagName(keys[i]) {
+			// Normalizer drops such macros, do not break config in case one slipped through
+			continue
+		}
+		// <layer>value</layer>
+		util.Iline(b, 8, "<%s>%s</%[1]s>", keys[i], xml.EscapeText(values[i]))
+	}
+
 	// 		</macros>
 	// </yandex>
 	util.Iline(b, 0, "    </macros>")
@@ -508,12 +675,69 @@ func (c *ClickHouseConfigGenerator) GetHostHostnameAndPorts(host *api.ChiHost) s
 	}
 
 	// Interserver host and port
-	util.Iline(b, 4, "<interserver_http_host>%s</interserver_http_host>", c.getRemoteServersReplicaHostname(host))
-	if host.InterserverHTTPPort != ChDefaultInterserverHTTPPortNumber {
+	util.Iline(b, 4, "<interserver_http_host>%s</interserver_http_host>", CreateInterserverHostname(host))
+	if host.GetCHI().GetCertManager().IsInterserverSecure() {
+		// Hosts served with certificates fetch parts over https on the interserver port
+		util.Iline(b, 4, "<interserver_http_port remove=\"1\"/>")
+		util.Iline(b, 4, "<interserver_https_port>%d</interserver_https_port>", host.InterserverHTTPPort)
+	} else if host.InterserverHTTPPort != ChDefaultInterserverHTTPPortNumber {
 		util.Iline(b, 4, "<interserver_http_port>%d</interserver_http_port>", host.InterserverHTTPPort)
 	}
 
+	// gRPC and Prometheus endpoints are opened in case port is declared only
+	if api.IsPortAssigned(host.GRPCPort) {
+		util.Iline(b, 4, "<grpc_port>%d</grpc_port>", host.GRPCPort)
+	}
+	if api.IsPortAssigned(host.PrometheusPort) {
+		util.Iline(b, 4, "<prometheus>")
+		util.Iline(b, 8, "<endpoint>/metrics</endpoint>")
+		util.Iline(b, 8, "<port>%d</port>", host.PrometheusPort)
+		util.Iline(b, 8, "<metrics>true</metrics>")
+		util.Iline(b, 8, "<events>true</events>")
+		util.Iline(b, 8, "<asynchronous_metrics>true</asynchronous_metrics>")
+		util.Iline(b, 4, "</prometheus>")
+	}
+
+	// </yandex>
+	util.Iline(b, 0, "</"+xmlTagYandex+">")
+
+	return b.String()
+}
+
+// GetHostOpenSSL creates "openssl.xml" content, which points ClickHouse to the certificate issued by cert-manager.
+// Certificate is used by the server on secure ports and by the client on connections to other hosts
+func (c *ClickHouseConfigGenerator) GetHostOpenSSL(host *api.ChiHost) string {
+	if !host.GetCHI().GetCertManager().IsEnabled() {
+		return ""
+	}
+
+	b := &bytes.Buffer{}
+
+	// <yandex>
+	//     <openSSL>
+	util.Iline(b, 0, "<"+xmlTagYandex+">")
+	util.Iline(b, 4, "<openSSL>")
+	for _, side := range []string{"server", "client"} {
+		util.Iline(b, 8, "<%s>", side)
+		util.Iline(b, 12, "<certificateFile>%s</certificateFile>", DirPathCertificates+CertificateFile)
+		util.Iline(b, 12, "<privateKeyFile>%s</privateKeyFile>", DirPathCertificates+PrivateKeyFile)
+		util.Iline(b, 12, "<caConfig>%s</caConfig>", DirPathCertificates+CAFile)
+		// Peer certificates are verified in case peer presents one
+		util.Iline(b, 12, "<verificationMode>relaxed</verificationMode>")
+		util.Iline(b, 12, "<loadDefaultCAFile>false</loadDefaultCAFile>")
+		util.Iline(b, 12, "<cacheSessions>true</cacheSessions>")
+		util.Iline(b, 12, "<disableProtocols>sslv2,sslv3,tlsv1,tlsv1_1</disableProtocols>")
+		util.Iline(b, 12, "<preferServerCiphers>true</preferServerCiphers>")
+		if side == "client" {
+			util.Iline(b, 12, "<invalidCertificateHandler>")
+			util.Iline(b, 12, "    <name>RejectCertificateHandler</name>")
+			util.Iline(b, 12, "</invalidCertificateHandler>")
+		}
+		util.Iline(b, 8, "</%s>", side)
+	}
+	//     </openSSL>
 	// </yandex>
+	util.Iline(b, 4, "</openSSL>")
 	util.Iline(b, 0, "</"+xmlTagYandex+">")
 
 	return b.String()
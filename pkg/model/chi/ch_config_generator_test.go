@@ -0,0 +1,366 @@
+package chi
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+func Test_GetHostHostnameAndPorts_GRPCAndPrometheus(t *testing.T) {
+	host := newInterserverTestHost(false, false)
+	host.TCPPort = ChDefaultTCPPortNumber
+	host.TLSPort = ChDefaultTLSPortNumber
+	host.HTTPPort = ChDefaultHTTPPortNumber
+	host.HTTPSPort = ChDefaultHTTPSPortNumber
+	host.InterserverHTTPPort = ChDefaultInterserverHTTPPortNumber
+	generator := NewClickHouseConfigGenerator(host.GetCHI())
+
+	// Optional endpoints are not opened unless declared
+	config := generator.GetHostHostnameAndPorts(host)
+	require.NotContains(t, config, "grpc_port")
+	require.NotContains(t, config, "<prometheus>")
+
+	host.GRPCPort = 9100
+	host.PrometheusPort = 9363
+	require.Equal(t, `<yandex>
+    <interserver_http_host>chi-chi-cluster-0-1</interserver_http_host>
+    <grpc_port>9100</grpc_port>
+    <prometheus>
+        <endpoint>/metrics</endpoint>
+        <port>9363</port>
+        <metrics>true</metrics>
+        <events>true</events>
+        <asynchronous_metrics>true</asynchronous_metrics>
+    </prometheus>
+</yandex>
+`, generator.GetHostHostnameAndPorts(host))
+	require.NoError(t, ValidateConfigFile("ports.xml", generator.GetHostHostnameAndPorts(host)))
+}
+
+func Test_HostWalkAssignedPorts_GRPCAndPrometheus(t *testing.T) {
+	host := newInterserverTestHost(false, false)
+	host.TCPPort = ChDefaultTCPPortNumber
+	host.GRPCPort = 9100
+	host.PrometheusPort = 9363
+
+	ports := map[string]int32{}
+	HostWalkAssignedPorts(host, func(name string, port *int32, protocol core.Protocol) bool {
+		ports[name] = *port
+		return false
+	})
+	require.Equal(t, map[string]int32{
+		ChDefaultTCPPortName:        ChDefaultTCPPortNumber,
+		ChDefaultGRPCPortName:       9100,
+		ChDefaultPrometheusPortName: 9363,
+	}, ports)
+}
+
+func Test_GetHostMacros_Custom(t *testing.T) {
+	host := newInterserverTestHost(false, false)
+	host.Runtime.Address.ShardName = "0"
+	cluster := &api.Cluster{
+		Name: "cluster",
+		Macros: map[string]string{
+			"region":  "eu",
+			"replica": "conflicting-replica",
+			"shard":   "conflicting-shard",
+		},
+	}
+	host.GetCHI().Spec.Configuration = &api.Configuration{
+		Macros: map[string]string{
+			"layer":  "hot",
+			"region": "us",
+		},
+		Clusters: []*api.Cluster{cluster},
+	}
+	// Cluster-level macros take precedence over CHI-level ones
+	cluster.InheritMacrosFrom(host.GetCHI())
+
+	// Custom macros are rendered alongside built-in ones, which can not be overridden
+	require.Equal(t, `<yandex>
+    <macros>
+        <installation>chi</installation>
+        <all-sharded-shard>0</all-sharded-shard>
+        <cluster>cluster</cluster>
+        <shard>0</shard>
+        <replica>chi-chi-cluster-0-1</replica>
+        <layer>hot</layer>
+        <region>eu</region>
+    </macros>
+</yandex>
+`, NewClickHouseConfigGenerator(host.GetCHI()).GetHostMacros(host))
+}
+
+func Test_GetHostMacros_EscapeCustom(t *testing.T) {
+	host := newInterserverTestHost(false, false)
+	host.Runtime.Address.ShardName = "0"
+	cluster := &api.Cluster{
+		Name: "cluster",
+		Macros: map[string]string{
+			"layer":          "</layer><evil>1</evil><layer>",
+			"bad name":       "skipped",
+			"<injected>":     "skipped",
+			"region.zone_id": "a&b",
+		},
+	}
+	host.GetCHI().Spec.Configuration = &api.Configuration{
+		Clusters: []*api.Cluster{cluster},
+	}
+
+	// Values are escaped and macros with names which are not valid XML tags are skipped
+	config := NewClickHouseConfigGenerator(host.GetCHI()).GetHostMacros(host)
+	require.Equal(t, `<yandex>
+    <macros>
+        <installation>chi</installation>
+        <all-sharded-shard>0</all-sharded-shard>
+        <cluster>cluster</cluster>
+        <shard>0</shard>
+        <replica>chi-chi-cluster-0-1</replica>
+        <layer>&lt;/layer&gt;&lt;evil&gt;1&lt;/evil&gt;&lt;layer&gt;</layer>
+        <region.zone_id>a&amp;b</region.zone_id>
+    </macros>
+</yandex>
+`, config)
+	require.NoError(t, ValidateConfigFile("macros.xml", config))
+}
+
+func newMaintenanceTestCHI(shardsCount int) *api.ClickHouseInstallation {
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+		},
+		Spec: api.ChiSpec{
+			Defaults: &api.ChiDefaults{},
+		},
+	}
+	cluster := &api.Cluster{
+		Name:   "cluster",
+		Layout: &api.ChiClusterLayout{},
+	}
+	for i := 0; i < shardsCount; i++ {
+		shardName := strconv.Itoa(i)
+		host := &api.ChiHost{Name: shardName + "-0"}
+		host.Runtime.CHI = chi
+		host.Runtime.Address = api.ChiHostAddress{
+			Namespace:   "ns",
+			CHIName:     "chi",
+			ClusterName: "cluster",
+			ShardName:   shardName,
+			ShardIndex:  i,
+			HostName:    host.Name,
+		}
+		cluster.Layout.Shards = append(cluster.Layout.Shards, api.ChiShard{
+			Name:  shardName,
+			Hosts: []*api.ChiHost{host},
+		})
+	}
+	chi.Spec.Configuration = &api.Configuration{
+		Clusters: []*api.Cluster{cluster},
+	}
+	return chi
+}
+
+func Test_GetRemoteServers_ShardMaintenance(t *testing.T) {
+	chi := newMaintenanceTestCHI(2)
+	generator := NewClickHouseConfigGenerator(chi)
+	shard0 := chi.FindShard("cluster", "0")
+	shard1 := chi.FindShard("cluster", "1")
+	hostname0 := generator.getRemoteServersReplicaHostname(shard0.Hosts[0])
+	hostname1 := generator.getRemoteServersReplicaHostname(shard1.Hosts[0])
+
+	config := generator.GetRemoteServers(nil)
+	require.Contains(t, config, hostname0)
+	require.Contains(t, config, hostname1)
+
+	// Shard in maintenance is removed from remote_servers
+	shard1.Maintenance = api.NewStringBool(true)
+	config = generator.GetRemoteServers(nil)
+	require.Contains(t, config, hostname0)
+	require.NotContains(t, config, hostname1)
+	require.NoError(t, ValidateConfigFile("remote_servers.xml", config))
+
+	// Shard is re-added as soon as maintenance is cleared
+	shard1.Maintenance = api.NewStringBool(false)
+	config = generator.GetRemoteServers(nil)
+	require.Contains(t, config, hostname0)
+	require.Contains(t, config, hostname1)
+
+	// The last serving shard of the cluster can not be cordoned
+	shard0.Maintenance = api.NewStringBool(true)
+	shard1.Maintenance = api.NewStringBool(true)
+	config = generator.GetRemoteServers(nil)
+	require.Contains(t, config, hostname0)
+	require.Contains(t, config, hostname1)
+}
+
+func Test_GetRemoteServers_HostWeight(t *testing.T) {
+	chi := newMaintenanceTestCHI(2)
+	generator := NewClickHouseConfigGenerator(chi)
+	host := chi.FindShard("cluster", "1").Hosts[0]
+
+	config := generator.GetRemoteServers(nil)
+	require.NotContains(t, config, "<weight>")
+
+	// Weight of the host is rendered into shard of the host in all-sharded cluster
+	weight := 3
+	host.Weight = &weight
+	config = generator.GetRemoteServers(nil)
+	require.Equal(t, 1, strings.Count(config, "<weight>3</weight>"))
+	require.NoError(t, ValidateConfigFile("remote_servers.xml", config))
+
+	// Weight change is reflected in the config
+	weight = 5
+	config = generator.GetRemoteServers(nil)
+	require.NotContains(t, config, "<weight>3</weight>")
+	require.Equal(t, 1, strings.Count(config, "<weight>5</weight>"))
+}
+
+func Test_GetStorageConfiguration(t *testing.T) {
+	chi := &api.ClickHouseInstallation{
+		Spec: api.ChiSpec{
+			Configuration: &api.Configuration{},
+		},
+	}
+	generator := NewClickHouseConfigGenerator(chi)
+
+	// Nothing to render
+	require.Equal(t, "", generator.GetStorageConfiguration())
+
+	chi.Spec.Configuration.Storage = &api.ChiStorageConfiguration{
+		Disks: []*api.ChiStorageDisk{
+			{Name: "cold", VolumeClaimTemplate: "cold-storage"},
+		},
+		Policies: []*api.ChiStoragePolicy{
+			{
+				Name: "tiered",
+				Volumes: []*api.ChiStoragePolicyVolume{
+					{Name: "hot", Disks: []string{StorageDiskDefault}},
+					{Name: "cold", Disks: []string{"cold"}},
+				},
+				MoveFactor: "0.2",
+			},
+		},
+	}
+	config := generator.GetStorageConfiguration()
+	require.Equal(t, `<yandex>
+    <storage_configuration>
+        <disks>
+            <cold>
+                <path>/var/lib/clickhouse-disks/cold/</path>
+            </cold>
+        </disks>
+        <policies>
+            <tiered>
+                <volumes>
+                    <hot>
+                        <disk>default</disk>
+                    </hot>
+                    <cold>
+                        <disk>cold</disk>
+                    </cold>
+                </volumes>
+                <move_factor>0.2</move_factor>
+            </tiered>
+        </policies>
+    </storage_configuration>
+</yandex>
+`, config)
+	require.NoError(t, ValidateConfigFile("storage.xml", config))
+}
+
+func Test_GetSettings_HostOverride(t *testing.T) {
+	chi := newMaintenanceTestCHI(2)
+	chi.Spec.Configuration.Settings = api.NewSettings().
+		Set("listen_host", api.NewSettingScalar("::")).
+		Set("max_server_memory_usage", api.NewSettingScalar("10000000000"))
+	reporting := chi.Spec.Configuration.Clusters[0].Layout.Shards[0].Hosts[0]
+	reporting.Settings = api.NewSettings().Set("max_server_memory_usage", api.NewSettingScalar("64000000000"))
+	regular := chi.Spec.Configuration.Clusters[0].Layout.Shards[1].Hosts[0]
+	generator := NewClickHouseConfigGenerator(chi)
+
+	// Global setting overridden by a host is not included into common config,
+	// since common config is merged by ClickHouse after the host one and would prevail
+	global := generator.GetSettingsGlobal()
+	require.Contains(t, global, "<listen_host>::</listen_host>")
+	require.NotContains(t, global, "max_server_memory_usage")
+
+	// Host settings take precedence
+	require.Equal(t, `<yandex>
+    <max_server_memory_usage>64000000000</max_server_memory_usage>
+</yandex>
+`, generator.GetSettings(reporting))
+	// Other hosts get overridden global setting into host config
+	require.Equal(t, `<yandex>
+    <max_server_memory_usage>10000000000</max_server_memory_usage>
+</yandex>
+`, generator.GetSettings(regular))
+
+	// No overrides - global settings are all in common config
+	reporting.Settings = nil
+	require.Contains(t, generator.GetSettingsGlobal(), "max_server_memory_usage")
+	require.Equal(t, "", generator.GetSettings(regular))
+}
+
+func Test_GetHostOpenSSL_CertManager(t *testing.T) {
+	host := newInterserverTestHost(false, false)
+	host.InterserverHTTPPort = ChDefaultInterserverHTTPPortNumber
+	generator := NewClickHouseConfigGenerator(host.GetCHI())
+
+	// Nothing is rendered unless certificates are issued by cert-manager
+	require.Empty(t, generator.GetHostOpenSSL(host))
+	require.NotContains(t, generator.GetHostHostnameAndPorts(host), "interserver_https_port")
+
+	host.GetCHI().Spec.CertManager = &api.ChiCertManager{Enabled: api.NewStringBool(true)}
+	config := generator.GetHostOpenSSL(host)
+	require.Equal(t, 2, strings.Count(config, "<certificateFile>/etc/clickhouse-server/tls/tls.crt</certificateFile>"))
+	require.Equal(t, 2, strings.Count(config, "<caConfig>/etc/clickhouse-server/tls/ca.crt</caConfig>"))
+	require.NoError(t, ValidateConfigFile("openssl.xml", config))
+
+	// Replicas keep fetching parts over http till all hosts are served with certificates
+	require.NotContains(t, generator.GetHostHostnameAndPorts(host), "interserver_https_port")
+
+	// Replicas fetch parts over https
+	host.GetCHI().Spec.CertManager.InterserverSecure = api.NewStringBool(true)
+	ports := generator.GetHostHostnameAndPorts(host)
+	require.Contains(t, ports, `<interserver_http_port remove="1"/>`)
+	require.Contains(t, ports, "<interserver_https_port>9009</interserver_https_port>")
+	require.NoError(t, ValidateConfigFile("ports.xml", ports))
+}
+
+func Test_GetInterserverCredentials(t *testing.T) {
+	host := newInterserverTestHost(false, false)
+	generator := NewClickHouseConfigGenerator(host.GetCHI())
+
+	// Nothing is rendered unless interserver credentials are enabled
+	require.Empty(t, generator.GetInterserverCredentials())
+
+	host.GetCHI().EnsureRuntime().SetInterserverCredentials(&api.InterserverCredentialsState{
+		Revision: "2",
+		Active:   InterserverCredentialsSlot1,
+		Accepted: InterserverCredentialsSlot0,
+	})
+	config := generator.GetInterserverCredentials()
+	require.Contains(t, config, `<user from_env="CLICKHOUSE_INTERSERVER_USERNAME_1"/>`)
+	require.Contains(t, config, `<password from_env="CLICKHOUSE_INTERSERVER_PASSWORD_1"/>`)
+	require.Contains(t, config, `<old>`)
+	require.Contains(t, config, `<password from_env="CLICKHOUSE_INTERSERVER_PASSWORD_0"/>`)
+	require.NotContains(t, config, "allow_empty")
+	require.NoError(t, ValidateConfigFile("interserver.xml", config))
+
+	host.GetCHI().EnsureRuntime().SetInterserverCredentials(&api.InterserverCredentialsState{
+		Revision:   "1",
+		Active:     InterserverCredentialsSlot0,
+		AllowEmpty: true,
+	})
+	config = generator.GetInterserverCredentials()
+	require.NotContains(t, config, `<old>`)
+	require.Contains(t, config, "<allow_empty>true</allow_empty>")
+	require.NoError(t, ValidateConfigFile("interserver.xml", config))
+}
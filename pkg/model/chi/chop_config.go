@@ -15,28 +15,127 @@
 package chi
 
 import (
-	"fmt"
-
 	"gopkg.in/d4l3k/messagediff.v1"
 
 	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
 	"github.com/altinity/clickhouse-operator/pkg/chop"
+	"github.com/altinity/clickhouse-operator/pkg/util"
 )
 
-// isZookeeperChangeRequiresReboot checks two ZooKeeper configs and decides,
+// ConfigurationChange specifies how configuration changes have to be applied to a host
+type ConfigurationChange int
+
+const (
+	// ConfigurationChangeNone means configuration is not changed
+	ConfigurationChangeNone ConfigurationChange = iota
+	// ConfigurationChangeReload means configuration changes can be applied by config reload, without host restart
+	ConfigurationChangeReload
+	// ConfigurationChangeRestart means configuration changes require host restart to be applied
+	ConfigurationChangeRestart
+)
+
+// Merge returns the most demanding of two configuration changes
+func (c ConfigurationChange) Merge(other ConfigurationChange) ConfigurationChange {
+	if other > c {
+		return other
+	}
+	return c
+}
+
+// String returns string representation of the configuration change
+func (c ConfigurationChange) String() string {
+	switch c {
+	case ConfigurationChangeReload:
+		return "reload"
+	case ConfigurationChangeRestart:
+		return "restart"
+	}
+	return "none"
+}
+
+// classifyZookeeperChange checks two ZooKeeper configs and decides,
 // whether config modifications require a reboot to be applied
-func isZookeeperChangeRequiresReboot(host *api.ChiHost, a, b *api.ChiZookeeperConfig) bool {
-	return !a.Equals(b)
+func classifyZookeeperChange(
+	host *api.ChiHost,
+	rules []api.OperatorConfigRestartPolicyRule,
+	a, b *api.ChiZookeeperConfig,
+) ConfigurationChange {
+	if a.Equals(b) {
+		return ConfigurationChangeNone
+	}
+	if isListedChangeRequiresReboot(host, rules, listAffectedZookeeperPaths(a, b)) {
+		return ConfigurationChangeRestart
+	}
+	return ConfigurationChangeReload
+}
+
+// listAffectedZookeeperPaths lists paths of ZooKeeper config, which differ between two ZooKeeper configs
+func listAffectedZookeeperPaths(a, b *api.ChiZookeeperConfig) (paths []string) {
+	if a == nil {
+		a = api.NewChiZookeeperConfig()
+	}
+	if b == nil {
+		b = api.NewChiZookeeperConfig()
+	}
+	path := func(name string) string {
+		return configurationRestartPolicyRulesSectionZookeeper + "/" + name
+	}
+	if _, equal := messagediff.DeepDiff(a.Nodes, b.Nodes); !equal {
+		paths = append(paths, path("nodes"))
+	}
+	if a.SessionTimeoutMs != b.SessionTimeoutMs {
+		paths = append(paths, path("session_timeout_ms"))
+	}
+	if a.OperationTimeoutMs != b.OperationTimeoutMs {
+		paths = append(paths, path("operation_timeout_ms"))
+	}
+	if a.Root != b.Root {
+		paths = append(paths, path("root"))
+	}
+	if a.Identity != b.Identity {
+		paths = append(paths, path("identity"))
+	}
+	return paths
+}
+
+// classifyMacrosChange checks two sets of macros and decides,
+// whether macros modifications require a reboot to be applied
+func classifyMacrosChange(host *api.ChiHost, a, b map[string]string) ConfigurationChange {
+	if util.MapsAreTheSame(a, b) {
+		return ConfigurationChangeNone
+	}
+	return ConfigurationChangeRestart
 }
 
-// isSettingsChangeRequiresReboot checks whether changes between two settings requires ClickHouse reboot
-func isSettingsChangeRequiresReboot(host *api.ChiHost, configurationRestartPolicyRulesSection string, a, b *api.Settings) bool {
+// classifyStorageChange checks two storage configurations and decides,
+// whether modifications require a reboot to be applied.
+// ClickHouse does not pick up changes of disks and storage policies on config reload
+func classifyStorageChange(a, b *api.ChiStorageConfiguration) ConfigurationChange {
+	if a.IsEmpty() && b.IsEmpty() {
+		return ConfigurationChangeNone
+	}
+	if _, equal := messagediff.DeepDiff(a, b); equal {
+		return ConfigurationChangeNone
+	}
+	return ConfigurationChangeRestart
+}
+
+// classifySettingsChange checks whether changes between two settings requires ClickHouse reboot or reload only
+func classifySettingsChange(
+	host *api.ChiHost,
+	rules []api.OperatorConfigRestartPolicyRule,
+	configurationRestartPolicyRulesSection string,
+	a, b *api.Settings,
+) ConfigurationChange {
 	diff, equal := messagediff.DeepDiff(a, b)
 	if equal {
-		return false
+		return ConfigurationChangeNone
 	}
 	affectedPaths := api.ListAffectedSettingsPathsFromDiff(a, b, diff, configurationRestartPolicyRulesSection)
-	return isListedChangeRequiresReboot(host, affectedPaths)
+	if isListedChangeRequiresReboot(host, rules, affectedPaths) {
+		return ConfigurationChangeRestart
+	}
+	return ConfigurationChangeReload
 }
 
 // hostVersionMatches checks whether host's ClickHouse version matches specified constraint
@@ -68,11 +167,10 @@ func ruleMatches(set api.OperatorConfigRestartPolicyRuleSet, path string) (match
 
 // getLatestConfigMatchValue returns value of the latest match of a specified `path` in ConfigRestartPolicy.Rules
 // in case match found in ConfigRestartPolicy.Rules or false
-func getLatestConfigMatchValue(host *api.ChiHost, path string) (matches bool, value bool) {
+func getLatestConfigMatchValue(host *api.ChiHost, rules []api.OperatorConfigRestartPolicyRule, path string) (matches bool, value bool) {
 	// Check all rules
-	for _, r := range chop.Config().ClickHouse.ConfigRestartPolicy.Rules {
+	for _, r := range rules {
 		// Check ClickHouse version of a particular rule
-		_ = fmt.Sprintf("%s", r.Version)
 		if hostVersionMatches(host, r.Version) {
 			// Yes, this is ClickHouse version of the host.
 			// Check whether any rule matches specified path.
@@ -89,10 +187,10 @@ func getLatestConfigMatchValue(host *api.ChiHost, path string) (matches bool, va
 }
 
 // isListedChangeRequiresReboot checks whether any of the provided paths requires reboot to apply configuration
-func isListedChangeRequiresReboot(host *api.ChiHost, paths []string) bool {
+func isListedChangeRequiresReboot(host *api.ChiHost, rules []api.OperatorConfigRestartPolicyRule, paths []string) bool {
 	// Check whether any path matches ClickHouse configuration restart policy rules requires reboot
 	for _, path := range paths {
-		if matches, value := getLatestConfigMatchValue(host, path); matches {
+		if matches, value := getLatestConfigMatchValue(host, rules, path); matches {
 			// This path matches configuration restart policy rule
 			if value {
 				// And this path not only matches, but requires reboot also - no need to find any other who requires reboot
@@ -149,6 +247,18 @@ const (
 
 // IsConfigurationChangeRequiresReboot checks whether configuration changes requires a reboot
 func IsConfigurationChangeRequiresReboot(host *api.ChiHost) bool {
+	return GetConfigurationChange(host) == ConfigurationChangeRestart
+}
+
+// GetConfigurationChange classifies configuration changes of the host into restart-required and reload-only,
+// based on CHOp configuration restart policy
+func GetConfigurationChange(host *api.ChiHost) ConfigurationChange {
+	return classifyConfigurationChange(host, chop.Config().ClickHouse.ConfigRestartPolicy.Rules)
+}
+
+// classifyConfigurationChange classifies configuration changes of the host according to specified restart policy rules
+func classifyConfigurationChange(host *api.ChiHost, rules []api.OperatorConfigRestartPolicyRule) ConfigurationChange {
+	change := ConfigurationChangeNone
 	// Zookeeper
 	{
 		var old, new *api.ChiZookeeperConfig
@@ -156,9 +266,27 @@ func IsConfigurationChangeRequiresReboot(host *api.ChiHost) bool {
 			old = host.GetAncestor().GetZookeeper()
 		}
 		new = host.GetZookeeper()
-		if isZookeeperChangeRequiresReboot(host, old, new) {
-			return true
+		change = change.Merge(classifyZookeeperChange(host, rules, old, new))
+	}
+	// Macros
+	{
+		var old, new map[string]string
+		if host.HasAncestor() {
+			old = host.GetAncestor().GetMacros()
 		}
+		new = host.GetMacros()
+		change = change.Merge(classifyMacrosChange(host, old, new))
+	}
+	// Storage
+	{
+		var old, new *api.ChiStorageConfiguration
+		if host.HasAncestorCHI() {
+			old = host.GetAncestorCHI().Spec.Configuration.Storage
+		}
+		if host.HasCHI() {
+			new = host.GetCHI().Spec.Configuration.Storage
+		}
+		change = change.Merge(classifyStorageChange(old, new))
 	}
 	// Profiles Global
 	{
@@ -169,9 +297,7 @@ func IsConfigurationChangeRequiresReboot(host *api.ChiHost) bool {
 		if host.HasCHI() {
 			new = host.GetCHI().Spec.Configuration.Profiles
 		}
-		if isSettingsChangeRequiresReboot(host, configurationRestartPolicyRulesSectionProfiles, old, new) {
-			return true
-		}
+		change = change.Merge(classifySettingsChange(host, rules, configurationRestartPolicyRulesSectionProfiles, old, new))
 	}
 	// Quotas Global
 	{
@@ -182,9 +308,7 @@ func IsConfigurationChangeRequiresReboot(host *api.ChiHost) bool {
 		if host.HasCHI() {
 			new = host.GetCHI().Spec.Configuration.Quotas
 		}
-		if isSettingsChangeRequiresReboot(host, configurationRestartPolicyRulesSectionQuotas, old, new) {
-			return true
-		}
+		change = change.Merge(classifySettingsChange(host, rules, configurationRestartPolicyRulesSectionQuotas, old, new))
 	}
 	// Settings Global
 	{
@@ -195,9 +319,7 @@ func IsConfigurationChangeRequiresReboot(host *api.ChiHost) bool {
 		if host.HasCHI() {
 			new = host.GetCHI().Spec.Configuration.Settings
 		}
-		if isSettingsChangeRequiresReboot(host, configurationRestartPolicyRulesSectionSettings, old, new) {
-			return true
-		}
+		change = change.Merge(classifySettingsChange(host, rules, configurationRestartPolicyRulesSectionSettings, old, new))
 	}
 	// Settings Local
 	{
@@ -206,9 +328,7 @@ func IsConfigurationChangeRequiresReboot(host *api.ChiHost) bool {
 			old = host.GetAncestor().Settings
 		}
 		new = host.Settings
-		if isSettingsChangeRequiresReboot(host, configurationRestartPolicyRulesSectionSettings, old, new) {
-			return true
-		}
+		change = change.Merge(classifySettingsChange(host, rules, configurationRestartPolicyRulesSectionSettings, old, new))
 	}
 	// Files Global
 	{
@@ -227,9 +347,7 @@ func IsConfigurationChangeRequiresReboot(host *api.ChiHost) bool {
 				true,
 			)
 		}
-		if isSettingsChangeRequiresReboot(host, configurationRestartPolicyRulesSectionFiles, old, new) {
-			return true
-		}
+		change = change.Merge(classifySettingsChange(host, rules, configurationRestartPolicyRulesSectionFiles, old, new))
 	}
 	// Files Local
 	{
@@ -246,10 +364,8 @@ func IsConfigurationChangeRequiresReboot(host *api.ChiHost) bool {
 			[]api.SettingsSection{api.SectionUsers},
 			true,
 		)
-		if isSettingsChangeRequiresReboot(host, configurationRestartPolicyRulesSectionFiles, old, new) {
-			return true
-		}
+		change = change.Merge(classifySettingsChange(host, rules, configurationRestartPolicyRulesSectionFiles, old, new))
 	}
 
-	return false
+	return change
 }
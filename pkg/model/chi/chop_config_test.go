@@ -0,0 +1,179 @@
+package chi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/apis/swversion"
+)
+
+func Test_ClassifySettingsChange(t *testing.T) {
+	rules := []api.OperatorConfigRestartPolicyRule{
+		{
+			Version: "*",
+			Rules: []api.OperatorConfigRestartPolicyRuleSet{
+				{"settings/*": "yes"},
+				{"settings/max_concurrent_queries": "no"},
+				{"settings/logger/*": "no"},
+			},
+		},
+	}
+	host := &api.ChiHost{}
+	settings := func(values map[string]string) *api.Settings {
+		s := api.NewSettings()
+		for name, value := range values {
+			s.Set(name, api.NewSettingScalar(value))
+		}
+		return s
+	}
+
+	old := settings(map[string]string{
+		"listen_host":            "0.0.0.0",
+		"max_concurrent_queries": "100",
+		"logger/level":           "debug",
+	})
+
+	// Nothing changed
+	require.Equal(t, ConfigurationChangeNone, classifySettingsChange(host, rules, configurationRestartPolicyRulesSectionSettings, old, old))
+
+	// Reload-only settings changed
+	reload := settings(map[string]string{
+		"listen_host":            "0.0.0.0",
+		"max_concurrent_queries": "200",
+		"logger/level":           "trace",
+	})
+	require.Equal(t, ConfigurationChangeReload, classifySettingsChange(host, rules, configurationRestartPolicyRulesSectionSettings, old, reload))
+
+	// Restart-required setting changed along with reload-only one
+	restart := settings(map[string]string{
+		"listen_host":            "::",
+		"max_concurrent_queries": "200",
+		"logger/level":           "debug",
+	})
+	require.Equal(t, ConfigurationChangeRestart, classifySettingsChange(host, rules, configurationRestartPolicyRulesSectionSettings, old, restart))
+
+	// Restart-required setting added
+	added := settings(map[string]string{
+		"listen_host":            "0.0.0.0",
+		"max_concurrent_queries": "100",
+		"logger/level":           "debug",
+		"tcp_port":               "9001",
+	})
+	require.Equal(t, ConfigurationChangeRestart, classifySettingsChange(host, rules, configurationRestartPolicyRulesSectionSettings, old, added))
+}
+
+func Test_ConfigurationChange_Merge(t *testing.T) {
+	require.Equal(t, ConfigurationChangeReload, ConfigurationChangeNone.Merge(ConfigurationChangeReload))
+	require.Equal(t, ConfigurationChangeRestart, ConfigurationChangeRestart.Merge(ConfigurationChangeReload))
+	require.Equal(t, ConfigurationChangeRestart, ConfigurationChangeReload.Merge(ConfigurationChangeRestart))
+	require.Equal(t, ConfigurationChangeNone, ConfigurationChangeNone.Merge(ConfigurationChangeNone))
+}
+
+func Test_ClassifyMacrosChange(t *testing.T) {
+	host := &api.ChiHost{}
+	macros := map[string]string{"layer": "hot"}
+
+	require.Equal(t, ConfigurationChangeNone, classifyMacrosChange(host, nil, map[string]string{}))
+	require.Equal(t, ConfigurationChangeNone, classifyMacrosChange(host, macros, map[string]string{"layer": "hot"}))
+	// Any macros change requires restart
+	require.Equal(t, ConfigurationChangeRestart, classifyMacrosChange(host, macros, map[string]string{"layer": "cold"}))
+	require.Equal(t, ConfigurationChangeRestart, classifyMacrosChange(host, macros, nil))
+}
+
+func Test_ClassifyStorageChange(t *testing.T) {
+	storage := func(moveFactor string) *api.ChiStorageConfiguration {
+		return &api.ChiStorageConfiguration{
+			Disks: []*api.ChiStorageDisk{
+				{Name: "cold", VolumeClaimTemplate: "cold-storage"},
+			},
+			Policies: []*api.ChiStoragePolicy{
+				{
+					Name: "tiered",
+					Volumes: []*api.ChiStoragePolicyVolume{
+						{Name: "hot", Disks: []string{"default"}},
+						{Name: "cold", Disks: []string{"cold"}},
+					},
+					MoveFactor: moveFactor,
+				},
+			},
+		}
+	}
+
+	require.Equal(t, ConfigurationChangeNone, classifyStorageChange(nil, &api.ChiStorageConfiguration{}))
+	require.Equal(t, ConfigurationChangeNone, classifyStorageChange(storage("0.2"), storage("0.2")))
+	// Any policy change requires restart
+	require.Equal(t, ConfigurationChangeRestart, classifyStorageChange(storage("0.2"), storage("0.1")))
+	require.Equal(t, ConfigurationChangeRestart, classifyStorageChange(nil, storage("0.2")))
+}
+
+func Test_ClassifyZookeeperChange(t *testing.T) {
+	rules := []api.OperatorConfigRestartPolicyRule{
+		{
+			Version: "*",
+			Rules: []api.OperatorConfigRestartPolicyRuleSet{
+				{"zookeeper/*": "yes"},
+			},
+		},
+		{
+			Version: ">= 22.3",
+			Rules: []api.OperatorConfigRestartPolicyRuleSet{
+				{"zookeeper/nodes": "no"},
+			},
+		},
+	}
+	zk := func(root string, hosts ...string) *api.ChiZookeeperConfig {
+		config := &api.ChiZookeeperConfig{Root: root}
+		for _, host := range hosts {
+			config.Nodes = append(config.Nodes, api.ChiZookeeperNode{Host: host, Port: 2181})
+		}
+		return config
+	}
+	old := zk("/clickhouse", "zk-0", "zk-1", "zk-2")
+	scaled := zk("/clickhouse", "zk-0", "zk-1", "zk-2", "zk-3", "zk-4")
+	rooted := zk("/clickhouse/other", "zk-0", "zk-1", "zk-2", "zk-3", "zk-4")
+
+	host := &api.ChiHost{}
+	host.Runtime.Version = swversion.NewSoftWareVersion("23.8.1.1")
+	require.Equal(t, ConfigurationChangeNone, classifyZookeeperChange(host, rules, old, old))
+	// Ensemble change is applied by config reload by capable version
+	require.Equal(t, ConfigurationChangeReload, classifyZookeeperChange(host, rules, old, scaled))
+	// Broader ZooKeeper config change requires restart
+	require.Equal(t, ConfigurationChangeRestart, classifyZookeeperChange(host, rules, old, rooted))
+
+	// Older and unknown versions fall back to restart
+	host.Runtime.Version = swversion.NewSoftWareVersion("21.8.1.1")
+	require.Equal(t, ConfigurationChangeRestart, classifyZookeeperChange(host, rules, old, scaled))
+	host.Runtime.Version = nil
+	require.Equal(t, ConfigurationChangeRestart, classifyZookeeperChange(host, rules, old, scaled))
+}
+
+func Test_ClassifyConfigurationChange_HostSettings(t *testing.T) {
+	rules := []api.OperatorConfigRestartPolicyRule{
+		{
+			Version: "*",
+			Rules: []api.OperatorConfigRestartPolicyRuleSet{
+				{"settings/*": "yes"},
+			},
+		},
+	}
+	hostSettings := func(value string) *api.Settings {
+		return api.NewSettings().Set("max_server_memory_usage", api.NewSettingScalar(value))
+	}
+	ancestor := newMaintenanceTestCHI(2)
+	ancestor.Spec.Configuration.Clusters[0].Layout.Shards[0].Hosts[0].Settings = hostSettings("32000000000")
+	chi := newMaintenanceTestCHI(2)
+	chi.SetAncestor(ancestor)
+	reporting := chi.Spec.Configuration.Clusters[0].Layout.Shards[0].Hosts[0]
+	regular := chi.Spec.Configuration.Clusters[0].Layout.Shards[1].Hosts[0]
+
+	// Unchanged override
+	reporting.Settings = hostSettings("32000000000")
+	require.Equal(t, ConfigurationChangeNone, classifyConfigurationChange(reporting, rules))
+
+	// Changed override of a single host rolls the host only
+	reporting.Settings = hostSettings("64000000000")
+	require.Equal(t, ConfigurationChangeRestart, classifyConfigurationChange(reporting, rules))
+	require.Equal(t, ConfigurationChangeNone, classifyConfigurationChange(regular, rules))
+}
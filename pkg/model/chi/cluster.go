@@ -0,0 +1,156 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/apis/deployment"
+)
+
+// ClusterGetClickHouseImages gets map of ClickHouse images the cluster's hosts are configured to run with.
+// Maps "image->list of host names"
+func ClusterGetClickHouseImages(cluster *api.Cluster) map[string][]string {
+	images := make(map[string][]string)
+	cluster.WalkHosts(func(host *api.ChiHost) error {
+		image := HostGetClickHouseImage(host)
+		images[image] = append(images[image], host.GetName())
+		return nil
+	})
+	return images
+}
+
+// ClusterCheckClickHouseImages checks whether all hosts of the cluster are configured to run the same ClickHouse image.
+// Desired (normalized) configuration is checked, so transient differences between running hosts,
+// such as during rolling upgrade, are not considered as mixed versions.
+func ClusterCheckClickHouseImages(cluster *api.Cluster) error {
+	images := ClusterGetClickHouseImages(cluster)
+	if len(images) < 2 {
+		return nil
+	}
+
+	var list []string
+	for image, hosts := range images {
+		list = append(list, fmt.Sprintf("%s: [%s]", image, strings.Join(hosts, ",")))
+	}
+	sort.Strings(list)
+	return fmt.Errorf("cluster %s has mixed ClickHouse images: %s", cluster.Name, strings.Join(list, " "))
+}
+
+// ClusterIsSchemaManaged checks whether schema of the cluster is created and migrated by the operator
+func ClusterIsSchemaManaged(cluster *api.Cluster) bool {
+	if cluster == nil {
+		return true
+	}
+	return cluster.SchemaManagement != SchemaManagementNone
+}
+
+// ClusterIsShardCordoned checks whether shard of the cluster is excluded from the cluster for maintenance.
+// The last shard of the cluster, which is not in maintenance, can not be cordoned,
+// so maintenance is not honored in case all shards of the cluster are requested to be in maintenance.
+func ClusterIsShardCordoned(cluster *api.Cluster, shard *api.ChiShard) bool {
+	if !shard.IsInMaintenance() {
+		return false
+	}
+	serving := 0
+	cluster.WalkShards(func(_ int, s *api.ChiShard) error {
+		if !s.IsInMaintenance() {
+			serving++
+		}
+		return nil
+	})
+	return serving > 0
+}
+
+// IsHostCordoned checks whether host is excluded from the cluster, because its shard is in maintenance
+func IsHostCordoned(host *api.ChiHost) bool {
+	cluster := host.GetCluster()
+	if cluster == nil {
+		return false
+	}
+	return ClusterIsShardCordoned(cluster, host.GetShard())
+}
+
+// isPodTemplateOneHostPerNode checks whether pod template guarantees cluster's hosts do not share a node
+func isPodTemplateOneHostPerNode(template *api.PodTemplate) bool {
+	for i := range template.PodDistribution {
+		podDistribution := &template.PodDistribution[i]
+		switch podDistribution.Scope {
+		case
+			deployment.PodDistributionScopeCluster,
+			deployment.PodDistributionScopeClickHouseInstallation,
+			deployment.PodDistributionScopeNamespace,
+			deployment.PodDistributionScopeGlobal:
+		default:
+			// Hosts of the cluster may co-exist on one node
+			continue
+		}
+		switch podDistribution.Type {
+		case deployment.PodDistributionClickHouseAntiAffinity:
+			return true
+		case deployment.PodDistributionMaxNumberPerNode:
+			if podDistribution.Number == 1 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ClusterCheckHostNetworkPorts checks whether hosts of the cluster running with hostNetwork have their ports handled.
+// Pods with hostNetwork bind ports on the node itself, so hosts, which may be scheduled on the same node,
+// have to use different ports. It is either explicit ports specification, port distribution by host template
+// or pod distribution, which prevents hosts from sharing a node.
+func ClusterCheckHostNetworkPorts(cluster *api.Cluster) error {
+	// Maps "port->list of host names", which may share a node and use this port
+	ports := make(map[int32][]string)
+	cluster.WalkHosts(func(host *api.ChiHost) error {
+		podTemplate, ok := host.GetPodTemplate()
+		if !ok || !podTemplate.Spec.HostNetwork || isPodTemplateOneHostPerNode(podTemplate) {
+			return nil
+		}
+		for _, port := range []int32{
+			host.TCPPort,
+			host.TLSPort,
+			host.HTTPPort,
+			host.HTTPSPort,
+			host.InterserverHTTPPort,
+			host.GRPCPort,
+			host.PrometheusPort,
+		} {
+			if api.IsPortAssigned(port) {
+				ports[port] = append(ports[port], host.GetName())
+			}
+		}
+		return nil
+	})
+
+	var list []string
+	for port, hosts := range ports {
+		if len(hosts) > 1 {
+			list = append(list, fmt.Sprintf("%d: [%s]", port, strings.Join(hosts, ",")))
+		}
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	sort.Strings(list)
+	return fmt.Errorf("cluster %s has hosts with hostNetwork, which may share a node, using the same ports: %s. "+
+		"Specify ports explicitly, use port distribution or pod distribution to run one host per node",
+		cluster.Name, strings.Join(list, " "))
+}
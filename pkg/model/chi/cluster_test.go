@@ -0,0 +1,147 @@
+package chi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/apis/deployment"
+)
+
+// newTestCluster creates cluster with one host per each specified pod template
+func newTestCluster(podTemplates map[string]string, hostPodTemplates ...string) *api.Cluster {
+	chi := &api.ClickHouseInstallation{
+		Spec: api.ChiSpec{
+			Templates: api.NewChiTemplates(),
+		},
+	}
+	for name, image := range podTemplates {
+		chi.Spec.Templates.EnsurePodTemplatesIndex().Set(name, &api.PodTemplate{
+			Name: name,
+			Spec: core.PodSpec{
+				Containers: []core.Container{
+					{
+						Name:  ClickHouseContainerName,
+						Image: image,
+					},
+				},
+			},
+		})
+	}
+
+	cluster := &api.Cluster{
+		Name:   "cluster",
+		Layout: api.NewChiClusterLayout(),
+	}
+	shard := api.ChiShard{}
+	for i, podTemplate := range hostPodTemplates {
+		host := &api.ChiHost{
+			Name:      "host-" + string(rune('a'+i)),
+			Templates: &api.ChiTemplateNames{PodTemplate: podTemplate},
+		}
+		host.Runtime.CHI = chi
+		shard.Hosts = append(shard.Hosts, host)
+	}
+	cluster.Layout.Shards = append(cluster.Layout.Shards, shard)
+	return cluster
+}
+
+func Test_ClusterCheckClickHouseImages_Uniform(t *testing.T) {
+	cluster := newTestCluster(
+		map[string]string{
+			"pod-1": "clickhouse/clickhouse-server:23.8",
+			"pod-2": "clickhouse/clickhouse-server:23.8",
+		},
+		"pod-1", "pod-2", "pod-2",
+	)
+	require.NoError(t, ClusterCheckClickHouseImages(cluster))
+	require.Len(t, ClusterGetClickHouseImages(cluster), 1)
+}
+
+func Test_ClusterCheckClickHouseImages_Mixed(t *testing.T) {
+	cluster := newTestCluster(
+		map[string]string{
+			"pod-1": "clickhouse/clickhouse-server:23.8",
+			"pod-2": "clickhouse/clickhouse-server:24.3",
+		},
+		"pod-1", "pod-2", "pod-1",
+	)
+	err := ClusterCheckClickHouseImages(cluster)
+	require.Error(t, err)
+	require.Equal(t,
+		"cluster cluster has mixed ClickHouse images: "+
+			"clickhouse/clickhouse-server:23.8: [host-a,host-c] clickhouse/clickhouse-server:24.3: [host-b]",
+		err.Error())
+}
+
+func Test_ClusterCheckClickHouseImages_DefaultImage(t *testing.T) {
+	// Host without pod template runs default image
+	cluster := newTestCluster(
+		map[string]string{
+			"pod-1": DefaultClickHouseDockerImage,
+			"pod-2": "clickhouse/clickhouse-server:24.3",
+		},
+		"pod-1", "",
+	)
+	require.NoError(t, ClusterCheckClickHouseImages(cluster))
+
+	cluster = newTestCluster(
+		map[string]string{
+			"pod-2": "clickhouse/clickhouse-server:24.3",
+		},
+		"pod-2", "",
+	)
+	require.Error(t, ClusterCheckClickHouseImages(cluster))
+}
+
+func Test_ClusterCheckHostNetworkPorts(t *testing.T) {
+	newCluster := func(distribution ...api.PodDistribution) *api.Cluster {
+		cluster := newTestCluster(map[string]string{"pod": DefaultClickHouseDockerImage}, "pod", "pod")
+		podTemplate, _ := cluster.Layout.Shards[0].Hosts[0].GetPodTemplate()
+		podTemplate.Spec.HostNetwork = true
+		podTemplate.PodDistribution = distribution
+		for i, host := range cluster.Layout.Shards[0].Hosts {
+			host.TCPPort = 9000
+			host.HTTPPort = 8123
+			host.InterserverHTTPPort = 9009 + int32(i)
+			host.TLSPort = api.PortUnassigned()
+			host.HTTPSPort = api.PortUnassigned()
+		}
+		return cluster
+	}
+
+	// Hosts may share a node and use the same ports
+	err := ClusterCheckHostNetworkPorts(newCluster())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "8123: [host-a,host-b] 9000: [host-a,host-b]")
+	require.NotContains(t, err.Error(), "9009")
+
+	// Pod distribution prevents hosts from sharing a node
+	require.NoError(t, ClusterCheckHostNetworkPorts(newCluster(api.PodDistribution{
+		Type:  deployment.PodDistributionClickHouseAntiAffinity,
+		Scope: deployment.PodDistributionScopeCluster,
+	})))
+	require.NoError(t, ClusterCheckHostNetworkPorts(newCluster(api.PodDistribution{
+		Type:   deployment.PodDistributionMaxNumberPerNode,
+		Scope:  deployment.PodDistributionScopeClickHouseInstallation,
+		Number: 1,
+	})))
+	// Shard scope lets hosts of different shards share a node
+	require.Error(t, ClusterCheckHostNetworkPorts(newCluster(api.PodDistribution{
+		Type:  deployment.PodDistributionClickHouseAntiAffinity,
+		Scope: deployment.PodDistributionScopeShard,
+	})))
+
+	// Ports are distributed
+	cluster := newCluster()
+	for i, host := range cluster.Layout.Shards[0].Hosts {
+		host.TCPPort += int32(i)
+		host.HTTPPort += int32(i)
+	}
+	require.NoError(t, ClusterCheckHostNetworkPorts(cluster))
+
+	// No hostNetwork - no conflicts
+	require.NoError(t, ClusterCheckHostNetworkPorts(newTestCluster(map[string]string{"pod": DefaultClickHouseDockerImage}, "pod", "pod")))
+}
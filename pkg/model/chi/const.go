@@ -23,6 +23,12 @@ const (
 	InternodeClusterSecretEnvName = "CLICKHOUSE_INTERNODE_CLUSTER_SECRET"
 )
 
+const (
+	// Prefixes of ENV vars interserver credentials are passed into ClickHouse with, followed by slot
+	InterserverUsernameEnvNamePrefix = "CLICKHOUSE_INTERSERVER_USERNAME_"
+	InterserverPasswordEnvNamePrefix = "CLICKHOUSE_INTERSERVER_PASSWORD_"
+)
+
 // Values for Schema Policy
 const (
 	SchemaPolicyReplicaNone                = "None"
@@ -31,3 +37,11 @@ const (
 	SchemaPolicyShardAll                   = "All"
 	SchemaPolicyShardDistributedTablesOnly = "DistributedTablesOnly"
 )
+
+// Values for Schema Management
+const (
+	// SchemaManagementManaged specifies cluster, which schema is created and migrated by the operator
+	SchemaManagementManaged = "Managed"
+	// SchemaManagementNone specifies cluster, which schema is maintained outside of the operator, ex.: restored from backup
+	SchemaManagementNone = "None"
+)
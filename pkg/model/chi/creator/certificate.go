@@ -0,0 +1,92 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package creator
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+)
+
+// CertificateGVR specifies cert-manager Certificate resource
+var CertificateGVR = schema.GroupVersionResource{
+	Group:    "cert-manager.io",
+	Version:  "v1",
+	Resource: "certificates",
+}
+
+// CreateCertificate creates cert-manager Certificate the host is served with.
+// Certificate is issued into the Secret with the same name. Returns nil in case cert-manager is not enabled
+func (c *Creator) CreateCertificate(host *api.ChiHost) *unstructured.Unstructured {
+	certManager := c.chi.GetCertManager()
+	if !certManager.IsEnabled() {
+		return nil
+	}
+
+	name := model.CreateCertificateName(host)
+	labels := c.labels.GetCertificateHost(host)
+	if certManager.IsScopeCHI() {
+		labels = c.labels.GetCertificateCHI()
+	}
+
+	dnsNames := make([]interface{}, 0)
+	for _, dnsName := range model.CreateCertificateDNSNames(host) {
+		dnsNames = append(dnsNames, dnsName)
+	}
+	spec := map[string]interface{}{
+		"secretName": name,
+		"commonName": model.CreateInstanceHostname(host),
+		"dnsNames":   dnsNames,
+		// Hosts act as clients on connections to other hosts
+		"usages": []interface{}{"server auth", "client auth"},
+	}
+	if certManager.IsScopeCHI() {
+		spec["commonName"] = model.CreateCHIServiceName(c.chi)
+	}
+	if issuerRef := certManager.GetIssuerRef(); issuerRef != nil {
+		ref := map[string]interface{}{
+			"name": issuerRef.Name,
+		}
+		if issuerRef.Kind != "" {
+			ref["kind"] = issuerRef.Kind
+		}
+		if issuerRef.Group != "" {
+			ref["group"] = issuerRef.Group
+		}
+		spec["issuerRef"] = ref
+	}
+	if certManager.Duration != "" {
+		spec["duration"] = certManager.Duration
+	}
+	if certManager.RenewBefore != "" {
+		spec["renewBefore"] = certManager.RenewBefore
+	}
+
+	certificate := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": spec,
+		},
+	}
+	certificate.SetAPIVersion(CertificateGVR.GroupVersion().String())
+	certificate.SetKind("Certificate")
+	certificate.SetNamespace(c.chi.Namespace)
+	certificate.SetName(name)
+	certificate.SetLabels(labels)
+	certificate.SetOwnerReferences(getOwnerReferences(c.chi))
+
+	return certificate
+}
@@ -35,6 +35,8 @@ func NewDefaultHostTemplate(name string) *api.HostTemplate {
 			HTTPPort:            api.PortUnassigned(),
 			HTTPSPort:           api.PortUnassigned(),
 			InterserverHTTPPort: api.PortUnassigned(),
+			GRPCPort:            api.PortUnassigned(),
+			PrometheusPort:      api.PortUnassigned(),
 			Templates:           nil,
 		},
 	}
@@ -56,6 +58,8 @@ func NewDefaultHostTemplateForHostNetwork(name string) *api.HostTemplate {
 			HTTPPort:            api.PortUnassigned(),
 			HTTPSPort:           api.PortUnassigned(),
 			InterserverHTTPPort: api.PortUnassigned(),
+			GRPCPort:            api.PortUnassigned(),
+			PrometheusPort:      api.PortUnassigned(),
 			Templates:           nil,
 		},
 	}
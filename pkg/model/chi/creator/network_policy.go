@@ -0,0 +1,138 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package creator
+
+import (
+	"sort"
+
+	core "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/chop"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+)
+
+const (
+	// Label operator pods are labelled with by the operator installation bundle
+	operatorPodLabelName  = "app"
+	operatorPodLabelValue = "clickhouse-operator"
+)
+
+// CreateNetworkPolicy creates NetworkPolicy, which allows ingress to ClickHouse ports of the CHI's pods
+// from the CHI's pods, the operator and peers specified in the CHI only.
+// The operator is allowed to reach clickhouse-backup REST API on the default port and on the specified ports as well
+func (c *Creator) CreateNetworkPolicy(backupPorts ...int32) *networking.NetworkPolicy {
+	from := []networking.NetworkPolicyPeer{
+		// Replicas fetch parts and run distributed queries
+		{
+			PodSelector: &meta.LabelSelector{
+				MatchLabels: c.labels.GetSelectorCHIScope(),
+			},
+		},
+	}
+	from = append(from, c.chi.GetNetworkPolicy().GetFrom()...)
+
+	// The operator maintains schema, collects metrics and runs backups
+	operator := []networking.NetworkPolicyPeer{
+		{
+			NamespaceSelector: &meta.LabelSelector{
+				MatchLabels: map[string]string{
+					core.LabelMetadataName: chop.Config().Runtime.Namespace,
+				},
+			},
+			PodSelector: &meta.LabelSelector{
+				MatchLabels: map[string]string{
+					operatorPodLabelName: operatorPodLabelValue,
+				},
+			},
+		},
+	}
+
+	return &networking.NetworkPolicy{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace:       c.chi.Namespace,
+			Name:            model.CreateNetworkPolicyName(c.chi),
+			Labels:          model.Macro(c.chi).Map(c.labels.GetNetworkPolicyCHI()),
+			OwnerReferences: getOwnerReferences(c.chi),
+		},
+		Spec: networking.NetworkPolicySpec{
+			PodSelector: meta.LabelSelector{
+				MatchLabels: c.labels.GetSelectorCHIScope(),
+			},
+			Ingress: []networking.NetworkPolicyIngressRule{
+				{
+					Ports: getNetworkPolicyPorts(c.chi),
+					From:  from,
+				},
+				{
+					Ports: getNetworkPolicyPorts(c.chi, append([]int32{api.DefaultBackupAPIPort}, backupPorts...)...),
+					From:  operator,
+				},
+			},
+			PolicyTypes: []networking.PolicyType{
+				networking.PolicyTypeIngress,
+			},
+		},
+	}
+}
+
+// getNetworkPolicyPorts gets ClickHouse ports of all hosts of the CHI along with specified extra TCP ports,
+// ordered by port number
+func getNetworkPolicyPorts(chi *api.ClickHouseInstallation, extraPorts ...int32) []networking.NetworkPolicyPort {
+	type portKey struct {
+		port     int32
+		protocol core.Protocol
+	}
+	seen := make(map[portKey]bool)
+	var keys []portKey
+	add := func(port int32, protocol core.Protocol) {
+		key := portKey{port: port, protocol: protocol}
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	chi.WalkHosts(func(host *api.ChiHost) error {
+		model.HostWalkAssignedPorts(host, func(name string, port *int32, protocol core.Protocol) bool {
+			add(*port, protocol)
+			// Do not break, continue iterating
+			return false
+		})
+		return nil
+	})
+	for _, port := range extraPorts {
+		add(port, core.ProtocolTCP)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].port != keys[j].port {
+			return keys[i].port < keys[j].port
+		}
+		return keys[i].protocol < keys[j].protocol
+	})
+
+	var ports []networking.NetworkPolicyPort
+	for _, key := range keys {
+		protocol := key.protocol
+		port := intstr.FromInt(int(key.port))
+		ports = append(ports, networking.NetworkPolicyPort{
+			Protocol: &protocol,
+			Port:     &port,
+		})
+	}
+	return ports
+}
@@ -0,0 +1,88 @@
+package creator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/chop"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+)
+
+// newNetworkPolicyTestHost builds host with the specified ports assigned only
+func newNetworkPolicyTestHost(tcp, http, https int32) *api.ChiHost {
+	host := &api.ChiHost{}
+	model.HostWalkPorts(host, func(name string, port *int32, protocol core.Protocol) bool {
+		*port = api.PortUnassigned()
+		return false
+	})
+	host.TCPPort = tcp
+	host.HTTPPort = http
+	host.HTTPSPort = https
+	return host
+}
+
+func newNetworkPolicyTestCHI() *api.ClickHouseInstallation {
+	return &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+		},
+		Spec: api.ChiSpec{
+			Configuration: &api.Configuration{
+				Clusters: []*api.Cluster{
+					{
+						Name: "cluster",
+						Layout: &api.ChiClusterLayout{
+							Shards: []api.ChiShard{
+								{
+									Hosts: []*api.ChiHost{
+										newNetworkPolicyTestHost(9000, 8123, api.PortUnassigned()),
+										newNetworkPolicyTestHost(9000, 8123, 8443),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// getNetworkPolicyTestPorts gets port numbers out of NetworkPolicy ports
+func getNetworkPolicyTestPorts(t *testing.T, ports []networking.NetworkPolicyPort) []int {
+	var numbers []int
+	for _, port := range ports {
+		require.Equal(t, core.ProtocolTCP, *port.Protocol)
+		numbers = append(numbers, port.Port.IntValue())
+	}
+	return numbers
+}
+
+func Test_GetNetworkPolicyPorts(t *testing.T) {
+	// Ports are collected over all hosts, each port is listed once
+	require.Equal(t, []int{8123, 8443, 9000}, getNetworkPolicyTestPorts(t, getNetworkPolicyPorts(newNetworkPolicyTestCHI())))
+}
+
+func Test_CreateNetworkPolicy_BackupAPIPorts(t *testing.T) {
+	chop.NewWithConfig(&api.OperatorConfig{})
+	t.Cleanup(func() {
+		chop.NewWithConfig(nil)
+	})
+
+	networkPolicy := NewCreator(newNetworkPolicyTestCHI()).CreateNetworkPolicy(7272, api.DefaultBackupAPIPort)
+	require.Len(t, networkPolicy.Spec.Ingress, 2)
+
+	// Peers reach ClickHouse ports only
+	require.Equal(t, []int{8123, 8443, 9000}, getNetworkPolicyTestPorts(t, networkPolicy.Spec.Ingress[0].Ports))
+
+	// The operator reaches clickhouse-backup REST API on the default and the specified ports as well
+	require.Equal(t, []int{7171, 7272, 8123, 8443, 9000}, getNetworkPolicyTestPorts(t, networkPolicy.Spec.Ingress[1].Ports))
+	require.Len(t, networkPolicy.Spec.Ingress[1].From, 1)
+	require.NotNil(t, networkPolicy.Spec.Ingress[1].From[0].NamespaceSelector)
+}
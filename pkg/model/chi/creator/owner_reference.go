@@ -41,3 +41,8 @@ func getOwnerReference(objectMeta *meta.ObjectMeta) meta.OwnerReference {
 		BlockOwnerDeletion: &block,
 	}
 }
+
+// GetOwnerReferences gets owner references to be used by objects, owned by the CHI
+func (c *Creator) GetOwnerReferences() []meta.OwnerReference {
+	return getOwnerReferences(c.chi)
+}
@@ -25,19 +25,20 @@ import (
 	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
 )
 
-// NewPodDisruptionBudget creates new PodDisruptionBudget
-func (c *Creator) NewPodDisruptionBudget(cluster *api.Cluster) *policy.PodDisruptionBudget {
+// NewPodDisruptionBudget creates new PodDisruptionBudget of the shard.
+// At most one replica of the shard is allowed to be disrupted at a time, so node drains never take the whole shard down
+func (c *Creator) NewPodDisruptionBudget(shard *api.ChiShard) *policy.PodDisruptionBudget {
 	return &policy.PodDisruptionBudget{
 		ObjectMeta: meta.ObjectMeta{
-			Name:            fmt.Sprintf("%s-%s", cluster.Runtime.Address.CHIName, cluster.Runtime.Address.ClusterName),
+			Name:            fmt.Sprintf("%s-%s-%s", shard.Runtime.Address.CHIName, shard.Runtime.Address.ClusterName, shard.Runtime.Address.ShardName),
 			Namespace:       c.chi.Namespace,
-			Labels:          model.Macro(c.chi).Map(c.labels.GetClusterScope(cluster)),
-			Annotations:     model.Macro(c.chi).Map(c.annotations.GetClusterScope(cluster)),
+			Labels:          model.Macro(c.chi).Map(c.labels.GetPDBShard(shard)),
+			Annotations:     model.Macro(c.chi).Map(c.annotations.GetPDBShard(shard)),
 			OwnerReferences: getOwnerReferences(c.chi),
 		},
 		Spec: policy.PodDisruptionBudgetSpec{
 			Selector: &meta.LabelSelector{
-				MatchLabels: model.GetSelectorClusterScope(cluster),
+				MatchLabels: model.GetSelectorShardScope(shard),
 			},
 			MaxUnavailable: &intstr.IntOrString{
 				Type:   intstr.Int,
@@ -0,0 +1,44 @@
+package creator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/chop"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+)
+
+func Test_NewPodDisruptionBudget(t *testing.T) {
+	chop.NewWithConfig(&api.OperatorConfig{})
+	t.Cleanup(func() {
+		chop.NewWithConfig(nil)
+	})
+
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+		},
+	}
+	shard := &api.ChiShard{Name: "1"}
+	shard.Runtime.CHI = chi
+	shard.Runtime.Address.Namespace = "ns"
+	shard.Runtime.Address.CHIName = "chi"
+	shard.Runtime.Address.ClusterName = "cluster"
+	shard.Runtime.Address.ShardName = "1"
+
+	pdb := NewCreator(chi).NewPodDisruptionBudget(shard)
+
+	// Each shard has PDB of its own
+	require.Equal(t, "chi-cluster-1", pdb.Name)
+	require.Equal(t, "ns", pdb.Namespace)
+	require.Equal(t, model.GetSelectorShardScope(shard), pdb.Spec.Selector.MatchLabels)
+	require.Equal(t, "1", pdb.Spec.Selector.MatchLabels[model.LabelShardName])
+
+	// At most one replica of the shard is disrupted at a time
+	require.Nil(t, pdb.Spec.MinAvailable)
+	require.Equal(t, 1, pdb.Spec.MaxUnavailable.IntValue())
+}
@@ -15,10 +15,14 @@
 package creator
 
 import (
+	"math"
+
 	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/chop"
 	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
 )
 
@@ -27,11 +31,6 @@ func newDefaultLivenessProbe(host *api.ChiHost) *core.Probe {
 	return newDefaultClickHouseLivenessProbe(host)
 }
 
-// newDefaultReadinessProbe is a unification wrapper
-func newDefaultReadinessProbe(host *api.ChiHost) *core.Probe {
-	return newDefaultClickHouseReadinessProbe(host)
-}
-
 // newDefaultClickHouseLivenessProbe returns default ClickHouse liveness probe
 func newDefaultClickHouseLivenessProbe(host *api.ChiHost) *core.Probe {
 	// Introduce http probe in case http port is specified
@@ -71,35 +70,125 @@ func newDefaultClickHouseLivenessProbe(host *api.ChiHost) *core.Probe {
 
 // newDefaultClickHouseReadinessProbe returns default ClickHouse readiness probe
 func newDefaultClickHouseReadinessProbe(host *api.ChiHost) *core.Probe {
-	// Introduce http probe in case http port is specified
-	if api.IsPortAssigned(host.HTTPPort) {
-		return &core.Probe{
-			ProbeHandler: core.ProbeHandler{
-				HTTPGet: &core.HTTPGetAction{
-					Path: "/ping",
-					Port: intstr.Parse(model.ChDefaultHTTPPortName), // What if port name is not a default?
-				},
-			},
-			InitialDelaySeconds: 10,
-			PeriodSeconds:       3,
-		}
+	return newClickHouseReadinessProbe(host, chop.Config().ClickHouse.Probes.Readiness)
+}
+
+// newScaledClickHouseStartupProbe returns ClickHouse startup probe scaled by the size of the host's data volume.
+// Startup probe is not set up in case its failure threshold is not specified
+func newScaledClickHouseStartupProbe(host *api.ChiHost, probes api.OperatorConfigProbes) *core.Probe {
+	if probes.Startup.FailureThreshold == 0 {
+		return nil
 	}
+	return scaleProbe(newClickHouseProbe(host, probes.Startup), host, probes.Scaling)
+}
 
-	// Introduce https probe in case https port is specified
-	if api.IsPortAssigned(host.HTTPSPort) {
-		return &core.Probe{
-			ProbeHandler: core.ProbeHandler{
-				HTTPGet: &core.HTTPGetAction{
-					Path:   "/ping",
-					Port:   intstr.Parse(model.ChDefaultHTTPSPortName), // What if port name is not a default?
-					Scheme: core.URISchemeHTTPS,
-				},
-			},
-			InitialDelaySeconds: 10,
-			PeriodSeconds:       3,
+// newClickHouseReadinessProbe returns ClickHouse readiness probe as specified by config
+func newClickHouseReadinessProbe(host *api.ChiHost, config api.OperatorConfigProbe) *core.Probe {
+	return newClickHouseProbe(host, config)
+}
+
+// newClickHouseProbe returns ClickHouse HTTP probe as specified by config
+func newClickHouseProbe(host *api.ChiHost, config api.OperatorConfigProbe) *core.Probe {
+	var handler *core.HTTPGetAction
+	switch {
+	case api.IsPortAssigned(host.HTTPPort):
+		// Introduce http probe in case http port is specified
+		handler = &core.HTTPGetAction{
+			Path: config.Path,
+			Port: intstr.Parse(model.ChDefaultHTTPPortName), // What if port name is not a default?
 		}
+	case api.IsPortAssigned(host.HTTPSPort):
+		// Introduce https probe in case https port is specified
+		handler = &core.HTTPGetAction{
+			Path:   config.Path,
+			Port:   intstr.Parse(model.ChDefaultHTTPSPortName), // What if port name is not a default?
+			Scheme: core.URISchemeHTTPS,
+		}
+	default:
+		// Probe is not available
+		return nil
 	}
 
-	// Probe is not available
-	return nil
+	return &core.Probe{
+		ProbeHandler: core.ProbeHandler{
+			HTTPGet: handler,
+		},
+		InitialDelaySeconds: config.InitialDelaySeconds,
+		PeriodSeconds:       config.PeriodSeconds,
+		TimeoutSeconds:      config.TimeoutSeconds,
+		SuccessThreshold:    config.SuccessThreshold,
+		FailureThreshold:    config.FailureThreshold,
+	}
+}
+
+// defaultProbeFailureThreshold is the failure threshold k8s applies in case it is not specified
+const defaultProbeFailureThreshold = 3
+
+// scaleProbe grows failure threshold of the probe with the size of the host's data volume,
+// since hosts with more data take longer to load it on start.
+// Only the host's own data is taken into account, so changes of the cluster do not change probes of other hosts
+func scaleProbe(probe *core.Probe, host *api.ChiHost, scaling api.OperatorConfigProbeScaling) *core.Probe {
+	if (probe == nil) || !scaling.IsEnabled() {
+		return probe
+	}
+
+	chunk, err := resource.ParseQuantity(scaling.StoragePerFailureThreshold)
+	if (err != nil) || (chunk.Value() <= 0) {
+		return probe
+	}
+	storage, ok := getHostDataStorage(host)
+	if !ok {
+		return probe
+	}
+	extra := storage.Value() / chunk.Value()
+	if extra <= 0 {
+		return probe
+	}
+
+	threshold := int64(probe.FailureThreshold)
+	if threshold == 0 {
+		threshold = defaultProbeFailureThreshold
+	}
+	threshold += extra
+	if (scaling.MaxFailureThreshold > 0) && (threshold > int64(scaling.MaxFailureThreshold)) {
+		threshold = int64(scaling.MaxFailureThreshold)
+	}
+	if threshold > math.MaxInt32 {
+		threshold = math.MaxInt32
+	}
+	probe.FailureThreshold = int32(threshold)
+
+	return probe
+}
+
+// getHostDataStorage gets storage requested by the data volume claim template of the host
+func getHostDataStorage(host *api.ChiHost) (resource.Quantity, bool) {
+	if !host.HasCHI() || !host.Templates.HasDataVolumeClaimTemplate() {
+		return resource.Quantity{}, false
+	}
+	template, ok := host.GetCHI().GetVolumeClaimTemplate(host.Templates.GetDataVolumeClaimTemplate())
+	if !ok {
+		return resource.Quantity{}, false
+	}
+	storage, ok := template.Spec.Resources.Requests[core.ResourceStorage]
+	return storage, ok
+}
+
+// applyProbeOverride applies explicit timings specified on pod template level to the probe
+func applyProbeOverride(probe *core.Probe, override *api.PodTemplateProbe) {
+	if (probe == nil) || (override == nil) {
+		return
+	}
+	if override.InitialDelaySeconds != nil {
+		probe.InitialDelaySeconds = *override.InitialDelaySeconds
+	}
+	if override.PeriodSeconds != nil {
+		probe.PeriodSeconds = *override.PeriodSeconds
+	}
+	if override.TimeoutSeconds != nil {
+		probe.TimeoutSeconds = *override.TimeoutSeconds
+	}
+	if override.FailureThreshold != nil {
+		probe.FailureThreshold = *override.FailureThreshold
+	}
 }
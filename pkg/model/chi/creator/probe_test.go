@@ -0,0 +1,51 @@
+package creator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+)
+
+func Test_NewClickHouseReadinessProbe(t *testing.T) {
+	config := api.OperatorConfigProbe{
+		Path:                "/?query=SELECT%201",
+		InitialDelaySeconds: 5,
+		PeriodSeconds:       2,
+		TimeoutSeconds:      1,
+		SuccessThreshold:    2,
+		FailureThreshold:    6,
+	}
+
+	// HTTP port
+	host := &api.ChiHost{HTTPPort: 8123, HTTPSPort: api.PortUnassigned()}
+	require.Equal(t, &core.Probe{
+		ProbeHandler: core.ProbeHandler{
+			HTTPGet: &core.HTTPGetAction{
+				Path: "/?query=SELECT%201",
+				Port: intstr.Parse(model.ChDefaultHTTPPortName),
+			},
+		},
+		InitialDelaySeconds: 5,
+		PeriodSeconds:       2,
+		TimeoutSeconds:      1,
+		SuccessThreshold:    2,
+		FailureThreshold:    6,
+	}, newClickHouseReadinessProbe(host, config))
+
+	// HTTPS port only
+	host = &api.ChiHost{HTTPPort: api.PortUnassigned(), HTTPSPort: 8443}
+	probe := newClickHouseReadinessProbe(host, config)
+	require.NotNil(t, probe)
+	require.Equal(t, core.URISchemeHTTPS, probe.HTTPGet.Scheme)
+	require.Equal(t, intstr.Parse(model.ChDefaultHTTPSPortName), probe.HTTPGet.Port)
+	require.Equal(t, "/?query=SELECT%201", probe.HTTPGet.Path)
+
+	// No HTTP port - no probe
+	host = &api.ChiHost{HTTPPort: api.PortUnassigned(), HTTPSPort: api.PortUnassigned()}
+	require.Nil(t, newClickHouseReadinessProbe(host, config))
+}
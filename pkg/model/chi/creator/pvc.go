@@ -66,10 +66,23 @@ func (c *Creator) createPVC(
 	// Overwrite .Spec.VolumeMode
 	volumeMode := core.PersistentVolumeFilesystem
 	persistentVolumeClaim.Spec.VolumeMode = &volumeMode
+	expandPVCDataSource(&persistentVolumeClaim.Spec, host)
 
 	return persistentVolumeClaim
 }
 
+// expandPVCDataSource expands macros in names of the data source PVC is provisioned from,
+// so each host is able to be cloned from its own source, such as snapshot of a replica of the same shard.
+// Data source is used by the provisioner only when PVC is created, thus it affects newly added hosts only.
+func expandPVCDataSource(spec *core.PersistentVolumeClaimSpec, host *api.ChiHost) {
+	if spec.DataSource != nil {
+		spec.DataSource.Name = model.Macro(host).Line(spec.DataSource.Name)
+	}
+	if spec.DataSourceRef != nil {
+		spec.DataSourceRef.Name = model.Macro(host).Line(spec.DataSourceRef.Name)
+	}
+}
+
 // CreatePVC creates PVC
 func (c *Creator) CreatePVC(name string, host *api.ChiHost, spec *core.PersistentVolumeClaimSpec) *core.PersistentVolumeClaim {
 	pvc := c.createPVC(name, host.Runtime.Address.Namespace, host, spec)
@@ -0,0 +1,80 @@
+package creator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+func newDataSourceTestHost(shard string) *api.ChiHost {
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+		},
+	}
+	host := &api.ChiHost{Name: shard + "-1"}
+	host.Runtime.CHI = chi
+	host.Runtime.Address = api.ChiHostAddress{
+		Namespace:   "ns",
+		CHIName:     "chi",
+		ClusterName: "cluster",
+		ShardName:   shard,
+		ReplicaName: "1",
+		HostName:    host.Name,
+	}
+	return host
+}
+
+func Test_ExpandPVCDataSource(t *testing.T) {
+	apiGroup := "snapshot.storage.k8s.io"
+	template := &api.VolumeClaimTemplate{
+		Name: "data",
+		Spec: core.PersistentVolumeClaimSpec{
+			AccessModes: []core.PersistentVolumeAccessMode{core.ReadWriteOnce},
+			Resources: core.ResourceRequirements{
+				Requests: core.ResourceList{
+					core.ResourceStorage: resource.MustParse("1Gi"),
+				},
+			},
+			DataSource: &core.TypedLocalObjectReference{
+				APIGroup: &apiGroup,
+				Kind:     "VolumeSnapshot",
+				Name:     "data-snapshot-{shard}",
+			},
+			DataSourceRef: &core.TypedObjectReference{
+				APIGroup: &apiGroup,
+				Kind:     "VolumeSnapshot",
+				Name:     "data-snapshot-{shard}",
+			},
+		},
+	}
+
+	// PVC of a new host is provisioned from the snapshot of its own shard
+	for _, shard := range []string{"0", "1"} {
+		spec := template.Spec.DeepCopy()
+		expandPVCDataSource(spec, newDataSourceTestHost(shard))
+		require.Equal(t, &core.TypedLocalObjectReference{
+			APIGroup: &apiGroup,
+			Kind:     "VolumeSnapshot",
+			Name:     "data-snapshot-" + shard,
+		}, spec.DataSource)
+		require.Equal(t, "data-snapshot-"+shard, spec.DataSourceRef.Name)
+		require.Equal(t, template.Spec.Resources, spec.Resources)
+	}
+
+	// Template itself is not modified
+	require.Equal(t, "data-snapshot-{shard}", template.Spec.DataSource.Name)
+	require.Equal(t, "data-snapshot-{shard}", template.Spec.DataSourceRef.Name)
+
+	// No data source - nothing to provision from
+	spec := &core.PersistentVolumeClaimSpec{}
+	expandPVCDataSource(spec, newDataSourceTestHost("0"))
+	require.Nil(t, spec.DataSource)
+	require.Nil(t, spec.DataSourceRef)
+}
@@ -18,6 +18,7 @@ import (
 	core "k8s.io/api/core/v1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
 	"github.com/altinity/clickhouse-operator/pkg/util"
 )
 
@@ -34,3 +35,43 @@ func (c *Creator) CreateClusterSecret(name string) *core.Secret {
 		Type: core.SecretTypeOpaque,
 	}
 }
+
+// CreateManagedUserSecret creates secret with password of the operator-managed user
+func (c *Creator) CreateManagedUserSecret() *core.Secret {
+	return &core.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: c.chi.Namespace,
+			Name:      model.CreateManagedUserSecretName(c.chi),
+		},
+		StringData: map[string]string{
+			model.ManagedUserSecretPasswordKey: util.RandStringRange(20, 30),
+		},
+		Type: core.SecretTypeOpaque,
+	}
+}
+
+// CreateGeneratedPasswordsSecret creates secret with passwords generated for the users
+func (c *Creator) CreateGeneratedPasswordsSecret(passwords map[string][]byte) *core.Secret {
+	return &core.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace:       c.chi.Namespace,
+			Name:            model.CreateGeneratedPasswordsSecretName(c.chi),
+			Labels:          model.Macro(c.chi).Map(c.labels.GetSecretCHI()),
+			OwnerReferences: getOwnerReferences(c.chi),
+		},
+		Data: passwords,
+		Type: core.SecretTypeOpaque,
+	}
+}
+
+// CreateInterserverCredentialsSecret creates secret with interserver credentials
+func (c *Creator) CreateInterserverCredentialsSecret(data map[string][]byte) *core.Secret {
+	return &core.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: c.chi.Namespace,
+			Name:      model.CreateInterserverCredentialsSecretName(c.chi),
+		},
+		Data: data,
+		Type: core.SecretTypeOpaque,
+	}
+}
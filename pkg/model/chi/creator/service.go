@@ -158,6 +158,70 @@ func (c *Creator) CreateServiceHost(host *api.ChiHost) *core.Service {
 	return svc
 }
 
+// CreateServiceHostInterserver creates new core.Service for interserver communication of specified host.
+// Returns nil in case interserver Service is not enabled for the CHI.
+func (c *Creator) CreateServiceHostInterserver(host *api.ChiHost) *core.Service {
+	if !host.GetCHI().Spec.Defaults.InterserverService.IsTrue() {
+		return nil
+	}
+
+	// Headless Service exposing interserver port only, so replicas fetch parts via stable per-host DNS name
+	svc := &core.Service{
+		ObjectMeta: meta.ObjectMeta{
+			Name:            model.CreateInterserverServiceName(host),
+			Namespace:       host.Runtime.Address.Namespace,
+			Labels:          model.Macro(host).Map(c.labels.GetServiceHostInterserver(host)),
+			Annotations:     model.Macro(host).Map(c.annotations.GetServiceHost(host)),
+			OwnerReferences: getOwnerReferences(c.chi),
+		},
+		Spec: core.ServiceSpec{
+			Selector:                 model.GetSelectorHostScope(host),
+			ClusterIP:                model.TemplateDefaultsServiceClusterIP,
+			Type:                     "ClusterIP",
+			PublishNotReadyAddresses: true,
+			Ports: []core.ServicePort{
+				{
+					Name:       model.ChDefaultInterserverHTTPPortName,
+					Protocol:   core.ProtocolTCP,
+					Port:       host.InterserverHTTPPort,
+					TargetPort: intstr.FromInt(int(host.InterserverHTTPPort)),
+				},
+			},
+		},
+	}
+	model.MakeObjectVersion(&svc.ObjectMeta, svc)
+	return svc
+}
+
+// CreateServiceSubdomain creates new headless core.Service, which governs StatefulSets of all hosts of the CHI.
+// Returns nil in case subdomain is not specified for the CHI.
+func (c *Creator) CreateServiceSubdomain() *core.Service {
+	name := model.CreateSubdomainServiceName(c.chi)
+	if name == "" {
+		return nil
+	}
+
+	// Headless Service publishing DNS records of all pods, including not ready ones,
+	// so pods are able to find each other while starting
+	svc := &core.Service{
+		ObjectMeta: meta.ObjectMeta{
+			Name:            name,
+			Namespace:       c.chi.Namespace,
+			Labels:          model.Macro(c.chi).Map(c.labels.GetServiceSubdomain()),
+			Annotations:     model.Macro(c.chi).Map(c.annotations.GetServiceCHI(c.chi)),
+			OwnerReferences: getOwnerReferences(c.chi),
+		},
+		Spec: core.ServiceSpec{
+			Selector:                 c.labels.GetSelectorCHIScope(),
+			ClusterIP:                model.TemplateDefaultsServiceClusterIP,
+			Type:                     core.ServiceTypeClusterIP,
+			PublishNotReadyAddresses: true,
+		},
+	}
+	model.MakeObjectVersion(&svc.ObjectMeta, svc)
+	return svc
+}
+
 func appendServicePorts(service *core.Service, host *api.ChiHost) {
 	// Walk over all assigned ports of the host and append each port to the list of service's ports
 	model.HostWalkAssignedPorts(
@@ -202,6 +266,14 @@ func (c *Creator) createServiceFromTemplate(
 		Spec:       *template.Spec.DeepCopy(),
 	}
 
+	// Session affinity specified on template level prevails over the one of the spec
+	if template.SessionAffinity != "" {
+		service.Spec.SessionAffinity = template.SessionAffinity
+	}
+	if template.SessionAffinityConfig != nil {
+		service.Spec.SessionAffinityConfig = template.SessionAffinityConfig.DeepCopy()
+	}
+
 	// Overwrite .name and .namespace - they are not allowed to be specified in template
 	service.Name = name
 	service.Namespace = namespace
@@ -15,6 +15,8 @@
 package creator
 
 import (
+	"strconv"
+
 	apps "k8s.io/api/apps/v1"
 	core "k8s.io/api/core/v1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -38,7 +40,7 @@ func (c *Creator) CreateStatefulSet(host *api.ChiHost, shutdown bool) *apps.Stat
 		},
 		Spec: apps.StatefulSetSpec{
 			Replicas:    host.GetStatefulSetReplicasNum(shutdown),
-			ServiceName: model.CreateStatefulSetServiceName(host),
+			ServiceName: model.CreateStatefulSetGoverningServiceName(host),
 			Selector: &meta.LabelSelector{
 				MatchLabels: model.GetSelectorHostScope(host),
 			},
@@ -53,17 +55,41 @@ func (c *Creator) CreateStatefulSet(host *api.ChiHost, shutdown bool) *apps.Stat
 			UpdateStrategy: apps.StatefulSetUpdateStrategy{
 				Type: apps.RollingUpdateStatefulSetStrategyType,
 			},
-			RevisionHistoryLimit: chop.Config().GetRevisionHistoryLimit(),
+			RevisionHistoryLimit: c.getRevisionHistoryLimit(),
 		},
 	}
 
 	c.setupStatefulSetPodTemplate(statefulSet, host)
 	c.setupStatefulSetVolumeClaimTemplates(statefulSet, host)
+	model.MakeObjectBaseVersion(&statefulSet.ObjectMeta, k8s.StatefulSetWithoutContainersResources(statefulSet))
 	model.MakeObjectVersion(&statefulSet.ObjectMeta, statefulSet)
+	// Generation is stamped after object version is calculated,
+	// so generation change alone does not make StatefulSet differ and does not roll Pods
+	if chop.Config().Annotation.AppendGeneration.IsTrue() {
+		stampReconcileGeneration(statefulSet, c.chi.GetGeneration())
+	}
 
 	return statefulSet
 }
 
+// stampReconcileGeneration annotates Pod template of the StatefulSet with generation of the CHI
+func stampReconcileGeneration(statefulSet *apps.StatefulSet, generation int64) {
+	if statefulSet.Spec.Template.Annotations == nil {
+		statefulSet.Spec.Template.Annotations = make(map[string]string)
+	}
+	statefulSet.Spec.Template.Annotations[model.AnnotationReconcileGeneration] = strconv.FormatInt(generation, 10)
+}
+
+// getRevisionHistoryLimit gets number of controller revisions StatefulSet keeps.
+// CHI-level limit prevails over the operator's default one.
+func (c *Creator) getRevisionHistoryLimit() *int32 {
+	if limit := c.chi.Spec.RevisionHistoryLimit; limit != nil {
+		revisionHistoryLimit := *limit
+		return &revisionHistoryLimit
+	}
+	return chop.Config().GetRevisionHistoryLimit()
+}
+
 // setupStatefulSetPodTemplate performs PodTemplate setup of StatefulSet
 func (c *Creator) setupStatefulSetPodTemplate(statefulSet *apps.StatefulSet, host *api.ChiHost) {
 	// Process Pod Template
@@ -73,7 +99,160 @@ func (c *Creator) setupStatefulSetPodTemplate(statefulSet *apps.StatefulSet, hos
 	// Post-process StatefulSet
 	ensureStatefulSetTemplateIntegrity(statefulSet, host)
 	setupEnvVars(statefulSet, host)
+	setupInterserverCredentials(statefulSet, host)
 	c.personalizeStatefulSetTemplate(statefulSet, host)
+	setupAdditionalVolumes(statefulSet, podTemplate)
+	setupTemplateEnvVars(statefulSet, podTemplate)
+	setupEphemeralStorage(statefulSet, podTemplate)
+	setupSecurityContext(statefulSet, podTemplate)
+	setupProbes(statefulSet, podTemplate, host, chop.Config().ClickHouse.Probes)
+	setupImagePullPolicy(statefulSet, podTemplate)
+	setupServiceAccount(statefulSet, podTemplate)
+}
+
+// setupServiceAccount applies ServiceAccount specified on pod template level to the pod
+func setupServiceAccount(statefulSet *apps.StatefulSet, template *api.PodTemplate) {
+	if template.ServiceAccountName == "" {
+		return
+	}
+	statefulSet.Spec.Template.Spec.ServiceAccountName = template.ServiceAccountName
+}
+
+// setupAdditionalVolumes appends volumes specified on pod template level to the pod
+// and mounts them into ClickHouse container
+func setupAdditionalVolumes(statefulSet *apps.StatefulSet, template *api.PodTemplate) {
+	for _, volume := range template.Volumes {
+		if k8s.StatefulSetHasVolumeByName(statefulSet, volume.Name) {
+			// Volume is already specified
+			continue
+		}
+		k8s.StatefulSetAppendVolumes(statefulSet, volume)
+	}
+
+	if container, ok := getClickHouseContainer(statefulSet); ok {
+		k8s.ContainerAppendVolumeMounts(container, template.VolumeMounts...)
+	}
+}
+
+// setupTemplateEnvVars appends env vars and env sources specified on pod template level to ClickHouse container.
+// Env vars already present in the container - specified by the operator or explicitly in the container - prevail.
+func setupTemplateEnvVars(statefulSet *apps.StatefulSet, template *api.PodTemplate) {
+	if container, ok := getClickHouseContainer(statefulSet); ok {
+		k8s.ContainerAppendEnvVars(container, template.Env...)
+		k8s.ContainerAppendEnvFrom(container, template.EnvFrom...)
+	}
+}
+
+// Names of emptyDir volumes for ClickHouse tmp/cache directories
+const (
+	volumeNameClickHouseTmp   = "clickhouse-tmp"
+	volumeNameClickHouseCache = "clickhouse-cache"
+)
+
+// setupEphemeralStorage applies ephemeral-storage request/limit specified on pod template level to ClickHouse container
+// and mounts emptyDir volumes at ClickHouse tmp/cache paths
+func setupEphemeralStorage(statefulSet *apps.StatefulSet, template *api.PodTemplate) {
+	ephemeralStorage := template.EphemeralStorage
+	if ephemeralStorage == nil {
+		return
+	}
+
+	container, ok := getClickHouseContainer(statefulSet)
+	if !ok {
+		return
+	}
+
+	if ephemeralStorage.Request != nil {
+		if container.Resources.Requests == nil {
+			container.Resources.Requests = core.ResourceList{}
+		}
+		container.Resources.Requests[core.ResourceEphemeralStorage] = *ephemeralStorage.Request
+	}
+	if ephemeralStorage.Limit != nil {
+		if container.Resources.Limits == nil {
+			container.Resources.Limits = core.ResourceList{}
+		}
+		container.Resources.Limits[core.ResourceEphemeralStorage] = *ephemeralStorage.Limit
+	}
+
+	setupEmptyDirVolume(statefulSet, container, volumeNameClickHouseTmp, model.DirPathClickHouseTmp, ephemeralStorage.Tmp)
+	setupEmptyDirVolume(statefulSet, container, volumeNameClickHouseCache, model.DirPathClickHouseCache, ephemeralStorage.Cache)
+}
+
+// setupEmptyDirVolume appends emptyDir volume to the pod and mounts it into the container
+func setupEmptyDirVolume(
+	statefulSet *apps.StatefulSet,
+	container *core.Container,
+	name string,
+	mountPath string,
+	emptyDir *core.EmptyDirVolumeSource,
+) {
+	if emptyDir == nil {
+		return
+	}
+	if k8s.StatefulSetHasVolumeByName(statefulSet, name) {
+		// Volume is already specified
+		return
+	}
+	k8s.StatefulSetAppendVolumes(statefulSet, newVolumeForEmptyDir(name, emptyDir))
+	k8s.ContainerAppendVolumeMount(container, newVolumeMount(name, mountPath))
+}
+
+// setupSecurityContext applies security context specified on pod template level to the pod and ClickHouse container
+func setupSecurityContext(statefulSet *apps.StatefulSet, template *api.PodTemplate) {
+	securityContext := template.SecurityContext
+	if securityContext == nil {
+		return
+	}
+
+	if securityContext.Pod != nil {
+		statefulSet.Spec.Template.Spec.SecurityContext = securityContext.Pod.DeepCopy()
+	}
+	if securityContext.Container != nil {
+		if container, ok := getClickHouseContainer(statefulSet); ok {
+			container.SecurityContext = securityContext.Container.DeepCopy()
+		}
+	}
+}
+
+// setupProbes applies explicit probe timings specified on pod template level to the ClickHouse container.
+// Explicit timings win over the ones computed by the operator as well as over the ones specified in pod spec
+func setupProbes(statefulSet *apps.StatefulSet, template *api.PodTemplate, host *api.ChiHost, config api.OperatorConfigProbes) {
+	probes := template.Probes
+	if probes == nil {
+		return
+	}
+	container, ok := getClickHouseContainer(statefulSet)
+	if !ok {
+		return
+	}
+
+	applyProbeOverride(container.ReadinessProbe, probes.Readiness)
+
+	if (probes.Startup != nil) && (container.StartupProbe == nil) {
+		// Startup probe is requested explicitly
+		container.StartupProbe = newClickHouseProbe(host, config.Startup)
+	}
+	applyProbeOverride(container.StartupProbe, probes.Startup)
+}
+
+// setupImagePullPolicy applies image pull policy specified on pod template level to all containers,
+// including the ones generated by the operator, which do not specify own policy
+func setupImagePullPolicy(statefulSet *apps.StatefulSet, template *api.PodTemplate) {
+	if template.ImagePullPolicy == "" {
+		return
+	}
+	spec := &statefulSet.Spec.Template.Spec
+	for i := range spec.InitContainers {
+		if spec.InitContainers[i].ImagePullPolicy == "" {
+			spec.InitContainers[i].ImagePullPolicy = template.ImagePullPolicy
+		}
+	}
+	for i := range spec.Containers {
+		if spec.Containers[i].ImagePullPolicy == "" {
+			spec.Containers[i].ImagePullPolicy = template.ImagePullPolicy
+		}
+	}
 }
 
 // ensureStatefulSetTemplateIntegrity
@@ -93,6 +272,49 @@ func setupEnvVars(statefulSet *apps.StatefulSet, host *api.ChiHost) {
 	container.Env = append(container.Env, host.GetCHI().EnsureRuntime().GetAttributes().AdditionalEnvVars...)
 }
 
+// setupInterserverCredentials passes interserver credentials of both slots into clickhouse container via ENV vars.
+// Pod template is annotated with revision of the credentials, so hosts are rolled on rotation,
+// since ENV vars are read on start only
+func setupInterserverCredentials(statefulSet *apps.StatefulSet, host *api.ChiHost) {
+	state := host.GetCHI().EnsureRuntime().GetInterserverCredentials()
+	if state == nil {
+		return
+	}
+	container, ok := getMainContainer(statefulSet)
+	if !ok {
+		return
+	}
+
+	secretName := model.CreateInterserverCredentialsSecretName(host.GetCHI())
+	// Slot may be empty, ex.: credentials were never rotated
+	optional := true
+	newEnvVar := func(name, key string) core.EnvVar {
+		return core.EnvVar{
+			Name: name,
+			ValueFrom: &core.EnvVarSource{
+				SecretKeyRef: &core.SecretKeySelector{
+					LocalObjectReference: core.LocalObjectReference{
+						Name: secretName,
+					},
+					Key:      key,
+					Optional: &optional,
+				},
+			},
+		}
+	}
+	for _, slot := range []string{model.InterserverCredentialsSlot0, model.InterserverCredentialsSlot1} {
+		container.Env = append(container.Env,
+			newEnvVar(model.InterserverUsernameEnvNamePrefix+slot, model.InterserverCredentialsUsernameKeyPrefix+slot),
+			newEnvVar(model.InterserverPasswordEnvNamePrefix+slot, model.InterserverCredentialsPasswordKeyPrefix+slot),
+		)
+	}
+
+	if statefulSet.Spec.Template.Annotations == nil {
+		statefulSet.Spec.Template.Annotations = make(map[string]string)
+	}
+	statefulSet.Spec.Template.Annotations[model.AnnotationInterserverCredentialsRevision] = state.Revision
+}
+
 // ensureMainContainerSpecified is a unification wrapper
 func ensureMainContainerSpecified(statefulSet *apps.StatefulSet, host *api.ChiHost) {
 	ensureClickHouseContainerSpecified(statefulSet, host)
@@ -134,6 +356,11 @@ func ensureClickHouseLogContainerSpecified(statefulSet *apps.StatefulSet) {
 
 // ensureProbesSpecified
 func ensureProbesSpecified(statefulSet *apps.StatefulSet, host *api.ChiHost) {
+	ensureProbes(statefulSet, host, chop.Config().ClickHouse.Probes)
+}
+
+// ensureProbes sets up probes of the main container, which are not specified explicitly
+func ensureProbes(statefulSet *apps.StatefulSet, host *api.ChiHost, config api.OperatorConfigProbes) {
 	container, ok := getMainContainer(statefulSet)
 	if !ok {
 		return
@@ -142,7 +369,10 @@ func ensureProbesSpecified(statefulSet *apps.StatefulSet, host *api.ChiHost) {
 		container.LivenessProbe = newDefaultLivenessProbe(host)
 	}
 	if container.ReadinessProbe == nil {
-		container.ReadinessProbe = newDefaultReadinessProbe(host)
+		container.ReadinessProbe = newClickHouseReadinessProbe(host, config.Readiness)
+	}
+	if container.StartupProbe == nil {
+		container.StartupProbe = newScaledClickHouseStartupProbe(host, config)
 	}
 }
 
@@ -237,6 +467,7 @@ func (c *Creator) getPodTemplate(host *api.ChiHost) *api.PodTemplate {
 func (c *Creator) statefulSetSetupVolumes(statefulSet *apps.StatefulSet, host *api.ChiHost) {
 	c.statefulSetSetupVolumesForConfigMaps(statefulSet, host)
 	c.statefulSetSetupVolumesForSecrets(statefulSet, host)
+	c.statefulSetSetupVolumesForCertificate(statefulSet, host)
 }
 
 // statefulSetSetupVolumesForConfigMaps adds to each container in the Pod VolumeMount objects
@@ -280,6 +511,26 @@ func (c *Creator) statefulSetSetupVolumesForSecrets(statefulSet *apps.StatefulSe
 	)
 }
 
+// statefulSetSetupVolumesForCertificate mounts Secret with certificate issued by cert-manager
+func (c *Creator) statefulSetSetupVolumesForCertificate(statefulSet *apps.StatefulSet, host *api.ChiHost) {
+	if !host.GetCHI().GetCertManager().IsEnabled() {
+		return
+	}
+
+	k8s.StatefulSetAppendVolumes(
+		statefulSet,
+		newVolumeForSecret(certificateVolumeName, model.CreateCertificateName(host)),
+	)
+	k8s.StatefulSetAppendVolumeMounts(
+		statefulSet,
+		core.VolumeMount{
+			Name:      certificateVolumeName,
+			MountPath: model.DirPathCertificates,
+			ReadOnly:  true,
+		},
+	)
+}
+
 // statefulSetAppendUsedPVCTemplates appends all PVC templates which are used (referenced by name) by containers
 // to the StatefulSet.Spec.VolumeClaimTemplates list
 func (c *Creator) statefulSetAppendUsedPVCTemplates(statefulSet *apps.StatefulSet, host *api.ChiHost) {
@@ -320,9 +571,29 @@ func (c *Creator) statefulSetAppendVolumeMountsForDataAndLogVolumeClaimTemplates
 	}
 }
 
+// statefulSetAppendVolumeMountsForStorageDisks appends VolumeMounts for VolumeClaimTemplates
+// backing additional storage disks on ClickHouse container
+func (c *Creator) statefulSetAppendVolumeMountsForStorageDisks(statefulSet *apps.StatefulSet, host *api.ChiHost) {
+	container, ok := getClickHouseContainer(statefulSet)
+	if !ok {
+		return
+	}
+	for _, disk := range host.GetCHI().Spec.Configuration.Storage.GetDisks() {
+		if _, ok := host.GetCHI().GetVolumeClaimTemplate(disk.VolumeClaimTemplate); !ok {
+			// Disk refers to unknown VolumeClaimTemplate, nothing to mount
+			continue
+		}
+		k8s.ContainerAppendVolumeMounts(
+			container,
+			newVolumeMount(disk.VolumeClaimTemplate, model.CreateStorageDiskPath(disk)),
+		)
+	}
+}
+
 // setupStatefulSetVolumeClaimTemplates performs VolumeClaimTemplate setup for Containers in PodTemplate of a StatefulSet
 func (c *Creator) setupStatefulSetVolumeClaimTemplates(statefulSet *apps.StatefulSet, host *api.ChiHost) {
 	c.statefulSetAppendVolumeMountsForDataAndLogVolumeClaimTemplates(statefulSet, host)
+	c.statefulSetAppendVolumeMountsForStorageDisks(statefulSet, host)
 	c.statefulSetAppendUsedPVCTemplates(statefulSet, host)
 }
 
@@ -352,6 +623,9 @@ func (c *Creator) statefulSetApplyPodTemplate(
 	if statefulSet.Spec.Template.Spec.TerminationGracePeriodSeconds == nil {
 		statefulSet.Spec.Template.Spec.TerminationGracePeriodSeconds = chop.Config().GetTerminationGracePeriod()
 	}
+
+	// PriorityClassName may be overridden on cluster level
+	statefulSet.Spec.Template.Spec.PriorityClassName = host.GetPriorityClassName()
 }
 
 // getMainContainer is a unification wrapper
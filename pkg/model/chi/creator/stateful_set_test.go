@@ -0,0 +1,687 @@
+package creator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/chop"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	"github.com/altinity/clickhouse-operator/pkg/model/k8s"
+)
+
+// newStatefulSetTestHost builds the only host of the CHI, which uses specified pod template
+func newStatefulSetTestHost(template *api.PodTemplate) *api.ChiHost {
+	host := newDataSourceTestHost("0")
+	host.Templates = &api.ChiTemplateNames{PodTemplate: template.Name}
+	chi := host.GetCHI()
+	chi.Spec.Templates = &api.Templates{}
+	chi.Spec.Templates.EnsurePodTemplatesIndex().Set(template.Name, template)
+	chi.Spec.Configuration = &api.Configuration{
+		Clusters: []*api.Cluster{
+			{
+				Name: "cluster",
+				Layout: &api.ChiClusterLayout{
+					Shards: []api.ChiShard{{Name: "0", Hosts: []*api.ChiHost{host}}},
+				},
+			},
+		},
+	}
+	return host
+}
+
+// newTestStatefulSet creates StatefulSet of the host by Creator
+func newTestStatefulSet(t *testing.T, host *api.ChiHost) *apps.StatefulSet {
+	return newTestStatefulSetWithConfig(t, host, &api.OperatorConfig{})
+}
+
+// newTestStatefulSetWithConfig creates StatefulSet of the host by Creator of the operator running with specified config
+func newTestStatefulSetWithConfig(t *testing.T, host *api.ChiHost, config *api.OperatorConfig) *apps.StatefulSet {
+	chop.NewWithConfig(config)
+	t.Cleanup(func() {
+		chop.NewWithConfig(nil)
+	})
+	return NewCreator(host.GetCHI()).CreateStatefulSet(host, false)
+}
+
+func Test_CreateStatefulSet_Subdomain(t *testing.T) {
+	host := newStatefulSetTestHost(newStatefulSetTestPodTemplate())
+	base := newTestStatefulSet(t, host)
+	require.Equal(t, "chi-chi-cluster-0-1", base.Spec.ServiceName)
+
+	// Subdomain Service governs StatefulSet.
+	// serviceName is immutable, so StatefulSet differs and is to be recreated
+	host.GetCHI().Spec.Defaults = &api.ChiDefaults{Subdomain: "sub"}
+	statefulSet := newTestStatefulSet(t, host)
+	require.Equal(t, "chi-chi-sub", statefulSet.Spec.ServiceName)
+	require.Equal(t, NewCreator(host.GetCHI()).CreateServiceSubdomain().Name, statefulSet.Spec.ServiceName)
+	require.False(t, model.IsObjectTheSame(&base.ObjectMeta, &statefulSet.ObjectMeta))
+}
+
+// newStatefulSetTestPodTemplate builds pod template having init and sidecar containers along with ClickHouse one
+func newStatefulSetTestPodTemplate() *api.PodTemplate {
+	return &api.PodTemplate{
+		Name: "pod",
+		Spec: core.PodSpec{
+			InitContainers: []core.Container{
+				{Name: "init"},
+			},
+			Containers: []core.Container{
+				{Name: model.ClickHouseContainerName},
+				{Name: "sidecar", ImagePullPolicy: core.PullNever},
+			},
+		},
+	}
+}
+
+func Test_SetupImagePullPolicy(t *testing.T) {
+	template := newStatefulSetTestPodTemplate()
+	template.ImagePullPolicy = core.PullAlways
+	template.Spec.ImagePullSecrets = []core.LocalObjectReference{{Name: "registry"}}
+	host := newStatefulSetTestHost(template)
+	// Log volume makes the operator generate log container
+	host.Templates.LogVolumeClaimTemplate = "log"
+
+	spec := newTestStatefulSet(t, host).Spec.Template.Spec
+
+	require.Equal(t, []core.LocalObjectReference{{Name: "registry"}}, spec.ImagePullSecrets)
+	require.Equal(t, core.PullAlways, spec.InitContainers[0].ImagePullPolicy)
+	require.Len(t, spec.Containers, 3)
+	for _, container := range spec.Containers {
+		switch container.Name {
+		case "sidecar":
+			// Explicitly specified container policy is kept
+			require.Equal(t, core.PullNever, container.ImagePullPolicy)
+		default:
+			// Including operator-generated log container
+			require.Equal(t, core.PullAlways, container.ImagePullPolicy, container.Name)
+		}
+	}
+
+	// No template-level policy - containers are left as is
+	spec = newTestStatefulSet(t, newStatefulSetTestHost(newStatefulSetTestPodTemplate())).Spec.Template.Spec
+	require.Equal(t, core.PullPolicy(""), spec.Containers[0].ImagePullPolicy)
+}
+
+func Test_ImagePullChangeRollsStatefulSet(t *testing.T) {
+	base := newTestStatefulSet(t, newStatefulSetTestHost(newStatefulSetTestPodTemplate()))
+
+	policy := newStatefulSetTestPodTemplate()
+	policy.ImagePullPolicy = core.PullIfNotPresent
+	require.False(t, model.IsObjectTheSame(&base.ObjectMeta, &newTestStatefulSet(t, newStatefulSetTestHost(policy)).ObjectMeta))
+
+	secrets := newStatefulSetTestPodTemplate()
+	secrets.Spec.ImagePullSecrets = []core.LocalObjectReference{{Name: "registry"}}
+	require.False(t, model.IsObjectTheSame(&base.ObjectMeta, &newTestStatefulSet(t, newStatefulSetTestHost(secrets)).ObjectMeta))
+
+	same := newStatefulSetTestHost(newStatefulSetTestPodTemplate())
+	require.True(t, model.IsObjectTheSame(&base.ObjectMeta, &newTestStatefulSet(t, same).ObjectMeta))
+}
+
+func newVolumesTestPodTemplate() *api.PodTemplate {
+	template := newStatefulSetTestPodTemplate()
+	template.Volumes = []core.Volume{
+		{
+			Name: "dictionaries",
+			VolumeSource: core.VolumeSource{
+				ConfigMap: &core.ConfigMapVolumeSource{
+					LocalObjectReference: core.LocalObjectReference{Name: "dictionaries"},
+				},
+			},
+		},
+	}
+	template.VolumeMounts = []core.VolumeMount{
+		{Name: "dictionaries", MountPath: "/etc/clickhouse-server/dictionaries.d"},
+	}
+	return template
+}
+
+func Test_SetupAdditionalVolumes(t *testing.T) {
+	template := newVolumesTestPodTemplate()
+	// Volume specified in pod spec as well is not duplicated
+	template.Spec.Volumes = append(template.Spec.Volumes, template.Volumes[0])
+	template.Volumes = append(template.Volumes, core.Volume{
+		Name: "geoip",
+		VolumeSource: core.VolumeSource{
+			Secret: &core.SecretVolumeSource{SecretName: "geoip"},
+		},
+	})
+	template.VolumeMounts = append(template.VolumeMounts, core.VolumeMount{Name: "geoip", MountPath: "/opt/geoip"})
+
+	spec := newTestStatefulSet(t, newStatefulSetTestHost(template)).Spec.Template.Spec
+
+	volumes := map[string]int{}
+	for _, volume := range spec.Volumes {
+		volumes[volume.Name]++
+	}
+	require.Equal(t, 1, volumes["dictionaries"])
+	require.Equal(t, 1, volumes["geoip"])
+	for _, container := range spec.Containers {
+		for _, volumeMount := range template.VolumeMounts {
+			switch container.Name {
+			case model.ClickHouseContainerName:
+				require.Contains(t, container.VolumeMounts, volumeMount)
+			default:
+				// Volumes are mounted into ClickHouse container only
+				require.NotContains(t, container.VolumeMounts, volumeMount, container.Name)
+			}
+		}
+	}
+}
+
+func Test_AdditionalVolumesChangeRollsStatefulSet(t *testing.T) {
+	base := newTestStatefulSet(t, newStatefulSetTestHost(newVolumesTestPodTemplate()))
+
+	volume := newVolumesTestPodTemplate()
+	volume.Volumes[0].ConfigMap.Name = "dictionaries-v2"
+	require.False(t, model.IsObjectTheSame(&base.ObjectMeta, &newTestStatefulSet(t, newStatefulSetTestHost(volume)).ObjectMeta))
+
+	mount := newVolumesTestPodTemplate()
+	mount.VolumeMounts[0].MountPath = "/etc/clickhouse-server/config.d/dictionaries"
+	require.False(t, model.IsObjectTheSame(&base.ObjectMeta, &newTestStatefulSet(t, newStatefulSetTestHost(mount)).ObjectMeta))
+
+	same := newStatefulSetTestHost(newVolumesTestPodTemplate())
+	require.True(t, model.IsObjectTheSame(&base.ObjectMeta, &newTestStatefulSet(t, same).ObjectMeta))
+}
+
+func Test_SetupServiceAccount(t *testing.T) {
+	// Pod spec ServiceAccount is kept as is
+	template := newStatefulSetTestPodTemplate()
+	template.Spec.ServiceAccountName = "spec-sa"
+	require.Equal(t, "spec-sa", newTestStatefulSet(t, newStatefulSetTestHost(template)).Spec.Template.Spec.ServiceAccountName)
+
+	// Pod template ServiceAccount lands on the pod spec
+	template.ServiceAccountName = "clickhouse-s3"
+	statefulSet := newTestStatefulSet(t, newStatefulSetTestHost(template))
+	require.Equal(t, "clickhouse-s3", statefulSet.Spec.Template.Spec.ServiceAccountName)
+
+	// Change of ServiceAccount rolls the StatefulSet
+	changed := template.DeepCopy()
+	changed.ServiceAccountName = "clickhouse-gcs"
+	require.False(t, model.IsObjectTheSame(&statefulSet.ObjectMeta, &newTestStatefulSet(t, newStatefulSetTestHost(changed)).ObjectMeta))
+}
+
+func newEphemeralStorageTestPodTemplate() *api.PodTemplate {
+	template := newStatefulSetTestPodTemplate()
+	request := resource.MustParse("10Gi")
+	limit := resource.MustParse("20Gi")
+	tmpSizeLimit := resource.MustParse("8Gi")
+	template.EphemeralStorage = &api.PodTemplateEphemeralStorage{
+		Request: &request,
+		Limit:   &limit,
+		Tmp: &core.EmptyDirVolumeSource{
+			SizeLimit: &tmpSizeLimit,
+		},
+		Cache: &core.EmptyDirVolumeSource{
+			Medium: core.StorageMediumMemory,
+		},
+	}
+	return template
+}
+
+// getStatefulSetTestVolume gets volume of the pod by name
+func getStatefulSetTestVolume(t *testing.T, spec core.PodSpec, name string) core.Volume {
+	for _, volume := range spec.Volumes {
+		if volume.Name == name {
+			return volume
+		}
+	}
+	require.Fail(t, "no volume", name)
+	return core.Volume{}
+}
+
+func Test_SetupEphemeralStorage(t *testing.T) {
+	template := newEphemeralStorageTestPodTemplate()
+	spec := newTestStatefulSet(t, newStatefulSetTestHost(template)).Spec.Template.Spec
+
+	tmp := getStatefulSetTestVolume(t, spec, volumeNameClickHouseTmp)
+	require.Equal(t, template.EphemeralStorage.Tmp, tmp.EmptyDir)
+	require.Equal(t, "8Gi", tmp.EmptyDir.SizeLimit.String())
+	require.Equal(t, core.StorageMediumMemory, getStatefulSetTestVolume(t, spec, volumeNameClickHouseCache).EmptyDir.Medium)
+
+	volumeMounts := []core.VolumeMount{
+		{Name: volumeNameClickHouseTmp, MountPath: model.DirPathClickHouseTmp},
+		{Name: volumeNameClickHouseCache, MountPath: model.DirPathClickHouseCache},
+	}
+	for _, container := range spec.Containers {
+		switch container.Name {
+		case model.ClickHouseContainerName:
+			require.Equal(t, "10Gi", container.Resources.Requests.StorageEphemeral().String())
+			require.Equal(t, "20Gi", container.Resources.Limits.StorageEphemeral().String())
+			require.Subset(t, container.VolumeMounts, volumeMounts)
+		default:
+			// Only ClickHouse container is affected
+			require.Empty(t, container.Resources, container.Name)
+			for _, volumeMount := range volumeMounts {
+				require.NotContains(t, container.VolumeMounts, volumeMount, container.Name)
+			}
+		}
+	}
+
+	// No ephemeral storage specified - nothing to set up
+	spec = newTestStatefulSet(t, newStatefulSetTestHost(newStatefulSetTestPodTemplate())).Spec.Template.Spec
+	for _, volume := range spec.Volumes {
+		require.NotContains(t, []string{volumeNameClickHouseTmp, volumeNameClickHouseCache}, volume.Name)
+	}
+}
+
+func Test_EphemeralStorageChangeRollsStatefulSet(t *testing.T) {
+	base := newTestStatefulSet(t, newStatefulSetTestHost(newEphemeralStorageTestPodTemplate()))
+
+	// ephemeral-storage can not be resized in-place, so StatefulSet is rolled
+	request := newEphemeralStorageTestPodTemplate()
+	*request.EphemeralStorage.Request = resource.MustParse("15Gi")
+	changed := newTestStatefulSet(t, newStatefulSetTestHost(request))
+	require.False(t, model.IsObjectTheSame(&base.ObjectMeta, &changed.ObjectMeta))
+	require.False(t, model.IsObjectResourcesChangedOnly(&base.ObjectMeta, &changed.ObjectMeta))
+
+	sizeLimit := newEphemeralStorageTestPodTemplate()
+	*sizeLimit.EphemeralStorage.Tmp.SizeLimit = resource.MustParse("16Gi")
+	require.False(t, model.IsObjectTheSame(&base.ObjectMeta, &newTestStatefulSet(t, newStatefulSetTestHost(sizeLimit)).ObjectMeta))
+
+	same := newStatefulSetTestHost(newEphemeralStorageTestPodTemplate())
+	require.True(t, model.IsObjectTheSame(&base.ObjectMeta, &newTestStatefulSet(t, same).ObjectMeta))
+}
+
+func Test_CreateStatefulSet_RevisionHistoryLimit(t *testing.T) {
+	config := &api.OperatorConfig{}
+	config.StatefulSet.RevisionHistoryLimit = 10
+	host := newStatefulSetTestHost(newStatefulSetTestPodTemplate())
+
+	// Operator's default limit is used in case CHI does not specify one
+	base := newTestStatefulSetWithConfig(t, host, config)
+	require.Equal(t, int32(10), *base.Spec.RevisionHistoryLimit)
+
+	// CHI-level limit prevails
+	limit := int32(3)
+	host.GetCHI().Spec.RevisionHistoryLimit = &limit
+	statefulSet := newTestStatefulSetWithConfig(t, host, config)
+	require.Equal(t, int32(3), *statefulSet.Spec.RevisionHistoryLimit)
+
+	// StatefulSet does not share the value with CHI
+	*statefulSet.Spec.RevisionHistoryLimit = 5
+	require.Equal(t, int32(3), limit)
+
+	// Limit is applied in-place, pods are not rolled
+	require.Equal(t, base.Spec.Template, statefulSet.Spec.Template)
+}
+
+func Test_CreateStatefulSet_HostNetworkDNS(t *testing.T) {
+	template := newStatefulSetTestPodTemplate()
+	template.Spec.HostNetwork = true
+	template.Spec.DNSPolicy = core.DNSNone
+	template.Spec.DNSConfig = &core.PodDNSConfig{
+		Nameservers: []string{"10.0.0.10"},
+		Searches:    []string{"dc1.example.com"},
+	}
+	base := newTestStatefulSet(t, newStatefulSetTestHost(template))
+
+	// Pod network settings of the template reach the pod as is
+	require.True(t, base.Spec.Template.Spec.HostNetwork)
+	require.Equal(t, core.DNSNone, base.Spec.Template.Spec.DNSPolicy)
+	require.Equal(t, template.Spec.DNSConfig, base.Spec.Template.Spec.DNSConfig)
+
+	// Change of resolver rolls StatefulSet
+	resolver := newStatefulSetTestPodTemplate()
+	resolver.Spec.HostNetwork = true
+	resolver.Spec.DNSPolicy = core.DNSClusterFirstWithHostNet
+	changed := newTestStatefulSet(t, newStatefulSetTestHost(resolver))
+	require.True(t, changed.Spec.Template.Spec.HostNetwork)
+	require.Equal(t, core.DNSClusterFirstWithHostNet, changed.Spec.Template.Spec.DNSPolicy)
+	require.Nil(t, changed.Spec.Template.Spec.DNSConfig)
+	require.False(t, model.IsObjectTheSame(&base.ObjectMeta, &changed.ObjectMeta))
+}
+
+func Test_StampReconcileGeneration(t *testing.T) {
+	config := &api.OperatorConfig{}
+	config.Annotation.AppendGeneration = api.NewStringBool(true)
+	template := newVolumesTestPodTemplate()
+	template.ObjectMeta.Annotations = map[string]string{"custom": "value"}
+	host := newStatefulSetTestHost(template)
+
+	// Annotation reflects current generation of the CHI
+	host.GetCHI().Generation = 1
+	first := newTestStatefulSetWithConfig(t, host, config)
+	require.Equal(t, "1", first.Spec.Template.Annotations[model.AnnotationReconcileGeneration])
+	// Other Pod template annotations are kept
+	require.Equal(t, "value", first.Spec.Template.Annotations["custom"])
+
+	host.GetCHI().Generation = 2
+	second := newTestStatefulSetWithConfig(t, host, config)
+	require.Equal(t, "2", second.Spec.Template.Annotations[model.AnnotationReconcileGeneration])
+
+	// Generation change alone does not make StatefulSet differ, so Pods are not rolled
+	require.True(t, model.IsObjectTheSame(&first.ObjectMeta, &second.ObjectMeta))
+}
+
+func Test_StorageDisksVolumeClaimTemplates(t *testing.T) {
+	host := newStatefulSetTestHost(newStatefulSetTestPodTemplate())
+	host.Templates.DataVolumeClaimTemplate = "data"
+	chi := host.GetCHI()
+	for _, name := range []string{"data", "cold-storage"} {
+		template := &api.VolumeClaimTemplate{Name: name}
+		template.PVCProvisioner = api.PVCProvisionerOperator
+		chi.Spec.Templates.EnsureVolumeClaimTemplatesIndex().Set(name, template)
+	}
+	chi.Spec.Configuration.Storage = &api.ChiStorageConfiguration{
+		Disks: []*api.ChiStorageDisk{
+			{Name: "cold", VolumeClaimTemplate: "cold-storage"},
+			// Unknown VolumeClaimTemplate is not mounted
+			{Name: "archive", VolumeClaimTemplate: "unknown"},
+		},
+	}
+
+	statefulSet := newTestStatefulSet(t, host)
+
+	container, ok := getClickHouseContainer(statefulSet)
+	require.True(t, ok)
+	require.Contains(t, container.VolumeMounts, core.VolumeMount{Name: "data", MountPath: model.DirPathClickHouseData})
+	require.Contains(t, container.VolumeMounts, core.VolumeMount{Name: "cold-storage", MountPath: "/var/lib/clickhouse-disks/cold"})
+	for _, volumeMount := range container.VolumeMounts {
+		require.NotEqual(t, "unknown", volumeMount.Name)
+	}
+
+	// Each disk has its own PVC, which is reconciled independently
+	host.Runtime.DesiredStatefulSet = statefulSet
+	pvcs := map[string]bool{}
+	host.WalkVolumeMounts(api.DesiredStatefulSet, func(volumeMount *core.VolumeMount) {
+		if name, ok := model.CreatePVCNameByVolumeMount(host, volumeMount); ok {
+			pvcs[name] = true
+		}
+	})
+	require.Equal(t, map[string]bool{
+		"data-chi-chi-cluster-0-1-0":         true,
+		"cold-storage-chi-chi-cluster-0-1-0": true,
+	}, pvcs)
+	require.True(t, k8s.StatefulSetHasVolumeByName(statefulSet, "data"))
+	require.True(t, k8s.StatefulSetHasVolumeByName(statefulSet, "cold-storage"))
+}
+
+func newEnvTestPodTemplate() *api.PodTemplate {
+	template := newStatefulSetTestPodTemplate()
+	template.Spec.Containers[0].Env = []core.EnvVar{
+		{Name: "TZ", Value: "UTC"},
+	}
+	template.Env = []core.EnvVar{
+		{Name: "TZ", Value: "Europe/Berlin"},
+		{Name: "CLICKHOUSE_PASSWORD", Value: "overridden"},
+		{
+			Name: "AWS_ACCESS_KEY_ID",
+			ValueFrom: &core.EnvVarSource{
+				SecretKeyRef: &core.SecretKeySelector{
+					LocalObjectReference: core.LocalObjectReference{Name: "s3-credentials"},
+					Key:                  "access-key-id",
+				},
+			},
+		},
+	}
+	template.EnvFrom = []core.EnvFromSource{
+		{ConfigMapRef: &core.ConfigMapEnvSource{LocalObjectReference: core.LocalObjectReference{Name: "settings"}}},
+		{ConfigMapRef: &core.ConfigMapEnvSource{LocalObjectReference: core.LocalObjectReference{Name: "settings"}}},
+	}
+	return template
+}
+
+func Test_SetupTemplateEnvVars(t *testing.T) {
+	template := newEnvTestPodTemplate()
+	host := newStatefulSetTestHost(template)
+	// Env var injected by the operator
+	operatorEnvVar := core.EnvVar{Name: "CLICKHOUSE_PASSWORD", Value: "operator"}
+	host.GetCHI().EnsureRuntime().GetAttributes().AdditionalEnvVars = []core.EnvVar{operatorEnvVar}
+	spec := newTestStatefulSet(t, host).Spec.Template.Spec
+
+	for _, container := range spec.Containers {
+		switch container.Name {
+		case model.ClickHouseContainerName:
+			// Env vars specified explicitly in the container and injected by the operator prevail
+			require.Equal(t, []core.EnvVar{
+				{Name: "TZ", Value: "UTC"},
+				operatorEnvVar,
+				template.Env[2],
+			}, container.Env)
+			// Duplicate env source is not appended twice
+			require.Equal(t, template.EnvFrom[:1], container.EnvFrom)
+		default:
+			// Env is set up for ClickHouse container only
+			require.Empty(t, container.Env, container.Name)
+			require.Empty(t, container.EnvFrom, container.Name)
+		}
+	}
+}
+
+func Test_EnvChangeRollsStatefulSet(t *testing.T) {
+	base := newTestStatefulSet(t, newStatefulSetTestHost(newEnvTestPodTemplate()))
+
+	env := newEnvTestPodTemplate()
+	env.Env[2].ValueFrom.SecretKeyRef.Name = "s3-credentials-v2"
+	require.False(t, model.IsObjectTheSame(&base.ObjectMeta, &newTestStatefulSet(t, newStatefulSetTestHost(env)).ObjectMeta))
+
+	envFrom := newEnvTestPodTemplate()
+	envFrom.EnvFrom = append(envFrom.EnvFrom, core.EnvFromSource{
+		SecretRef: &core.SecretEnvSource{LocalObjectReference: core.LocalObjectReference{Name: "credentials"}},
+	})
+	require.False(t, model.IsObjectTheSame(&base.ObjectMeta, &newTestStatefulSet(t, newStatefulSetTestHost(envFrom)).ObjectMeta))
+
+	same := newStatefulSetTestHost(newEnvTestPodTemplate())
+	require.True(t, model.IsObjectTheSame(&base.ObjectMeta, &newTestStatefulSet(t, same).ObjectMeta))
+}
+
+func newSecurityContextTestPodTemplate() *api.PodTemplate {
+	template := newStatefulSetTestPodTemplate()
+	user := int64(101)
+	fsGroupChangePolicy := core.FSGroupChangeOnRootMismatch
+	template.SecurityContext = &api.PodTemplateSecurityContext{
+		Pod: &core.PodSecurityContext{
+			RunAsUser:           &user,
+			FSGroup:             &user,
+			FSGroupChangePolicy: &fsGroupChangePolicy,
+		},
+		Container: &core.SecurityContext{
+			Capabilities: &core.Capabilities{
+				Add: []core.Capability{"IPC_LOCK"},
+			},
+		},
+	}
+	return template
+}
+
+func Test_SetupSecurityContext(t *testing.T) {
+	template := newSecurityContextTestPodTemplate()
+	// Security context of pod template level prevails over the one of the pod spec
+	root := int64(0)
+	template.Spec.SecurityContext = &core.PodSecurityContext{RunAsUser: &root}
+
+	spec := newTestStatefulSet(t, newStatefulSetTestHost(template)).Spec.Template.Spec
+
+	require.Equal(t, template.SecurityContext.Pod, spec.SecurityContext)
+	for _, container := range spec.Containers {
+		switch container.Name {
+		case model.ClickHouseContainerName:
+			require.Equal(t, template.SecurityContext.Container, container.SecurityContext)
+		default:
+			// Container security context is applied to ClickHouse container only
+			require.Nil(t, container.SecurityContext, container.Name)
+		}
+	}
+
+	// Pod template without security context keeps pod spec intact
+	template = newStatefulSetTestPodTemplate()
+	template.Spec.SecurityContext = &core.PodSecurityContext{RunAsUser: &root}
+	spec = newTestStatefulSet(t, newStatefulSetTestHost(template)).Spec.Template.Spec
+	require.Equal(t, template.Spec.SecurityContext, spec.SecurityContext)
+}
+
+func Test_SecurityContextChangeRollsStatefulSet(t *testing.T) {
+	base := newTestStatefulSet(t, newStatefulSetTestHost(newSecurityContextTestPodTemplate()))
+
+	pod := newSecurityContextTestPodTemplate()
+	fsGroup := int64(1000)
+	pod.SecurityContext.Pod.FSGroup = &fsGroup
+	require.False(t, model.IsObjectTheSame(&base.ObjectMeta, &newTestStatefulSet(t, newStatefulSetTestHost(pod)).ObjectMeta))
+
+	container := newSecurityContextTestPodTemplate()
+	container.SecurityContext.Container.Capabilities = nil
+	require.False(t, model.IsObjectTheSame(&base.ObjectMeta, &newTestStatefulSet(t, newStatefulSetTestHost(container)).ObjectMeta))
+
+	same := newStatefulSetTestHost(newSecurityContextTestPodTemplate())
+	require.True(t, model.IsObjectTheSame(&base.ObjectMeta, &newTestStatefulSet(t, same).ObjectMeta))
+}
+
+// newProbesTestHost builds host of the cluster having specified number of hosts with data volume of specified size
+func newProbesTestHost(template *api.PodTemplate, hostsCount int, storage string) *api.ChiHost {
+	host := newStatefulSetTestHost(template)
+	chi := host.GetCHI()
+	shard := &chi.Spec.Configuration.Clusters[0].Layout.Shards[0]
+	for i := 1; i < hostsCount; i++ {
+		replica := &api.ChiHost{}
+		replica.Runtime.CHI = chi
+		shard.Hosts = append(shard.Hosts, replica)
+	}
+	host.HTTPPort = 8123
+	host.HTTPSPort = api.PortUnassigned()
+	if storage != "" {
+		host.Templates.DataVolumeClaimTemplate = "data"
+		data := &api.VolumeClaimTemplate{
+			Name: "data",
+			Spec: core.PersistentVolumeClaimSpec{
+				Resources: core.ResourceRequirements{
+					Requests: core.ResourceList{core.ResourceStorage: resource.MustParse(storage)},
+				},
+			},
+		}
+		data.PVCProvisioner = api.PVCProvisionerStatefulSet
+		chi.Spec.Templates.EnsureVolumeClaimTemplatesIndex().Set("data", data)
+	}
+	return host
+}
+
+func newProbesTestConfig() api.OperatorConfigProbes {
+	return api.OperatorConfigProbes{
+		Readiness: api.OperatorConfigProbe{Path: "/ping", InitialDelaySeconds: 10, PeriodSeconds: 3},
+		Startup:   api.OperatorConfigProbe{Path: "/ping", PeriodSeconds: 5, FailureThreshold: 10},
+		Scaling: api.OperatorConfigProbeScaling{
+			Enabled:                    *api.NewStringBool(true),
+			StoragePerFailureThreshold: "10Gi",
+			MaxFailureThreshold:        30,
+		},
+	}
+}
+
+// newProbesTestStatefulSet creates StatefulSet of the host by Creator of the operator running with specified probes config
+func newProbesTestStatefulSet(t *testing.T, host *api.ChiHost, config api.OperatorConfigProbes) *apps.StatefulSet {
+	operatorConfig := &api.OperatorConfig{}
+	operatorConfig.ClickHouse.Probes = config
+	return newTestStatefulSetWithConfig(t, host, operatorConfig)
+}
+
+func Test_ScaledProbes(t *testing.T) {
+	config := newProbesTestConfig()
+
+	// Host without data volume keeps configured thresholds
+	container, ok := getClickHouseContainer(newProbesTestStatefulSet(t, newProbesTestHost(newStatefulSetTestPodTemplate(), 1, ""), config))
+	require.True(t, ok)
+	require.Equal(t, int32(0), container.ReadinessProbe.FailureThreshold)
+	require.Equal(t, int32(10), container.StartupProbe.FailureThreshold)
+
+	// Startup threshold grows with the size of the host's data volume, readiness probe is not scaled
+	container, _ = getClickHouseContainer(newProbesTestStatefulSet(t, newProbesTestHost(newStatefulSetTestPodTemplate(), 1, "35Gi"), config))
+	require.Equal(t, int32(0), container.ReadinessProbe.FailureThreshold)
+	require.Equal(t, int32(10+3), container.StartupProbe.FailureThreshold)
+	require.Equal(t, int32(10), container.ReadinessProbe.InitialDelaySeconds)
+	require.Equal(t, int32(5), container.StartupProbe.PeriodSeconds)
+
+	// Size of the cluster does not affect probes of the host
+	scaled, _ := getClickHouseContainer(newProbesTestStatefulSet(t, newProbesTestHost(newStatefulSetTestPodTemplate(), 4, "35Gi"), config))
+	require.Equal(t, container.StartupProbe, scaled.StartupProbe)
+	require.Equal(t, container.ReadinessProbe, scaled.ReadinessProbe)
+
+	// Scaled threshold is capped
+	container, _ = getClickHouseContainer(newProbesTestStatefulSet(t, newProbesTestHost(newStatefulSetTestPodTemplate(), 1, "1Ti"), config))
+	require.Equal(t, int32(30), container.StartupProbe.FailureThreshold)
+
+	// Disabled scaling keeps configured thresholds, unspecified startup threshold means no startup probe
+	config.Scaling.Enabled = *api.NewStringBool(false)
+	container, _ = getClickHouseContainer(newProbesTestStatefulSet(t, newProbesTestHost(newStatefulSetTestPodTemplate(), 1, "35Gi"), config))
+	require.Equal(t, int32(10), container.StartupProbe.FailureThreshold)
+	config.Startup.FailureThreshold = 0
+	container, _ = getClickHouseContainer(newProbesTestStatefulSet(t, newProbesTestHost(newStatefulSetTestPodTemplate(), 1, "35Gi"), config))
+	require.Equal(t, int32(0), container.ReadinessProbe.FailureThreshold)
+	require.Nil(t, container.StartupProbe)
+
+	// Readiness budget of the container covers scaled probes
+	config = newProbesTestConfig()
+	container, _ = getClickHouseContainer(newProbesTestStatefulSet(t, newProbesTestHost(newStatefulSetTestPodTemplate(), 1, "35Gi"), config))
+	require.Equal(t, time.Duration(5*13+10+3*3)*time.Second, k8s.ContainerReadyDuration(container))
+}
+
+func Test_SetupProbesOverrides(t *testing.T) {
+	config := newProbesTestConfig()
+	template := newStatefulSetTestPodTemplate()
+	host := newProbesTestHost(template, 1, "1Ti")
+	delay := int32(120)
+	period := int32(7)
+	timeout := int32(4)
+	threshold := int32(2)
+	startupThreshold := int32(100)
+
+	// Probe specified in pod spec is overridden as well
+	template.Spec.Containers[0].ReadinessProbe = &core.Probe{PeriodSeconds: 1, FailureThreshold: 50}
+	template.Probes = &api.PodTemplateProbes{
+		Readiness: &api.PodTemplateProbe{
+			InitialDelaySeconds: &delay,
+			PeriodSeconds:       &period,
+			TimeoutSeconds:      &timeout,
+			FailureThreshold:    &threshold,
+		},
+		Startup: &api.PodTemplateProbe{FailureThreshold: &startupThreshold},
+	}
+
+	container, ok := getClickHouseContainer(newProbesTestStatefulSet(t, host, config))
+	require.True(t, ok)
+	require.Equal(t, int32(120), container.ReadinessProbe.InitialDelaySeconds)
+	require.Equal(t, int32(7), container.ReadinessProbe.PeriodSeconds)
+	require.Equal(t, int32(4), container.ReadinessProbe.TimeoutSeconds)
+	require.Equal(t, int32(2), container.ReadinessProbe.FailureThreshold)
+	// Explicit threshold wins over the scaled and capped one, unspecified timings are kept
+	require.Equal(t, int32(100), container.StartupProbe.FailureThreshold)
+	require.Equal(t, int32(5), container.StartupProbe.PeriodSeconds)
+
+	// Explicit startup probe is set up even though config does not request one
+	config.Startup.FailureThreshold = 0
+	container, _ = getClickHouseContainer(newProbesTestStatefulSet(t, host, config))
+	require.NotNil(t, container.StartupProbe)
+	require.Equal(t, "/ping", container.StartupProbe.HTTPGet.Path)
+	require.Equal(t, int32(100), container.StartupProbe.FailureThreshold)
+}
+
+func Test_CreateStatefulSet_PriorityClassName(t *testing.T) {
+	newHost := func(podTemplateClass, clusterClass string) *api.ChiHost {
+		template := newStatefulSetTestPodTemplate()
+		template.Spec.PriorityClassName = podTemplateClass
+		host := newStatefulSetTestHost(template)
+		host.GetCHI().Spec.Configuration.Clusters[0].PriorityClassName = clusterClass
+		return host
+	}
+	base := newTestStatefulSet(t, newHost("", ""))
+	require.Empty(t, base.Spec.Template.Spec.PriorityClassName)
+
+	// Pods are scheduled with PriorityClass of the pod template
+	podTemplateClass := newTestStatefulSet(t, newHost("clickhouse-high", ""))
+	require.Equal(t, "clickhouse-high", podTemplateClass.Spec.Template.Spec.PriorityClassName)
+
+	// PriorityClass of the cluster overrides the one of the pod template
+	clusterClass := newTestStatefulSet(t, newHost("clickhouse-high", "clickhouse-critical"))
+	require.Equal(t, "clickhouse-critical", clusterClass.Spec.Template.Spec.PriorityClassName)
+	require.Equal(t, "clickhouse-critical", newTestStatefulSet(t, newHost("", "clickhouse-critical")).Spec.Template.Spec.PriorityClassName)
+
+	// Change of PriorityClass rolls StatefulSet
+	require.False(t, model.IsObjectTheSame(&base.ObjectMeta, &podTemplateClass.ObjectMeta))
+	require.False(t, model.IsObjectTheSame(&podTemplateClass.ObjectMeta, &clusterClass.ObjectMeta))
+	same := newTestStatefulSet(t, newHost("clickhouse-high", "clickhouse-critical"))
+	require.True(t, model.IsObjectTheSame(&clusterClass.ObjectMeta, &same.ObjectMeta))
+}
@@ -49,6 +49,33 @@ func newVolumeForConfigMap(name string) core.Volume {
 	}
 }
 
+// certificateVolumeName specifies name of the Volume certificate issued by cert-manager is mounted from
+const certificateVolumeName = "chop-certificate"
+
+// newVolumeForSecret returns core.Volume object with defined name
+func newVolumeForSecret(name, secretName string) core.Volume {
+	var defaultMode int32 = 0644
+	return core.Volume{
+		Name: name,
+		VolumeSource: core.VolumeSource{
+			Secret: &core.SecretVolumeSource{
+				SecretName:  secretName,
+				DefaultMode: &defaultMode,
+			},
+		},
+	}
+}
+
+// newVolumeForEmptyDir returns core.Volume object with defined name
+func newVolumeForEmptyDir(name string, emptyDir *core.EmptyDirVolumeSource) core.Volume {
+	return core.Volume{
+		Name: name,
+		VolumeSource: core.VolumeSource{
+			EmptyDir: emptyDir.DeepCopy(),
+		},
+	}
+}
+
 // newVolumeMount returns core.VolumeMount object with name and mount path
 func newVolumeMount(name, mountPath string) core.VolumeMount {
 	return core.VolumeMount{
@@ -15,6 +15,9 @@
 package chi
 
 import (
+	"fmt"
+	"strings"
+
 	core "k8s.io/api/core/v1"
 
 	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
@@ -33,6 +36,55 @@ func HostHasTablesCreated(host *api.ChiHost) bool {
 	return util.InArray(CreateFQDN(host), host.GetCHI().EnsureStatus().GetHostsWithTablesCreated())
 }
 
+// GetRolloutBreakpointHost finds host the rollout of the CHI pauses at.
+// Returns nil in case breakpoint is not specified or does not match any host.
+func GetRolloutBreakpointHost(chi *api.ClickHouseInstallation) *api.ChiHost {
+	parts := strings.Split(chi.GetRolloutBreakpoint(), "/")
+	if len(parts) != 3 {
+		return nil
+	}
+	var breakpoint *api.ChiHost
+	chi.WalkHosts(func(host *api.ChiHost) error {
+		address := host.Runtime.Address
+		if (address.ClusterName == parts[0]) && (address.ShardName == parts[1]) && (address.ReplicaName == parts[2]) {
+			breakpoint = host
+		}
+		return nil
+	})
+	return breakpoint
+}
+
+// CHICheckRolloutBreakpoint checks whether rollout breakpoint, in case specified, matches a host of the CHI
+func CHICheckRolloutBreakpoint(chi *api.ClickHouseInstallation) error {
+	if chi.GetRolloutBreakpoint() == "" {
+		return nil
+	}
+	if GetRolloutBreakpointHost(chi) == nil {
+		return fmt.Errorf("rollout breakpoint %q does not match any host, expected as cluster/shard/replica", chi.GetRolloutBreakpoint())
+	}
+	return nil
+}
+
+// IsHostBeyondRolloutBreakpoint checks whether host goes after the rollout breakpoint host in reconcile order,
+// so it is not to be reconciled until the breakpoint is advanced or cleared
+func IsHostBeyondRolloutBreakpoint(host *api.ChiHost) bool {
+	breakpoint := GetRolloutBreakpointHost(host.GetCHI())
+	if breakpoint == nil {
+		return false
+	}
+	return host.Runtime.Address.CHIScopeIndex > breakpoint.Runtime.Address.CHIScopeIndex
+}
+
+// IsRolloutPausedAtBreakpoint checks whether rollout of the CHI pauses at the breakpoint,
+// leaving some hosts not reconciled
+func IsRolloutPausedAtBreakpoint(chi *api.ClickHouseInstallation) bool {
+	breakpoint := GetRolloutBreakpointHost(chi)
+	if breakpoint == nil {
+		return false
+	}
+	return breakpoint.Runtime.Address.CHIScopeIndex < chi.HostsCount()-1
+}
+
 func HostWalkPorts(host *api.ChiHost, f func(name string, port *int32, protocol core.Protocol) bool) {
 	if host == nil {
 		return
@@ -52,6 +104,12 @@ func HostWalkPorts(host *api.ChiHost, f func(name string, port *int32, protocol
 	if f(ChDefaultInterserverHTTPPortName, &host.InterserverHTTPPort, core.ProtocolTCP) {
 		return
 	}
+	if f(ChDefaultGRPCPortName, &host.GRPCPort, core.ProtocolTCP) {
+		return
+	}
+	if f(ChDefaultPrometheusPortName, &host.PrometheusPort, core.ProtocolTCP) {
+		return
+	}
 }
 
 func HostWalkAssignedPorts(host *api.ChiHost, f func(name string, port *int32, protocol core.Protocol) bool) {
@@ -85,3 +143,25 @@ func HostWalkInvalidPorts(host *api.ChiHost, f func(name string, port *int32, pr
 		},
 	)
 }
+
+// HostGetClickHouseImage gets ClickHouse image the host is configured to run with
+func HostGetClickHouseImage(host *api.ChiHost) string {
+	podTemplate, ok := host.GetPodTemplate()
+	if !ok {
+		// No pod template - default container would be used
+		return DefaultClickHouseDockerImage
+	}
+
+	containers := podTemplate.Spec.Containers
+	for i := range containers {
+		if containers[i].Name == ClickHouseContainerName {
+			return containers[i].Image
+		}
+	}
+	if len(containers) > 0 {
+		// The first container is considered to be ClickHouse container
+		return containers[0].Image
+	}
+
+	return DefaultClickHouseDockerImage
+}
@@ -0,0 +1,81 @@
+package chi
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+// newRolloutTestCHI creates CHI with one cluster of specified number of shards and replicas
+func newRolloutTestCHI(shardsCount, replicasCount int) *api.ClickHouseInstallation {
+	chi := &api.ClickHouseInstallation{}
+	cluster := &api.Cluster{Name: "cluster", Layout: &api.ChiClusterLayout{}}
+	chi.Spec.Configuration = &api.Configuration{Clusters: []*api.Cluster{cluster}}
+	index := 0
+	for s := 0; s < shardsCount; s++ {
+		shard := api.ChiShard{Name: fmt.Sprint(s)}
+		for r := 0; r < replicasCount; r++ {
+			host := &api.ChiHost{}
+			host.Runtime.CHI = chi
+			host.Runtime.Address = api.ChiHostAddress{
+				ClusterName:   "cluster",
+				ShardName:     shard.Name,
+				ReplicaName:   fmt.Sprint(r),
+				HostName:      fmt.Sprintf("%d-%d", s, r),
+				CHIScopeIndex: index,
+			}
+			index++
+			shard.Hosts = append(shard.Hosts, host)
+		}
+		cluster.Layout.Shards = append(cluster.Layout.Shards, shard)
+	}
+	return chi
+}
+
+// getRolloutTestReconciledHosts lists hosts reconcile proceeds with
+func getRolloutTestReconciledHosts(chi *api.ClickHouseInstallation) (hosts []string) {
+	chi.WalkHosts(func(host *api.ChiHost) error {
+		if !IsHostBeyondRolloutBreakpoint(host) {
+			hosts = append(hosts, host.Runtime.Address.HostName)
+		}
+		return nil
+	})
+	return hosts
+}
+
+func Test_RolloutBreakpoint(t *testing.T) {
+	chi := newRolloutTestCHI(3, 2)
+
+	// Reconcile stops at the breakpoint host, including it
+	chi.Spec.RolloutBreakpoint = "cluster/1/0"
+	require.NoError(t, CHICheckRolloutBreakpoint(chi))
+	require.Equal(t, "1-0", GetRolloutBreakpointHost(chi).Runtime.Address.HostName)
+	require.Equal(t, []string{"0-0", "0-1", "1-0"}, getRolloutTestReconciledHosts(chi))
+	require.True(t, IsRolloutPausedAtBreakpoint(chi))
+
+	// Advanced breakpoint resumes the rollout up to the new breakpoint
+	chi.Spec.RolloutBreakpoint = "cluster/2/0"
+	require.Equal(t, []string{"0-0", "0-1", "1-0", "1-1", "2-0"}, getRolloutTestReconciledHosts(chi))
+	require.True(t, IsRolloutPausedAtBreakpoint(chi))
+
+	// Breakpoint at the last host does not pause anything
+	chi.Spec.RolloutBreakpoint = "cluster/2/1"
+	require.Len(t, getRolloutTestReconciledHosts(chi), 6)
+	require.False(t, IsRolloutPausedAtBreakpoint(chi))
+
+	// Cleared breakpoint resumes the rollout of all hosts
+	chi.Spec.RolloutBreakpoint = ""
+	require.NoError(t, CHICheckRolloutBreakpoint(chi))
+	require.Len(t, getRolloutTestReconciledHosts(chi), 6)
+	require.False(t, IsRolloutPausedAtBreakpoint(chi))
+
+	// Breakpoint which does not match any host is rejected
+	for _, breakpoint := range []string{"cluster/3/0", "other/0/0", "cluster/0", "cluster/0/0/0"} {
+		chi.Spec.RolloutBreakpoint = breakpoint
+		require.Error(t, CHICheckRolloutBreakpoint(chi), breakpoint)
+		require.False(t, IsRolloutPausedAtBreakpoint(chi), breakpoint)
+	}
+}
@@ -53,6 +53,8 @@ const (
 	labelServiceValueCluster          = "cluster"
 	labelServiceValueShard            = "shard"
 	labelServiceValueHost             = "host"
+	labelServiceValueHostInterserver  = "host-interserver"
+	labelServiceValueSubdomain        = "subdomain"
 	LabelPVCReclaimPolicyName         = clickhouse_altinity_com.APIGroupName + "/" + "reclaimPolicy"
 
 	// Supplementary service labels - used to cooperate with k8s
@@ -60,6 +62,8 @@ const (
 	LabelZookeeperConfigVersion = clickhouse_altinity_com.APIGroupName + "/" + "zookeeper-version"
 	LabelSettingsConfigVersion  = clickhouse_altinity_com.APIGroupName + "/" + "settings-version"
 	LabelObjectVersion          = clickhouse_altinity_com.APIGroupName + "/" + "object-version"
+	// Object base version does not take into account resources requests/limits of the containers
+	LabelObjectBaseVersion = clickhouse_altinity_com.APIGroupName + "/" + "object-base-version"
 
 	// Optional labels
 
@@ -123,6 +127,41 @@ func (l *Labeler) GetServiceCHI(chi *api.ClickHouseInstallation) map[string]stri
 		})
 }
 
+// GetServiceAccountCHI
+func (l *Labeler) GetServiceAccountCHI() map[string]string {
+	return l.getCHIScope()
+}
+
+// GetPDBShard
+func (l *Labeler) GetPDBShard(shard *api.ChiShard) map[string]string {
+	return l.getShardScope(shard)
+}
+
+// GetNetworkPolicyCHI
+func (l *Labeler) GetNetworkPolicyCHI() map[string]string {
+	return l.getCHIScope()
+}
+
+// GetKeeperCHI
+func (l *Labeler) GetKeeperCHI() map[string]string {
+	return l.getCHIScope()
+}
+
+// GetSecretCHI
+func (l *Labeler) GetSecretCHI() map[string]string {
+	return l.getCHIScope()
+}
+
+// GetCertificateCHI
+func (l *Labeler) GetCertificateCHI() map[string]string {
+	return l.getCHIScope()
+}
+
+// GetCertificateHost
+func (l *Labeler) GetCertificateHost(host *api.ChiHost) map[string]string {
+	return l.GetHostScope(host, false)
+}
+
 // GetServiceCluster
 func (l *Labeler) GetServiceCluster(cluster *api.Cluster) map[string]string {
 	return util.MergeStringMapsOverwrite(
@@ -150,6 +189,24 @@ func (l *Labeler) GetServiceHost(host *api.ChiHost) map[string]string {
 		})
 }
 
+// GetServiceSubdomain
+func (l *Labeler) GetServiceSubdomain() map[string]string {
+	return util.MergeStringMapsOverwrite(
+		l.getCHIScope(),
+		map[string]string{
+			LabelService: labelServiceValueSubdomain,
+		})
+}
+
+// GetServiceHostInterserver
+func (l *Labeler) GetServiceHostInterserver(host *api.ChiHost) map[string]string {
+	return util.MergeStringMapsOverwrite(
+		l.GetHostScope(host, false),
+		map[string]string{
+			LabelService: labelServiceValueHostInterserver,
+		})
+}
+
 // getCHIScope gets labels for CHI-scoped object
 func (l *Labeler) getCHIScope() map[string]string {
 	// Combine generated labels and CHI-provided labels
@@ -198,11 +255,11 @@ func GetSelectorClusterScopeReady(cluster *api.Cluster) map[string]string {
 // getShardScope gets labels for Shard-scoped object
 func (l *Labeler) getShardScope(shard *api.ChiShard) map[string]string {
 	// Combine generated labels and CHI-provided labels
-	return l.filterOutPredefined(l.appendCHIProvidedTo(getSelectorShardScope(shard)))
+	return l.filterOutPredefined(l.appendCHIProvidedTo(GetSelectorShardScope(shard)))
 }
 
-// getSelectorShardScope gets labels to select a Shard-scoped object
-func getSelectorShardScope(shard *api.ChiShard) map[string]string {
+// GetSelectorShardScope gets labels to select a Shard-scoped object
+func GetSelectorShardScope(shard *api.ChiShard) map[string]string {
 	// Do not include CHI-provided labels
 	return map[string]string{
 		LabelNamespace:   labelsNamer.getNamePartNamespace(shard),
@@ -215,7 +272,7 @@ func getSelectorShardScope(shard *api.ChiShard) map[string]string {
 
 // GetSelectorShardScopeReady gets labels to select a ready-labelled Shard-scoped object
 func GetSelectorShardScopeReady(shard *api.ChiShard) map[string]string {
-	return appendKeyReady(getSelectorShardScope(shard))
+	return appendKeyReady(GetSelectorShardScope(shard))
 }
 
 // GetHostScope gets labels for Host-scoped object
@@ -412,6 +469,38 @@ func GetObjectVersion(meta meta.ObjectMeta) (string, bool) {
 	return label, ok
 }
 
+// MakeObjectBaseVersion makes object base version label.
+// Base version is expected to be calculated over the object with resources requests/limits of the containers cleared.
+func MakeObjectBaseVersion(meta *meta.ObjectMeta, obj interface{}) {
+	meta.Labels = util.MergeStringMapsOverwrite(
+		meta.Labels,
+		map[string]string{
+			LabelObjectBaseVersion: util.Fingerprint(obj),
+		},
+	)
+}
+
+// IsObjectResourcesChangedOnly checks whether objects differ in resources requests/limits of the containers only
+func IsObjectResourcesChangedOnly(cur, desired *meta.ObjectMeta) bool {
+	if (cur == nil) || (desired == nil) {
+		return false
+	}
+	if IsObjectTheSame(cur, desired) {
+		// No changes at all
+		return false
+	}
+
+	curBase, ok := cur.Labels[LabelObjectBaseVersion]
+	if !ok {
+		return false
+	}
+	desiredBase, ok := desired.Labels[LabelObjectBaseVersion]
+	if !ok {
+		return false
+	}
+	return curBase == desiredBase
+}
+
 // isObjectVersionLabelTheSame checks whether object version in meta.Labels is the same as provided value
 func isObjectVersionLabelTheSame(meta *meta.ObjectMeta, value string) bool {
 	if meta == nil {
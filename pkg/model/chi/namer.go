@@ -16,6 +16,7 @@ package chi
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -59,6 +60,15 @@ const (
 	// statefulSetServiceNamePattern is a template of hosts's StatefulSet's Service name. "chi-{chi}-{cluster}-{shard}-{host}"
 	statefulSetServiceNamePattern = "chi-" + macrosChiName + "-" + macrosClusterName + "-" + macrosHostName
 
+	// interserverServiceNamePattern is a template of hosts's interserver Service name. "chi-{chi}-{cluster}-{shard}-{host}-is"
+	interserverServiceNamePattern = "chi-" + macrosChiName + "-" + macrosClusterName + "-" + macrosHostName + "-is"
+
+	// subdomainServiceNamePattern is a template of subdomain Service name. "chi-{chi}-{subdomain}"
+	subdomainServiceNamePattern = "chi-" + macrosChiName + "-%s"
+
+	// keeperNamePattern is a template of Keeper ensemble, managed along with the CHI. "{chi}-keeper"
+	keeperNamePattern = macrosChiName + "-keeper"
+
 	// configMapCommonNamePattern is a template of common settings for the CHI ConfigMap. "chi-{chi}-common-configd"
 	configMapCommonNamePattern = "chi-" + macrosChiName + "-common-configd"
 
@@ -396,6 +406,22 @@ func CreateCHIServiceFQDN(chi *api.ClickHouseInstallation) string {
 	)
 }
 
+// CreateKeeperName returns a name of the Keeper ensemble, managed along with the CHI.
+// Keeper's StatefulSet, ConfigMap and client Service are named after it
+func CreateKeeperName(chi *api.ClickHouseInstallation) string {
+	return Macro(chi).Line(keeperNamePattern)
+}
+
+// CreateKeeperServiceFQDN creates a FQDN of the client Service of the Keeper ensemble, managed along with the CHI
+func CreateKeeperServiceFQDN(chi *api.ClickHouseInstallation) string {
+	pattern := serviceFQDNPattern
+	if chi.Spec.NamespaceDomainPattern != "" {
+		// NamespaceDomainPattern has been explicitly specified
+		pattern = "%s." + chi.Spec.NamespaceDomainPattern
+	}
+	return fmt.Sprintf(pattern, CreateKeeperName(chi), chi.Namespace)
+}
+
 // CreateClusterServiceName returns a name of a cluster's Service
 func CreateClusterServiceName(cluster *api.Cluster) string {
 	// Name can be generated either from default name pattern,
@@ -557,6 +583,33 @@ func CreateStatefulSetServiceName(host *api.ChiHost) string {
 	return Macro(host).Line(pattern)
 }
 
+// CreateInterserverServiceName returns a name of a host's interserver Service
+func CreateInterserverServiceName(host *api.ChiHost) string {
+	return Macro(host).Line(interserverServiceNamePattern)
+}
+
+// CreateInterserverHostname returns hostname to be used by replicas to fetch parts from the host.
+// In case interserver Service is enabled, replicas are pointed to the dedicated per-host interserver Service,
+// otherwise instance hostname is used.
+func CreateInterserverHostname(host *api.ChiHost) string {
+	if !host.GetCHI().Spec.Defaults.InterserverService.IsTrue() {
+		return CreateInstanceHostname(host)
+	}
+
+	name := CreateInterserverServiceName(host)
+	if !host.GetCHI().Spec.Defaults.ReplicasUseFQDN.IsTrue() {
+		return name
+	}
+
+	// FQDN of the interserver Service
+	pattern := serviceFQDNPattern
+	if host.GetCHI().Spec.NamespaceDomainPattern != "" {
+		// NamespaceDomainPattern has been explicitly specified
+		pattern = "%s." + host.GetCHI().Spec.NamespaceDomainPattern
+	}
+	return fmt.Sprintf(pattern, name, host.Runtime.Address.Namespace)
+}
+
 // CreatePodHostname returns a hostname of a Pod of a ClickHouse instance.
 // Is supposed to be used where network connection to a Pod is required.
 // NB: right now Pod's hostname points to a Service, through which Pod can be accessed.
@@ -565,8 +618,37 @@ func CreatePodHostname(host *api.ChiHost) string {
 	return CreateStatefulSetServiceName(host)
 }
 
+// CreateInstanceHostnamePrefix creates prefix of instance hostnames of the CHI with specified name.
+// Prefix is applicable to hostnames built with the default name pattern
+// chi-{chi}-
+func CreateInstanceHostnamePrefix(chiName string) string {
+	return "chi-" + newNamer(namerContextNames).namePartChiName(chiName) + "-"
+}
+
+// CreateSubdomainServiceName creates name of the subdomain Service of the CHI.
+// Name is prefixed with the CHI name, so CHIs in one namespace may specify the same subdomain.
+// Returns empty string in case subdomain is not specified for the CHI.
+func CreateSubdomainServiceName(chi *api.ClickHouseInstallation) string {
+	subdomain := chi.Spec.Defaults.GetSubdomain()
+	if subdomain == "" {
+		return ""
+	}
+	return fmt.Sprintf(Macro(chi).Line(subdomainServiceNamePattern), subdomain)
+}
+
+// CreateStatefulSetGoverningServiceName creates name of headless Service, which governs StatefulSet of the host.
+// Host's own Service governs StatefulSet, unless subdomain is specified for the CHI.
+func CreateStatefulSetGoverningServiceName(host *api.ChiHost) string {
+	if subdomain := CreateSubdomainServiceName(host.GetCHI()); subdomain != "" {
+		return subdomain
+	}
+	return CreateStatefulSetServiceName(host)
+}
+
 // createPodFQDN creates a fully qualified domain name of a pod
 // ss-1eb454-2-0.my-dev-domain.svc.cluster.local
+// In case subdomain is specified, stable DNS name of the pod within subdomain is used
+// chi-chi-cluster-0-0-0.chi-chi-subdomain.my-dev-domain.svc.cluster.local
 func createPodFQDN(host *api.ChiHost) string {
 	// FQDN can be generated either from default pattern,
 	// or from personal pattern provided
@@ -579,10 +661,15 @@ func createPodFQDN(host *api.ChiHost) string {
 		pattern = "%s." + host.GetCHI().Spec.NamespaceDomainPattern
 	}
 
+	hostname := CreatePodHostname(host)
+	if subdomain := CreateSubdomainServiceName(host.GetCHI()); subdomain != "" {
+		hostname = CreatePodName(host) + "." + subdomain
+	}
+
 	// Create FQDN based on pattern available
 	return fmt.Sprintf(
 		pattern,
-		CreatePodHostname(host),
+		hostname,
 		host.Runtime.Address.Namespace,
 	)
 }
@@ -724,13 +811,14 @@ func CreatePVCNameByVolumeClaimTemplate(host *api.ChiHost, volumeClaimTemplate *
 
 // CreatePVCNameByVolumeMount creates PVC name
 func CreatePVCNameByVolumeMount(host *api.ChiHost, volumeMount *core.VolumeMount) (string, bool) {
-	volumeClaimTemplate, ok := GetVolumeClaimTemplate(host, volumeMount)
-	if !ok {
+	if _, ok := GetVolumeClaimTemplate(host, volumeMount); !ok {
 		// Unable to find VolumeClaimTemplate related to this volumeMount.
 		// May be this volumeMount is not created from VolumeClaimTemplate, it may be a reference to a ConfigMap
 		return "", false
 	}
-	return createPVCName(host, volumeClaimTemplate.Name), true
+	// PVC is named after volume claim template of the StatefulSet, which differs from the one of the CHI
+	// in case StatefulSet is adopted
+	return createPVCName(host, volumeMount.Name), true
 }
 
 // createPVCName is an internal function
@@ -753,3 +841,85 @@ func CreateClusterAutoSecretName(cluster *api.Cluster) string {
 		cluster.Name,
 	)
 }
+
+// CreateManagedUserSecretName creates Secret name where password of the operator-managed user is kept
+func CreateManagedUserSecretName(chi *api.ClickHouseInstallation) string {
+	return fmt.Sprintf(
+		"%s-managed-user",
+		chi.Name,
+	)
+}
+
+// CreateGeneratedPasswordsSecretName creates Secret name where passwords generated for the users are kept
+func CreateGeneratedPasswordsSecretName(chi *api.ClickHouseInstallation) string {
+	return fmt.Sprintf(
+		"%s-generated-passwords",
+		chi.Name,
+	)
+}
+
+// CreateInterserverCredentialsSecretName creates Secret name where interserver credentials are kept
+func CreateInterserverCredentialsSecretName(chi *api.ClickHouseInstallation) string {
+	return fmt.Sprintf(
+		"%s-interserver-credentials",
+		chi.Name,
+	)
+}
+
+// CreateNetworkPolicyName creates name of NetworkPolicy ingress to ClickHouse instances of the CHI is restricted with
+func CreateNetworkPolicyName(chi *api.ClickHouseInstallation) string {
+	return fmt.Sprintf(
+		"%s-clickhouse",
+		chi.Name,
+	)
+}
+
+// CreateCertificateName creates name of cert-manager Certificate the host is served with.
+// Secret the certificate is issued into has the same name, so name is prefixed as names of StatefulSets are,
+// in order not to collide with Secrets of the user
+func CreateCertificateName(host *api.ChiHost) string {
+	if host.GetCHI().GetCertManager().IsScopeCHI() {
+		return fmt.Sprintf(
+			"chi-%s-tls",
+			host.GetCHI().Name,
+		)
+	}
+
+	return fmt.Sprintf(
+		"%s-tls",
+		CreateStatefulSetName(host),
+	)
+}
+
+// CreateCertificateDNSNames creates DNS names the certificate of the host has to be valid for.
+// Certificate issued within CHI scope has to be valid for all hosts of the CHI
+func CreateCertificateDNSNames(host *api.ChiHost) []string {
+	hosts := []*api.ChiHost{host}
+	if host.GetCHI().GetCertManager().IsScopeCHI() {
+		hosts = nil
+		host.GetCHI().WalkHosts(func(host *api.ChiHost) error {
+			hosts = append(hosts, host)
+			return nil
+		})
+	}
+
+	var names []string
+	for _, host := range hosts {
+		names = append(names,
+			CreatePodHostname(host),
+			CreateFQDN(host),
+			CreateInstanceHostname(host),
+			CreateInterserverHostname(host),
+		)
+	}
+	// Clients may access hosts via CHI entry point
+	names = append(names,
+		CreateCHIServiceName(host.GetCHI()),
+		CreateCHIServiceFQDN(host.GetCHI()),
+	)
+
+	// Keep order stable, so certificate is not updated in vain
+	names = util.Unique(names)
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,95 @@
+package chi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+func newInterserverTestHost(interserverService, replicasUseFQDN bool) *api.ChiHost {
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+		},
+		Spec: api.ChiSpec{
+			Defaults: &api.ChiDefaults{
+				ReplicasUseFQDN:    api.NewStringBool(replicasUseFQDN),
+				InterserverService: api.NewStringBool(interserverService),
+			},
+		},
+	}
+	host := &api.ChiHost{Name: "0-1"}
+	host.Runtime.CHI = chi
+	host.Runtime.Address = api.ChiHostAddress{
+		Namespace:   "ns",
+		CHIName:     "chi",
+		ClusterName: "cluster",
+		HostName:    "0-1",
+	}
+	return host
+}
+
+func Test_CreateInterserverHostname(t *testing.T) {
+	// Interserver Service disabled - instance hostname is used
+	host := newInterserverTestHost(false, false)
+	require.Equal(t, "chi-chi-cluster-0-1", CreateInterserverHostname(host))
+
+	// Interserver Service enabled - replicas are pointed to the interserver Service
+	host = newInterserverTestHost(true, false)
+	require.Equal(t, "chi-chi-cluster-0-1-is", CreateInterserverServiceName(host))
+	require.Equal(t, "chi-chi-cluster-0-1-is", CreateInterserverHostname(host))
+
+	host = newInterserverTestHost(true, true)
+	require.Equal(t, "chi-chi-cluster-0-1-is.ns.svc.cluster.local", CreateInterserverHostname(host))
+}
+
+func Test_CreateKeeperServiceFQDN(t *testing.T) {
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+		},
+	}
+	require.Equal(t, "chi-keeper", CreateKeeperName(chi))
+	require.Equal(t, "chi-keeper.ns.svc.cluster.local", CreateKeeperServiceFQDN(chi))
+
+	chi.Spec.NamespaceDomainPattern = "%s.svc.my.test"
+	require.Equal(t, "chi-keeper.ns.svc.my.test", CreateKeeperServiceFQDN(chi))
+}
+
+func Test_CreateStatefulSetGoverningServiceName(t *testing.T) {
+	// No subdomain - StatefulSet is governed by the Service of the host
+	host := newInterserverTestHost(false, false)
+	require.Equal(t, "chi-chi-cluster-0-1", CreateStatefulSetGoverningServiceName(host))
+	require.Equal(t, "chi-chi-cluster-0-1.ns.svc.cluster.local", CreateFQDN(host))
+
+	// Subdomain - StatefulSet is governed by the subdomain Service, pod is addressed within the subdomain
+	host.GetCHI().Spec.Defaults.Subdomain = "sub"
+	require.Equal(t, "chi-chi-sub", CreateStatefulSetGoverningServiceName(host))
+	require.Equal(t, "chi-chi-cluster-0-1-0.chi-chi-sub.ns.svc.cluster.local", CreateFQDN(host))
+
+	// Subdomain Service is CHI-scoped, so CHIs in one namespace do not collide with the same subdomain
+	other := newInterserverTestHost(false, false)
+	other.GetCHI().Name = "other"
+	other.GetCHI().Spec.Defaults.Subdomain = "sub"
+	require.Equal(t, "chi-other-sub", CreateSubdomainServiceName(other.GetCHI()))
+}
+
+func Test_CreateCertificateName(t *testing.T) {
+	host := newInterserverTestHost(false, false)
+	host.GetCHI().Spec.CertManager = &api.ChiCertManager{Enabled: api.NewStringBool(true)}
+	require.Equal(t, "chi-chi-cluster-0-1-tls", CreateCertificateName(host))
+	require.Equal(t, []string{
+		"chi-chi-cluster-0-1",
+		"chi-chi-cluster-0-1.ns.svc.cluster.local",
+		"clickhouse-chi",
+		"clickhouse-chi.ns.svc.cluster.local",
+	}, CreateCertificateDNSNames(host))
+
+	host.GetCHI().Spec.CertManager.Scope = "chi"
+	require.Equal(t, "chi-chi-tls", CreateCertificateName(host))
+}
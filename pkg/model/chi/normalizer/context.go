@@ -20,6 +20,8 @@ import api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity
 type Context struct {
 	// chi specifies current CHI being normalized
 	chi *api.ClickHouseInstallation
+	// ancestor specifies CHI as it was reconciled the last time, if any
+	ancestor *api.ClickHouseInstallation
 	// options specifies normalization options
 	options *Options
 }
@@ -46,6 +48,23 @@ func (c *Context) SetTarget(chi *api.ClickHouseInstallation) *api.ClickHouseInst
 	return c.chi
 }
 
+// GetAncestor gets CHI as it was reconciled the last time
+func (c *Context) GetAncestor() *api.ClickHouseInstallation {
+	if c == nil {
+		return nil
+	}
+	return c.ancestor
+}
+
+// SetAncestor sets CHI as it was reconciled the last time
+func (c *Context) SetAncestor(ancestor *api.ClickHouseInstallation) *api.ClickHouseInstallation {
+	if c == nil {
+		return nil
+	}
+	c.ancestor = ancestor
+	return c.ancestor
+}
+
 func (c *Context) Options() *Options {
 	if c == nil {
 		return nil
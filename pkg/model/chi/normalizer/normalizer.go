@@ -19,12 +19,17 @@ import (
 	"encoding/hex"
 	"fmt"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/google/uuid"
 
 	core "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 
 	log "github.com/altinity/clickhouse-operator/pkg/announcer"
 	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
@@ -35,13 +40,18 @@ import (
 	entitiesNormalizer "github.com/altinity/clickhouse-operator/pkg/model/chi/normalizer/entities"
 	templatesNormalizer "github.com/altinity/clickhouse-operator/pkg/model/chi/normalizer/templates"
 	"github.com/altinity/clickhouse-operator/pkg/util"
+	"github.com/altinity/clickhouse-operator/pkg/xml"
 )
 
 type secretGet func(namespace, name string) (*core.Secret, error)
 
+// usersGet gets ClickHouseUser resources of the CHI
+type usersGet func(namespace, chi string) []*api.ClickHouseUser
+
 // Normalizer specifies structures normalizer
 type Normalizer struct {
 	secretGet secretGet
+	usersGet  usersGet
 	ctx       *Context
 }
 
@@ -52,6 +62,12 @@ func NewNormalizer(secretGet secretGet) *Normalizer {
 	}
 }
 
+// SetUsersGet sets function to get ClickHouseUser resources, which are rendered along with users of the CHI
+func (n *Normalizer) SetUsersGet(usersGet usersGet) *Normalizer {
+	n.usersGet = usersGet
+	return n
+}
+
 // CreateTemplatedCHI produces ready-to-use CHI object
 func (n *Normalizer) CreateTemplatedCHI(
 	chi *api.ClickHouseInstallation,
@@ -62,6 +78,7 @@ func (n *Normalizer) CreateTemplatedCHI(
 
 	// Ensure normalization entity present
 	chi = n.ensureNormalizationEntity(chi)
+	n.ctx.SetAncestor(chi.GetAncestor())
 
 	// Create new target that will be populated with data during normalization process
 	n.ctx.SetTarget(n.createTarget())
@@ -112,9 +129,13 @@ func (n *Normalizer) normalize() (*api.ClickHouseInstallation, error) {
 	n.ctx.GetTarget().Spec.Restart = n.normalizeRestart(n.ctx.GetTarget().Spec.Restart)
 	n.ctx.GetTarget().Spec.Troubleshoot = n.normalizeTroubleshoot(n.ctx.GetTarget().Spec.Troubleshoot)
 	n.ctx.GetTarget().Spec.NamespaceDomainPattern = n.normalizeNamespaceDomainPattern(n.ctx.GetTarget().Spec.NamespaceDomainPattern)
+	n.ctx.GetTarget().Spec.RevisionHistoryLimit = n.normalizeRevisionHistoryLimit(n.ctx.GetTarget().Spec.RevisionHistoryLimit)
 	n.ctx.GetTarget().Spec.Templating = n.normalizeTemplating(n.ctx.GetTarget().Spec.Templating)
 	n.ctx.GetTarget().Spec.Reconciling = n.normalizeReconciling(n.ctx.GetTarget().Spec.Reconciling)
+	n.ctx.GetTarget().Spec.Access = n.normalizeAccess(n.ctx.GetTarget().Spec.Access)
+	n.ctx.GetTarget().Spec.CertManager = n.normalizeCertManager(n.ctx.GetTarget().Spec.CertManager)
 	n.ctx.GetTarget().Spec.Defaults = n.normalizeDefaults(n.ctx.GetTarget().Spec.Defaults)
+	n.ctx.GetTarget().Spec.Scale = n.normalizeScale(n.ctx.GetTarget().Spec.Scale)
 	n.ctx.GetTarget().Spec.Configuration = n.normalizeConfiguration(n.ctx.GetTarget().Spec.Configuration)
 	n.ctx.GetTarget().Spec.Templates = n.normalizeTemplates(n.ctx.GetTarget().Spec.Templates)
 	// UseTemplates already done
@@ -135,6 +156,17 @@ func (n *Normalizer) finalizeCHI() {
 		return nil
 	})
 	n.fillCHIAddressInfo()
+	n.checkClusterImages()
+}
+
+// checkClusterImages warns about clusters with hosts configured to run different ClickHouse images
+func (n *Normalizer) checkClusterImages() {
+	n.ctx.GetTarget().WalkClusters(func(cluster *api.Cluster) error {
+		if err := model.ClusterCheckClickHouseImages(cluster); err != nil {
+			log.V(1).M(n.ctx.GetTarget()).F().Warning("%v", err)
+		}
+		return nil
+	})
 }
 
 // fillCHIAddressInfo
@@ -204,6 +236,12 @@ func hostApplyHostTemplate(host *api.ChiHost, template *api.HostTemplate) {
 			if api.IsPortUnassigned(host.InterserverHTTPPort) {
 				host.InterserverHTTPPort = template.Spec.InterserverHTTPPort
 			}
+			if api.IsPortUnassigned(host.GRPCPort) {
+				host.GRPCPort = template.Spec.GRPCPort
+			}
+			if api.IsPortUnassigned(host.PrometheusPort) {
+				host.PrometheusPort = template.Spec.PrometheusPort
+			}
 		case deployment.PortDistributionClusterScopeIndex:
 			if api.IsPortUnassigned(host.TCPPort) {
 				base := model.ChDefaultTCPPortNumber
@@ -240,6 +278,13 @@ func hostApplyHostTemplate(host *api.ChiHost, template *api.HostTemplate) {
 				}
 				host.InterserverHTTPPort = base + int32(host.Runtime.Address.ClusterScopeIndex)
 			}
+			// gRPC and Prometheus ports are optional and are distributed only in case template declares them
+			if api.IsPortUnassigned(host.GRPCPort) && api.IsPortAssigned(template.Spec.GRPCPort) {
+				host.GRPCPort = template.Spec.GRPCPort + int32(host.Runtime.Address.ClusterScopeIndex)
+			}
+			if api.IsPortUnassigned(host.PrometheusPort) && api.IsPortAssigned(template.Spec.PrometheusPort) {
+				host.PrometheusPort = template.Spec.PrometheusPort + int32(host.Runtime.Address.ClusterScopeIndex)
+			}
 		}
 	}
 
@@ -290,6 +335,9 @@ func hostEnsurePortValuesFromSettings(host *api.ChiHost, settings *api.Settings,
 	host.HTTPPort = api.EnsurePortValue(host.HTTPPort, settings.GetHTTPPort(), fallbackHTTPPort)
 	host.HTTPSPort = api.EnsurePortValue(host.HTTPSPort, settings.GetHTTPSPort(), fallbackHTTPSPort)
 	host.InterserverHTTPPort = api.EnsurePortValue(host.InterserverHTTPPort, settings.GetInterserverHTTPPort(), fallbackInterserverHTTPPort)
+	// gRPC and Prometheus ports are optional, they are opened only in case declared explicitly
+	host.GRPCPort = api.EnsurePortValue(host.GRPCPort, settings.GetGRPCPort(), api.PortUnassigned())
+	host.PrometheusPort = api.EnsurePortValue(host.PrometheusPort, settings.GetPrometheusPort(), api.PortUnassigned())
 }
 
 // fillStatus fills .status section of a CHI with values based on current CHI
@@ -304,6 +352,25 @@ func (n *Normalizer) fillStatus() {
 	})
 	ip, _ := chop.Get().ConfigManager.GetRuntimeParam(deployment.OPERATOR_POD_IP)
 	n.ctx.GetTarget().FillStatus(endpoint, pods, fqdns, ip)
+	n.fillStatusScale()
+}
+
+// fillStatusScale fills .status section of a CHI with current scale of the cluster scaled by /scale subresource
+func (n *Normalizer) fillStatusScale() {
+	scale := n.ctx.GetTarget().GetScale()
+	for i, cluster := range n.ctx.GetTarget().Spec.Configuration.Clusters {
+		if !scale.IsScaled(cluster, i) {
+			continue
+		}
+		// Report number of replicas or shards the cluster actually has, as it may differ from the requested one
+		count := cluster.Layout.HostsField.ReplicasCount
+		if scale.IsShards() {
+			count = cluster.Layout.HostsField.ShardsCount
+		}
+		selector := labels.SelectorFromSet(model.GetSelectorClusterScope(cluster)).String()
+		n.ctx.GetTarget().EnsureStatus().SetScale(count, selector)
+		return
+	}
 }
 
 // normalizeTaskID normalizes .spec.taskID
@@ -369,6 +436,15 @@ func (n *Normalizer) normalizeNamespaceDomainPattern(namespaceDomainPattern stri
 	return ""
 }
 
+// normalizeRevisionHistoryLimit normalizes .spec.revisionHistoryLimit
+func (n *Normalizer) normalizeRevisionHistoryLimit(limit *int32) *int32 {
+	if (limit != nil) && (*limit < 0) {
+		// In case limit is not valid - fallback to operator's default
+		return nil
+	}
+	return limit
+}
+
 // normalizeDefaults normalizes .spec.defaults
 func (n *Normalizer) normalizeDefaults(defaults *api.ChiDefaults) *api.ChiDefaults {
 	if defaults == nil {
@@ -376,6 +452,8 @@ func (n *Normalizer) normalizeDefaults(defaults *api.ChiDefaults) *api.ChiDefaul
 	}
 	// Set defaults for CHI object properties
 	defaults.ReplicasUseFQDN = defaults.ReplicasUseFQDN.Normalize(false)
+	defaults.InterserverService = defaults.InterserverService.Normalize(false)
+	defaults.CreateDistributedTables = defaults.CreateDistributedTables.Normalize(false)
 	// Ensure field
 	if defaults.DistributedDDL == nil {
 		//defaults.DistributedDDL = api.NewChiDistributedDDL()
@@ -397,15 +475,17 @@ func (n *Normalizer) normalizeConfiguration(conf *api.Configuration) *api.Config
 	if conf == nil {
 		conf = api.NewConfiguration()
 	}
-	conf.Zookeeper = n.normalizeConfigurationZookeeper(conf.Zookeeper)
+	conf.Keeper = n.normalizeConfigurationKeeper(n.ensureKeeperOfDefaults(conf))
+	conf.Zookeeper = n.normalizeConfigurationZookeeper(n.ensureZookeeperOfKeeper(conf.Zookeeper, conf.Keeper))
 	n.normalizeConfigurationAllSettingsBasedSections(conf)
+	conf.Storage = n.normalizeConfigurationStorage(conf.Storage)
 	conf.Clusters = n.normalizeClusters(conf.Clusters)
 	return conf
 }
 
 // normalizeConfigurationAllSettingsBasedSections normalizes Settings-based configuration
 func (n *Normalizer) normalizeConfigurationAllSettingsBasedSections(conf *api.Configuration) {
-	conf.Users = n.normalizeConfigurationUsers(conf.Users)
+	conf.Users = n.normalizeConfigurationUsers(n.appendClickHouseUsers(conf.Users))
 	conf.Profiles = n.normalizeConfigurationProfiles(conf.Profiles)
 	conf.Quotas = n.normalizeConfigurationQuotas(conf.Quotas)
 	conf.Settings = n.normalizeConfigurationSettings(conf.Settings)
@@ -462,9 +542,95 @@ func (n *Normalizer) normalizeReconciling(reconciling *api.ChiReconciling) *api.
 		reconciling.SetPolicy(api.ReconcilingPolicyUnspecified)
 	}
 	reconciling.Cleanup = n.normalizeReconcilingCleanup(reconciling.Cleanup)
+	reconciling.MembershipWebhook = n.normalizeReconcilingMembershipWebhook(reconciling.MembershipWebhook)
 	return reconciling
 }
 
+// normalizeCertManager normalizes .spec.certManager.
+// Switch of replicas to fetch parts over https is staged - hosts have to be served with certificates first,
+// so replicas, which are not rolled yet, are able to fetch parts from replicas switched to https already
+func (n *Normalizer) normalizeCertManager(certManager *api.ChiCertManager) *api.ChiCertManager {
+	if !certManager.IsEnabled() || (certManager.InterserverSecure != nil) {
+		return certManager
+	}
+	ancestor := n.ctx.GetAncestor()
+	served := (ancestor != nil) && ancestor.GetCertManager().IsEnabled()
+	certManager.InterserverSecure = api.NewStringBool(served)
+	return certManager
+}
+
+// normalizeAccess normalizes .spec.access
+func (n *Normalizer) normalizeAccess(access *api.ChiAccess) *api.ChiAccess {
+	if access == nil {
+		// Access is optional, operator-wide access settings are used
+		return nil
+	}
+	switch strings.ToLower(access.Scheme) {
+	case api.ChSchemeHTTP:
+		// Known value, overwrite it to ensure case-ness
+		access.Scheme = api.ChSchemeHTTP
+	case api.ChSchemeHTTPS:
+		// Known value, overwrite it to ensure case-ness
+		access.Scheme = api.ChSchemeHTTPS
+	case api.ChSchemeAuto:
+		// Known value, overwrite it to ensure case-ness
+		access.Scheme = api.ChSchemeAuto
+	default:
+		// Unknown value, fallback to operator-wide scheme
+		access.Scheme = ""
+	}
+	if tls := access.GetTLS(); tls != nil {
+		// Secrets of TLS material are watched, so rotated material is picked up
+		for _, selector := range []*core.SecretKeySelector{tls.CA, tls.Cert, tls.Key} {
+			if selector != nil {
+				n.ctx.GetTarget().EnsureRuntime().AddReferencedSecret(n.ctx.GetTarget().Namespace, selector.Name)
+			}
+		}
+	}
+	return access
+}
+
+// normalizeScale normalizes .spec.scale
+func (n *Normalizer) normalizeScale(scale *api.ChiScale) *api.ChiScale {
+	if scale == nil {
+		// Scale is optional, the first cluster is reported by /scale subresource
+		return nil
+	}
+	switch strings.ToLower(scale.Target) {
+	case api.ScaleTargetShards:
+		// Known value, overwrite it to ensure case-ness
+		scale.Target = api.ScaleTargetShards
+	default:
+		// Replicas are scaled by default
+		scale.Target = api.ScaleTargetReplicas
+	}
+	if scale.HasReplicas() && (scale.GetReplicas() < 1) {
+		// Cluster has to have at least one shard and one replica
+		log.V(1).M(n.ctx.GetTarget()).F().Warning("skip scale to %d, at least 1 is required", scale.GetReplicas())
+		scale.Replicas = nil
+	}
+	if scale.AllowShardsScaleDown != nil {
+		scale.AllowShardsScaleDown = scale.AllowShardsScaleDown.Normalize(false)
+	}
+	return scale
+}
+
+// defaultMembershipWebhookTimeout specifies default timeout of membership webhook call in seconds
+const defaultMembershipWebhookTimeout = 10
+
+// normalizeReconcilingMembershipWebhook normalizes .spec.reconciling.membershipWebhook
+func (n *Normalizer) normalizeReconcilingMembershipWebhook(webhook *api.ChiMembershipWebhook) *api.ChiMembershipWebhook {
+	if webhook == nil {
+		// Membership webhook is optional
+		return nil
+	}
+	webhook.URL = strings.TrimSpace(webhook.URL)
+	if webhook.Timeout <= 0 {
+		webhook.Timeout = defaultMembershipWebhookTimeout
+	}
+	return webhook
+}
+
 func (n *Normalizer) normalizeReconcilingCleanup(cleanup *api.ChiCleanup) *api.ChiCleanup {
 	if cleanup == nil {
 		cleanup = api.NewChiCleanup()
@@ -573,11 +739,63 @@ func (n *Normalizer) normalizeClusters(clusters []*api.Cluster) []*api.Cluster {
 	clusters = n.ensureClusters(clusters)
 	// Normalize all clusters
 	for i := range clusters {
+		if n.ctx.GetTarget().GetScale().IsScaled(clusters[i], i) {
+			clusters[i] = n.scaleCluster(clusters[i])
+		}
 		clusters[i] = n.normalizeCluster(clusters[i])
 	}
 	return clusters
 }
 
+// scaleCluster applies number of replicas or shards requested by /scale subresource to the cluster layout
+func (n *Normalizer) scaleCluster(cluster *api.Cluster) *api.Cluster {
+	scale := n.ctx.GetTarget().GetScale()
+	if !scale.HasReplicas() {
+		return cluster
+	}
+
+	if cluster == nil {
+		cluster = creator.NewDefaultCluster()
+	}
+	if cluster.Layout == nil {
+		cluster.Layout = api.NewChiClusterLayout()
+	}
+
+	// Explicitly specified shards and replicas can not be removed by scale
+	if scale.IsShards() {
+		cluster.Layout.ShardsCount = scale.GetReplicas()
+		// Removed shards take their data away, thus shards scale down has to be explicitly allowed
+		if current := n.getStatusScaleReplicas(cluster); (cluster.Layout.ShardsCount < current) && !scale.IsShardsScaleDownAllowed() {
+			log.V(1).M(n.ctx.GetTarget()).F().Warning("cluster %s has %d shards, scale down to %d is not allowed, keep %d shards",
+				cluster.Name, current, cluster.Layout.ShardsCount, current)
+			cluster.Layout.ShardsCount = current
+		}
+		if len(cluster.Layout.Shards) > cluster.Layout.ShardsCount {
+			log.V(1).M(n.ctx.GetTarget()).F().Warning("cluster %s has %d shards specified explicitly, can not scale down to %d",
+				cluster.Name, len(cluster.Layout.Shards), cluster.Layout.ShardsCount)
+		}
+	} else {
+		cluster.Layout.ReplicasCount = scale.GetReplicas()
+		if len(cluster.Layout.Replicas) > cluster.Layout.ReplicasCount {
+			log.V(1).M(n.ctx.GetTarget()).F().Warning("cluster %s has %d replicas specified explicitly, can not scale down to %d",
+				cluster.Name, len(cluster.Layout.Replicas), cluster.Layout.ReplicasCount)
+		}
+	}
+
+	return cluster
+}
+
+// getStatusScaleReplicas gets number of replicas or shards of the cluster, as reported by status of the CHI
+func (n *Normalizer) getStatusScaleReplicas(cluster *api.Cluster) int {
+	status := n.ctx.GetTarget().EnsureStatus()
+	selector, err := labels.ConvertSelectorToLabelsMap(status.GetScaleSelector())
+	if (err != nil) || (selector[model.LabelClusterName] != cluster.Name) {
+		// Status reports another cluster
+		return 0
+	}
+	return status.GetScaleReplicas()
+}
+
 // ensureClusters
 func (n *Normalizer) ensureClusters(clusters []*api.Cluster) []*api.Cluster {
 	// May be we have cluster(s) available
@@ -619,6 +837,198 @@ func (n *Normalizer) normalizeConfigurationZookeeper(zk *api.ChiZookeeperConfig)
 	return zk
 }
 
+// normalizeConfigurationKeeper normalizes .spec.configuration.keeper
+func (n *Normalizer) normalizeConfigurationKeeper(keeper *api.ChiKeeper) *api.ChiKeeper {
+	if keeper == nil {
+		return nil
+	}
+
+	// Keeper ensemble has to have at least one node
+	if keeper.Replicas < 1 {
+		keeper.Replicas = 1
+	}
+
+	return keeper
+}
+
+// storageNameRegexp specifies valid name of disk, policy and volume of storage configuration.
+// Names are rendered as XML tag names
+var storageNameRegexp = regexp.MustCompile("^[a-zA-Z_][a-zA-Z0-9_]*$")
+
+// normalizeConfigurationStorage normalizes .spec.configuration.storage
+func (n *Normalizer) normalizeConfigurationStorage(storage *api.ChiStorageConfiguration) *api.ChiStorageConfiguration {
+	if storage == nil {
+		return nil
+	}
+
+	// Each disk has to be named and backed by a known VolumeClaimTemplate.
+	// Disk which is not backed by a PVC would be placed on ephemeral filesystem of the container, so it is skipped.
+	// Built-in `default` disk is managed by ClickHouse itself and can not be redefined
+	var disks []*api.ChiStorageDisk
+	known := map[string]bool{
+		model.StorageDiskDefault: true,
+	}
+	for _, disk := range storage.Disks {
+		switch {
+		case disk == nil:
+			continue
+		case !storageNameRegexp.MatchString(disk.Name) || known[disk.Name]:
+			log.V(1).M(n.ctx.GetTarget()).F().Warning("skip storage disk with invalid or duplicate name %q", disk.Name)
+			continue
+		case !n.hasVolumeClaimTemplate(disk.VolumeClaimTemplate):
+			log.V(1).M(n.ctx.GetTarget()).F().Warning("skip storage disk %q with unknown volumeClaimTemplate %q", disk.Name, disk.VolumeClaimTemplate)
+			continue
+		}
+		known[disk.Name] = true
+		disks = append(disks, disk)
+	}
+	storage.Disks = disks
+
+	// Policy referencing unknown disk makes ClickHouse reject the whole config, so such volumes are skipped
+	var policies []*api.ChiStoragePolicy
+	for _, policy := range storage.Policies {
+		if policy == nil {
+			continue
+		}
+		if !storageNameRegexp.MatchString(policy.Name) {
+			log.V(1).M(n.ctx.GetTarget()).F().Warning("skip storage policy with invalid name %q", policy.Name)
+			continue
+		}
+		policy.Volumes = n.normalizeStoragePolicyVolumes(policy, known)
+		if len(policy.Volumes) == 0 {
+			log.V(1).M(n.ctx.GetTarget()).F().Warning("skip storage policy %q with no volumes", policy.Name)
+			continue
+		}
+		if policy.MoveFactor != "" {
+			if factor, err := strconv.ParseFloat(policy.MoveFactor, 64); (err != nil) || (factor < 0) || (factor > 1) {
+				log.V(1).M(n.ctx.GetTarget()).F().Warning("skip invalid moveFactor %q of storage policy %q", policy.MoveFactor, policy.Name)
+				policy.MoveFactor = ""
+			}
+		}
+		policies = append(policies, policy)
+	}
+	storage.Policies = policies
+
+	return storage
+}
+
+// normalizeStoragePolicyVolumes normalizes volumes of the storage policy.
+// Volumes with invalid names or referencing unknown disks are skipped
+func (n *Normalizer) normalizeStoragePolicyVolumes(policy *api.ChiStoragePolicy, disks map[string]bool) []*api.ChiStoragePolicyVolume {
+	var volumes []*api.ChiStoragePolicyVolume
+	for _, volume := range policy.Volumes {
+		if volume == nil {
+			continue
+		}
+		valid := storageNameRegexp.MatchString(volume.Name) && (len(volume.Disks) > 0)
+		for _, disk := range volume.Disks {
+			valid = valid && disks[disk]
+		}
+		if !valid {
+			log.V(1).M(n.ctx.GetTarget()).F().Warning("skip volume %q of storage policy %q with invalid name or unknown disks %v", volume.Name, policy.Name, volume.Disks)
+			continue
+		}
+		volumes = append(volumes, volume)
+	}
+	return volumes
+}
+
+// hasVolumeClaimTemplate checks whether VolumeClaimTemplate with specified name is specified in the CHI
+func (n *Normalizer) hasVolumeClaimTemplate(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, template := range n.ctx.GetTarget().Spec.Templates.GetVolumeClaimTemplates() {
+		if template.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultAutoKeeperReplicas specifies number of nodes of automatically provisioned Keeper ensemble,
+// which is the smallest ensemble tolerating loss of a node
+const defaultAutoKeeperReplicas = 3
+
+// ensureKeeperOfDefaults provisions Keeper ensemble specified in .spec.defaults.keeper in case CHI has replicated clusters,
+// which need coordination, while neither ZooKeeper nor Keeper is specified in configuration.
+// Keeper provisioned once is kept, even though clusters are not replicated anymore or defaults do not specify it,
+// since replicated tables created already keep their metadata in it. Keeper is dropped only in favour of explicit ZooKeeper.
+// Provisioned Keeper is marked as such, so Keeper specified in configuration explicitly is not kept once it is removed.
+func (n *Normalizer) ensureKeeperOfDefaults(conf *api.Configuration) *api.ChiKeeper {
+	if (conf.Keeper != nil) || !conf.Zookeeper.IsEmpty() {
+		return conf.Keeper
+	}
+
+	keeper := n.ctx.GetTarget().Spec.Defaults.GetKeeper()
+	provisioned := n.getProvisionedKeeper()
+	switch {
+	case (keeper == nil) && (provisioned == nil):
+		return nil
+	case keeper == nil:
+		return provisioned.DeepCopy()
+	case (provisioned == nil) && !hasReplicatedClusters(conf):
+		return nil
+	}
+
+	keeper = keeper.DeepCopy()
+	if keeper.Replicas < 1 {
+		keeper.Replicas = defaultAutoKeeperReplicas
+	}
+	keeper.Provisioned = true
+	return keeper
+}
+
+// getProvisionedKeeper gets Keeper ensemble, which is provisioned out of .spec.defaults.keeper
+// along with the CHI by the last completed reconcile
+func (n *Normalizer) getProvisionedKeeper() *api.ChiKeeper {
+	ancestor := n.ctx.GetAncestor()
+	if (ancestor == nil) || (ancestor.Spec.Configuration == nil) || !ancestor.Spec.Configuration.Keeper.IsProvisioned() {
+		return nil
+	}
+	return ancestor.Spec.Configuration.Keeper
+}
+
+// hasReplicatedClusters checks whether configuration has clusters with replicated shards,
+// which do not have own ZooKeeper specified
+func hasReplicatedClusters(conf *api.Configuration) bool {
+	for _, cluster := range conf.Clusters {
+		if (cluster == nil) || !cluster.Zookeeper.IsEmpty() || (cluster.Layout == nil) {
+			continue
+		}
+		if (cluster.Layout.ReplicasCount > 1) || (len(cluster.Layout.Replicas) > 1) {
+			return true
+		}
+		for i := range cluster.Layout.Shards {
+			shard := &cluster.Layout.Shards[i]
+			if (shard.ReplicasCount > 1) || (len(shard.Hosts) > 1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ensureZookeeperOfKeeper points ClickHouse to the Keeper ensemble managed along with the CHI,
+// unless ZooKeeper nodes are specified explicitly
+func (n *Normalizer) ensureZookeeperOfKeeper(zk *api.ChiZookeeperConfig, keeper *api.ChiKeeper) *api.ChiZookeeperConfig {
+	if (keeper == nil) || !zk.IsEmpty() {
+		return zk
+	}
+
+	if zk == nil {
+		zk = api.NewChiZookeeperConfig()
+	}
+	zk.Nodes = []api.ChiZookeeperNode{
+		{
+			Host: model.CreateKeeperServiceFQDN(n.ctx.GetTarget()),
+			Port: int32(keeper.GetClientPort()),
+		},
+	}
+
+	return zk
+}
+
 type SettingsSubstitution interface {
 	Has(string) bool
 	Get(string) *api.Setting
@@ -673,8 +1083,9 @@ func (n *Normalizer) substSettingsFieldWithSecretFieldValue(
 	settings SettingsSubstitution,
 	dstField,
 	srcSecretRefField string,
+	parseScalarString bool,
 ) bool {
-	return n.substSettingsFieldWithDataFromDataSource(settings, dstField, srcSecretRefField, true,
+	return n.substSettingsFieldWithDataFromDataSource(settings, dstField, srcSecretRefField, parseScalarString,
 		func(secretAddress api.ObjectAddress) (*api.Setting, error) {
 			secretFieldValue, err := n.fetchSecretFieldValue(secretAddress)
 			if err != nil {
@@ -847,6 +1258,8 @@ var ErrSecretValueNotFound = fmt.Errorf("secret value not found")
 // fetchSecretFieldValue fetches the value of the specified field in the specified secret
 // TODO this is the only usage of k8s API in the normalizer. How to remove it?
 func (n *Normalizer) fetchSecretFieldValue(secretAddress api.ObjectAddress) (string, error) {
+	// Secret is referenced even in case it is not available yet, so it is watched for values to appear
+	n.ctx.GetTarget().EnsureRuntime().AddReferencedSecret(secretAddress.Namespace, secretAddress.Name)
 
 	// Fetch the secret
 	secret, err := n.secretGet(secretAddress.Namespace, secretAddress.Name)
@@ -892,13 +1305,21 @@ func (n *Normalizer) normalizeConfigurationUsers(users *api.Settings) *api.Setti
 	// 2. Specify host_regexp for default user as "allowed hosts to visit from"
 	// Add special "chop" user to the list of users, which is used/required for:
 	// 1. Operator to communicate with hosts
+	// Add special least-privilege user managed by CHOp, in case it is enabled, which is used/required for:
+	// 1. Operator to check health of hosts and to reload config
+	managedUsername := n.getManagedUsername()
+	if managedUsername != "" {
+		n.ensureManagedUser(api.NewSettingsUser(users, managedUsername))
+	}
 	usernames := n.normalizeUsersList(
 		// user-based settings contains non-explicit users list in it
 		users,
 		// Add default user which always exists
 		defaultUsername,
 		// Add CHOp user
-		chop.Config().ClickHouse.Access.Username,
+		getAccessUsername(),
+		// Add CHOp-managed user
+		managedUsername,
 	)
 
 	// Normalize each user in the list of users
@@ -912,6 +1333,309 @@ func (n *Normalizer) normalizeConfigurationUsers(users *api.Settings) *api.Setti
 	return users
 }
 
+// appendClickHouseUsers appends users specified by ClickHouseUser resources of the CHI.
+// Users specified in the CHI itself take precedence, so ClickHouseUser is not able to override them.
+func (n *Normalizer) appendClickHouseUsers(users *api.Settings) *api.Settings {
+	chi := n.ctx.GetTarget()
+	if (n.usersGet == nil) || (chi.Name == "") {
+		return users
+	}
+
+	chus := n.usersGet(chi.Namespace, chi.Name)
+	if len(chus) == 0 {
+		return users
+	}
+
+	users = users.Ensure()
+	rejected := CheckClickHouseUsers(chus, users.Groups(), ReservedUsernames())
+	for _, chu := range chus {
+		if err, ok := rejected[chu.Name]; ok {
+			log.V(2).M(chu).F().Info("skip ClickHouseUser %s/%s err: %v", chu.Namespace, chu.Name, err)
+			continue
+		}
+		n.appendClickHouseUser(api.NewSettingsUser(users, chu.GetUsername()), chu)
+	}
+
+	return users
+}
+
+// getAccessUsername gets name of the user CHOp accesses ClickHouse instances with
+func getAccessUsername() string {
+	username, _ := chop.Config().GetAccessCredentials()
+	return username
+}
+
+// ReservedUsernames returns names of the users, which are set up by the operator in each CHI
+func ReservedUsernames() []string {
+	reserved := []string{
+		defaultUsername,
+		getAccessUsername(),
+	}
+	if managedUser := chop.Config().ClickHouse.Access.ManagedUser; managedUser.IsEnabled() {
+		reserved = append(reserved, managedUser.Username)
+	}
+	return reserved
+}
+
+// CheckClickHouseUsers checks whether users specified by ClickHouseUser resources are able to be rendered
+// along with the users specified in the CHI. In case the same user is specified by several resources,
+// the one with the lexicographically first name wins.
+// Returns errors of the rejected resources, indexed by name of the resource.
+func CheckClickHouseUsers(chus []*api.ClickHouseUser, usernames, reserved []string) map[string]error {
+	sorted := append([]*api.ClickHouseUser{}, chus...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	rejected := make(map[string]error)
+	owners := make(map[string]string)
+	for _, chu := range sorted {
+		username := chu.GetUsername()
+		switch {
+		case util.InArray(username, reserved):
+			rejected[chu.Name] = fmt.Errorf("user %s is reserved by the operator", username)
+		case util.InArray(username, usernames):
+			rejected[chu.Name] = fmt.Errorf("user %s is specified in the CHI", username)
+		case owners[username] != "":
+			rejected[chu.Name] = fmt.Errorf("user %s is specified by ClickHouseUser %s", username, owners[username])
+		case checkClickHouseUserGrants(chu.Spec.Grants) != nil:
+			rejected[chu.Name] = checkClickHouseUserGrants(chu.Spec.Grants)
+		default:
+			owners[username] = chu.Name
+		}
+	}
+	return rejected
+}
+
+// checkClickHouseUserGrants checks whether grants of ClickHouseUser are limited to databases of the users.
+// Grants on all databases or on system database, as well as grants the user is able to pass on, are refused,
+// since ClickHouseUser would otherwise be able to take over the whole ClickHouse.
+// Each access element of the grant is checked, identifiers are compared unquoted.
+func checkClickHouseUserGrants(grants []string) error {
+	for _, grant := range grants {
+		targets, err := parseGrantTargets(grant)
+		if err != nil {
+			return fmt.Errorf("grant %q: %v", grant, err)
+		}
+		for _, target := range targets {
+			if !isGrantTargetAllowed(target) {
+				return fmt.Errorf("grant %q: grant on %s is not allowed", grant, strings.Join(target, "."))
+			}
+		}
+	}
+	return nil
+}
+
+// isGrantTargetAllowed checks whether grant target is a table or all tables of a database, other than system ones.
+// Target is expected to specify database explicitly, since the current database of the user is not known in advance.
+func isGrantTargetAllowed(target []string) bool {
+	if len(target) != 2 {
+		return false
+	}
+	database := target[0]
+	if strings.Contains(database, "*") {
+		// All databases or databases by prefix, which may include system ones
+		return false
+	}
+	switch strings.ToLower(database) {
+	case "", "system", "information_schema":
+		return false
+	}
+	return true
+}
+
+// grantToken is a token of GRANT statement
+type grantToken struct {
+	// text is either unquoted identifier, keyword or punctuation
+	text string
+	// quoted specifies text is a quoted identifier, so it is not a keyword
+	quoted bool
+}
+
+// is checks whether token is the keyword or punctuation specified
+func (t grantToken) is(text string) bool {
+	return !t.quoted && strings.EqualFold(t.text, text)
+}
+
+// tokenizeGrant splits GRANT statement into tokens.
+// Identifiers quoted with backticks or double quotes are unquoted, anything but identifiers, dots, commas and
+// parentheses is refused.
+func tokenizeGrant(grant string) ([]grantToken, error) {
+	var tokens []grantToken
+	runes := []rune(grant)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case strings.ContainsRune(".,()", r):
+			tokens = append(tokens, grantToken{text: string(r)})
+			i++
+		case (r == '`') || (r == '"'):
+			var text strings.Builder
+			closed := false
+			for i++; i < len(runes); i++ {
+				switch {
+				case (runes[i] == '\\') && (i+1 < len(runes)):
+					i++
+					text.WriteRune(runes[i])
+				case (runes[i] == r) && (i+1 < len(runes)) && (runes[i+1] == r):
+					i++
+					text.WriteRune(r)
+				case runes[i] == r:
+					closed = true
+				default:
+					text.WriteRune(runes[i])
+				}
+				if closed {
+					i++
+					break
+				}
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated quoted identifier")
+			}
+			tokens = append(tokens, grantToken{text: text.String(), quoted: true})
+		case isGrantIdentifierRune(r):
+			start := i
+			for i < len(runes) && isGrantIdentifierRune(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, grantToken{text: string(runes[start:i])})
+		default:
+			return nil, fmt.Errorf("unexpected %q", r)
+		}
+	}
+	return tokens, nil
+}
+
+// isGrantIdentifierRune checks whether rune is able to be a part of unquoted identifier or wildcard
+func isGrantIdentifierRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || (r == '_') || (r == '*') || (r == '$')
+}
+
+// parseGrantTargets parses GRANT statement without grantee and returns targets of all its access elements,
+// each one as dot-separated parts, such as [db *] for 'db.*'.
+// Grants of roles and grants with grant option are refused.
+func parseGrantTargets(grant string) ([][]string, error) {
+	tokens, err := tokenizeGrant(grant)
+	if err != nil {
+		return nil, err
+	}
+	if (len(tokens) == 0) || !tokens[0].is("GRANT") {
+		return nil, fmt.Errorf("GRANT statement is expected")
+	}
+	i := 1
+	if (len(tokens) > i+2) && tokens[i].is("ON") && tokens[i+1].is("CLUSTER") {
+		i += 3
+	}
+
+	var targets [][]string
+	for {
+		// Privileges of the access element, column lists included, up to ON
+		privileges, depth := 0, 0
+		for ; (i < len(tokens)) && !((depth == 0) && tokens[i].is("ON")); i++ {
+			switch {
+			case tokens[i].is("("):
+				depth++
+			case tokens[i].is(")"):
+				depth--
+			case (depth == 0) && tokens[i].is(","):
+			default:
+				privileges++
+			}
+		}
+		if i >= len(tokens) {
+			return nil, fmt.Errorf("grant on database objects is expected")
+		}
+		if privileges == 0 {
+			return nil, fmt.Errorf("privilege is expected")
+		}
+
+		// Target of the access element, as [database.]table
+		i++
+		var target []string
+		for {
+			if (i >= len(tokens)) || (!tokens[i].quoted && strings.ContainsAny(tokens[i].text, ".,()")) {
+				return nil, fmt.Errorf("database object is expected")
+			}
+			target = append(target, tokens[i].text)
+			i++
+			if (i < len(tokens)) && tokens[i].is(".") {
+				i++
+				continue
+			}
+			break
+		}
+		targets = append(targets, target)
+
+		switch {
+		case i >= len(tokens):
+			return targets, nil
+		case tokens[i].is(","):
+			// Next access element follows
+			i++
+		case tokens[i].is("WITH"):
+			return nil, fmt.Errorf("only grants with no options are allowed")
+		default:
+			return nil, fmt.Errorf("unexpected %q", tokens[i].text)
+		}
+	}
+}
+
+// appendClickHouseUser sets up user settings as specified by ClickHouseUser.
+// Settings are normalized later on the same way as the rest of the users are.
+func (n *Normalizer) appendClickHouseUser(user *api.SettingsUser, chu *api.ClickHouseUser) {
+	spec := chu.Spec
+	if spec.PasswordSHA256Hex != "" {
+		user.Set("password_sha256_hex", api.NewSettingScalar(spec.PasswordSHA256Hex))
+	}
+	if ref := spec.PasswordSecretRef; ref != nil {
+		// Password is read from the Secret and rendered as a hash
+		user.Set("k8s_secret_password", api.NewSettingScalar(chu.Namespace+"/"+ref.Name+"/"+ref.Key))
+	}
+	if spec.Profile != "" {
+		user.Set("profile", api.NewSettingScalar(spec.Profile))
+	}
+	if spec.Quota != "" {
+		user.Set("quota", api.NewSettingScalar(spec.Quota))
+	}
+	if networks := spec.Networks; networks != nil {
+		if len(networks.IP) > 0 {
+			user.Set("networks/ip", api.NewSettingVector(networks.IP))
+		}
+		if networks.HostRegexp != "" {
+			user.Set("networks/host_regexp", api.NewSettingScalar(networks.HostRegexp))
+		}
+	}
+	if len(spec.Grants) > 0 {
+		user.Set("grants/query", api.NewSettingVector(spec.Grants))
+	}
+}
+
+// managedUserGrants specifies minimal grants of the user managed by CHOp
+var managedUserGrants = []string{
+	"GRANT SELECT ON system.clusters",
+	"GRANT SELECT ON system.processes",
+	"GRANT SELECT ON system.replicas",
+	"GRANT SYSTEM RELOAD CONFIG ON *.*",
+	"GRANT SYSTEM RELOAD DICTIONARY ON *.*",
+	"GRANT SYSTEM DROP DNS CACHE ON *.*",
+}
+
+// ensureManagedUser sets up the user managed by CHOp with minimal grants and password from the CHI's Secret
+func (n *Normalizer) ensureManagedUser(user *api.SettingsUser) {
+	// Password is passed via ENV var from the Secret generated by CHOp
+	user.Set(
+		"k8s_secret_env_password",
+		api.NewSettingScalar(model.CreateManagedUserSecretName(n.ctx.GetTarget())+"/"+model.ManagedUserSecretPasswordKey),
+	)
+	user.Delete("password")
+	user.Delete("password_sha256_hex")
+	user.Delete("password_double_sha1_hex")
+	user.Set("grants/query", api.NewSettingVector(managedUserGrants))
+}
+
 func (n *Normalizer) removePlainPassword(user *api.SettingsUser) {
 	// If user has any of encrypted password(s) specified, we need to delete existing plaintext password.
 	// Set `remove` flag for user's plaintext `password`, which is specified as empty in stock ClickHouse users.xml,
@@ -927,22 +1651,88 @@ const (
 )
 
 func (n *Normalizer) normalizeConfigurationUser(user *api.SettingsUser) {
+	n.normalizeConfigurationUserGeneratedPassword(user)
 	n.normalizeConfigurationUserSecretRef(user)
 	n.normalizeConfigurationUserPassword(user)
 	n.normalizeConfigurationUserEnsureMandatoryFields(user)
 }
 
+// normalizeConfigurationUserGeneratedPassword sets password of the user having `generatePassword` specified.
+// Password is read from the Secret of the CHI. Normalization never generates passwords, since CHI is normalized
+// on many occasions besides the reconcile. User, password of which is not found in the Secret, is listed
+// as pending and is provided with the password by the reconciler, which generates and stores it, before users config is rendered.
+func (n *Normalizer) normalizeConfigurationUserGeneratedPassword(user *api.SettingsUser) {
+	if !user.Has("generatePassword") {
+		return
+	}
+	generate := api.StringBool(user.Get("generatePassword").String())
+	user.Delete("generatePassword")
+	if !generate.IsTrue() {
+		return
+	}
+
+	// Generated password overrides any password specified
+	for _, field := range userPasswordFields {
+		user.Delete(field)
+	}
+
+	target := n.ctx.GetTarget()
+	if n.secretGet == nil {
+		target.EnsureRuntime().AddPendingGeneratedPassword(user.Username())
+		return
+	}
+	secret, err := n.secretGet(target.Namespace, model.CreateGeneratedPasswordsSecretName(target))
+	switch {
+	case err == nil:
+		if password, ok := secret.Data[user.Username()]; ok {
+			user.Set("password", api.NewSettingScalar(string(password)))
+			return
+		}
+	case !apiErrors.IsNotFound(err):
+		// Secret may have the password, which can not be overwritten. Users config is not rendered till the Secret is available
+		log.V(1).M(target).F().Warning("unable to read generated password of the user %s err: %v", user.Username(), err)
+		target.EnsureRuntime().SetGeneratedPasswordsError(err)
+	}
+	target.EnsureRuntime().AddPendingGeneratedPassword(user.Username())
+}
+
+// userPasswordFields specifies fields of the user, which specify password
+var userPasswordFields = []string{
+	"password",
+	"password_sha256_hex",
+	"password_double_sha1_hex",
+}
+
 func (n *Normalizer) normalizeConfigurationUserSecretRef(user *api.SettingsUser) {
 	user.WalkSafe(func(name string, _ *api.Setting) {
-		if strings.HasPrefix(name, "k8s_secret_") {
+		switch {
+		case strings.HasPrefix(name, "k8s_secret_"):
 			// TODO remove as obsoleted
 			// Skip this user field, it will be processed later
-		} else {
+		case util.InArray(name, userPasswordFields):
+			n.normalizeConfigurationUserPasswordSecretRef(user, name)
+		default:
 			n.substSettingsFieldWithEnvRefToSecretField(user, name, name, envVarNamePrefixConfigurationUsers, false)
 		}
 	})
 }
 
+// normalizeConfigurationUserPasswordSecretRef substitutes password referring to the secret with the value of the secret.
+// Password is rendered into users config instead of being passed via ENV var,
+// so rotated password is picked up by ClickHouse on the fly, with no restart.
+func (n *Normalizer) normalizeConfigurationUserPasswordSecretRef(user *api.SettingsUser, name string) {
+	if !n.substSettingsFieldWithSecretFieldValue(user, name, name, false) {
+		// Password does not refer to the secret
+		return
+	}
+	if user.Get(name).Type() == api.SettingTypeSource {
+		// Secret is not available. Lock the user out rather than leave it with the default password
+		log.V(1).M(n.ctx.GetTarget()).F().Warning("unable to read password of the user %s, the user is locked", user.Username())
+		user.Delete(name)
+		user.Set("password", api.NewSettingScalar(util.RandStringRange(20, 30)))
+	}
+}
+
 func (n *Normalizer) normalizeConfigurationUserEnsureMandatoryFields(user *api.SettingsUser) {
 	//
 	// Ensure each user has mandatory fields:
@@ -964,7 +1754,7 @@ func (n *Normalizer) normalizeConfigurationUserEnsureMandatoryFields(user *api.S
 		if !n.ctx.Options().DefaultUserInsertHostRegex {
 			hostRegexp = ""
 		}
-	case chop.Config().ClickHouse.Access.Username:
+	case getAccessUsername(), n.getManagedUsername():
 		// User used by CHOp to access ClickHouse instances.
 		ip, _ := chop.Get().ConfigManager.GetRuntimeParam(deployment.OPERATOR_POD_IP)
 
@@ -983,6 +1773,14 @@ func (n *Normalizer) normalizeConfigurationUserEnsureMandatoryFields(user *api.S
 	})
 }
 
+// getManagedUsername returns name of the user managed by CHOp or empty string in case it is not enabled
+func (n *Normalizer) getManagedUsername() string {
+	if managedUser := chop.Config().ClickHouse.Access.ManagedUser; managedUser.IsEnabled() {
+		return managedUser.Username
+	}
+	return ""
+}
+
 type userFields struct {
 	profile    string
 	quota      string
@@ -1010,9 +1808,9 @@ func (n *Normalizer) setMandatoryUserFields(user *api.SettingsUser, fields *user
 // normalizeConfigurationUserPassword deals with user passwords
 func (n *Normalizer) normalizeConfigurationUserPassword(user *api.SettingsUser) {
 	// Values from the secret have higher priority
-	n.substSettingsFieldWithSecretFieldValue(user, "password", "k8s_secret_password")
-	n.substSettingsFieldWithSecretFieldValue(user, "password_sha256_hex", "k8s_secret_password_sha256_hex")
-	n.substSettingsFieldWithSecretFieldValue(user, "password_double_sha1_hex", "k8s_secret_password_double_sha1_hex")
+	n.substSettingsFieldWithSecretFieldValue(user, "password", "k8s_secret_password", true)
+	n.substSettingsFieldWithSecretFieldValue(user, "password_sha256_hex", "k8s_secret_password_sha256_hex", true)
+	n.substSettingsFieldWithSecretFieldValue(user, "password_double_sha1_hex", "k8s_secret_password_double_sha1_hex", true)
 
 	// Values from the secret passed via ENV have even higher priority
 	n.substSettingsFieldWithEnvRefToSecretField(user, "password", "k8s_secret_env_password", envVarNamePrefixConfigurationUsers, true)
@@ -1053,13 +1851,14 @@ func (n *Normalizer) normalizeConfigurationUserPassword(user *api.SettingsUser)
 	// 2. ClickHouse user gets password from his section of CHOp configuration
 	// 3. All the rest users get default password
 	if passwordPlaintext == "" {
+		accessUsername, accessPassword := chop.Config().GetAccessCredentials()
 		switch user.Username() {
 		case defaultUsername:
 			// NB "default" user keeps empty password in here.
-		case chop.Config().ClickHouse.Access.Username:
+		case accessUsername:
 			// User used by CHOp to access ClickHouse instances.
 			// Gets ClickHouse access password from "ClickHouse.Access.Password"
-			passwordPlaintext = chop.Config().ClickHouse.Access.Password
+			passwordPlaintext = accessPassword
 		default:
 			// All the rest users get default password from "ClickHouse.Config.User.Default.Password"
 			passwordPlaintext = chop.Config().ClickHouse.Config.User.Default.Password
@@ -1132,6 +1931,17 @@ func (n *Normalizer) normalizeConfigurationFiles(files *api.Settings) *api.Setti
 	return files
 }
 
+// normalizeConfigurationMacros normalizes .spec.configuration.macros and drops macros which can not be used as XML tags
+func (n *Normalizer) normalizeConfigurationMacros(macros map[string]string) map[string]string {
+	for name := range macros {
+		if !xml.IsValidTagName(name) {
+			log.V(1).M(n.ctx.GetTarget()).F().Warning("macro name is not a valid XML tag name, skip it: %q", name)
+			delete(macros, name)
+		}
+	}
+	return macros
+}
+
 // normalizeCluster normalizes cluster and returns deployments usage counters for this cluster
 func (n *Normalizer) normalizeCluster(cluster *api.Cluster) *api.Cluster {
 	if cluster == nil {
@@ -1144,14 +1954,18 @@ func (n *Normalizer) normalizeCluster(cluster *api.Cluster) *api.Cluster {
 	cluster.InheritZookeeperFrom(n.ctx.GetTarget())
 	// Inherit from .spec.configuration.files
 	cluster.InheritFilesFrom(n.ctx.GetTarget())
+	// Inherit from .spec.configuration.macros
+	cluster.InheritMacrosFrom(n.ctx.GetTarget())
 	// Inherit from .spec.defaults
 	cluster.InheritTemplatesFrom(n.ctx.GetTarget())
 
 	cluster.Zookeeper = n.normalizeConfigurationZookeeper(cluster.Zookeeper)
 	cluster.Settings = n.normalizeConfigurationSettings(cluster.Settings)
 	cluster.Files = n.normalizeConfigurationFiles(cluster.Files)
+	cluster.Macros = n.normalizeConfigurationMacros(cluster.Macros)
 
 	cluster.SchemaPolicy = n.normalizeClusterSchemaPolicy(cluster.SchemaPolicy)
+	cluster.SchemaManagement = n.normalizeClusterSchemaManagement(cluster.SchemaManagement)
 
 	if cluster.Layout == nil {
 		cluster.Layout = api.NewChiClusterLayout()
@@ -1164,17 +1978,22 @@ func (n *Normalizer) normalizeCluster(cluster *api.Cluster) *api.Cluster {
 	n.createHostsField(cluster)
 	n.appendClusterSecretEnvVar(cluster)
 
+	// Names specified explicitly are reserved and can not be taken by auto-generated names
+	shardNames, replicaNames := n.getClusterLayoutExplicitNames(cluster)
+
 	// Loop over all shards and replicas inside shards and fill structure
 	cluster.WalkShards(func(index int, shard *api.ChiShard) error {
-		n.normalizeShard(shard, cluster, index)
+		n.normalizeShard(shard, cluster, index, shardNames)
 		return nil
 	})
 
 	cluster.WalkReplicas(func(index int, replica *api.ChiReplica) error {
-		n.normalizeReplica(replica, cluster, index)
+		n.normalizeReplica(replica, cluster, index, replicaNames)
 		return nil
 	})
 
+	n.removeOrphanHosts(cluster)
+
 	cluster.Layout.HostsField.WalkHosts(func(shard, replica int, host *api.ChiHost) error {
 		n.normalizeHost(host, cluster.GetShard(shard), cluster.GetReplica(replica), cluster, shard, replica)
 		return nil
@@ -1201,6 +2020,54 @@ func (n *Normalizer) createHostsField(cluster *api.Cluster) {
 	cluster.WalkHostsByReplicas(hostMergeFunc)
 }
 
+// getClusterLayoutExplicitNames returns sets of shard and replica names specified explicitly
+func (n *Normalizer) getClusterLayoutExplicitNames(cluster *api.Cluster) (shards, replicas map[string]bool) {
+	shards = make(map[string]bool)
+	replicas = make(map[string]bool)
+	cluster.WalkShards(func(index int, shard *api.ChiShard) error {
+		if (len(shard.Name) > 0) && !model.IsAutoGeneratedShardName(shard.Name, shard, index) {
+			shards[shard.Name] = true
+		}
+		return nil
+	})
+	cluster.WalkReplicas(func(index int, replica *api.ChiReplica) error {
+		if (len(replica.Name) > 0) && !model.IsAutoGeneratedReplicaName(replica.Name, replica, index) {
+			replicas[replica.Name] = true
+		}
+		return nil
+	})
+	return shards, replicas
+}
+
+// removeOrphanHosts removes hosts which do not belong to any shard.
+// Shards and replicas are two views of the same hosts field. In case shards have different number of replicas,
+// view by replicas may reach out for hosts which are not present in view by shards. Such hosts are not deployed.
+func (n *Normalizer) removeOrphanHosts(cluster *api.Cluster) {
+	hosts := make(map[*api.ChiHost]bool)
+	cluster.WalkHosts(func(host *api.ChiHost) error {
+		hosts[host] = true
+		return nil
+	})
+
+	cluster.Layout.HostsField.WalkHosts(func(shard, replica int, host *api.ChiHost) error {
+		if !hosts[host] {
+			cluster.Layout.HostsField.Set(shard, replica, nil)
+		}
+		return nil
+	})
+
+	cluster.WalkReplicas(func(index int, replica *api.ChiReplica) error {
+		var replicaHosts []*api.ChiHost
+		for _, host := range replica.Hosts {
+			if hosts[host] {
+				replicaHosts = append(replicaHosts, host)
+			}
+		}
+		replica.Hosts = replicaHosts
+		return nil
+	})
+}
+
 // normalizeClusterLayoutShardsCountAndReplicasCount ensures at least 1 shard and 1 replica counters
 func (n *Normalizer) normalizeClusterSchemaPolicy(policy *api.SchemaPolicy) *api.SchemaPolicy {
 	if policy == nil {
@@ -1237,6 +2104,21 @@ func (n *Normalizer) normalizeClusterSchemaPolicy(policy *api.SchemaPolicy) *api
 	return policy
 }
 
+// normalizeClusterSchemaManagement normalizes cluster schema management
+func (n *Normalizer) normalizeClusterSchemaManagement(management string) string {
+	switch strings.ToLower(management) {
+	case strings.ToLower(model.SchemaManagementNone):
+		// Known value, overwrite it to ensure case-ness
+		return model.SchemaManagementNone
+	case strings.ToLower(model.SchemaManagementManaged):
+		// Known value, overwrite it to ensure case-ness
+		return model.SchemaManagementManaged
+	default:
+		// Unknown value, fallback to default
+		return model.SchemaManagementManaged
+	}
+}
+
 // normalizeClusterLayoutShardsCountAndReplicasCount ensures at least 1 shard and 1 replica counters
 func (n *Normalizer) normalizeClusterLayoutShardsCountAndReplicasCount(clusterLayout *api.ChiClusterLayout) *api.ChiClusterLayout {
 	if clusterLayout == nil {
@@ -1336,9 +2218,10 @@ func (n *Normalizer) ensureClusterLayoutReplicas(layout *api.ChiClusterLayout) {
 }
 
 // normalizeShard normalizes a shard - walks over all fields
-func (n *Normalizer) normalizeShard(shard *api.ChiShard, cluster *api.Cluster, shardIndex int) {
-	n.normalizeShardName(shard, shardIndex)
+func (n *Normalizer) normalizeShard(shard *api.ChiShard, cluster *api.Cluster, shardIndex int, reserved map[string]bool) {
+	n.normalizeShardName(shard, shardIndex, reserved)
 	n.normalizeShardWeight(shard)
+	n.normalizeShardMaintenance(shard)
 	// For each shard of this normalized cluster inherit from cluster
 	shard.InheritSettingsFrom(cluster)
 	shard.Settings = n.normalizeConfigurationSettings(shard.Settings)
@@ -1353,8 +2236,8 @@ func (n *Normalizer) normalizeShard(shard *api.ChiShard, cluster *api.Cluster, s
 }
 
 // normalizeReplica normalizes a replica - walks over all fields
-func (n *Normalizer) normalizeReplica(replica *api.ChiReplica, cluster *api.Cluster, replicaIndex int) {
-	n.normalizeReplicaName(replica, replicaIndex)
+func (n *Normalizer) normalizeReplica(replica *api.ChiReplica, cluster *api.Cluster, replicaIndex int, reserved map[string]bool) {
+	n.normalizeReplicaName(replica, replicaIndex, reserved)
 	// For each replica of this normalized cluster inherit from cluster
 	replica.InheritSettingsFrom(cluster)
 	replica.Settings = n.normalizeConfigurationSettings(replica.Settings)
@@ -1369,7 +2252,11 @@ func (n *Normalizer) normalizeReplica(replica *api.ChiReplica, cluster *api.Clus
 // normalizeShardReplicasCount ensures shard.ReplicasCount filled properly
 func (n *Normalizer) normalizeShardReplicasCount(shard *api.ChiShard, layoutReplicasCount int) {
 	if shard.ReplicasCount > 0 {
-		// Shard has explicitly specified number of replicas
+		// Shard has explicitly specified number of replicas.
+		// Explicitly specified replicas can not be dropped by the count
+		if len(shard.Hosts) > shard.ReplicasCount {
+			shard.ReplicasCount = len(shard.Hosts)
+		}
 		return
 	}
 
@@ -1393,7 +2280,11 @@ func (n *Normalizer) normalizeShardReplicasCount(shard *api.ChiShard, layoutRepl
 // normalizeReplicaShardsCount ensures replica.ShardsCount filled properly
 func (n *Normalizer) normalizeReplicaShardsCount(replica *api.ChiReplica, layoutShardsCount int) {
 	if replica.ShardsCount > 0 {
-		// Replica has explicitly specified number of shards
+		// Replica has explicitly specified number of shards.
+		// Explicitly specified shards can not be dropped by the count
+		if len(replica.Hosts) > replica.ShardsCount {
+			replica.ShardsCount = len(replica.Hosts)
+		}
 		return
 	}
 
@@ -1414,23 +2305,32 @@ func (n *Normalizer) normalizeReplicaShardsCount(replica *api.ChiReplica, layout
 }
 
 // normalizeShardName normalizes shard name
-func (n *Normalizer) normalizeShardName(shard *api.ChiShard, index int) {
+func (n *Normalizer) normalizeShardName(shard *api.ChiShard, index int, reserved map[string]bool) {
 	if (len(shard.Name) > 0) && !model.IsAutoGeneratedShardName(shard.Name, shard, index) {
 		// Has explicitly specified name already
 		return
 	}
 
-	shard.Name = model.CreateShardName(shard, index)
+	shard.Name = n.createUnreservedName(model.CreateShardName(shard, index), reserved)
 }
 
 // normalizeReplicaName normalizes replica name
-func (n *Normalizer) normalizeReplicaName(replica *api.ChiReplica, index int) {
+func (n *Normalizer) normalizeReplicaName(replica *api.ChiReplica, index int, reserved map[string]bool) {
 	if (len(replica.Name) > 0) && !model.IsAutoGeneratedReplicaName(replica.Name, replica, index) {
 		// Has explicitly specified name already
 		return
 	}
 
-	replica.Name = model.CreateReplicaName(replica, index)
+	replica.Name = n.createUnreservedName(model.CreateReplicaName(replica, index), reserved)
+}
+
+// createUnreservedName returns auto-generated name, suffixed in case it is taken by explicitly specified name
+func (n *Normalizer) createUnreservedName(name string, reserved map[string]bool) string {
+	result := name
+	for suffix := 1; reserved[result]; suffix++ {
+		result = fmt.Sprintf("%s-%d", name, suffix)
+	}
+	return result
 }
 
 // normalizeShardName normalizes shard weight
@@ -1516,3 +2416,10 @@ func (n *Normalizer) normalizeShardInternalReplication(shard *api.ChiShard) {
 	}
 	shard.InternalReplication = shard.InternalReplication.Normalize(defaultInternalReplication)
 }
+
+// normalizeShardMaintenance ensures reasonable values in
+// .spec.configuration.clusters.layout.shards.maintenance
+func (n *Normalizer) normalizeShardMaintenance(shard *api.ChiShard) {
+	// Shards are serving by default
+	shard.Maintenance = shard.Maintenance.Normalize(false)
+}
@@ -0,0 +1,644 @@
+package normalizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/chop"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+)
+
+func newLayoutTestCHI(layout *api.ChiClusterLayout) *api.ClickHouseInstallation {
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+		},
+	}
+	chi.Spec.Configuration = &api.Configuration{
+		Clusters: []*api.Cluster{
+			{
+				Name:   "cluster",
+				Layout: layout,
+			},
+		},
+	}
+
+	n := NewNormalizer(nil)
+	n.ctx = NewContext(NewOptions())
+	n.ctx.SetTarget(chi)
+	for i := range chi.Spec.Configuration.Clusters {
+		chi.Spec.Configuration.Clusters[i] = n.normalizeCluster(chi.Spec.Configuration.Clusters[i])
+	}
+	return chi
+}
+
+func getLayoutTestHostNames(chi *api.ClickHouseInstallation) []string {
+	var names []string
+	chi.WalkHosts(func(host *api.ChiHost) error {
+		names = append(names, host.GetName())
+		return nil
+	})
+	return names
+}
+
+func Test_NormalizeCluster_CountExpansion(t *testing.T) {
+	chi := newLayoutTestCHI(&api.ChiClusterLayout{ShardsCount: 2, ReplicasCount: 2})
+
+	cluster := chi.Spec.Configuration.Clusters[0]
+	require.Equal(t, []string{"0-0", "0-1", "1-0", "1-1"}, getLayoutTestHostNames(chi))
+	require.Equal(t, 4, cluster.Layout.HostsField.HostsCount())
+	require.Equal(t, "0", cluster.Layout.Shards[0].Name)
+	require.Equal(t, "1", cluster.Layout.Shards[1].Name)
+}
+
+func Test_NormalizeCluster_CountWithExplicitShards(t *testing.T) {
+	chi := newLayoutTestCHI(&api.ChiClusterLayout{
+		ShardsCount:   3,
+		ReplicasCount: 2,
+		Shards: []api.ChiShard{
+			// Explicit name collides with auto-generated name of the next shard
+			{Name: "1", ReplicasCount: 1},
+			// Explicit hosts outnumber explicit count
+			{ReplicasCount: 1, Hosts: []*api.ChiHost{{}, {}}},
+		},
+	})
+
+	cluster := chi.Spec.Configuration.Clusters[0]
+	require.Equal(t, []string{"1", "1-1", "2"}, []string{
+		cluster.Layout.Shards[0].Name,
+		cluster.Layout.Shards[1].Name,
+		cluster.Layout.Shards[2].Name,
+	})
+	require.Equal(t, []string{"1-0", "1-1-0", "1-1-1", "2-0", "2-1"}, getLayoutTestHostNames(chi))
+
+	// No orphaned hosts are expected - all hosts belong to shards
+	require.Equal(t, 5, cluster.Layout.HostsField.HostsCount())
+	require.Len(t, cluster.Layout.Replicas[1].Hosts, 2)
+}
+
+func Test_NormalizeCluster_CountChange(t *testing.T) {
+	walk := func(ap *model.ActionPlan, removed bool) (shards, hosts []string) {
+		shardFunc := func(shard *api.ChiShard) { shards = append(shards, shard.Name) }
+		hostFunc := func(host *api.ChiHost) { hosts = append(hosts, host.GetName()) }
+		if removed {
+			ap.WalkRemoved(func(*api.Cluster) {}, shardFunc, hostFunc)
+		} else {
+			ap.WalkAdded(func(*api.Cluster) {}, shardFunc, hostFunc)
+		}
+		sort.Strings(shards)
+		sort.Strings(hosts)
+		return shards, hosts
+	}
+
+	small := func() *api.ClickHouseInstallation {
+		return newLayoutTestCHI(&api.ChiClusterLayout{ShardsCount: 1, ReplicasCount: 1})
+	}
+	large := func() *api.ClickHouseInstallation {
+		return newLayoutTestCHI(&api.ChiClusterLayout{ShardsCount: 2, ReplicasCount: 2})
+	}
+
+	// Scale up
+	shards, hosts := walk(model.NewActionPlan(small(), large()), false)
+	require.Equal(t, []string{"1"}, shards)
+	require.Equal(t, []string{"0-1"}, hosts)
+	shards, hosts = walk(model.NewActionPlan(small(), large()), true)
+	require.Empty(t, shards)
+	require.Empty(t, hosts)
+
+	// Scale down
+	shards, hosts = walk(model.NewActionPlan(large(), small()), true)
+	require.Equal(t, []string{"1"}, shards)
+	require.Equal(t, []string{"0-1"}, hosts)
+	shards, hosts = walk(model.NewActionPlan(large(), small()), false)
+	require.Empty(t, shards)
+	require.Empty(t, hosts)
+}
+
+func Test_NormalizeCluster_DropsInvalidMacros(t *testing.T) {
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+		},
+	}
+	chi.Spec.Configuration = &api.Configuration{
+		Macros: map[string]string{
+			"layer":      "<hot>",
+			"bad name":   "x",
+			"<injected>": "x",
+		},
+		Clusters: []*api.Cluster{
+			{
+				Name:   "cluster",
+				Macros: map[string]string{"1region": "x"},
+			},
+		},
+	}
+
+	n := NewNormalizer(nil)
+	n.ctx = NewContext(NewOptions())
+	n.ctx.SetTarget(chi)
+	cluster := n.normalizeCluster(chi.Spec.Configuration.Clusters[0])
+
+	// Values are kept as is, escaping is up to config generator
+	require.Equal(t, map[string]string{"layer": "<hot>"}, cluster.Macros)
+}
+
+func Test_NormalizeClusters_Scale(t *testing.T) {
+	replicas := int32(3)
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+		},
+		Spec: api.ChiSpec{
+			Scale: &api.ChiScale{
+				Cluster:  "second",
+				Target:   "Shards",
+				Replicas: &replicas,
+			},
+		},
+	}
+
+	n := NewNormalizer(nil)
+	n.ctx = NewContext(NewOptions())
+	n.ctx.SetTarget(chi)
+	chi.Spec.Scale = n.normalizeScale(chi.Spec.Scale)
+	clusters := n.normalizeClusters([]*api.Cluster{
+		{Name: "first", Layout: &api.ChiClusterLayout{ShardsCount: 1, ReplicasCount: 2}},
+		{Name: "second", Layout: &api.ChiClusterLayout{ShardsCount: 1, ReplicasCount: 2}},
+	})
+	chi.Spec.Configuration = &api.Configuration{Clusters: clusters}
+
+	// Only designated cluster is scaled
+	require.Equal(t, api.ScaleTargetShards, chi.Spec.Scale.Target)
+	require.Equal(t, 1, clusters[0].Layout.ShardsCount)
+	require.Equal(t, 3, clusters[1].Layout.ShardsCount)
+	require.Equal(t, 2, clusters[1].Layout.ReplicasCount)
+	require.Equal(t, 6, clusters[1].Layout.HostsField.HostsCount())
+
+	chi.FillSelfCalculatedAddressInfo()
+	n.fillStatusScale()
+	require.Equal(t, 3, chi.Status.GetScaleReplicas())
+	require.Contains(t, chi.Status.GetScaleSelector(), model.LabelClusterName+"=second")
+
+	// Shards scale down is refused unless explicitly allowed
+	two := int32(2)
+	chi.Spec.Scale.Replicas = &two
+	scaled := n.scaleCluster(&api.Cluster{Name: "second", Layout: &api.ChiClusterLayout{ShardsCount: 1}})
+	require.Equal(t, 3, scaled.Layout.ShardsCount)
+	chi.Spec.Scale.AllowShardsScaleDown = api.NewStringBool(true)
+	scaled = n.scaleCluster(&api.Cluster{Name: "second", Layout: &api.ChiClusterLayout{ShardsCount: 1}})
+	require.Equal(t, 2, scaled.Layout.ShardsCount)
+
+	// Status reports number of shards the cluster actually has
+	clusters[1].Layout.ShardsCount = 5
+	n.fillStatusScale()
+	require.Equal(t, 3, chi.Status.GetScaleReplicas())
+
+	// Replicas of the first cluster are scaled by default, invalid number is skipped
+	zero := int32(0)
+	scale := n.normalizeScale(&api.ChiScale{Replicas: &zero})
+	require.Equal(t, api.ScaleTargetReplicas, scale.Target)
+	require.False(t, scale.HasReplicas())
+	require.True(t, scale.IsScaled(clusters[0], 0))
+	require.False(t, scale.IsScaled(clusters[1], 1))
+}
+
+func Test_EnsureManagedUser(t *testing.T) {
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+		},
+	}
+	n := NewNormalizer(nil)
+	n.ctx = NewContext(NewOptions())
+	n.ctx.SetTarget(chi)
+
+	// Settings specified in the CHI can not widen rights or override password of the managed user
+	users := api.NewSettings()
+	users.Set("managed/password", api.NewSettingScalar("qwerty"))
+	users.Set("managed/grants/query", api.NewSettingVector([]string{"GRANT ALL ON *.*"}))
+	user := api.NewSettingsUser(users, "managed")
+
+	n.ensureManagedUser(user)
+	n.normalizeConfigurationUserPassword(user)
+
+	require.Equal(t, []string{
+		"GRANT SELECT ON system.clusters",
+		"GRANT SELECT ON system.processes",
+		"GRANT SELECT ON system.replicas",
+		"GRANT SYSTEM RELOAD CONFIG ON *.*",
+		"GRANT SYSTEM RELOAD DICTIONARY ON *.*",
+		"GRANT SYSTEM DROP DNS CACHE ON *.*",
+	}, users.Get("managed/grants/query").VectorOfStrings())
+	require.False(t, users.Has("managed/password_sha256_hex"))
+	require.True(t, users.Get("managed/password").HasAttribute("from_env"))
+
+	envVars := chi.EnsureRuntime().GetAttributes().AdditionalEnvVars
+	require.Len(t, envVars, 1)
+	require.Equal(t, "chi-managed-user", envVars[0].ValueFrom.SecretKeyRef.Name)
+	require.Equal(t, "password", envVars[0].ValueFrom.SecretKeyRef.Key)
+}
+
+func Test_EnsureKeeperOfDefaults(t *testing.T) {
+	newNormalizer := func(keeper *api.ChiKeeper) *Normalizer {
+		chi := &api.ClickHouseInstallation{}
+		chi.Spec.Defaults = &api.ChiDefaults{Keeper: keeper}
+		n := NewNormalizer(nil)
+		n.ctx = NewContext(NewOptions())
+		n.ctx.SetTarget(chi)
+		return n
+	}
+	replicated := func() *api.Configuration {
+		return &api.Configuration{
+			Clusters: []*api.Cluster{
+				{Name: "cluster", Layout: &api.ChiClusterLayout{ReplicasCount: 2}},
+			},
+		}
+	}
+
+	// Keeper is not provisioned unless opted-in
+	require.Nil(t, newNormalizer(nil).ensureKeeperOfDefaults(replicated()))
+
+	// Keeper is provisioned for replicated clusters with 3 nodes by default
+	keeper := newNormalizer(&api.ChiKeeper{}).ensureKeeperOfDefaults(replicated())
+	require.NotNil(t, keeper)
+	require.Equal(t, 3, keeper.Replicas)
+	require.True(t, keeper.IsProvisioned())
+
+	// Keeper is not provisioned for non-replicated clusters
+	conf := replicated()
+	conf.Clusters[0].Layout.ReplicasCount = 1
+	require.Nil(t, newNormalizer(&api.ChiKeeper{}).ensureKeeperOfDefaults(conf))
+
+	// Explicitly specified ZooKeeper is preferred
+	conf = replicated()
+	conf.Zookeeper = &api.ChiZookeeperConfig{Nodes: []api.ChiZookeeperNode{{Host: "zk"}}}
+	require.Nil(t, newNormalizer(&api.ChiKeeper{}).ensureKeeperOfDefaults(conf))
+
+	// Explicitly specified Keeper is kept as is
+	conf = replicated()
+	conf.Keeper = &api.ChiKeeper{Replicas: 5}
+	require.Equal(t, 5, newNormalizer(&api.ChiKeeper{Replicas: 1}).ensureKeeperOfDefaults(conf).Replicas)
+	require.False(t, newNormalizer(&api.ChiKeeper{Replicas: 1}).ensureKeeperOfDefaults(conf).IsProvisioned())
+
+	// Keeper provisioned once is kept for clusters, which are not replicated anymore
+	withAncestorKeeper := func(n *Normalizer, keeper *api.ChiKeeper) *Normalizer {
+		ancestor := &api.ClickHouseInstallation{}
+		ancestor.Spec.Configuration = replicated()
+		ancestor.Spec.Configuration.Keeper = keeper
+		n.ctx.SetAncestor(ancestor)
+		return n
+	}
+	withAncestor := func(n *Normalizer) *Normalizer {
+		return withAncestorKeeper(n, &api.ChiKeeper{Replicas: 3, Provisioned: true})
+	}
+	conf = replicated()
+	conf.Clusters[0].Layout.ReplicasCount = 1
+	keeper = withAncestor(newNormalizer(&api.ChiKeeper{})).ensureKeeperOfDefaults(conf)
+	require.NotNil(t, keeper)
+	require.Equal(t, 3, keeper.Replicas)
+
+	// Keeper provisioned once is kept in case defaults do not specify it anymore
+	keeper = withAncestor(newNormalizer(nil)).ensureKeeperOfDefaults(conf)
+	require.NotNil(t, keeper)
+	require.Equal(t, 3, keeper.Replicas)
+
+	// Keeper provisioned once is dropped in favour of explicitly specified ZooKeeper
+	conf.Zookeeper = &api.ChiZookeeperConfig{Nodes: []api.ChiZookeeperNode{{Host: "zk"}}}
+	require.Nil(t, withAncestor(newNormalizer(&api.ChiKeeper{})).ensureKeeperOfDefaults(conf))
+
+	// Keeper specified explicitly is not kept once it is removed
+	conf = replicated()
+	require.Nil(t, withAncestorKeeper(newNormalizer(nil), &api.ChiKeeper{Replicas: 3}).ensureKeeperOfDefaults(conf))
+	conf.Clusters[0].Layout.ReplicasCount = 1
+	require.Nil(t, withAncestorKeeper(newNormalizer(&api.ChiKeeper{}), &api.ChiKeeper{Replicas: 3}).ensureKeeperOfDefaults(conf))
+}
+
+func Test_CreateTemplatedCHI_KeepsProvisionedKeeper(t *testing.T) {
+	chop.NewWithConfig(&api.OperatorConfig{})
+	t.Cleanup(func() {
+		chop.NewWithConfig(nil)
+	})
+
+	chi := &api.ClickHouseInstallation{}
+	chi.Spec.Defaults = &api.ChiDefaults{Keeper: &api.ChiKeeper{}}
+	chi.Spec.Configuration = &api.Configuration{
+		Clusters: []*api.Cluster{
+			{Name: "cluster", Layout: &api.ChiClusterLayout{ReplicasCount: 2}},
+		},
+	}
+	normalized, err := NewNormalizer(nil).CreateTemplatedCHI(chi, NewOptions())
+	require.NoError(t, err)
+	require.NotNil(t, normalized.Spec.Configuration.Keeper)
+
+	// Clusters are scaled down to a single replica after Keeper is provisioned.
+	// Ancestor is read out of the status, so the mark of provisioned Keeper has to survive serialization
+	chi.Spec.Configuration.Clusters[0].Layout.ReplicasCount = 1
+	chi.SetAncestor(jsonRoundTrip(t, normalized))
+	normalized, err = NewNormalizer(nil).CreateTemplatedCHI(chi, NewOptions())
+	require.NoError(t, err)
+	require.NotNil(t, normalized.Spec.Configuration.Keeper)
+
+	// Keeper specified explicitly is removed along with its specification
+	chi = &api.ClickHouseInstallation{}
+	chi.Spec.Configuration = &api.Configuration{
+		Keeper: &api.ChiKeeper{Replicas: 3},
+		Clusters: []*api.Cluster{
+			{Name: "cluster", Layout: &api.ChiClusterLayout{ReplicasCount: 2}},
+		},
+	}
+	normalized, err = NewNormalizer(nil).CreateTemplatedCHI(chi, NewOptions())
+	require.NoError(t, err)
+	require.NotNil(t, normalized.Spec.Configuration.Keeper)
+	chi.Spec.Configuration.Keeper = nil
+	chi.SetAncestor(jsonRoundTrip(t, normalized))
+	normalized, err = NewNormalizer(nil).CreateTemplatedCHI(chi, NewOptions())
+	require.NoError(t, err)
+	require.Nil(t, normalized.Spec.Configuration.Keeper)
+}
+
+// jsonRoundTrip serializes and deserializes the CHI, the same way as it is stored in status
+func jsonRoundTrip(t *testing.T, chi *api.ClickHouseInstallation) *api.ClickHouseInstallation {
+	b, err := json.Marshal(chi)
+	require.NoError(t, err)
+	restored := &api.ClickHouseInstallation{}
+	require.NoError(t, json.Unmarshal(b, restored))
+	return restored
+}
+
+func Test_CreateTemplatedCHI_StagesInterserverSecure(t *testing.T) {
+	chop.NewWithConfig(&api.OperatorConfig{})
+	t.Cleanup(func() {
+		chop.NewWithConfig(nil)
+	})
+
+	newCHI := func(ancestor *api.ClickHouseInstallation) *api.ClickHouseInstallation {
+		chi := &api.ClickHouseInstallation{}
+		chi.Spec.CertManager = &api.ChiCertManager{Enabled: api.NewStringBool(true)}
+		chi.Spec.Configuration = &api.Configuration{
+			Clusters: []*api.Cluster{
+				{Name: "cluster", Layout: &api.ChiClusterLayout{ReplicasCount: 2}},
+			},
+		}
+		if ancestor != nil {
+			chi.SetAncestor(ancestor)
+		}
+		return chi
+	}
+
+	// Replicas keep fetching parts over http till hosts are served with certificates
+	normalized, err := NewNormalizer(nil).CreateTemplatedCHI(newCHI(nil), NewOptions())
+	require.NoError(t, err)
+	require.False(t, normalized.GetCertManager().IsInterserverSecure())
+
+	// Hosts are served with certificates by the completed reconcile
+	normalized, err = NewNormalizer(nil).CreateTemplatedCHI(newCHI(normalized), NewOptions())
+	require.NoError(t, err)
+	require.True(t, normalized.GetCertManager().IsInterserverSecure())
+
+	// Explicitly specified value is kept
+	chi := newCHI(normalized)
+	chi.Spec.CertManager.InterserverSecure = api.NewStringBool(false)
+	normalized, err = NewNormalizer(nil).CreateTemplatedCHI(chi, NewOptions())
+	require.NoError(t, err)
+	require.False(t, normalized.GetCertManager().IsInterserverSecure())
+}
+
+func Test_CheckClickHouseUsers(t *testing.T) {
+	newUser := func(name, username string) *api.ClickHouseUser {
+		chu := &api.ClickHouseUser{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: name}}
+		chu.Spec.CHI = "chi"
+		chu.Spec.Username = username
+		return chu
+	}
+	app := newUser("app", "")
+	app.Spec.PasswordSecretRef = &core.SecretKeySelector{LocalObjectReference: core.LocalObjectReference{Name: "app"}, Key: "password"}
+	app.Spec.Profile = "readonly"
+	app.Spec.Grants = []string{"GRANT SELECT ON db.*"}
+	clash := newUser("clash", "admin")
+	duplicate := newUser("zzz", "app")
+	reserved := newUser("reserved", "default")
+
+	escalated := newUser("escalated", "")
+	escalated.Spec.Grants = []string{"GRANT SELECT ON db.*", "GRANT ALL ON *.*"}
+
+	rejected := CheckClickHouseUsers([]*api.ClickHouseUser{duplicate, reserved, clash, app, escalated}, []string{"admin"}, []string{"default"})
+	require.Len(t, rejected, 4)
+	require.Contains(t, rejected, "clash")
+	require.Contains(t, rejected, "zzz")
+	require.Contains(t, rejected, "reserved")
+	require.Contains(t, rejected, "escalated")
+
+	// Grants have to be limited to databases of the users
+	require.NoError(t, checkClickHouseUserGrants([]string{
+		"GRANT SELECT, INSERT ON db.*",
+		"grant select on `db`.`table`",
+		`GRANT SELECT ON "db".*`,
+		"GRANT SELECT(a, b), INSERT ON db.t, ALTER UPDATE ON `my db`.t",
+		"GRANT ON CLUSTER c SELECT ON db.*",
+	}))
+	for _, grant := range []string{
+		"GRANT ALL ON *.*",
+		"GRANT SELECT ON *",
+		"GRANT SELECT ON t",
+		"grant select on  system.*",
+		"GRANT SELECT ON `system`.`users`",
+		"GRANT SELECT ON INFORMATION_SCHEMA.tables",
+		"GRANT SELECT ON db.* WITH GRANT OPTION",
+		"GRANT admin",
+		// Quoted identifiers
+		`GRANT ALL ON "system".*`,
+		`GRANT ALL ON "SYSTEM"."users"`,
+		"GRANT ALL ON `*`.*",
+		"GRANT ALL ON `system",
+		// Each access element is checked
+		"GRANT SELECT ON db.*, ALL ON *.*",
+		"GRANT SELECT(a, b) ON db.t, INSERT ON system.users",
+		`GRANT SELECT ON db.*, INSERT ON "system".*`,
+		"GRANT SELECT ON db.*, admin",
+		// Wildcard databases may include system ones
+		"GRANT SELECT ON s*.*",
+		// Anything but a single statement
+		"GRANT SELECT ON db.*; GRANT ALL ON *.*",
+		"GRANT SELECT ON db.* -- ON *.*",
+		"REVOKE SELECT ON db.*",
+	} {
+		require.Error(t, checkClickHouseUserGrants([]string{grant}), grant)
+	}
+
+	users := api.NewSettings()
+	NewNormalizer(nil).appendClickHouseUser(api.NewSettingsUser(users, app.GetUsername()), app)
+	require.Equal(t, []string{"app"}, users.Groups())
+	require.Equal(t, "ns/app/password", users.Get("app/k8s_secret_password").String())
+	require.Equal(t, "readonly", users.Get("app/profile").String())
+	require.Equal(t, []string{"GRANT SELECT ON db.*"}, users.Get("app/grants/query").VectorOfStrings())
+}
+
+func Test_NormalizeConfigurationUserSecretRef(t *testing.T) {
+	n := NewNormalizer(func(namespace, name string) (*core.Secret, error) {
+		if (namespace != "ns") || (name != "secret") {
+			return nil, fmt.Errorf("secret %s/%s not found", namespace, name)
+		}
+		return &core.Secret{Data: map[string][]byte{"pwd": []byte("qwerty")}}, nil
+	})
+	n.ctx = NewContext(NewOptions())
+	n.ctx.SetTarget(&api.ClickHouseInstallation{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "chi"}})
+
+	newSecretRef := func(name string) *api.Setting {
+		return api.NewSettingSource(&api.SettingSource{
+			ValueFrom: &api.DataSource{
+				SecretKeyRef: &core.SecretKeySelector{
+					LocalObjectReference: core.LocalObjectReference{Name: name},
+					Key:                  "pwd",
+				},
+			},
+		})
+	}
+
+	// Password is read from the secret
+	user := api.NewSettingsUser(api.NewSettings(), "alice")
+	user.Set("password", newSecretRef("secret"))
+	n.normalizeConfigurationUserSecretRef(user)
+	require.Equal(t, "qwerty", user.Get("password").String())
+
+	// Plain password is not treated as secret address
+	user = api.NewSettingsUser(api.NewSettings(), "bob")
+	user.Set("password", api.NewSettingScalar("ns/secret/pwd"))
+	n.normalizeConfigurationUserSecretRef(user)
+	require.Equal(t, "ns/secret/pwd", user.Get("password").String())
+
+	// User with password not available is locked out
+	user = api.NewSettingsUser(api.NewSettings(), "carol")
+	user.Set("password_sha256_hex", newSecretRef("unknown"))
+	n.normalizeConfigurationUserSecretRef(user)
+	require.False(t, user.Has("password_sha256_hex"))
+	require.Equal(t, api.SettingTypeScalar, user.Get("password").Type())
+	require.NotEmpty(t, user.Get("password").String())
+}
+
+func Test_NormalizeConfigurationUserGeneratedPassword(t *testing.T) {
+	var secretErr error
+	n := NewNormalizer(func(namespace, name string) (*core.Secret, error) {
+		if secretErr != nil {
+			return nil, secretErr
+		}
+		require.Equal(t, "chi-generated-passwords", name)
+		return &core.Secret{Data: map[string][]byte{"alice": []byte("qwerty")}}, nil
+	})
+	n.ctx = NewContext(NewOptions())
+	n.ctx.SetTarget(&api.ClickHouseInstallation{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "chi"}})
+	runtime := n.ctx.GetTarget().EnsureRuntime()
+
+	// Password stored in the secret is used
+	user := api.NewSettingsUser(api.NewSettings(), "alice")
+	user.Set("generatePassword", api.NewSettingScalar("true"))
+	user.Set("password_sha256_hex", api.NewSettingScalar("abc"))
+	n.normalizeConfigurationUserGeneratedPassword(user)
+	require.False(t, user.Has("generatePassword"))
+	require.False(t, user.Has("password_sha256_hex"))
+	require.Equal(t, "qwerty", user.Get("password").String())
+	require.Empty(t, runtime.GetPendingGeneratedPasswords())
+
+	// Password not stored in the secret is not generated by normalization
+	user = api.NewSettingsUser(api.NewSettings(), "bob")
+	user.Set("generatePassword", api.NewSettingScalar("yes"))
+	n.normalizeConfigurationUserGeneratedPassword(user)
+	require.False(t, user.Has("password"))
+	require.Equal(t, []string{"bob"}, runtime.GetPendingGeneratedPasswords())
+
+	// Secret not created yet
+	secretErr = apiErrors.NewNotFound(core.Resource("secrets"), "chi-generated-passwords")
+	user = api.NewSettingsUser(api.NewSettings(), "carol")
+	user.Set("generatePassword", api.NewSettingScalar("true"))
+	n.normalizeConfigurationUserGeneratedPassword(user)
+	require.False(t, user.Has("password"))
+	require.Equal(t, []string{"bob", "carol"}, runtime.GetPendingGeneratedPasswords())
+	require.NoError(t, runtime.GetGeneratedPasswordsError())
+
+	// Secret not available, no password is made up, since the secret may have it already
+	secretErr = fmt.Errorf("connection refused")
+	user = api.NewSettingsUser(api.NewSettings(), "dave")
+	user.Set("generatePassword", api.NewSettingScalar("true"))
+	n.normalizeConfigurationUserGeneratedPassword(user)
+	require.False(t, user.Has("password"))
+	require.Error(t, runtime.GetGeneratedPasswordsError())
+
+	// Password is kept as it is
+	user = api.NewSettingsUser(api.NewSettings(), "eve")
+	user.Set("generatePassword", api.NewSettingScalar("false"))
+	user.Set("password", api.NewSettingScalar("secret"))
+	n.normalizeConfigurationUserGeneratedPassword(user)
+	require.False(t, user.Has("generatePassword"))
+	require.Equal(t, "secret", user.Get("password").String())
+}
+
+func Test_NormalizeConfigurationStorage(t *testing.T) {
+	chi := &api.ClickHouseInstallation{
+		Spec: api.ChiSpec{
+			Templates: &api.Templates{
+				VolumeClaimTemplates: []api.VolumeClaimTemplate{{Name: "cold-volume"}},
+			},
+		},
+	}
+	n := NewNormalizer(nil)
+	n.ctx = NewContext(NewOptions())
+	n.ctx.SetTarget(chi)
+
+	storage := n.normalizeConfigurationStorage(&api.ChiStorageConfiguration{
+		Disks: []*api.ChiStorageDisk{
+			{Name: "cold", VolumeClaimTemplate: "cold-volume"},
+			{Name: "lost", VolumeClaimTemplate: "unknown-volume"},
+			{Name: "bad><name", VolumeClaimTemplate: "cold-volume"},
+			{Name: "default", VolumeClaimTemplate: "cold-volume"},
+			{Name: "cold", VolumeClaimTemplate: "cold-volume"},
+		},
+		Policies: []*api.ChiStoragePolicy{
+			{
+				Name:       "tiered",
+				MoveFactor: "0.2",
+				Volumes: []*api.ChiStoragePolicyVolume{
+					{Name: "hot", Disks: []string{"default"}},
+					{Name: "cold", Disks: []string{"cold"}},
+					{Name: "lost", Disks: []string{"lost"}},
+				},
+			},
+			{
+				Name:       "lost",
+				MoveFactor: "2",
+				Volumes: []*api.ChiStoragePolicyVolume{
+					{Name: "lost", Disks: []string{"lost"}},
+				},
+			},
+			{
+				Name: "bad-name",
+				Volumes: []*api.ChiStoragePolicyVolume{
+					{Name: "hot", Disks: []string{"default"}},
+				},
+			},
+		},
+	})
+
+	// Only disk backed by known VolumeClaimTemplate and having valid unique name is kept
+	require.Len(t, storage.Disks, 1)
+	require.Equal(t, "cold", storage.Disks[0].Name)
+
+	// Volumes referencing skipped disks are skipped, policies with no volumes left are skipped
+	require.Len(t, storage.Policies, 1)
+	require.Equal(t, "tiered", storage.Policies[0].Name)
+	require.Equal(t, "0.2", storage.Policies[0].MoveFactor)
+	require.Len(t, storage.Policies[0].Volumes, 2)
+	require.Equal(t, "hot", storage.Policies[0].Volumes[0].Name)
+	require.Equal(t, "cold", storage.Policies[0].Volumes[1].Name)
+}
@@ -37,10 +37,43 @@ func NormalizePodTemplate(replicasCount int, template *api.PodTemplate) {
 	// Spec
 	template.Spec.Affinity = model.MergeAffinity(template.Spec.Affinity, model.NewAffinity(template))
 
+	// DNS
+	normalizePodTemplateDNSPolicy(template)
+
+	// Image pull secrets
+	normalizePodTemplateImagePullSecrets(template)
+}
+
+// normalizePodTemplateImagePullSecrets appends image pull secrets specified on pod template level to the pod spec
+func normalizePodTemplateImagePullSecrets(template *api.PodTemplate) {
+	for _, secret := range template.ImagePullSecrets {
+		if secret.Name == "" {
+			continue
+		}
+		found := false
+		for _, existing := range template.Spec.ImagePullSecrets {
+			if existing.Name == secret.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			template.Spec.ImagePullSecrets = append(template.Spec.ImagePullSecrets, secret)
+		}
+	}
+}
+
+func normalizePodTemplateDNSPolicy(template *api.PodTemplate) {
 	// In case we have hostNetwork specified, we need to have ClusterFirstWithHostNet DNS policy, because of
 	// https://kubernetes.io/docs/concepts/services-networking/dns-pod-service/#pod-s-dns-policy
 	// which tells:  For Pods running with hostNetwork, you should explicitly set its DNS policy “ClusterFirstWithHostNet”.
-	if template.Spec.HostNetwork {
+	// However, explicitly specified custom DNS policy, such as "None" with dnsConfig or "Default", is kept as is,
+	// because on-prem deployments may need to resolve names via node's or custom resolvers.
+	if !template.Spec.HostNetwork {
+		return
+	}
+	switch template.Spec.DNSPolicy {
+	case "", core.DNSClusterFirst:
 		template.Spec.DNSPolicy = core.DNSClusterFirstWithHostNet
 	}
 }
@@ -0,0 +1,79 @@
+package templates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+func Test_NormalizePodTemplate_HostNetworkDNS(t *testing.T) {
+	dnsConfig := &core.PodDNSConfig{
+		Nameservers: []string{"10.0.0.10"},
+		Searches:    []string{"dc1.example.com"},
+	}
+
+	tests := []struct {
+		name        string
+		hostNetwork bool
+		dnsPolicy   core.DNSPolicy
+		dnsConfig   *core.PodDNSConfig
+		want        core.DNSPolicy
+	}{
+		{"no host network", false, "", nil, ""},
+		{"no host network custom policy", false, core.DNSNone, dnsConfig, core.DNSNone},
+		{"host network default policy", true, "", nil, core.DNSClusterFirstWithHostNet},
+		{"host network cluster first", true, core.DNSClusterFirst, nil, core.DNSClusterFirstWithHostNet},
+		{"host network node resolver", true, core.DNSDefault, nil, core.DNSDefault},
+		{"host network custom resolver", true, core.DNSNone, dnsConfig, core.DNSNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := &api.PodTemplate{
+				Name: "pod",
+				Spec: core.PodSpec{
+					HostNetwork: tt.hostNetwork,
+					DNSPolicy:   tt.dnsPolicy,
+					DNSConfig:   tt.dnsConfig,
+				},
+			}
+			NormalizePodTemplate(1, template)
+
+			// Pod spec of the template is applied to StatefulSet's pod as is
+			require.Equal(t, tt.hostNetwork, template.Spec.HostNetwork)
+			require.Equal(t, tt.want, template.Spec.DNSPolicy)
+			require.Equal(t, tt.dnsConfig, template.Spec.DNSConfig)
+		})
+	}
+}
+
+func Test_NormalizePodTemplate_ImagePullSecrets(t *testing.T) {
+	template := &api.PodTemplate{
+		Name: "pod",
+		ImagePullSecrets: []core.LocalObjectReference{
+			{Name: "registry-a"},
+			{Name: "registry-b"},
+			{Name: ""},
+		},
+		Spec: core.PodSpec{
+			ImagePullSecrets: []core.LocalObjectReference{
+				{Name: "registry-b"},
+				{Name: "registry-c"},
+			},
+		},
+	}
+	NormalizePodTemplate(1, template)
+
+	// Template-level secrets are appended to the pod spec without duplicates
+	require.Equal(t, []core.LocalObjectReference{
+		{Name: "registry-b"},
+		{Name: "registry-c"},
+		{Name: "registry-a"},
+	}, template.Spec.ImagePullSecrets)
+
+	// Normalization is idempotent
+	NormalizePodTemplate(1, template)
+	require.Len(t, template.Spec.ImagePullSecrets, 3)
+}
@@ -28,12 +28,19 @@ import (
 // Cluster specifies ClickHouse cluster
 type Cluster struct {
 	*clickhouse.Cluster
+
+	// systemCommands specifies SYSTEM commands permitted to be run
+	systemCommands *SystemCommandsPolicy
+	// onSystemCommandDenied is called on each SYSTEM command, which is denied to be run
+	onSystemCommandDenied func(sql string)
+	// onExecFailed is called on each failed run of SQL queries
+	onExecFailed func(ctx context.Context, err error)
 }
 
 // NewCluster creates new cluster object
 func NewCluster() *Cluster {
 	return &Cluster{
-		clickhouse.NewCluster(),
+		Cluster: clickhouse.NewCluster(),
 	}
 }
 
@@ -46,6 +53,33 @@ func (c *Cluster) SetClusterConnectionParams(clusterConnectionParams *clickhouse
 	return c
 }
 
+// SetSystemCommandsPolicy sets SYSTEM commands policy along with the callback called on each denied command
+func (c *Cluster) SetSystemCommandsPolicy(policy *SystemCommandsPolicy, onDenied func(sql string)) *Cluster {
+	if c == nil {
+		return nil
+	}
+	c.systemCommands = policy
+	c.onSystemCommandDenied = onDenied
+	return c
+}
+
+// SetExecFailedHandler sets the callback called on each failed run of SQL queries
+func (c *Cluster) SetExecFailedHandler(onFailed func(ctx context.Context, err error)) *Cluster {
+	if c == nil {
+		return nil
+	}
+	c.onExecFailed = onFailed
+	return c
+}
+
+// execFailed calls the callback in case run of SQL queries failed
+func (c *Cluster) execFailed(ctx context.Context, err error) error {
+	if (err != nil) && (c.onExecFailed != nil) {
+		c.onExecFailed(ctx, err)
+	}
+	return err
+}
+
 // queryUnzipColumns
 func (c *Cluster) queryUnzipColumns(ctx context.Context, hosts []string, sql string, columns ...*[]string) error {
 	if util.IsContextDone(ctx) {
@@ -105,21 +139,21 @@ func (c *Cluster) QueryUnzipAndApplyUUIDs(ctx context.Context, endpoints []strin
 func (c *Cluster) ExecCHI(ctx context.Context, chi *api.ClickHouseInstallation, SQLs []string, _opts ...*clickhouse.QueryOptions) error {
 	hosts := model.CreateFQDNs(chi, nil, false)
 	opts := clickhouse.QueryOptionsNormalize(_opts...)
-	return c.SetHosts(hosts).ExecAll(ctx, SQLs, opts)
+	return c.execFailed(ctx, c.SetHosts(hosts).ExecAll(ctx, c.filterSystemCommands(SQLs), opts))
 }
 
 // ExecCluster runs set of SQL queries over the cluster
 func (c *Cluster) ExecCluster(ctx context.Context, cluster *api.Cluster, SQLs []string, _opts ...*clickhouse.QueryOptions) error {
 	hosts := model.CreateFQDNs(cluster, nil, false)
 	opts := clickhouse.QueryOptionsNormalize(_opts...)
-	return c.SetHosts(hosts).ExecAll(ctx, SQLs, opts)
+	return c.execFailed(ctx, c.SetHosts(hosts).ExecAll(ctx, c.filterSystemCommands(SQLs), opts))
 }
 
 // ExecShard runs set of SQL queries over the shard replicas
 func (c *Cluster) ExecShard(ctx context.Context, shard *api.ChiShard, SQLs []string, _opts ...*clickhouse.QueryOptions) error {
 	hosts := model.CreateFQDNs(shard, nil, false)
 	opts := clickhouse.QueryOptionsNormalize(_opts...)
-	return c.SetHosts(hosts).ExecAll(ctx, SQLs, opts)
+	return c.execFailed(ctx, c.SetHosts(hosts).ExecAll(ctx, c.filterSystemCommands(SQLs), opts))
 }
 
 // ExecHost runs set of SQL queries over the replica
@@ -132,7 +166,7 @@ func (c *Cluster) ExecHost(ctx context.Context, host *api.ChiHost, SQLs []string
 	} else {
 		c.SetLog(log.New())
 	}
-	return c.ExecAll(ctx, SQLs, opts)
+	return c.execFailed(ctx, c.ExecAll(ctx, c.filterSystemCommands(SQLs), opts))
 }
 
 // QueryHost runs specified query on specified host
@@ -146,7 +180,7 @@ func (c *Cluster) QueryHost(ctx context.Context, host *api.ChiHost, sql string,
 		c.SetLog(log.New())
 	}
 	// Fetch data from any of specified hosts
-	return c.SetHosts(hosts).QueryAny(ctx, sql)
+	return c.SetHosts(hosts).QueryAny(ctx, sql, opts)
 }
 
 // QueryHostInt runs specified query on specified host and returns one int as a result
@@ -186,3 +220,27 @@ func (c *Cluster) QueryHostString(ctx context.Context, host *api.ChiHost, sql st
 
 	return query.String()
 }
+
+// QueryHostUnzip2Columns runs specified query on specified host and unzips query result into two columns
+func (c *Cluster) QueryHostUnzip2Columns(ctx context.Context, host *api.ChiHost, sql string, _opts ...*clickhouse.QueryOptions) ([]string, []string, error) {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("ctx is done")
+		return nil, nil, nil
+	}
+
+	query, err := c.QueryHost(ctx, host, sql, _opts...)
+	defer query.Close()
+	if query == nil {
+		return nil, nil, err
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var column1 []string
+	var column2 []string
+	if err := query.UnzipColumnsAsStrings(&column1, &column2); err != nil {
+		return nil, nil, err
+	}
+	return column1, column2, nil
+}
@@ -0,0 +1,176 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schemer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/model/clickhouse"
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
+// TableDrift describes table, which definition diverges across hosts of a cluster
+type TableDrift struct {
+	// Table is a fully qualified name of the table
+	Table string
+	// Hosts lists hosts, where table definition differs from the prevailing one or table is missing
+	Hosts []string
+}
+
+// String returns string representation of the drift
+func (d TableDrift) String() string {
+	return fmt.Sprintf("%s on %s", d.Table, strings.Join(d.Hosts, ","))
+}
+
+// SchemaDrift is a list of diverged tables
+type SchemaDrift []TableDrift
+
+// HasDrift checks whether any table diverged
+func (d SchemaDrift) HasDrift() bool {
+	return len(d) > 0
+}
+
+// String returns string representation of the drift
+func (d SchemaDrift) String() string {
+	var tables []string
+	for _, table := range d {
+		tables = append(tables, table.String())
+	}
+	return strings.Join(tables, "; ")
+}
+
+// hostSchemaFetcher fetches checksums of definitions of the tables on a host
+type hostSchemaFetcher interface {
+	HostTablesChecksums(ctx context.Context, host *api.ChiHost) (map[string]string, error)
+}
+
+// HostTablesChecksums returns checksums of definitions of the tables on the host, indexed by table name
+func (s *ClusterSchemer) HostTablesChecksums(ctx context.Context, host *api.ChiHost) (map[string]string, error) {
+	opts := clickhouse.NewQueryOptions().SetSilent(true)
+	tables, checksums, err := s.QueryHostUnzip2Columns(ctx, host, s.sqlTablesChecksums(), opts)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]string, len(tables))
+	for i := range tables {
+		res[tables[i]] = checksums[i]
+	}
+	return res, nil
+}
+
+// ClusterCheckSchemaConsistency compares definitions of the tables across all hosts of the cluster
+// and reports tables which definitions diverge.
+// Unreachable hosts are not taken into account.
+func (s *ClusterSchemer) ClusterCheckSchemaConsistency(ctx context.Context, cluster *api.Cluster) (SchemaDrift, error) {
+	return clusterCheckSchemaConsistency(ctx, s, cluster)
+}
+
+// clusterCheckSchemaConsistency fetches schemas of all hosts of the cluster and compares them
+func clusterCheckSchemaConsistency(ctx context.Context, fetcher hostSchemaFetcher, cluster *api.Cluster) (SchemaDrift, error) {
+	schemas := make(map[string]map[string]string)
+	var fetchErr error
+	cluster.WalkHosts(func(host *api.ChiHost) error {
+		if util.IsContextDone(ctx) {
+			return nil
+		}
+		schema, err := fetcher.HostTablesChecksums(ctx, host)
+		if err != nil {
+			log.V(1).M(host).F().Warning("unable to fetch schema of the host %s err: %v", host.GetName(), err)
+			fetchErr = err
+			return nil
+		}
+		schemas[host.GetName()] = schema
+		return nil
+	})
+
+	if (len(schemas) == 0) && (fetchErr != nil) {
+		// No host to compare
+		return nil, fetchErr
+	}
+
+	return compareSchemas(schemas), nil
+}
+
+// compareSchemas compares schemas of the hosts, specified as table checksums indexed by table name, indexed by host name.
+// Definition of a table, which is the most common across hosts, is considered to be the correct one.
+func compareSchemas(schemas map[string]map[string]string) SchemaDrift {
+	// Collect all tables known to any host
+	known := make(map[string]bool)
+	var tables []string
+	for _, schema := range schemas {
+		for table := range schema {
+			if !known[table] {
+				known[table] = true
+				tables = append(tables, table)
+			}
+		}
+	}
+	sort.Strings(tables)
+
+	var drift SchemaDrift
+	for _, table := range tables {
+		// How many hosts have each version of the table. Missing table has empty checksum
+		versions := make(map[string]int)
+		for _, schema := range schemas {
+			versions[schema[table]]++
+		}
+		if len(versions) < 2 {
+			// All hosts agree on the table
+			continue
+		}
+
+		prevailing := prevailingChecksum(versions)
+		var hosts []string
+		for host, schema := range schemas {
+			if schema[table] != prevailing {
+				hosts = append(hosts, host)
+			}
+		}
+		sort.Strings(hosts)
+		drift = append(drift, TableDrift{
+			Table: table,
+			Hosts: hosts,
+		})
+	}
+	return drift
+}
+
+// prevailingChecksum selects the most common checksum. Present table prevails over missing one in case of a tie.
+func prevailingChecksum(versions map[string]int) string {
+	var checksums []string
+	for checksum := range versions {
+		checksums = append(checksums, checksum)
+	}
+	sort.Strings(checksums)
+
+	prevailing := ""
+	count := 0
+	for _, checksum := range checksums {
+		switch {
+		case versions[checksum] > count:
+		case (versions[checksum] == count) && (prevailing == ""):
+		default:
+			continue
+		}
+		prevailing = checksum
+		count = versions[checksum]
+	}
+	return prevailing
+}
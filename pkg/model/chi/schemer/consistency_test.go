@@ -0,0 +1,101 @@
+package schemer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+// fakeSchemaFetcher returns predefined schemas of the hosts
+type fakeSchemaFetcher map[string]map[string]string
+
+func (f fakeSchemaFetcher) HostTablesChecksums(_ context.Context, host *api.ChiHost) (map[string]string, error) {
+	schema, ok := f[host.GetName()]
+	if !ok {
+		return nil, fmt.Errorf("host %s is unreachable", host.GetName())
+	}
+	return schema, nil
+}
+
+func newConsistencyTestCluster(shards, replicas int) *api.Cluster {
+	cluster := &api.Cluster{
+		Name:   "cluster",
+		Layout: api.NewChiClusterLayout(),
+	}
+	for s := 0; s < shards; s++ {
+		shard := api.ChiShard{}
+		for r := 0; r < replicas; r++ {
+			shard.Hosts = append(shard.Hosts, &api.ChiHost{Name: fmt.Sprintf("%d-%d", s, r)})
+		}
+		cluster.Layout.Shards = append(cluster.Layout.Shards, shard)
+	}
+	return cluster
+}
+
+func Test_ClusterCheckSchemaConsistency_Consistent(t *testing.T) {
+	schema := map[string]string{
+		"default.events":       "A1",
+		"default.events_local": "B1",
+	}
+	fetcher := fakeSchemaFetcher{
+		"0-0": schema,
+		"0-1": schema,
+		"1-0": schema,
+		"1-1": schema,
+	}
+
+	drift, err := clusterCheckSchemaConsistency(context.Background(), fetcher, newConsistencyTestCluster(2, 2))
+	require.NoError(t, err)
+	require.False(t, drift.HasDrift())
+}
+
+func Test_ClusterCheckSchemaConsistency_Divergent(t *testing.T) {
+	fetcher := fakeSchemaFetcher{
+		"0-0": {"default.events": "A1", "default.events_local": "B1"},
+		"0-1": {"default.events": "A1", "default.events_local": "B2"},
+		"1-0": {"default.events": "A1", "default.events_local": "B1"},
+		"1-1": {"default.events_local": "B1"},
+	}
+
+	drift, err := clusterCheckSchemaConsistency(context.Background(), fetcher, newConsistencyTestCluster(2, 2))
+	require.NoError(t, err)
+	require.True(t, drift.HasDrift())
+	require.Equal(t, SchemaDrift{
+		{Table: "default.events", Hosts: []string{"1-1"}},
+		{Table: "default.events_local", Hosts: []string{"0-1"}},
+	}, drift)
+	require.Equal(t, "default.events on 1-1; default.events_local on 0-1", drift.String())
+}
+
+func Test_ClusterCheckSchemaConsistency_UnreachableHost(t *testing.T) {
+	schema := map[string]string{
+		"default.events": "A1",
+	}
+
+	// Unreachable host is skipped
+	fetcher := fakeSchemaFetcher{
+		"0-0": schema,
+		"0-1": schema,
+	}
+	drift, err := clusterCheckSchemaConsistency(context.Background(), fetcher, newConsistencyTestCluster(1, 3))
+	require.NoError(t, err)
+	require.False(t, drift.HasDrift())
+
+	// No host is reachable
+	_, err = clusterCheckSchemaConsistency(context.Background(), fakeSchemaFetcher{}, newConsistencyTestCluster(1, 3))
+	require.Error(t, err)
+}
+
+func Test_CompareSchemas_TieKeepsPresentTable(t *testing.T) {
+	drift := compareSchemas(map[string]map[string]string{
+		"0-0": {"default.events": "A1"},
+		"0-1": {},
+	})
+	require.Equal(t, SchemaDrift{
+		{Table: "default.events", Hosts: []string{"0-1"}},
+	}, drift)
+}
@@ -0,0 +1,153 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schemer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	"github.com/altinity/clickhouse-operator/pkg/model/clickhouse"
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
+// getDatabasesSQLs returns a list of databases that needs to be created on a host in a cluster,
+// before replicated and distributed objects are created
+func (s *ClusterSchemer) getDatabasesSQLs(ctx context.Context, host *api.ChiHost) ([]string, []string, error) {
+	if util.IsContextDone(ctx) {
+		log.V(2).Info("ctx is done")
+		return nil, nil, nil
+	}
+
+	var names, sqls []string
+	if shouldCreateReplicatedObjects(host) {
+		n, s := debugCreateSQLs(
+			s.QueryUnzip2Columns(
+				ctx,
+				model.CreateFQDNs(host, api.ClickHouseInstallation{}, false),
+				s.sqlCreateDatabaseReplicated(host.Runtime.Address.ClusterName),
+			),
+		)
+		names = append(names, n...)
+		sqls = append(sqls, s...)
+	}
+	if shouldCreateDistributedObjects(host) {
+		n, s := debugCreateSQLs(
+			s.QueryUnzip2Columns(
+				ctx,
+				model.CreateFQDNs(host, api.ClickHouseInstallation{}, false),
+				s.sqlCreateDatabaseDistributed(host.Runtime.Address.ClusterName),
+			),
+		)
+		names = append(names, n...)
+		sqls = append(sqls, s...)
+	}
+
+	names, sqls = uniqDatabasesSQLs(names, sqls)
+	for i := range sqls {
+		sqls[i] = normalizeCreateDatabaseSQL(sqls[i])
+	}
+	return names, sqls, nil
+}
+
+// uniqDatabasesSQLs removes duplicate databases, keeping the first SQL of the database
+func uniqDatabasesSQLs(names, sqls []string) (uniqNames, uniqSQLs []string) {
+	seen := make(map[string]bool)
+	for i := range names {
+		if seen[names[i]] || (i >= len(sqls)) {
+			continue
+		}
+		seen[names[i]] = true
+		uniqNames = append(uniqNames, names[i])
+		uniqSQLs = append(uniqSQLs, sqls[i])
+	}
+	return uniqNames, uniqSQLs
+}
+
+// replicatedDatabaseEngine matches arguments of Replicated database engine - ZooKeeper path, shard and replica names
+var replicatedDatabaseEngine = regexp.MustCompile(`Engine = Replicated\('([^']*)',\s*'([^']*)',\s*'([^']*)'\)`)
+
+// normalizeCreateDatabaseSQL makes CREATE DATABASE SQL fetched from another replica applicable to the host.
+// Replicated database has to be created with the same ZooKeeper path in order to join the database replicas,
+// but shard and replica names specified literally belong to another replica and are replaced with
+// {shard} and {replica} macros, which are specified by the operator for each host.
+func normalizeCreateDatabaseSQL(sql string) string {
+	return replicatedDatabaseEngine.ReplaceAllStringFunc(sql, func(engine string) string {
+		args := replicatedDatabaseEngine.FindStringSubmatch(engine)
+		zkPath, shard, replica := args[1], args[2], args[3]
+		if !strings.Contains(shard, "{") {
+			shard = "{shard}"
+		}
+		if !strings.Contains(replica, "{") {
+			replica = "{replica}"
+		}
+		return fmt.Sprintf("Engine = Replicated('%s', '%s', '%s')", zkPath, shard, replica)
+	})
+}
+
+// hostSchema specifies schema objects to be created on a host
+type hostSchema struct {
+	databaseNames          []string
+	databaseSQLs           []string
+	replicatedObjectNames  []string
+	replicatedCreateSQLs   []string
+	distributedObjectNames []string
+	distributedCreateSQLs  []string
+}
+
+// hostSchemaExecutor executes SQLs on a host
+type hostSchemaExecutor interface {
+	ExecHost(ctx context.Context, host *api.ChiHost, SQLs []string, opts ...*clickhouse.QueryOptions) error
+}
+
+// hostCreateSchema creates schema objects on a host.
+// Databases are ensured first, since tables can not be created in databases missing on the host.
+func hostCreateSchema(ctx context.Context, executor hostSchemaExecutor, host *api.ChiHost, schema *hostSchema) error {
+	if len(schema.databaseSQLs) > 0 {
+		log.V(1).M(host).F().Info("Creating databases at %s: %v", host.Runtime.Address.HostName, schema.databaseNames)
+		log.V(2).M(host).F().Info("\n%v", schema.databaseSQLs)
+		if err := executor.ExecHost(ctx, host, schema.databaseSQLs, clickhouse.NewQueryOptions().SetRetry(true)); err != nil {
+			log.V(1).M(host).F().Warning("unable to create databases at %s err: %v", host.Runtime.Address.HostName, err)
+			return err
+		}
+	}
+
+	var err1 error
+	if len(schema.replicatedCreateSQLs) > 0 {
+		log.V(1).M(host).F().Info("Creating replicated objects at %s: %v", host.Runtime.Address.HostName, schema.replicatedObjectNames)
+		log.V(2).M(host).F().Info("\n%v", schema.replicatedCreateSQLs)
+		err1 = executor.ExecHost(ctx, host, schema.replicatedCreateSQLs, clickhouse.NewQueryOptions().SetRetry(true))
+	}
+
+	var err2 error
+	if len(schema.distributedCreateSQLs) > 0 {
+		log.V(1).M(host).F().Info("Creating distributed objects at %s: %v", host.Runtime.Address.HostName, schema.distributedObjectNames)
+		log.V(2).M(host).F().Info("\n%v", schema.distributedCreateSQLs)
+		err2 = executor.ExecHost(ctx, host, schema.distributedCreateSQLs, clickhouse.NewQueryOptions().SetRetry(true))
+	}
+
+	if err2 != nil {
+		return err2
+	}
+	if err1 != nil {
+		return err1
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package schemer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/model/clickhouse"
+)
+
+// fakeHostSchemaExecutor records executed SQLs and fails on the specified ones
+type fakeHostSchemaExecutor struct {
+	executed []string
+	fail     map[string]bool
+}
+
+func (f *fakeHostSchemaExecutor) ExecHost(_ context.Context, _ *api.ChiHost, SQLs []string, _ ...*clickhouse.QueryOptions) error {
+	for _, sql := range SQLs {
+		if f.fail[sql] {
+			return fmt.Errorf("unable to exec %s", sql)
+		}
+		f.executed = append(f.executed, sql)
+	}
+	return nil
+}
+
+func newTestHostSchema() *hostSchema {
+	return &hostSchema{
+		databaseNames: []string{"analytics", "replicated"},
+		databaseSQLs: []string{
+			`CREATE DATABASE IF NOT EXISTS "analytics" Engine = Atomic`,
+			`CREATE DATABASE IF NOT EXISTS "replicated" Engine = Replicated('/clickhouse/databases/replicated', '{shard}', '{replica}')`,
+		},
+		replicatedObjectNames:  []string{"events_local"},
+		replicatedCreateSQLs:   []string{`CREATE TABLE IF NOT EXISTS analytics.events_local`},
+		distributedObjectNames: []string{"analytics.events"},
+		distributedCreateSQLs:  []string{`CREATE TABLE IF NOT EXISTS analytics.events`},
+	}
+}
+
+func Test_HostCreateSchema_DatabasesBeforeTables(t *testing.T) {
+	host := &api.ChiHost{Name: "0-1"}
+	schema := newTestHostSchema()
+
+	executor := &fakeHostSchemaExecutor{}
+	require.NoError(t, hostCreateSchema(context.Background(), executor, host, schema))
+	require.Equal(t, []string{
+		schema.databaseSQLs[0],
+		schema.databaseSQLs[1],
+		schema.replicatedCreateSQLs[0],
+		schema.distributedCreateSQLs[0],
+	}, executor.executed)
+
+	// Tables are not created into databases, which failed to be created
+	executor = &fakeHostSchemaExecutor{
+		fail: map[string]bool{schema.databaseSQLs[1]: true},
+	}
+	require.Error(t, hostCreateSchema(context.Background(), executor, host, schema))
+	require.Equal(t, schema.databaseSQLs[:1], executor.executed)
+}
+
+func Test_NormalizeCreateDatabaseSQL(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{
+			name: "atomic",
+			sql:  `CREATE DATABASE IF NOT EXISTS "analytics" Engine = Atomic`,
+			want: `CREATE DATABASE IF NOT EXISTS "analytics" Engine = Atomic`,
+		},
+		{
+			name: "replicated with macros",
+			sql:  `CREATE DATABASE IF NOT EXISTS "db" Engine = Replicated('/clickhouse/databases/db', '{layer}-{shard}', '{replica}')`,
+			want: `CREATE DATABASE IF NOT EXISTS "db" Engine = Replicated('/clickhouse/databases/db', '{layer}-{shard}', '{replica}')`,
+		},
+		{
+			// Literal names belong to the replica the SQL is fetched from, ZooKeeper path is kept
+			name: "replicated with literal names",
+			sql:  `CREATE DATABASE IF NOT EXISTS "db" Engine = Replicated('/clickhouse/databases/db', '0', 'chi-a-cluster-0-0')`,
+			want: `CREATE DATABASE IF NOT EXISTS "db" Engine = Replicated('/clickhouse/databases/db', '{shard}', '{replica}')`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, normalizeCreateDatabaseSQL(tt.sql))
+		})
+	}
+}
+
+func Test_UniqDatabasesSQLs(t *testing.T) {
+	names, sqls := uniqDatabasesSQLs(
+		[]string{"analytics", "logs", "analytics"},
+		[]string{"CREATE analytics", "CREATE logs", "CREATE analytics again"},
+	)
+	require.Equal(t, []string{"analytics", "logs"}, names)
+	require.Equal(t, []string{"CREATE analytics", "CREATE logs"}, sqls)
+}
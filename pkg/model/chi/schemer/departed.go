@@ -0,0 +1,146 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schemer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/model/clickhouse"
+)
+
+// zkReplicasNode is a ZooKeeper node of a replicated table, which lists replicas of the table
+const zkReplicasNode = "/replicas"
+
+// ReplicaPath describes replica registered in ZooKeeper under replicated table's path
+type ReplicaPath struct {
+	// ZKPath is a ZooKeeper path of the replicated table
+	ZKPath string
+	// Replica is a name of the replica
+	Replica string
+}
+
+// String returns string representation of the replica path
+func (p ReplicaPath) String() string {
+	return fmt.Sprintf("%s:%s", p.ZKPath, p.Replica)
+}
+
+// DepartedReplicaMatcher selects replicas, which belong to a departed CHI, by replica name
+type DepartedReplicaMatcher struct {
+	// prefix of replica names of the departed CHI
+	prefix string
+	// excluded specifies prefixes of replica names of alive CHIs, which overlap with the departed CHI's prefix
+	excluded []string
+}
+
+// NewDepartedReplicaMatcher creates new matcher of replicas of the departed CHI.
+// departed specifies replica name prefix of the departed CHI, alive specifies replica name prefixes of alive CHIs
+func NewDepartedReplicaMatcher(departed string, alive ...string) *DepartedReplicaMatcher {
+	m := &DepartedReplicaMatcher{
+		prefix: departed,
+	}
+	for _, prefix := range alive {
+		// Alive CHI, which replica names may start with the departed prefix, shields its replicas
+		if strings.HasPrefix(prefix, departed) {
+			m.excluded = append(m.excluded, prefix)
+		}
+	}
+	return m
+}
+
+// Match checks whether replica belongs to the departed CHI
+func (m *DepartedReplicaMatcher) Match(replica string) bool {
+	if (m == nil) || (m.prefix == "") {
+		return false
+	}
+	if !strings.HasPrefix(replica, m.prefix) {
+		return false
+	}
+	for _, prefix := range m.excluded {
+		if strings.HasPrefix(replica, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// hostReplicaPathsDropper fetches and drops replicas registered in ZooKeeper
+type hostReplicaPathsDropper interface {
+	HostReplicaPaths(ctx context.Context, host *api.ChiHost) ([]ReplicaPath, error)
+	HostDropReplicaPath(ctx context.Context, host *api.ChiHost, replicaPath ReplicaPath) error
+}
+
+// HostReplicaPaths returns replicas registered in ZooKeeper under paths of replicated tables of the host
+func (s *ClusterSchemer) HostReplicaPaths(ctx context.Context, host *api.ChiHost) ([]ReplicaPath, error) {
+	opts := clickhouse.NewQueryOptions().SetSilent(true)
+	paths, replicas, err := s.QueryHostUnzip2Columns(ctx, host, s.sqlReplicasOfZKPaths(), opts)
+	if err != nil {
+		return nil, err
+	}
+	var res []ReplicaPath
+	for i := range paths {
+		res = append(res, ReplicaPath{
+			ZKPath:  strings.TrimSuffix(paths[i], zkReplicasNode),
+			Replica: replicas[i],
+		})
+	}
+	return res, nil
+}
+
+// HostDropReplicaPath calls SYSTEM DROP REPLICA ... FROM ZKPATH
+func (s *ClusterSchemer) HostDropReplicaPath(ctx context.Context, host *api.ChiHost, replicaPath ReplicaPath) error {
+	log.V(1).M(host).F().Info("Drop replica: %s from ZK path: %s", replicaPath.Replica, replicaPath.ZKPath)
+	sql := s.sqlDropReplicaFromZKPath(replicaPath.Replica, replicaPath.ZKPath)
+	return s.ExecHost(ctx, host, []string{sql}, clickhouse.NewQueryOptions().SetRetry(false))
+}
+
+// HostDropDepartedReplicas drops from ZooKeeper replicas of the departed CHI, selected by the matcher,
+// which are registered under paths of replicated tables of the host.
+// Returns list of dropped replicas
+func (s *ClusterSchemer) HostDropDepartedReplicas(
+	ctx context.Context,
+	host *api.ChiHost,
+	matcher *DepartedReplicaMatcher,
+) ([]ReplicaPath, error) {
+	return hostDropDepartedReplicas(ctx, s, host, matcher)
+}
+
+// hostDropDepartedReplicas drops replicas selected by the matcher
+func hostDropDepartedReplicas(
+	ctx context.Context,
+	dropper hostReplicaPathsDropper,
+	host *api.ChiHost,
+	matcher *DepartedReplicaMatcher,
+) (dropped []ReplicaPath, err error) {
+	replicaPaths, err := dropper.HostReplicaPaths(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, replicaPath := range replicaPaths {
+		if !matcher.Match(replicaPath.Replica) {
+			continue
+		}
+		if e := dropper.HostDropReplicaPath(ctx, host, replicaPath); e != nil {
+			log.V(1).M(host).F().Warning("unable to drop replica %s err: %v", replicaPath, e)
+			err = e
+			continue
+		}
+		dropped = append(dropped, replicaPath)
+	}
+	return dropped, err
+}
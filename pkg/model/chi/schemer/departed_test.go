@@ -0,0 +1,90 @@
+package schemer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+// fakeReplicaPathsDropper keeps replicas registered in ZooKeeper and records dropped ones
+type fakeReplicaPathsDropper struct {
+	replicaPaths []ReplicaPath
+	dropped      []ReplicaPath
+	fail         map[string]bool
+}
+
+func (f *fakeReplicaPathsDropper) HostReplicaPaths(_ context.Context, _ *api.ChiHost) ([]ReplicaPath, error) {
+	return f.replicaPaths, nil
+}
+
+func (f *fakeReplicaPathsDropper) HostDropReplicaPath(_ context.Context, _ *api.ChiHost, replicaPath ReplicaPath) error {
+	if f.fail[replicaPath.Replica] {
+		return fmt.Errorf("unable to drop %s", replicaPath)
+	}
+	f.dropped = append(f.dropped, replicaPath)
+	return nil
+}
+
+func Test_DepartedReplicaMatcher_Match(t *testing.T) {
+	matcher := NewDepartedReplicaMatcher("chi-old-", "chi-new-", "chi-old-copy-", "chi-ol-")
+	tests := []struct {
+		replica string
+		want    bool
+	}{
+		{"chi-old-cluster-0-0", true},
+		{"chi-old-cluster-1-1.ns.svc.cluster.local", true},
+		{"chi-new-cluster-0-0", false},
+		// Replica of alive CHI, which name starts with the departed CHI's name
+		{"chi-old-copy-cluster-0-0", false},
+		{"chi-older-cluster-0-0", false},
+		{"old-cluster-0-0", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.replica, func(t *testing.T) {
+			require.Equal(t, tt.want, matcher.Match(tt.replica))
+		})
+	}
+
+	// Empty prefix matches nothing
+	require.False(t, NewDepartedReplicaMatcher("").Match("chi-old-cluster-0-0"))
+	var nilMatcher *DepartedReplicaMatcher
+	require.False(t, nilMatcher.Match("chi-old-cluster-0-0"))
+}
+
+func Test_HostDropDepartedReplicas(t *testing.T) {
+	dropper := &fakeReplicaPathsDropper{
+		replicaPaths: []ReplicaPath{
+			{ZKPath: "/clickhouse/tables/0/default/events", Replica: "chi-new-cluster-0-0"},
+			{ZKPath: "/clickhouse/tables/0/default/events", Replica: "chi-old-cluster-0-0"},
+			{ZKPath: "/clickhouse/tables/0/default/events", Replica: "chi-old-copy-cluster-0-0"},
+			{ZKPath: "/clickhouse/tables/0/default/users", Replica: "chi-old-cluster-0-0"},
+			{ZKPath: "/clickhouse/tables/0/default/users", Replica: "chi-old-cluster-0-1"},
+		},
+		fail: map[string]bool{
+			"chi-old-cluster-0-1": true,
+		},
+	}
+	matcher := NewDepartedReplicaMatcher("chi-old-", "chi-new-", "chi-old-copy-")
+
+	dropped, err := hostDropDepartedReplicas(context.Background(), dropper, &api.ChiHost{Name: "0-0"}, matcher)
+	require.Error(t, err)
+	want := []ReplicaPath{
+		{ZKPath: "/clickhouse/tables/0/default/events", Replica: "chi-old-cluster-0-0"},
+		{ZKPath: "/clickhouse/tables/0/default/users", Replica: "chi-old-cluster-0-0"},
+	}
+	require.Equal(t, want, dropped)
+	require.Equal(t, want, dropper.dropped)
+}
+
+func Test_SqlDropReplicaFromZKPath(t *testing.T) {
+	s := &ClusterSchemer{}
+	require.Equal(t,
+		"SYSTEM DROP REPLICA 'chi-old-cluster-0-0' FROM ZKPATH '/clickhouse/tables/0/default/events'",
+		s.sqlDropReplicaFromZKPath("chi-old-cluster-0-0", "/clickhouse/tables/0/default/events"),
+	)
+}
@@ -16,10 +16,12 @@ package schemer
 
 import (
 	"context"
+	"strings"
 
 	log "github.com/altinity/clickhouse-operator/pkg/announcer"
 	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
 	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	"github.com/altinity/clickhouse-operator/pkg/model/clickhouse"
 	"github.com/altinity/clickhouse-operator/pkg/util"
 )
 
@@ -41,7 +43,7 @@ func shouldCreateDistributedObjects(host *api.ChiHost) bool {
 }
 
 // getDistributedObjectsSQLs returns a list of objects that needs to be created on a shard in a cluster.
-// That includes all distributed tables, corresponding local tables and databases, if necessary
+// That includes all distributed tables and corresponding local tables. Databases are ensured by getDatabasesSQLs
 func (s *ClusterSchemer) getDistributedObjectsSQLs(ctx context.Context, host *api.ChiHost) ([]string, []string, error) {
 	if util.IsContextDone(ctx) {
 		log.V(2).Info("ctx is done")
@@ -53,13 +55,6 @@ func (s *ClusterSchemer) getDistributedObjectsSQLs(ctx context.Context, host *ap
 		return nil, nil, nil
 	}
 
-	databaseNames, createDatabaseSQLs := debugCreateSQLs(
-		s.QueryUnzip2Columns(
-			ctx,
-			model.CreateFQDNs(host, api.ClickHouseInstallation{}, false),
-			s.sqlCreateDatabaseDistributed(host.Runtime.Address.ClusterName),
-		),
-	)
 	tableNames, createTableSQLs := debugCreateSQLs(
 		s.QueryUnzipAndApplyUUIDs(
 			ctx,
@@ -74,7 +69,79 @@ func (s *ClusterSchemer) getDistributedObjectsSQLs(ctx context.Context, host *ap
 			s.sqlCreateFunction(host.Runtime.Address.ClusterName),
 		),
 	)
-	return util.ConcatSlices([][]string{databaseNames, tableNames, functionNames}),
-		util.ConcatSlices([][]string{createDatabaseSQLs, createTableSQLs, createFunctionSQLs}),
+	return util.ConcatSlices([][]string{tableNames, functionNames}),
+		util.ConcatSlices([][]string{createTableSQLs, createFunctionSQLs}),
 		nil
 }
+
+const (
+	// localTableSuffix is a conventional suffix of local tables, Distributed table over which is named without it
+	localTableSuffix = "_local"
+	// distributedTableSuffix is appended to the name of a local table without conventional suffix
+	distributedTableSuffix = "_distributed"
+)
+
+// distributedTableName makes name of the Distributed table over the local table
+func distributedTableName(local string) string {
+	if strings.HasSuffix(local, localTableSuffix) && (local != localTableSuffix) {
+		return strings.TrimSuffix(local, localTableSuffix)
+	}
+	return local + distributedTableSuffix
+}
+
+// distributedTablesCreator creates Distributed tables over replicated tables
+type distributedTablesCreator interface {
+	HostReplicatedTablesNotDistributed(ctx context.Context, host *api.ChiHost) (databases, tables []string, err error)
+	HostCreateDistributedTable(ctx context.Context, host *api.ChiHost, database, local, distributed string) error
+}
+
+// HostReplicatedTablesNotDistributed lists replicated tables of the host's cluster, which are not referenced by any Distributed table
+func (s *ClusterSchemer) HostReplicatedTablesNotDistributed(ctx context.Context, host *api.ChiHost) ([]string, []string, error) {
+	return s.QueryHostUnzip2Columns(ctx, host, s.sqlReplicatedTablesNotDistributed(host.Runtime.Address.ClusterName))
+}
+
+// HostCreateDistributedTable creates Distributed table over the local table on the host, unless it already exists
+func (s *ClusterSchemer) HostCreateDistributedTable(ctx context.Context, host *api.ChiHost, database, local, distributed string) error {
+	sql := s.sqlCreateDistributedTable(host.Runtime.Address.ClusterName, database, local, distributed)
+	return s.ExecHost(ctx, host, []string{sql}, clickhouse.NewQueryOptions().SetRetry(true))
+}
+
+// ClusterCreateDistributedTables ensures every replicated table of the cluster has a Distributed table over it
+// on all hosts of the cluster. Returns names of the Distributed tables
+func (s *ClusterSchemer) ClusterCreateDistributedTables(ctx context.Context, cluster *api.Cluster) ([]string, error) {
+	return clusterCreateDistributedTables(ctx, s, cluster)
+}
+
+// clusterCreateDistributedTables creates Distributed tables over replicated tables on all hosts of the cluster
+func clusterCreateDistributedTables(ctx context.Context, creator distributedTablesCreator, cluster *api.Cluster) ([]string, error) {
+	host := cluster.FirstHost()
+	if host == nil {
+		return nil, nil
+	}
+
+	databases, tables, err := creator.HostReplicatedTablesNotDistributed(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var created []string
+	var createErr error
+	for i := range tables {
+		database := databases[i]
+		local := tables[i]
+		distributed := distributedTableName(local)
+		cluster.WalkHosts(func(host *api.ChiHost) error {
+			if util.IsContextDone(ctx) {
+				return nil
+			}
+			if err := creator.HostCreateDistributedTable(ctx, host, database, local, distributed); err != nil {
+				log.V(1).M(host).F().Warning("unable to create distributed table %s.%s on host %s err: %v", database, distributed, host.GetName(), err)
+				createErr = err
+			}
+			return nil
+		})
+		created = append(created, database+"."+distributed)
+	}
+
+	return created, createErr
+}
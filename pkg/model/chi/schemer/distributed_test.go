@@ -0,0 +1,141 @@
+package schemer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+// fakeDistributedTablesCreator keeps replicated tables of a cluster and records Distributed tables created on hosts
+type fakeDistributedTablesCreator struct {
+	// replicated lists replicated tables as "database.table"
+	replicated []string
+	// distributed maps local table to Distributed table over it, both as "database.table", indexed by host name
+	distributed map[string]map[string]string
+	fail        map[string]bool
+	created     int
+}
+
+func newFakeDistributedTablesCreator(replicated ...string) *fakeDistributedTablesCreator {
+	return &fakeDistributedTablesCreator{
+		replicated:  replicated,
+		distributed: make(map[string]map[string]string),
+		fail:        make(map[string]bool),
+	}
+}
+
+func (f *fakeDistributedTablesCreator) HostReplicatedTablesNotDistributed(_ context.Context, _ *api.ChiHost) ([]string, []string, error) {
+	var databases, tables []string
+	for _, table := range f.replicated {
+		if f.isDistributed(table) {
+			continue
+		}
+		database, name, _ := strings.Cut(table, ".")
+		databases = append(databases, database)
+		tables = append(tables, name)
+	}
+	return databases, tables, nil
+}
+
+func (f *fakeDistributedTablesCreator) isDistributed(table string) bool {
+	for _, distributed := range f.distributed {
+		if _, ok := distributed[table]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeDistributedTablesCreator) HostCreateDistributedTable(_ context.Context, host *api.ChiHost, database, local, distributed string) error {
+	if f.fail[host.GetName()] {
+		return fmt.Errorf("host %s is unreachable", host.GetName())
+	}
+	if f.distributed[host.GetName()] == nil {
+		f.distributed[host.GetName()] = make(map[string]string)
+	}
+	// IF NOT EXISTS keeps already existing table
+	if _, ok := f.distributed[host.GetName()][database+"."+local]; !ok {
+		f.distributed[host.GetName()][database+"."+local] = database + "." + distributed
+		f.created++
+	}
+	return nil
+}
+
+// addShard adds a shard to the cluster, as it is done on scale out
+func addShard(cluster *api.Cluster, replicas int) {
+	s := len(cluster.Layout.Shards)
+	shard := api.ChiShard{}
+	for r := 0; r < replicas; r++ {
+		shard.Hosts = append(shard.Hosts, &api.ChiHost{Name: fmt.Sprintf("%d-%d", s, r)})
+	}
+	cluster.Layout.Shards = append(cluster.Layout.Shards, shard)
+}
+
+func Test_DistributedTableName(t *testing.T) {
+	require.Equal(t, "events", distributedTableName("events_local"))
+	require.Equal(t, "events_distributed", distributedTableName("events"))
+	require.Equal(t, "_local_distributed", distributedTableName("_local"))
+}
+
+func Test_ClusterCreateDistributedTables_AddShard(t *testing.T) {
+	creator := newFakeDistributedTablesCreator("default.events_local", "default.metrics")
+	cluster := newConsistencyTestCluster(2, 2)
+
+	// Shard is added to the cluster
+	addShard(cluster, 2)
+
+	created, err := clusterCreateDistributedTables(context.Background(), creator, cluster)
+	require.NoError(t, err)
+	require.Equal(t, []string{"default.events", "default.metrics_distributed"}, created)
+
+	// Distributed tables are created on all hosts, including new ones
+	for _, host := range []string{"0-0", "0-1", "1-0", "1-1", "2-0", "2-1"} {
+		require.Equal(t, map[string]string{
+			"default.events_local": "default.events",
+			"default.metrics":      "default.metrics_distributed",
+		}, creator.distributed[host], host)
+	}
+	require.Equal(t, 12, creator.created)
+}
+
+func Test_ClusterCreateDistributedTables_Idempotent(t *testing.T) {
+	creator := newFakeDistributedTablesCreator("default.events_local")
+	cluster := newConsistencyTestCluster(1, 2)
+
+	_, err := clusterCreateDistributedTables(context.Background(), creator, cluster)
+	require.NoError(t, err)
+	require.Equal(t, 2, creator.created)
+
+	// Another shard is added later on - replicated table is already covered by Distributed one
+	addShard(cluster, 2)
+	created, err := clusterCreateDistributedTables(context.Background(), creator, cluster)
+	require.NoError(t, err)
+	require.Empty(t, created)
+	require.Equal(t, 2, creator.created)
+
+}
+
+func Test_ClusterCreateDistributedTables_UnreachableHost(t *testing.T) {
+	creator := newFakeDistributedTablesCreator("default.events_local")
+	creator.fail["1-0"] = true
+	cluster := newConsistencyTestCluster(2, 1)
+
+	created, err := clusterCreateDistributedTables(context.Background(), creator, cluster)
+	require.Error(t, err)
+	require.Equal(t, []string{"default.events"}, created)
+	require.Contains(t, creator.distributed, "0-0")
+	require.NotContains(t, creator.distributed, "1-0")
+}
+
+func Test_SQLCreateDistributedTable(t *testing.T) {
+	s := &ClusterSchemer{}
+	require.Equal(t,
+		`CREATE TABLE IF NOT EXISTS "default"."events" AS "default"."events_local" ENGINE = Distributed('cluster', 'default', 'events_local', rand())`,
+		s.sqlCreateDistributedTable("cluster", "default", "events_local", "events"),
+	)
+}
@@ -63,13 +63,6 @@ func (s *ClusterSchemer) getReplicatedObjectsSQLs(ctx context.Context, host *api
 		return nil, nil, nil
 	}
 
-	databaseNames, createDatabaseSQLs := debugCreateSQLs(
-		s.QueryUnzip2Columns(
-			ctx,
-			model.CreateFQDNs(host, api.ClickHouseInstallation{}, false),
-			s.sqlCreateDatabaseReplicated(host.Runtime.Address.ClusterName),
-		),
-	)
 	tableNames, createTableSQLs := debugCreateSQLs(
 		s.QueryUnzipAndApplyUUIDs(
 			ctx,
@@ -84,7 +77,7 @@ func (s *ClusterSchemer) getReplicatedObjectsSQLs(ctx context.Context, host *api
 			s.sqlCreateFunction(host.Runtime.Address.ClusterName),
 		),
 	)
-	return util.ConcatSlices([][]string{databaseNames, tableNames, functionNames}),
-		util.ConcatSlices([][]string{createDatabaseSQLs, createTableSQLs, createFunctionSQLs}),
+	return util.ConcatSlices([][]string{tableNames, functionNames}),
+		util.ConcatSlices([][]string{createTableSQLs, createFunctionSQLs}),
 		nil
 }
@@ -0,0 +1,72 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schemer
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi"
+	"github.com/altinity/clickhouse-operator/pkg/model/clickhouse"
+)
+
+// HostDropTablesMatching drops tables of the host matching the pattern of tables, as accepted by clickhouse-backup.
+// Empty pattern matches all tables.
+func (s *ClusterSchemer) HostDropTablesMatching(ctx context.Context, host *api.ChiHost, pattern string) error {
+	sql := heredoc.Docf(`
+		SELECT
+			DISTINCT concat(database, '.', name) AS table,
+			concat('DROP TABLE IF EXISTS "', database, '"."', name, '" SYNC') AS drop_table_query
+		FROM
+			system.tables
+		WHERE
+			database NOT IN (%s) AND
+			(engine like '%%MergeTree%%' OR engine like '%%View%%')
+		`,
+		ignoredDBs,
+	)
+	names, dropTableSQLs, err := s.QueryUnzip2Columns(ctx, chi.CreateFQDNs(host, api.ChiHost{}, false), sql)
+	if err != nil {
+		return err
+	}
+
+	var matching []string
+	for i := range names {
+		if matchTablesPattern(names[i], pattern) {
+			matching = append(matching, dropTableSQLs[i])
+		}
+	}
+	log.V(1).M(host).F().Info("Drop tables matching %q: %v", pattern, matching)
+	return s.ExecHost(ctx, host, matching, clickhouse.NewQueryOptions().SetRetry(false))
+}
+
+// matchTablesPattern checks whether table, specified as 'database.table', matches the pattern of tables.
+// Pattern is a comma-separated list of shell patterns, as accepted by clickhouse-backup --tables.
+func matchTablesPattern(table, pattern string) bool {
+	if strings.TrimSpace(pattern) == "" {
+		return true
+	}
+	for _, p := range strings.Split(pattern, ",") {
+		if matched, _ := path.Match(strings.TrimSpace(p), table); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,15 @@
+package schemer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MatchTablesPattern(t *testing.T) {
+	require.True(t, matchTablesPattern("db.table", ""))
+	require.True(t, matchTablesPattern("db.table", "db.*"))
+	require.True(t, matchTablesPattern("db.table", "other.*, db.tab?e"))
+	require.False(t, matchTablesPattern("db.table", "other.*"))
+	require.False(t, matchTablesPattern("db2.table", "db.*"))
+}
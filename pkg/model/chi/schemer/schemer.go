@@ -16,6 +16,8 @@ package schemer
 
 import (
 	"context"
+	"strconv"
+	"strings"
 	"time"
 
 	log "github.com/altinity/clickhouse-operator/pkg/announcer"
@@ -29,6 +31,9 @@ import (
 // ClusterSchemer specifies cluster schema manager
 type ClusterSchemer struct {
 	*Cluster
+	// managed specifies cluster accessed as least-privilege user managed by the operator.
+	// Used for health checks and config reload, in case specified
+	managed *Cluster
 	version *swversion.SoftWareVersion
 }
 
@@ -40,6 +45,46 @@ func NewClusterSchemer(clusterConnectionParams *clickhouse.ClusterConnectionPara
 	}
 }
 
+// SetManagedUserConnectionParams sets connection params of the least-privilege user managed by the operator
+func (s *ClusterSchemer) SetManagedUserConnectionParams(clusterConnectionParams *clickhouse.ClusterConnectionParams) *ClusterSchemer {
+	if s == nil {
+		return nil
+	}
+	s.managed = NewCluster().
+		SetClusterConnectionParams(clusterConnectionParams).
+		SetSystemCommandsPolicy(s.systemCommands, s.onSystemCommandDenied).
+		SetExecFailedHandler(s.onExecFailed)
+	return s
+}
+
+// SetSystemCommandsPolicy sets SYSTEM commands policy along with the callback called on each denied command
+func (s *ClusterSchemer) SetSystemCommandsPolicy(policy *SystemCommandsPolicy, onDenied func(sql string)) *ClusterSchemer {
+	if s == nil {
+		return nil
+	}
+	s.Cluster.SetSystemCommandsPolicy(policy, onDenied)
+	s.managed.SetSystemCommandsPolicy(policy, onDenied)
+	return s
+}
+
+// SetExecFailedHandler sets the callback called on each failed run of SQL queries
+func (s *ClusterSchemer) SetExecFailedHandler(onFailed func(ctx context.Context, err error)) *ClusterSchemer {
+	if s == nil {
+		return nil
+	}
+	s.Cluster.SetExecFailedHandler(onFailed)
+	s.managed.SetExecFailedHandler(onFailed)
+	return s
+}
+
+// health returns cluster to run health checks and config reload on
+func (s *ClusterSchemer) health() *Cluster {
+	if s.managed != nil {
+		return s.managed
+	}
+	return s.Cluster
+}
+
 // HostSyncTables calls SYSTEM SYNC REPLICA for replicated tables
 func (s *ClusterSchemer) HostSyncTables(ctx context.Context, host *api.ChiHost) error {
 	tableNames, syncTableSQLs, _ := s.sqlSyncTable(ctx, host)
@@ -58,24 +103,21 @@ func (s *ClusterSchemer) HostDropReplica(ctx context.Context, hostToRunOn, hostT
 }
 
 // createTablesSQLs makes all SQL for migrating tables
-func (s *ClusterSchemer) createTablesSQLs(
-	ctx context.Context,
-	host *api.ChiHost,
-) (
-	replicatedObjectNames []string,
-	replicatedCreateSQLs []string,
-	distributedObjectNames []string,
-	distributedCreateSQLs []string,
-) {
+func (s *ClusterSchemer) createTablesSQLs(ctx context.Context, host *api.ChiHost) *hostSchema {
+	schema := &hostSchema{}
+	if names, sql, err := s.getDatabasesSQLs(ctx, host); err == nil {
+		schema.databaseNames = names
+		schema.databaseSQLs = sql
+	}
 	if names, sql, err := s.getReplicatedObjectsSQLs(ctx, host); err == nil {
-		replicatedObjectNames = names
-		replicatedCreateSQLs = sql
+		schema.replicatedObjectNames = names
+		schema.replicatedCreateSQLs = sql
 	}
 	if names, sql, err := s.getDistributedObjectsSQLs(ctx, host); err == nil {
-		distributedObjectNames = names
-		distributedCreateSQLs = sql
+		schema.distributedObjectNames = names
+		schema.distributedCreateSQLs = sql
 	}
-	return
+	return schema
 }
 
 // HostCreateTables creates tables on a new host
@@ -88,33 +130,7 @@ func (s *ClusterSchemer) HostCreateTables(ctx context.Context, host *api.ChiHost
 	log.V(1).M(host).F().S().Info("Migrating schema objects to host %s", host.Runtime.Address.HostName)
 	defer log.V(1).M(host).F().E().Info("Migrating schema objects to host %s", host.Runtime.Address.HostName)
 
-	replicatedObjectNames,
-		replicatedCreateSQLs,
-		distributedObjectNames,
-		distributedCreateSQLs := s.createTablesSQLs(ctx, host)
-
-	var err1 error
-	if len(replicatedCreateSQLs) > 0 {
-		log.V(1).M(host).F().Info("Creating replicated objects at %s: %v", host.Runtime.Address.HostName, replicatedObjectNames)
-		log.V(2).M(host).F().Info("\n%v", replicatedCreateSQLs)
-		err1 = s.ExecHost(ctx, host, replicatedCreateSQLs, clickhouse.NewQueryOptions().SetRetry(true))
-	}
-
-	var err2 error
-	if len(distributedCreateSQLs) > 0 {
-		log.V(1).M(host).F().Info("Creating distributed objects at %s: %v", host.Runtime.Address.HostName, distributedObjectNames)
-		log.V(2).M(host).F().Info("\n%v", distributedCreateSQLs)
-		err2 = s.ExecHost(ctx, host, distributedCreateSQLs, clickhouse.NewQueryOptions().SetRetry(true))
-	}
-
-	if err2 != nil {
-		return err2
-	}
-	if err1 != nil {
-		return err1
-	}
-
-	return nil
+	return hostCreateSchema(ctx, s, host, s.createTablesSQLs(ctx, host))
 }
 
 // HostDropTables drops tables on a host
@@ -124,12 +140,18 @@ func (s *ClusterSchemer) HostDropTables(ctx context.Context, host *api.ChiHost)
 	return s.ExecHost(ctx, host, dropTableSQLs, clickhouse.NewQueryOptions().SetRetry(false))
 }
 
+// hostInClusterTries specifies number of tries to check whether host is a member of the cluster
+const hostInClusterTries = 3
+
 // IsHostInCluster checks whether host is a member of at least one ClickHouse cluster
 func (s *ClusterSchemer) IsHostInCluster(ctx context.Context, host *api.ChiHost) bool {
 	inside := false
 	SQLs := []string{s.sqlHostInCluster()}
+	// Host being not reachable for a moment does not mean it is outside of the cluster,
+	// so connection-level errors are retried, while query failure is reported right away
 	opts := clickhouse.NewQueryOptions().SetSilent(true)
-	err := s.ExecHost(ctx, host, SQLs, opts)
+	opts.Tries = hostInClusterTries
+	err := s.health().ExecHost(ctx, host, SQLs, opts)
 	if err == nil {
 		log.V(1).M(host).F().Info("The host %s is inside the cluster", host.GetName())
 		inside = true
@@ -140,22 +162,100 @@ func (s *ClusterSchemer) IsHostInCluster(ctx context.Context, host *api.ChiHost)
 	return inside
 }
 
+// IsHostReadyByQuery checks whether specified readiness query returns truthy result on the host.
+// Query is provided by the user, so it runs in readonly mode, which forbids to modify anything
+// and to reach other hosts via table functions on behalf of the operator user
+func (s *ClusterSchemer) IsHostReadyByQuery(ctx context.Context, host *api.ChiHost, sql string) bool {
+	ctx = clickhouse.WithSettings(ctx, clickhouse.NewReadonlySettings())
+	value, err := s.QueryHostString(ctx, host, sql, clickhouse.NewQueryOptions().SetSilent(true))
+	if err != nil {
+		log.V(1).M(host).F().Info("Readiness query failed on the host %s err: %v", host.GetName(), err)
+		return false
+	}
+	if !isTruthy(value) {
+		log.V(1).M(host).F().Info("Readiness query returned %q on the host %s", value, host.GetName())
+		return false
+	}
+	return true
+}
+
+// isTruthy checks whether query result is truthy - non-zero number or "true"
+func isTruthy(value string) bool {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, "true") {
+		return true
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	return (err == nil) && (number != 0)
+}
+
+// IsHostKnownToPeer checks whether the peer host has the host listed in its cluster configuration
+func (s *ClusterSchemer) IsHostKnownToPeer(ctx context.Context, peer, host *api.ChiHost) bool {
+	sql := s.sqlClusterHasHost(model.CreateInstanceHostname(host))
+	opts := clickhouse.NewQueryOptions().SetSilent(true)
+	if count, err := s.health().QueryHostInt(ctx, peer, sql, opts); (err != nil) || (count == 0) {
+		log.V(1).M(peer).F().Info("The host %s is not known to the host %s yet", host.GetName(), peer.GetName())
+		return false
+	}
+	log.V(1).M(peer).F().Info("The host %s is known to the host %s", host.GetName(), peer.GetName())
+	return true
+}
+
 // CHIDropDnsCache runs 'DROP DNS CACHE' over the whole CHI
 func (s *ClusterSchemer) CHIDropDnsCache(ctx context.Context, chi *api.ClickHouseInstallation) error {
 	chi.WalkHosts(func(host *api.ChiHost) error {
-		return s.ExecHost(ctx, host, []string{s.sqlDropDNSCache()})
+		return s.health().ExecHost(ctx, host, []string{s.sqlDropDNSCache()})
 	})
 	return nil
 }
 
+// HostReloadConfig runs 'RELOAD CONFIG' on the host
+func (s *ClusterSchemer) HostReloadConfig(ctx context.Context, host *api.ChiHost) error {
+	return s.health().ExecHost(ctx, host, []string{s.sqlReloadConfig()})
+}
+
+// HostReloadDictionaries runs 'RELOAD DICTIONARIES' on the host
+func (s *ClusterSchemer) HostReloadDictionaries(ctx context.Context, host *api.ChiHost) error {
+	return s.health().ExecHost(ctx, host, []string{s.sqlReloadDictionaries()})
+}
+
+// HostStopMerges runs 'STOP MERGES' on the host.
+// Merges are not stopped in case either of 'STOP MERGES' or 'START MERGES' is denied,
+// since merges stopped are not able to be started again
+func (s *ClusterSchemer) HostStopMerges(ctx context.Context, host *api.ChiHost) error {
+	if err := s.checkSystemCommands(s.sqlStopMerges(), s.sqlStartMerges()); err != nil {
+		return err
+	}
+	return s.ExecHost(ctx, host, []string{s.sqlStopMerges()})
+}
+
+// HostStartMerges runs 'START MERGES' on the host
+func (s *ClusterSchemer) HostStartMerges(ctx context.Context, host *api.ChiHost) error {
+	if err := s.checkSystemCommands(s.sqlStartMerges()); err != nil {
+		return err
+	}
+	return s.ExecHost(ctx, host, []string{s.sqlStartMerges()})
+}
+
 // HostActiveQueriesNum returns how many active queries are on the host
 func (s *ClusterSchemer) HostActiveQueriesNum(ctx context.Context, host *api.ChiHost) (int, error) {
-	return s.QueryHostInt(ctx, host, s.sqlActiveQueriesNum())
+	return s.health().QueryHostInt(ctx, host, s.sqlActiveQueriesNum())
+}
+
+// HostReadonlyReplicasNum returns how many replicated tables on the host are not able to replicate,
+// being read-only or having ZooKeeper session expired
+func (s *ClusterSchemer) HostReadonlyReplicasNum(ctx context.Context, host *api.ChiHost) (int, error) {
+	return s.health().QueryHostInt(ctx, host, s.sqlReadonlyReplicasNum())
 }
 
 // HostClickHouseVersion returns ClickHouse version on the host
 func (s *ClusterSchemer) HostClickHouseVersion(ctx context.Context, host *api.ChiHost) (string, error) {
-	return s.QueryHostString(ctx, host, s.sqlVersion())
+	return s.health().QueryHostString(ctx, host, s.sqlVersion())
+}
+
+// HostServerUUID returns UUID of ClickHouse server on the host
+func (s *ClusterSchemer) HostServerUUID(ctx context.Context, host *api.ChiHost) (string, error) {
+	return s.health().QueryHostString(ctx, host, s.sqlServerUUID())
 }
 
 func debugCreateSQLs(names, sqls []string, err error) ([]string, []string) {
@@ -0,0 +1,79 @@
+package schemer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/model/clickhouse"
+)
+
+func Test_ClusterSchemer_ManagedUser(t *testing.T) {
+	s := NewClusterSchemer(clickhouse.NewClusterConnectionParams("http", "operator", "operator_password", "", 8123), nil)
+
+	// Without managed user health checks run as the operator user
+	require.Equal(t, "operator", s.health().Username)
+
+	s.SetManagedUserConnectionParams(clickhouse.NewClusterConnectionParams("http", "managed", "managed_password", "", 8123))
+	policy := NewSystemCommandsPolicy(nil, []string{"DROP REPLICA"})
+	s.SetSystemCommandsPolicy(policy, nil)
+
+	// Health checks run as the managed user, while schema is maintained as the operator user
+	require.Equal(t, "managed", s.health().Username)
+	require.Equal(t, "managed_password", s.health().Password)
+	require.Equal(t, "operator", s.Username)
+	require.Same(t, policy, s.health().systemCommands)
+	require.Same(t, policy, s.systemCommands)
+}
+
+func Test_ClusterSchemer_ExecFailedHandler(t *testing.T) {
+	var failed []error
+	s := NewClusterSchemer(clickhouse.NewClusterConnectionParams("http", "operator", "operator_password", "", 8123), nil)
+	s.SetManagedUserConnectionParams(clickhouse.NewClusterConnectionParams("http", "managed", "managed_password", "", 8123))
+	s.SetExecFailedHandler(func(ctx context.Context, err error) {
+		failed = append(failed, err)
+	})
+
+	// Successful runs are not reported
+	require.NoError(t, s.execFailed(context.Background(), nil))
+	require.Empty(t, failed)
+
+	// Failed runs are reported by both operator and managed user clusters
+	err := errors.New("DDL failed")
+	require.Same(t, err, s.execFailed(context.Background(), err))
+	require.Same(t, err, s.health().execFailed(context.Background(), err))
+	require.Equal(t, []error{err, err}, failed)
+}
+
+func Test_IsTruthy(t *testing.T) {
+	for _, value := range []string{"1", "42", "0.5", "true", "TRUE", " 1\n"} {
+		require.True(t, isTruthy(value), value)
+	}
+	for _, value := range []string{"", "0", "0.0", "false", "no", "null"} {
+		require.False(t, isTruthy(value), value)
+	}
+}
+
+func Test_ClusterSchemer_HostMergesDenied(t *testing.T) {
+	ctx := context.Background()
+	host := &api.ChiHost{}
+	var denied []string
+	onDenied := func(sql string) {
+		denied = append(denied, sql)
+	}
+	s := NewClusterSchemer(clickhouse.NewClusterConnectionParams("http", "operator", "operator_password", "", 8123), nil)
+
+	// Merges are not stopped, since they would not be able to be started again
+	s.SetSystemCommandsPolicy(NewSystemCommandsPolicy(nil, []string{"START MERGES"}), onDenied)
+	require.ErrorIs(t, s.HostStopMerges(ctx, host), ErrSystemCommandDenied)
+	require.ErrorIs(t, s.HostStartMerges(ctx, host), ErrSystemCommandDenied)
+	require.Equal(t, []string{"SYSTEM START MERGES", "SYSTEM START MERGES"}, denied)
+
+	denied = nil
+	s.SetSystemCommandsPolicy(NewSystemCommandsPolicy(nil, []string{"STOP MERGES"}), onDenied)
+	require.ErrorIs(t, s.HostStopMerges(ctx, host), ErrSystemCommandDenied)
+	require.Equal(t, []string{"SYSTEM STOP MERGES"}, denied)
+}
@@ -241,14 +241,68 @@ func (s *ClusterSchemer) sqlDropDNSCache() string {
 	return `SYSTEM DROP DNS CACHE`
 }
 
+func (s *ClusterSchemer) sqlReloadConfig() string {
+	return `SYSTEM RELOAD CONFIG`
+}
+
+func (s *ClusterSchemer) sqlReloadDictionaries() string {
+	return `SYSTEM RELOAD DICTIONARIES`
+}
+
+func (s *ClusterSchemer) sqlStopMerges() string {
+	return `SYSTEM STOP MERGES`
+}
+
+func (s *ClusterSchemer) sqlStartMerges() string {
+	return `SYSTEM START MERGES`
+}
+
 func (s *ClusterSchemer) sqlActiveQueriesNum() string {
 	return `SELECT count() FROM system.processes`
 }
 
+func (s *ClusterSchemer) sqlReadonlyReplicasNum() string {
+	return `SELECT count() FROM system.replicas WHERE is_readonly OR is_session_expired`
+}
+
 func (s *ClusterSchemer) sqlVersion() string {
 	return `SELECT version()`
 }
 
+func (s *ClusterSchemer) sqlServerUUID() string {
+	return `SELECT serverUUID()`
+}
+
+func (s *ClusterSchemer) sqlClusterHasHost(hostname string) string {
+	return heredoc.Docf(`
+		SELECT
+			count()
+		FROM
+			system.clusters
+		WHERE
+			cluster='%s' AND host_name='%s'
+		`,
+		chi.AllShardsOneReplicaClusterName,
+		hostname,
+	)
+}
+
+func (s *ClusterSchemer) sqlClusterTopology(cluster string) string {
+	return heredoc.Docf(`
+		SELECT
+			toString(shard_num),
+			host_name
+		FROM
+			system.clusters
+		WHERE
+			cluster='%s'
+		ORDER BY
+			shard_num, replica_num
+		`,
+		cluster,
+	)
+}
+
 func (s *ClusterSchemer) sqlHostInCluster() string {
 	// TODO: Change to select count() query to avoid exception in operator and ClickHouse logs
 	return heredoc.Docf(`
@@ -262,3 +316,76 @@ func (s *ClusterSchemer) sqlHostInCluster() string {
 		chi.AllShardsOneReplicaClusterName,
 	)
 }
+
+func (s *ClusterSchemer) sqlTablesChecksums() string {
+	// UUIDs are unique per replica unless table is created ON CLUSTER, so they are excluded from comparison
+	return heredoc.Docf(`
+		SELECT
+			concat(database, '.', name) AS table_name,
+			hex(cityHash64(replaceRegexpAll(create_table_query, ' UUID \'[0-9a-fA-F-]+\'', ''))) AS checksum
+		FROM
+			system.tables
+		WHERE
+			database NOT IN (%s) AND
+			NOT is_temporary
+		ORDER BY
+			table_name
+		`,
+		ignoredDBs,
+	)
+}
+
+// sqlReplicasOfZKPaths lists replicas registered in ZooKeeper for replicated tables of the host
+func (s *ClusterSchemer) sqlReplicasOfZKPaths() string {
+	return heredoc.Docf(`
+		SELECT
+			path,
+			name
+		FROM
+			system.zookeeper
+		WHERE
+			path IN (SELECT DISTINCT concat(zookeeper_path, '%s') FROM system.replicas)
+		`,
+		zkReplicasNode,
+	)
+}
+
+func (s *ClusterSchemer) sqlDropReplicaFromZKPath(replica, zkPath string) string {
+	return fmt.Sprintf("SYSTEM DROP REPLICA '%s' FROM ZKPATH '%s'", replica, zkPath)
+}
+
+// sqlReplicatedTablesNotDistributed lists replicated tables of the cluster, which are not referenced by any Distributed table
+func (s *ClusterSchemer) sqlReplicatedTablesNotDistributed(cluster string) string {
+	return heredoc.Docf(`
+		SELECT
+			DISTINCT database,
+			name
+		FROM
+			clusterAllReplicas('%s', system.tables) tables
+		WHERE
+			database NOT IN (%s) AND
+			engine LIKE 'Replicated%%MergeTree' AND
+			(database, name) NOT IN (
+				SELECT
+					extract(engine_full, 'Distributed\\([^,]+, *\'?([^,\']+)\'?, *[^,]+') AS database,
+					extract(engine_full, 'Distributed\\([^,]+, [^,]+, *\'?([^,\\\')]+)') AS name
+				FROM
+					clusterAllReplicas('%s', system.tables) tables
+				WHERE
+					engine = 'Distributed'
+				SETTINGS skip_unavailable_shards = 1
+			)
+		SETTINGS skip_unavailable_shards = 1
+		`,
+		cluster,
+		ignoredDBs,
+		cluster,
+	)
+}
+
+func (s *ClusterSchemer) sqlCreateDistributedTable(cluster, database, local, distributed string) string {
+	return fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS \"%s\".\"%s\" AS \"%s\".\"%s\" ENGINE = Distributed('%s', '%s', '%s', rand())",
+		database, distributed, database, local, cluster, database, local,
+	)
+}
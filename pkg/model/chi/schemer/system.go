@@ -0,0 +1,133 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schemer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	log "github.com/altinity/clickhouse-operator/pkg/announcer"
+)
+
+const systemKeyword = "SYSTEM"
+
+// ErrSystemCommandDenied specifies SYSTEM command is not run, since it is denied by the operator configuration
+var ErrSystemCommandDenied = errors.New("SYSTEM command is denied by the operator configuration")
+
+// SystemCommandsPolicy specifies SYSTEM commands the schemer is permitted to run
+type SystemCommandsPolicy struct {
+	allow []string
+	deny  []string
+}
+
+// NewSystemCommandsPolicy creates new SYSTEM commands policy.
+// Empty allow list permits all commands, which are not denied explicitly.
+func NewSystemCommandsPolicy(allow, deny []string) *SystemCommandsPolicy {
+	p := &SystemCommandsPolicy{}
+	for _, command := range allow {
+		if command = normalizeSystemCommand(command); command != "" {
+			p.allow = append(p.allow, command)
+		}
+	}
+	for _, command := range deny {
+		if command = normalizeSystemCommand(command); command != "" {
+			p.deny = append(p.deny, command)
+		}
+	}
+	return p
+}
+
+// IsAllowed checks whether SQL is permitted to be run. SQLs other than SYSTEM commands are always permitted.
+func (p *SystemCommandsPolicy) IsAllowed(sql string) bool {
+	if p == nil {
+		return true
+	}
+	command, ok := getSystemCommand(sql)
+	if !ok {
+		return true
+	}
+	if matchSystemCommand(command, p.deny) {
+		return false
+	}
+	if len(p.allow) == 0 {
+		return true
+	}
+	return matchSystemCommand(command, p.allow)
+}
+
+// Filter splits SQLs into permitted and denied ones
+func (p *SystemCommandsPolicy) Filter(SQLs []string) (allowed, denied []string) {
+	for _, sql := range SQLs {
+		if p.IsAllowed(sql) {
+			allowed = append(allowed, sql)
+		} else {
+			denied = append(denied, sql)
+		}
+	}
+	return allowed, denied
+}
+
+// getSystemCommand extracts normalized command from SYSTEM SQL
+func getSystemCommand(sql string) (string, bool) {
+	fields := strings.Fields(sql)
+	if (len(fields) < 2) || !strings.EqualFold(fields[0], systemKeyword) {
+		return "", false
+	}
+	return strings.ToUpper(strings.Join(fields[1:], " ")), true
+}
+
+// normalizeSystemCommand normalizes command specified in the policy, SYSTEM keyword is optional
+func normalizeSystemCommand(command string) string {
+	if c, ok := getSystemCommand(command); ok {
+		return c
+	}
+	return strings.ToUpper(strings.Join(strings.Fields(command), " "))
+}
+
+// matchSystemCommand checks whether command matches any of the listed ones.
+// Listed command matches all its variations, ex.: "DROP REPLICA" matches "DROP REPLICA 'replica' FROM ZKPATH '/path'"
+func matchSystemCommand(command string, commands []string) bool {
+	for _, c := range commands {
+		if (command == c) || strings.HasPrefix(command, c+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// filterSystemCommands drops SYSTEM commands, which are not permitted to be run
+func (c *Cluster) filterSystemCommands(SQLs []string) []string {
+	allowed, denied := c.systemCommands.Filter(SQLs)
+	for _, sql := range denied {
+		if c.onSystemCommandDenied == nil {
+			log.V(1).F().Warning("SYSTEM command is denied by the operator configuration, skip it: %s", sql)
+			continue
+		}
+		c.onSystemCommandDenied(sql)
+	}
+	return allowed
+}
+
+// checkSystemCommands checks all SYSTEM commands are permitted to be run. Denied commands are reported
+// and result in error, so the caller does not assume effect of the command, which is not run
+func (c *Cluster) checkSystemCommands(SQLs ...string) error {
+	allowed := c.filterSystemCommands(SQLs)
+	if len(allowed) == len(SQLs) {
+		return nil
+	}
+	_, denied := c.systemCommands.Filter(SQLs)
+	return fmt.Errorf("%w: %s", ErrSystemCommandDenied, strings.Join(denied, "; "))
+}
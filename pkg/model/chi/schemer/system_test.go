@@ -0,0 +1,69 @@
+package schemer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SystemCommandsPolicy_IsAllowed(t *testing.T) {
+	policy := NewSystemCommandsPolicy(
+		[]string{"RELOAD CONFIG", "system sync  replica", "DROP REPLICA"},
+		[]string{"SYSTEM DROP REPLICA"},
+	)
+	tests := []struct {
+		sql  string
+		want bool
+	}{
+		{"SYSTEM RELOAD CONFIG", true},
+		{"system reload config", true},
+		{`SYSTEM SYNC REPLICA "db"."table"`, true},
+		// Deny prevails over allow
+		{"SYSTEM DROP REPLICA 'chi-0-1'", false},
+		{"SYSTEM DROP REPLICA 'chi-0-1' FROM ZKPATH '/clickhouse/tables/0/db/table'", false},
+		// Not listed in allow
+		{"SYSTEM DROP DNS CACHE", false},
+		{"SYSTEM DROP DATABASE REPLICA '0|chi-0-1'", false},
+		// Command prefix matches whole words only
+		{"SYSTEM RELOAD CONFIGS", false},
+		// Not a SYSTEM command
+		{"SELECT 1", true},
+		{"CREATE TABLE IF NOT EXISTS db.table AS db.table_local", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			require.Equal(t, tt.want, policy.IsAllowed(tt.sql))
+		})
+	}
+}
+
+func Test_SystemCommandsPolicy_Defaults(t *testing.T) {
+	// No policy permits everything
+	var policy *SystemCommandsPolicy
+	require.True(t, policy.IsAllowed("SYSTEM DROP REPLICA 'chi-0-1'"))
+
+	// Empty allow list permits everything not denied
+	policy = NewSystemCommandsPolicy(nil, []string{"DROP REPLICA"})
+	require.True(t, policy.IsAllowed("SYSTEM RELOAD CONFIG"))
+	require.True(t, policy.IsAllowed("SYSTEM DROP DATABASE REPLICA '0|chi-0-1'"))
+	require.False(t, policy.IsAllowed("SYSTEM DROP REPLICA 'chi-0-1'"))
+}
+
+func Test_Cluster_FilterSystemCommands(t *testing.T) {
+	var denied []string
+	c := NewCluster().SetSystemCommandsPolicy(
+		NewSystemCommandsPolicy([]string{"RELOAD CONFIG"}, []string{"DROP REPLICA"}),
+		func(sql string) {
+			denied = append(denied, sql)
+		},
+	)
+
+	SQLs := []string{
+		"SYSTEM RELOAD CONFIG",
+		"SYSTEM DROP REPLICA 'chi-0-1'",
+		"SELECT 1",
+		"SYSTEM DROP DNS CACHE",
+	}
+	require.Equal(t, []string{"SYSTEM RELOAD CONFIG", "SELECT 1"}, c.filterSystemCommands(SQLs))
+	require.Equal(t, []string{"SYSTEM DROP REPLICA 'chi-0-1'", "SYSTEM DROP DNS CACHE"}, denied)
+}
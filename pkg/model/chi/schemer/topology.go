@@ -0,0 +1,164 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schemer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	model "github.com/altinity/clickhouse-operator/pkg/model/chi"
+	"github.com/altinity/clickhouse-operator/pkg/model/clickhouse"
+)
+
+// Topology describes hosts of a cluster as they are listed in system.clusters - host names indexed by shard number
+type Topology map[string][]string
+
+// ShardMismatch describes shard, which hosts differ from desired ones
+type ShardMismatch struct {
+	// Shard is a shard number, as reported in system.clusters
+	Shard string
+	// Desired lists hosts the shard is expected to have
+	Desired []string
+	// Actual lists hosts the shard actually has
+	Actual []string
+}
+
+// String returns string representation of the mismatch
+func (m ShardMismatch) String() string {
+	return fmt.Sprintf("shard %s desired [%s] actual [%s]", m.Shard, strings.Join(m.Desired, ","), strings.Join(m.Actual, ","))
+}
+
+// TopologyMismatch is a list of mismatched shards
+type TopologyMismatch []ShardMismatch
+
+// HasMismatch checks whether any shard mismatched
+func (m TopologyMismatch) HasMismatch() bool {
+	return len(m) > 0
+}
+
+// String returns string representation of the mismatch
+func (m TopologyMismatch) String() string {
+	var shards []string
+	for _, shard := range m {
+		shards = append(shards, shard.String())
+	}
+	return strings.Join(shards, "; ")
+}
+
+// hostTopologyFetcher fetches topology of a cluster as it is seen by a host
+type hostTopologyFetcher interface {
+	HostClusterTopology(ctx context.Context, host *api.ChiHost, cluster string) (Topology, error)
+}
+
+// HostClusterTopology returns topology of the cluster as it is seen by the host in system.clusters
+func (s *ClusterSchemer) HostClusterTopology(ctx context.Context, host *api.ChiHost, cluster string) (Topology, error) {
+	opts := clickhouse.NewQueryOptions().SetSilent(true)
+	shards, hosts, err := s.QueryHostUnzip2Columns(ctx, host, s.sqlClusterTopology(cluster), opts)
+	if err != nil {
+		return nil, err
+	}
+	topology := make(Topology)
+	for i := range shards {
+		topology[shards[i]] = append(topology[shards[i]], hosts[i])
+	}
+	return topology, nil
+}
+
+// ClusterCheckTopology compares topology of the cluster reported by the host in system.clusters
+// with the desired one, rendered into remote_servers config
+func (s *ClusterSchemer) ClusterCheckTopology(ctx context.Context, host *api.ChiHost, cluster *api.Cluster) (TopologyMismatch, error) {
+	return clusterCheckTopology(ctx, s, host, cluster)
+}
+
+// clusterCheckTopology fetches actual topology of the cluster from the host and compares it with the desired one
+func clusterCheckTopology(ctx context.Context, fetcher hostTopologyFetcher, host *api.ChiHost, cluster *api.Cluster) (TopologyMismatch, error) {
+	actual, err := fetcher.HostClusterTopology(ctx, host, cluster.Name)
+	if err != nil {
+		return nil, err
+	}
+	return compareTopologies(ClusterDesiredTopology(cluster), actual), nil
+}
+
+// ClusterDesiredTopology builds topology of the cluster the same way as it is rendered into remote_servers config.
+// Shards without hosts, such as shards in maintenance, are skipped and do not take shard numbers.
+func ClusterDesiredTopology(cluster *api.Cluster) Topology {
+	options := model.NewRemoteServersGeneratorOptions()
+	topology := make(Topology)
+	num := 0
+	cluster.WalkShards(func(_ int, shard *api.ChiShard) error {
+		var hosts []string
+		shard.WalkHosts(func(host *api.ChiHost) error {
+			if options.Include(host) {
+				hosts = append(hosts, model.CreateInstanceHostname(host))
+			}
+			return nil
+		})
+		if len(hosts) == 0 {
+			// Skip empty shard
+			return nil
+		}
+		num++
+		topology[fmt.Sprintf("%d", num)] = hosts
+		return nil
+	})
+	return topology
+}
+
+// compareTopologies compares desired and actual topologies and reports mismatched shards
+func compareTopologies(desired, actual Topology) TopologyMismatch {
+	// Collect all shards known to any topology
+	known := make(map[string]bool)
+	var shards []string
+	for _, topology := range []Topology{desired, actual} {
+		for shard := range topology {
+			if !known[shard] {
+				known[shard] = true
+				shards = append(shards, shard)
+			}
+		}
+	}
+	sort.Slice(shards, func(i, j int) bool {
+		if len(shards[i]) != len(shards[j]) {
+			// Numeric order of shard numbers
+			return len(shards[i]) < len(shards[j])
+		}
+		return shards[i] < shards[j]
+	})
+
+	var mismatch TopologyMismatch
+	for _, shard := range shards {
+		d := sortedCopy(desired[shard])
+		a := sortedCopy(actual[shard])
+		if strings.Join(d, ",") == strings.Join(a, ",") {
+			continue
+		}
+		mismatch = append(mismatch, ShardMismatch{
+			Shard:   shard,
+			Desired: d,
+			Actual:  a,
+		})
+	}
+	return mismatch
+}
+
+// sortedCopy returns sorted copy of the slice
+func sortedCopy(s []string) []string {
+	res := append([]string(nil), s...)
+	sort.Strings(res)
+	return res
+}
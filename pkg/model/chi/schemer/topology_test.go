@@ -0,0 +1,109 @@
+package schemer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+// fakeTopologyFetcher returns predefined system.clusters content
+type fakeTopologyFetcher struct {
+	topology Topology
+	err      error
+}
+
+func (f *fakeTopologyFetcher) HostClusterTopology(_ context.Context, _ *api.ChiHost, _ string) (Topology, error) {
+	return f.topology, f.err
+}
+
+func newTopologyTestCluster(shards, replicas int) *api.Cluster {
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+		},
+		Spec: api.ChiSpec{
+			Defaults: &api.ChiDefaults{},
+		},
+	}
+	cluster := &api.Cluster{
+		Name:   "cluster",
+		Layout: api.NewChiClusterLayout(),
+	}
+	for s := 0; s < shards; s++ {
+		shard := api.ChiShard{Name: fmt.Sprintf("%d", s)}
+		for r := 0; r < replicas; r++ {
+			host := &api.ChiHost{Name: fmt.Sprintf("%d-%d", s, r)}
+			host.Runtime.CHI = chi
+			host.Runtime.Address = api.ChiHostAddress{
+				Namespace:   "ns",
+				CHIName:     "chi",
+				ClusterName: cluster.Name,
+				ShardName:   shard.Name,
+				HostName:    host.Name,
+			}
+			shard.Hosts = append(shard.Hosts, host)
+		}
+		cluster.Layout.Shards = append(cluster.Layout.Shards, shard)
+	}
+	chi.Spec.Configuration = &api.Configuration{
+		Clusters: []*api.Cluster{cluster},
+	}
+	return cluster
+}
+
+func Test_ClusterCheckTopology_Matching(t *testing.T) {
+	cluster := newTopologyTestCluster(2, 2)
+	fetcher := &fakeTopologyFetcher{
+		topology: Topology{
+			"1": {"chi-chi-cluster-0-1", "chi-chi-cluster-0-0"},
+			"2": {"chi-chi-cluster-1-0", "chi-chi-cluster-1-1"},
+		},
+	}
+
+	mismatch, err := clusterCheckTopology(context.Background(), fetcher, cluster.FirstHost(), cluster)
+	require.NoError(t, err)
+	require.False(t, mismatch.HasMismatch())
+}
+
+func Test_ClusterCheckTopology_Mismatching(t *testing.T) {
+	cluster := newTopologyTestCluster(2, 2)
+
+	// Added replica and shard are not picked up by ClickHouse
+	fetcher := &fakeTopologyFetcher{
+		topology: Topology{
+			"1": {"chi-chi-cluster-0-0"},
+		},
+	}
+	mismatch, err := clusterCheckTopology(context.Background(), fetcher, cluster.FirstHost(), cluster)
+	require.NoError(t, err)
+	require.True(t, mismatch.HasMismatch())
+	require.Equal(t, TopologyMismatch{
+		{Shard: "1", Desired: []string{"chi-chi-cluster-0-0", "chi-chi-cluster-0-1"}, Actual: []string{"chi-chi-cluster-0-0"}},
+		{Shard: "2", Desired: []string{"chi-chi-cluster-1-0", "chi-chi-cluster-1-1"}, Actual: nil},
+	}, mismatch)
+	require.Equal(t,
+		"shard 1 desired [chi-chi-cluster-0-0,chi-chi-cluster-0-1] actual [chi-chi-cluster-0-0]; "+
+			"shard 2 desired [chi-chi-cluster-1-0,chi-chi-cluster-1-1] actual []",
+		mismatch.String(),
+	)
+
+	// Shard in maintenance is expected to be absent, so shards are renumbered
+	cluster.Layout.Shards[0].Maintenance = api.NewStringBool(true)
+	fetcher.topology = Topology{
+		"1": {"chi-chi-cluster-1-0", "chi-chi-cluster-1-1"},
+	}
+	mismatch, err = clusterCheckTopology(context.Background(), fetcher, cluster.FirstHost(), cluster)
+	require.NoError(t, err)
+	require.False(t, mismatch.HasMismatch())
+
+	// Unable to fetch
+	fetcher.err = fmt.Errorf("host is unreachable")
+	_, err = clusterCheckTopology(context.Background(), fetcher, cluster.FirstHost(), cluster)
+	require.Error(t, err)
+}
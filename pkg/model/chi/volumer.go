@@ -22,6 +22,10 @@ import (
 
 func GetVolumeClaimTemplate(host *api.ChiHost, volumeMount *core.VolumeMount) (*api.VolumeClaimTemplate, bool) {
 	volumeClaimTemplateName := volumeMount.Name
+	if name, ok := host.Runtime.AdoptedVolumeClaimTemplates[volumeMount.Name]; ok {
+		// Volume mount refers to volume claim template of adopted StatefulSet
+		volumeClaimTemplateName = name
+	}
 	volumeClaimTemplate, ok := host.GetCHI().GetVolumeClaimTemplate(volumeClaimTemplateName)
 	// Sometimes it is impossible to find VolumeClaimTemplate related to specified volumeMount.
 	// May be this volumeMount is not created from VolumeClaimTemplate, it may be a reference to a ConfigMap
@@ -59,3 +63,8 @@ func GetPVCProvisioner(host *api.ChiHost, template *api.VolumeClaimTemplate) api
 	// Default value
 	return api.PVCProvisionerStatefulSet
 }
+
+// CreateStorageDiskPath creates path where PVC of the storage disk is mounted
+func CreateStorageDiskPath(disk *api.ChiStorageDisk) string {
+	return DirPathClickHouseDisks + "/" + disk.Name
+}
@@ -0,0 +1,44 @@
+package chi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+func Test_CreatePVCNameByVolumeMount_SkipsNonTemplateMounts(t *testing.T) {
+	chi := &api.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "chi",
+		},
+		Spec: api.ChiSpec{
+			Templates: &api.Templates{},
+		},
+	}
+	chi.Spec.Templates.EnsureVolumeClaimTemplatesIndex().Set("data", &api.VolumeClaimTemplate{Name: "data"})
+
+	host := &api.ChiHost{Name: "0-0"}
+	host.Runtime.CHI = chi
+	host.Runtime.Address = api.ChiHostAddress{
+		Namespace:   "ns",
+		CHIName:     "chi",
+		ClusterName: "cluster",
+		HostName:    "0-0",
+	}
+
+	// Mount of a volume claim template
+	name, ok := CreatePVCNameByVolumeMount(host, &core.VolumeMount{Name: "data", MountPath: DirPathClickHouseData})
+	require.True(t, ok)
+	require.Equal(t, "data-chi-chi-cluster-0-0-0", name)
+
+	// Mount of an additional volume, ex.: ConfigMap with dictionaries, is not a PVC
+	_, ok = CreatePVCNameByVolumeMount(host, &core.VolumeMount{Name: "dictionaries", MountPath: "/etc/clickhouse-server/dictionaries.d"})
+	require.False(t, ok)
+	_, ok = GetVolumeClaimTemplate(host, &core.VolumeMount{Name: "dictionaries", MountPath: "/etc/clickhouse-server/dictionaries.d"})
+	require.False(t, ok)
+}
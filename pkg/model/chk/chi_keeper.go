@@ -0,0 +1,57 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chk
+
+import (
+	apiChk "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse-keeper.altinity.com/v1"
+	apiChi "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	modelChi "github.com/altinity/clickhouse-operator/pkg/model/chi"
+)
+
+// keeperClusterName specifies name of the cluster of Keeper ensemble, managed along with the CHI
+const keeperClusterName = "keeper"
+
+// CreateCHIKeeper creates CHK out of Keeper ensemble specified in the CHI.
+// Returns nil in case CHI does not specify Keeper ensemble
+func CreateCHIKeeper(chi *apiChi.ClickHouseInstallation) *apiChk.ClickHouseKeeperInstallation {
+	if chi.Spec.Configuration == nil {
+		return nil
+	}
+	keeper := chi.Spec.Configuration.Keeper
+	if keeper == nil {
+		return nil
+	}
+
+	chk := newCHK()
+	chk.Name = modelChi.CreateKeeperName(chi)
+	chk.Namespace = chi.Namespace
+	// Keeper ensemble is unique within the CHI
+	chk.UID = chi.UID
+	chk.Spec = apiChk.ChkSpec{
+		Configuration: &apiChk.ChkConfiguration{
+			Settings: keeper.GetSettings().DeepCopy(),
+			Clusters: []*apiChk.ChkCluster{
+				{
+					Name: keeperClusterName,
+					Layout: &apiChk.ChkClusterLayout{
+						ReplicasCount: keeper.GetReplicas(),
+					},
+				},
+			},
+		},
+		Templates: keeper.GetTemplates().DeepCopy(),
+	}
+	return chk
+}
@@ -0,0 +1,70 @@
+package chk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiChi "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/model/chi/normalizer"
+)
+
+func newKeeperTestCHI(keeper *apiChi.ChiKeeper) *apiChi.ClickHouseInstallation {
+	return &apiChi.ClickHouseInstallation{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns",
+			Name:      "simple",
+			UID:       "1234",
+		},
+		Spec: apiChi.ChiSpec{
+			Configuration: &apiChi.Configuration{
+				Keeper: keeper,
+			},
+		},
+	}
+}
+
+func Test_CreateCHIKeeper_NoKeeper(t *testing.T) {
+	require.Nil(t, CreateCHIKeeper(newKeeperTestCHI(nil)))
+	require.Nil(t, CreateCHIKeeper(&apiChi.ClickHouseInstallation{}))
+}
+
+func Test_CreateCHIKeeper_StatefulSet(t *testing.T) {
+	chi := newKeeperTestCHI(&apiChi.ChiKeeper{Replicas: 3})
+
+	chk, err := NewNormalizer().CreateTemplatedCHK(CreateCHIKeeper(chi), normalizer.NewOptions())
+	require.NoError(t, err)
+	require.Equal(t, "simple-keeper", chk.Name)
+	require.Equal(t, "ns", chk.Namespace)
+	require.Equal(t, 3, GetReplicasCount(chk))
+
+	statefulSet := CreateStatefulSet(chk)
+	require.Equal(t, "simple-keeper", statefulSet.Name)
+	require.Equal(t, "ns", statefulSet.Namespace)
+	require.Equal(t, int32(3), *statefulSet.Spec.Replicas)
+	require.Equal(t, "simple-keeper-headless", statefulSet.Spec.ServiceName)
+	require.Equal(t, GetPodLabels(chk), statefulSet.Spec.Selector.MatchLabels)
+
+	// Raft config lists all nodes of the ensemble
+	config := CreateConfigMap(chk).Data["keeper_config.xml"]
+	for _, hostname := range []string{
+		"simple-keeper-0.simple-keeper-headless.ns.svc.cluster.local",
+		"simple-keeper-1.simple-keeper-headless.ns.svc.cluster.local",
+		"simple-keeper-2.simple-keeper-headless.ns.svc.cluster.local",
+	} {
+		require.Contains(t, config, hostname)
+	}
+	require.NotContains(t, config, "simple-keeper-3.")
+
+	// Client service is the one ClickHouse connects to
+	require.Equal(t, "simple-keeper", CreateClientService(chk).Name)
+	require.Equal(t, "simple-keeper-headless", CreateHeadlessService(chk).Name)
+}
+
+func Test_SetReplicasCount(t *testing.T) {
+	chk := CreateCHIKeeper(newKeeperTestCHI(&apiChi.ChiKeeper{Replicas: 5}))
+	SetReplicasCount(chk, 4)
+	require.Equal(t, 4, GetReplicasCount(chk))
+	require.Equal(t, int32(4), *CreateStatefulSet(chk).Spec.Replicas)
+}
@@ -29,3 +29,15 @@ func GetReplicasCount(chk *api.ClickHouseKeeperInstallation) int {
 	}
 	return cluster.GetLayout().GetReplicasCount()
 }
+
+// SetReplicasCount sets number of replicas of the cluster
+func SetReplicasCount(chk *api.ClickHouseKeeperInstallation, replicas int) {
+	cluster := getCluster(chk)
+	if cluster == nil {
+		return
+	}
+	if cluster.Layout == nil {
+		cluster.Layout = &api.ChkClusterLayout{}
+	}
+	cluster.Layout.ReplicasCount = replicas
+}
@@ -73,7 +73,27 @@ func (c *Cluster) getHostConnection(host string) *Connection {
 // QueryAny walks over all endpoints and runs query sequentially on each of them.
 // In case endpoint returned result, walk is completed and result is returned.
 // In case endpoint failed, continue with the next endpoint.
-func (c *Cluster) QueryAny(ctx context.Context, sql string) (*QueryResult, error) {
+// In case all endpoints failed due to connection-level errors, walk is retried according to specified options.
+func (c *Cluster) QueryAny(ctx context.Context, sql string, _opts ...*QueryOptions) (*QueryResult, error) {
+	opts := QueryOptionsNormalize(_opts...)
+	var result *QueryResult
+	err := r.Retry(ctx, opts.Tries, "Querying any host", c.l.V(1).F(),
+		func() error {
+			query, err := c.queryAny(ctx, sql)
+			if (err != nil) && !IsConnectionError(err) {
+				// Query itself failed, retries would not help
+				return r.Abort(err)
+			}
+			result = query
+			return err
+		},
+	)
+	return result, err
+}
+
+// queryAny walks over all endpoints once and runs query sequentially on each of them.
+func (c *Cluster) queryAny(ctx context.Context, sql string) (*QueryResult, error) {
+	var lastErr error
 	// Try to fetch data from any of the endpoints.
 	for _, host := range c.Hosts {
 		if util.IsContextDone(ctx) {
@@ -89,11 +109,15 @@ func (c *Cluster) QueryAny(ctx context.Context, sql string) (*QueryResult, error
 		}
 		// Still need to iterate more
 		c.l.V(1).F().Warning("FAILED to run query on: %s of %v skip to next. err: %v", host, c.Hosts, err)
+		lastErr = err
 	}
 
 	str := fmt.Sprintf("FAILED to run query on all hosts %v", c.Hosts)
 	c.l.V(1).F().Error(str)
-	return nil, fmt.Errorf(str)
+	if lastErr == nil {
+		return nil, fmt.Errorf(str)
+	}
+	return nil, fmt.Errorf("%s: %w", str, lastErr)
 }
 
 // ExecAll runs set of SQL queries on all endpoints of the cluster.
@@ -145,6 +169,10 @@ func (c *Cluster) exec(ctx context.Context, host string, queries []string, _opts
 	err := r.Retry(ctx, opts.Tries, "Applying sqls", c.l.V(1).M(host).F(),
 		func() error {
 			var errors []error
+			// progress specifies whether any query succeeded during this pass
+			progress := false
+			// transient specifies whether any query failed due to connection-level error
+			transient := false
 			for i, sql := range queries {
 				if util.IsContextDone(ctx) {
 					c.l.V(2).Info("ctx is done")
@@ -163,12 +191,21 @@ func (c *Cluster) exec(ctx context.Context, host string, queries []string, _opts
 				}
 				if err == nil || strings.Contains(err.Error(), "ALREADY_EXISTS") {
 					queries[i] = "" // Query is executed or object already exists, removing from the list
+					progress = true
 				} else {
 					errors = append(errors, err)
+					if IsConnectionError(err) {
+						transient = true
+					}
 				}
 			}
 
 			if len(errors) > 0 {
+				if !progress && !transient {
+					// Queries failed by themselves and nothing has changed since the previous pass,
+					// so retries would not help
+					return r.Abort(errors[0])
+				}
 				return errors[0]
 			}
 			return nil
@@ -71,7 +71,13 @@ func (c *Connection) SetLog(l log.Announcer) *Connection {
 }
 
 // connect performs connect
-func (c *Connection) connect(ctx context.Context) {
+func (c *Connection) connect(ctx context.Context) error {
+	// TLS material of the endpoint has TLS config of its own
+	if err := c.params.tls.Register(); err != nil {
+		c.l.V(1).F().Error("unable to register TLS config %v", err)
+		return err
+	}
+
 	// Add root CA
 	if c.params.rootCA != "" {
 		rootCAs := x509.NewCertPool()
@@ -91,7 +97,7 @@ func (c *Connection) connect(ctx context.Context) {
 	dbConnection, err := sql.Open(clickHouseDriverName, c.params.GetDSN())
 	if err != nil {
 		c.l.V(1).F().Error("FAILED Open(%s). Err: %v", c.params.GetDSNWithHiddenCredentials(), err)
-		return
+		return err
 	}
 
 	// Ping should have timeout
@@ -101,34 +107,55 @@ func (c *Connection) connect(ctx context.Context) {
 	if err := dbConnection.PingContext(pingCtx); err != nil {
 		c.l.V(1).F().Error("FAILED Ping(%s). Err: %v", c.params.GetDSNWithHiddenCredentials(), err)
 		_ = dbConnection.Close()
-		return
+		return err
 	}
 
 	c.db = dbConnection
+	return nil
+}
+
+// close closes connection. Queries already started are waited for to finish
+func (c *Connection) close() {
+	if c.db == nil {
+		return
+	}
+	if err := c.db.Close(); err != nil {
+		c.l.V(1).F().Error("FAILED Close(%s). Err: %v", c.params.GetDSNWithHiddenCredentials(), err)
+	}
 }
 
 // ensureConnected ensures connection is set
-func (c *Connection) ensureConnected(ctx context.Context) bool {
+func (c *Connection) ensureConnected(ctx context.Context) error {
 	if c.db != nil {
 		c.l.V(2).F().Info("Already connected: %s", c.params.GetDSNWithHiddenCredentials())
-		return true
+		return nil
 	}
 
-	c.connect(ctx)
+	if err := c.connect(ctx); err != nil {
+		return &ConnectionError{Err: err}
+	}
 
-	return c.db != nil
+	return nil
 }
 
 // QueryContext runs given sql query on behalf of specified context
 func (c *Connection) QueryContext(ctx context.Context, sql string) (*QueryResult, error) {
+	result, err := c.queryContext(ctx, sql)
+	if fallback := c.fallback(err); fallback != nil {
+		return fallback.queryContext(ctx, sql)
+	}
+	return result, err
+}
+
+// queryContext runs given sql query on behalf of specified context with no fallback
+func (c *Connection) queryContext(ctx context.Context, sql string) (*QueryResult, error) {
 	if len(sql) == 0 {
 		return nil, nil
 	}
 
-	if !c.ensureConnected(ctx) {
-		s := fmt.Sprintf("FAILED connect(%s) for SQL: %s", c.params.GetDSNWithHiddenCredentials(), sql)
-		c.l.V(1).F().Error(s)
-		return nil, fmt.Errorf(s)
+	if err := c.ensureConnected(ctx); err != nil {
+		c.l.V(1).F().Error("FAILED connect(%s) for SQL: %s", c.params.GetDSNWithHiddenCredentials(), sql)
+		return nil, err
 	}
 
 	if util.IsContextDone(ctx) {
@@ -172,7 +199,16 @@ func (c *Connection) ctx(ctx context.Context, opts *QueryOptions) (context.Conte
 }
 
 // Exec runs given sql query
-func (c *Connection) Exec(_ctx context.Context, sql string, opts *QueryOptions) error {
+func (c *Connection) Exec(ctx context.Context, sql string, opts *QueryOptions) error {
+	err := c.exec(ctx, sql, opts)
+	if fallback := c.fallback(err); fallback != nil {
+		return fallback.exec(ctx, sql, opts)
+	}
+	return err
+}
+
+// exec runs given sql query with no fallback
+func (c *Connection) exec(_ctx context.Context, sql string, opts *QueryOptions) error {
 	if len(sql) == 0 {
 		return nil
 	}
@@ -180,11 +216,10 @@ func (c *Connection) Exec(_ctx context.Context, sql string, opts *QueryOptions)
 	ctx, cancel := c.ctx(_ctx, opts)
 	defer cancel()
 
-	if !c.ensureConnected(ctx) {
+	if err := c.ensureConnected(ctx); err != nil {
 		cancel()
-		s := fmt.Sprintf("FAILED connect(%s) for SQL: %s", c.params.GetDSNWithHiddenCredentials(), sql)
-		c.l.V(1).F().Error(s)
-		return fmt.Errorf(s)
+		c.l.V(1).F().Error("FAILED connect(%s) for SQL: %s", c.params.GetDSNWithHiddenCredentials(), sql)
+		return err
 	}
 
 	_, err := c.db.ExecContext(ctx, sql)
@@ -199,3 +234,18 @@ func (c *Connection) Exec(_ctx context.Context, sql string, opts *QueryOptions)
 
 	return nil
 }
+
+// fallback gets connection with fallback credentials in case the error is caused by credentials being rejected.
+// Returns nil in case there is no need or nowhere to fallback to
+func (c *Connection) fallback(err error) *Connection {
+	if !IsAuthenticationError(err) {
+		return nil
+	}
+	params := c.params.GetFallback()
+	if params == nil {
+		return nil
+	}
+	c.l.V(1).F().Warning("Credentials are rejected by %s, fallback to %s",
+		c.params.GetDSNWithHiddenCredentials(), params.GetDSNWithHiddenCredentials())
+	return GetPooledDBConnection(params).SetLog(c.l)
+}
@@ -35,13 +35,17 @@ func NewClusterConnectionParams(scheme, username, password, rootCA string, port
 // NewClusterConnectionParamsFromCHOpConfig is the same as NewClusterConnectionParams, but works with
 // CHOp config to get parameters from
 func NewClusterConnectionParamsFromCHOpConfig(config *api.OperatorConfig) *ClusterConnectionParams {
+	// Credentials may be rotated in runtime
+	username, password := config.GetAccessCredentials()
 	params := NewClusterConnectionParams(
 		config.ClickHouse.Access.Scheme,
-		config.ClickHouse.Access.Username,
-		config.ClickHouse.Access.Password,
+		username,
+		password,
 		config.ClickHouse.Access.RootCA,
 		config.ClickHouse.Access.Port,
 	)
+	// Hosts may have not picked up rotated credentials yet
+	params.SetFallbackCredentials(config.GetPreviousAccessCredentials())
 	params.SetConnectTimeout(config.ClickHouse.Access.Timeouts.Connect)
 	params.SetQueryTimeout(config.ClickHouse.Access.Timeouts.Query)
 
@@ -62,12 +66,42 @@ func (p *ClusterConnectionParams) NewEndpointConnectionParams(host string) *Endp
 	if p == nil {
 		return nil
 	}
-	return NewEndpointConnectionParams(
+	params := NewEndpointConnectionParams(
 		p.Scheme,
 		host,
 		p.Username,
 		p.Password,
 		p.RootCA,
 		p.Port,
-	).SetTimeouts(p.Timeouts)
+	).SetTimeouts(p.Timeouts).SetTLS(p.TLS)
+	if p.HasFallback() {
+		params.SetFallback(NewEndpointConnectionParams(
+			p.Scheme,
+			host,
+			p.FallbackUsername,
+			p.FallbackPassword,
+			p.RootCA,
+			p.Port,
+		).SetTimeouts(p.Timeouts).SetTLS(p.TLS))
+	}
+	return params
+}
+
+// SetFallbackCredentials sets credentials to connect with in case main credentials are rejected
+func (p *ClusterConnectionParams) SetFallbackCredentials(username, password string) *ClusterConnectionParams {
+	if p == nil {
+		return nil
+	}
+	p.FallbackUsername = username
+	p.FallbackPassword = password
+	return p
+}
+
+// SetTLS sets TLS material to connect over https with
+func (p *ClusterConnectionParams) SetTLS(tls *TLS) *ClusterConnectionParams {
+	if p == nil {
+		return nil
+	}
+	p.TLS = tls
+	return p
 }
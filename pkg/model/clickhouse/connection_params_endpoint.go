@@ -18,13 +18,15 @@ package clickhouse
 type EndpointConnectionParams struct {
 	*EndpointCredentials
 	*Timeouts
+	// fallback specifies params to connect with in case credentials are rejected
+	fallback *EndpointConnectionParams
 }
 
 // NewEndpointConnectionParams creates new EndpointConnectionParams
 func NewEndpointConnectionParams(scheme, hostname, username, password, rootCA string, port int) *EndpointConnectionParams {
 	return &EndpointConnectionParams{
-		NewEndpointCredentials(scheme, hostname, username, password, rootCA, port),
-		NewTimeouts(),
+		EndpointCredentials: NewEndpointCredentials(scheme, hostname, username, password, rootCA, port),
+		Timeouts:            NewTimeouts(),
 	}
 }
 
@@ -36,3 +38,29 @@ func (p *EndpointConnectionParams) SetTimeouts(timeouts *Timeouts) *EndpointConn
 	p.Timeouts = timeouts
 	return p
 }
+
+// SetTLS sets TLS material to connect over https with
+func (p *EndpointConnectionParams) SetTLS(tls *TLS) *EndpointConnectionParams {
+	if p == nil {
+		return nil
+	}
+	p.EndpointCredentials.setTLS(tls)
+	return p
+}
+
+// SetFallback sets params to connect with in case credentials are rejected
+func (p *EndpointConnectionParams) SetFallback(fallback *EndpointConnectionParams) *EndpointConnectionParams {
+	if p == nil {
+		return nil
+	}
+	p.fallback = fallback
+	return p
+}
+
+// GetFallback gets params to connect with in case credentials are rejected
+func (p *EndpointConnectionParams) GetFallback() *EndpointConnectionParams {
+	if p == nil {
+		return nil
+	}
+	return p.fallback
+}
@@ -21,6 +21,12 @@ type ClusterCredentials struct {
 	Password string
 	RootCA   string
 	Port     int
+	// TLS specifies TLS material to connect over https with, overrides RootCA
+	TLS *TLS
+	// FallbackUsername and FallbackPassword specify credentials to connect with in case
+	// Username and Password are rejected, ex.: hosts have not picked up rotated credentials yet
+	FallbackUsername string
+	FallbackPassword string
 }
 
 // NewClusterCredentials creates new ClusterCredentials
@@ -33,3 +39,14 @@ func NewClusterCredentials(scheme, username, password, rootCA string, port int)
 		Port:     port,
 	}
 }
+
+// HasFallback checks whether credentials to fallback to are specified and differ from the main ones
+func (c *ClusterCredentials) HasFallback() bool {
+	if c == nil {
+		return false
+	}
+	if c.FallbackUsername == "" {
+		return false
+	}
+	return (c.FallbackUsername != c.Username) || (c.FallbackPassword != c.Password)
+}
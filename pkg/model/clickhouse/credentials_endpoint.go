@@ -42,6 +42,7 @@ type EndpointCredentials struct {
 	password string
 	rootCA   string
 	port     int
+	tls      *TLS
 
 	// Internal generated data
 	dsn                  string
@@ -65,6 +66,13 @@ func NewEndpointCredentials(scheme, hostname, username, password, rootCA string,
 	return params
 }
 
+// setTLS sets TLS material to connect over https with. DSN refers to TLS config of the material, so it is re-made
+func (c *EndpointCredentials) setTLS(tls *TLS) {
+	c.tls = tls
+	c.dsn = c.makeDSN(false)
+	c.dsnHiddenCredentials = c.makeDSN(true)
+}
+
 // formatUsernamePassword formats username and password pair
 func (c *EndpointCredentials) formatUsernamePassword(username, password string) string {
 	// We may have neither username nor password
@@ -106,7 +114,7 @@ func (c *EndpointCredentials) makeDSN(hideCredentials bool) string {
 		strconv.Itoa(c.port),
 	)
 	if c.scheme == httpsScheme {
-		baseUrl += "?tls_config=" + tlsSettings
+		baseUrl += "?tls_config=" + c.tls.Name()
 	}
 	return baseUrl
 }
@@ -0,0 +1,121 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+
+	goch "github.com/mailru/go-clickhouse/v2"
+)
+
+// ConnectionError specifies failure to establish connection to ClickHouse
+type ConnectionError struct {
+	Err error
+}
+
+// Error implements error interface
+func (e *ConnectionError) Error() string {
+	if e.Err == nil {
+		return "connection failed"
+	}
+	return "connection failed: " + e.Err.Error()
+}
+
+// Unwrap returns underlying error
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// ClickHouse error codes, which are caused by network issues rather than by the query itself
+const (
+	errorCodeTimeoutExceeded = 159
+	errorCodeSocketTimeout   = 209
+	errorCodeNetworkError    = 210
+)
+
+// ClickHouse error codes, which are caused by credentials being rejected
+const (
+	errorCodeUnknownUser          = 192
+	errorCodeWrongPassword        = 193
+	errorCodeRequiredPassword     = 194
+	errorCodeAuthenticationFailed = 516
+)
+
+// IsConnectionError checks whether error is a connection-level error, such as connection refused or timeout.
+// Connection-level errors are transient - ClickHouse may be not up yet - and the query is worth retrying.
+// Errors reported by ClickHouse for the query itself, such as syntax or permission errors, are not connection-level.
+func IsConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) {
+		// Canceled by ourselves
+		return false
+	}
+
+	var connErr *ConnectionError
+	if errors.As(err, &connErr) {
+		return true
+	}
+
+	var chErr *goch.Error
+	if errors.As(err, &chErr) {
+		// Error reported by ClickHouse
+		switch chErr.Code {
+		case
+			errorCodeTimeoutExceeded,
+			errorCodeSocketTimeout,
+			errorCodeNetworkError:
+			return true
+		}
+		return false
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.As(err, &netErr):
+		return true
+	case
+		errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	}
+
+	return false
+}
+
+// IsAuthenticationError checks whether error is caused by ClickHouse rejecting credentials
+func IsAuthenticationError(err error) bool {
+	var chErr *goch.Error
+	if !errors.As(err, &chErr) {
+		return false
+	}
+	switch chErr.Code {
+	case
+		errorCodeUnknownUser,
+		errorCodeWrongPassword,
+		errorCodeRequiredPassword,
+		errorCodeAuthenticationFailed:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,216 @@
+package clickhouse
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	goch "github.com/mailru/go-clickhouse/v2"
+	"github.com/stretchr/testify/require"
+
+	r "github.com/altinity/clickhouse-operator/pkg/util/retry"
+)
+
+// closedPort returns local port nobody listens on
+func closedPort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+	return port
+}
+
+// fakeClickHouse emulates ClickHouse HTTP interface.
+// Ping requests are answered with success, all other queries are answered by the handler.
+type fakeClickHouse struct {
+	queries int32
+	handler func(w http.ResponseWriter, query string)
+}
+
+func (f *fakeClickHouse) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var body io.Reader = req.Body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(req.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body = gz
+	}
+	query, _ := io.ReadAll(body)
+	if string(query) == "select 1" {
+		_, _ = fmt.Fprint(w, "1\n")
+		return
+	}
+	atomic.AddInt32(&f.queries, 1)
+	f.handler(w, string(query))
+}
+
+// fakeRetryWait makes retries not sleep between attempts. Waits are recorded and onWait is called on each of them
+func fakeRetryWait(t *testing.T, onWait func()) *[]time.Duration {
+	var waits []time.Duration
+	wait := r.Wait
+	r.Wait = func(ctx context.Context, timeout time.Duration) bool {
+		waits = append(waits, timeout)
+		if onWait != nil {
+			onWait()
+		}
+		return false
+	}
+	t.Cleanup(func() {
+		r.Wait = wait
+	})
+	return &waits
+}
+
+func newTestCluster(t *testing.T, port int) *Cluster {
+	cluster := NewCluster().SetHosts([]string{"127.0.0.1"})
+	cluster.ClusterConnectionParams = NewClusterConnectionParams("http", "", "", "", port)
+	return cluster
+}
+
+func Test_IsConnectionError(t *testing.T) {
+	_, refused := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", closedPort(t)))
+	require.Error(t, refused)
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"connection refused", refused, true},
+		{"wrapped connection refused", fmt.Errorf("query failed: %w", refused), true},
+		{"connection error", &ConnectionError{Err: fmt.Errorf("ping failed")}, true},
+		{"deadline exceeded", context.DeadlineExceeded, true},
+		{"unexpected eof", io.ErrUnexpectedEOF, true},
+		{"canceled", context.Canceled, false},
+		{"syntax error", &goch.Error{Code: 62, Message: "Syntax error"}, false},
+		{"access denied", &goch.Error{Code: 497, Message: "Not enough privileges"}, false},
+		{"unknown table", fmt.Errorf("wrapped: %w", &goch.Error{Code: 60, Message: "Table doesn't exist"}), false},
+		{"network error", &goch.Error{Code: 210, Message: "Connection refused"}, true},
+		{"no zookeeper", &goch.Error{Code: 225, Message: "Cannot use ZooKeeper table because ZooKeeper is not configured"}, false},
+		{"table is read only", &goch.Error{Code: 242, Message: "Table is in readonly mode"}, false},
+		{"keeper error", &goch.Error{Code: 999, Message: "Coordination::Exception: No node"}, false},
+		{"wrapped keeper error", fmt.Errorf("wrapped: %w", &goch.Error{Code: 999, Message: "Coordination::Exception: Session expired"}), false},
+		{"plain error", fmt.Errorf("something went wrong"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, IsConnectionError(tt.err))
+		})
+	}
+}
+
+func Test_ExecAll_SQLErrorIsNotRetried(t *testing.T) {
+	fake := &fakeClickHouse{
+		handler: func(w http.ResponseWriter, query string) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprint(w, "Code: 62. DB::Exception: Syntax error: failed at position 1: BROKEN. (SYNTAX_ERROR) (version 23.8.1.1)\n")
+		},
+	}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	waits := fakeRetryWait(t, nil)
+	opts := NewQueryOptions()
+	opts.Tries = 5
+	err := newTestCluster(t, server.Listener.Addr().(*net.TCPAddr).Port).ExecAll(context.Background(), []string{"BROKEN SQL"}, opts)
+
+	require.Error(t, err)
+	require.False(t, IsConnectionError(err))
+	require.Equal(t, int32(1), atomic.LoadInt32(&fake.queries))
+	require.Empty(t, *waits)
+}
+
+func Test_QueryAny_ConnectionRefusedIsRetried(t *testing.T) {
+	port := closedPort(t)
+	fake := &fakeClickHouse{
+		handler: func(w http.ResponseWriter, query string) {
+			_, _ = fmt.Fprint(w, "x\nUInt8\n1\n")
+		},
+	}
+
+	// ClickHouse comes up after the first attempt has failed
+	server := httptest.NewUnstartedServer(fake)
+	defer server.Close()
+	waits := fakeRetryWait(t, func() {
+		if server.URL != "" {
+			return
+		}
+		listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+		require.NoError(t, err)
+		server.Listener = listener
+		server.Start()
+	})
+
+	opts := NewQueryOptions()
+	opts.Tries = 3
+	query, err := newTestCluster(t, port).QueryAny(context.Background(), "SELECT 1 AS x", opts)
+
+	require.NoError(t, err)
+	require.NotNil(t, query)
+	defer query.Close()
+	require.Equal(t, []time.Duration{5 * time.Second}, *waits)
+	require.Equal(t, int32(1), atomic.LoadInt32(&fake.queries))
+	columns, err := query.Rows.Columns()
+	require.NoError(t, err)
+	require.Equal(t, []string{"x"}, columns)
+}
+
+func Test_ExecAll_ConnectionRefusedIsRetriedWithBackoff(t *testing.T) {
+	waits := fakeRetryWait(t, nil)
+	opts := NewQueryOptions()
+	opts.Tries = 3
+	err := newTestCluster(t, closedPort(t)).ExecAll(context.Background(), []string{"SELECT 1"}, opts)
+
+	require.True(t, IsConnectionError(err))
+	require.Equal(t, []time.Duration{5 * time.Second, 10 * time.Second}, *waits)
+}
+
+func Test_IsAuthenticationError(t *testing.T) {
+	require.False(t, IsAuthenticationError(nil))
+	require.False(t, IsAuthenticationError(&goch.Error{Code: 62, Message: "Syntax error"}))
+	require.True(t, IsAuthenticationError(&goch.Error{Code: 516, Message: "Authentication failed"}))
+	require.True(t, IsAuthenticationError(&ConnectionError{Err: &goch.Error{Code: 193, Message: "Wrong password"}}))
+}
+
+func Test_QueryAny_FallbackOnRejectedCredentials(t *testing.T) {
+	// ClickHouse has not picked up rotated password yet
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if _, password, _ := req.BasicAuth(); password != "old" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = fmt.Fprint(w, "Code: 516. DB::Exception: operator: Authentication failed: password is incorrect. (AUTHENTICATION_FAILED) (version 23.8.1.1)\n")
+			return
+		}
+		(&fakeClickHouse{
+			handler: func(w http.ResponseWriter, query string) {
+				_, _ = fmt.Fprint(w, "x\nUInt8\n1\n")
+			},
+		}).ServeHTTP(w, req)
+	}))
+	defer server.Close()
+	port := server.Listener.Addr().(*net.TCPAddr).Port
+
+	cluster := NewCluster().SetHosts([]string{"127.0.0.1"})
+	cluster.ClusterConnectionParams = NewClusterConnectionParams("http", "operator", "new", "", port)
+	_, err := cluster.QueryAny(context.Background(), "SELECT 1 AS x")
+	require.True(t, IsAuthenticationError(err))
+
+	cluster.ClusterConnectionParams = NewClusterConnectionParams("http", "operator", "new", "", port).
+		SetFallbackCredentials("operator", "old")
+	query, err := cluster.QueryAny(context.Background(), "SELECT 1 AS x")
+	require.NoError(t, err)
+	require.NotNil(t, query)
+	defer query.Close()
+	value, err := query.String()
+	require.NoError(t, err)
+	require.Equal(t, "1", value)
+}
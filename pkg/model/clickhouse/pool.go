@@ -58,13 +58,30 @@ func GetPooledDBConnection(params *EndpointConnectionParams) *Connection {
 	return nil
 }
 
+// dropPooledConnections deletes connections with matching params from the pool and closes them
+func dropPooledConnections(match func(params *EndpointConnectionParams) bool) {
+	dbConnectionPool.Range(func(key, value interface{}) bool {
+		connection := value.(*Connection)
+		if match(connection.Params()) {
+			log.V(2).F().Info("Drop connection from the pool: %s", connection.Params().GetDSNWithHiddenCredentials())
+			dbConnectionPool.Delete(key)
+			connection.close()
+		}
+		return true
+	})
+}
+
 // DropHost deletes host from the pool
 // TODO we need to be able to remove entries from the pool
 func DropHost(host string) {
 
 }
 
-// makePoolKey makes key out of connection params to be used by the pool
+// makePoolKey makes key out of connection params to be used by the pool.
+// Connections with different fallback credentials are pooled separately, so fallback is dropped along with them
 func makePoolKey(params *EndpointConnectionParams) string {
+	if fallback := params.GetFallback(); fallback != nil {
+		return params.GetDSN() + " " + fallback.GetDSN()
+	}
 	return params.GetDSN()
 }
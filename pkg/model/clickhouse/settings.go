@@ -0,0 +1,40 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clickhouse
+
+import (
+	"context"
+
+	goch "github.com/mailru/go-clickhouse/v2"
+)
+
+// NewReadonlySettings creates ClickHouse settings for queries provided by users, which are run by the operator.
+// readonly=1 allows reads only and forbids to change settings, so it can not be lifted by the query itself.
+// Table functions, such as remote() or url(), are forbidden in readonly mode as well, so the query
+// can not reach other ClickHouse instances or external endpoints on behalf of the operator
+func NewReadonlySettings() map[string]string {
+	return map[string]string{
+		"readonly":  "1",
+		"allow_ddl": "0",
+	}
+}
+
+// WithSettings makes queries run on behalf of the context to be run with specified ClickHouse settings
+func WithSettings(ctx context.Context, settings map[string]string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, goch.RequestQueryParams, settings)
+}
@@ -0,0 +1,44 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WithSettings(t *testing.T) {
+	var mu sync.Mutex
+	var params []url.Values
+	fake := &fakeClickHouse{
+		handler: func(w http.ResponseWriter, query string) {
+			_, _ = fmt.Fprint(w, "value\nUInt8\n1\n")
+		},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		params = append(params, req.URL.Query())
+		mu.Unlock()
+		fake.ServeHTTP(w, req)
+	}))
+	defer server.Close()
+	cluster := newTestCluster(t, server.Listener.Addr().(*net.TCPAddr).Port)
+
+	ctx := WithSettings(context.Background(), NewReadonlySettings())
+	result, err := cluster.QueryAny(ctx, "SELECT 1")
+	require.NoError(t, err)
+	result.Close()
+
+	// Query is run in readonly mode
+	mu.Lock()
+	defer mu.Unlock()
+	last := params[len(params)-1]
+	require.Equal(t, "1", last.Get("readonly"))
+	require.Equal(t, "0", last.Get("allow_ddl"))
+}
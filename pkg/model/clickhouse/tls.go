@@ -0,0 +1,118 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clickhouse
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	goch "github.com/mailru/go-clickhouse/v2"
+)
+
+// registeredTLS keeps names of TLS configs registered with the driver
+var registeredTLS = sync.Map{}
+
+// TLS specifies TLS material connections are established with
+type TLS struct {
+	// CA specifies PEM-encoded CA certificates server certificates are verified with
+	CA []byte
+	// Cert specifies PEM-encoded client certificate
+	Cert []byte
+	// Key specifies PEM-encoded private key of the client certificate
+	Key []byte
+	// InsecureSkipVerify specifies whether server certificates are not verified
+	InsecureSkipVerify bool
+}
+
+// NewTLS creates new TLS
+func NewTLS(ca, cert, key []byte, insecureSkipVerify bool) *TLS {
+	return &TLS{
+		CA:                 ca,
+		Cert:               cert,
+		Key:                key,
+		InsecureSkipVerify: insecureSkipVerify,
+	}
+}
+
+// Name makes name TLS config is registered with. Name is derived from the material,
+// so connections with the same material share TLS config, while connections with rotated material do not
+func (t *TLS) Name() string {
+	if t == nil {
+		return tlsSettings
+	}
+	hash := sha256.New()
+	for _, data := range [][]byte{t.CA, t.Cert, t.Key} {
+		hash.Write(data)
+		// Separator, so material is not mixed up
+		hash.Write([]byte{0})
+	}
+	if t.InsecureSkipVerify {
+		hash.Write([]byte{1})
+	}
+	return tlsSettings + "-" + hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// Register registers TLS config built out of the material with the driver.
+// TLS config is registered once, connections with the same material share it.
+func (t *TLS) Register() error {
+	if t == nil {
+		return nil
+	}
+	name := t.Name()
+	if _, registered := registeredTLS.Load(name); registered {
+		return nil
+	}
+
+	config := &tls.Config{
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+	if len(t.CA) > 0 {
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(t.CA) {
+			return fmt.Errorf("unable to parse CA certificates")
+		}
+	}
+	if (len(t.Cert) > 0) || (len(t.Key) > 0) {
+		cert, err := tls.X509KeyPair(t.Cert, t.Key)
+		if err != nil {
+			return fmt.Errorf("unable to parse client certificate err: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if err := goch.RegisterTLSConfig(name, config); err != nil {
+		return err
+	}
+	registeredTLS.Store(name, true)
+	return nil
+}
+
+// Drop deregisters TLS config of the material and closes pooled connections established with it,
+// so material being rotated is not kept
+func (t *TLS) Drop() {
+	if t == nil {
+		return
+	}
+	name := t.Name()
+	dropPooledConnections(func(params *EndpointConnectionParams) bool {
+		return (params.tls != nil) && (params.tls.Name() == name)
+	})
+	goch.DeregisterTLSConfig(name)
+	registeredTLS.Delete(name)
+}
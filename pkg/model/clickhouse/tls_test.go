@@ -0,0 +1,94 @@
+package clickhouse
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TLSName(t *testing.T) {
+	ca := []byte("ca")
+	require.Equal(t, tlsSettings, (*TLS)(nil).Name())
+	require.Equal(t, NewTLS(ca, nil, nil, false).Name(), NewTLS(ca, nil, nil, false).Name())
+	require.NotEqual(t, NewTLS(ca, nil, nil, false).Name(), NewTLS([]byte("rotated"), nil, nil, false).Name())
+	require.NotEqual(t, NewTLS(ca, nil, nil, false).Name(), NewTLS(ca, nil, nil, true).Name())
+	require.NotEqual(t, NewTLS([]byte("a"), []byte("b"), nil, false).Name(), NewTLS(nil, []byte("ab"), nil, false).Name())
+}
+
+func Test_ExecAll_TLS(t *testing.T) {
+	fake := &fakeClickHouse{
+		handler: func(w http.ResponseWriter, query string) {
+			_, _ = fmt.Fprint(w, "")
+		},
+	}
+	server := httptest.NewTLSServer(fake)
+	defer server.Close()
+	port := server.Listener.Addr().(*net.TCPAddr).Port
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	newCluster := func(tls *TLS) *Cluster {
+		cluster := NewCluster().SetHosts([]string{"127.0.0.1"})
+		cluster.ClusterConnectionParams = NewClusterConnectionParams("https", "", "", "", port).SetTLS(tls)
+		return cluster
+	}
+	opts := NewQueryOptions()
+	opts.Tries = 1
+
+	// Server certificate is verified with the CA
+	require.NoError(t, newCluster(NewTLS(ca, nil, nil, false)).ExecAll(context.Background(), []string{"SELECT 1"}, opts))
+	// Server certificate is not trusted by system CAs
+	require.Error(t, newCluster(NewTLS(nil, nil, nil, false)).ExecAll(context.Background(), []string{"SELECT 1"}, opts))
+	// Malformed CA is reported
+	require.Error(t, NewTLS([]byte("malformed"), nil, nil, false).Register())
+}
+
+func Test_TLSDrop(t *testing.T) {
+	fake := &fakeClickHouse{
+		handler: func(w http.ResponseWriter, query string) {
+			_, _ = fmt.Fprint(w, "")
+		},
+	}
+	server := httptest.NewTLSServer(fake)
+	defer server.Close()
+	port := server.Listener.Addr().(*net.TCPAddr).Port
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	tls := NewTLS(ca, nil, nil, false)
+
+	cluster := NewCluster().SetHosts([]string{"127.0.0.1"})
+	cluster.ClusterConnectionParams = NewClusterConnectionParams("https", "", "", "", port).SetTLS(tls)
+	opts := NewQueryOptions()
+	opts.Tries = 1
+	pooled := func() (connections int) {
+		dbConnectionPool.Range(func(key, value interface{}) bool {
+			if value.(*Connection).Params().tls.Name() == tls.Name() {
+				connections++
+			}
+			return true
+		})
+		return connections
+	}
+	// Test servers share certificate, so material may already be in use by other tests
+	tls.Drop()
+
+	require.NoError(t, cluster.ExecAll(context.Background(), []string{"SELECT 1"}, opts))
+	require.Equal(t, 1, pooled())
+	_, registered := registeredTLS.Load(tls.Name())
+	require.True(t, registered)
+
+	// Rotated material leaves neither TLS config nor connections behind
+	tls.Drop()
+	require.Zero(t, pooled())
+	_, registered = registeredTLS.Load(tls.Name())
+	require.False(t, registered)
+
+	// Dropped material is registered again on connect
+	require.NoError(t, cluster.ExecAll(context.Background(), []string{"SELECT 1"}, opts))
+	require.Equal(t, 1, pooled())
+	tls.Drop()
+}
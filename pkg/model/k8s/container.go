@@ -15,7 +15,10 @@
 package k8s
 
 import (
+	"time"
+
 	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 
 	api "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
 )
@@ -73,6 +76,55 @@ func ContainerAppendVolumeMount(container *core.Container, volumeMount core.Volu
 	container.VolumeMounts = append(container.VolumeMounts, volumeMount)
 }
 
+// ContainerAppendEnvVars appends env vars to the specified container.
+// Env vars already specified in the container prevail over the appended ones with the same name.
+func ContainerAppendEnvVars(container *core.Container, envVars ...core.EnvVar) {
+	if container == nil {
+		return
+	}
+
+	for _, envVar := range envVars {
+		if (envVar.Name == "") || ContainerHasEnvVar(container, envVar.Name) {
+			continue
+		}
+		container.Env = append(container.Env, envVar)
+	}
+}
+
+// ContainerHasEnvVar checks whether the specified container has env var with specified name
+func ContainerHasEnvVar(container *core.Container, name string) bool {
+	for i := range container.Env {
+		if container.Env[i].Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainerAppendEnvFrom appends env sources to the specified container, skipping the ones already specified
+func ContainerAppendEnvFrom(container *core.Container, envFromSources ...core.EnvFromSource) {
+	if container == nil {
+		return
+	}
+
+	for _, envFrom := range envFromSources {
+		if containerHasEnvFrom(container, envFrom) {
+			continue
+		}
+		container.EnvFrom = append(container.EnvFrom, envFrom)
+	}
+}
+
+// containerHasEnvFrom checks whether the specified container has the specified env source
+func containerHasEnvFrom(container *core.Container, envFrom core.EnvFromSource) bool {
+	for i := range container.EnvFrom {
+		if equality.Semantic.DeepEqual(container.EnvFrom[i], envFrom) {
+			return true
+		}
+	}
+	return false
+}
+
 // ContainerEnsurePortByName
 func ContainerEnsurePortByName(container *core.Container, name string, port int32) {
 	if api.IsPortUnassigned(port) {
@@ -99,3 +151,34 @@ func ContainerEnsurePortByName(container *core.Container, name string, port int3
 		ContainerPort: port,
 	})
 }
+
+// k8s defaults applied to probes, which do not specify own period and failure threshold
+const (
+	defaultProbePeriodSeconds    = 10
+	defaultProbeFailureThreshold = 3
+)
+
+// ProbeDuration returns how long probe is allowed to fail before k8s gives up on the container
+func ProbeDuration(probe *core.Probe) time.Duration {
+	if probe == nil {
+		return 0
+	}
+	period := probe.PeriodSeconds
+	if period == 0 {
+		period = defaultProbePeriodSeconds
+	}
+	threshold := probe.FailureThreshold
+	if threshold == 0 {
+		threshold = defaultProbeFailureThreshold
+	}
+	return time.Duration(probe.InitialDelaySeconds+period*threshold) * time.Second
+}
+
+// ContainerReadyDuration returns how long container is allowed to take to become ready as its probes go.
+// Readiness probe starts to be checked only after startup probe succeeds.
+func ContainerReadyDuration(container *core.Container) time.Duration {
+	if container == nil {
+		return 0
+	}
+	return ProbeDuration(container.StartupProbe) + ProbeDuration(container.ReadinessProbe)
+}
@@ -0,0 +1,58 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"fmt"
+
+	core "k8s.io/api/core/v1"
+)
+
+// podStuckWaitingReasons lists reasons of waiting containers, which do not resolve without intervention
+var podStuckWaitingReasons = map[string]bool{
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"CrashLoopBackOff":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+	"RunContainerError":          true,
+}
+
+// PodGetStuckReason explains why pod does not become ready, ex.: "Unschedulable: 0/3 nodes are available..."
+// Empty string means nothing suspicious found, pod may be just starting.
+func PodGetStuckReason(pod *core.Pod) string {
+	if pod == nil {
+		return ""
+	}
+	for _, condition := range pod.Status.Conditions {
+		if (condition.Type == core.PodScheduled) &&
+			(condition.Status == core.ConditionFalse) &&
+			(condition.Reason == core.PodReasonUnschedulable) {
+			return fmt.Sprintf("%s: %s", condition.Reason, condition.Message)
+		}
+	}
+	statuses := append(append([]core.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if (status.State.Waiting == nil) || !podStuckWaitingReasons[status.State.Waiting.Reason] {
+			continue
+		}
+		if status.State.Waiting.Message == "" {
+			return fmt.Sprintf("container %s: %s", status.Name, status.State.Waiting.Reason)
+		}
+		return fmt.Sprintf("container %s: %s: %s", status.Name, status.State.Waiting.Reason, status.State.Waiting.Message)
+	}
+	return ""
+}
@@ -32,3 +32,101 @@ func ServiceSpecVerifyPorts(spec *core.ServiceSpec) error {
 	}
 	return nil
 }
+
+// ServiceIPFamiliesChanged checks whether target service requests IP families setup,
+// which differs from the one current service has. IP families can not be changed in-place and
+// such a service has to be re-created.
+// Only explicitly specified target values are considered, since unspecified values are defaulted by the cluster.
+func ServiceIPFamiliesChanged(curService, targetService *core.Service) bool {
+	if (curService == nil) || (targetService == nil) {
+		return false
+	}
+
+	if targetService.Spec.IPFamilyPolicy != nil {
+		if curService.Spec.IPFamilyPolicy == nil {
+			return true
+		}
+		if *curService.Spec.IPFamilyPolicy != *targetService.Spec.IPFamilyPolicy {
+			return true
+		}
+	}
+
+	if len(targetService.Spec.IPFamilies) > 0 {
+		if len(curService.Spec.IPFamilies) != len(targetService.Spec.IPFamilies) {
+			return true
+		}
+		for i := range targetService.Spec.IPFamilies {
+			if curService.Spec.IPFamilies[i] != targetService.Spec.IPFamilies[i] {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ServiceClusterIPChanged checks whether target service requests cluster IP, which differs from the one current service has,
+// ex.: headless service (clusterIP: None) is requested in place of a regular one or vice versa.
+// Cluster IP can not be changed in-place and such a service has to be re-created.
+// Only explicitly specified target value is considered, since unspecified value is assigned by the cluster.
+func ServiceClusterIPChanged(curService, targetService *core.Service) bool {
+	if (curService == nil) || (targetService == nil) {
+		return false
+	}
+
+	if targetService.Spec.ClusterIP == "" {
+		return false
+	}
+	return curService.Spec.ClusterIP != targetService.Spec.ClusterIP
+}
+
+// ServicePreserveSessionAffinity copies session affinity of current service into target service,
+// in case target service does not specify session affinity explicitly,
+// so session affinity set up on the service is not silently reset.
+func ServicePreserveSessionAffinity(curService, targetService *core.Service) {
+	if (curService == nil) || (targetService == nil) {
+		return
+	}
+
+	switch {
+	case targetService.Spec.SessionAffinity == "":
+		targetService.Spec.SessionAffinity = curService.Spec.SessionAffinity
+		targetService.Spec.SessionAffinityConfig = curService.Spec.SessionAffinityConfig.DeepCopy()
+	case targetService.Spec.SessionAffinityConfig != nil:
+		// Explicitly specified
+	case targetService.Spec.SessionAffinity == curService.Spec.SessionAffinity:
+		// Same affinity keeps its config, ex.: ClientIP timeout
+		targetService.Spec.SessionAffinityConfig = curService.Spec.SessionAffinityConfig.DeepCopy()
+	}
+}
+
+// ServicePreserveNodePorts copies already allocated node ports from current service into target service,
+// so re-created service would be exposed on the same node ports whenever possible.
+// Only ports, which do not have node port explicitly specified in the target service, are touched.
+func ServicePreserveNodePorts(curService, targetService *core.Service) {
+	if (curService == nil) || (targetService == nil) {
+		return
+	}
+
+	switch targetService.Spec.Type {
+	case core.ServiceTypeNodePort, core.ServiceTypeLoadBalancer:
+	default:
+		// Node ports are not applicable
+		return
+	}
+
+	for i := range targetService.Spec.Ports {
+		targetPort := &targetService.Spec.Ports[i]
+		if targetPort.NodePort != 0 {
+			// Explicitly specified
+			continue
+		}
+		for j := range curService.Spec.Ports {
+			curPort := &curService.Spec.Ports[j]
+			if (curPort.Port == targetPort.Port) && (curPort.Protocol == targetPort.Protocol) {
+				targetPort.NodePort = curPort.NodePort
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+)
+
+func newTestService(policy *core.IPFamilyPolicy, families ...core.IPFamily) *core.Service {
+	return &core.Service{
+		Spec: core.ServiceSpec{
+			Type:           core.ServiceTypeNodePort,
+			IPFamilyPolicy: policy,
+			IPFamilies:     families,
+			Ports: []core.ServicePort{
+				{
+					Name:     "http",
+					Protocol: core.ProtocolTCP,
+					Port:     8123,
+				},
+				{
+					Name:     "tcp",
+					Protocol: core.ProtocolTCP,
+					Port:     9000,
+				},
+			},
+		},
+	}
+}
+
+func Test_ServiceIPFamiliesChanged(t *testing.T) {
+	singleStack := core.IPFamilyPolicySingleStack
+	preferDualStack := core.IPFamilyPolicyPreferDualStack
+
+	// Current service has values defaulted by the cluster
+	cur := newTestService(&singleStack, core.IPv4Protocol)
+
+	// Nothing specified in target - nothing to change
+	require.False(t, ServiceIPFamiliesChanged(cur, newTestService(nil)))
+	// Same values specified
+	require.False(t, ServiceIPFamiliesChanged(cur, newTestService(&singleStack, core.IPv4Protocol)))
+	// Single-stack to dual-stack migration
+	require.True(t, ServiceIPFamiliesChanged(cur, newTestService(&preferDualStack)))
+	require.True(t, ServiceIPFamiliesChanged(cur, newTestService(nil, core.IPv4Protocol, core.IPv6Protocol)))
+	require.True(t, ServiceIPFamiliesChanged(cur, newTestService(nil, core.IPv6Protocol)))
+}
+
+func Test_ServicePreserveNodePorts(t *testing.T) {
+	cur := newTestService(nil)
+	cur.Spec.Ports[0].NodePort = 30123
+	cur.Spec.Ports[1].NodePort = 30900
+
+	target := newTestService(nil)
+	target.Spec.Ports[1].NodePort = 31900
+	ServicePreserveNodePorts(cur, target)
+
+	require.Equal(t, int32(30123), target.Spec.Ports[0].NodePort)
+	// Explicitly specified node port is kept
+	require.Equal(t, int32(31900), target.Spec.Ports[1].NodePort)
+
+	// Node ports are not applicable to ClusterIP services
+	target = newTestService(nil)
+	target.Spec.Type = core.ServiceTypeClusterIP
+	ServicePreserveNodePorts(cur, target)
+	require.Equal(t, int32(0), target.Spec.Ports[0].NodePort)
+}
+
+func Test_ServiceClusterIPChanged(t *testing.T) {
+	cur := newTestService(nil)
+	cur.Spec.ClusterIP = "10.0.0.10"
+
+	// Nothing specified in target - cluster IP is assigned by the cluster
+	require.False(t, ServiceClusterIPChanged(cur, newTestService(nil)))
+
+	// Regular service to headless service migration
+	headless := newTestService(nil)
+	headless.Spec.ClusterIP = core.ClusterIPNone
+	require.True(t, ServiceClusterIPChanged(cur, headless))
+	require.False(t, ServiceClusterIPChanged(headless, headless.DeepCopy()))
+}
+
+func Test_ServicePreserveSessionAffinity(t *testing.T) {
+	timeout := int32(600)
+	cur := newTestService(nil)
+	cur.Spec.SessionAffinity = core.ServiceAffinityClientIP
+	cur.Spec.SessionAffinityConfig = &core.SessionAffinityConfig{
+		ClientIP: &core.ClientIPConfig{TimeoutSeconds: &timeout},
+	}
+
+	// Session affinity not specified in target is preserved
+	target := newTestService(nil)
+	ServicePreserveSessionAffinity(cur, target)
+	require.Equal(t, cur.Spec.SessionAffinity, target.Spec.SessionAffinity)
+	require.Equal(t, cur.Spec.SessionAffinityConfig, target.Spec.SessionAffinityConfig)
+
+	// Same session affinity keeps its config
+	target = newTestService(nil)
+	target.Spec.SessionAffinity = core.ServiceAffinityClientIP
+	ServicePreserveSessionAffinity(cur, target)
+	require.Equal(t, cur.Spec.SessionAffinityConfig, target.Spec.SessionAffinityConfig)
+
+	// Explicitly specified session affinity is kept
+	target = newTestService(nil)
+	target.Spec.SessionAffinity = core.ServiceAffinityNone
+	ServicePreserveSessionAffinity(cur, target)
+	require.Equal(t, core.ServiceAffinityNone, target.Spec.SessionAffinity)
+	require.Nil(t, target.Spec.SessionAffinityConfig)
+}
@@ -17,6 +17,9 @@ package k8s
 import (
 	apps "k8s.io/api/apps/v1"
 	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	"github.com/altinity/clickhouse-operator/pkg/util"
 )
 
 // StatefulSetContainerGet gets container from the StatefulSet either by name or by index
@@ -82,6 +85,54 @@ func IsStatefulSetNotReady(statefulSet *apps.StatefulSet) bool {
 	return !IsStatefulSetReady(statefulSet)
 }
 
+// podSpecDNSPolicy gets DNS policy of the pod spec the way k8s defaults it
+func podSpecDNSPolicy(spec *core.PodSpec) core.DNSPolicy {
+	if spec.DNSPolicy == "" {
+		return core.DNSClusterFirst
+	}
+	return spec.DNSPolicy
+}
+
+// IsStatefulSetPodNetworkChanged checks whether pod networking - hostNetwork, dnsPolicy or dnsConfig -
+// differs between StatefulSets. Such a change affects networking fundamentally and is not a subject for rolling update.
+func IsStatefulSetPodNetworkChanged(cur, desired *apps.StatefulSet) bool {
+	if (cur == nil) || (desired == nil) {
+		return false
+	}
+
+	curSpec := &cur.Spec.Template.Spec
+	desiredSpec := &desired.Spec.Template.Spec
+	return (curSpec.HostNetwork != desiredSpec.HostNetwork) ||
+		(podSpecDNSPolicy(curSpec) != podSpecDNSPolicy(desiredSpec)) ||
+		!equality.Semantic.DeepEqual(curSpec.DNSConfig, desiredSpec.DNSConfig)
+}
+
+// StatefulSetWithoutContainersResources makes a copy of the StatefulSet with cpu and memory requests/limits
+// of the containers cleared. Init containers and other resources, such as ephemeral-storage, are kept intact,
+// since they can not be resized in-place.
+func StatefulSetWithoutContainersResources(statefulSet *apps.StatefulSet) *apps.StatefulSet {
+	if statefulSet == nil {
+		return nil
+	}
+	res := statefulSet.DeepCopy()
+	for i := range res.Spec.Template.Spec.Containers {
+		resources := &res.Spec.Template.Spec.Containers[i].Resources
+		resources.Requests = resourceListWithoutResizable(resources.Requests)
+		resources.Limits = resourceListWithoutResizable(resources.Limits)
+	}
+	return res
+}
+
+// resourceListWithoutResizable removes resources, which can be resized in-place, from the list
+func resourceListWithoutResizable(list core.ResourceList) core.ResourceList {
+	delete(list, core.ResourceCPU)
+	delete(list, core.ResourceMemory)
+	if len(list) == 0 {
+		return nil
+	}
+	return list
+}
+
 func StatefulSetHasVolumeClaimTemplateByName(statefulSet *apps.StatefulSet, name string) bool {
 	// Check whether provided VolumeClaimTemplate name is already listed in statefulSet.Spec.VolumeClaimTemplates
 	for i := range statefulSet.Spec.VolumeClaimTemplates {
@@ -129,3 +180,82 @@ func StatefulSetAppendVolumeMounts(statefulSet *apps.StatefulSet, volumeMounts .
 		)
 	}
 }
+
+// StatefulSetKeepImmutableFields copies into new StatefulSet spec fields of current StatefulSet,
+// which can not be updated in-place, ex.: selector and volume claim templates of the adopted StatefulSet.
+// Labels of current selector are applied to the pod template of new StatefulSet, so pods keep matching the selector.
+// Volume mounts of new StatefulSet are mapped onto volume claim templates of current StatefulSet mounted by the same path.
+// Returns names of volume claim templates of new StatefulSet, keyed by names of volume claim templates of current one
+// they are mapped onto.
+func StatefulSetKeepImmutableFields(curStatefulSet, newStatefulSet *apps.StatefulSet) map[string]string {
+	if (curStatefulSet == nil) || (newStatefulSet == nil) {
+		return nil
+	}
+
+	mapped := statefulSetMapVolumeClaimTemplates(curStatefulSet, newStatefulSet)
+
+	spec := curStatefulSet.Spec.DeepCopy()
+	// Fields, which are allowed to be updated
+	spec.Replicas = newStatefulSet.Spec.Replicas
+	spec.Template = newStatefulSet.Spec.Template
+	spec.UpdateStrategy = newStatefulSet.Spec.UpdateStrategy
+	spec.MinReadySeconds = newStatefulSet.Spec.MinReadySeconds
+	spec.Ordinals = newStatefulSet.Spec.Ordinals
+	spec.PersistentVolumeClaimRetentionPolicy = newStatefulSet.Spec.PersistentVolumeClaimRetentionPolicy
+	newStatefulSet.Spec = *spec
+
+	if selector := newStatefulSet.Spec.Selector; selector != nil {
+		newStatefulSet.Spec.Template.Labels = util.MergeStringMapsOverwrite(newStatefulSet.Spec.Template.Labels, selector.MatchLabels)
+	}
+
+	return mapped
+}
+
+// statefulSetMapVolumeClaimTemplates renames volume mounts of new StatefulSet, which refer to volume claim templates
+// unknown to current StatefulSet, to volume claim templates of current StatefulSet mounted by the same path.
+// Returns names of volume claim templates of new StatefulSet, keyed by names they are mapped onto.
+func statefulSetMapVolumeClaimTemplates(curStatefulSet, newStatefulSet *apps.StatefulSet) map[string]string {
+	// Mount path -> volume claim template of current StatefulSet mounted by this path
+	curPaths := make(map[string]string)
+	walkPodSpecVolumeMounts(&curStatefulSet.Spec.Template.Spec, func(volumeMount *core.VolumeMount) {
+		if StatefulSetHasVolumeClaimTemplateByName(curStatefulSet, volumeMount.Name) {
+			curPaths[volumeMount.MountPath] = volumeMount.Name
+		}
+	})
+
+	// Volume claim template of new StatefulSet -> volume claim template of current StatefulSet
+	names := make(map[string]string)
+	walkPodSpecVolumeMounts(&newStatefulSet.Spec.Template.Spec, func(volumeMount *core.VolumeMount) {
+		if !StatefulSetHasVolumeClaimTemplateByName(newStatefulSet, volumeMount.Name) ||
+			StatefulSetHasVolumeClaimTemplateByName(curStatefulSet, volumeMount.Name) {
+			// Either not a volume claim template or is known to current StatefulSet as is
+			return
+		}
+		if curName, ok := curPaths[volumeMount.MountPath]; ok {
+			names[volumeMount.Name] = curName
+		}
+	})
+	if len(names) == 0 {
+		return nil
+	}
+
+	mapped := make(map[string]string)
+	walkPodSpecVolumeMounts(&newStatefulSet.Spec.Template.Spec, func(volumeMount *core.VolumeMount) {
+		if curName, ok := names[volumeMount.Name]; ok {
+			mapped[curName] = volumeMount.Name
+			volumeMount.Name = curName
+		}
+	})
+	return mapped
+}
+
+// walkPodSpecVolumeMounts walks over volume mounts of all containers of the pod spec
+func walkPodSpecVolumeMounts(spec *core.PodSpec, f func(volumeMount *core.VolumeMount)) {
+	for _, containers := range [][]core.Container{spec.InitContainers, spec.Containers} {
+		for i := range containers {
+			for j := range containers[i].VolumeMounts {
+				f(&containers[i].VolumeMounts[j])
+			}
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+)
+
+func newTestStatefulSet(hostNetwork bool, dnsPolicy core.DNSPolicy, dnsConfig *core.PodDNSConfig) *apps.StatefulSet {
+	return &apps.StatefulSet{
+		Spec: apps.StatefulSetSpec{
+			Template: core.PodTemplateSpec{
+				Spec: core.PodSpec{
+					HostNetwork: hostNetwork,
+					DNSPolicy:   dnsPolicy,
+					DNSConfig:   dnsConfig,
+				},
+			},
+		},
+	}
+}
+
+func Test_IsStatefulSetPodNetworkChanged(t *testing.T) {
+	dnsConfig := &core.PodDNSConfig{
+		Nameservers: []string{"10.0.0.10"},
+		Searches:    []string{"dc1.example.com"},
+	}
+
+	// Current StatefulSet has DNS policy defaulted by the cluster
+	cur := newTestStatefulSet(false, core.DNSClusterFirst, nil)
+	require.False(t, IsStatefulSetPodNetworkChanged(cur, newTestStatefulSet(false, "", nil)))
+	require.False(t, IsStatefulSetPodNetworkChanged(nil, newTestStatefulSet(true, "", nil)))
+
+	require.True(t, IsStatefulSetPodNetworkChanged(cur, newTestStatefulSet(true, core.DNSClusterFirstWithHostNet, nil)))
+	require.True(t, IsStatefulSetPodNetworkChanged(cur, newTestStatefulSet(false, core.DNSDefault, nil)))
+	require.True(t, IsStatefulSetPodNetworkChanged(cur, newTestStatefulSet(false, core.DNSNone, dnsConfig)))
+
+	cur = newTestStatefulSet(true, core.DNSNone, dnsConfig)
+	require.False(t, IsStatefulSetPodNetworkChanged(cur, newTestStatefulSet(true, core.DNSNone, dnsConfig.DeepCopy())))
+	changed := dnsConfig.DeepCopy()
+	changed.Nameservers = []string{"10.0.0.11"}
+	require.True(t, IsStatefulSetPodNetworkChanged(cur, newTestStatefulSet(true, core.DNSNone, changed)))
+}
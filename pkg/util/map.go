@@ -217,6 +217,19 @@ func MapHasKeys(m map[string]string, keys ...string) bool {
 	return true
 }
 
+// MapsAreTheSame checks whether two maps have the same keys with the same values
+func MapsAreTheSame(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if v, ok := b[key]; !ok || (v != value) {
+			return false
+		}
+	}
+	return true
+}
+
 // Map2String returns named map[string]string mas as a string
 func Map2String(name string, m map[string]string) string {
 	// Write map entries according to sorted keys
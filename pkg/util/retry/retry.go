@@ -16,12 +16,40 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	log "github.com/altinity/clickhouse-operator/pkg/announcer"
 	"github.com/altinity/clickhouse-operator/pkg/util"
 )
 
+// abortError specifies error which is not worth retrying
+type abortError struct {
+	err error
+}
+
+// Error implements error interface
+func (e *abortError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns underlying error
+func (e *abortError) Unwrap() error {
+	return e.err
+}
+
+// Abort wraps specified error to make Retry stop retrying and return the error immediately
+func Abort(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &abortError{err: err}
+}
+
+// Wait waits between attempts either for ctx to be done or specified timeout.
+// Is replaced in tests to not actually sleep
+var Wait = util.WaitContextDoneOrTimeout
+
 // Retry retries specified function
 func Retry(ctx context.Context, tries int, desc string, a log.Announcer, f func() error) error {
 	var err error
@@ -42,11 +70,18 @@ func Retry(ctx context.Context, tries int, desc string, a log.Announcer, f func(
 			return nil
 		}
 
+		var abort *abortError
+		if errors.As(err, &abort) {
+			// Error is not worth retrying
+			a.Warning("FAILED attempt %d of %d, abort due to non-retryable error: %s err: %v", try, tries, desc, abort.err)
+			return abort.err
+		}
+
 		if try < tries {
 			// Try failed, need to sleep and retry
 			seconds := try * 5
 			a.Info("FAILED attempt %d of %d, sleep %d sec and retry: %s", try, tries, seconds, desc)
-			util.WaitContextDoneOrTimeout(ctx, time.Duration(seconds)*time.Second)
+			Wait(ctx, time.Duration(seconds)*time.Second)
 		} else if tries == 1 {
 			// On single try do not put so much emotion. It just failed and user is not intended to retry
 			a.Warning("FAILED single try. No retries will be made for %s", desc)
@@ -15,6 +15,7 @@
 package xml
 
 import (
+	"encoding/xml"
 	"fmt"
 	"io"
 	"regexp"
@@ -222,3 +223,18 @@ func (n *xmlNode) writeTag(w io.Writer, indent uint8, attributes string, openTag
 func (n *xmlNode) writeValue(w io.Writer, value string) {
 	_, _ = fmt.Fprintf(w, "%s", value)
 }
+
+// tagNameRegexp matches names which are safe to be used as XML element names
+var tagNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// IsValidTagName checks whether name can be used as XML element name as is
+func IsValidTagName(name string) bool {
+	return tagNameRegexp.MatchString(name)
+}
+
+// EscapeText escapes text to be safely placed as XML element value
+func EscapeText(text string) string {
+	b := &strings.Builder{}
+	_ = xml.EscapeText(b, []byte(text))
+	return b.String()
+}